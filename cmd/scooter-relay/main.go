@@ -0,0 +1,62 @@
+// Command scooter-relay lets a Scooter on another network be reached
+// through it, e.g. your home Scooter from a laptop at work. Run it on a
+// host both can reach; Scooter connects out to it (settings.tunnel) and
+// clients connect to it as if it were the gateway.
+//
+// Usage:
+//
+//	scooter-relay -cert cert.pem -key key.pem -token SECRET [-listen :8443]
+//
+// TLS is mandatory. The token can also be set in SCOOTER_RELAY_TOKEN, which
+// keeps it out of the process list.
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mcp-scooter/scooter/internal/tunnel"
+)
+
+func main() {
+	fs := flag.NewFlagSet("scooter-relay", flag.ExitOnError)
+	listen := fs.String("listen", ":8443", "Address to listen on")
+	certFile := fs.String("cert", "", "TLS certificate file (PEM)")
+	keyFile := fs.String("key", "", "TLS key file (PEM)")
+	token := fs.String("token", os.Getenv("SCOOTER_RELAY_TOKEN"), "Secret Scooter connects with (default $SCOOTER_RELAY_TOKEN)")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+	if err := run(*listen, *certFile, *keyFile, *token); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(listen, certFile, keyFile, token string) error {
+	if certFile == "" || keyFile == "" {
+		return fmt.Errorf("-cert and -key are required: the relay only serves TLS")
+	}
+	if len(token) < 16 {
+		return fmt.Errorf("a token of at least 16 characters is required (-token or SCOOTER_RELAY_TOKEN)")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the certificate: %w", err)
+	}
+
+	ln, err := tls.Listen("tcp", listen, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"http/1.1"}, // Client traffic is passed through as is
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Scooter relay listening on %s\n", ln.Addr())
+	return tunnel.NewRelay(token).Serve(ln)
+}
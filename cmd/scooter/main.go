@@ -2,21 +2,81 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/mcp-scooter/scooter/internal/api"
+	"github.com/mcp-scooter/scooter/internal/audit"
+	"github.com/mcp-scooter/scooter/internal/demo"
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/integration"
 	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/mcp-scooter/scooter/internal/hooks"
 	"github.com/mcp-scooter/scooter/internal/logger"
+	"github.com/mcp-scooter/scooter/internal/notify"
+	"github.com/mcp-scooter/scooter/internal/tunnel"
+	"github.com/mcp-scooter/scooter/internal/update"
+	"github.com/mcp-scooter/scooter/internal/version"
 )
 
+// strictConfig refuses to start when the configuration has errors or warnings.
+var strictConfig bool
+
+// bridgeURL, when set, runs Scooter as a stdio bridge to a gateway SSE
+// endpoint instead of starting the app. Clients that only speak stdio launch
+// it this way.
+var bridgeURL string
+
+// demoServer serves the onboarding demo tools over stdio (see internal/demo).
+var demoServer bool
+
+// ephemeral runs the daemon from a throwaway directory with in-memory
+// credentials and random loopback ports, for tests and demos.
+var ephemeral bool
+
+// registryPath loads the registry from this directory as is, instead of the
+// config directory's copy of the bundled registry.
+var registryPath string
+
 func main() {
-	if err := run(true); err != nil {
+	flag.BoolVar(&strictConfig, "strict", false, "refuse to start if profiles.yaml or settings.yaml has any validation issue")
+	flag.StringVar(&bridgeURL, "bridge", "", "run as a stdio bridge to this gateway SSE URL (API key from "+integration.BridgeAPIKeyEnv+")")
+	flag.BoolVar(&demoServer, strings.TrimPrefix(demo.ServerFlag, "--"), false, "serve the offline demo tools over stdio")
+	flag.BoolVar(&ephemeral, "ephemeral", false, "run from a temporary config directory that is deleted on exit, with in-memory credentials; prints the bound ports as a JSON line")
+	flag.StringVar(&registryPath, "registry", "", "load registry entries from this directory instead of the config directory (a copy of it with --ephemeral)")
+	flag.Parse()
+
+	if demoServer {
+		if err := demo.ServeStdio(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if bridgeURL != "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := api.RunStdioBridge(ctx, bridgeURL, os.Getenv(integration.BridgeAPIKeyEnv), os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, true); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -62,17 +122,20 @@ func getBundledAppDataDir() string {
 	return ""
 }
 
-func run(serve bool) error {
+// run starts the daemon, and with serve its servers until ctx is done or the
+// control API asks it to shut down.
+func run(ctx context.Context, serve bool) error {
 	fmt.Println("MCP Scooter - Initializing...")
 
 	// Setup profile store
-	appDir := os.Getenv("SCOOTER_CONFIG_DIR")
-	if appDir == "" {
-		configDir, err := os.UserConfigDir()
+	appDir := profile.ConfigDir()
+	if ephemeral {
+		dir, err := os.MkdirTemp("", "scooter-ephemeral-")
 		if err != nil {
-			configDir = "."
+			return fmt.Errorf("failed to create ephemeral dir: %w", err)
 		}
-		appDir = filepath.Join(configDir, "mcp-scooter")
+		defer os.RemoveAll(dir)
+		appDir = dir
 	}
 
 	if err := os.MkdirAll(appDir, 0755); err != nil {
@@ -85,12 +148,34 @@ func run(serve bool) error {
 	}
 	defer logger.Close()
 
+	// Typed event history for the desktop app, kept across restarts
+	if err := events.Default.Open(filepath.Join(appDir, "events.jsonl")); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("Event journal will not be persisted: %v", err))
+	}
+	defer events.Default.Close()
+
+	// Append-only record of tool calls, separate from the debug log
+	if err := audit.Default.Open(filepath.Join(appDir, "audit.jsonl")); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("Tool calls will not be audited: %v", err))
+	}
+	defer audit.Default.Close()
+
 	wasmDir := filepath.Join(appDir, "wasm")
 	os.MkdirAll(wasmDir, 0755)
 
 	registryDir := filepath.Join(appDir, "registry")
-	os.MkdirAll(filepath.Join(registryDir, "official"), 0755)
-	os.MkdirAll(filepath.Join(registryDir, "custom"), 0755)
+	if registryPath != "" && ephemeral {
+		// Registering, probing and overriding tools write to the registry,
+		// and an ephemeral run never changes anything outside its directory
+		if err := copyDir(registryPath, registryDir); err != nil {
+			return fmt.Errorf("failed to copy registry: %w", err)
+		}
+	} else if registryPath != "" {
+		registryDir = registryPath
+	} else {
+		os.MkdirAll(filepath.Join(registryDir, "official"), 0755)
+		os.MkdirAll(filepath.Join(registryDir, "custom"), 0755)
+	}
 
 	clientsDir := filepath.Join(appDir, "clients")
 	os.MkdirAll(clientsDir, 0755)
@@ -101,7 +186,7 @@ func run(serve bool) error {
 	// Copy official registry files from bundled resources if they are different or missing
 	// Try multiple source locations for registry files
 	registrySources := []string{}
-	if bundledAppData != "" {
+	if bundledAppData != "" && registryPath == "" {
 		registrySources = append(registrySources, filepath.Join(bundledAppData, "registry", "official"))
 	}
 	
@@ -146,7 +231,7 @@ func run(serve bool) error {
 		}
 	}
 	
-	if !registryFilesFound {
+	if !registryFilesFound && registryPath == "" {
 		fmt.Println("Warning: No bundled registry files found. Tools catalog will be empty.")
 	}
 
@@ -201,62 +286,282 @@ func run(serve bool) error {
 		filepath.Join(appDir, "profiles.yaml"),
 		filepath.Join(appDir, "settings.yaml"),
 	)
+	// CI can configure a fresh install through the environment instead of YAML
+	if seeded, err := store.Bootstrap(os.Environ()); err != nil {
+		return fmt.Errorf("failed to bootstrap config from environment: %w", err)
+	} else if seeded {
+		fmt.Println("Wrote initial configuration from SCOOTER_* environment variables")
+	}
+
+	if strictConfig {
+		known := map[string]bool{}
+		engine := discovery.NewDiscoveryEngine(context.Background(), wasmDir, registryDir)
+		for _, td := range engine.Find("") {
+			known[td.Name] = true
+		}
+		engine.Stop()
+		if err := store.Validate(known).Err(true); err != nil {
+			return err
+		}
+	}
+
 	profiles, settings, err := store.Load()
 	if err != nil {
+		// Prefer actionable diagnostics over the raw YAML error
+		if verr := store.Validate(nil).Err(false); verr != nil {
+			return verr
+		}
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
 	// Initialize Logger Verbosity from settings
 	logger.SetVerbose(settings.VerboseLogging)
+	logger.SetConsoleLevel(settings.ConsoleLogLevel)
 
 	onboardingRequired := len(profiles) == 0
 
 	// Initialize Profile Manager
 	manager := api.NewProfileManager(profiles, wasmDir, registryDir, clientsDir)
+	manager.SetWorkspaceRoot(filepath.Join(appDir, "workspaces"))
+	manager.SetHomeRoot(filepath.Join(appDir, "homes"))
+	manager.SetPackageDir(filepath.Join(appDir, "packages"))
+	manager.SetActivationMetrics(discovery.NewActivationMetrics(filepath.Join(appDir, "activation-metrics.json")))
+	if ephemeral {
+		manager.SetCredentialManager(integration.NewMemoryCredentialManager())
+	}
+	manager.SetInstanceID(settings.InstanceID)
 
 	logger.AddLog("INFO", "=== MCP Scooter Backend Starting ===")
+	logger.AddLog("INFO", fmt.Sprintf("Version: %s (commit %s, built %s)", version.Version, version.Commit, version.BuildDate))
 	logger.AddLog("INFO", fmt.Sprintf("App Directory: %s", appDir))
 	logger.AddLog("INFO", fmt.Sprintf("McpPort: %d, ControlPort: %d", settings.McpPort, settings.ControlPort))
 
+	// Shared by both servers so settings changes are visible everywhere
+	settingsProvider := profile.NewSettingsProvider(settings)
+
 	// Initialize Control Server (Management API)
-	controlServer := api.NewControlServer(store, manager, &settings, onboardingRequired)
+	controlServer := api.NewControlServer(store, manager, settingsProvider, onboardingRequired)
 
 	// Initialize MCP Gateway (Traffic Proxy)
-	mcpGateway := api.NewMcpGateway(manager, &settings)
+	mcpGateway := api.NewMcpGateway(manager, settingsProvider)
+	controlServer.SetGateway(mcpGateway)
 
 	if !serve {
 		return nil
 	}
 
-	fmt.Printf("Starting MCP Gateway on :%d...\n", settings.McpPort)
+	ctx, shutdown := context.WithCancel(ctx)
+	defer shutdown()
+	controlServer.SetShutdown(shutdown)
+
+	// Scooter Desktop reads this run's internal token from the config dir
+	internalToken, err := api.WriteInternalToken(appDir)
+	if err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("Failed to write the internal token; the desktop tool tester won't work: %v", err))
+	} else {
+		mcpGateway.SetInternalToken(internalToken)
+		controlServer.SetInternalToken(internalToken)
+		defer api.RemoveInternalToken(appDir)
+	}
+
+	// Profile hooks, before anything can activate a server; they only run
+	// when enabled in the settings
+	hookRunner := hooks.NewRunner(settingsProvider, manager.GetProfile)
+	manager.SetHooks(hookRunner)
+	hooksCtx, stopHooks := context.WithCancel(context.Background())
+	defer stopHooks()
+	go hookRunner.Watch(hooksCtx, events.Default)
+
+	// Control tokens with a user get that user their own profiles,
+	// credentials and engines, under users/<name>
+	users := api.NewUsers(filepath.Join(appDir, "users"), manager, store, settingsProvider)
+	defer users.Close()
+	controlServer.SetUsers(users)
+	mcpGateway.SetUsers(users)
+
+	// Remove artifacts of deleted profiles and registry entries once they
+	// outlive the retention period
+	gcCtx, stopGC := context.WithCancel(context.Background())
+	defer stopGC()
+	go manager.RunArtifactGC(gcCtx, settingsProvider)
+
+	// Expose a profile through a relay, when configured
+	if cfg := settings.Tunnel; cfg.Enabled {
+		client, err := tunnel.NewClient(tunnel.Config{
+			RelayURL:    cfg.RelayURL,
+			Token:       cfg.Token,
+			CAFile:      cfg.CAFile,
+			Connections: cfg.Connections,
+			Profile:     cfg.Profile,
+		}, mcpGateway.TunnelHandler(cfg.Profile))
+		if err != nil {
+			logger.AddLog("ERROR", fmt.Sprintf("Tunnel disabled: %v", err))
+		} else {
+			controlServer.SetTunnel(client)
+			tunnelCtx, stopTunnel := context.WithCancel(context.Background())
+			defer stopTunnel()
+			go client.Run(tunnelCtx)
+		}
+	}
+
+	// Ephemeral runs take any free loopback port so they never clash
+	gatewayAddr := fmt.Sprintf(":%d", settings.McpPort)
+	controlAddr := fmt.Sprintf(":%d", settings.ControlPort)
+	if ephemeral {
+		gatewayAddr, controlAddr = "127.0.0.1:0", "127.0.0.1:0"
+	}
+
+	fmt.Printf("Starting MCP Gateway on %s...\n", gatewayAddr)
+	gatewayServer := api.NewHTTPServer(gatewayAddr, mcpGateway, settings.HTTPServer)
+	gatewayListener, err := api.Listen(gatewayServer, settings.HTTPServer)
+	if err != nil {
+		return fmt.Errorf("MCP Gateway failed: %w", err)
+	}
 	go func() {
-		if err := http.ListenAndServe(fmt.Sprintf(":%d", settings.McpPort), mcpGateway); err != nil {
+		if err := api.Serve(gatewayServer, gatewayListener, settings.HTTPServer); err != nil && err != http.ErrServerClosed {
 			fmt.Printf("MCP Gateway failed: %v\n", err)
 		}
 	}()
 
-	fmt.Printf("Starting control server on :%d...\n", settings.ControlPort)
-	server := &http.Server{Addr: fmt.Sprintf(":%d", settings.ControlPort), Handler: controlServer}
-	
+	// The control API stays on plain HTTP for the desktop app
+	fmt.Printf("Starting control server on %s...\n", controlAddr)
+	controlSettings := settings.HTTPServer
+	controlSettings.TLSCertFile, controlSettings.TLSKeyFile = "", ""
+	server := api.NewHTTPServer(controlAddr, controlServer, controlSettings)
+	controlListener, err := api.Listen(server, controlSettings)
+	if err != nil {
+		return fmt.Errorf("control server failed: %w", err)
+	}
+
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := api.Serve(server, controlListener, controlSettings); err != nil && err != http.ErrServerClosed {
 			fmt.Printf("control server failed: %v\n", err)
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	if ephemeral {
+		// The gateway advertises its own port in SSE endpoint events
+		running := settingsProvider.Get()
+		running.McpPort = gatewayListener.Addr().(*net.TCPAddr).Port
+		running.ControlPort = controlListener.Addr().(*net.TCPAddr).Port
+		settingsProvider.Set(running)
+
+		// One JSON line for test harnesses to wait for
+		ready, _ := json.Marshal(map[string]interface{}{
+			"event":        "ready",
+			"mcp_port":     running.McpPort,
+			"control_port": running.ControlPort,
+			"config_dir":   appDir,
+		})
+		fmt.Println(string(ready))
+	}
+
+	if settings.AutoUpdate {
+		go runAutoUpdate()
+	}
+
+	// Desktop notifications for critical events, when enabled in the settings
+	notifyCtx, stopNotify := context.WithCancel(context.Background())
+	defer stopNotify()
+	go notify.NewDispatcher(events.Default, settingsProvider, notify.Native()).Run(notifyCtx)
 
-	<-stop
+	// SIGHUP reloads the configuration without dropping connected clients
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for range hup {
+			logger.AddLog("INFO", "SIGHUP received, reloading configuration")
+			if _, err := controlServer.Reload(); err != nil {
+				logger.AddLog("ERROR", fmt.Sprintf("Configuration reload failed: %v", err))
+			}
+		}
+	}()
+
+	// Wait for an interrupt or /api/shutdown to gracefully shutdown, so the
+	// deferred clean-up still runs
+	<-ctx.Done()
 	fmt.Println("\nShutting down gracefully...")
+	mcpGateway.Shutdown()
 	
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	
-	if err := server.Shutdown(ctx); err != nil {
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		fmt.Printf("Server shutdown failed: %v\n", err)
 	}
+	if err := gatewayServer.Shutdown(shutdownCtx); err != nil {
+		fmt.Printf("Gateway shutdown failed: %v\n", err)
+	}
 
 	return nil
 }
+
+// copyDir copies the files under src to dst, creating directories as needed.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+}
+
+// autoUpdateInterval is how often the daemon looks for a new release when AutoUpdate is enabled.
+const autoUpdateInterval = 24 * time.Hour
+
+// runAutoUpdate periodically installs newer releases over the daemon binary.
+// The running process keeps its current code; updates take effect on the next start.
+func runAutoUpdate() {
+	if version.Version == "dev" {
+		logger.AddLog("INFO", "Auto-update disabled for development builds")
+		return
+	}
+	if !update.Enabled() {
+		logger.AddLog("WARN", "Auto-update disabled: this build has no release key to verify updates with")
+		return
+	}
+
+	u, err := update.New(update.DaemonBinary)
+	if err != nil {
+		logger.AddLog("ERROR", fmt.Sprintf("Auto-update unavailable: %v", err))
+		return
+	}
+
+	installed := ""
+	ticker := time.NewTicker(autoUpdateInterval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		release, newer, err := u.Check(ctx)
+		switch {
+		case err != nil:
+			logger.AddLog("WARN", fmt.Sprintf("Auto-update check failed: %v", err))
+		case newer && release.Version() != installed:
+			logger.AddLog("INFO", fmt.Sprintf("Installing update %s -> %s", version.Version, release.Version()))
+			if err := u.Update(ctx, release); err != nil {
+				logger.AddLog("ERROR", fmt.Sprintf("Auto-update failed: %v", err))
+			} else {
+				installed = release.Version()
+				logger.AddLog("INFO", fmt.Sprintf("Update %s installed; restart Scooter to apply it", installed))
+			}
+		}
+		cancel()
+		<-ticker.C
+	}
+}
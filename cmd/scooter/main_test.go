@@ -1,9 +1,16 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestRun(t *testing.T) {
@@ -25,7 +32,7 @@ func TestRun(t *testing.T) {
 	// Since tests run in the package directory, we might need to adjust paths.
 	
 	// For this test, we just want to see if run(false) completes without error.
-	err = run(false)
+	err = run(context.Background(), false)
 	if err != nil {
 		t.Fatalf("run(false) failed: %v", err)
 	}
@@ -46,3 +53,80 @@ func TestRun(t *testing.T) {
 		// Actually, profile.NewStore just sets the path. store.Load() reads it.
 	}
 }
+
+func TestRunEphemeral(t *testing.T) {
+	registry := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(registry, "custom"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(registry, "custom", "tool.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ephemeral, registryPath = true, registry
+	defer func() { ephemeral, registryPath = false, "" }()
+
+	// The ready line goes to stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout; w.Close() }()
+	readyc := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.Contains(line, `"event":"ready"`) {
+				readyc <- line
+			}
+		}
+	}()
+
+	done := make(chan error, 1)
+	go func() { done <- run(context.Background(), true) }()
+
+	var ready struct {
+		ControlPort int    `json:"control_port"`
+		ConfigDir   string `json:"config_dir"`
+	}
+	select {
+	case line := <-readyc:
+		if err := json.Unmarshal([]byte(line), &ready); err != nil {
+			t.Fatal(err)
+		}
+	case err := <-done:
+		t.Fatalf("run returned before it was ready: %v", err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("no ready line")
+	}
+
+	// The registry is a copy, so nothing writes to the user's directory
+	if _, err := os.Stat(filepath.Join(ready.ConfigDir, "registry", "custom", "tool.json")); err != nil {
+		t.Errorf("registry was not copied: %v", err)
+	}
+
+	resp, err := http.Post(fmt.Sprintf("http://127.0.0.1:%d/api/shutdown", ready.ControlPort), "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("shutdown returned %d", resp.StatusCode)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("run didn't return after /api/shutdown")
+	}
+	if _, err := os.Stat(ready.ConfigDir); !os.IsNotExist(err) {
+		t.Errorf("ephemeral dir %s was left behind", ready.ConfigDir)
+	}
+	if _, err := os.Stat(filepath.Join(registry, "custom", "tool.json")); err != nil {
+		t.Errorf("registry directory changed: %v", err)
+	}
+}
@@ -266,6 +266,24 @@ async fn install_update(app: tauri::AppHandle, include_beta: bool) -> Result<(),
     }
 }
 
+/// Read the backend's per-run internal token, which marks the tool tester's
+/// requests to the gateway. The backend writes it to its config directory
+/// (SCOOTER_CONFIG_DIR, or mcp-scooter in the user config dir) on start.
+#[tauri::command]
+fn internal_token(app: tauri::AppHandle) -> Result<String, String> {
+    let dir = match std::env::var("SCOOTER_CONFIG_DIR") {
+        Ok(dir) if !dir.is_empty() => std::path::PathBuf::from(dir),
+        _ => app
+            .path()
+            .config_dir()
+            .map_err(|e| format!("Failed to find the config directory: {}", e))?
+            .join("mcp-scooter"),
+    };
+    std::fs::read_to_string(dir.join("internal-token"))
+        .map(|token| token.trim().to_string())
+        .map_err(|e| format!("Failed to read the internal token (is the backend running?): {}", e))
+}
+
 /// Spawn the scooter backend process
 fn spawn_backend() -> Result<Child, String> {
     // Get the path to the sidecar binary
@@ -318,7 +336,7 @@ pub fn run() {
     tauri::Builder::default()
         .plugin(tauri_plugin_opener::init())
         .plugin(tauri_plugin_updater::Builder::new().build())
-        .invoke_handler(tauri::generate_handler![check_port_usage, kill_process, check_for_updates, install_update])
+        .invoke_handler(tauri::generate_handler![check_port_usage, kill_process, check_for_updates, install_update, internal_token])
         .setup(|app| {
             let handle = app.handle().clone();
             
@@ -46,6 +46,7 @@ import { JsonEditor } from "./components/JsonEditor";
 import "vanilla-jsoneditor/themes/jse-theme-dark.css";
 import { Mode } from "vanilla-jsoneditor";
 import { invoke } from "@tauri-apps/api/core";
+import { controlFetch, controlEventStream } from "./controlApi";
 import { revealItemInDir } from "@tauri-apps/plugin-opener";
 import "./App.css";
 
@@ -56,6 +57,31 @@ interface Profile {
   env: Record<string, string>;
   allow_tools: string[];
   disabled_system_tools: string[];
+  builtin_prefix?: string;
+  call_history?: boolean;
+  max_result_bytes?: number;
+  summarize_results?: boolean;
+  minify_schemas?: boolean;
+  minify_description_chars?: number;
+  tool_migrations?: {
+    from: string;
+    server: string;
+    tool: string;
+    rename_arguments?: Record<string, string>;
+    set_arguments?: Record<string, unknown>;
+  }[];
+  isolated_credentials?: boolean;
+  isolate_home?: boolean;
+  identities?: Record<string, string>;
+  hooks?: {
+    name?: string;
+    events: string[];
+    match?: string[];
+    command: string;
+    args?: string[];
+    env?: Record<string, string>;
+    timeout_seconds?: number;
+  }[];
 }
 
 interface Settings {
@@ -63,6 +89,8 @@ interface Settings {
   mcp_port: number;
   enable_beta: boolean;
   verbose_logging: boolean;
+  console_log_level?: string;
+  instance_id?: string;
   gateway_api_key: string;
   last_profile_id?: string;
   // Tool lifecycle settings
@@ -76,6 +104,15 @@ interface Settings {
   primary_ai_model: string;
   fallback_ai_provider: string;
   fallback_ai_model: string;
+  summary_prompt?: string;
+  summary_max_tokens?: number;
+}
+
+interface ConfigIssue {
+  field?: string;
+  message: string;
+  hint?: string;
+  suggestions?: string[];
 }
 
 interface ProcessInfo {
@@ -87,6 +124,8 @@ interface LogEntry {
   timestamp: string;
   level: string;
   message: string;
+  event?: string;
+  data?: Record<string, any>;
 }
 
 interface ToolDefinition {
@@ -143,6 +182,16 @@ interface ToolDefinition {
     license?: string;
   };
   verified_at?: string;
+  activation?: {
+    attempts: number;
+    successes: number;
+    failures: number;
+    reliability: number;
+    median_startup_ms?: number;
+    last_error?: string;
+    quarantined?: boolean;
+    quarantine_reason?: string;
+  };
 }
 
 interface ClientDefinition {
@@ -163,6 +212,10 @@ interface ClientDefinition {
   download_url?: string;
   platforms?: string[];
   installed: boolean;
+  installed_version?: string;
+  config_path?: string;
+  detected_by?: "command" | "path";
+  preferred_transport?: "sse" | "http" | "stdio";
   mcp_support?: {
     transports: string[];
     features: string[];
@@ -249,11 +302,13 @@ function App() {
   const [profiles, setProfiles] = useState<Profile[]>([]);
   const [allTools, setAllTools] = useState<ToolDefinition[]>([]);
   const [allClients, setAllClients] = useState<ClientDefinition[]>([]);
+  const [profileWarnings, setProfileWarnings] = useState<ConfigIssue[]>([]);
   const [loading, setLoading] = useState(true);
   const [onboardingRequired, setOnboardingRequired] = useState(false);
   const [configPath, setConfigPath] = useState("");
   const [selectedProfileId, setSelectedProfileId] = useState<string>("");
   const [selectedTool, setSelectedTool] = useState<ToolDefinition | null>(null);
+  const [credentialPromptTool, setCredentialPromptTool] = useState<string | null>(null);
 
   // Keep selectedTool in sync with allTools
   useEffect(() => {
@@ -296,7 +351,7 @@ function App() {
     const measureLatency = async () => {
       const start = performance.now();
       try {
-        const res = await fetch(`${CONTROL_API}/ping`);
+        const res = await controlFetch(`${CONTROL_API}/ping`);
         if (res.ok) {
           const end = performance.now();
           const rtt = Math.round(end - start);
@@ -346,7 +401,7 @@ function App() {
   useEffect(() => {
     const loadSavedParams = async () => {
       try {
-        const res = await fetch(`http://localhost:${appSettings.control_port}/api/tool-params`);
+        const res = await controlFetch(`http://localhost:${appSettings.control_port}/api/tool-params`);
         if (res.ok) {
           const data = await res.json();
           setSavedToolParams(data || {});
@@ -360,7 +415,7 @@ function App() {
     // Fetch initial logs
     const loadLogs = async () => {
       try {
-        const res = await fetch(`http://localhost:${appSettings.control_port}/api/logs`);
+        const res = await controlFetch(`http://localhost:${appSettings.control_port}/api/logs`);
         if (res.ok) {
           const data = await res.json();
           if (data.logs) {
@@ -374,27 +429,44 @@ function App() {
     loadLogs();
 
     // Subscribe to real-time logs
-    const eventSource = new EventSource(`http://localhost:${appSettings.control_port}/api/logs/stream`);
-    
-    eventSource.addEventListener('log', (event) => {
-      try {
-        const log = JSON.parse(event.data);
-        setLogs(prev => [log, ...prev].slice(0, 1000));
-      } catch (err) {
-        console.error("Failed to parse log from SSE:", err);
+    const closeStream = controlEventStream(`http://localhost:${appSettings.control_port}/api/logs/stream`, (event, data) => {
+      if (event === 'log') {
+        try {
+          const log = JSON.parse(data);
+          setLogs(prev => [log, ...prev].slice(0, 1000));
+        } catch (err) {
+          console.error("Failed to parse log from SSE:", err);
+        }
+      } else if (event === 'control') {
+        // A tool failed to activate for lack of credentials: open its form
+        try {
+          const entry: LogEntry = JSON.parse(data);
+          if (entry.event === 'credentials_required' && entry.data?.tool) {
+            setCredentialPromptTool(entry.data.tool);
+          }
+        } catch (err) {
+          console.error("Failed to parse control event from SSE:", err);
+        }
       }
-    });
-
-    eventSource.onerror = (err) => {
+    }, (err) => {
       console.error("SSE connection error:", err);
-      eventSource.close();
-    };
+    });
 
-    return () => {
-      eventSource.close();
-    };
+    return closeStream;
   }, [appSettings.control_port]);
 
+  // Show the catalog page of a tool that asked for credentials
+  useEffect(() => {
+    if (!credentialPromptTool) return;
+    const tool = allTools.find(t => t.name === credentialPromptTool);
+    if (tool) {
+      setActiveTab('catalog');
+      setSelectedClient(null);
+      setSelectedTool(tool);
+    }
+    setCredentialPromptTool(null);
+  }, [credentialPromptTool, allTools]);
+
   // Reset optional auth accordion when tool changes
   useEffect(() => {
     setOptionalAuthExpanded(false);
@@ -441,7 +513,7 @@ function App() {
   // Save tool params when modified
   const saveToolParams = async (functionName: string, params: Record<string, any>) => {
     try {
-      await fetch(`http://localhost:${appSettings.control_port}/api/tool-params`, {
+      await controlFetch(`http://localhost:${appSettings.control_port}/api/tool-params`, {
         method: "PUT",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify({ tool_name: functionName, parameters: params }),
@@ -519,7 +591,7 @@ function App() {
 
   const clearLogs = async () => {
     try {
-      const res = await fetch(`http://localhost:${appSettings.control_port}/api/logs`, {
+      const res = await controlFetch(`http://localhost:${appSettings.control_port}/api/logs`, {
         method: "DELETE"
       });
       if (res.ok) {
@@ -533,7 +605,7 @@ function App() {
 
   const revealLogs = async () => {
     try {
-      await fetch(`http://localhost:${appSettings.control_port}/api/logs/reveal`, {
+      await controlFetch(`http://localhost:${appSettings.control_port}/api/logs/reveal`, {
         method: "POST"
       });
     } catch (err) {
@@ -643,21 +715,30 @@ function App() {
     }
   }, [profiles, selectedProfileId, loading, appSettings.last_profile_id]);
 
+  const fetchSettings = async () => {
+    try {
+      const res = await controlFetch(`${CONTROL_API}/settings`);
+      if (res.ok) {
+        setAppSettings(await res.json());
+      }
+    } catch (err) {
+      console.error("Failed to fetch settings", err);
+    }
+  };
+
   const fetchProfiles = async () => {
     try {
-      const res = await fetch(`${CONTROL_API}/profiles`);
+      const res = await controlFetch(`${CONTROL_API}/profiles`);
       const data = await res.json();
       const updatedProfiles = data.profiles || [];
       setProfiles(updatedProfiles);
       setConfigPath(data.config_path || "");
       
-      if (data.settings) {
-        setAppSettings(data.settings);
-      }
-      
       setOnboardingRequired(data.onboarding_required);
       
       if (lastConnectionState.current !== true) {
+        // Settings are not part of the profile list; load them once per connection
+        fetchSettings();
         setStatus(s => ({ ...s, connected: true }));
         splashLog('Backend connected!', 'success');
         lastConnectionState.current = true;
@@ -697,7 +778,7 @@ function App() {
 
   const updateGlobalSettings = async (newSettings: Settings) => {
     try {
-      const res = await fetch(`${CONTROL_API}/settings`, {
+      const res = await controlFetch(`${CONTROL_API}/settings`, {
         method: "PUT",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify(newSettings),
@@ -713,7 +794,7 @@ function App() {
 
   const updateProfile = async (oldId: string, p: Profile) => {
     try {
-      const res = await fetch(`${CONTROL_API}/profiles`, {
+      const res = await controlFetch(`${CONTROL_API}/profiles`, {
         method: "PUT",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify({ old_id: oldId, profile: p }),
@@ -746,7 +827,7 @@ function App() {
     // Save the last selected profile to settings
     try {
       const newSettings = { ...appSettings, last_profile_id: profileId };
-      await fetch(`${CONTROL_API}/settings`, {
+      await controlFetch(`${CONTROL_API}/settings`, {
         method: "PUT",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify(newSettings),
@@ -766,7 +847,7 @@ function App() {
   const handleResetApp = async () => {
     try {
       addLog("Resetting application...", "INFO");
-      const res = await fetch(`${CONTROL_API}/reset`, { method: "POST" });
+      const res = await controlFetch(`${CONTROL_API}/reset`, { method: "POST" });
       if (res.ok) {
         addLog("Application reset successful.", "INFO");
         setProfiles([]);
@@ -807,9 +888,25 @@ function App() {
     }
   }, [selectedTool?.name, selectedProfile?.allow_tools, selectedProfile?.env, lastVerifiedTool]);
 
+  // Flag allow_tools entries that aren't in the registry, e.g. typos in profiles.yaml
+  useEffect(() => {
+    if (!selectedProfile) {
+      setProfileWarnings([]);
+      return;
+    }
+    controlFetch(`${CONTROL_API}/profiles/lint`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(selectedProfile),
+    })
+      .then(res => res.ok ? res.json() : { warnings: [] })
+      .then(data => setProfileWarnings(data.warnings || []))
+      .catch(() => setProfileWarnings([]));
+  }, [selectedProfile?.id, selectedProfile?.allow_tools, allTools.length]);
+
   const fetchAllTools = async () => {
     try {
-      const res = await fetch(`${CONTROL_API}/tools`);
+      const res = await controlFetch(`${CONTROL_API}/tools`);
       const data = await res.json();
       const tools = data.tools || [];
       setAllTools(prev => {
@@ -825,7 +922,7 @@ function App() {
 
   const fetchClients = async () => {
     try {
-      const res = await fetch(`${CONTROL_API}/clients`);
+      const res = await controlFetch(`${CONTROL_API}/clients`);
       const data = await res.json();
       const clients = data.clients || [];
       setAllClients(prev => {
@@ -843,7 +940,7 @@ function App() {
     console.log(`[${level}] ${message}`);
     // Only update local state if SSE is not active or for immediate feedback
     // But since SSE will push it back, we can just send it to the backend
-    fetch(`http://localhost:${appSettings.control_port}/api/logs`, {
+    controlFetch(`http://localhost:${appSettings.control_port}/api/logs`, {
       method: "POST",
       headers: { "Content-Type": "application/json" },
       body: JSON.stringify({ level, message })
@@ -898,7 +995,7 @@ function App() {
   const deleteProfile = async (id: string) => {
     if (!confirm(`Are you sure you want to delete profile "${id}"?`)) return;
     try {
-      const res = await fetch(`${CONTROL_API}/profiles?id=${id}`, { method: "DELETE" });
+      const res = await controlFetch(`${CONTROL_API}/profiles?id=${id}`, { method: "DELETE" });
       if (res.ok) {
         addLog(`Deleted profile: ${id}`, "INFO");
         fetchProfiles();
@@ -914,7 +1011,7 @@ function App() {
   const deleteTool = async (name: string) => {
     if (!confirm(`Are you sure you want to delete custom tool "${name}"?`)) return;
     try {
-      const res = await fetch(`${CONTROL_API}/tools?name=${name}`, { method: "DELETE" });
+      const res = await controlFetch(`${CONTROL_API}/tools?name=${name}`, { method: "DELETE" });
       if (res.ok) {
         addLog(`Deleted custom tool: ${name}`, "INFO");
         fetchAllTools(); // Refresh tool list
@@ -926,12 +1023,26 @@ function App() {
     }
   };
 
+  const unquarantineTool = async (name: string) => {
+    try {
+      const res = await controlFetch(`${CONTROL_API}/tools/${encodeURIComponent(name)}/unquarantine`, { method: "POST" });
+      if (res.ok) {
+        addLog(`Unquarantined ${name}`, "INFO");
+        fetchAllTools();
+      } else {
+        addLog(`Failed to unquarantine ${name}: ${await res.text()}`, "ERROR");
+      }
+    } catch (err) {
+      addLog(`Error unquarantining ${name}: ${err}`, "ERROR");
+    }
+  };
+
   const updateProfileTools = async (profileId: string, tools: string[]) => {
     const profile = profiles.find(p => p.id === profileId);
     if (!profile) return;
 
     try {
-      const res = await fetch(`${CONTROL_API}/profiles`, {
+      const res = await controlFetch(`${CONTROL_API}/profiles`, {
         method: "PUT",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify({ 
@@ -985,7 +1096,7 @@ function App() {
         addLog("[Verify] Request timed out (server may be slow to start)", "ERROR");
       }, 120000); // 2 minute timeout for verification
 
-      const res = await fetch(`${CONTROL_API}/tools/verify`, {
+      const res = await controlFetch(`${CONTROL_API}/tools/verify`, {
         method: "POST",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify({ 
@@ -1047,7 +1158,7 @@ function App() {
       : [...currentDisabled, toolName];               // Disable: add to disabled list
 
     try {
-      const res = await fetch(`${CONTROL_API}/profiles`, {
+      const res = await controlFetch(`${CONTROL_API}/profiles`, {
         method: "PUT",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify({ ...selectedProfile, disabled_system_tools: newDisabled }),
@@ -1069,7 +1180,7 @@ function App() {
     if (!profile) return;
 
     try {
-      const res = await fetch(`${CONTROL_API}/profiles`, {
+      const res = await controlFetch(`${CONTROL_API}/profiles`, {
         method: "PUT",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify({ 
@@ -1092,7 +1203,7 @@ function App() {
   const createProfile = async () => {
     if (!newProfile.id) return;
     try {
-      const res = await fetch(`${CONTROL_API}/profiles`, {
+      const res = await controlFetch(`${CONTROL_API}/profiles`, {
         method: "POST",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify({ ...newProfile, remote_auth_mode: "none" }),
@@ -1123,12 +1234,14 @@ function App() {
       
       // Use the unified gateway port and include profile ID in path
       const url = `http://localhost:${appSettings.mcp_port}/profiles/${selectedProfile.id}/message`;
+      // Proves the request comes from this app; the backend makes a new one each run
+      const internalToken = await invoke<string>("internal_token");
       
       const res = await fetch(url, {
         method: "POST",
         headers: { 
           "Content-Type": "application/json",
-          "X-Scooter-Internal": "true"
+          "X-Scooter-Internal-Token": internalToken
         },
         body: JSON.stringify({
           jsonrpc: "2.0",
@@ -1182,7 +1295,7 @@ function App() {
   const startFresh = async () => {
     try {
       addLog("Initializing default workspace...", "INFO");
-      const res = await fetch(`${CONTROL_API}/onboarding/start-fresh`, { method: "POST" });
+      const res = await controlFetch(`${CONTROL_API}/onboarding/start-fresh`, { method: "POST" });
       if (res.ok) {
         addLog("Workspace ready!", "INFO");
         fetchProfiles();
@@ -1209,7 +1322,7 @@ function App() {
       reader.onload = async () => {
         try {
           addLog(`Importing ${file.name}...`, "INFO");
-          const res = await fetch(`${CONTROL_API}/onboarding/import`, {
+          const res = await controlFetch(`${CONTROL_API}/onboarding/import`, {
             method: "POST",
             headers: { "Content-Type": "application/json" },
             body: JSON.stringify({ profiles: [] }) // Placeholder for parsed profiles
@@ -1866,6 +1979,23 @@ function App() {
                               VERIFIED {selectedTool.tools.length} {selectedTool.tools.length === 1 ? 'TOOL' : 'TOOLS'}
                             </span>
                           )}
+                          {selectedTool?.activation && (
+                            <span
+                              title={`${selectedTool.activation.successes} of ${selectedTool.activation.attempts} activations succeeded${selectedTool.activation.median_startup_ms ? `, median start-up ${selectedTool.activation.median_startup_ms}ms` : ''}${selectedTool.activation.last_error ? `\nLast error: ${selectedTool.activation.last_error}` : ''}`}
+                              style={{ fontSize: '11px', opacity: 0.6, background: 'var(--background-card)', padding: '2px 8px', borderRadius: '10px', border: '1px solid var(--border-subtle)', textTransform: 'uppercase', color: selectedTool.activation.reliability < 0.5 ? '#ff4d4d' : undefined }}
+                            >
+                              {Math.round(selectedTool.activation.reliability * 100)}% RELIABLE
+                            </span>
+                          )}
+                          {selectedTool?.activation?.quarantined && (
+                            <button
+                              onClick={() => unquarantineTool(selectedTool.name)}
+                              title={`${selectedTool.activation.quarantine_reason}\nClick to allow activation again once it is fixed.`}
+                              style={{ fontSize: '11px', background: 'transparent', padding: '2px 8px', borderRadius: '10px', border: '1px solid #ff4d4d', color: '#ff4d4d', textTransform: 'uppercase', cursor: 'pointer' }}
+                            >
+                              QUARANTINED · UNQUARANTINE
+                            </button>
+                          )}
                         </div>
                       </h3>
                       <div style={{ display: 'flex', flexDirection: 'column', gap: '12px' }}>
@@ -2016,6 +2146,9 @@ function App() {
                   {selectedClient.version && (
                     <span style={{ fontSize: '12px', color: 'var(--text-secondary)', fontWeight: 400 }}>v{selectedClient.version}</span>
                   )}
+                  {selectedClient.installed_version && (
+                    <span style={{ fontSize: '12px', color: 'var(--text-secondary)', fontWeight: 400 }}>installed {selectedClient.installed_version}</span>
+                  )}
                 </div>
                 <div className="card-actions" style={{ marginTop: 0, paddingTop: 0, borderTop: 'none', display: 'flex', gap: '8px' }}>
                   {selectedClient.installed ? (
@@ -2024,7 +2157,7 @@ function App() {
                       onClick={async (e) => {
                         e.stopPropagation();
                         try {
-                          const res = await fetch(`${CONTROL_API}/clients/sync`, {
+                          const res = await controlFetch(`${CONTROL_API}/clients/sync`, {
                             method: "POST",
                             headers: { "Content-Type": "application/json" },
                             body: JSON.stringify({ target: selectedClient.id, profile: selectedProfileId })
@@ -2527,8 +2660,9 @@ function App() {
                       </div>
                     )}
                     
-                    {selectedProfile?.allow_tools?.map(toolName => {
+                    {selectedProfile?.allow_tools?.map((toolName, index) => {
                       const tool = allTools.find(t => t.name === toolName);
+                      const warning = profileWarnings.find(w => w.field === `allow_tools[${index}]`);
                       return (
                         <div 
                           key={toolName} 
@@ -2564,12 +2698,29 @@ function App() {
                                 {tool.tools.length} {tool.tools.length === 1 ? 'tool' : 'tools'}
                               </span>
                             )}
-                            <span className="card-tag official" style={{ background: 'var(--status-ok-bg)', color: 'var(--status-ok-text)', borderColor: 'var(--status-ok-border)' }}>
-                              Active
-                            </span>
+                            {warning ? (
+                              <span className="card-tag" title={`${warning.message}${warning.hint ? ` (${warning.hint})` : ''}`} style={{ color: '#ff4d4d', borderColor: '#ff4d4d' }}>
+                                Not in registry
+                              </span>
+                            ) : (
+                              <span className="card-tag official" style={{ background: 'var(--status-ok-bg)', color: 'var(--status-ok-text)', borderColor: 'var(--status-ok-border)' }}>
+                                Active
+                              </span>
+                            )}
                           </div>
 
                           <div className="card-actions">
+                            {warning?.suggestions?.[0] && (
+                              <button
+                                onClick={(e) => {
+                                  e.stopPropagation();
+                                  const tools = (selectedProfile.allow_tools || []).map((t, i) => i === index ? warning.suggestions![0] : t);
+                                  updateProfileTools(selectedProfile.id, tools);
+                                }}
+                              >
+                                Use {warning.suggestions[0]}
+                              </button>
+                            )}
                             <button onClick={(e) => { e.stopPropagation(); setDrawer({ type: "test-tool", data: toolName }); }}>Test</button>
                             <button 
                               className="secondary"
@@ -2794,7 +2945,7 @@ function App() {
                               onClick={async (e) => {
                                 e.stopPropagation();
                                 try {
-                                  const res = await fetch(`${CONTROL_API}/clients/sync`, {
+                                  const res = await controlFetch(`${CONTROL_API}/clients/sync`, {
                                     method: "POST",
                                     headers: { "Content-Type": "application/json" },
                                     body: JSON.stringify({ target: client.id, profile: selectedProfileId })
@@ -3464,7 +3615,7 @@ function App() {
                   onClick={async () => {
                     try {
                       const td = JSON.parse(drawer.data || "");
-                      const res = await fetch(`${CONTROL_API}/tools`, {
+                      const res = await controlFetch(`${CONTROL_API}/tools`, {
                         method: "POST",
                         headers: { "Content-Type": "application/json" },
                         body: JSON.stringify(td),
@@ -1,5 +1,6 @@
 import { useState, useEffect } from 'react';
 import { revealItemInDir } from '@tauri-apps/plugin-opener';
+import { controlFetch } from '../controlApi';
 import { EyeRegular, EyeOffRegular, SettingsRegular, DismissRegular, ArrowResetRegular } from "@fluentui/react-icons";
 
 interface Settings {
@@ -7,6 +8,7 @@ interface Settings {
   mcp_port: number;
   enable_beta: boolean;
   verbose_logging: boolean;
+  notifications?: { enabled: boolean; events?: string[] };
   gateway_api_key: string;
   // Tool lifecycle settings
   auto_cleanup_enabled: boolean;
@@ -112,7 +114,7 @@ export function SettingsModal({
         ? `http://localhost:${settings.control_port}/api/credentials/ai-primary`
         : `http://localhost:${settings.control_port}/api/credentials/ai-fallback`;
       
-      const res = await fetch(endpoint, {
+      const res = await controlFetch(endpoint, {
         method: "POST",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify({ value }),
@@ -141,7 +143,7 @@ export function SettingsModal({
         ? `http://localhost:${settings.control_port}/api/credentials/ai-primary`
         : `http://localhost:${settings.control_port}/api/credentials/ai-fallback`;
       
-      const res = await fetch(endpoint, {
+      const res = await controlFetch(endpoint, {
         method: "DELETE",
       });
       
@@ -160,7 +162,7 @@ export function SettingsModal({
 
   const handleRegenerateKey = async () => {
     try {
-      const res = await fetch(`http://localhost:${settings.control_port}/api/settings/regenerate-key`, {
+      const res = await controlFetch(`http://localhost:${settings.control_port}/api/settings/regenerate-key`, {
         method: "POST",
       });
       if (res.ok) {
@@ -244,6 +246,18 @@ export function SettingsModal({
                   </div>
                   <p className="settings-field-helper">Enable detailed TRACE-level logs for debugging (may affect performance)</p>
                 </div>
+
+                <div className="settings-field" style={{ marginTop: '16px' }}>
+                  <div 
+                    className="toggle-switch-container" 
+                    onClick={() => onUpdateSettings({ ...settings, notifications: { ...settings.notifications, enabled: !settings.notifications?.enabled } })}
+                    style={{ cursor: 'pointer' }}
+                  >
+                    <div className={`toggle-switch ${settings.notifications?.enabled ? 'active' : ''}`} />
+                    <span className="toggle-switch-label">Desktop Notifications</span>
+                  </div>
+                  <p className="settings-field-helper">Show a system notification when a server crashes or is quarantined</p>
+                </div>
               </div>
 
               <div className="settings-section">
@@ -0,0 +1,53 @@
+import { invoke } from "@tauri-apps/api/core";
+
+// Calls the daemon's control API as its owner. Once control tokens exist
+// the daemon refuses requests without one, so the app presents the per-run
+// internal token. It is read on each call since the daemon writes a new one
+// whenever it restarts.
+export async function controlFetch(input: string, init: RequestInit = {}): Promise<Response> {
+  const headers = new Headers(init.headers);
+  try {
+    headers.set("X-Scooter-Internal-Token", await invoke<string>("internal_token"));
+  } catch {
+    // The backend isn't up yet; without control tokens it needs none
+  }
+  return fetch(input, { ...init, headers });
+}
+
+// Streams server-sent events from the control API. Unlike EventSource it
+// can send the internal token. Returns a function that closes the stream.
+export function controlEventStream(
+  url: string,
+  onEvent: (event: string, data: string) => void,
+  onError: (err: unknown) => void,
+): () => void {
+  const abort = new AbortController();
+  (async () => {
+    const res = await controlFetch(url, { signal: abort.signal });
+    if (!res.ok || !res.body) {
+      throw new Error(`HTTP ${res.status}`);
+    }
+    const reader = res.body.pipeThrough(new TextDecoderStream()).getReader();
+    let buffer = "";
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) return;
+      buffer += value;
+      let end;
+      while ((end = buffer.indexOf("\n\n")) >= 0) {
+        const block = buffer.slice(0, end);
+        buffer = buffer.slice(end + 2);
+        let event = "message";
+        const data: string[] = [];
+        for (const line of block.split("\n")) {
+          if (line.startsWith("event:")) event = line.slice(6).trim();
+          else if (line.startsWith("data:")) data.push(line.slice(5).replace(/^ /, ""));
+        }
+        if (data.length > 0) onEvent(event, data.join("\n"));
+      }
+    }
+  })().catch((err) => {
+    if (!abort.signal.aborted) onError(err);
+  });
+  return () => abort.abort();
+}
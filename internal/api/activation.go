@@ -0,0 +1,47 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// autoActivateLimit is how many servers a profile may auto-activate per minute.
+const autoActivateLimit = 10
+
+// activationLimiter bounds how often tool calls may start servers for a
+// profile, so a looping agent can't spawn processes without end.
+type activationLimiter struct {
+	mu     sync.Mutex
+	max    int
+	window time.Duration
+	recent map[string][]time.Time // profileID -> activation times within window
+}
+
+func newActivationLimiter(max int, window time.Duration) *activationLimiter {
+	return &activationLimiter{
+		max:    max,
+		window: window,
+		recent: make(map[string][]time.Time),
+	}
+}
+
+// Allow records an activation for the profile and reports whether it is
+// within the limit.
+func (l *activationLimiter) Allow(profileID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	kept := l.recent[profileID][:0]
+	for _, t := range l.recent[profileID] {
+		if now.Sub(t) < l.window {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) >= l.max {
+		l.recent[profileID] = kept
+		return false
+	}
+	l.recent[profileID] = append(kept, now)
+	return true
+}
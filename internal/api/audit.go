@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/audit"
+)
+
+// parseAuditQuery reads the filters of GET /api/audit: profile, tool,
+// outcome (success or error), since and until (RFC 3339 times) and limit.
+func parseAuditQuery(q url.Values) (audit.Query, error) {
+	aq := audit.Query{
+		Profile: q.Get("profile"),
+		Tool:    q.Get("tool"),
+		Outcome: q.Get("outcome"),
+	}
+	if aq.Outcome != "" && aq.Outcome != audit.Success && aq.Outcome != audit.Failure {
+		return aq, fmt.Errorf("invalid outcome %q: use %s or %s", aq.Outcome, audit.Success, audit.Failure)
+	}
+	for name, t := range map[string]*time.Time{"since": &aq.Since, "until": &aq.Until} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return aq, fmt.Errorf("invalid %s %q: use an RFC 3339 time", name, v)
+		}
+		*t = parsed
+	}
+	var err error
+	if aq.Limit, err = parseNonNegative(q, "limit"); err != nil {
+		return aq, err
+	}
+	if aq.Limit > audit.MaxLimit {
+		return aq, fmt.Errorf("limit must be at most %d", audit.MaxLimit)
+	}
+	return aq, nil
+}
+
+// handleGetAudit returns the newest audited tool calls matching the query,
+// oldest first. Access groups limited to some profiles only see those
+// profiles' calls.
+func (s *ControlServer) handleGetAudit(w http.ResponseWriter, r *http.Request) {
+	query, err := parseAuditQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if group, restricted := accessGroupFrom(r.Context()); restricted && len(group.Profiles) > 0 {
+		query.Profiles = group.Profiles
+	}
+
+	records, truncated, err := audit.Default.Query(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"records":   records,
+		"truncated": truncated,
+	})
+}
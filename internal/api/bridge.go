@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// RunStdioBridge connects a stdio MCP client to the gateway's SSE endpoint
+// at sseURL. Newline-delimited JSON-RPC messages read from in are POSTed to
+// the session endpoint; responses and notifications from the SSE stream are
+// written to out, one per line. It returns when in is exhausted, the stream
+// closes or ctx is cancelled.
+func RunStdioBridge(ctx context.Context, sseURL, apiKey string, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sseURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to gateway: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("gateway returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var outMu sync.Mutex
+	writeLine := func(data []byte) error {
+		outMu.Lock()
+		defer outMu.Unlock()
+		_, err := fmt.Fprintf(out, "%s\n", bytes.TrimSpace(data))
+		return err
+	}
+
+	endpoint := make(chan string, 1)
+	streamDone := make(chan error, 1)
+	go func() {
+		streamDone <- readSSE(resp.Body, func(event, data string) error {
+			switch event {
+			case "endpoint":
+				select {
+				case endpoint <- data:
+				default:
+				}
+			case "message":
+				return writeLine([]byte(data))
+			}
+			return nil // pulses and unknown events
+		})
+	}()
+
+	var postURL string
+	select {
+	case postURL = <-endpoint:
+	case err := <-streamDone:
+		return fmt.Errorf("gateway closed the stream before sending its endpoint: %v", err)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	lines := make(chan []byte)
+	inputDone := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			select {
+			case lines <- append([]byte(nil), line...):
+			case <-ctx.Done():
+				return
+			}
+		}
+		inputDone <- scanner.Err()
+	}()
+
+	for {
+		select {
+		case line := <-lines:
+			body, err := postBridgeMessage(ctx, postURL, apiKey, line)
+			if err != nil {
+				return err
+			}
+			// The gateway answers in the body when it can't use the stream
+			if len(bytes.TrimSpace(body)) > 0 {
+				if err := writeLine(body); err != nil {
+					return err
+				}
+			}
+		case err := <-inputDone:
+			return err
+		case err := <-streamDone:
+			if err == nil {
+				err = io.EOF
+			}
+			return fmt.Errorf("gateway stream closed: %w", err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func postBridgeMessage(ctx context.Context, url, apiKey string, msg []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(msg))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message to gateway: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("gateway returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// readSSE parses a text/event-stream and calls fn for every event.
+func readSSE(r io.Reader, fn func(event, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if event == "" {
+					event = "message"
+				}
+				if err := fn(event, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,553 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/mcp-scooter/scooter/internal/logger"
+	"github.com/mcp-scooter/scooter/internal/version"
+	"gopkg.in/yaml.v3"
+)
+
+// A bundle moves a setup to another machine: a zip archive holding
+// profiles.yaml, settings.yaml without the gateway key, control tokens,
+// access group keys and tunnel token, tool-params.json, tool-presets.json,
+// and the registry's custom entries, profile overlays and overrides under
+// registry/. Profiles' API keys and environment values are replaced by
+// bundleSecret: an import keeps those of the profile it replaces, generates
+// new keys and leaves out the other variables. Imported settings switch
+// hooks off, since a bundle's profiles may bring hooks of their own. An
+// import either applies the whole bundle or, if a step fails, restores
+// every file it had changed.
+
+const (
+	bundleFormat = 1
+	// bundleSecret stands for a secret left out of a bundle.
+	bundleSecret = "********"
+	// maxBundleBytes bounds both the archive and its files together, once
+	// decompressed.
+	maxBundleBytes = 32 << 20
+)
+
+// bundleManifest is manifest.json in a bundle.
+type bundleManifest struct {
+	Format    int       `json:"format"`
+	Version   string    `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ImportResult is what POST /api/profiles/import changed.
+type ImportResult struct {
+	Profiles      []string     `json:"profiles"`
+	CustomEntries int          `json:"custom_entries"`
+	Overrides     int          `json:"overrides"`
+	ToolParams    int          `json:"tool_params"`
+	ToolPresets   int          `json:"tool_presets"`
+	Settings      bool         `json:"settings"`
+	Reload        ReloadResult `json:"reload"`
+}
+
+// bundleSettings returns settings without the secrets that belong to this
+// machine.
+func bundleSettings(s profile.Settings) profile.Settings {
+	s.GatewayAPIKey = ""
+	s.ControlTokens = nil
+	s.InstanceID = ""
+	s.Tunnel.Token = ""
+	s.Onboarding = profile.OnboardingState{}
+	groups := make([]profile.AccessGroup, len(s.AccessGroups))
+	for i, g := range s.AccessGroups {
+		g.APIKey = ""
+		groups[i] = g
+	}
+	if len(groups) > 0 {
+		s.AccessGroups = groups
+	}
+	return s
+}
+
+// bundleProfile returns p without its API key and environment values.
+func bundleProfile(p profile.Profile) profile.Profile {
+	if p.APIKey != "" {
+		p.APIKey = bundleSecret
+	}
+	if len(p.Env) > 0 {
+		env := make(map[string]string, len(p.Env))
+		for k := range p.Env {
+			env[k] = bundleSecret
+		}
+		p.Env = env
+	}
+	return p
+}
+
+// importedProfile returns imported with the secrets of current, the
+// profile it replaces if any. Keys it doesn't have are generated;
+// environment variables it doesn't have are dropped rather than set empty.
+func importedProfile(imported profile.Profile, current *profile.Profile) profile.Profile {
+	if imported.APIKey == bundleSecret {
+		imported.APIKey = profile.GenerateAPIKey()
+		if current != nil && current.APIKey != "" {
+			imported.APIKey = current.APIKey
+		}
+	}
+	if len(imported.Env) > 0 {
+		env := make(map[string]string, len(imported.Env))
+		for k, v := range imported.Env {
+			if v == bundleSecret {
+				if current == nil || current.Env[k] == "" {
+					continue
+				}
+				v = current.Env[k]
+			}
+			env[k] = v
+		}
+		imported.Env = env
+	}
+	return imported
+}
+
+// importedSettings returns imported with the secrets of current, the
+// settings being replaced, and hooks off. Access groups keep their key by
+// name; new groups get a generated one.
+func importedSettings(imported, current profile.Settings) profile.Settings {
+	imported.EnableHooks = false
+	imported.GatewayAPIKey = current.GatewayAPIKey
+	imported.ControlTokens = current.ControlTokens
+	imported.InstanceID = current.InstanceID
+	imported.Tunnel.Token = current.Tunnel.Token
+	imported.Onboarding = current.Onboarding
+	for i, g := range imported.AccessGroups {
+		imported.AccessGroups[i].APIKey = profile.GenerateAPIKey()
+		for _, old := range current.AccessGroups {
+			if old.Name == g.Name {
+				imported.AccessGroups[i].APIKey = old.APIKey
+			}
+		}
+	}
+	return imported
+}
+
+// registryFiles returns the custom entries, overlays and overrides of
+// registryDir by their path in a bundle.
+func registryFiles(registryDir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for _, dir := range []string{"custom", "overrides"} {
+		root := filepath.Join(registryDir, dir)
+		err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			rel, _ := filepath.Rel(registryDir, p)
+			rel = filepath.ToSlash(rel)
+			if d.IsDir() {
+				// Overlays are one level below custom; overrides have none
+				if p != root && (dir == "overrides" || strings.Count(rel, "/") > 1) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(d.Name(), ".json") || strings.HasPrefix(d.Name(), ".") {
+				return nil
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			files["registry/"+rel] = data
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// handleExportBundle returns the setup as a zip archive.
+func (s *ControlServer) handleExportBundle(w http.ResponseWriter, r *http.Request) {
+	files := make(map[string][]byte)
+	manifest, _ := json.MarshalIndent(bundleManifest{Format: bundleFormat, Version: version.Get().Version, CreatedAt: time.Now().UTC()}, "", "  ")
+	files["manifest.json"] = manifest
+
+	var profiles []profile.Profile
+	for _, p := range s.manager.GetProfiles() {
+		profiles = append(profiles, bundleProfile(p))
+	}
+	profilesYAML, err := yaml.Marshal(profile.ProfilesConfig{Profiles: profiles})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	files["profiles.yaml"] = profilesYAML
+	settingsYAML, err := yaml.Marshal(profile.SettingsConfig{Settings: bundleSettings(s.settings.Get())})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	files["settings.yaml"] = settingsYAML
+
+	if s.store != nil {
+		if params, err := s.store.LoadToolParams(); err == nil && len(params) > 0 {
+			data, _ := json.MarshalIndent(params, "", "  ")
+			files["tool-params.json"] = data
+		}
+		if presets, err := s.store.LoadToolPresets(); err == nil && len(presets) > 0 {
+			data, _ := json.MarshalIndent(presets, "", "  ")
+			files["tool-presets.json"] = data
+		}
+	}
+	if s.manager.registryDir != "" {
+		regFiles, err := registryFiles(s.manager.registryDir)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read the registry: %v", err), http.StatusInternalServerError)
+			return
+		}
+		for name, data := range regFiles {
+			files[name] = data
+		}
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := zw.Create(name)
+		if err == nil {
+			_, err = f.Write(data)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("scooter-%s.zip", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Write(buf.Bytes())
+}
+
+// bundle is a decoded bundle.
+type bundle struct {
+	profiles   []profile.Profile
+	settings   *profile.Settings
+	toolParams map[string]map[string]interface{}
+	presets    profile.ToolPresets
+	entries    []bundleEntry
+	overrides  map[string][]byte
+}
+
+// bundleEntry is a custom entry of a bundle, in an overlay or "" for the
+// shared registry.
+type bundleEntry struct {
+	overlay string
+	entry   registry.MCPEntry
+}
+
+// readBundle decodes and validates a bundle. Unknown files are an error, so
+// a bundle from a newer version isn't half imported.
+func readBundle(data []byte) (*bundle, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a zip archive: %w", err)
+	}
+	b := &bundle{overrides: make(map[string][]byte)}
+	remaining := int64(maxBundleBytes)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, remaining+1))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		if int64(len(content)) > remaining {
+			return nil, fmt.Errorf("%s: the bundle's files exceed %d bytes", f.Name, maxBundleBytes)
+		}
+		remaining -= int64(len(content))
+		if err := b.add(f.Name, content); err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	return b, nil
+}
+
+// add decodes one file of a bundle.
+func (b *bundle) add(name string, content []byte) error {
+	parts := strings.Split(path.Clean(name), "/")
+	switch {
+	case name == "manifest.json":
+		var m bundleManifest
+		if err := json.Unmarshal(content, &m); err != nil {
+			return err
+		}
+		if m.Format > bundleFormat {
+			return fmt.Errorf("bundle format %d is newer than this version supports", m.Format)
+		}
+	case name == "profiles.yaml":
+		var config profile.ProfilesConfig
+		if err := yaml.Unmarshal(content, &config); err != nil {
+			return err
+		}
+		for _, p := range config.Profiles {
+			if err := p.Validate(); err != nil {
+				return fmt.Errorf("profile %q: %w", p.ID, err)
+			}
+		}
+		b.profiles = config.Profiles
+	case name == "settings.yaml":
+		var config profile.SettingsConfig
+		if err := yaml.Unmarshal(content, &config); err != nil {
+			return err
+		}
+		b.settings = &config.Settings
+	case name == "tool-params.json":
+		if err := json.Unmarshal(content, &b.toolParams); err != nil {
+			return err
+		}
+	case name == "tool-presets.json":
+		if err := json.Unmarshal(content, &b.presets); err != nil {
+			return err
+		}
+		if err := b.presets.Validate(); err != nil {
+			return err
+		}
+	case len(parts) >= 3 && len(parts) <= 4 && parts[0] == "registry" && parts[1] == "custom" && strings.HasSuffix(name, ".json"):
+		overlay := ""
+		if len(parts) == 4 {
+			overlay = parts[2]
+			if err := (profile.Profile{ID: overlay}).Validate(); err != nil || strings.HasPrefix(overlay, ".") {
+				return fmt.Errorf("invalid overlay %q", overlay)
+			}
+		}
+		var entry registry.MCPEntry
+		if err := json.Unmarshal(content, &entry); err != nil {
+			return err
+		}
+		if result := registry.ValidateCustom(&entry); !result.Valid {
+			return fmt.Errorf("%w: %v", discovery.ErrInvalidEntry, result.Errors)
+		}
+		b.entries = append(b.entries, bundleEntry{overlay: overlay, entry: entry})
+	case len(parts) == 3 && parts[0] == "registry" && parts[1] == "overrides":
+		entryName, ok := strings.CutSuffix(parts[2], ".json")
+		if !ok || !registry.ValidName(entryName) {
+			return errors.New("invalid override name")
+		}
+		b.overrides[entryName] = content
+	default:
+		return errors.New("unexpected file in bundle")
+	}
+	return nil
+}
+
+// handleImportBundle applies a bundle from GET /api/profiles/export. Its
+// profiles replace those with the same ID and others are kept; its settings
+// replace the current ones except for this machine's secrets; registry files
+// and tool parameters are added, replacing those of the same name. The
+// configuration is then reloaded.
+func (s *ControlServer) handleImportBundle(w http.ResponseWriter, r *http.Request) {
+	if s.store == nil {
+		http.Error(w, "store not initialized", http.StatusInternalServerError)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBundleBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b, err := readBundle(data)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if (len(b.entries) > 0 || len(b.overrides) > 0) && s.manager.registryDir == "" {
+		http.Error(w, "Registry entries need a registry directory", http.StatusBadRequest)
+		return
+	}
+
+	result := ImportResult{Profiles: []string{}}
+	// undo restores the files changed so far, in reverse order
+	var undo []func() error
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			if err := undo[i](); err != nil {
+				logger.AddLog("ERROR", fmt.Sprintf("Bundle import failed to roll back: %v", err))
+			}
+		}
+	}
+	fail := func(what string, err error) {
+		rollback()
+		logger.AddLog("ERROR", fmt.Sprintf("Bundle import failed to save %s: %v", what, err))
+		http.Error(w, fmt.Sprintf("Failed to save %s: %v", what, err), http.StatusInternalServerError)
+	}
+	// saving snapshots path before what is saved to it
+	saving := func(what, path string) bool {
+		restore, err := snapshotFile(path)
+		if err != nil {
+			fail(what, err)
+			return false
+		}
+		undo = append(undo, restore)
+		return true
+	}
+
+	// Entries first, so overrides are checked against them
+	for _, e := range b.entries {
+		what := "custom entry " + e.entry.Name
+		if !saving(what, discovery.CustomEntryPath(s.manager.registryDir, e.overlay, e.entry.Name)) {
+			return
+		}
+		if _, _, err := discovery.WriteCustomEntry(s.manager.registryDir, e.overlay, &e.entry, ""); err != nil {
+			fail(what, err)
+			return
+		}
+		result.CustomEntries++
+	}
+	for name, patch := range b.overrides {
+		what := "override of " + name
+		if !saving(what, discovery.OverridePath(s.manager.registryDir, name)) {
+			return
+		}
+		if err := discovery.WriteOverride(s.manager.registryDir, name, patch); err != nil {
+			fail(what, err)
+			return
+		}
+		result.Overrides++
+	}
+
+	if len(b.toolParams) > 0 {
+		params, _ := s.store.LoadToolParams()
+		if params == nil {
+			params = make(map[string]map[string]interface{})
+		}
+		for tool, p := range b.toolParams {
+			params[tool] = p
+		}
+		if !saving("tool parameters", s.store.GetToolParamsPath()) {
+			return
+		}
+		if err := s.store.SaveToolParams(params); err != nil {
+			fail("tool parameters", err)
+			return
+		}
+		result.ToolParams = len(b.toolParams)
+	}
+	if len(b.presets) > 0 {
+		presets, err := s.store.LoadToolPresets()
+		if err != nil {
+			fail("tool presets", err)
+			return
+		}
+		added, _ := presets.Merge(b.presets, true)
+		if !saving("tool presets", s.store.GetToolPresetsPath()) {
+			return
+		}
+		if err := s.store.SaveToolPresets(presets); err != nil {
+			fail("tool presets", err)
+			return
+		}
+		result.ToolPresets = len(added)
+	}
+
+	if b.settings != nil {
+		if !saving("settings", s.store.GetSettingsPath()) {
+			return
+		}
+		if err := s.store.SaveSettings(importedSettings(*b.settings, s.settings.Get())); err != nil {
+			fail("settings", err)
+			return
+		}
+		result.Settings = true
+		if b.settings.EnableHooks || s.settings.Get().EnableHooks {
+			logger.AddLog("WARN", "Imported settings switch hooks off; review the profiles' hooks before enabling them again")
+		}
+	}
+	if len(b.profiles) > 0 {
+		profiles := append([]profile.Profile(nil), s.manager.GetProfiles()...)
+		for _, p := range b.profiles {
+			replaced := false
+			for i := range profiles {
+				if profiles[i].ID == p.ID {
+					profiles[i] = importedProfile(p, &profiles[i])
+					replaced = true
+				}
+			}
+			if !replaced {
+				profiles = append(profiles, importedProfile(p, nil))
+			}
+			result.Profiles = append(result.Profiles, p.ID)
+		}
+		if !saving("profiles", s.store.GetProfilesPath()) {
+			return
+		}
+		if err := s.store.SaveProfiles(profiles); err != nil {
+			fail("profiles", err)
+			return
+		}
+	}
+
+	result.Reload, err = s.Reload()
+	if err != nil {
+		// A failed reload keeps the running configuration; put its files back
+		rollback()
+		logger.AddLog("ERROR", fmt.Sprintf("Configuration reload after bundle import failed: %v", err))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if len(result.Profiles) > 0 {
+		s.onboardingRequired = false
+	}
+	events.Record(events.ConfigChanged, "", "", map[string]interface{}{"change": "bundle_imported", "profiles": result.Profiles})
+	logger.AddLog("INFO", fmt.Sprintf("Imported bundle: profiles [%s], %d custom entries, %d overrides",
+		strings.Join(result.Profiles, ", "), result.CustomEntries, result.Overrides))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// snapshotFile returns what restores the file at path to its current
+// content, or removes it if there is none yet.
+func snapshotFile(path string) (func() error, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return func() error {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			return nil
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func() error { return os.WriteFile(path, data, 0644) }, nil
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// compressMinBytes is the smallest response worth compressing.
+const compressMinBytes = 1024
+
+// acceptedEncoding picks gzip or deflate from the request's Accept-Encoding,
+// or "" if the client accepts neither.
+func acceptedEncoding(r *http.Request) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if q := strings.ReplaceAll(params, " ", ""); q == "q=0" || q == "q=0.0" {
+			continue
+		}
+		accepted[strings.ToLower(name)] = true
+	}
+	switch {
+	case accepted["gzip"]:
+		return "gzip"
+	case accepted["deflate"]:
+		return "deflate"
+	}
+	return ""
+}
+
+// compressWriter compresses a response once it is known to be large enough
+// and not a stream. Writes are buffered until compressMinBytes have been
+// written or the handler flushes; event streams and small responses are
+// passed through untouched.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     io.WriteCloser // nil when passing through
+}
+
+// newCompressWriter wraps w if the client accepts a supported encoding. The
+// caller must Close the returned writer once the handler returns.
+func newCompressWriter(w http.ResponseWriter, r *http.Request) *compressWriter {
+	if r.Method == http.MethodHead {
+		return nil
+	}
+	encoding := acceptedEncoding(r)
+	if encoding == "" {
+		return nil
+	}
+	return &compressWriter{ResponseWriter: w, encoding: encoding}
+}
+
+func (c *compressWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if !c.decided {
+		c.buf = append(c.buf, p...)
+		if len(c.buf) < compressMinBytes {
+			return len(p), nil
+		}
+		if err := c.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if c.enc != nil {
+		return c.enc.Write(p)
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// decide sends the headers and buffered body, compressed if large is set and
+// the response can be compressed.
+func (c *compressWriter) decide(large bool) error {
+	c.decided = true
+	h := c.Header()
+	compress := large &&
+		h.Get("Content-Encoding") == "" &&
+		!strings.HasPrefix(h.Get("Content-Type"), "text/event-stream") &&
+		c.status != http.StatusNoContent && c.status != http.StatusNotModified
+	if compress {
+		h.Set("Content-Encoding", c.encoding)
+		h.Add("Vary", "Accept-Encoding")
+		h.Del("Content-Length")
+		if c.encoding == "gzip" {
+			c.enc = gzip.NewWriter(c.ResponseWriter)
+		} else {
+			c.enc = zlib.NewWriter(c.ResponseWriter)
+		}
+	}
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	c.ResponseWriter.WriteHeader(c.status)
+
+	buf := c.buf
+	c.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if c.enc != nil {
+		_, err := c.enc.Write(buf)
+		return err
+	}
+	_, err := c.ResponseWriter.Write(buf)
+	return err
+}
+
+// FlushError sends what has been written so far. A response flushed before
+// it reached compressMinBytes, such as an event stream, is not compressed.
+func (c *compressWriter) FlushError() error {
+	if !c.decided {
+		if err := c.decide(false); err != nil {
+			return err
+		}
+	}
+	if f, ok := c.enc.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+func (c *compressWriter) Flush() {
+	c.FlushError()
+}
+
+// Close finishes the response, sending anything still buffered.
+func (c *compressWriter) Close() error {
+	if !c.decided {
+		if c.status == 0 && len(c.buf) == 0 {
+			// Nothing was written; let net/http send its default response
+			return nil
+		}
+		if err := c.decide(false); err != nil {
+			return err
+		}
+	}
+	if c.enc != nil {
+		return c.enc.Close()
+	}
+	return nil
+}
+
+// discardUnsent drops the status and body buffered so far, reporting
+// whether nothing had been sent yet.
+func (c *compressWriter) discardUnsent() bool {
+	if c.decided {
+		return false
+	}
+	c.status = 0
+	c.buf = nil
+	return true
+}
+
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/mcp-scooter/scooter/internal/i18n"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// Control tokens scope what a caller may do on the control API: each route
+// is registered with the least role it needs (see routes). A request
+// presenting a control token gets the token's role. Without one, requests
+// are admins only while no control token is configured: anyone on this
+// machine could otherwise drop a viewer token to become admin. Scooter
+// Desktop and the CLI of the daemon's owner then present the internal token
+// instead (see SetInternalToken). Tokens with a user are further confined
+// to that user's scope (see Users). Access group keys with control get
+// their group's control_role, viewer or operator. A request without a role
+// reaches no route.
+
+type controlRoleContextKey struct{}
+
+func withControlRole(ctx context.Context, role profile.ControlRole) context.Context {
+	return context.WithValue(ctx, controlRoleContextKey{}, role)
+}
+
+// controlRoleFrom returns the role of a control API request, or none if
+// ServeHTTP didn't set one.
+func controlRoleFrom(ctx context.Context) profile.ControlRole {
+	if role, ok := ctx.Value(controlRoleContextKey{}).(profile.ControlRole); ok {
+		return role
+	}
+	return ""
+}
+
+// controlRole works out the role of a request, and false if it presented an
+// unknown token or none where one is required.
+func (s *ControlServer) controlRole(r *http.Request) (profile.ControlRole, bool) {
+	settings := s.settings.Get()
+	key := requestAPIKey(r)
+	if t, ok := settings.ControlTokenFor(key); ok {
+		return t.Role, true
+	}
+	if len(settings.ControlTokens) == 0 {
+		return profile.RoleAdmin, true
+	}
+	if key == "" && s.isOwner(r) {
+		return profile.RoleAdmin, true
+	}
+	return "", false
+}
+
+// isOwner reports whether r presents the internal token, which only the
+// daemon's owner can read.
+func (s *ControlServer) isOwner(r *http.Request) bool {
+	token := r.Header.Get(InternalTokenHeader)
+	return token != "" && s.internalToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.internalToken)) == 1
+}
+
+// require wraps a handler so that only requests with at least role reach
+// it. Access group keys are refused unless groups is set, for routes that
+// keep to the group's profiles and tools.
+func (s *ControlServer) require(role profile.ControlRole, groups bool, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if group, ok := accessGroupFrom(r.Context()); ok && !groups {
+			logger.AddLog("WARN", fmt.Sprintf("Rejected %s %s from access group '%s': not available to access groups", r.Method, r.URL.Path, group.Name))
+			http.Error(w, i18n.T(s.locale(r), "api.forbidden"), http.StatusForbidden)
+			return
+		}
+		if have := controlRoleFrom(r.Context()); !have.Allows(role) {
+			logger.AddLog("WARN", fmt.Sprintf("Rejected %s %s from %s: needs the %s role, has %s", r.Method, r.URL.Path, r.RemoteAddr, role, have))
+			http.Error(w, i18n.T(s.locale(r), "api.forbidden"), http.StatusForbidden)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// redactProfile masks the profile's secrets (its API key and environment
+// values) for callers that may not read them.
+func redactProfile(p profile.Profile) profile.Profile {
+	if p.APIKey != "" {
+		p.APIKey = maskToken(p.APIKey)
+	}
+	if len(p.Env) > 0 {
+		env := make(map[string]string, len(p.Env))
+		for k := range p.Env {
+			env[k] = "********"
+		}
+		p.Env = env
+	}
+	return p
+}
+
+// controlTokenInfo is a control token as listed: the token itself is only
+// returned when it is created.
+type controlTokenInfo struct {
+	Name      string              `json:"name"`
+	Role      profile.ControlRole `json:"role"`
+	User      string              `json:"user,omitempty"`
+	Token     string              `json:"token"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+func maskToken(token string) string {
+	if len(token) <= 4 {
+		return "****"
+	}
+	return "****" + token[len(token)-4:]
+}
+
+func (s *ControlServer) handleListControlTokens(w http.ResponseWriter, r *http.Request) {
+	tokens := make([]controlTokenInfo, 0)
+	for _, t := range s.settings.Get().ControlTokens {
+		tokens = append(tokens, controlTokenInfo{Name: t.Name, Role: t.Role, User: t.User, Token: maskToken(t.Token), CreatedAt: t.CreatedAt})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"tokens": tokens})
+}
+
+// handleCreateControlToken creates a token with a generated value, returned
+// only in this response. A token for a user only reaches that user's scope.
+func (s *ControlServer) handleCreateControlToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string              `json:"name"`
+		Role profile.ControlRole `json:"role"`
+		User string              `json:"user"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if !req.Role.Valid() {
+		http.Error(w, fmt.Sprintf("unknown role %q: use admin, operator or viewer", req.Role), http.StatusBadRequest)
+		return
+	}
+	if req.User != "" && !profile.ValidUserName(req.User) {
+		http.Error(w, fmt.Sprintf("invalid user %q: use lowercase letters, digits, '-' and '_'", req.User), http.StatusBadRequest)
+		return
+	}
+
+	token := profile.ControlToken{Name: req.Name, Role: req.Role, User: req.User, Token: profile.GenerateAPIKey(), CreatedAt: time.Now().UTC()}
+	exists := false
+	updated := s.settings.Update(func(st *profile.Settings) {
+		if slices.ContainsFunc(st.ControlTokens, func(t profile.ControlToken) bool { return t.Name == token.Name }) {
+			exists = true
+			return
+		}
+		st.ControlTokens = append(slices.Clone(st.ControlTokens), token)
+	})
+	if exists {
+		http.Error(w, fmt.Sprintf("a control token named %q already exists", token.Name), http.StatusConflict)
+		return
+	}
+	if s.store != nil {
+		if err := s.store.SaveSettings(updated); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if token.User != "" {
+		logger.AddLog("INFO", fmt.Sprintf("Created %s control token '%s' for user '%s'", token.Role, token.Name, token.User))
+	} else {
+		logger.AddLog("INFO", fmt.Sprintf("Created %s control token '%s'", token.Role, token.Name))
+	}
+	events.Record(events.ConfigChanged, "", "", map[string]interface{}{"change": "control_token_created", "name": token.Name, "role": token.Role})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(controlTokenInfo{Name: token.Name, Role: token.Role, User: token.User, Token: token.Token, CreatedAt: token.CreatedAt})
+}
+
+func (s *ControlServer) handleDeleteControlToken(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	found := false
+	updated := s.settings.Update(func(st *profile.Settings) {
+		tokens := slices.DeleteFunc(slices.Clone(st.ControlTokens), func(t profile.ControlToken) bool { return t.Name == name })
+		found = len(tokens) != len(st.ControlTokens)
+		st.ControlTokens = tokens
+	})
+	if !found {
+		http.Error(w, fmt.Sprintf("no control token named %q", name), http.StatusNotFound)
+		return
+	}
+	if s.store != nil {
+		if err := s.store.SaveSettings(updated); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	logger.AddLog("INFO", fmt.Sprintf("Deleted control token '%s'", name))
+	events.Record(events.ConfigChanged, "", "", map[string]interface{}{"change": "control_token_deleted", "name": name})
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// Both servers answer browsers according to the settings' cors policy.
+// Requests from a page whose origin isn't allowed are refused before they
+// reach a handler: CORS alone only hides the response, and a simple POST
+// would still run. Requests without an Origin header don't come from a web
+// page and pass, as do same-origin ones to a loopback host name. Other
+// same-origin requests go through the allow-list: a DNS-rebinding page on
+// a name resolving to 127.0.0.1 sends a Host matching its Origin.
+//
+// Its same-origin GETs may send no Origin at all, though, so every request
+// must also name this machine in its Host header (see allowedHost).
+
+const (
+	controlCORSMethods = "GET, POST, PUT, DELETE, OPTIONS"
+	gatewayCORSMethods = "GET, POST, OPTIONS"
+)
+
+var corsHeaders = strings.Join([]string{
+	"Content-Type", "Authorization", "X-Scooter-API-Key", InternalTokenHeader,
+	"If-Match", "Accept-Language", "Mcp-Session-Id", "Last-Event-ID",
+}, ", ")
+
+// applyCORS sets the CORS headers of r's response, reporting whether the
+// handlers should serve it. Preflights and refused requests are answered
+// here.
+func applyCORS(w http.ResponseWriter, r *http.Request, policy profile.CORSSettings, methods string) bool {
+	h := w.Header()
+	if !strings.Contains(h.Get("Vary"), "Origin") {
+		h.Add("Vary", "Origin")
+	}
+	origin := r.Header.Get("Origin")
+	if origin != "" && !sameOrigin(r, origin) {
+		if !policy.AllowsOrigin(origin) {
+			logger.AddLog("WARN", fmt.Sprintf("Rejected %s %s from origin %s: not in the cors allowed_origins", r.Method, r.URL.Path, origin))
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return false
+		}
+		h.Set("Access-Control-Allow-Origin", origin)
+		if policy.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+
+	if r.Method == http.MethodOptions {
+		if origin != "" && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", methods)
+			h.Set("Access-Control-Allow-Headers", corsHeaders)
+			h.Set("Access-Control-Max-Age", strconv.Itoa(policy.MaxAge()))
+		}
+		w.WriteHeader(http.StatusOK)
+		return false
+	}
+	return true
+}
+
+// sameOrigin reports whether origin is the server r was sent to, under a
+// loopback name or address.
+func sameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host) && isLoopbackHost(r.Host)
+}
+
+// isLoopbackHost reports whether a Host header names this machine:
+// localhost, a subdomain of it, or a loopback address.
+func isLoopbackHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(host, "[]")), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// checkHost refuses a request whose Host header doesn't name this machine,
+// reporting whether it may be served.
+func checkHost(w http.ResponseWriter, r *http.Request, settings profile.Settings, profiles []profile.Profile) bool {
+	if allowedHost(r.Host, settings.AllowedHosts, profiles) {
+		return true
+	}
+	logger.AddLog("WARN", fmt.Sprintf("Rejected %s %s for host %s: add it to allowed_hosts if it names this machine", r.Method, r.URL.Path, r.Host))
+	http.Error(w, "host not allowed", http.StatusForbidden)
+	return false
+}
+
+// allowedHost reports whether a Host header names this machine: a loopback
+// name, an IP address, one of hosts or a profile's host name. A page's
+// requests after a DNS rebind carry its own host name; addresses can't be
+// rebound, so any of them will do.
+func allowedHost(hostport string, hosts []string, profiles []profile.Profile) bool {
+	if hostport == "" || isLoopbackHost(hostport) {
+		return true
+	}
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if net.ParseIP(host) != nil {
+		return true
+	}
+	match := func(name string) bool { return strings.EqualFold(strings.TrimSuffix(name, "."), host) }
+	if slices.ContainsFunc(hosts, match) {
+		return true
+	}
+	for _, p := range profiles {
+		if slices.ContainsFunc(p.Hosts, match) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// Custom tools are registry entries kept in registry/custom, or with
+// ?profile=<id> in that profile's overlay, registry/custom/<id>, which only
+// its engine loads. Each file has a version, returned as the ETag of GET
+// /api/tools/{name}/entry and of every write; sending it back as If-Match
+// makes an update or delete fail with 412 if someone else changed the entry
+// in between.
+
+// overlayParam returns the profile overlay ?profile names, "" for the
+// shared registry. It writes an error and returns false if the profile
+// doesn't exist.
+func (s *ControlServer) overlayParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := r.URL.Query().Get("profile")
+	if id == "" {
+		return "", true
+	}
+	if _, ok := s.manager.GetProfile(id); !ok {
+		http.Error(w, fmt.Sprintf("Profile '%s' not found", id), http.StatusNotFound)
+		return "", false
+	}
+	return id, true
+}
+
+// handleRegisterTool validates a registry entry and saves it to the custom
+// registry, replacing an entry of the same name.
+func (s *ControlServer) handleRegisterTool(w http.ResponseWriter, r *http.Request) {
+	var entry registry.MCPEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.saveCustomTool(w, r, &entry, http.StatusCreated)
+}
+
+// handleUpdateTool replaces the custom entry {name}, if it still has the
+// version in If-Match when one is sent.
+func (s *ControlServer) handleUpdateTool(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	var entry registry.MCPEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if entry.Name == "" {
+		entry.Name = name
+	}
+	if entry.Name != name {
+		http.Error(w, fmt.Sprintf("the entry is named %q, not %q", entry.Name, name), http.StatusBadRequest)
+		return
+	}
+	s.saveCustomTool(w, r, &entry, http.StatusOK)
+}
+
+// saveCustomTool validates and writes entry, then reloads the registry of
+// every engine so the change applies at the next activation.
+func (s *ControlServer) saveCustomTool(w http.ResponseWriter, r *http.Request, entry *registry.MCPEntry, status int) {
+	overlay, ok := s.overlayParam(w, r)
+	if !ok {
+		return
+	}
+	td := discovery.DefinitionFromEntry(entry, "custom")
+	td.Overlay = overlay
+
+	// Without a registry directory, tools are only registered in memory
+	if s.manager.registryDir == "" {
+		if overlay != "" {
+			http.Error(w, "Profile overlays need a registry directory", http.StatusBadRequest)
+			return
+		}
+		result := registry.ValidateCustom(entry)
+		if !result.Valid {
+			writeInvalidEntry(w, result)
+			return
+		}
+		s.manager.setCustomTool(td)
+		logger.AddLog("INFO", fmt.Sprintf("Registered tool: %s", td.Name))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(td)
+		return
+	}
+
+	version, result, err := discovery.WriteCustomEntry(s.manager.registryDir, overlay, entry, r.Header.Get("If-Match"))
+	switch {
+	case errors.Is(err, discovery.ErrInvalidEntry):
+		writeInvalidEntry(w, result)
+		return
+	case errors.Is(err, discovery.ErrEntryChanged):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	case err != nil:
+		http.Error(w, fmt.Sprintf("Failed to save tool file: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if overlay == "" {
+		s.manager.removeCustomTool(td.Name)
+	}
+	s.manager.ReloadRegistries()
+	logger.AddLog("INFO", fmt.Sprintf("Registered and persisted tool: %s%s", td.Name, overlayTag(overlay)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", version)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(td)
+}
+
+// writeInvalidEntry reports the field errors of an entry that failed validation.
+func writeInvalidEntry(w http.ResponseWriter, result *registry.ValidationResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":    "registry entry is not valid",
+		"errors":   result.Errors,
+		"warnings": result.Warnings,
+	})
+}
+
+// overlayTag describes an overlay in log messages.
+func overlayTag(overlay string) string {
+	if overlay == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (profile %s)", overlay)
+}
+
+// handleGetToolEntry returns the custom entry {name} as stored, with its
+// version as the ETag.
+func (s *ControlServer) handleGetToolEntry(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	overlay, ok := s.overlayParam(w, r)
+	if !ok {
+		return
+	}
+	if s.manager.registryDir == "" || !registry.ValidName(name) {
+		http.Error(w, "Tool not found", http.StatusNotFound)
+		return
+	}
+	entry, version, err := discovery.ReadCustomEntry(s.manager.registryDir, overlay, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, "Tool not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", version)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// handleDeleteTool removes the custom entry ?name, if it still has the
+// version in If-Match when one is sent.
+func (s *ControlServer) handleDeleteTool(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if !registry.ValidName(name) {
+		http.Error(w, fmt.Sprintf("invalid tool name %q", name), http.StatusBadRequest)
+		return
+	}
+	overlay, ok := s.overlayParam(w, r)
+	if !ok {
+		return
+	}
+
+	// Remove from custom registry folder
+	if s.manager.registryDir != "" {
+		err := discovery.RemoveCustomEntry(s.manager.registryDir, overlay, name, r.Header.Get("If-Match"))
+		if errors.Is(err, discovery.ErrEntryChanged) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to delete tool file: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	if overlay == "" {
+		s.manager.removeCustomTool(name)
+	}
+	s.manager.ReloadRegistries()
+
+	logger.AddLog("INFO", fmt.Sprintf("Deleted tool: %s%s", name, overlayTag(overlay)))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ProfileCatalog returns the catalog as a profile's engine sees it: the
+// entries of the profile's registry overlay replace shared ones of the same
+// name, and custom tools registered at runtime still win over both.
+func (pm *ProfileManager) ProfileCatalog(id string) []discovery.ToolDefinition {
+	tools := pm.Catalog()
+	if pm.registryDir == "" {
+		return tools
+	}
+	pm.mu.RLock()
+	runtime := make(map[string]bool, len(pm.customTools))
+	for _, td := range pm.customTools {
+		runtime[td.Name] = true
+	}
+	pm.mu.RUnlock()
+
+	index := make(map[string]int, len(tools))
+	for i, td := range tools {
+		index[td.Name] = i
+	}
+	for _, td := range discovery.LoadOverlay(pm.registryDir, id) {
+		if runtime[td.Name] {
+			continue
+		}
+		if stats, ok := pm.metrics.Stats(td.Name); ok {
+			td.Activation = &stats
+		}
+		if i, ok := index[td.Name]; ok {
+			tools[i] = td
+			continue
+		}
+		index[td.Name] = len(tools)
+		tools = append(tools, td)
+	}
+	return tools
+}
+
+// setCustomTool registers td in memory, replacing a tool of the same name.
+func (pm *ProfileManager) setCustomTool(td discovery.ToolDefinition) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	for i, existing := range pm.customTools {
+		if existing.Name == td.Name {
+			pm.customTools[i] = td
+			return
+		}
+	}
+	pm.customTools = append(pm.customTools, td)
+}
+
+// removeCustomTool drops the in-memory tool name, if there is one.
+func (pm *ProfileManager) removeCustomTool(name string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	for i, existing := range pm.customTools {
+		if existing.Name == name {
+			pm.customTools = append(pm.customTools[:i], pm.customTools[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/mcp-scooter/scooter/internal/events"
+)
+
+// eventQuery holds the options of GET /api/events and its stream.
+type eventQuery struct {
+	Since   int64           // since=<last event ID seen>
+	Limit   int             // limit=<n>, 0 means no limit
+	Types   map[string]bool // type=server.activated,... (empty means all)
+	Profile string          // profile=<id>
+}
+
+func parseEventQuery(q url.Values) (eventQuery, error) {
+	var eq eventQuery
+	if v := q.Get("since"); v != "" {
+		since, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || since < 0 {
+			return eq, fmt.Errorf("invalid since %q", v)
+		}
+		eq.Since = since
+	}
+	var err error
+	if eq.Limit, err = parseNonNegative(q, "limit"); err != nil {
+		return eq, err
+	}
+	eq.Types = map[string]bool{}
+	for _, t := range splitList(q.Get("type")) {
+		eq.Types[t] = true
+	}
+	eq.Profile = q.Get("profile")
+	return eq, nil
+}
+
+// match reports whether ev passes the type and profile filters.
+func (eq eventQuery) match(ev events.Event) bool {
+	if len(eq.Types) > 0 && !eq.Types[ev.Type] {
+		return false
+	}
+	return eq.Profile == "" || ev.Profile == eq.Profile
+}
+
+// visibleEvent reports whether the caller may see ev. Access groups limited
+// to some profiles only see those profiles' events.
+func visibleEvent(r *http.Request, ev events.Event) bool {
+	group, restricted := accessGroupFrom(r.Context())
+	return !restricted || len(group.Profiles) == 0 || (ev.Profile != "" && group.AllowsProfile(ev.Profile))
+}
+
+// handleGetEvents returns journal events after the since cursor. Clients
+// pass the returned last_id as since to fetch only newer events; truncated
+// is set when events after since were already dropped from the journal.
+func (s *ControlServer) handleGetEvents(w http.ResponseWriter, r *http.Request) {
+	query, err := parseEventQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	all, truncated := events.Default.Since(query.Since, 0)
+	matched := []events.Event{}
+	lastID := query.Since
+	for _, ev := range all {
+		if query.Limit > 0 && len(matched) >= query.Limit {
+			break
+		}
+		lastID = ev.ID
+		if query.match(ev) && visibleEvent(r, ev) {
+			matched = append(matched, ev)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"events":    matched,
+		"last_id":   lastID,
+		"truncated": truncated,
+	})
+}
+
+// handleEventStream streams journal events over SSE. It first replays the
+// events after since (or the Last-Event-ID header of a reconnecting
+// client), then sends new ones as they are recorded.
+func (s *ControlServer) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if id := r.Header.Get("Last-Event-ID"); id != "" && q.Get("since") == "" {
+		q.Set("since", id)
+	}
+	query, err := parseEventQuery(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	streamWithoutDeadline(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before replaying so nothing recorded in between is missed
+	ch := events.Default.Subscribe()
+	defer events.Default.Unsubscribe(ch)
+
+	lastID := query.Since
+	send := func(ev events.Event) {
+		if ev.ID <= lastID {
+			return
+		}
+		lastID = ev.ID
+		if !query.match(ev) || !visibleEvent(r, ev) {
+			return
+		}
+		data, _ := json.Marshal(ev)
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+	}
+
+	fmt.Fprintf(w, "event: connected\ndata: {\"status\": \"ok\"}\n\n")
+	if query.Since > 0 || r.Header.Get("Last-Event-ID") != "" {
+		replay, _ := events.Default.Since(query.Since, 0)
+		for _, ev := range replay {
+			send(ev)
+		}
+	} else {
+		lastID = events.Default.LastID()
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case ev := <-ch:
+			send(ev)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// artifactGCInterval is how often RunArtifactGC collects.
+const artifactGCInterval = 24 * time.Hour
+
+// CollectArtifacts removes what the app directory keeps for things that no
+// longer exist: WASM modules and binary packages no catalog entry uses (or
+// an older version of it), incomplete downloads, and the homes and
+// workspaces of deleted profiles. Homes of servers that aren't running are
+// left over by crashes, as a stopped server's home is deleted, so they go
+// too. Nothing modified within gc.Retention is removed.
+func (pm *ProfileManager) CollectArtifacts(gc discovery.GC) discovery.GCReport {
+	known := map[string]discovery.ToolDefinition{}
+	for _, td := range pm.Catalog() {
+		known[td.Name] = td
+	}
+	// Packages of profile overlays' entries are kept too
+	if pm.registryDir != "" {
+		for _, p := range pm.GetProfiles() {
+			for _, td := range discovery.LoadOverlay(pm.registryDir, p.ID) {
+				if _, ok := known[td.Name]; !ok {
+					known[td.Name] = td
+				}
+			}
+		}
+	}
+
+	pm.mu.RLock()
+	wasmDir, homeRoot, workspaceRoot, packageDir := pm.wasmDir, pm.homeRoot, pm.workspaceRoot, pm.packageDir
+	engines := make(map[string]*discovery.DiscoveryEngine, len(pm.engines))
+	for _, p := range pm.profiles {
+		engines[p.ID] = pm.engines[p.ID]
+	}
+	pm.mu.RUnlock()
+
+	report := discovery.GCReport{Removed: []discovery.Artifact{}}
+	if wasmDir != "" {
+		gc.Collect(wasmDir, func(d fs.DirEntry) string {
+			if strings.HasSuffix(d.Name(), ".wasm.part") {
+				return "incomplete download"
+			}
+			name, isWASM := strings.CutSuffix(d.Name(), ".wasm")
+			if _, ok := known[name]; d.IsDir() || !isWASM || ok {
+				return ""
+			}
+			return "no registry entry"
+		}, &report)
+	}
+	if packageDir != "" {
+		gc.Collect(packageDir, func(d fs.DirEntry) string {
+			if td, ok := known[d.Name()]; ok && td.Package != nil && td.Package.Type == registry.PackageBinary {
+				return ""
+			}
+			return "no registry entry"
+		}, &report)
+		for name, td := range known {
+			if td.Package == nil || td.Package.Type != registry.PackageBinary {
+				continue
+			}
+			current := discovery.PackageVersionDir(td.Package)
+			gc.Collect(filepath.Join(packageDir, name), func(d fs.DirEntry) string {
+				if d.Name() == current {
+					return ""
+				}
+				return "superseded by " + current
+			}, &report)
+		}
+	}
+	if homeRoot != "" {
+		gc.Collect(homeRoot, func(d fs.DirEntry) string {
+			if _, ok := engines[d.Name()]; !ok {
+				return "profile deleted"
+			}
+			return ""
+		}, &report)
+		for id, engine := range engines {
+			gc.Collect(filepath.Join(homeRoot, id), func(d fs.DirEntry) string {
+				if engine != nil && engine.IsActive(d.Name()) {
+					return ""
+				}
+				return "server not running"
+			}, &report)
+		}
+	}
+	if workspaceRoot != "" {
+		gc.Collect(workspaceRoot, func(d fs.DirEntry) string {
+			if _, ok := engines[d.Name()]; !ok {
+				return "profile deleted"
+			}
+			return ""
+		}, &report)
+	}
+	return report
+}
+
+// RunArtifactGC collects orphaned artifacts now and then daily, keeping
+// them for the settings' ArtifactRetentionHours, until ctx is done.
+func (pm *ProfileManager) RunArtifactGC(ctx context.Context, settings *profile.SettingsProvider) {
+	ticker := time.NewTicker(artifactGCInterval)
+	defer ticker.Stop()
+	for {
+		if hours := settings.Get().ArtifactRetentionHours; hours > 0 {
+			report := pm.CollectArtifacts(discovery.GC{Retention: time.Duration(hours) * time.Hour, Now: time.Now()})
+			logArtifactGC(report)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func logArtifactGC(report discovery.GCReport) {
+	for _, e := range report.Errors {
+		logger.AddLog("WARN", fmt.Sprintf("[GC] %s", e))
+	}
+	if len(report.Removed) > 0 && !report.DryRun {
+		logger.AddLog("INFO", fmt.Sprintf("[GC] Removed %d orphaned artifact(s), reclaiming %d bytes", len(report.Removed), report.ReclaimedBytes))
+	}
+}
+
+// handleGC collects orphaned artifacts now. ?retention_hours overrides the
+// settings' retention (0 removes every orphan) and ?dry_run=true only
+// reports what would be removed.
+func (s *ControlServer) handleGC(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	hours := s.settings.Get().ArtifactRetentionHours
+	if q.Get("retention_hours") != "" {
+		var err error
+		if hours, err = parseNonNegative(q, "retention_hours"); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	var dryRun bool
+	if v := q.Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, fmt.Sprintf("invalid dry_run %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	report := s.manager.CollectArtifacts(discovery.GC{
+		Retention: time.Duration(hours) * time.Hour,
+		DryRun:    dryRun,
+		Now:       time.Now(),
+	})
+	logArtifactGC(report)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/i18n"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// scooter_history is answered by the gateway rather than the engine: the
+// calls it reports belong to one SSE session, which the engine knows nothing
+// about.
+
+const (
+	// maxCallHistory is how many calls a session remembers.
+	maxCallHistory = 50
+	// defaultHistoryLimit is how many calls scooter_history returns by default.
+	defaultHistoryLimit = 10
+	// maxHistoryArgs bounds the recorded arguments of each call.
+	maxHistoryArgs = 200
+)
+
+// callRecord is one tool call as reported by scooter_history.
+type callRecord struct {
+	Tool       string    `json:"tool"`
+	Arguments  string    `json:"arguments,omitempty"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// newCallRecord describes a finished call, truncating its arguments.
+func newCallRecord(tool string, args map[string]interface{}, started time.Time, err error) callRecord {
+	rec := callRecord{
+		Tool:       tool,
+		Success:    err == nil,
+		Timestamp:  started.UTC(),
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	if len(args) > 0 {
+		if b, jsonErr := json.Marshal(args); jsonErr == nil {
+			rec.Arguments = logger.TruncateForLog(string(b), maxHistoryArgs)
+		}
+	}
+	if err != nil {
+		rec.Error = logger.TruncateForLog(err.Error(), maxHistoryArgs)
+	}
+	return rec
+}
+
+// recordCall remembers a tool call, dropping the oldest beyond maxCallHistory.
+func (s *gatewaySession) recordCall(rec callRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.history) == maxCallHistory {
+		copy(s.history, s.history[1:])
+		s.history = s.history[:maxCallHistory-1]
+	}
+	s.history = append(s.history, rec)
+}
+
+// recentCalls returns up to n of the session's latest calls, newest first.
+func (s *gatewaySession) recentCalls(n int) []callRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n > len(s.history) {
+		n = len(s.history)
+	}
+	calls := make([]callRecord, 0, n)
+	for i := len(s.history) - 1; i >= len(s.history)-n; i-- {
+		calls = append(calls, s.history[i])
+	}
+	return calls
+}
+
+// handleHistoryTool implements scooter_history for the session the request
+// was posted to.
+func (g *McpGateway) handleHistoryTool(locale string, p profile.Profile, sess *gatewaySession, args map[string]interface{}) (interface{}, error) {
+	if !p.CallHistory {
+		return nil, errors.New(i18n.T(locale, "builtin.history.disabled"))
+	}
+	if sess == nil {
+		return nil, errors.New(i18n.T(locale, "builtin.history.no_session"))
+	}
+
+	limit := defaultHistoryLimit
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+	if limit > maxCallHistory {
+		limit = maxCallHistory
+	}
+	return map[string]interface{}{
+		"session": sess.id,
+		"calls":   sess.recentCalls(limit),
+	}, nil
+}
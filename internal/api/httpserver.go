@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+)
+
+// NewHTTPServer returns a server for handler configured from cfg. The write
+// timeout is applied per request rather than on the server, so that SSE
+// handlers can lift it for their long-lived streams.
+func NewHTTPServer(addr string, handler http.Handler, cfg profile.HTTPServerSettings) *http.Server {
+	seconds := func(n int) time.Duration { return time.Duration(n) * time.Second }
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           withWriteTimeout(handler, seconds(cfg.WriteTimeoutSeconds)),
+		ReadHeaderTimeout: seconds(cfg.ReadHeaderTimeoutSeconds),
+		ReadTimeout:       seconds(cfg.ReadTimeoutSeconds),
+		IdleTimeout:       seconds(cfg.IdleTimeoutSeconds),
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+	if cfg.TLSCertFile != "" {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+	}
+	return srv
+}
+
+// ListenAndServe serves srv until it is shut down, over TLS if cfg has a
+// certificate, accepting at most cfg.MaxConnections connections at a time.
+func ListenAndServe(srv *http.Server, cfg profile.HTTPServerSettings) error {
+	ln, err := Listen(srv, cfg)
+	if err != nil {
+		return err
+	}
+	return Serve(srv, ln, cfg)
+}
+
+// Listen opens srv's listening socket, limited to cfg.MaxConnections. Use it
+// with Serve to learn the port before serving when srv.Addr asks for any
+// free port (":0").
+func Listen(srv *http.Server, cfg profile.HTTPServerSettings) (net.Listener, error) {
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxConnections > 0 {
+		ln = &limitListener{Listener: ln, sem: make(chan struct{}, cfg.MaxConnections)}
+	}
+	return ln, nil
+}
+
+// Serve serves srv on ln, over TLS if cfg has a certificate.
+func Serve(srv *http.Server, ln net.Listener, cfg profile.HTTPServerSettings) error {
+	if cfg.TLSCertFile != "" {
+		return srv.ServeTLS(ln, cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+	return srv.Serve(ln)
+}
+
+// withWriteTimeout sets a write deadline on every response.
+func withWriteTimeout(next http.Handler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// streamWithoutDeadline lifts the read and write timeouts for an event
+// stream, which stays open far longer than any ordinary request.
+func streamWithoutDeadline(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+}
+
+// limitListener blocks Accept while sem is full.
+type limitListener struct {
+	net.Listener
+	sem chan struct{}
+}
+
+func (l *limitListener) Accept() (net.Conn, error) {
+	l.sem <- struct{}{}
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		<-l.sem
+		return nil, err
+	}
+	return &limitConn{Conn: conn, release: func() { <-l.sem }}, nil
+}
+
+type limitConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
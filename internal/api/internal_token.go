@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+)
+
+// Scooter Desktop's tool tester calls tools without an API key, activating
+// servers on demand and skipping AllowTools and rate limits. It proves it is
+// the desktop app with a token the daemon generates at each start and writes
+// to InternalTokenFile in its config directory, readable only by the user.
+// Requests presenting a wrong token, or the old X-Scooter-Internal: true
+// header, are logged and refused. The same token makes the app, and the CLI
+// run by the same user, an admin of the control API once control tokens
+// exist.
+
+const (
+	// InternalTokenHeader carries the internal token.
+	InternalTokenHeader = "X-Scooter-Internal-Token"
+	// InternalTokenFile is the file in the config directory holding the
+	// token while the daemon runs.
+	InternalTokenFile = "internal-token"
+
+	legacyInternalHeader = "X-Scooter-Internal"
+)
+
+type internalContextKey struct{}
+
+// isInternalRequest reports whether the request came from Scooter Desktop
+// with the internal token.
+func isInternalRequest(ctx context.Context) bool {
+	internal, _ := ctx.Value(internalContextKey{}).(bool)
+	return internal
+}
+
+// WriteInternalToken generates this run's internal token and writes it to
+// dir, replacing the previous run's.
+func WriteInternalToken(dir string) (string, error) {
+	token := profile.GenerateAPIKey()
+	path := filepath.Join(dir, InternalTokenFile)
+	// A new file, so an old one's permissions aren't kept
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(token); err != nil {
+		f.Close()
+		return "", err
+	}
+	return token, f.Close()
+}
+
+// RemoveInternalToken deletes the internal token file of dir.
+func RemoveInternalToken(dir string) {
+	os.Remove(filepath.Join(dir, InternalTokenFile))
+}
+
+// SetInternalToken sets the token that marks requests from Scooter Desktop.
+// Without one, no request is internal.
+func (g *McpGateway) SetInternalToken(token string) {
+	g.internalToken = token
+}
+
+// SetInternalToken sets the token with which Scooter Desktop reaches the
+// control API as the daemon's owner. Without one, requests need a control
+// token once any exists.
+func (s *ControlServer) SetInternalToken(token string) {
+	s.internalToken = token
+}
+
+// checkInternal reports whether r presents the internal token, or why it
+// is a spoofed internal request.
+func (g *McpGateway) checkInternal(r *http.Request) (internal bool, spoofed string) {
+	if r.Header.Get(legacyInternalHeader) != "" {
+		return false, "the " + legacyInternalHeader + " header is no longer accepted"
+	}
+	token := r.Header.Get(InternalTokenHeader)
+	if token == "" {
+		return false, ""
+	}
+	if g.internalToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(g.internalToken)) != 1 {
+		return false, "wrong internal token"
+	}
+	return true, ""
+}
+
+// stripInternalHeaders removes the internal token from requests that must
+// not skip authentication, such as those of users and tunnels.
+func stripInternalHeaders(r *http.Request) {
+	r.Header.Del(InternalTokenHeader)
+	r.Header.Del(legacyInternalHeader)
+}
+
+// rejectSpoofedInternal logs a request that claimed to be internal.
+func (g *McpGateway) rejectSpoofedInternal(w http.ResponseWriter, r *http.Request, reason string) {
+	g.rejectAuth(r, "spoofed internal request: "+reason)
+	http.Error(w, "invalid internal request: "+reason, http.StatusUnauthorized)
+}
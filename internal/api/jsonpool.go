@@ -0,0 +1,72 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// maxPooledBuffer is the largest buffer returned to the pool; a rare huge
+// request shouldn't pin its memory for the life of the process.
+const maxPooledBuffer = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		bufferPool.Put(buf)
+	}
+}
+
+// readBody reads r into a pooled buffer. The returned bytes are only valid
+// until the buffer is put back.
+func readBody(r io.Reader) (*bytes.Buffer, error) {
+	buf := getBuffer()
+	if _, err := buf.ReadFrom(r); err != nil {
+		putBuffer(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+// wireRequest is a JSON-RPC request as received, keeping the id raw.
+type wireRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// decodeRequest parses a JSON-RPC request in one pass. The id is returned
+// exactly as sent (see RequestID), or nil for a notification. Params are
+// copied, so the request outlives body.
+func decodeRequest(body []byte) (JSONRPCRequest, RequestID, error) {
+	var wire wireRequest
+	if err := json.Unmarshal(body, &wire); err != nil {
+		return JSONRPCRequest{}, nil, err
+	}
+	req := JSONRPCRequest{JSONRPC: wire.JSONRPC, Method: wire.Method, Params: wire.Params}
+	if len(wire.ID) == 0 {
+		return req, nil, nil
+	}
+	return req, RequestID(bytes.TrimSpace(wire.ID)), nil
+}
+
+// encodeJSON marshals v into buf like json.Marshal, without the trailing
+// newline json.Encoder adds.
+func encodeJSON(buf *bytes.Buffer, v interface{}) error {
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
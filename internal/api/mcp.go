@@ -1,6 +1,8 @@
 package api
 
 import (
+	"encoding/json"
+
 	"github.com/mcp-scooter/scooter/internal/domain/registry"
 )
 
@@ -18,6 +20,35 @@ const (
 	InternalError  = registry.InternalError
 )
 
+// RequestID is a JSON-RPC id exactly as the client sent it. Keeping the raw
+// bytes means string ids stay strings, 0 and null survive, and large numbers
+// aren't rounded through float64 on their way back.
+type RequestID json.RawMessage
+
+// Valid reports whether the id is a string, a number or null, the only
+// types JSON-RPC 2.0 allows.
+func (id RequestID) Valid() bool {
+	if len(id) == 0 {
+		return false
+	}
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
+
+func (id RequestID) MarshalJSON() ([]byte, error) {
+	if len(id) == 0 {
+		return []byte("null"), nil
+	}
+	return id, nil
+}
+
+func (id RequestID) String() string {
+	return string(id)
+}
+
 // NewJSONRPCResponse creates a success response.
 func NewJSONRPCResponse(id interface{}, result interface{}) JSONRPCResponse {
 	return JSONRPCResponse{
@@ -38,3 +69,26 @@ func NewJSONRPCErrorResponse(id interface{}, code int, message string) JSONRPCRe
 		},
 	}
 }
+
+// appendTextContent adds a text block to the content of an MCP tool result.
+func appendTextContent(result map[string]interface{}, text string) {
+	block := map[string]interface{}{"type": "text", "text": text}
+	switch content := result["content"].(type) {
+	case []interface{}:
+		result["content"] = append(content, block)
+	case []map[string]interface{}:
+		result["content"] = append(content, block)
+	case nil:
+		result["content"] = []interface{}{block}
+	}
+}
+
+// setResultMeta sets a key in a result's _meta object, creating it if needed.
+func setResultMeta(result map[string]interface{}, key string, value interface{}) {
+	meta, ok := result["_meta"].(map[string]interface{})
+	if !ok {
+		meta = map[string]interface{}{}
+		result["_meta"] = meta
+	}
+	meta[key] = value
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/mcp-scooter/scooter/internal/metrics"
+)
+
+// handleMetrics serves the counters of the metrics package, and gauges of
+// the running profiles, for Prometheus to scrape.
+func (s *ControlServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	metrics.Default.WriteText(w)
+
+	var active []metrics.Sample
+	for _, p := range s.manager.GetProfiles() {
+		if engine, ok := s.manager.GetEngine(p.ID); ok {
+			active = append(active, metrics.Sample{LabelValues: []string{p.ID}, Value: float64(len(engine.ListActive()))})
+		}
+	}
+	metrics.WriteGauge(w, "scooter_active_servers", "Servers running per profile.", []string{"profile"}, active)
+
+	clients := map[string]int{}
+	if s.gateway != nil {
+		for _, sess := range s.gateway.Sessions() {
+			clients[sess.Profile]++
+		}
+	}
+	var sse []metrics.Sample
+	for id, n := range clients {
+		sse = append(sse, metrics.Sample{LabelValues: []string{id}, Value: float64(n)})
+	}
+	metrics.WriteGauge(w, "scooter_sse_clients", "Connected SSE clients per profile.", []string{"profile"}, sse)
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/integration"
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// oauthCallbackPath is where providers send the browser back to. It needs no
+// control token: the flow's state, known only to whoever started it,
+// authenticates the request.
+const oauthCallbackPath = "/api/credentials/oauth/callback"
+
+// oauthFlowTTL is how long a started flow waits for its callback.
+const oauthFlowTTL = 10 * time.Minute
+
+// oauthExchangeTimeout bounds trading the code for a token.
+const oauthExchangeTimeout = 30 * time.Second
+
+// oauthFlow is a login started with /api/credentials/oauth/start.
+type oauthFlow struct {
+	tool         string
+	cfg          *registry.OAuthConfig
+	handler      *integration.OAuthHandler
+	credentials  *integration.CredentialManager
+	clientID     string
+	clientSecret string
+	verifier     string
+	expires      time.Time
+}
+
+// oauthFlows holds the flows waiting for their callback by state. It is
+// shared by every control server, users' included, since callbacks all
+// arrive at the owner's.
+type oauthFlows struct {
+	mu    sync.Mutex
+	flows map[string]*oauthFlow
+}
+
+var pendingOAuth = &oauthFlows{flows: map[string]*oauthFlow{}}
+
+func (f *oauthFlows) add(state string, flow *oauthFlow) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	for s, old := range f.flows {
+		if now.After(old.expires) {
+			delete(f.flows, s)
+		}
+	}
+	f.flows[state] = flow
+}
+
+// take removes and returns the flow of state, if it hasn't expired.
+func (f *oauthFlows) take(state string) (*oauthFlow, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	flow, ok := f.flows[state]
+	delete(f.flows, state)
+	if !ok || time.Now().After(flow.expires) {
+		return nil, false
+	}
+	return flow, true
+}
+
+// handleStartOAuth starts the PKCE flow of a registry entry's OAuth config
+// and returns the URL the user logs in at. The provider then sends the
+// browser to the callback, which stores the token in the keychain under
+// the tool name (in ?profile's and ?identity's namespace, as for other
+// credentials).
+func (s *ControlServer) handleStartOAuth(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ToolName     string `json:"tool_name"`
+		Profile      string `json:"profile,omitempty"`
+		Identity     string `json:"identity,omitempty"`
+		ClientID     string `json:"client_id,omitempty"`
+		ClientSecret string `json:"client_secret,omitempty"`
+		RedirectURI  string `json:"redirect_uri,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ToolName == "" {
+		http.Error(w, "tool_name is required", http.StatusBadRequest)
+		return
+	}
+
+	toolDef, found := s.manager.FindTool(req.ToolName)
+	if !found {
+		http.Error(w, "Tool not found", http.StatusNotFound)
+		return
+	}
+	if toolDef.Authorization == nil || toolDef.Authorization.OAuth == nil {
+		http.Error(w, fmt.Sprintf("%s has no OAuth configuration", req.ToolName), http.StatusBadRequest)
+		return
+	}
+	cfg := toolDef.Authorization.OAuth
+	if cfg.AuthorizationURL == "" || cfg.TokenURL == "" || cfg.TokenEnv == "" {
+		http.Error(w, fmt.Sprintf("the OAuth configuration of %s needs authorization_url, token_url and token_env", req.ToolName), http.StatusBadRequest)
+		return
+	}
+
+	credManager, ok := s.manager.ToolCredentials(req.Profile, req.ToolName, req.Identity)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Profile not found: %s", req.Profile), http.StatusNotFound)
+		return
+	}
+	clientID, clientSecret := credManager.OAuthClient(req.ToolName, cfg)
+	if req.ClientID != "" {
+		clientID, clientSecret = req.ClientID, req.ClientSecret
+	}
+	if clientID == "" {
+		http.Error(w, fmt.Sprintf("no OAuth client ID for %s: pass client_id or store %s", req.ToolName, cfg.ClientIDEnv), http.StatusBadRequest)
+		return
+	}
+
+	redirect := req.RedirectURI
+	if redirect == "" {
+		redirect = fmt.Sprintf("http://127.0.0.1:%d%s", s.settings.Get().ControlPort, oauthCallbackPath)
+	}
+	verifier, challenge, err := integration.GeneratePKCE()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	state := hex.EncodeToString(b)
+
+	flow := &oauthFlow{
+		tool:         req.ToolName,
+		cfg:          cfg,
+		handler:      integration.NewOAuthHandler(cfg, clientID, clientSecret, redirect),
+		credentials:  credManager,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		verifier:     verifier,
+		expires:      time.Now().Add(oauthFlowTTL),
+	}
+	pendingOAuth.add(state, flow)
+	logger.AddLog("INFO", fmt.Sprintf("[OAuth] Started login for %s", req.ToolName))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"auth_url":     flow.handler.AuthCodeURL(state, challenge),
+		"state":        state,
+		"redirect_uri": redirect,
+		"expires_at":   flow.expires.UTC(),
+	})
+}
+
+// handleOAuthCallback completes a flow: GET is the provider's redirect of
+// the browser, POST lets a client that caught the redirect itself (e.g. with
+// a custom redirect_uri) pass on {state, code}.
+func (s *ControlServer) handleOAuthCallback(w http.ResponseWriter, r *http.Request) {
+	var state, code, providerErr string
+	if r.Method == http.MethodPost {
+		var req struct {
+			State string `json:"state"`
+			Code  string `json:"code"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		state, code = req.State, req.Code
+	} else {
+		q := r.URL.Query()
+		state, code, providerErr = q.Get("state"), q.Get("code"), q.Get("error")
+	}
+
+	flow, ok := pendingOAuth.take(state)
+	if !ok {
+		http.Error(w, "Unknown or expired login; start it again", http.StatusBadRequest)
+		return
+	}
+	if providerErr != "" || code == "" {
+		if providerErr == "" {
+			providerErr = "no code received"
+		}
+		logger.AddLog("WARN", fmt.Sprintf("[OAuth] Login for %s failed: %s", flow.tool, providerErr))
+		http.Error(w, "Login failed: "+providerErr, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), oauthExchangeTimeout)
+	defer cancel()
+	token, err := flow.handler.Exchange(ctx, code, flow.verifier)
+	if err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("[OAuth] Token exchange for %s failed: %v", flow.tool, err))
+		http.Error(w, fmt.Sprintf("Token exchange failed: %v", err), http.StatusBadGateway)
+		return
+	}
+	if err := flow.credentials.SetOAuthToken(flow.tool, flow.cfg, token, flow.clientID, flow.clientSecret); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to store credential: %v", err), http.StatusInternalServerError)
+		return
+	}
+	logger.AddLog("INFO", fmt.Sprintf("[OAuth] Stored OAuth token for tool %s", flow.tool))
+
+	if r.Method == http.MethodPost {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "stored", "tool_name": flow.tool})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "Authentication successful! You can close this window.")
+}
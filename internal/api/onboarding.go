@@ -0,0 +1,284 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/mcp-scooter/scooter/internal/demo"
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/integration"
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// onboardingTestTool is called by the test_call step when the request
+// doesn't name a tool. It needs no credentials and exercises the engine.
+const onboardingTestTool = "scooter_list_active"
+
+// OnboardingStepStatus is one row of the wizard as shown by the UI.
+type OnboardingStepStatus struct {
+	ID        profile.OnboardingStep `json:"id"`
+	Status    string                 `json:"status"` // "completed", "skipped", "current" or "pending"
+	Skippable bool                   `json:"skippable"`
+}
+
+// OnboardingResponse is returned by GET and POST /api/onboarding/state.
+type OnboardingResponse struct {
+	profile.OnboardingState
+	Current            profile.OnboardingStep `json:"current,omitempty"`
+	Done               bool                   `json:"done"`
+	Steps              []OnboardingStepStatus `json:"steps"`
+	OnboardingRequired bool                   `json:"onboarding_required"`
+}
+
+func (s *ControlServer) onboardingResponse(state profile.OnboardingState) OnboardingResponse {
+	resp := OnboardingResponse{
+		OnboardingState:    state,
+		Current:            state.Current(),
+		Done:               state.Done(),
+		OnboardingRequired: s.onboardingRequired,
+	}
+	if resp.Completed == nil {
+		resp.Completed = []profile.OnboardingStep{}
+	}
+	if resp.Skipped == nil {
+		resp.Skipped = []profile.OnboardingStep{}
+	}
+	for _, step := range profile.OnboardingSteps {
+		resp.Steps = append(resp.Steps, OnboardingStepStatus{
+			ID:        step,
+			Status:    state.Status(step),
+			Skippable: step.Skippable(),
+		})
+	}
+	return resp
+}
+
+func (s *ControlServer) handleGetOnboardingState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.onboardingResponse(s.settings.Get().Onboarding))
+}
+
+// handleAdvanceOnboarding validates a wizard step against the real state of
+// profiles, credentials and clients, then records it as completed. Skippable
+// steps may be skipped with "skip": true.
+func (s *ControlServer) handleAdvanceOnboarding(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Step    profile.OnboardingStep `json:"step"`
+		Skip    bool                   `json:"skip"`
+		Profile string                 `json:"profile,omitempty"`
+		// For test_call: the tool to call, defaulting to a builtin
+		Tool      string                 `json:"tool,omitempty"`
+		Arguments map[string]interface{} `json:"arguments,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	state := s.settings.Get().Onboarding
+	if req.Profile != "" && req.Step == profile.StepCreateProfile {
+		state.ProfileID = req.Profile
+	}
+
+	// Check ordering before doing any (possibly slow) validation
+	check := state
+	if err := check.Advance(req.Step, req.Skip); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !req.Skip {
+		if err := s.validateOnboardingStep(&state, req.Step, req.Tool, req.Arguments); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+	}
+
+	var advanceErr error
+	updated := s.settings.Update(func(st *profile.Settings) {
+		st.Onboarding.ProfileID = state.ProfileID
+		advanceErr = st.Onboarding.Advance(req.Step, req.Skip)
+	})
+	if advanceErr != nil {
+		http.Error(w, advanceErr.Error(), http.StatusConflict)
+		return
+	}
+	if s.store != nil {
+		if err := s.store.SaveSettings(updated); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	verb := "completed"
+	if req.Skip {
+		verb = "skipped"
+	}
+	logger.AddLog("INFO", fmt.Sprintf("Onboarding step %s %s", req.Step, verb))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.onboardingResponse(updated.Onboarding))
+}
+
+// handleOnboardingDemo installs the offline demo tools and creates (or
+// refreshes) the demo profile with both of them active, so a new user can
+// list and call tools without any API keys.
+func (s *ControlServer) handleOnboardingDemo(w http.ResponseWriter, r *http.Request) {
+	if s.manager.registryDir == "" {
+		http.Error(w, "no registry directory configured", http.StatusInternalServerError)
+		return
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to locate the Scooter binary: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err := demo.Install(s.manager.registryDir, s.manager.wasmDir, executable); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to install demo tools: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err := s.manager.ReloadIndex(); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("Failed to refresh registry index: %v", err))
+	}
+
+	demoProfile := profile.Profile{
+		ID:             demo.ProfileID,
+		RemoteAuthMode: "none",
+		AllowTools:     []string{demo.ServerName, demo.WASMServerName},
+		AutoActivate:   true,
+	}
+	status := http.StatusCreated
+	if _, exists := s.manager.GetProfile(demo.ProfileID); exists {
+		status = http.StatusOK
+		err = s.manager.UpdateProfile(demo.ProfileID, demoProfile)
+	} else {
+		err = s.manager.AddProfile(demoProfile)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if s.store != nil {
+		if err := s.store.SaveProfiles(s.manager.GetProfiles()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	s.onboardingRequired = false
+	s.recordProfileCreated(demo.ProfileID)
+
+	// Start the demo servers now so they show up in the first tools/list.
+	// AutoActivate covers a failure here on the first call.
+	activated := []string{}
+	if engine, ok := s.manager.GetEngine(demo.ProfileID); ok {
+		engine.ReloadRegistry()
+		for _, name := range demoProfile.AllowTools {
+			if err := engine.Add(name); err != nil {
+				logger.AddLog("WARN", fmt.Sprintf("Failed to activate demo tool %s: %v", name, err))
+				continue
+			}
+			activated = append(activated, name)
+		}
+	}
+
+	logger.AddLog("INFO", fmt.Sprintf("Demo profile ready with %d active demo tools", len(activated)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"profile":   demoProfile,
+		"activated": activated,
+	})
+}
+
+// recordProfileCreated completes the create_profile step for the start-fresh
+// and import shortcuts, which create profiles outside the wizard.
+func (s *ControlServer) recordProfileCreated(profileID string) {
+	updated := s.settings.Update(func(st *profile.Settings) {
+		if st.Onboarding.ProfileID == "" {
+			st.Onboarding.ProfileID = profileID
+		}
+		st.Onboarding.Advance(profile.StepCreateProfile, false)
+	})
+	if s.store != nil {
+		if err := s.store.SaveSettings(updated); err != nil {
+			logger.AddLog("WARN", fmt.Sprintf("Failed to save onboarding progress: %v", err))
+		}
+	}
+}
+
+// validateOnboardingStep checks that a step has really been done.
+func (s *ControlServer) validateOnboardingStep(state *profile.OnboardingState, step profile.OnboardingStep, tool string, args map[string]interface{}) error {
+	if step == profile.StepCreateProfile && state.ProfileID == "" {
+		if profiles := s.manager.GetProfiles(); len(profiles) > 0 {
+			state.ProfileID = profiles[0].ID
+		}
+	}
+	p, ok := s.manager.GetProfile(state.ProfileID)
+	if !ok {
+		return fmt.Errorf("create a profile first")
+	}
+
+	switch step {
+	case profile.StepCreateProfile:
+		return nil
+
+	case profile.StepPickTools:
+		if len(p.AllowTools) == 0 {
+			return fmt.Errorf("profile %q has no tools yet", p.ID)
+		}
+		return nil
+
+	case profile.StepStoreCredentials:
+		var missing []string
+		for _, name := range discovery.ResolveAllowTools(p.AllowTools, s.manager.Catalog()) {
+			def, found := s.manager.FindTool(name)
+			if !found || def.Authorization == nil {
+				continue
+			}
+			status := s.manager.Credentials().ResolveAuth(name, def.Authorization, p.Env)
+			if !status.HasRequired {
+				missing = append(missing, fmt.Sprintf("%s (%s)", name, strings.Join(status.Missing, ", ")))
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("missing credentials for %s", strings.Join(missing, "; "))
+		}
+		return nil
+
+	case profile.StepSyncClients:
+		for _, cd := range s.loadClients() {
+			if cd.ConfigPath == "" {
+				continue
+			}
+			entry, err := integration.FindScooterEntry(cd.ConfigPath)
+			if err == nil && entry != nil && entry.Profile == p.ID {
+				return nil
+			}
+		}
+		return fmt.Errorf("no client is configured for profile %q yet", p.ID)
+
+	case profile.StepTestCall:
+		engine, ok := s.manager.GetEngine(p.ID)
+		if !ok {
+			return fmt.Errorf("profile %q is not running", p.ID)
+		}
+		if tool == "" {
+			tool = onboardingTestTool
+		}
+		if args == nil {
+			args = map[string]interface{}{}
+		}
+		if _, err := engine.CallTool(tool, args); err != nil {
+			return fmt.Errorf("test call to %s failed: %w", tool, err)
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// handleGetToolDefinition returns the registry entry {name} as it is loaded,
+// with ?profile=<id> as that profile sees it: its base definition, the
+// effective one after its override and the override itself.
+func (s *ControlServer) handleGetToolDefinition(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	overlay, ok := s.overlayParam(w, r)
+	if !ok {
+		return
+	}
+	if s.manager.registryDir == "" || !registry.ValidName(name) {
+		http.Error(w, "Tool not found", http.StatusNotFound)
+		return
+	}
+	layers, err := discovery.LoadDefinitionLayers(s.manager.registryDir, overlay, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, "Tool not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(layers)
+}
+
+// handleSetOverride saves the request body, a JSON merge patch, as the
+// override of the registry entry {name}.
+func (s *ControlServer) handleSetOverride(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !registry.ValidName(name) {
+		http.Error(w, fmt.Sprintf("invalid tool name %q", name), http.StatusBadRequest)
+		return
+	}
+	if s.manager.registryDir == "" {
+		http.Error(w, "Overrides need a registry directory", http.StatusBadRequest)
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = discovery.WriteOverride(s.manager.registryDir, name, patch)
+	if errors.Is(err, discovery.ErrInvalidEntry) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save override: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.manager.ReloadRegistries()
+	logger.AddLog("INFO", fmt.Sprintf("Saved override of %s", name))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDeleteOverride removes the override of the registry entry {name}.
+func (s *ControlServer) handleDeleteOverride(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !registry.ValidName(name) {
+		http.Error(w, fmt.Sprintf("invalid tool name %q", name), http.StatusBadRequest)
+		return
+	}
+	if s.manager.registryDir != "" {
+		if err := discovery.RemoveOverride(s.manager.registryDir, name); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to delete override: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	s.manager.ReloadRegistries()
+	logger.AddLog("INFO", fmt.Sprintf("Deleted override of %s", name))
+	w.WriteHeader(http.StatusNoContent)
+}
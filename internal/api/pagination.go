@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+)
+
+// errInvalidCursor is returned for a cursor the gateway didn't issue.
+var errInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor returns the opaque cursor for the page starting at offset.
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+// decodeCursor returns the offset of a cursor from encodeCursor. An empty
+// cursor is the first page.
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(b) < len("offset:") || string(b[:len("offset:")]) != "offset:" {
+		return 0, errInvalidCursor
+	}
+	offset, err := strconv.Atoi(string(b[len("offset:"):]))
+	if err != nil || offset < 0 {
+		return 0, errInvalidCursor
+	}
+	return offset, nil
+}
+
+// paginate returns the page of n items starting at cursor as [start, end)
+// bounds, and the cursor of the next page ("" on the last one). A pageSize
+// of 0 or less returns everything in one page.
+func paginate(n int, cursor string, pageSize int) (start, end int, next string, err error) {
+	start, err = decodeCursor(cursor)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	if start > n {
+		// The list shrank since the cursor was issued
+		start = n
+	}
+	if pageSize <= 0 {
+		return start, n, "", nil
+	}
+	end = start + pageSize
+	if end >= n {
+		return start, n, "", nil
+	}
+	return start, end, encodeCursor(end), nil
+}
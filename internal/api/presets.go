@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// presetsFormat is the version of the preset export document.
+const presetsFormat = 1
+
+// errNoPreset is returned for a preset that doesn't exist.
+var errNoPreset = errors.New("no such preset")
+
+// presetsDocument is what GET /api/tool-presets/export returns and
+// POST /api/tool-presets/import takes.
+type presetsDocument struct {
+	Format     int                 `json:"format"`
+	ExportedAt time.Time           `json:"exported_at,omitempty"`
+	Presets    profile.ToolPresets `json:"presets"`
+}
+
+// loadPresets returns the saved presets, writing the error if there are none
+// to read.
+func (s *ControlServer) loadPresets(w http.ResponseWriter) (profile.ToolPresets, bool) {
+	if s.store == nil {
+		http.Error(w, "store not initialized", http.StatusInternalServerError)
+		return nil, false
+	}
+	presets, err := s.store.LoadToolPresets()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read presets: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+	return presets, true
+}
+
+// filterPresets returns the presets of tool, or all of them if tool is "".
+func filterPresets(presets profile.ToolPresets, tool string) profile.ToolPresets {
+	if tool == "" {
+		return presets
+	}
+	filtered := profile.ToolPresets{}
+	if p, ok := presets[tool]; ok {
+		filtered[tool] = p
+	}
+	return filtered
+}
+
+// handleGetPresets lists the presets, of one tool with ?tool.
+func (s *ControlServer) handleGetPresets(w http.ResponseWriter, r *http.Request) {
+	presets, ok := s.loadPresets(w)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"presets": filterPresets(presets, r.URL.Query().Get("tool"))})
+}
+
+// handleSavePreset stores the JSON object in the body as the preset {name}
+// of {tool}.
+func (s *ControlServer) handleSavePreset(w http.ResponseWriter, r *http.Request) {
+	tool, name := r.PathValue("tool"), r.PathValue("name")
+	if err := profile.ValidatePresetName(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var args map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		http.Error(w, fmt.Sprintf("a preset must be a JSON object of arguments: %v", err), http.StatusBadRequest)
+		return
+	}
+	presets, ok := s.loadPresets(w)
+	if !ok {
+		return
+	}
+
+	presets.Set(tool, name, args)
+	if err := s.store.SaveToolPresets(presets); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *ControlServer) handleDeletePreset(w http.ResponseWriter, r *http.Request) {
+	presets, ok := s.loadPresets(w)
+	if !ok {
+		return
+	}
+	tool, name := r.PathValue("tool"), r.PathValue("name")
+	if !presets.Delete(tool, name) {
+		http.Error(w, fmt.Sprintf("no preset %q for %s", name, tool), http.StatusNotFound)
+		return
+	}
+	if err := s.store.SaveToolPresets(presets); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleExportPresets returns the presets, of one tool with ?tool, as a
+// document to share.
+func (s *ControlServer) handleExportPresets(w http.ResponseWriter, r *http.Request) {
+	presets, ok := s.loadPresets(w)
+	if !ok {
+		return
+	}
+	doc := presetsDocument{Format: presetsFormat, ExportedAt: time.Now().UTC(), Presets: filterPresets(presets, r.URL.Query().Get("tool"))}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="scooter-presets.json"`)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(doc)
+}
+
+// handleImportPresets adds the presets of an exported document. Presets
+// whose name a tool already uses are skipped unless ?replace=true.
+func (s *ControlServer) handleImportPresets(w http.ResponseWriter, r *http.Request) {
+	replace := false
+	if v := r.URL.Query().Get("replace"); v != "" {
+		var err error
+		if replace, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, fmt.Sprintf("invalid replace %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+	var doc presetsDocument
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if doc.Format > presetsFormat {
+		http.Error(w, fmt.Sprintf("preset format %d is newer than this version supports", doc.Format), http.StatusBadRequest)
+		return
+	}
+	if err := doc.Presets.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	presets, ok := s.loadPresets(w)
+	if !ok {
+		return
+	}
+
+	added, skipped := presets.Merge(doc.Presets, replace)
+	if err := s.store.SaveToolPresets(presets); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sort.Strings(added)
+	sort.Strings(skipped)
+	logger.AddLog("INFO", fmt.Sprintf("Imported %d tool presets, skipped %d", len(added), len(skipped)))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"imported": added, "skipped": skipped})
+}
+
+// presetArguments returns the arguments of a tool's preset with args on top.
+func (s *ControlServer) presetArguments(tool, preset string, args map[string]interface{}) (map[string]interface{}, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("%w: %q for %s", errNoPreset, preset, tool)
+	}
+	presets, err := s.store.LoadToolPresets()
+	if err != nil {
+		return nil, err
+	}
+	base, ok := presets.Get(tool, preset)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q for %s", errNoPreset, preset, tool)
+	}
+	merged := make(map[string]interface{}, len(base)+len(args))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range args {
+		merged[k] = v
+	}
+	return merged, nil
+}
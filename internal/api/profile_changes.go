@@ -0,0 +1,142 @@
+package api
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// ProfileChange describes what an update changed in a profile. The profile's
+// engine is reconfigured before subscribers are told, so they only need to
+// react, e.g. by refreshing their clients' tool lists.
+type ProfileChange struct {
+	ProfileID string `json:"profile_id"`
+	// OldID is the profile's previous ID when it was renamed.
+	OldID string `json:"old_id,omitempty"`
+	// Fields are the JSON names of the changed profile fields.
+	Fields []string `json:"fields,omitempty"`
+	// Restarting are active servers restarted for the new environment;
+	// another change with Restarted follows once they are back.
+	Restarting []string `json:"restarting,omitempty"`
+	Restarted  []string `json:"restarted,omitempty"`
+}
+
+// toolListFields are the profile fields that change what tools/list shows.
+var toolListFields = []string{
+	"allow_tools", "disabled_system_tools", "tool_migrations", "builtin_prefix",
+	"call_history", "max_result_bytes", "minify_schemas", "minify_description_chars",
+}
+
+// Changed reports whether the field with the JSON name changed.
+func (c ProfileChange) Changed(field string) bool {
+	return slices.Contains(c.Fields, field)
+}
+
+// ToolsChanged reports whether the profile's clients may see different tools.
+func (c ProfileChange) ToolsChanged() bool {
+	if len(c.Restarting) > 0 || len(c.Restarted) > 0 || c.OldID != "" {
+		return true
+	}
+	for _, field := range toolListFields {
+		if c.Changed(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// changedFields lists the JSON names of the fields that differ between old
+// and p. Empty and missing lists or maps are the same.
+func changedFields(old, p profile.Profile) []string {
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(p)
+	t := ov.Type()
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		a, b := ov.Field(i), nv.Field(i)
+		switch a.Kind() {
+		case reflect.Map, reflect.Slice:
+			if a.Len() == 0 && b.Len() == 0 {
+				continue
+			}
+		}
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// Subscribe registers fn to be called after every profile update, once the
+// profile's engine has the new configuration.
+func (pm *ProfileManager) Subscribe(fn func(ProfileChange)) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.subscribers = append(pm.subscribers, fn)
+}
+
+// publish calls the subscribers with change; pm.mu must not be held.
+func (pm *ProfileManager) publish(change ProfileChange) {
+	pm.mu.RLock()
+	subscribers := slices.Clone(pm.subscribers)
+	pm.mu.RUnlock()
+	for _, sub := range subscribers {
+		sub(change)
+	}
+}
+
+// configureEngine pushes the per-profile options an engine applies itself.
+// The environment is set separately, since changing it may need restarts.
+func configureEngine(engine *discovery.DiscoveryEngine, p profile.Profile) {
+	engine.SetDisabledTools(p.DisabledSystemTools)
+	engine.SetStrictOutputSchema(p.StrictOutputSchema)
+	engine.SetResultBudget(p.MaxResultBytes, p.SummarizeResults)
+	engine.SetLogLevels(p.LogLevels)
+}
+
+// applyProfileLocked reconfigures the engine of p, whose previous version
+// was old, and returns what changed; pm.mu must be held. Servers whose
+// environment changed are listed in Restarting for restartServers.
+func (pm *ProfileManager) applyProfileLocked(engine *discovery.DiscoveryEngine, old, p profile.Profile) ProfileChange {
+	change := ProfileChange{ProfileID: p.ID, Fields: changedFields(old, p)}
+	if old.ID != p.ID {
+		change.OldID = old.ID
+	}
+	if change.OldID != "" || p.IsolatedCredentials != old.IsolatedCredentials || !maps.Equal(p.Identities, old.Identities) {
+		engine.SetCredentialManager(pm.profileCredentials(p))
+	}
+	if change.OldID != "" || p.IsolateHome != old.IsolateHome {
+		engine.SetHomeIsolation(pm.homeDir(p.ID), p.IsolateHome)
+	}
+	configureEngine(engine, p)
+	if change.Changed("env") {
+		change.Restarting = engine.UpdateEnv(p.Env)
+	}
+	if len(change.Fields) > 0 {
+		logger.AddLog("INFO", fmt.Sprintf("Profile '%s' changed: %s", p.ID, strings.Join(change.Fields, ", ")))
+	}
+	return change
+}
+
+// restartServers restarts servers of a profile's engine in the background
+// and then publishes a change listing the ones that came back.
+func (pm *ProfileManager) restartServers(engine *discovery.DiscoveryEngine, profileID string, servers []string) {
+	go func() {
+		var restarted []string
+		for _, name := range servers {
+			logger.AddLog("INFO", fmt.Sprintf("Restarting %s in profile '%s' for its new environment", name, profileID))
+			if err := engine.Restart(name); err != nil {
+				logger.AddLog("WARN", fmt.Sprintf("Failed to restart %s in profile '%s': %v", name, profileID, err))
+				continue
+			}
+			restarted = append(restarted, name)
+		}
+		pm.publish(ProfileChange{ProfileID: profileID, Restarted: restarted})
+	}()
+}
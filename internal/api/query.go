@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+)
+
+// profileQuery holds the filtering, paging and field selection options
+// accepted by GET /api/profiles and GET /api/status.
+type profileQuery struct {
+	Running *bool    // running=true|false
+	Prefix  string   // prefix=<profile id prefix>
+	Fields  []string // fields=id,running,...
+	Include map[string]bool
+	Offset  int
+	Limit   int // 0 means no limit
+}
+
+func parseProfileQuery(q url.Values) (profileQuery, error) {
+	var pq profileQuery
+
+	if v := q.Get("running"); v != "" {
+		running, err := strconv.ParseBool(v)
+		if err != nil {
+			return pq, fmt.Errorf("invalid running filter %q", v)
+		}
+		pq.Running = &running
+	}
+	pq.Prefix = q.Get("prefix")
+	pq.Fields = splitList(q.Get("fields"))
+
+	pq.Include = map[string]bool{}
+	for _, name := range splitList(q.Get("include")) {
+		pq.Include[name] = true
+	}
+
+	var err error
+	if pq.Offset, err = parseNonNegative(q, "offset"); err != nil {
+		return pq, err
+	}
+	if pq.Limit, err = parseNonNegative(q, "limit"); err != nil {
+		return pq, err
+	}
+	return pq, nil
+}
+
+func parseNonNegative(q url.Values, name string) (int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, v)
+	}
+	return n, nil
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// match reports whether a profile passes the running and prefix filters.
+func (pq profileQuery) match(id string, running bool) bool {
+	if pq.Running != nil && *pq.Running != running {
+		return false
+	}
+	return strings.HasPrefix(id, pq.Prefix)
+}
+
+// page returns the slice bounds for the requested page of total items and the
+// offset of the next page, or nil if this is the last one.
+func (pq profileQuery) page(total int) (start, end int, next *int) {
+	start = pq.Offset
+	if start > total {
+		start = total
+	}
+	end = total
+	if pq.Limit > 0 && start+pq.Limit < total {
+		end = start + pq.Limit
+		next = &end
+	}
+	return start, end, next
+}
+
+// selectFields reduces each item to the requested JSON fields. With no fields
+// the items are returned unchanged; unknown field names are ignored.
+func selectFields[T any](items []T, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return items, nil
+	}
+	out := make([]map[string]json.RawMessage, len(items))
+	for i, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+		out[i] = make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if v, ok := all[field]; ok {
+				out[i][field] = v
+			}
+		}
+	}
+	return out, nil
+}
+
+// parseReliabilityFilter reads the reliability options of GET /api/tools:
+// min_reliability and max_reliability (0-1), and sort=reliability for the
+// most reliable entries first or sort=-reliability for the least.
+func parseReliabilityFilter(q url.Values) (discovery.ReliabilityFilter, error) {
+	var f discovery.ReliabilityFilter
+	for name, bound := range map[string]**float64{"min_reliability": &f.Min, "max_reliability": &f.Max} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil || r < 0 || r > 1 {
+			return f, fmt.Errorf("invalid %s %q: must be between 0 and 1", name, v)
+		}
+		*bound = &r
+	}
+	switch sort := q.Get("sort"); sort {
+	case "", "reliability", "-reliability":
+		f.Sort = sort
+	default:
+		return f, fmt.Errorf("invalid sort %q: use reliability or -reliability", sort)
+	}
+	return f, nil
+}
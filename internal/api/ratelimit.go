@@ -0,0 +1,164 @@
+package api
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+)
+
+// RateLimited is the JSON-RPC error of a call over a profile's rate limit or
+// a tool quota. Its data has retry_after, in seconds, and the limit hit.
+const RateLimited = -32029
+
+// callLimiter enforces the profiles' rate limits and tool quotas on
+// tools/call. Calls are kept for an hour, and only under limits that are
+// set.
+type callLimiter struct {
+	mu       sync.Mutex
+	calls    map[string][]time.Time // limitKey -> call times within the last hour, oldest first
+	rejected map[string]int         // profileID -> calls refused
+	now      func() time.Time
+}
+
+func newCallLimiter() *callLimiter {
+	return &callLimiter{
+		calls:    make(map[string][]time.Time),
+		rejected: make(map[string]int),
+		now:      time.Now,
+	}
+}
+
+// limitKey identifies the calls of a profile ("" quota) or of one of its
+// tool quotas.
+func limitKey(profileID, quota string) string {
+	if quota == "" {
+		return profileID
+	}
+	return profileID + "\x00" + quota
+}
+
+// RateLimitExceeded describes a call refused by a callLimiter.
+type RateLimitExceeded struct {
+	// Limit is "profile" or the name of the tool quota.
+	Limit      string
+	RetryAfter time.Duration
+}
+
+// Allow checks a call of tool, served by server ("" for builtin tools),
+// against p's limits and records it if it is within all of them.
+func (l *callLimiter) Allow(p profile.Profile, tool, server string) (RateLimitExceeded, bool) {
+	type check struct {
+		key, name string
+		limit     profile.RateLimit
+	}
+	var checks []check
+	if !p.RateLimit.IsZero() {
+		checks = append(checks, check{limitKey(p.ID, ""), "profile", p.RateLimit})
+	}
+	names := []string{tool}
+	if server != "" && server != tool {
+		names = append(names, server)
+	}
+	for _, name := range names {
+		if q := p.ToolQuotas[name]; !q.IsZero() {
+			checks = append(checks, check{limitKey(p.ID, name), name, q})
+		}
+	}
+	if len(checks) == 0 {
+		return RateLimitExceeded{}, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	var exceeded RateLimitExceeded
+	for _, c := range checks {
+		calls := l.prune(c.key, now)
+		wait := max(waitFor(calls, time.Minute, c.limit.PerMinute, now), waitFor(calls, time.Hour, c.limit.PerHour, now))
+		if wait > exceeded.RetryAfter {
+			exceeded = RateLimitExceeded{Limit: c.name, RetryAfter: wait}
+		}
+	}
+	if exceeded.RetryAfter > 0 {
+		l.rejected[p.ID]++
+		return exceeded, false
+	}
+	for _, c := range checks {
+		l.calls[c.key] = append(l.calls[c.key], now)
+	}
+	return RateLimitExceeded{}, true
+}
+
+// prune drops the calls under key older than an hour and returns the rest;
+// l.mu must be held.
+func (l *callLimiter) prune(key string, now time.Time) []time.Time {
+	calls := l.calls[key]
+	start := sort.Search(len(calls), func(i int) bool { return now.Sub(calls[i]) < time.Hour })
+	if start == len(calls) {
+		delete(l.calls, key)
+		return nil
+	}
+	l.calls[key] = calls[start:]
+	return calls[start:]
+}
+
+// waitFor returns how long until fewer than limit of calls are within
+// window of now, or 0 if they already are or limit is 0.
+func waitFor(calls []time.Time, window time.Duration, limit int, now time.Time) time.Duration {
+	if limit <= 0 || len(calls) < limit {
+		return 0
+	}
+	return max(0, calls[len(calls)-limit].Add(window).Sub(now))
+}
+
+// RateLimitUsage is a limit and the calls counted against it.
+type RateLimitUsage struct {
+	Limit           profile.RateLimit `json:"limit"`
+	CallsLastMinute int               `json:"calls_last_minute"`
+	CallsLastHour   int               `json:"calls_last_hour"`
+}
+
+// RateLimitStatus is the usage of a profile's rate limit and tool quotas,
+// as reported by /api/status.
+type RateLimitStatus struct {
+	RateLimitUsage
+	Rejected int                       `json:"rejected"`
+	Tools    map[string]RateLimitUsage `json:"tools,omitempty"`
+}
+
+// Status returns the usage of p's limits, or nil if it has none.
+func (l *callLimiter) Status(p profile.Profile) *RateLimitStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	usage := func(quota string, limit profile.RateLimit) RateLimitUsage {
+		u := RateLimitUsage{Limit: limit}
+		for _, t := range l.prune(limitKey(p.ID, quota), now) {
+			u.CallsLastHour++
+			if now.Sub(t) < time.Minute {
+				u.CallsLastMinute++
+			}
+		}
+		return u
+	}
+
+	status := &RateLimitStatus{Rejected: l.rejected[p.ID]}
+	if !p.RateLimit.IsZero() {
+		status.RateLimitUsage = usage("", p.RateLimit)
+	}
+	for name, quota := range p.ToolQuotas {
+		if quota.IsZero() {
+			continue
+		}
+		if status.Tools == nil {
+			status.Tools = make(map[string]RateLimitUsage)
+		}
+		status.Tools[name] = usage(name, quota)
+	}
+	if p.RateLimit.IsZero() && status.Tools == nil && status.Rejected == 0 {
+		return nil
+	}
+	return status
+}
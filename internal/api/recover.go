@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/mcp-scooter/scooter/internal/logger"
+	"github.com/mcp-scooter/scooter/internal/metrics"
+)
+
+// A panic in a handler of either server is recovered so the client gets an
+// answer instead of a dropped connection: a JSON 500 from the control API,
+// a JSON-RPC internal error from the gateway. The stack goes to the log and
+// the event journal as handler.panicked, and scooter_panics_total counts
+// it. A response that had already reached the client, such as an SSE
+// stream, is only cut short; one still buffered, e.g. by compressWriter, is
+// dropped for the error.
+
+// recoveryWriter notes whether a response has started.
+type recoveryWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (rw *recoveryWriter) WriteHeader(code int) {
+	rw.started = true
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recoveryWriter) Write(b []byte) (int, error) {
+	rw.started = true
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *recoveryWriter) Flush() {
+	rw.started = true
+	http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+func (rw *recoveryWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// discardUnsent drops a started response that the writer underneath still
+// holds back, reporting whether nothing of it had been sent.
+func (rw *recoveryWriter) discardUnsent() bool {
+	if b, ok := rw.ResponseWriter.(interface{ discardUnsent() bool }); ok && b.discardUnsent() {
+		rw.started = false
+	}
+	return !rw.started
+}
+
+// recoverPanic answers r if its handler panicked; it must be deferred.
+// server names the server in reports, and jsonRPC answers with a JSON-RPC
+// error. http.ErrAbortHandler, which handlers use to abort a response on
+// purpose, is passed on.
+func recoverPanic(rw *recoveryWriter, r *http.Request, server string, jsonRPC bool) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	stack := string(debug.Stack())
+	logger.AddLog("ERROR", fmt.Sprintf("Recovered from a panic serving %s %s on the %s server: %v\n%s", r.Method, r.URL.Path, server, v, stack))
+	events.Record(events.HandlerPanicked, "", "", map[string]interface{}{
+		"server": server,
+		"method": r.Method,
+		"path":   r.URL.Path,
+		"error":  fmt.Sprint(v),
+		"stack":  stack,
+	})
+	metrics.Panics.Inc(server)
+
+	if rw.started && !rw.discardUnsent() {
+		return
+	}
+	h := rw.Header()
+	h.Del("Content-Length")
+	h.Del("Content-Disposition")
+	h.Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusInternalServerError)
+	if jsonRPC {
+		json.NewEncoder(rw).Encode(NewJSONRPCErrorResponse(nil, InternalError, "Internal error"))
+		return
+	}
+	json.NewEncoder(rw).Encode(map[string]string{"error": "internal server error"})
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// ReloadResult summarises what a configuration reload changed.
+type ReloadResult struct {
+	Added           []string `json:"added"`
+	Removed         []string `json:"removed"`
+	Updated         []string `json:"updated"`
+	SettingsChanged bool     `json:"settings_changed"`
+}
+
+// SyncProfiles replaces the profile list with profiles. Engines of profiles
+// that still exist keep running, with their active servers, so only added
+// and removed profiles start or stop anything, besides servers restarted
+// because their profile's environment changed.
+func (pm *ProfileManager) SyncProfiles(profiles []profile.Profile) ReloadResult {
+	pm.mu.Lock()
+	var changes []ProfileChange
+	defer func() {
+		pm.mu.Unlock()
+		for _, change := range changes {
+			pm.publish(change)
+		}
+	}()
+
+	result := ReloadResult{Added: []string{}, Removed: []string{}, Updated: []string{}}
+	old := make(map[string]profile.Profile, len(pm.profiles))
+	for _, p := range pm.profiles {
+		old[p.ID] = p
+	}
+
+	keep := make(map[string]bool, len(profiles))
+	for _, p := range profiles {
+		keep[p.ID] = true
+		previous, existed := old[p.ID]
+		switch {
+		case !existed:
+			pm.engines[p.ID] = pm.newEngine(p)
+			result.Added = append(result.Added, p.ID)
+		case !reflect.DeepEqual(previous, p):
+			change := pm.applyProfileLocked(pm.engines[p.ID], previous, p)
+			if len(change.Restarting) > 0 {
+				pm.restartServers(pm.engines[p.ID], p.ID, change.Restarting)
+			}
+			changes = append(changes, change)
+			result.Updated = append(result.Updated, p.ID)
+		}
+	}
+	for id := range old {
+		if keep[id] {
+			continue
+		}
+		if engine, ok := pm.engines[id]; ok {
+			engine.Stop()
+		}
+		delete(pm.engines, id)
+		result.Removed = append(result.Removed, id)
+	}
+
+	pm.profiles = profiles
+	return result
+}
+
+// Reload re-reads profiles, settings and the registry from disk and applies
+// them without a restart. Connected clients stay connected unless their
+// profile was removed; they are told to refresh their tool lists.
+func (s *ControlServer) Reload() (ReloadResult, error) {
+	if s.store == nil {
+		return ReloadResult{}, errors.New("no configuration store to reload from")
+	}
+	profiles, settings, err := s.store.Load()
+	if err != nil {
+		// Keep running with the old configuration
+		if verr := s.store.Validate(nil).Err(false); verr != nil {
+			return ReloadResult{}, verr
+		}
+		return ReloadResult{}, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	result := s.manager.SyncProfiles(profiles)
+	if !reflect.DeepEqual(s.settings.Get(), settings) {
+		s.settings.Set(settings)
+		logger.SetVerbose(settings.VerboseLogging)
+		logger.SetConsoleLevel(settings.ConsoleLogLevel)
+		s.manager.SetInstanceID(settings.InstanceID)
+		result.SettingsChanged = true
+	}
+
+	if err := s.manager.ReloadIndex(); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("Failed to reload registry index: %v", err))
+	}
+	for _, p := range s.manager.GetProfiles() {
+		if engine, ok := s.manager.GetEngine(p.ID); ok {
+			if err := engine.ReloadRegistry(); err != nil {
+				logger.AddLog("WARN", fmt.Sprintf("Failed to reload registry for profile '%s': %v", p.ID, err))
+			}
+		}
+	}
+
+	if s.gateway != nil {
+		for _, id := range result.Added {
+			if p, ok := s.manager.GetProfile(id); ok {
+				s.gateway.watchProfile(p)
+			}
+		}
+		for _, id := range result.Removed {
+			s.gateway.closeProfileSessions(id)
+		}
+		// Allowed tools, prefixes or the registry may have changed
+		for _, p := range s.manager.GetProfiles() {
+			s.gateway.NotifyToolsChanged(p.ID)
+		}
+	}
+
+	events.Record(events.ConfigChanged, "", "", map[string]interface{}{
+		"change":           "reloaded",
+		"added":            result.Added,
+		"removed":          result.Removed,
+		"updated":          result.Updated,
+		"settings_changed": result.SettingsChanged,
+	})
+	logger.AddLog("INFO", fmt.Sprintf("Configuration reloaded: added [%s], removed [%s], updated [%s], settings changed: %v",
+		strings.Join(result.Added, ", "), strings.Join(result.Removed, ", "), strings.Join(result.Updated, ", "), result.SettingsChanged))
+	return result, nil
+}
+
+// closeProfileSessions disconnects the SSE clients of a removed profile.
+func (g *McpGateway) closeProfileSessions(profileID string) {
+	g.sseClientsMu.RLock()
+	defer g.sseClientsMu.RUnlock()
+	for _, sess := range g.sseSessions {
+		if sess.profileID == profileID {
+			sess.close(causeProfileRemoved, "the profile was removed by a configuration reload")
+		}
+	}
+}
+
+func (s *ControlServer) handleReload(w http.ResponseWriter, r *http.Request) {
+	result, err := s.Reload()
+	if err != nil {
+		logger.AddLog("ERROR", fmt.Sprintf("Configuration reload failed: %v", err))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
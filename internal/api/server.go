@@ -3,11 +3,15 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -16,11 +20,18 @@ import (
 	"encoding/hex"
 	"os/exec"
 	"runtime"
+	"github.com/mcp-scooter/scooter/internal/audit"
 	"github.com/mcp-scooter/scooter/internal/domain/discovery"
 	"github.com/mcp-scooter/scooter/internal/domain/integration"
 	"github.com/mcp-scooter/scooter/internal/domain/profile"
 	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/mcp-scooter/scooter/internal/hooks"
+	"github.com/mcp-scooter/scooter/internal/i18n"
 	"github.com/mcp-scooter/scooter/internal/logger"
+	"github.com/mcp-scooter/scooter/internal/metrics"
+	"github.com/mcp-scooter/scooter/internal/tunnel"
+	"github.com/mcp-scooter/scooter/internal/version"
 )
 
 // Helper function to extract tool names from tools
@@ -37,66 +48,157 @@ type ControlServer struct {
 	mux                *http.ServeMux
 	store              *profile.Store
 	manager            *ProfileManager
-	settings           *profile.Settings
+	settings           *profile.SettingsProvider
 	onboardingRequired bool
-	mu                 sync.RWMutex
+	startedAt          time.Time
+
+	// gateway is set by SetGateway; it backs the /api/sessions endpoints
+	gateway *McpGateway
+	// tunnel is set by SetTunnel when a profile is exposed through a relay
+	tunnel *tunnel.Client
+	// users is set by SetUsers; user is the user whose scope this is, ""
+	// for the daemon's owner
+	users *Users
+	user  string
+	// shutdown is set by SetShutdown; it stops the daemon for /api/shutdown
+	shutdown func()
+	// internalToken is set by SetInternalToken; it authenticates Scooter
+	// Desktop as the owner once control tokens exist
+	internalToken string
 }
 
 // NewControlServer creates a new management server.
-func NewControlServer(store *profile.Store, manager *ProfileManager, settings *profile.Settings, onboardingRequired bool) *ControlServer {
+func NewControlServer(store *profile.Store, manager *ProfileManager, settings *profile.SettingsProvider, onboardingRequired bool) *ControlServer {
 	s := &ControlServer{
 		mux:                http.NewServeMux(),
 		store:              store,
 		manager:            manager,
 		settings:           settings,
 		onboardingRequired: onboardingRequired,
+		startedAt:          time.Now(),
 	}
 	s.routes()
 	return s
 }
 
+// SetGateway gives the control API access to the gateway's sessions.
+func (s *ControlServer) SetGateway(g *McpGateway) {
+	s.gateway = g
+}
+
+// SetTunnel lets /api/status report the tunnel's connection.
+func (s *ControlServer) SetTunnel(t *tunnel.Client) {
+	s.tunnel = t
+}
+
+// SetShutdown makes /api/shutdown call stop rather than exit the process,
+// so the daemon's deferred clean-up runs.
+func (s *ControlServer) SetShutdown(stop func()) {
+	s.shutdown = stop
+}
+
+// SetUsers sends requests with users' control tokens to their scopes.
+func (s *ControlServer) SetUsers(u *Users) {
+	s.users = u
+}
+
 func (s *ControlServer) routes() {
-	s.mux.HandleFunc("GET /api/profiles", s.handleGetProfiles)
-	s.mux.HandleFunc("POST /api/profiles", s.handleCreateProfile)
-	s.mux.HandleFunc("PUT /api/profiles", s.handleUpdateProfile)
-	s.mux.HandleFunc("DELETE /api/profiles", s.handleDeleteProfile)
-	s.mux.HandleFunc("POST /api/clients/sync", s.handleInstallIntegration)
-	s.mux.HandleFunc("POST /api/onboarding/start-fresh", s.handleOnboardingStartFresh)
-	s.mux.HandleFunc("POST /api/onboarding/import", s.handleOnboardingImport)
-	s.mux.HandleFunc("POST /api/reset", s.handleReset)
-	s.mux.HandleFunc("POST /api/shutdown", s.handleShutdown)
-	s.mux.HandleFunc("GET /api/tools", s.handleGetTools)
-	s.mux.HandleFunc("POST /api/tools", s.handleRegisterTool)
-	s.mux.HandleFunc("POST /api/tools/refresh", s.handleRefreshTools)
-	s.mux.HandleFunc("POST /api/tools/verify", s.handleVerifyTool)
-	s.mux.HandleFunc("DELETE /api/tools", s.handleDeleteTool)
-	s.mux.HandleFunc("GET /api/health", s.handleHealth)
-	s.mux.HandleFunc("GET /api/ping", s.handlePing)
-	s.mux.HandleFunc("GET /api/clients", s.handleGetClients)
-	s.mux.HandleFunc("GET /api/settings", s.handleGetSettings)
-	s.mux.HandleFunc("PUT /api/settings", s.handleUpdateSettings)
-	s.mux.HandleFunc("POST /api/settings/regenerate-key", s.handleRegenerateKey)
-	s.mux.HandleFunc("GET /api/tool-params", s.handleGetToolParams)
-	s.mux.HandleFunc("PUT /api/tool-params", s.handleSaveToolParams)
-	// Log management
-	s.mux.HandleFunc("GET /api/logs", s.handleGetLogs)
-	s.mux.HandleFunc("POST /api/logs", s.handlePostLog)
-	s.mux.HandleFunc("GET /api/logs/stream", s.handleLogStream)
-	s.mux.HandleFunc("DELETE /api/logs", s.handleClearLogs)
-	s.mux.HandleFunc("POST /api/logs/reveal", s.handleRevealLogs)
-	// Secure credential management
-	s.mux.HandleFunc("POST /api/credentials", s.handleSetCredential)
-	s.mux.HandleFunc("GET /api/credentials/check", s.handleCheckCredentials)
-	s.mux.HandleFunc("DELETE /api/credentials", s.handleDeleteCredential)
-	// AI routing credentials
-	s.mux.HandleFunc("POST /api/credentials/ai-primary", s.handleSetPrimaryAIKey)
-	s.mux.HandleFunc("POST /api/credentials/ai-fallback", s.handleSetFallbackAIKey)
-	s.mux.HandleFunc("GET /api/credentials/ai", s.handleCheckAICredentials)
-	s.mux.HandleFunc("DELETE /api/credentials/ai-primary", s.handleDeletePrimaryAIKey)
-	s.mux.HandleFunc("DELETE /api/credentials/ai-fallback", s.handleDeleteFallbackAIKey)
-	s.mux.HandleFunc("POST /api/tools/call", s.handleCallTool)
-	s.mux.HandleFunc("POST /api/tools/activate", s.handleActivateTool)
-	s.mux.HandleFunc("GET /api/status", s.handleGetStatus)
+	// Each route needs at least the given control role. Routes wrapped in
+	// ownerOnly belong to the daemon's owner, not to its users (see Users)
+	handle := func(role profile.ControlRole, groups bool) func(string, http.HandlerFunc) {
+		return func(pattern string, h http.HandlerFunc) { s.mux.HandleFunc(pattern, s.require(role, groups, h)) }
+	}
+	viewer, operator, admin := handle(profile.RoleViewer, false), handle(profile.RoleOperator, false), handle(profile.RoleAdmin, false)
+	// Access group keys only reach these routes, which keep to the group's
+	// profiles and tools
+	groupViewer, groupOperator := handle(profile.RoleViewer, true), handle(profile.RoleOperator, true)
+
+	// Status, catalog, logs and events
+	groupViewer("GET /api/profiles", s.handleGetProfiles)
+	viewer("POST /api/profiles/lint", s.handleLintProfile)
+	viewer("GET /api/allow-tools/preview", s.handlePreviewAllowTools)
+	viewer("GET /api/onboarding/state", s.handleGetOnboardingState)
+	groupViewer("GET /api/tools", s.handleGetTools)
+	groupViewer("GET /api/registry", s.handleSearchRegistry)
+	groupViewer("GET /api/health", s.handleHealth)
+	groupViewer("GET /api/ping", s.handlePing)
+	groupViewer("GET /api/version", s.handleGetVersion)
+	viewer("GET /api/clients", s.handleGetClients)
+	viewer("GET /api/clients/status", s.handleGetClientStatus)
+	viewer("GET /api/logs", s.ownerOnly(s.handleGetLogs))
+	viewer("GET /api/logs/stream", s.ownerOnly(s.handleLogStream))
+	groupViewer("GET /api/events", s.ownerOnly(s.handleGetEvents))
+	groupViewer("GET /api/events/stream", s.ownerOnly(s.handleEventStream))
+	groupViewer("GET /api/audit", s.ownerOnly(s.handleGetAudit))
+	groupViewer("GET /api/status", s.handleGetStatus)
+	viewer("GET /api/sessions", s.handleGetSessions)
+	viewer("GET /metrics", s.ownerOnly(s.handleMetrics))
+
+	// Running tools and sessions
+	operator("POST /api/reload", s.ownerOnly(s.handleReload))
+	operator("POST /api/tools/refresh", s.ownerOnly(s.handleRefreshTools))
+	operator("POST /api/tools/{name}/unquarantine", s.ownerOnly(s.handleUnquarantineTool))
+	operator("GET /api/tool-params", s.handleGetToolParams)
+	operator("PUT /api/tool-params", s.handleSaveToolParams)
+	operator("GET /api/tool-presets", s.handleGetPresets)
+	operator("GET /api/tool-presets/export", s.handleExportPresets)
+	operator("POST /api/tool-presets/import", s.handleImportPresets)
+	operator("PUT /api/tool-presets/{tool}/{name}", s.handleSavePreset)
+	operator("DELETE /api/tool-presets/{tool}/{name}", s.handleDeletePreset)
+	operator("POST /api/logs", s.ownerOnly(s.handlePostLog))
+	operator("DELETE /api/logs", s.ownerOnly(s.handleClearLogs))
+	operator("GET /api/credentials/check", s.handleCheckCredentials)
+	operator("GET /api/tools/{name}/auth", s.handleGetToolAuth)
+	groupViewer("GET /api/tools/{name}/entry", s.handleGetToolEntry)
+	groupViewer("GET /api/tools/{name}/definition", s.handleGetToolDefinition)
+	operator("GET /api/tools/{name}/env-preview", s.handleEnvPreview)
+	operator("GET /api/credentials/ai", s.handleCheckAICredentials)
+	groupOperator("POST /api/tools/call", s.handleCallTool)
+	groupOperator("POST /api/tools/activate", s.handleActivateTool)
+	groupOperator("POST /api/tools/deactivate", s.handleDeactivateTool)
+	operator("DELETE /api/cache", s.handleClearCache)
+	operator("DELETE /api/sessions/{id}", s.handleDeleteSession)
+	operator("GET /api/sessions/{id}/transcript", s.handleGetTranscript)
+
+	// Configuration, credentials and the daemon itself. Probing runs any
+	// command and can save what it finds to the registry
+	admin("POST /api/tools/verify", s.ownerOnly(s.handleVerifyTool))
+	admin("POST /api/probe", s.ownerOnly(s.handleProbe))
+	admin("POST /api/profiles", s.handleCreateProfile)
+	admin("PUT /api/profiles", s.handleUpdateProfile)
+	admin("DELETE /api/profiles", s.handleDeleteProfile)
+	admin("POST /api/profiles/regenerate-key", s.handleRegenerateProfileKey)
+	admin("GET /api/profiles/export", s.ownerOnly(s.handleExportBundle))
+	admin("POST /api/profiles/import", s.ownerOnly(s.handleImportBundle))
+	admin("POST /api/clients/sync", s.ownerOnly(s.handleInstallIntegration))
+	admin("POST /api/onboarding/start-fresh", s.ownerOnly(s.handleOnboardingStartFresh))
+	admin("POST /api/onboarding/import", s.ownerOnly(s.handleOnboardingImport))
+	admin("POST /api/onboarding/demo", s.ownerOnly(s.handleOnboardingDemo))
+	admin("POST /api/onboarding/state", s.ownerOnly(s.handleAdvanceOnboarding))
+	admin("POST /api/gc", s.ownerOnly(s.handleGC))
+	admin("POST /api/reset", s.ownerOnly(s.handleReset))
+	admin("POST /api/shutdown", s.ownerOnly(s.handleShutdown))
+	admin("POST /api/tools", s.ownerOnly(s.handleRegisterTool))
+	admin("PUT /api/tools/{name}", s.ownerOnly(s.handleUpdateTool))
+	admin("DELETE /api/tools", s.ownerOnly(s.handleDeleteTool))
+	admin("PUT /api/tools/{name}/override", s.ownerOnly(s.handleSetOverride))
+	admin("DELETE /api/tools/{name}/override", s.ownerOnly(s.handleDeleteOverride))
+	admin("GET /api/settings", s.ownerOnly(s.handleGetSettings))
+	admin("PUT /api/settings", s.ownerOnly(s.handleUpdateSettings))
+	admin("POST /api/settings/regenerate-key", s.ownerOnly(s.handleRegenerateKey))
+	admin("POST /api/logs/reveal", s.ownerOnly(s.handleRevealLogs))
+	admin("POST /api/credentials", s.handleSetCredential)
+	admin("POST /api/credentials/oauth/start", s.handleStartOAuth)
+	s.mux.HandleFunc("GET "+oauthCallbackPath, s.handleOAuthCallback)
+	s.mux.HandleFunc("POST "+oauthCallbackPath, s.handleOAuthCallback)
+	admin("DELETE /api/credentials", s.handleDeleteCredential)
+	admin("POST /api/credentials/ai-primary", s.handleSetPrimaryAIKey)
+	admin("POST /api/credentials/ai-fallback", s.handleSetFallbackAIKey)
+	admin("DELETE /api/credentials/ai-primary", s.handleDeletePrimaryAIKey)
+	admin("DELETE /api/credentials/ai-fallback", s.handleDeleteFallbackAIKey)
+	admin("GET /api/control-tokens", s.ownerOnly(s.handleListControlTokens))
+	admin("POST /api/control-tokens", s.ownerOnly(s.handleCreateControlToken))
+	admin("DELETE /api/control-tokens/{name}", s.ownerOnly(s.handleDeleteControlToken))
 }
 
 func (s *ControlServer) handleCallTool(w http.ResponseWriter, r *http.Request) {
@@ -105,20 +207,36 @@ func (s *ControlServer) handleCallTool(w http.ResponseWriter, r *http.Request) {
 		Server    string                 `json:"server"`
 		Tool      string                 `json:"tool"`
 		Arguments map[string]interface{} `json:"arguments"`
+		// Preset names saved arguments of the tool; Arguments override them
+		Preset string `json:"preset"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
 	profileID := req.Profile
 	if profileID == "" {
-		profileID = s.settings.LastProfileID
+		profileID = s.settings.Get().LastProfileID
+	}
+	if !s.groupAllows(w, r, profileID, req.Tool, req.Server) {
+		return
+	}
+	if req.Preset != "" {
+		args, err := s.presetArguments(req.Tool, req.Preset, req.Arguments)
+		if errors.Is(err, errNoPreset) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		req.Arguments = args
 	}
 
 	engine, ok := s.manager.GetEngine(profileID)
 	if !ok {
-		http.Error(w, "profile not found", http.StatusNotFound)
+		http.Error(w, i18n.T(s.locale(r), "api.profile_not_found"), http.StatusNotFound)
 		return
 	}
 
@@ -135,8 +253,16 @@ func (s *ControlServer) handleCallTool(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("server '%s' is not active", req.Server), http.StatusNotFound)
 		return
 	}
+	// The tool must be the server's, or the server named above says nothing
+	// about what is called
+	if owner, found := engine.GetServerForTool(req.Tool); found && owner != req.Server {
+		http.Error(w, fmt.Sprintf("tool '%s' is not provided by server '%s'", req.Tool, req.Server), http.StatusNotFound)
+		return
+	}
 
+	started := time.Now()
 	result, err := engine.CallTool(req.Tool, req.Arguments)
+	audit.Append(audit.NewRecord(audit.SourceControl, profileID, req.Server, req.Tool, req.Arguments, time.Since(started), err))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -158,12 +284,15 @@ func (s *ControlServer) handleActivateTool(w http.ResponseWriter, r *http.Reques
 
 	profileID := req.Profile
 	if profileID == "" {
-		profileID = s.settings.LastProfileID
+		profileID = s.settings.Get().LastProfileID
+	}
+	if !s.groupAllows(w, r, profileID, req.Server, req.Server) {
+		return
 	}
 
 	engine, ok := s.manager.GetEngine(profileID)
 	if !ok {
-		http.Error(w, "profile not found", http.StatusNotFound)
+		http.Error(w, i18n.T(s.locale(r), "api.profile_not_found"), http.StatusNotFound)
 		return
 	}
 
@@ -176,6 +305,102 @@ func (s *ControlServer) handleActivateTool(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(map[string]string{"status": "activated", "server": req.Server})
 }
 
+// handleDeactivateTool stops a server in a profile's engine.
+func (s *ControlServer) handleDeactivateTool(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Profile string `json:"profile"`
+		Server  string `json:"server"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profileID := req.Profile
+	if profileID == "" {
+		profileID = s.settings.Get().LastProfileID
+	}
+	if !s.groupAllows(w, r, profileID, req.Server, req.Server) {
+		return
+	}
+
+	engine, ok := s.manager.GetEngine(profileID)
+	if !ok {
+		http.Error(w, i18n.T(s.locale(r), "api.profile_not_found"), http.StatusNotFound)
+		return
+	}
+	if !engine.IsActive(req.Server) {
+		http.Error(w, fmt.Sprintf("server '%s' is not active", req.Server), http.StatusNotFound)
+		return
+	}
+
+	if err := engine.Remove(req.Server); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "deactivated", "server": req.Server})
+}
+
+// handleClearCache drops cached tool results, of ?profile and ?server if
+// given.
+func (s *ControlServer) handleClearCache(w http.ResponseWriter, r *http.Request) {
+	profileID, server := r.URL.Query().Get("profile"), r.URL.Query().Get("server")
+	profileIDs := []string{profileID}
+	if profileID == "" {
+		profileIDs = profileIDs[:0]
+		for _, p := range s.manager.GetProfiles() {
+			profileIDs = append(profileIDs, p.ID)
+		}
+	}
+
+	cleared := 0
+	for _, id := range profileIDs {
+		engine, ok := s.manager.GetEngine(id)
+		if !ok {
+			if profileID != "" {
+				http.Error(w, i18n.T(s.locale(r), "api.profile_not_found"), http.StatusNotFound)
+				return
+			}
+			continue
+		}
+		cleared += engine.ClearCache(server)
+	}
+	logger.AddLog("INFO", fmt.Sprintf("Cleared %d cached tool results", cleared))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"cleared": cleared})
+}
+
+// handleSearchRegistry returns the registry entries matching ?q, ?category
+// and every ?tag, best matches first, as the engine of ?profile sees them
+// (its overlay included). Without ?profile the shared catalog is searched.
+func (s *ControlServer) handleSearchRegistry(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	engine := s.manager.index
+	if id := q.Get("profile"); id != "" {
+		var ok bool
+		if engine, ok = s.manager.GetEngine(id); !ok {
+			http.Error(w, i18n.T(s.locale(r), "api.profile_not_found"), http.StatusNotFound)
+			return
+		}
+	}
+
+	entries := []discovery.ToolDefinition{}
+	for _, td := range engine.Search(discovery.SearchOptions{
+		Query:    q.Get("q"),
+		Category: q.Get("category"),
+		Tags:     q["tag"],
+	}) {
+		// Builtins are always there; they can't be activated
+		if td.Source != "builtin" {
+			entries = append(entries, td)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
 func (s *ControlServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -189,7 +414,25 @@ func (s *ControlServer) handlePing(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (s *ControlServer) handleGetVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(version.Get())
+}
+
+// statusSchemaVersion is bumped whenever GET /api/status changes shape
+// incompatibly.
+const statusSchemaVersion = 2
+
+// handleGetStatus reports gateway and per-profile status. It accepts the same
+// running, prefix, fields, offset and limit parameters as GET /api/profiles.
 func (s *ControlServer) handleGetStatus(w http.ResponseWriter, r *http.Request) {
+	query, err := parseProfileQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group, restricted := accessGroupFrom(r.Context())
 	profiles := s.manager.GetProfiles()
 
 	type ToolStatus struct {
@@ -198,20 +441,52 @@ func (s *ControlServer) handleGetStatus(w http.ResponseWriter, r *http.Request)
 	}
 
 	type ProfileStatus struct {
-		ID          string       `json:"id"`
-		Running     bool         `json:"running"`
-		ActiveTools int          `json:"active_tools"`
-		ToolStatus  []ToolStatus `json:"tool_status"`
-	}
-
-	info := make([]ProfileStatus, len(profiles))
+		ID      string `json:"id"`
+		Running bool   `json:"running"`
+		// Health is "ok", "degraded" (a server is unhealthy, crashed,
+		// restarting or failed to start) or "stopped" (the profile has no
+		// engine)
+		Health       string                         `json:"health"`
+		ActiveTools  int                            `json:"active_tools"`
+		ToolStatus   []ToolStatus                   `json:"tool_status"`
+		Servers      []discovery.ServerStatus       `json:"servers"`
+		RecentErrors []discovery.CallError          `json:"recent_errors"`
+		CallStats    map[string]discovery.CallStats `json:"call_stats,omitempty"`
+		Cache        *discovery.CacheStats          `json:"cache,omitempty"` // Tool result cache (see discovery/cache.go)
+		RateLimit    *RateLimitStatus               `json:"rate_limit,omitempty"`
+	}
+
+	info := make([]ProfileStatus, 0, len(profiles))
 	s.manager.mu.RLock()
-	for i, p := range profiles {
+	for _, p := range profiles {
 		engine, running := s.manager.engines[p.ID]
+		if !query.match(p.ID, running) || (restricted && !group.AllowsProfile(p.ID)) {
+			continue
+		}
 
 		toolStatuses := []ToolStatus{}
+		servers := []discovery.ServerStatus{}
+		recentErrors := []discovery.CallError{}
+		health := "stopped"
 		activeTools := 0
+		var callStats map[string]discovery.CallStats
+		var cache *discovery.CacheStats
+		var rateLimit *RateLimitStatus
+		if s.gateway != nil {
+			rateLimit = s.gateway.limits.Status(p)
+		}
 		if running {
+			callStats = engine.Stats()
+			cacheStats := engine.CacheStats()
+			cache = &cacheStats
+			servers = engine.ServerStatuses()
+			recentErrors = engine.RecentErrors()
+			health = "ok"
+			for _, server := range servers {
+				if server.State != "ready" && server.State != "starting" {
+					health = "degraded"
+				}
+			}
 			activeNames := engine.ListActive()
 			activeTools = len(activeNames)
 
@@ -221,8 +496,9 @@ func (s *ControlServer) handleGetStatus(w http.ResponseWriter, r *http.Request)
 				activeMap[name] = true
 			}
 
-			// Add allowed tools
-			for _, name := range p.AllowTools {
+			// Add allowed tools, with expressions expanded
+			allowed := engine.AllowedServers(p.AllowTools)
+			for _, name := range allowed {
 				status := "idle"
 				if activeMap[name] {
 					status = "ok"
@@ -235,14 +511,7 @@ func (s *ControlServer) handleGetStatus(w http.ResponseWriter, r *http.Request)
 
 			// Add active tools that might not be in AllowTools (e.g. builtins)
 			for _, name := range activeNames {
-				alreadyAdded := false
-				for _, added := range p.AllowTools {
-					if added == name {
-						alreadyAdded = true
-						break
-					}
-				}
-				if !alreadyAdded {
+				if !slices.Contains(allowed, name) {
 					toolStatuses = append(toolStatuses, ToolStatus{
 						Name:   name,
 						Status: "ok",
@@ -251,30 +520,62 @@ func (s *ControlServer) handleGetStatus(w http.ResponseWriter, r *http.Request)
 			}
 		}
 
-		info[i] = ProfileStatus{
-			ID:          p.ID,
-			Running:     running,
-			ActiveTools: activeTools,
-			ToolStatus:  toolStatuses,
-		}
-		if info[i].ToolStatus == nil {
-			info[i].ToolStatus = []ToolStatus{}
-		}
+		info = append(info, ProfileStatus{
+			ID:           p.ID,
+			Running:      running,
+			Health:       health,
+			ActiveTools:  activeTools,
+			ToolStatus:   toolStatuses,
+			Servers:      servers,
+			RecentErrors: recentErrors,
+			CallStats:    callStats,
+			Cache:        cache,
+			RateLimit:    rateLimit,
+		})
 	}
 	s.manager.mu.RUnlock()
 
+	start, end, next := query.page(len(info))
+	items, err := selectFields(info[start:end], query.Fields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type Ports struct {
+		Control int `json:"control"`
+		Gateway int `json:"gateway"`
+	}
+
+	settings := s.settings.Get()
+	uptime := time.Since(s.startedAt)
 	response := struct {
-		GatewayRunning  bool            `json:"gateway_running"`
-		ControlPort     int             `json:"control_port"`
-		McpPort         int             `json:"mcp_port"`
-		ActiveProfileID string          `json:"active_profile_id"`
-		Profiles        []ProfileStatus `json:"profiles"`
+		SchemaVersion   int            `json:"schema_version"`
+		Running         bool           `json:"running"`
+		Version         version.Info   `json:"version"`
+		StartedAt       time.Time      `json:"started_at"`
+		UptimeSeconds   int64          `json:"uptime_seconds"`
+		Ports           Ports          `json:"ports"`
+		ActiveProfileID string         `json:"active_profile_id"`
+		Profiles        interface{}    `json:"profiles"`
+		Total           int            `json:"total"`
+		NextOffset      *int           `json:"next_offset,omitempty"`
+		Tunnel          *tunnel.Status `json:"tunnel,omitempty"`
 	}{
-		GatewayRunning:  true,
-		ControlPort:     s.settings.ControlPort,
-		McpPort:         s.settings.McpPort,
-		ActiveProfileID: s.settings.LastProfileID,
-		Profiles:        info,
+		SchemaVersion:   statusSchemaVersion,
+		Running:         true,
+		Version:         version.Get(),
+		StartedAt:       s.startedAt.UTC(),
+		UptimeSeconds:   int64(uptime.Seconds()),
+		Ports:           Ports{Control: settings.ControlPort, Gateway: settings.McpPort},
+		ActiveProfileID: settings.LastProfileID,
+		Profiles:        items,
+		Total:           len(info),
+		NextOffset:      next,
+	}
+	if s.tunnel != nil {
+		status := s.tunnel.Status()
+		response.Tunnel = &status
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -283,10 +584,10 @@ func (s *ControlServer) handleGetStatus(w http.ResponseWriter, r *http.Request)
 
 func (s *ControlServer) handleRegenerateKey(w http.ResponseWriter, r *http.Request) {
 	newKey := profile.GenerateAPIKey()
-	s.settings.GatewayAPIKey = newKey
+	updated := s.settings.Update(func(st *profile.Settings) { st.GatewayAPIKey = newKey })
 
 	if s.store != nil {
-		if err := s.store.SaveSettings(*s.settings); err != nil {
+		if err := s.store.SaveSettings(updated); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -350,7 +651,15 @@ func (s *ControlServer) handleSaveToolParams(w http.ResponseWriter, r *http.Requ
 }
 
 func (s *ControlServer) handleGetLogs(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseNonNegative(r.URL.Query(), "limit")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	logs := logger.GetLogs()
+	if limit > 0 {
+		logs = logger.Tail(limit)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"logs": logs,
@@ -374,10 +683,10 @@ func (s *ControlServer) handlePostLog(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *ControlServer) handleLogStream(w http.ResponseWriter, r *http.Request) {
+	streamWithoutDeadline(w)
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
@@ -397,6 +706,11 @@ func (s *ControlServer) handleLogStream(w http.ResponseWriter, r *http.Request)
 		case entry := <-logChan:
 			data, _ := json.Marshal(entry)
 			fmt.Fprintf(w, "event: log\ndata: %s\n\n", string(data))
+			// Control-plane events also get their own event type so the
+			// desktop app can react without parsing every log line
+			if entry.Event != "" {
+				fmt.Fprintf(w, "event: control\ndata: %s\n\n", string(data))
+			}
 			flusher.Flush()
 		case <-r.Context().Done():
 			return
@@ -436,7 +750,7 @@ func (s *ControlServer) handleRevealLogs(w http.ResponseWriter, r *http.Request)
 
 func (s *ControlServer) handleGetSettings(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(s.settings)
+	json.NewEncoder(w).Encode(s.settings.Get())
 }
 
 func (s *ControlServer) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
@@ -446,37 +760,108 @@ func (s *ControlServer) handleUpdateSettings(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	s.mu.Lock()
-	*s.settings = settings
-	s.mu.Unlock()
+	if err := settings.CORS.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]string{"error": "cors: " + err.Error()})
+		return
+	}
+
+	// Wizard progress and control tokens aren't part of the settings JSON;
+	// keep what we have
+	settings.Onboarding = s.settings.Get().Onboarding
+	settings.ControlTokens = s.settings.Get().ControlTokens
+	s.settings.Set(settings)
 
 	logger.SetVerbose(settings.VerboseLogging)
+	logger.SetConsoleLevel(settings.ConsoleLogLevel)
+	s.manager.SetInstanceID(settings.InstanceID)
 	if s.store != nil {
-		if err := s.store.SaveSettings(*s.settings); err != nil {
+		if err := s.store.SaveSettings(settings); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 	}
+	events.Record(events.ConfigChanged, "", "", map[string]interface{}{"change": "settings_updated"})
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(s.settings)
+	json.NewEncoder(w).Encode(settings)
+}
+
+// locale returns the message locale for a request: Accept-Language wins,
+// then the configured settings locale.
+func (s *ControlServer) locale(r *http.Request) string {
+	return i18n.FromAcceptLanguage(r.Header.Get("Accept-Language"), s.settings.Get().Locale)
 }
 
 func (s *ControlServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Global CORS headers
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	if s.settings.Get().CompressResponses {
+		if cw := newCompressWriter(w, r); cw != nil {
+			defer cw.Close()
+			w = cw
+		}
+	}
 
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
+	rw := &recoveryWriter{ResponseWriter: w}
+	defer recoverPanic(rw, r, "control", false)
+	w = rw
+
+	if !checkHost(w, r, s.settings.Get(), s.manager.GetProfiles()) {
+		return
+	}
+	if !applyCORS(w, r, s.settings.Get().CORS, controlCORSMethods) {
 		return
 	}
 
+	// The provider sends the browser back without a token
+	if r.URL.Path == oauthCallbackPath {
+		s.mux.ServeHTTP(w, r)
+		return
+	}
+
+	// Access group keys only reach the control API if the group allows it
+	if group, ok := s.settings.Get().AccessGroupForKey(requestAPIKey(r)); ok {
+		if !group.Control {
+			http.Error(w, i18n.T(s.locale(r), "api.forbidden"), http.StatusForbidden)
+			return
+		}
+		r = r.WithContext(withControlRole(withAccessGroup(r.Context(), group), group.Role()))
+	} else {
+		role, ok := s.controlRole(r)
+		if !ok {
+			logger.AddLog("WARN", fmt.Sprintf("Rejected %s %s from %s: no valid control token", r.Method, r.URL.Path, r.RemoteAddr))
+			http.Error(w, i18n.T(s.locale(r), "api.unauthorized"), http.StatusUnauthorized)
+			return
+		}
+		r = r.WithContext(withControlRole(r.Context(), role))
+
+		// Users' tokens only reach their own scope
+		if user := s.controlUser(r); user != "" {
+			s.serveUser(w, r, user)
+			return
+		}
+	}
+
 	s.mux.ServeHTTP(w, r)
 }
 
+// handleGetProfiles lists profiles. Query parameters: running=true|false,
+// prefix=<id prefix>, fields=<comma-separated JSON fields>, offset, limit and
+// include=settings. Settings are only returned when asked for so that polling
+// clients don't receive the gateway API key on every request.
 func (s *ControlServer) handleGetProfiles(w http.ResponseWriter, r *http.Request) {
+	query, err := parseProfileQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group, restricted := accessGroupFrom(r.Context())
+	isAdmin := controlRoleFrom(r.Context()).Allows(profile.RoleAdmin)
+	if query.Include["settings"] && !isAdmin {
+		http.Error(w, i18n.T(s.locale(r), "api.forbidden"), http.StatusForbidden)
+		return
+	}
 	profiles := s.manager.GetProfiles()
 
 	type ProfileInfo struct {
@@ -484,17 +869,30 @@ func (s *ControlServer) handleGetProfiles(w http.ResponseWriter, r *http.Request
 		Running bool `json:"running"`
 	}
 
-	info := make([]ProfileInfo, len(profiles))
+	info := make([]ProfileInfo, 0, len(profiles))
 	s.manager.mu.RLock()
-	for i, p := range profiles {
+	for _, p := range profiles {
 		_, running := s.manager.engines[p.ID]
-		info[i] = ProfileInfo{
+		if !query.match(p.ID, running) || (restricted && !group.AllowsProfile(p.ID)) {
+			continue
+		}
+		if !isAdmin {
+			p = redactProfile(p)
+		}
+		info = append(info, ProfileInfo{
 			Profile: p,
 			Running: running,
-		}
+		})
 	}
 	s.manager.mu.RUnlock()
 
+	start, end, next := query.page(len(info))
+	items, err := selectFields(info[start:end], query.Fields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	configPath := ""
 	settingsPath := ""
 	if s.store != nil {
@@ -503,18 +901,25 @@ func (s *ControlServer) handleGetProfiles(w http.ResponseWriter, r *http.Request
 	}
 
 	response := struct {
-		Profiles           []ProfileInfo    `json:"profiles"`
-		Settings           profile.Settings `json:"settings"`
-		OnboardingRequired bool             `json:"onboarding_required"`
-		ConfigPath         string           `json:"config_path"`
-		SettingsPath       string           `json:"settings_path"`
+		Profiles           interface{}       `json:"profiles"`
+		Total              int               `json:"total"`
+		NextOffset         *int              `json:"next_offset,omitempty"`
+		Settings           *profile.Settings `json:"settings,omitempty"`
+		OnboardingRequired bool              `json:"onboarding_required"`
+		ConfigPath         string            `json:"config_path"`
+		SettingsPath       string            `json:"settings_path"`
 	}{
-		Profiles:           info,
-		Settings:           *s.settings,
+		Profiles:           items,
+		Total:              len(info),
+		NextOffset:         next,
 		OnboardingRequired: s.onboardingRequired,
 		ConfigPath:         configPath,
 		SettingsPath:       settingsPath,
 	}
+	if query.Include["settings"] {
+		settings := s.settings.Get()
+		response.Settings = &settings
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -539,6 +944,7 @@ func (s *ControlServer) handleOnboardingStartFresh(w http.ResponseWriter, r *htt
 	}
 
 	s.onboardingRequired = false
+	s.recordProfileCreated(defaultProfile.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -561,8 +967,9 @@ func (s *ControlServer) handleOnboardingImport(w http.ResponseWriter, r *http.Re
 		}
 	}
 
-	if len(s.manager.GetProfiles()) > 0 {
+	if profiles := s.manager.GetProfiles(); len(profiles) > 0 {
 		s.onboardingRequired = false
+		s.recordProfileCreated(profiles[0].ID)
 	}
 
 	if s.store != nil {
@@ -579,13 +986,19 @@ func (s *ControlServer) handleOnboardingImport(w http.ResponseWriter, r *http.Re
 func (s *ControlServer) handleReset(w http.ResponseWriter, r *http.Request) {
 	s.manager.ClearProfiles()
 	s.onboardingRequired = true
-	
-	s.mu.Lock()
-	*s.settings = profile.DefaultSettings()
-	s.mu.Unlock()
+
+	// Keep who may reach the daemon and how: without its control tokens a
+	// reset daemon would take every caller for an admin
+	old := s.settings.Get()
+	settings := profile.DefaultSettings()
+	settings.ControlTokens = old.ControlTokens
+	settings.AccessGroups = old.AccessGroups
+	settings.CORS = old.CORS
+	settings.HTTPServer = old.HTTPServer
+	s.settings.Set(settings)
 
 	if s.store != nil {
-		if err := s.store.Save(s.manager.GetProfiles(), *s.settings); err != nil {
+		if err := s.store.Save(s.manager.GetProfiles(), s.settings.Get()); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -602,7 +1015,12 @@ func (s *ControlServer) handleShutdown(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"status": "shutdown_initiated"})
 
-	// Gracefully shutdown the server after a short delay to allow the response to be sent
+	// The daemon shuts its servers down gracefully, which lets this response
+	// finish; without a shutdown func, exit after a short delay instead
+	if s.shutdown != nil {
+		go s.shutdown()
+		return
+	}
 	go func() {
 		time.Sleep(500 * time.Millisecond)
 		os.Exit(0)
@@ -610,19 +1028,32 @@ func (s *ControlServer) handleShutdown(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *ControlServer) handleGetTools(w http.ResponseWriter, r *http.Request) {
-	engine := discovery.NewDiscoveryEngine(context.Background(), s.manager.wasmDir, s.manager.registryDir)
-
-	s.manager.mu.RLock()
-	for _, td := range s.manager.customTools {
-		engine.Register(td)
+	filter, err := parseReliabilityFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	s.manager.mu.RUnlock()
+	tools := s.manager.Catalog()
+	if id := r.URL.Query().Get("profile"); id != "" {
+		if _, ok := s.manager.GetProfile(id); !ok {
+			http.Error(w, fmt.Sprintf("Profile '%s' not found", id), http.StatusNotFound)
+			return
+		}
+		tools = s.manager.ProfileCatalog(id)
+	}
+	tools = filter.Apply(tools, s.manager.ActivationMetrics().All())
 
-	tools := engine.Find("")
+	warnings := []string{}
+	for _, td := range tools {
+		if notice := td.DeprecationNotice(); notice != "" {
+			warnings = append(warnings, notice)
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"tools": tools,
+		"tools":    tools,
+		"warnings": warnings,
 	})
 }
 
@@ -637,6 +1068,9 @@ func (s *ControlServer) handleRefreshTools(w http.ResponseWriter, r *http.Reques
 	s.manager.mu.RUnlock()
 
 	logger.AddLog("INFO", fmt.Sprintf("Refreshing tools for %d profile(s): %v", len(profileIDs), profileIDs))
+	if err := s.manager.ReloadIndex(); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("Failed to refresh registry index: %v", err))
+	}
 
 	if len(engines) == 0 {
 		logger.AddLog("WARN", "No active engines found - nothing to refresh")
@@ -703,18 +1137,8 @@ func (s *ControlServer) handleVerifyTool(w http.ResponseWriter, r *http.Request)
 	// Step 1: Find the tool definition in the registry
 	logger.AddLog("INFO", fmt.Sprintf("[Verify] Step 1: Looking up tool '%s' in registry...", req.ToolName))
 	
-	engine := discovery.NewDiscoveryEngine(r.Context(), s.manager.wasmDir, s.manager.registryDir)
-	tools := engine.Find("")
-	
-	var toolDef *discovery.ToolDefinition
-	for i := range tools {
-		if tools[i].Name == req.ToolName {
-			toolDef = &tools[i]
-			break
-		}
-	}
-
-	if toolDef == nil {
+	toolDef, found := s.manager.FindTool(req.ToolName)
+	if !found {
 		logger.AddLog("ERROR", fmt.Sprintf("[Verify] Tool '%s' not found in registry", req.ToolName))
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
@@ -746,7 +1170,7 @@ func (s *ControlServer) handleVerifyTool(w http.ResponseWriter, r *http.Request)
 	logger.AddLog("INFO", fmt.Sprintf("[Verify] Command: %s %v", toolDef.Runtime.Command, toolDef.Runtime.Args))
 
 	// Get credentials for this tool
-	credManager := engine.GetCredentialManager()
+	credManager := s.manager.Credentials()
 	toolEnv := make(map[string]string)
 	
 	// Check if we have credentials in the request (from the UI form)
@@ -763,12 +1187,13 @@ func (s *ControlServer) handleVerifyTool(w http.ResponseWriter, r *http.Request)
 	// Also layer in stored credentials if not provided in request
 	if toolDef.Authorization != nil {
 		creds, err := credManager.GetCredentialsForTool(req.ToolName, toolDef.Authorization)
-		if err == nil {
-			for k, v := range creds {
-				if _, exists := toolEnv[k]; !exists {
-					toolEnv[k] = v
-					logger.AddLog("INFO", fmt.Sprintf("[Verify] Injected stored credential: %s", k))
-				}
+		if err != nil {
+			logger.AddLog("WARN", fmt.Sprintf("[Verify] %v", err))
+		}
+		for k, v := range creds {
+			if _, exists := toolEnv[k]; !exists {
+				toolEnv[k] = v
+				logger.AddLog("INFO", fmt.Sprintf("[Verify] Injected stored credential: %s", k))
 			}
 		}
 	}
@@ -777,6 +1202,7 @@ func (s *ControlServer) handleVerifyTool(w http.ResponseWriter, r *http.Request)
 	verifyResult, err := discovery.VerifyMCPTool(r.Context(), toolDef, toolEnv)
 	if err != nil {
 		logger.AddLog("ERROR", fmt.Sprintf("[Verify] Failed to verify tool '%s': %v", req.ToolName, err))
+		events.Record(events.ToolVerified, "", req.ToolName, map[string]interface{}{"success": false, "error": err.Error()})
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -848,9 +1274,17 @@ func (s *ControlServer) handleVerifyTool(w http.ResponseWriter, r *http.Request)
 			}
 		}
 		s.manager.mu.RUnlock()
+		s.manager.ReloadIndex()
 		logger.AddLog("INFO", "[Verify] In-memory registry reload complete")
 	}
 
+	events.Record(events.ToolVerified, "", req.ToolName, map[string]interface{}{
+		"success":          true,
+		"server_tools":     len(verifyResult.ServerTools),
+		"tools_changed":    toolsChanged,
+		"registry_updated": registryUpdated,
+	})
+
 	// Build response
 	response := map[string]interface{}{
 		"success":          true,
@@ -880,6 +1314,71 @@ func (s *ControlServer) handleVerifyTool(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
+// handleProbe connects to an MCP server given by command or URL, outside
+// the registry, and returns a compatibility report (see discovery.Probe).
+// With "save", a server that passes is also written to the custom registry
+// and loaded.
+func (s *ControlServer) handleProbe(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		discovery.ProbeOptions
+		TimeoutSeconds int                     `json:"timeout_seconds"`
+		Save           *discovery.EntryOptions `json:"save,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	opts := req.ProbeOptions
+	opts.Timeout = time.Duration(req.TimeoutSeconds) * time.Second
+
+	logger.AddLog("INFO", fmt.Sprintf("[Probe] Probing %s%s", opts.URL, strings.TrimSpace(opts.Command+" "+strings.Join(opts.Args, " "))))
+	report, err := discovery.Probe(r.Context(), opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !report.Compatible {
+		logger.AddLog("WARN", fmt.Sprintf("[Probe] %s is not compatible", report.Target))
+	}
+
+	resp := struct {
+		*discovery.ProbeReport
+		Entry      *registry.MCPEntry         `json:"entry,omitempty"`
+		SavedPath  string                     `json:"saved_path,omitempty"`
+		Validation *registry.ValidationResult `json:"validation,omitempty"`
+		SaveError  string                     `json:"save_error,omitempty"`
+	}{ProbeReport: report}
+	if req.Save != nil {
+		resp.Entry, resp.SavedPath, resp.Validation, err = s.saveProbeEntry(report, *req.Save)
+		if err != nil {
+			resp.SaveError = err.Error()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// saveProbeEntry writes the registry entry for a probed server to the
+// custom registry and reloads the registry of every engine.
+func (s *ControlServer) saveProbeEntry(report *discovery.ProbeReport, opts discovery.EntryOptions) (*registry.MCPEntry, string, *registry.ValidationResult, error) {
+	if s.manager.registryDir == "" {
+		return nil, "", nil, fmt.Errorf("no registry directory configured")
+	}
+	entry, err := report.RegistryEntry(opts)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	path, result, err := discovery.SaveRegistryEntry(s.manager.registryDir, entry, opts.Overwrite)
+	if err != nil {
+		return entry, "", result, err
+	}
+	logger.AddLog("INFO", fmt.Sprintf("[Probe] Saved registry entry %s to %s", entry.Name, path))
+
+	s.manager.ReloadRegistries()
+	return entry, path, result, nil
+}
+
 // updateRegistryTools updates the tools array in the registry JSON file for a specific tool.
 func (s *ControlServer) updateRegistryTools(toolName string, newTools []registry.Tool) error {
 	if s.manager.registryDir == "" {
@@ -961,9 +1460,77 @@ type ClientDefinition struct {
 		License string `json:"license,omitempty"`
 		Pricing string `json:"pricing,omitempty"`
 	} `json:"metadata,omitempty"`
+
+	// Detection rules from the definition file, and what they found on this machine
+	Detection        *integration.DetectionRules `json:"detection,omitempty"`
+	InstalledVersion string                      `json:"installed_version,omitempty"`
+	ConfigPath       string                      `json:"config_path,omitempty"`
+	DetectedBy       string                      `json:"detected_by,omitempty"`
+
+	// PreferredTransport is the transport written when a sync request doesn't
+	// choose one: "sse", "http" or "stdio". Empty means SSE.
+	PreferredTransport string `json:"preferred_transport,omitempty"`
+}
+
+// supportsTransport reports whether the definition lists t among the
+// client's MCP transports. Definitions without the list accept anything.
+func (cd *ClientDefinition) supportsTransport(t integration.Transport) bool {
+	if cd.MCPSupport == nil || len(cd.MCPSupport.Transports) == 0 {
+		return true
+	}
+	for _, name := range cd.MCPSupport.Transports {
+		if parsed, err := integration.ParseTransport(name); err == nil && parsed == t {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *ControlServer) handleGetClients(w http.ResponseWriter, r *http.Request) {
+	clients := s.loadClients()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"clients": clients,
+	})
+}
+
+// loadClients reads the client definitions and runs their detection rules.
+func (s *ControlServer) loadClients() []ClientDefinition {
+	clients := s.readClientDefinitions()
+
+	// Command probes can take a moment each, so detect clients in parallel
+	detector := integration.NewDetector()
+	var wg sync.WaitGroup
+	for i := range clients {
+		if clients[i].Detection == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(cd *ClientDefinition) {
+			defer wg.Done()
+			det := detector.Detect(*cd.Detection)
+			cd.Installed = det.Installed
+			cd.InstalledVersion = det.Version
+			cd.ConfigPath = det.ConfigPath
+			cd.DetectedBy = det.Method
+		}(&clients[i])
+	}
+	wg.Wait()
+	return clients
+}
+
+// clientDefinition returns the definition of one client, without detection.
+func (s *ControlServer) clientDefinition(id string) (ClientDefinition, bool) {
+	for _, cd := range s.readClientDefinitions() {
+		if cd.ID == id {
+			return cd, true
+		}
+	}
+	return ClientDefinition{}, false
+}
+
+func (s *ControlServer) readClientDefinitions() []ClientDefinition {
 	clients := []ClientDefinition{}
 	clientsDir := s.manager.clientsDir
 
@@ -978,132 +1545,119 @@ func (s *ControlServer) handleGetClients(w http.ResponseWriter, r *http.Request)
 					}
 					var cd ClientDefinition
 					if err := json.Unmarshal(data, &cd); err == nil {
-						// Simple installation detection
-						cd.Installed = s.isClientInstalled(cd.ID)
 						clients = append(clients, cd)
 					}
 				}
 			}
 		}
 	}
+	return clients
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"clients": clients,
-	})
+// ClientStatus reports whether a client's config still points at this Scooter.
+type ClientStatus struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Installed  bool   `json:"installed"`
+	ConfigPath string `json:"config_path,omitempty"`
+	Configured bool   `json:"configured"`
+	URL        string `json:"url,omitempty"`
+	Port       int    `json:"port,omitempty"`
+	Profile    string `json:"profile,omitempty"`
+	// Stale means the entry exists but would not work against the current settings.
+	Stale bool `json:"stale"`
+	// Problems are machine-readable reasons: unreadable_config, unknown_url,
+	// wrong_port, unknown_profile, api_key_mismatch.
+	Problems []string `json:"problems,omitempty"`
+	// Repair is the request that rewrites the entry, when one is needed.
+	Repair *RepairAction `json:"repair,omitempty"`
 }
 
-func (s *ControlServer) isClientInstalled(id string) bool {
-	home, _ := os.UserHomeDir()
-	appData := os.Getenv("APPDATA")
-	localAppData := os.Getenv("LOCALAPPDATA")
+// RepairAction is a control API request the UI can send to fix a client.
+type RepairAction struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Body   map[string]string `json:"body"`
+}
 
-	switch id {
-	case "vscode":
-		// Check for VS Code executable or config
-		paths := []string{
-			filepath.Join(home, ".vscode"),
-			filepath.Join(appData, "Code"),
-			filepath.Join(localAppData, "Programs", "Microsoft VS Code"),
-		}
-		for _, p := range paths {
-			if _, err := os.Stat(p); err == nil {
-				return true
+func (s *ControlServer) handleGetClientStatus(w http.ResponseWriter, r *http.Request) {
+	settings := s.settings.Get()
+	statuses := []ClientStatus{}
+
+	for _, cd := range s.loadClients() {
+		st := ClientStatus{ID: cd.ID, Name: cd.Name, Installed: cd.Installed, ConfigPath: cd.ConfigPath}
+
+		if cd.ConfigPath != "" {
+			entry, err := integration.FindScooterEntry(cd.ConfigPath)
+			switch {
+			case err != nil:
+				st.Problems = append(st.Problems, "unreadable_config")
+			case entry != nil:
+				st.Configured = true
+				st.URL = entry.URL
+				st.Port = entry.Port
+				st.Profile = entry.Profile
+				st.Problems = s.clientEntryProblems(entry, settings)
 			}
 		}
-	case "cursor":
-		paths := []string{
-			filepath.Join(home, ".cursor"),
-			filepath.Join(appData, "Cursor"),
-			filepath.Join(localAppData, "Programs", "cursor"),
-		}
-		for _, p := range paths {
-			if _, err := os.Stat(p); err == nil {
-				return true
+		st.Stale = st.Configured && len(st.Problems) > 0
+
+		if st.Installed && (!st.Configured || st.Stale) {
+			profileID := st.Profile
+			if _, ok := s.manager.GetProfile(profileID); !ok {
+				profileID = settings.LastProfileID
 			}
-		}
-	case "claude-desktop":
-		paths := []string{
-			filepath.Join(appData, "Claude"),
-			filepath.Join(localAppData, "Programs", "Claude"),
-		}
-		for _, p := range paths {
-			if _, err := os.Stat(p); err == nil {
-				return true
+			if profileID == "" {
+				profileID = "work"
 			}
-		}
-	case "zed":
-		paths := []string{
-			filepath.Join(home, ".zed"),
-			filepath.Join(appData, "Zed"),
-		}
-		for _, p := range paths {
-			if _, err := os.Stat(p); err == nil {
-				return true
+			st.Repair = &RepairAction{
+				Method: "POST",
+				Path:   "/api/clients/sync",
+				Body:   map[string]string{"target": cd.ID, "profile": profileID},
 			}
 		}
-	case "claude-code":
-		// Usually installed via npm globally
-		return true // Assume true for CLI if we can't easily check
-	case "gemini-cli":
-		// Usually installed via npm globally
-		return true // Assume true for CLI if we can't easily check
+		statuses = append(statuses, st)
 	}
-	return false
-}
 
-func (s *ControlServer) handleRegisterTool(w http.ResponseWriter, r *http.Request) {
-	var td discovery.ToolDefinition
-	if err := json.NewDecoder(r.Body).Decode(&td); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Persist to custom registry folder
-	if s.manager.registryDir != "" {
-		customDir := filepath.Join(s.manager.registryDir, "custom")
-		os.MkdirAll(customDir, 0755)
-
-		filePath := filepath.Join(customDir, fmt.Sprintf("%s.json", td.Name))
-		data, err := json.MarshalIndent(td, "", "  ")
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to serialize tool: %v", err), http.StatusInternalServerError)
-			return
-		}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"clients": statuses,
+	})
+}
 
-		if err := os.WriteFile(filePath, data, 0644); err != nil {
-			http.Error(w, fmt.Sprintf("Failed to save tool file: %v", err), http.StatusInternalServerError)
-			return
-		}
+// clientEntryProblems compares a client's mcp-scooter entry with the running configuration.
+func (s *ControlServer) clientEntryProblems(entry *integration.ScooterEntry, settings profile.Settings) []string {
+	var problems []string
+	if entry.Profile == "" {
+		return append(problems, "unknown_url")
+	}
+	if entry.Port != settings.McpPort {
+		problems = append(problems, "wrong_port")
 	}
 
-	s.manager.mu.Lock()
-	// Check for duplicates in memory
-	found := false
-	for i, existing := range s.manager.customTools {
-		if existing.Name == td.Name {
-			s.manager.customTools[i] = td
-			found = true
-			break
-		}
+	expectedKey := settings.GatewayAPIKey
+	if p, ok := s.manager.GetProfile(entry.Profile); !ok {
+		problems = append(problems, "unknown_profile")
+	} else if p.APIKey != "" {
+		expectedKey = p.APIKey
 	}
-	if !found {
-		s.manager.customTools = append(s.manager.customTools, td)
+	if expectedKey != "" && entry.APIKey != expectedKey {
+		problems = append(problems, "api_key_mismatch")
 	}
-	s.manager.mu.Unlock()
-
-	logger.AddLog("INFO", fmt.Sprintf("Registered and persisted tool: %s", td.Name))
-
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(td)
+	return problems
 }
 
 // handleSetCredential securely stores a credential in the system keychain.
+// With a profile that has isolated credentials, it is stored for that
+// profile only. An identity stores one of several named accounts for the
+// tool; without one, the identity the profile selects is used.
 func (s *ControlServer) handleSetCredential(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		ToolName string `json:"tool_name"`
 		EnvVar   string `json:"env_var"`
 		Value    string `json:"value"`
+		Profile  string `json:"profile,omitempty"`
+		Identity string `json:"identity,omitempty"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -1115,9 +1669,15 @@ func (s *ControlServer) handleSetCredential(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Get credential manager from an active engine
-	engine := discovery.NewDiscoveryEngine(r.Context(), s.manager.wasmDir, s.manager.registryDir)
-	credManager := engine.GetCredentialManager()
+	if req.Identity != "" && !profile.ValidIdentity(req.Identity) {
+		http.Error(w, "identity may only contain letters, digits, '-' and '_'", http.StatusBadRequest)
+		return
+	}
+	credManager, ok := s.manager.ToolCredentials(req.Profile, req.ToolName, req.Identity)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Profile not found: %s", req.Profile), http.StatusNotFound)
+		return
+	}
 
 	if err := credManager.SetCredential(req.ToolName, req.EnvVar, req.Value); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to store credential: %v", err), http.StatusInternalServerError)
@@ -1130,7 +1690,8 @@ func (s *ControlServer) handleSetCredential(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(map[string]string{"status": "stored"})
 }
 
-// handleCheckCredentials checks if required credentials are present for a tool.
+// handleCheckCredentials checks if required credentials are present for a
+// tool, in the credentials of ?profile=<id> and ?identity=<name> if given.
 func (s *ControlServer) handleCheckCredentials(w http.ResponseWriter, r *http.Request) {
 	toolName := r.URL.Query().Get("tool_name")
 	if toolName == "" {
@@ -1139,23 +1700,18 @@ func (s *ControlServer) handleCheckCredentials(w http.ResponseWriter, r *http.Re
 	}
 
 	// Get tool definition to check authorization requirements
-	engine := discovery.NewDiscoveryEngine(r.Context(), s.manager.wasmDir, s.manager.registryDir)
-	tools := engine.Find("")
-
-	var toolDef *discovery.ToolDefinition
-	for i := range tools {
-		if tools[i].Name == toolName {
-			toolDef = &tools[i]
-			break
-		}
-	}
-
-	if toolDef == nil {
+	toolDef, found := s.manager.FindTool(toolName)
+	if !found {
 		http.Error(w, "Tool not found", http.StatusNotFound)
 		return
 	}
 
-	credManager := engine.GetCredentialManager()
+	query := r.URL.Query()
+	credManager, ok := s.manager.ToolCredentials(query.Get("profile"), toolName, query.Get("identity"))
+	if !ok {
+		http.Error(w, fmt.Sprintf("Profile not found: %s", query.Get("profile")), http.StatusNotFound)
+		return
+	}
 	hasAll, missing := credManager.HasRequiredCredentials(toolName, toolDef.Authorization)
 
 	w.Header().Set("Content-Type", "application/json")
@@ -1165,38 +1721,94 @@ func (s *ControlServer) handleCheckCredentials(w http.ResponseWriter, r *http.Re
 	})
 }
 
-func (s *ControlServer) handleDeleteTool(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
-	if name == "" {
-		http.Error(w, "name is required", http.StatusBadRequest)
+// handleGetToolAuth returns the resolved authorization picture for a tool:
+// auth type, help text and, per credential, whether a value is available from
+// the keychain, the profile's env (?profile=<id>) or a default. Secret values
+// are never included.
+func (s *ControlServer) handleGetToolAuth(w http.ResponseWriter, r *http.Request) {
+	toolName := r.PathValue("name")
+
+	toolDef, found := s.manager.FindTool(toolName)
+	if !found {
+		http.Error(w, "Tool not found", http.StatusNotFound)
 		return
 	}
 
-	// Remove from custom registry folder
-	if s.manager.registryDir != "" {
-		filePath := filepath.Join(s.manager.registryDir, "custom", fmt.Sprintf("%s.json", name))
-		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
-			http.Error(w, fmt.Sprintf("Failed to delete tool file: %v", err), http.StatusInternalServerError)
+	var profileEnv map[string]string
+	profileID := r.URL.Query().Get("profile")
+	if profileID != "" {
+		p, ok := s.manager.GetProfile(profileID)
+		if !ok {
+			http.Error(w, fmt.Sprintf("Profile not found: %s", profileID), http.StatusNotFound)
 			return
 		}
+		profileEnv = p.Env
+	}
+	credManager, ok := s.manager.ToolCredentials(profileID, toolName, r.URL.Query().Get("identity"))
+	if !ok {
+		http.Error(w, fmt.Sprintf("Profile not found: %s", profileID), http.StatusNotFound)
+		return
 	}
 
-	s.manager.mu.Lock()
-	// Remove from memory
-	for i, existing := range s.manager.customTools {
-		if existing.Name == name {
-			s.manager.customTools = append(s.manager.customTools[:i], s.manager.customTools[i+1:]...)
-			break
-		}
+	status := credManager.ResolveAuth(toolName, toolDef.Authorization, profileEnv)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleEnvPreview returns the environment a server would be started with
+// in ?profile=<id>, variable by variable, with the source of each value and
+// the sources it overrides. Secret values are masked.
+func (s *ControlServer) handleEnvPreview(w http.ResponseWriter, r *http.Request) {
+	toolName := r.PathValue("name")
+	profileID := r.URL.Query().Get("profile")
+	if profileID == "" {
+		http.Error(w, "profile is required", http.StatusBadRequest)
+		return
 	}
-	s.manager.mu.Unlock()
+	p, ok := s.manager.GetProfile(profileID)
+	engine, hasEngine := s.manager.GetEngine(profileID)
+	if !ok || !hasEngine {
+		http.Error(w, fmt.Sprintf("Profile not found: %s", profileID), http.StatusNotFound)
+		return
+	}
+	// The gateway syncs the profile env on each call; do the same here so
+	// edits show up before the next one
+	engine.SetEnv(p.Env)
 
-	logger.AddLog("INFO", fmt.Sprintf("Deleted tool: %s", name))
+	vars, err := engine.PreviewEnv(toolName)
+	if err != nil {
+		http.Error(w, "Tool not found", http.StatusNotFound)
+		return
+	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"server":  toolName,
+		"profile": profileID,
+		"env":     vars,
+	})
+}
+
+// handleUnquarantineTool lets a registry entry quarantined after repeated
+// failed activations be activated again, once the user has fixed it.
+func (s *ControlServer) handleUnquarantineTool(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !s.manager.ActivationMetrics().Unquarantine(name) {
+		http.Error(w, fmt.Sprintf("Tool is not quarantined: %s", name), http.StatusNotFound)
+		return
+	}
+	logger.AddLog("INFO", fmt.Sprintf("[API] Unquarantined %s", name))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":        name,
+		"quarantined": false,
+	})
 }
 
-// handleDeleteCredential removes a credential from the keychain.
+// handleDeleteCredential removes a credential from the keychain, from the
+// credentials of ?profile=<id> and ?identity=<name> if given.
 func (s *ControlServer) handleDeleteCredential(w http.ResponseWriter, r *http.Request) {
 	toolName := r.URL.Query().Get("tool_name")
 	envVar := r.URL.Query().Get("env_var")
@@ -1206,8 +1818,12 @@ func (s *ControlServer) handleDeleteCredential(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	engine := discovery.NewDiscoveryEngine(r.Context(), s.manager.wasmDir, s.manager.registryDir)
-	credManager := engine.GetCredentialManager()
+	query := r.URL.Query()
+	credManager, ok := s.manager.ToolCredentials(query.Get("profile"), toolName, query.Get("identity"))
+	if !ok {
+		http.Error(w, fmt.Sprintf("Profile not found: %s", query.Get("profile")), http.StatusNotFound)
+		return
+	}
 
 	if err := credManager.DeleteCredential(toolName, envVar); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to delete credential: %v", err), http.StatusInternalServerError)
@@ -1233,9 +1849,7 @@ func (s *ControlServer) handleSetPrimaryAIKey(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// Get credential manager from an active engine
-	engine := discovery.NewDiscoveryEngine(r.Context(), s.manager.wasmDir, s.manager.registryDir)
-	credManager := engine.GetCredentialManager()
+	credManager := s.manager.Credentials()
 
 	if err := credManager.SetCredential("mcp-scooter:ai_primary", "MCP_SCOOTER_PRIMARY_AI_KEY", req.Value); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to store primary AI key: %v", err), http.StatusInternalServerError)
@@ -1262,9 +1876,7 @@ func (s *ControlServer) handleSetFallbackAIKey(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Get credential manager from an active engine
-	engine := discovery.NewDiscoveryEngine(r.Context(), s.manager.wasmDir, s.manager.registryDir)
-	credManager := engine.GetCredentialManager()
+	credManager := s.manager.Credentials()
 
 	if err := credManager.SetCredential("mcp-scooter:ai_fallback", "MCP_SCOOTER_FALLBACK_AI_KEY", req.Value); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to store fallback AI key: %v", err), http.StatusInternalServerError)
@@ -1278,26 +1890,25 @@ func (s *ControlServer) handleSetFallbackAIKey(w http.ResponseWriter, r *http.Re
 
 // handleCheckAICredentials checks if AI routing credentials are configured.
 func (s *ControlServer) handleCheckAICredentials(w http.ResponseWriter, r *http.Request) {
-	engine := discovery.NewDiscoveryEngine(r.Context(), s.manager.wasmDir, s.manager.registryDir)
-	credManager := engine.GetCredentialManager()
+	credManager := s.manager.Credentials()
 
 	primaryKey, err1 := credManager.GetCredential("mcp-scooter:ai_primary", "MCP_SCOOTER_PRIMARY_AI_KEY")
 	fallbackKey, err2 := credManager.GetCredential("mcp-scooter:ai_fallback", "MCP_SCOOTER_FALLBACK_AI_KEY")
 
 	hasPrimary := err1 == nil && primaryKey != ""
 	hasFallback := err2 == nil && fallbackKey != ""
+	settings := s.settings.Get()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"primary_configured":  hasPrimary && s.settings.PrimaryAIProvider != "",
-		"fallback_configured": hasFallback && s.settings.FallbackAIProvider != "",
+		"primary_configured":  hasPrimary && settings.PrimaryAIProvider != "",
+		"fallback_configured": hasFallback && settings.FallbackAIProvider != "",
 	})
 }
 
 // handleDeletePrimaryAIKey removes the primary AI routing API key.
 func (s *ControlServer) handleDeletePrimaryAIKey(w http.ResponseWriter, r *http.Request) {
-	engine := discovery.NewDiscoveryEngine(r.Context(), s.manager.wasmDir, s.manager.registryDir)
-	credManager := engine.GetCredentialManager()
+	credManager := s.manager.Credentials()
 
 	if err := credManager.DeleteCredential("mcp-scooter:ai_primary", "MCP_SCOOTER_PRIMARY_AI_KEY"); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to delete primary AI key: %v", err), http.StatusInternalServerError)
@@ -1310,8 +1921,7 @@ func (s *ControlServer) handleDeletePrimaryAIKey(w http.ResponseWriter, r *http.
 
 // handleDeleteFallbackAIKey removes the fallback AI routing API key.
 func (s *ControlServer) handleDeleteFallbackAIKey(w http.ResponseWriter, r *http.Request) {
-	engine := discovery.NewDiscoveryEngine(r.Context(), s.manager.wasmDir, s.manager.registryDir)
-	credManager := engine.GetCredentialManager()
+	credManager := s.manager.Credentials()
 
 	if err := credManager.DeleteCredential("mcp-scooter:ai_fallback", "MCP_SCOOTER_FALLBACK_AI_KEY"); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to delete fallback AI key: %v", err), http.StatusInternalServerError)
@@ -1333,6 +1943,13 @@ func (s *ControlServer) handleCreateProfile(w http.ResponseWriter, r *http.Reque
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if s.rejectUserExec(w, r, p) {
+		return
+	}
+	// Users' clients need a key to reach the profile (see Users)
+	if s.user != "" && p.APIKey == "" {
+		p.APIKey = profile.GenerateAPIKey()
+	}
 
 	if err := s.manager.AddProfile(p); err != nil {
 		http.Error(w, err.Error(), http.StatusConflict)
@@ -1348,8 +1965,81 @@ func (s *ControlServer) handleCreateProfile(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	events.Record(events.ConfigChanged, p.ID, "", map[string]interface{}{"change": "profile_created"})
+
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(p)
+	json.NewEncoder(w).Encode(profileResponse{Profile: p, Warnings: s.lintProfile(p)})
+}
+
+// profileResponse is a saved profile with the problems found in it, which
+// don't stop it being saved.
+type profileResponse struct {
+	profile.Profile
+	Warnings []profile.ConfigIssue `json:"warnings,omitempty"`
+}
+
+// lintProfile checks the registry entries p names against the catalog.
+func (s *ControlServer) lintProfile(p profile.Profile) []profile.ConfigIssue {
+	known := make(map[string]bool)
+	for _, td := range s.manager.Catalog() {
+		known[td.Name] = true
+	}
+	return p.LintServers(known)
+}
+
+// handleLintProfile reports unknown registry names in a profile without
+// saving it, so the UI can flag typos as the user types.
+func (s *ControlServer) handleLintProfile(w http.ResponseWriter, r *http.Request) {
+	var p profile.Profile
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	warnings := s.lintProfile(p)
+	if warnings == nil {
+		warnings = []profile.ConfigIssue{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"warnings": warnings})
+}
+
+// handlePreviewAllowTools shows which registry entries AllowTools entries
+// match now, one or more given as ?expr=. Unknown server names match
+// nothing.
+func (s *ControlServer) handlePreviewAllowTools(w http.ResponseWriter, r *http.Request) {
+	exprs := r.URL.Query()["expr"]
+	if len(exprs) == 0 {
+		http.Error(w, "expr is required", http.StatusBadRequest)
+		return
+	}
+
+	type match struct {
+		Expr    string   `json:"expr"`
+		Servers []string `json:"servers"`
+		Error   string   `json:"error,omitempty"`
+	}
+	catalog := s.manager.Catalog()
+	matches := make([]match, 0, len(exprs))
+	servers := []string{}
+	for _, expr := range exprs {
+		m := match{Expr: expr, Servers: discovery.MatchAllowEntry(expr, catalog), Error: profile.ValidateAllowEntry(expr)}
+		if m.Servers == nil {
+			m.Servers = []string{}
+		}
+		for _, name := range m.Servers {
+			if !slices.Contains(servers, name) {
+				servers = append(servers, name)
+			}
+		}
+		matches = append(matches, m)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"expressions": matches,
+		"servers":     servers,
+	})
 }
 
 func (s *ControlServer) handleUpdateProfile(w http.ResponseWriter, r *http.Request) {
@@ -1367,34 +2057,93 @@ func (s *ControlServer) handleUpdateProfile(w http.ResponseWriter, r *http.Reque
 	if oldID == "" {
 		oldID = req.Profile.ID
 	}
+	if s.rejectUserExec(w, r, req.Profile) {
+		return
+	}
+
+	// Keys only change through regenerate-key, so an edit that leaves
+	// api_key out, or sends it back masked, keeps the profile's key
+	current, ok := s.manager.GetProfile(oldID)
+	if !ok {
+		http.Error(w, i18n.T(s.locale(r), "api.profile_not_found"), http.StatusNotFound)
+		return
+	}
+	req.Profile.APIKey = current.APIKey
 
 	if err := s.manager.UpdateProfile(oldID, req.Profile); err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	// Update last profile ID if it was renamed
-	s.mu.Lock()
-	if s.settings.LastProfileID == oldID {
-		s.settings.LastProfileID = req.Profile.ID
+	// Update last profile ID if it was renamed; it is the owner's
+	var saveErr error
+	if s.store != nil && s.user != "" {
+		saveErr = s.store.SaveProfiles(s.manager.GetProfiles())
+	} else {
+		updated := s.settings.Update(func(st *profile.Settings) {
+			if st.LastProfileID == oldID {
+				st.LastProfileID = req.Profile.ID
+			}
+		})
+		if s.store != nil {
+			saveErr = s.store.Save(s.manager.GetProfiles(), updated)
+		}
+	}
+	if saveErr != nil {
+		http.Error(w, saveErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{"change": "profile_updated"}
+	if oldID != req.Profile.ID {
+		data["old_id"] = oldID
+	}
+	events.Record(events.ConfigChanged, req.Profile.ID, "", data)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(profileResponse{Profile: req.Profile, Warnings: s.lintProfile(req.Profile)})
+}
+
+func (s *ControlServer) handleRegenerateProfileKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     string `json:"id"`
+		Remove bool   `json:"remove"` // Clear the key so the global gateway key applies again
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p, ok := s.manager.GetProfile(req.ID)
+	if !ok {
+		http.Error(w, i18n.T(s.locale(r), "api.profile_not_found"), http.StatusNotFound)
+		return
+	}
+
+	p.APIKey = ""
+	if !req.Remove {
+		p.APIKey = profile.GenerateAPIKey()
+	}
+	if err := s.manager.UpdateProfile(p.ID, p); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-	s.mu.Unlock()
 
 	if s.store != nil {
-		if err := s.store.Save(s.manager.GetProfiles(), *s.settings); err != nil {
+		if err := s.store.SaveProfiles(s.manager.GetProfiles()); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(req.Profile)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"id": p.ID, "api_key": p.APIKey})
 }
 
 func (s *ControlServer) handleDeleteProfile(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("id")
 	if id == "" {
-		http.Error(w, "id is required", http.StatusBadRequest)
+		http.Error(w, i18n.T(s.locale(r), "api.id_required"), http.StatusBadRequest)
 		return
 	}
 
@@ -1415,13 +2164,22 @@ func (s *ControlServer) handleDeleteProfile(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	events.Record(events.ConfigChanged, id, "", map[string]interface{}{"change": "profile_deleted"})
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (s *ControlServer) handleInstallIntegration(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Target  string `json:"target"` // "cursor", "claude-desktop", "claude-code"
+		Target  string `json:"target"` // "cursor", "claude-desktop", "claude-code", ..., or "custom"
 		Profile string `json:"profile"`
+		// Transport overrides the client's preferred transport: "sse", "http" or "stdio"
+		Transport string `json:"transport,omitempty"`
+
+		// For target "custom": the client's JSON config file and its server key
+		Path     string `json:"path,omitempty"`
+		Key      string `json:"key,omitempty"`
+		URLField string `json:"url_field,omitempty"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -1429,32 +2187,66 @@ func (s *ControlServer) handleInstallIntegration(w http.ResponseWriter, r *http.
 	}
 
 	// Use configured McpPort from settings
-	mcpPort := s.settings.McpPort
-	apiKey := s.settings.GatewayAPIKey
+	settings := s.settings.Get()
+	mcpPort := settings.McpPort
+	apiKey := settings.GatewayAPIKey
+	if p, ok := s.manager.GetProfile(req.Profile); ok && p.APIKey != "" {
+		apiKey = p.APIKey
+	}
+
+	transport, err := integration.ParseTransport(req.Transport)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if cd, ok := s.clientDefinition(req.Target); ok {
+		if transport == "" {
+			transport, _ = integration.ParseTransport(cd.PreferredTransport)
+		}
+		if transport != "" && !cd.supportsTransport(transport) {
+			http.Error(w, fmt.Sprintf("%s does not support the %s transport", cd.Name, transport), http.StatusBadRequest)
+			return
+		}
+	}
 
-	var err error
 	switch req.Target {
 	case "cursor":
-		c := &integration.CursorIntegration{}
+		c := &integration.CursorIntegration{Transport: transport}
 		err = c.Configure(mcpPort, req.Profile, apiKey)
 	case "claude-desktop":
-		c := &integration.ClaudeIntegration{}
+		c := &integration.ClaudeIntegration{Transport: transport}
 		err = c.Configure(mcpPort, req.Profile, apiKey)
 	case "claude-code":
-		c := &integration.ClaudeIntegration{}
+		c := &integration.ClaudeIntegration{Transport: transport}
 		err = c.ConfigureCode(mcpPort, req.Profile, apiKey)
 	case "vscode":
-		v := &integration.VSCodeIntegration{}
+		v := &integration.VSCodeIntegration{Transport: transport}
 		err = v.Configure(mcpPort, req.Profile, apiKey)
 	case "antigravity", "gemini-cli":
-		g := &integration.GeminiIntegration{}
+		g := &integration.GeminiIntegration{Transport: transport}
 		err = g.Configure(mcpPort, req.Profile, apiKey)
 	case "codex":
-		c := &integration.CodexIntegration{}
+		c := &integration.CodexIntegration{Transport: transport}
 		err = c.Configure(mcpPort, req.Profile, apiKey)
 	case "zed":
-		z := &integration.ZedIntegration{}
+		z := &integration.ZedIntegration{Transport: transport}
 		err = z.Configure(mcpPort, req.Profile, apiKey)
+	case "jetbrains":
+		j := &integration.JetBrainsIntegration{Transport: transport}
+		err = j.Configure(mcpPort, req.Profile, apiKey)
+	case "windsurf":
+		ws := &integration.WindsurfIntegration{Transport: transport}
+		err = ws.Configure(mcpPort, req.Profile, apiKey)
+	case "custom":
+		g := &integration.GenericJSONIntegration{Path: req.Path, Key: req.Key, URLField: req.URLField, Transport: transport}
+		if g.Key == "" {
+			g.Key = integration.KeyMcpServers
+		}
+		if verr := g.Validate(); verr != nil {
+			http.Error(w, verr.Error(), http.StatusBadRequest)
+			return
+		}
+		err = g.Configure(mcpPort, req.Profile, apiKey)
 	default:
 		err = fmt.Errorf("unknown integration target")
 	}
@@ -1472,56 +2264,183 @@ func (s *ControlServer) handleInstallIntegration(w http.ResponseWriter, r *http.
 type McpGateway struct {
 	manager      *ProfileManager
 	mux          *http.ServeMux
-	settings     *profile.Settings
+	settings     *profile.SettingsProvider
 	sseClients   map[string][]chan string // profileID -> list of SSE notification channels
-	sseSessions  map[string]chan string   // sessionId -> specific session channel
+	sseSessions  map[string]*gatewaySession // sessionId -> connected SSE client
 	sseClientsMu sync.RWMutex
+	activations  *activationLimiter
+	limits       *callLimiter
+
+	// Minimum log level each profile's clients asked for via logging/setLevel.
+	// Profiles without an entry don't receive server log messages.
+	clientLogLevels   map[string]string
+	clientLogLevelsMu sync.RWMutex
+
+	disconnects   map[disconnectCause]int // ended sessions by cause
+	disconnectsMu sync.Mutex
+
+	ended         []*gatewaySession // disconnected sessions with transcripts, oldest first
+	transcriptsMu sync.Mutex
+
+	// users serve /users/<name>/ (see SetUsers); user is the user whose
+	// gateway this is, "" for the daemon's owner
+	users *Users
+	user  string
+
+	// internalToken marks requests from Scooter Desktop (see SetInternalToken)
+	internalToken string
+}
+
+// SetUsers serves users' profiles under /users/<name>/.
+func (g *McpGateway) SetUsers(u *Users) {
+	g.users = u
 }
 
-func NewMcpGateway(manager *ProfileManager, settings *profile.Settings) *McpGateway {
+func NewMcpGateway(manager *ProfileManager, settings *profile.SettingsProvider) *McpGateway {
 	g := &McpGateway{
 		manager:     manager,
 		mux:         http.NewServeMux(),
 		settings:    settings,
 		sseClients:  make(map[string][]chan string),
-		sseSessions: make(map[string]chan string),
+		sseSessions: make(map[string]*gatewaySession),
+		activations: newActivationLimiter(autoActivateLimit, time.Minute),
+		limits:      newCallLimiter(),
+
+		clientLogLevels: make(map[string]string),
+		disconnects:     make(map[disconnectCause]int),
 	}
 	g.routes()
 
+	// Push settings changes to every profile's engine as soon as they happen
+	settings.Subscribe(func(updated profile.Settings) {
+		for _, p := range manager.GetProfiles() {
+			if engine, ok := manager.GetEngine(p.ID); ok {
+				engine.SetSettings(updated)
+			}
+		}
+	})
+
+	// Profile edits reach the engine at once; clients refresh their tool lists
+	manager.Subscribe(func(change ProfileChange) {
+		if change.ToolsChanged() {
+			g.NotifyToolsChanged(change.ProfileID)
+		}
+	})
+
 	// Set up cleanup callbacks for all engines to notify SSE clients when tools are auto-unloaded
 	for _, p := range manager.GetProfiles() {
-		if engine, ok := manager.GetEngine(p.ID); ok {
-			profileID := p.ID // Capture for closure
-			engine.SetCleanupCallback(func(serverName string) {
-				logger.AddLog("INFO", fmt.Sprintf("Tool '%s' auto-unloaded, notifying SSE clients", serverName))
-				g.NotifyToolsChanged(profileID)
+		g.watchProfile(p)
+	}
+
+	return g
+}
+
+// watchProfile connects a profile engine's callbacks to the profile's SSE
+// clients. Engines created after the gateway (by a reload) need it too.
+func (g *McpGateway) watchProfile(p profile.Profile) {
+	engine, ok := g.manager.GetEngine(p.ID)
+	if !ok {
+		return
+	}
+	profileID := p.ID // Capture for closure
+	engine.SetCleanupCallback(func(serverName string) {
+		logger.AddLog("INFO", fmt.Sprintf("Tool '%s' auto-unloaded, notifying SSE clients", serverName))
+		g.NotifyToolsChanged(profileID)
+	})
+	engine.SetActivationCallback(func(serverName string, err error) {
+		if err != nil {
+			g.forwardServerLog(profileID, serverName, discovery.LogMessage{
+				Level: "error",
+				Data:  fmt.Sprintf("Background activation of %s failed: %v", serverName, err),
 			})
+			return
 		}
+		g.NotifyToolsChanged(profileID)
+	})
+	engine.SetLogLevels(p.LogLevels)
+	engine.SetLogCallback(func(serverName string, msg discovery.LogMessage) {
+		g.forwardServerLog(profileID, serverName, msg)
+	})
+}
+
+// forwardServerLog relays a downstream server's log message to the profile's
+// SSE clients, if they enabled logging and the message meets their level.
+func (g *McpGateway) forwardServerLog(profileID, serverName string, msg discovery.LogMessage) {
+	g.clientLogLevelsMu.RLock()
+	minLevel, enabled := g.clientLogLevels[profileID]
+	g.clientLogLevelsMu.RUnlock()
+	if !enabled || !discovery.LogLevelAtLeast(msg.Level, minLevel) {
+		return
 	}
 
-	return g
+	notification, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/message",
+		"params": map[string]interface{}{
+			"level":  msg.Level,
+			"logger": serverName,
+			"data":   msg.Data,
+		},
+	})
+	if err != nil {
+		return
+	}
+	g.broadcast(profileID, string(notification))
 }
 
-// NotifyToolsChanged sends a tools/list_changed notification to all SSE clients for a profile.
-// This is called after scooter_activate or auto-cleanup.
-func (g *McpGateway) NotifyToolsChanged(profileID string) {
+// broadcast sends a message to every SSE client of a profile without blocking,
+// returning how many clients it reached.
+func (g *McpGateway) broadcast(profileID, message string) int {
 	g.sseClientsMu.RLock()
 	clients := g.sseClients[profileID]
 	g.sseClientsMu.RUnlock()
 
-	notification := `{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`
-
+	sent := 0
 	for _, ch := range clients {
 		select {
-		case ch <- notification:
-			// Sent successfully
+		case ch <- message:
+			sent++
 		default:
 			// Channel full, skip (client will catch up on next poll)
 		}
 	}
+	return sent
+}
+
+// credentialPrompt returns structured error data when err is a failed
+// activation for lack of credentials, and logs a credentials_required event
+// so the desktop app can open the credential form for the tool. It returns
+// nil for any other error.
+func credentialPrompt(profileID string, err error) map[string]interface{} {
+	var authErr *discovery.AuthRequiredError
+	if !errors.As(err, &authErr) {
+		return nil
+	}
+
+	data := map[string]interface{}{
+		"type":      "auth_required",
+		"tool":      authErr.Server,
+		"missing":   authErr.Missing,
+		"deep_link": authErr.DeepLink(),
+	}
+	if authErr.HelpURL != "" {
+		data["help_url"] = authErr.HelpURL
+	}
 
-	if len(clients) > 0 {
-		logger.AddLog("INFO", fmt.Sprintf("Sent tools/list_changed to %d SSE clients for profile '%s'", len(clients), profileID))
+	event := map[string]interface{}{"profile": profileID}
+	for k, v := range data {
+		event[k] = v
+	}
+	logger.AddEvent("credentials_required", fmt.Sprintf("Tool '%s' needs credentials: %s", authErr.Server, strings.Join(authErr.Missing, ", ")), event)
+	return data
+}
+
+// NotifyToolsChanged sends a tools/list_changed notification to all SSE clients for a profile.
+// This is called after scooter_activate or auto-cleanup.
+func (g *McpGateway) NotifyToolsChanged(profileID string) {
+	sent := g.broadcast(profileID, `{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`)
+	if sent > 0 {
+		logger.AddLog("INFO", fmt.Sprintf("Sent tools/list_changed to %d SSE clients for profile '%s'", sent, profileID))
 	}
 }
 
@@ -1531,77 +2450,258 @@ func (g *McpGateway) routes() {
 	g.mux.HandleFunc("POST /profiles/{id}/sse", g.handleMessage) // Streamable HTTP: POST to same endpoint
 	g.mux.HandleFunc("POST /profiles/{id}/message", g.handleMessage)
 
-	// Default routes for "work" profile (compatibility)
+	// Root routes serve the API key's profile, or "work" (compatibility)
 	g.mux.HandleFunc("GET /sse", func(w http.ResponseWriter, r *http.Request) {
-		r.SetPathValue("id", "work")
+		r.SetPathValue("id", routedProfile(r))
 		g.handleSSE(w, r)
 	})
 	g.mux.HandleFunc("POST /sse", func(w http.ResponseWriter, r *http.Request) {
-		r.SetPathValue("id", "work")
+		r.SetPathValue("id", routedProfile(r))
 		g.handleMessage(w, r) // Streamable HTTP: POST to same endpoint
 	})
 	g.mux.HandleFunc("POST /message", func(w http.ResponseWriter, r *http.Request) {
-		r.SetPathValue("id", "work")
+		r.SetPathValue("id", routedProfile(r))
 		g.handleMessage(w, r)
 	})
 }
 
 func (g *McpGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Global CORS headers for MCP clients
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Scooter-API-Key, X-Scooter-Internal")
+	if g.settings.Get().CompressResponses {
+		if cw := newCompressWriter(w, r); cw != nil {
+			defer cw.Close()
+			w = cw
+		}
+	}
 
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
+	rw := &recoveryWriter{ResponseWriter: w}
+	defer recoverPanic(rw, r, "gateway", r.Method == http.MethodPost)
+	w = rw
+
+	// Tunnelled requests name the relay's host
+	if _, tunnelled := publicBase(r.Context()); !tunnelled && !checkHost(w, r, g.settings.Get(), g.manager.GetProfiles()) {
+		return
+	}
+	if !applyCORS(w, r, g.settings.Get().CORS, gatewayCORSMethods) {
+		return
+	}
+
+	if g.user == "" && strings.HasPrefix(r.URL.Path, "/users/") {
+		g.serveUser(w, r)
 		return
 	}
 
 	// Internal requests from Scooter Desktop bypass authentication
-	isInternal := r.Header.Get("X-Scooter-Internal") == "true"
+	isInternal, spoofed := g.checkInternal(r)
+	if spoofed != "" {
+		g.rejectSpoofedInternal(w, r, spoofed)
+		return
+	}
+	stripInternalHeaders(r)
+	if isInternal {
+		r = r.WithContext(context.WithValue(r.Context(), internalContextKey{}, true))
+	}
 
-	g.sseClientsMu.RLock()
-	apiKey := g.settings.GatewayAPIKey
-	g.sseClientsMu.RUnlock()
+	apiKey := g.settings.Get().GatewayAPIKey
+	requestApiKey := requestAPIKey(r)
 
-	// Check authentication if a key is configured (skip for internal requests)
-	if apiKey != "" && !isInternal {
-		authHeader := r.Header.Get("Authorization")
-		requestApiKey := r.Header.Get("X-Scooter-API-Key")
+	// Root routes go to the profile the host name or API key is mapped to
+	profileID := profileIDFromPath(r.URL.Path)
+	if !strings.HasPrefix(r.URL.Path, "/profiles/") {
+		id, ok := g.hostProfile(r.Host)
+		if !ok {
+			id, ok = g.keyProfile(requestApiKey)
+		}
+		if ok {
+			profileID = id
+			r = r.WithContext(context.WithValue(r.Context(), routedProfileContextKey{}, id))
+		}
+	}
 
-		if authHeader != "" {
-			if strings.HasPrefix(authHeader, "Bearer ") {
-				requestApiKey = strings.TrimPrefix(authHeader, "Bearer ")
-			} else {
-				requestApiKey = authHeader
-			}
+	// A profile's own key takes precedence over the global gateway key
+	if p, ok := g.manager.GetProfile(profileID); ok && p.APIKey != "" {
+		apiKey = p.APIKey
+	}
+
+	// Users' profiles only open with their own key
+	if g.user != "" {
+		p, ok := g.manager.GetProfile(profileID)
+		if ok && p.APIKey == "" {
+			logger.AddLog("WARN", fmt.Sprintf("Refused a request for profile '%s' of user '%s': it has no api_key", profileID, g.user))
+			http.Error(w, "This profile has no API key; users' profiles need one", http.StatusServiceUnavailable)
+			return
+		}
+		if ok && requestApiKey != p.APIKey {
+			g.rejectAuth(r, "wrong or no API key (user "+g.user+")")
+			http.Error(w, i18n.T(g.locale(r), "api.unauthorized"), http.StatusUnauthorized)
+			return
 		}
+		g.mux.ServeHTTP(w, r)
+		return
+	}
 
-		if requestApiKey != apiKey {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	// Access group keys are an alternative to the gateway/profile key, limited
+	// to the group's profiles and tools
+	if group, ok := g.settings.Get().AccessGroupForKey(requestApiKey); ok {
+		if !group.AllowsProfile(profileID) {
+			g.rejectAuth(r, "access group not allowed on this profile")
+			http.Error(w, i18n.T(g.locale(r), "api.forbidden"), http.StatusForbidden)
 			return
 		}
+		g.mux.ServeHTTP(w, r.WithContext(withAccessGroup(r.Context(), group)))
+		return
+	}
+
+	// Check authentication if a key is configured (skip for internal requests)
+	if apiKey != "" && !isInternal && requestApiKey != apiKey {
+		if requestApiKey == "" {
+			g.rejectAuth(r, "no API key")
+		} else {
+			g.rejectAuth(r, "wrong API key")
+		}
+		http.Error(w, i18n.T(g.locale(r), "api.unauthorized"), http.StatusUnauthorized)
+		return
 	}
 
 	g.mux.ServeHTTP(w, r)
 }
 
-func (g *McpGateway) handleSSE(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	_, ok := g.manager.GetEngine(id)
-	if !ok {
-		http.Error(w, "Profile not found", http.StatusNotFound)
-		return
+// rejectAuth logs and counts a gateway request turned away by authentication,
+// so clients that keep dropping because of a bad key show up in the logs.
+func (g *McpGateway) rejectAuth(r *http.Request, detail string) {
+	g.countDisconnect(causeAuthFailed)
+	metrics.Errors.Inc(metrics.ErrorAuthFailed)
+	logger.AddLog("WARN", fmt.Sprintf("Rejected %s %s from %s (cause: %s, %s)", r.Method, r.URL.Path, r.RemoteAddr, causeAuthFailed, detail))
+}
+
+// requestAPIKey returns the key a client presented in the Authorization
+// (with or without "Bearer ") or X-Scooter-API-Key header.
+func requestAPIKey(r *http.Request) string {
+	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+		return strings.TrimPrefix(authHeader, "Bearer ")
 	}
+	return r.Header.Get("X-Scooter-API-Key")
+}
 
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
+type accessGroupContextKey struct{}
 
-	logger.AddLog("INFO", fmt.Sprintf("SSE connection opened for profile: %s", id))
+func withAccessGroup(ctx context.Context, group profile.AccessGroup) context.Context {
+	return context.WithValue(ctx, accessGroupContextKey{}, group)
+}
 
-	flusher, ok := w.(http.Flusher)
-	if !ok {
+// groupAllows reports whether the request's access group, if it has one,
+// may use profileID and the tool of server, writing a 403 if not. Groups
+// can't call builtin tools here: scooter_activate would reach any server.
+func (s *ControlServer) groupAllows(w http.ResponseWriter, r *http.Request, profileID, tool, server string) bool {
+	group, restricted := accessGroupFrom(r.Context())
+	if !restricted {
+		return true
+	}
+	if group.AllowsProfile(profileID) && group.AllowsTool(tool, server) && !discovery.IsBuiltinTool(tool) {
+		return true
+	}
+	logger.AddLog("WARN", fmt.Sprintf("Rejected %s %s from access group '%s': %s/%s is outside the group", r.Method, r.URL.Path, group.Name, profileID, tool))
+	http.Error(w, i18n.T(s.locale(r), "api.forbidden"), http.StatusForbidden)
+	return false
+}
+
+// accessGroupFrom returns the access group of a request authenticated with a
+// group key. ok is false for unrestricted requests.
+func accessGroupFrom(ctx context.Context) (group profile.AccessGroup, ok bool) {
+	group, ok = ctx.Value(accessGroupContextKey{}).(profile.AccessGroup)
+	return group, ok
+}
+
+// locale returns the message locale for a gateway request.
+func (g *McpGateway) locale(r *http.Request) string {
+	return i18n.FromAcceptLanguage(r.Header.Get("Accept-Language"), g.settings.Get().Locale)
+}
+
+type routedProfileContextKey struct{}
+
+// routedProfile returns the profile a request to the root /sse and /message
+// routes is served by: the one its host name or API key is mapped to, or
+// "work".
+func routedProfile(r *http.Request) string {
+	if id, ok := r.Context().Value(routedProfileContextKey{}).(string); ok {
+		return id
+	}
+	return "work"
+}
+
+// hostProfileSuffixes turn host names like work.scooter.localhost into a
+// profile ID. Browsers and most resolvers send *.localhost to loopback.
+var hostProfileSuffixes = []string{".scooter.localhost", ".localhost"}
+
+// hostProfile returns the profile a request's Host header names: one that
+// lists the host in its hosts, or <id>.scooter.localhost and
+// <id>.localhost for an existing profile id.
+func (g *McpGateway) hostProfile(host string) (string, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return "", false
+	}
+
+	profiles := g.manager.GetProfiles()
+	for _, p := range profiles {
+		for _, alias := range p.Hosts {
+			if strings.EqualFold(alias, host) {
+				return p.ID, true
+			}
+		}
+	}
+	for _, suffix := range hostProfileSuffixes {
+		id, ok := strings.CutSuffix(host, suffix)
+		if !ok || id == "" || strings.Contains(id, ".") {
+			continue
+		}
+		for _, p := range profiles {
+			if strings.EqualFold(p.ID, id) {
+				return p.ID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// keyProfile returns the profile that requests authenticated with key are
+// routed to when the URL doesn't name one: the default profile of the key's
+// access group.
+func (g *McpGateway) keyProfile(key string) (string, bool) {
+	group, ok := g.settings.Get().AccessGroupForKey(key)
+	return group.DefaultProfile, ok && group.DefaultProfile != ""
+}
+
+// profileIDFromPath returns the profile a gateway request is routed to.
+// The root /sse and /message routes serve the "work" profile.
+func profileIDFromPath(path string) string {
+	rest, ok := strings.CutPrefix(path, "/profiles/")
+	if !ok {
+		return "work"
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	return id
+}
+
+func (g *McpGateway) handleSSE(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	_, ok := g.manager.GetEngine(id)
+	if !ok {
+		http.Error(w, i18n.T(g.locale(r), "gateway.profile_not_found"), http.StatusNotFound)
+		return
+	}
+
+	streamWithoutDeadline(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	logger.AddLog("INFO", fmt.Sprintf("SSE connection opened for profile: %s", id))
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
 		logger.AddLog("ERROR", "Streaming unsupported for SSE")
 		http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
 		return
@@ -1609,9 +2709,13 @@ func (g *McpGateway) handleSSE(w http.ResponseWriter, r *http.Request) {
 
 	// Register this SSE client for notifications and responses
 	sessionId := generateSessionID()
-	notifyChan := make(chan string, 10)
+	sess := newGatewaySession(sessionId, id, r.RemoteAddr)
+	if g.settings.Get().SessionTranscripts {
+		sess.startTranscript()
+	}
+	notifyChan := sess.ch
 	g.sseClientsMu.Lock()
-	g.sseSessions[sessionId] = notifyChan
+	g.sseSessions[sessionId] = sess
 	g.sseClients[id] = append(g.sseClients[id], notifyChan)
 	g.sseClientsMu.Unlock()
 
@@ -1628,18 +2732,54 @@ func (g *McpGateway) handleSSE(w http.ResponseWriter, r *http.Request) {
 		}
 		g.sseClientsMu.Unlock()
 		close(notifyChan)
-		logger.AddLog("INFO", fmt.Sprintf("SSE connection closed for profile: %s (session: %s)", id, sessionId))
+
+		g.countDisconnect(sess.closeCause)
+		g.keepTranscript(sess)
+		info := sess.info()
+		msg := fmt.Sprintf("SSE connection closed for profile: %s (session: %s, cause: %s", id, sessionId, sess.closeCause)
+		if sess.closeReason != "" {
+			msg += ", " + sess.closeReason
+		}
+		msg += fmt.Sprintf(", duration: %s, messages in/out: %d/%d)", time.Since(info.ConnectedAt).Round(time.Second), info.MessagesIn, info.MessagesOut)
+		logger.AddLog("INFO", msg)
 	}()
 
 	// Send endpoint event for client to know where to POST messages
 	// Standard MCP SSE transport requires the client to POST to this endpoint
-	g.sseClientsMu.RLock()
-	mcpPort := g.settings.McpPort
-	g.sseClientsMu.RUnlock()
-	fmt.Fprintf(w, "event: endpoint\ndata: http://127.0.0.1:%d/profiles/%s/sse?sessionId=%s\n\n", mcpPort, id, sessionId)
+	mcpPort := g.settings.Get().McpPort
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	base := fmt.Sprintf("%s://127.0.0.1:%d", scheme, mcpPort)
+	if public, ok := publicBase(r.Context()); ok {
+		base = public
+	}
+	if g.user != "" {
+		base += "/users/" + g.user
+	}
+	fmt.Fprintf(w, "event: endpoint\ndata: %s/profiles/%s/sse?sessionId=%s\n\n", base, id, sessionId)
 	flusher.Flush()
 
-	ticker := time.NewTicker(30 * time.Second) // Increased heartbeat interval
+	// A failed flush means the connection is gone (reset, broken pipe)
+	// rather than closed cleanly by the client.
+	rc := http.NewResponseController(w)
+	flush := func() bool {
+		if err := rc.Flush(); err != nil {
+			sess.close(causeWriteError, err.Error())
+			return false
+		}
+		return true
+	}
+
+	// The ticker drives keep-alives and session reaping; with keep-alives
+	// off it still ticks so idle sessions are noticed.
+	heartbeat := time.Duration(g.settings.Get().HeartbeatSeconds) * time.Second
+	tick := heartbeat
+	if tick <= 0 {
+		tick = defaultHeartbeat
+	}
+	ticker := time.NewTicker(tick)
 	defer ticker.Stop()
 
 	for {
@@ -1647,17 +2787,42 @@ func (g *McpGateway) handleSSE(w http.ResponseWriter, r *http.Request) {
 		case notification := <-notifyChan:
 			// Send MCP message (notification or response)
 			fmt.Fprintf(w, "event: message\ndata: %s\n\n", notification)
-			flusher.Flush()
+			if !flush() {
+				return
+			}
+			sess.sent()
+			sess.transcribe("out", []byte(notification))
 		case <-ticker.C:
-			// Keep-alive pulse (non-standard but helpful)
-			fmt.Fprintf(w, "event: pulse\ndata: {\"profile\": \"%s\", \"session\": \"%s\", \"status\": \"ok\", \"timestamp\": \"%s\"}\n\n", id, sessionId, time.Now().Format(time.RFC3339))
-			flusher.Flush()
+			settings := g.settings.Get()
+			if heartbeat > 0 {
+				if sess.legacyPulse(settings.LegacyPulseClients) {
+					// Non-standard pulse event, for clients that relied on it
+					fmt.Fprintf(w, "event: pulse\ndata: {\"profile\": \"%s\", \"session\": \"%s\", \"status\": \"ok\", \"timestamp\": \"%s\"}\n\n", id, sessionId, time.Now().Format(time.RFC3339))
+				} else {
+					// SSE comment: keeps proxies from timing out, ignored by parsers
+					fmt.Fprint(w, ": ping\n\n")
+				}
+				if !flush() {
+					return
+				}
+			}
+			// Reap sessions whose client went quiet or that lived too long
+			idle, lifetime := sessionLimits(settings)
+			if cause, reason := sess.expired(time.Now(), idle, lifetime); cause != "" {
+				sess.close(cause, reason)
+			}
+		case <-sess.done:
+			return
 		case <-r.Context().Done():
+			sess.close(causeClientClosed, r.Context().Err().Error())
 			return
 		}
 	}
 }
 
+// defaultHeartbeat is the reaping interval when keep-alives are disabled.
+const defaultHeartbeat = 30 * time.Second
+
 func generateSessionID() string {
 	b := make([]byte, 16)
 	rand.Read(b)
@@ -1668,28 +2833,57 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	engine, ok := g.manager.GetEngine(id)
 	if !ok {
-		http.Error(w, "Profile not found", http.StatusNotFound)
+		http.Error(w, i18n.T(g.locale(r), "gateway.profile_not_found"), http.StatusNotFound)
 		return
 	}
 
-	var req JSONRPCRequest
-	body, err := io.ReadAll(r.Body)
+	bodyBuf, err := readBody(r.Body)
 	if err != nil {
 		logger.AddLog("ERROR", fmt.Sprintf("Failed to read MCP request body: %v", err))
 		http.Error(w, "Failed to read body", http.StatusBadRequest)
 		return
 	}
+	body := bodyBuf.Bytes()
 
-	logger.Trace(fmt.Sprintf("[MCP] Raw request from profile %s: %s", id, logger.TruncateForLog(string(body), 2048)))
+	if logger.Verbose() {
+		logger.Trace(fmt.Sprintf("[MCP] Raw request from profile %s: %s", id, logger.TruncateForLog(string(body), 2048)))
+	}
 
-	if err := json.Unmarshal(body, &req); err != nil {
+	// One pass keeps the client's id verbatim so the response echoes it exactly
+	req, rawID, err := decodeRequest(body)
+	if err != nil {
 		logger.AddLog("ERROR", fmt.Sprintf("Failed to decode MCP request: %v. Body: %s", err, string(body)))
+		putBuffer(bodyBuf)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(NewJSONRPCErrorResponse(nil, ParseError, "Parse error"))
 		return
 	}
+	putBuffer(bodyBuf)
+
+	if rawID != nil && !rawID.Valid() {
+		logger.AddLog("ERROR", fmt.Sprintf("Invalid JSON-RPC id from profile %s: %s", id, rawID))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(NewJSONRPCErrorResponse(nil, InvalidRequest, "Invalid Request: id must be a string, number or null"))
+		return
+	}
+	req.ID = nil
+	if rawID != nil {
+		req.ID = rawID
+	}
 
 	logger.Trace(fmt.Sprintf("[MCP] Parsed request: method=%s, id=%v", req.Method, req.ID))
+	metrics.GatewayRequests.Inc(metrics.GatewayMethod(req.Method))
+
+	// Messages posted for an SSE session count as activity on it
+	sessionId := r.URL.Query().Get("sessionId")
+	sess, hasSession := g.session(sessionId)
+	if hasSession {
+		sess.received()
+		if sess.transcribing() {
+			msg, _ := json.Marshal(req)
+			sess.transcribe("in", msg)
+		}
+	}
 
 	// Handle notifications (no ID)
 	if req.ID == nil {
@@ -1706,14 +2900,23 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 	var resp JSONRPCResponse
 	logger.AddLog("INFO", fmt.Sprintf("MCP Request [%v] from profile %s: %s", req.ID, id, req.Method))
 
+	var traceID string // Set by tools/call
 	switch req.Method {
 	case "initialize":
 		logger.AddLog("INFO", "Handling 'initialize' request")
+		if hasSession {
+			var params struct {
+				ClientInfo struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+				} `json:"clientInfo"`
+			}
+			json.Unmarshal(req.Params, &params)
+			sess.setClientInfo(params.ClientInfo.Name, params.ClientInfo.Version)
+		}
 		
 		// Layer 3: Session-Based Cleanup
-		g.sseClientsMu.RLock()
-		cleanupOnSession := g.settings.CleanupOnSession
-		g.sseClientsMu.RUnlock()
+		cleanupOnSession := g.settings.Get().CleanupOnSession
 
 		if cleanupOnSession {
 			logger.AddLog("INFO", fmt.Sprintf("CleanupOnSession enabled, deactivating all tools for profile '%s'", id))
@@ -1730,13 +2933,69 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 				"tools": map[string]interface{}{
 					"listChanged": true, // Server will emit notifications/tools/list_changed when tools change
 				},
+				"logging":     map[string]interface{}{}, // Downstream server logs, after logging/setLevel
+				"completions": map[string]interface{}{},
 			},
 			"serverInfo": map[string]string{
 				"name":    "mcp-scooter",
-				"version": "0.1.0",
+				"version": version.Version,
 			},
 		})
 
+	case "ping":
+		// Liveness check: clients expect an empty result
+		resp = NewJSONRPCResponse(req.ID, map[string]interface{}{})
+
+	case "completion/complete":
+		p, profileOk := g.manager.GetProfile(id)
+		var ref discovery.CompletionRequest
+		json.Unmarshal(req.Params, &ref)
+		params := req.Params
+		if ref.Ref.Type == discovery.RefTool {
+			// Builtins are answered locally, so the rewritten request is never forwarded
+			if name := discovery.CanonicalBuiltinName(ref.Ref.Name, p.BuiltinToolPrefix()); name != ref.Ref.Name {
+				ref.Ref.Name = name
+				params, _ = json.Marshal(ref)
+			}
+		}
+		completion, err := engine.Complete(params)
+		if err != nil {
+			resp = NewJSONRPCErrorResponse(req.ID, InvalidParams, err.Error())
+			break
+		}
+		// Only suggest servers the client would be allowed to activate
+		if ref.Ref.Type == discovery.RefTool && (ref.Ref.Name == "scooter_add" || ref.Ref.Name == "scooter_activate") {
+			group, restricted := accessGroupFrom(r.Context())
+			checkAllowed := profileOk && !isInternalRequest(r.Context())
+			values := completion.Values[:0]
+			for _, name := range completion.Values {
+				if checkAllowed && !engine.IsAllowed(p.AllowTools, name) {
+					continue
+				}
+				if restricted && !group.AllowsTool(name, name) {
+					continue
+				}
+				values = append(values, name)
+			}
+			completion.Values = values
+			completion.Total = len(values)
+		}
+		resp = NewJSONRPCResponse(req.ID, map[string]interface{}{"completion": completion})
+
+	case "logging/setLevel":
+		var params struct {
+			Level string `json:"level"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil || !discovery.IsLogLevel(params.Level) {
+			resp = NewJSONRPCErrorResponse(req.ID, InvalidParams, i18n.T(g.locale(r), "gateway.invalid_log_level", params.Level))
+			break
+		}
+		g.clientLogLevelsMu.Lock()
+		g.clientLogLevels[id] = strings.ToLower(params.Level)
+		g.clientLogLevelsMu.Unlock()
+		logger.AddLog("INFO", fmt.Sprintf("Profile '%s' clients set log level to %s", id, params.Level))
+		resp = NewJSONRPCResponse(req.ID, map[string]interface{}{})
+
 	case "tools/list", "list_tools":
 		logger.AddLog("INFO", "Handling 'tools/list' request")
 		p, ok := g.manager.GetProfile(id)
@@ -1748,9 +3007,19 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 
 		// 1. Always include builtin (primordial) tools - these are the "meta-layer"
 		//    that allows agents to discover and activate other tools dynamically.
+		//    Profiles may rename them with their own prefix.
 		for _, td := range discovery.PrimordialTools() {
+			if td.Name == "scooter_history" && !p.CallHistory {
+				continue
+			}
+			if td.Name == "scooter_result" && p.MaxResultBytes == 0 {
+				continue
+			}
+			if td.Name == "scooter_expand" && !p.MinifySchemas {
+				continue
+			}
 			if !engine.IsToolDisabled(td.Name) {
-				mcpTools = append(mcpTools, td.Tools...)
+				mcpTools = append(mcpTools, discovery.WithBuiltinPrefix(td, p.BuiltinToolPrefix())...)
 			}
 		}
 
@@ -1759,10 +3028,19 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 		//    activated via scooter_add before they appear in the tool list.
 		activeServers := engine.ListActive()
 		logger.AddLog("DEBUG", fmt.Sprintf("Active servers: %v", activeServers))
+		group, restricted := accessGroupFrom(r.Context())
 		for _, serverName := range activeServers {
 			serverTools := engine.GetActiveToolsForServer(serverName)
 			logger.AddLog("DEBUG", fmt.Sprintf("Server '%s' provides %d tools: %v", serverName, len(serverTools), getToolNames(serverTools)))
-			mcpTools = append(mcpTools, serverTools...)
+			for _, tool := range serverTools {
+				if restricted && !group.AllowsTool(tool.Name, serverName) {
+					continue
+				}
+				if p.MinifySchemas {
+					tool = discovery.MinifyTool(tool, p.MinifyDescriptionChars)
+				}
+				mcpTools = append(mcpTools, tool)
+			}
 		}
 
 		// Log all tool names being returned for debugging
@@ -1772,10 +3050,24 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 		}
 		logger.Trace(fmt.Sprintf("[MCP] tools/list returning %d tools: %v", len(mcpTools), allToolNames))
 
-		resp = NewJSONRPCResponse(req.ID, map[string]interface{}{
-			"tools": mcpTools,
-		})
-		logger.AddLog("INFO", fmt.Sprintf("Returned %d tools (builtins + %d active servers)", len(mcpTools), len(engine.ListActive())))
+		// Large tool sets are split into pages for clients with size limits
+		var listParams struct {
+			Cursor string `json:"cursor"`
+		}
+		json.Unmarshal(req.Params, &listParams)
+		start, end, next, err := paginate(len(mcpTools), listParams.Cursor, g.settings.Get().ToolsPageSize)
+		if err != nil {
+			resp = NewJSONRPCErrorResponse(req.ID, InvalidParams, i18n.T(g.locale(r), "gateway.invalid_cursor"))
+			break
+		}
+		result := map[string]interface{}{
+			"tools": mcpTools[start:end],
+		}
+		if next != "" {
+			result["nextCursor"] = next
+		}
+		resp = NewJSONRPCResponse(req.ID, result)
+		logger.AddLog("INFO", fmt.Sprintf("Returned %d of %d tools (builtins + %d active servers)", end-start, len(mcpTools), len(engine.ListActive())))
 
 	case "resources/list":
 		logger.AddLog("INFO", "Handling 'resources/list' request")
@@ -1799,6 +3091,7 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 		var params struct {
 			Name      string                 `json:"name"`
 			Arguments map[string]interface{} `json:"arguments"`
+			Meta      map[string]interface{} `json:"_meta"`
 		}
 		if err := json.Unmarshal(req.Params, &params); err != nil {
 			msg := fmt.Sprintf("Invalid params for call_tool: %v", err)
@@ -1807,16 +3100,35 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 			break
 		}
 
-		logger.AddLog("INFO", fmt.Sprintf("Handling 'tools/call' for '%s' (Profile: %s)", params.Name, id))
+		// Correlate this call across our logs, the downstream server and the client.
+		// A trace ID supplied by the client is kept so its own traces line up.
+		traceID, _ = params.Meta[discovery.TraceMetaKey].(string)
+		if traceID == "" {
+			traceID = discovery.NewTraceID()
+		}
+		traceLog := func(level, msg string) {
+			logger.AddLog(level, fmt.Sprintf("[trace %s] %s", traceID, msg))
+		}
+
+		traceLog("INFO", fmt.Sprintf("Handling 'tools/call' for '%s' (Profile: %s)", params.Name, id))
 
 		// Sync profile settings with engine
 		p, profileOk := g.manager.GetProfile(id)
+		params.Name = discovery.CanonicalBuiltinName(params.Name, p.BuiltinToolPrefix())
 		if profileOk {
 			engine.SetEnv(p.Env)
 			engine.SetDisabledTools(p.DisabledSystemTools)
-			g.sseClientsMu.RLock()
-			engine.SetSettings(*g.settings)
-			g.sseClientsMu.RUnlock()
+			engine.SetStrictOutputSchema(p.StrictOutputSchema)
+			engine.SetResultBudget(p.MaxResultBytes, p.SummarizeResults)
+			engine.SetLogLevels(p.LogLevels)
+			engine.SetSettings(g.settings.Get())
+		}
+
+		// Calls to tools the profile has retired go to their replacement
+		if m, ok := p.Migration(params.Name); ok {
+			traceLog("WARN", fmt.Sprintf("Tool '%s' is deprecated in profile '%s'; calling '%s' (%s) instead", params.Name, id, m.Tool, m.Server))
+			params.Name = m.Tool
+			params.Arguments = m.Apply(params.Arguments)
 		}
 
 		// Check if this is a builtin tool (always allowed)
@@ -1828,29 +3140,88 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		// Special permission check for scooter_add - the tool being added must be in AllowTools
-		if params.Name == "scooter_add" {
-			toolToAdd, _ := params.Arguments["tool_name"].(string)
-			if toolToAdd != "" && profileOk {
-				isInternal := r.Header.Get("X-Scooter-Internal") == "true"
-				isAllowed := isInternal
-
-				if !isAllowed {
-					for _, allowed := range p.AllowTools {
-						if allowed == toolToAdd {
-							isAllowed = true
-							break
-						}
+		// Access group keys may only activate and call the group's tools
+		if group, restricted := accessGroupFrom(r.Context()); restricted {
+			forbidden := ""
+			switch {
+			case params.Name == "scooter_add" || params.Name == "scooter_activate":
+				for _, target := range discovery.ActivationTargets(params.Arguments) {
+					if !group.AllowsTool(target, target) {
+						forbidden = target
+						break
 					}
 				}
+			case params.Name == "scooter_env" || params.Name == "scooter_deactivate":
+				// These take a server name; deactivating all reaches outside the group
+				target, _ := params.Arguments["tool_name"].(string)
+				if all, _ := params.Arguments["all"].(bool); all && len(group.Tools) > 0 {
+					forbidden = params.Name
+				} else if !group.AllowsTool(target, target) {
+					forbidden = target
+				}
+			case params.Name == "scooter_expand":
+				target, _ := params.Arguments["tool_name"].(string)
+				serverName, _ := engine.GetServerForTool(target)
+				if !group.AllowsTool(target, serverName) {
+					forbidden = target
+				}
+			case params.Name == "scooter_history" || params.Name == "scooter_workspace":
+				// Calls and files of every server in the profile
+				if len(group.Tools) > 0 {
+					forbidden = params.Name
+				}
+			case !isBuiltin:
+				serverName, _ := engine.GetServerForTool(params.Name)
+				if !group.AllowsTool(params.Name, serverName) {
+					forbidden = params.Name
+				}
+			}
+			if forbidden != "" {
+				msg := i18n.T(g.locale(r), "gateway.tool_forbidden", forbidden)
+				traceLog("WARN", fmt.Sprintf("Access group '%s': %s", group.Name, msg))
+				resp = NewJSONRPCErrorResponse(req.ID, InvalidParams, msg)
+				break
+			}
+		}
 
-				if !isAllowed {
-					msg := fmt.Sprintf("Tool '%s' is not allowed for this profile. Add it to AllowTools in your profile configuration before using scooter_add.", toolToAdd)
-					logger.AddLog("ERROR", msg)
-					resp = NewJSONRPCErrorResponse(req.ID, InvalidParams, msg)
+		// Special permission check for scooter_add - the tool being added must be in AllowTools
+		if params.Name == "scooter_add" && profileOk && !isInternalRequest(r.Context()) {
+			notAllowed := ""
+			for _, toolToAdd := range discovery.ActivationTargets(params.Arguments) {
+				if !engine.IsAllowed(p.AllowTools, toolToAdd) {
+					notAllowed = toolToAdd
 					break
 				}
 			}
+
+			if notAllowed != "" {
+				msg := i18n.T(g.locale(r), "gateway.tool_not_allowed_add", notAllowed)
+				traceLog("ERROR", msg)
+				resp = NewJSONRPCErrorResponse(req.ID, InvalidParams, msg)
+				break
+			}
+		}
+
+		// Profile rate limits and tool quotas; tool testing isn't counted
+		if profileOk && !isInternalRequest(r.Context()) {
+			serverName := ""
+			if !isBuiltin {
+				serverName, _ = engine.GetServerForTool(params.Name)
+			}
+			if exceeded, ok := g.limits.Allow(p, params.Name, serverName); !ok {
+				seconds := int(math.Ceil(exceeded.RetryAfter.Seconds()))
+				var msg string
+				if exceeded.Limit == "profile" {
+					msg = i18n.T(g.locale(r), "gateway.rate_limited", seconds)
+				} else {
+					msg = i18n.T(g.locale(r), "gateway.quota_exceeded", exceeded.Limit, seconds)
+				}
+				traceLog("WARN", msg)
+				metrics.Errors.Inc(metrics.ErrorRateLimited)
+				resp = NewJSONRPCErrorResponse(req.ID, RateLimited, msg)
+				resp.Error.Data = map[string]interface{}{"retry_after": seconds, "limit": exceeded.Limit}
+				break
+			}
 		}
 
 		if !isBuiltin {
@@ -1859,8 +3230,8 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 			logger.Trace(fmt.Sprintf("[MCP] Tool lookup: name=%s, serverName=%s, found=%v", params.Name, serverName, found))
 			if !found {
 				// Tool not found in registry at all
-				msg := fmt.Sprintf("Tool '%s' not found. Use scooter_find to discover available tools.", params.Name)
-				logger.AddLog("ERROR", msg)
+				msg := i18n.T(g.locale(r), "gateway.tool_not_found", params.Name)
+				traceLog("ERROR", msg)
 				resp = NewJSONRPCErrorResponse(req.ID, MethodNotFound, msg)
 				break
 			}
@@ -1877,41 +3248,47 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 			if !isActive {
 				// Tool exists but server is not active - NO auto-loading (Docker MCP Toolkit pattern)
 				// Check if it's an internal request (tool testing) - internal requests bypass activation requirement
-				internalHeaderValue := r.Header.Get("X-Scooter-Internal")
-				isInternal := internalHeaderValue == "true"
-				logger.AddLog("DEBUG", fmt.Sprintf("Tool '%s': isActive=%v, internalHeaderValue='%s', isInternal=%v", params.Name, isActive, internalHeaderValue, isInternal))
+				isInternal := isInternalRequest(r.Context())
+				traceLog("DEBUG", fmt.Sprintf("Tool '%s': isActive=%v, isInternal=%v", params.Name, isActive, isInternal))
 
 				// For external requests, check if tool is allowed for this profile
-				isAllowed := false
-				if profileOk {
-					for _, allowed := range p.AllowTools {
-						if allowed == serverName {
-							isAllowed = true
-							break
-						}
-					}
-				}
+				isAllowed := profileOk && engine.IsAllowed(p.AllowTools, serverName)
 
 	logger.Trace(fmt.Sprintf("[MCP] Activation check: tool=%s, isActive=%v, isInternal=%v, isAllowed=%v", params.Name, isActive, isInternal, isAllowed))
 
-				if isInternal {
-					// For internal requests (tool testing), temporarily activate the tool
-					logger.AddLog("DEBUG", fmt.Sprintf("Tool '%s': Internal request detected, temporarily activating server '%s'", params.Name, serverName))
+				// Profiles with auto_activate start allowed servers on first use,
+				// the same way internal requests (tool testing) do
+				autoActivate := !isInternal && isAllowed && p.AutoActivate
+
+				if isInternal || autoActivate {
+					if autoActivate && !g.activations.Allow(id) {
+						msg := i18n.T(g.locale(r), "gateway.auto_activate_limited", serverName)
+						traceLog("WARN", msg)
+						resp = NewJSONRPCErrorResponse(req.ID, InternalError, msg)
+						break
+					}
+					traceLog("DEBUG", fmt.Sprintf("Tool '%s': activating server '%s' on demand (internal=%v)", params.Name, serverName, isInternal))
 					err := engine.Add(serverName)
 					if err != nil {
-						logger.AddLog("ERROR", fmt.Sprintf("Failed to temporarily activate server '%s' for internal request: %v", serverName, err))
+						traceLog("ERROR", fmt.Sprintf("Failed to activate server '%s' on demand: %v", serverName, err))
 						resp = NewJSONRPCErrorResponse(req.ID, MethodNotFound, fmt.Sprintf("Tool error: Failed to activate server '%s': %v", serverName, err))
+						if data := credentialPrompt(id, err); data != nil {
+							resp.Error.Data = data
+						}
 						break
 					}
-					logger.AddLog("DEBUG", fmt.Sprintf("Tool '%s': Server '%s' temporarily activated for testing", params.Name, serverName))
+					if autoActivate {
+						traceLog("INFO", fmt.Sprintf("Auto-activated server '%s' for tool '%s' (Profile: %s)", serverName, params.Name, id))
+						g.NotifyToolsChanged(id)
+					}
 				} else {
 					if isAllowed {
-						msg := fmt.Sprintf("Tool '%s' is not active. Use scooter_add('%s') to enable it first.", params.Name, serverName)
-						logger.AddLog("ERROR", msg)
+						msg := i18n.T(g.locale(r), "gateway.tool_not_active", params.Name, serverName)
+						traceLog("ERROR", msg)
 						resp = NewJSONRPCErrorResponse(req.ID, MethodNotFound, msg)
 					} else {
-						msg := fmt.Sprintf("Tool '%s' is not allowed for this profile. Add '%s' to AllowTools in your profile configuration.", params.Name, serverName)
-						logger.AddLog("ERROR", msg)
+						msg := i18n.T(g.locale(r), "gateway.tool_not_allowed", params.Name, serverName)
+						traceLog("ERROR", msg)
 						resp = NewJSONRPCErrorResponse(req.ID, MethodNotFound, msg)
 					}
 					break
@@ -1921,17 +3298,46 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 
 		// Call unified tool executor
 		startTime := time.Now()
-		result, err := engine.CallTool(params.Name, params.Arguments)
+		var result interface{}
+		var err error
+		if params.Name == "scooter_history" && !engine.IsToolDisabled(params.Name) {
+			var current *gatewaySession
+			if hasSession {
+				current = sess
+			}
+			result, err = g.handleHistoryTool(g.locale(r), p, current, params.Arguments)
+		} else {
+			result, err = engine.CallToolWithMeta(params.Name, params.Arguments, map[string]interface{}{discovery.TraceMetaKey: traceID})
+			if hasSession && p.CallHistory {
+				sess.recordCall(newCallRecord(params.Name, params.Arguments, startTime, err))
+			}
+		}
 		duration := time.Since(startTime)
 
+		calledServer, _ := engine.GetServerForTool(params.Name)
+		record := audit.NewRecord(audit.SourceGateway, id, calledServer, params.Name, params.Arguments, duration, err)
+		record.TraceID = traceID
+		audit.Append(record)
+		if hookRunner := g.manager.Hooks(); hookRunner != nil {
+			ev := hooks.Event{Type: profile.HookToolCalled, Profile: id, Server: calledServer, Tool: params.Name}
+			if err != nil {
+				ev.Error = err.Error()
+			}
+			hookRunner.Fire(ev)
+		}
+
 		if err != nil {
 			msg := fmt.Sprintf("Tool execution error for '%s': %v", params.Name, err)
-			logger.AddLog("ERROR", msg)
-			resp = NewJSONRPCErrorResponse(req.ID, MethodNotFound, fmt.Sprintf("Tool error: %v", err))
+			traceLog("ERROR", msg)
+			resp = NewJSONRPCErrorResponse(req.ID, MethodNotFound, i18n.T(g.locale(r), "gateway.tool_error", err))
+			if data := credentialPrompt(id, err); data != nil {
+				resp.Error.Data = data
+			}
 		} else {
-			logger.AddLog("INFO", fmt.Sprintf("Tool '%s' executed successfully in %v", params.Name, duration))
+			traceLog("INFO", fmt.Sprintf("Tool '%s' executed successfully in %v", params.Name, duration))
 			// If scooter_activate or scooter_deactivate succeeded, notify SSE clients to refresh tools
-			if params.Name == "scooter_activate" || params.Name == "scooter_deactivate" {
+			// One notification per call, however many servers it changed
+			if params.Name == "scooter_activate" || params.Name == "scooter_add" || params.Name == "scooter_deactivate" {
 				g.NotifyToolsChanged(id)
 			}
 
@@ -1963,25 +3369,62 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 					},
 				})
 			}
+
+			// Builtin results name other builtins; use the names this client sees
+			if discovery.IsBuiltinTool(params.Name) && p.BuiltinToolPrefix() != profile.DefaultBuiltinPrefix {
+				if b, err := json.Marshal(resp.Result); err == nil {
+					var renamed map[string]interface{}
+					if json.Unmarshal([]byte(discovery.RenameBuiltins(string(b), p.BuiltinToolPrefix())), &renamed) == nil {
+						resp.Result = renamed
+					}
+				}
+			}
+
+			// Remind the agent on every call that it is relying on a deprecated server
+			if !isBuiltin {
+				if serverName, found := engine.GetServerForTool(params.Name); found {
+					if notice := engine.DeprecationNotice(serverName); notice != "" {
+						if resMap, ok := resp.Result.(map[string]interface{}); ok {
+							appendTextContent(resMap, notice)
+						}
+					}
+				}
+			}
 		}
 
 	default:
-		resp = NewJSONRPCErrorResponse(req.ID, MethodNotFound, "Method not found")
+		resp = NewJSONRPCErrorResponse(req.ID, MethodNotFound, i18n.T(g.locale(r), "gateway.method_not_found"))
+	}
+
+	// Hand the trace ID back so clients can correlate with our logs
+	if traceID != "" {
+		if resp.Error != nil {
+			data, _ := resp.Error.Data.(map[string]interface{})
+			if data == nil {
+				data = map[string]interface{}{}
+			}
+			data[discovery.TraceMetaKey] = traceID
+			resp.Error.Data = data
+		} else if resMap, ok := resp.Result.(map[string]interface{}); ok {
+			setResultMeta(resMap, discovery.TraceMetaKey, traceID)
+		}
+	}
+
+	// Encoded once, whichever way it is delivered
+	respBuf := getBuffer()
+	defer putBuffer(respBuf)
+	encodeJSON(respBuf, resp)
+	respData := respBuf.Bytes()
+	if logger.Verbose() {
+		logger.Trace(fmt.Sprintf("[MCP] Response for request %v: %s", req.ID, logger.TruncateForLog(string(respData), 2048)))
 	}
 
 	// For standard MCP SSE transport, the response SHOULD be sent via the SSE stream,
 	// and the POST request should return 202 Accepted or 200 OK with no body.
-	sessionId := r.URL.Query().Get("sessionId")
 	if sessionId != "" {
-		g.sseClientsMu.RLock()
-		ch, ok := g.sseSessions[sessionId]
-		g.sseClientsMu.RUnlock()
-
-		if ok {
-			respData, _ := json.Marshal(resp)
-			logger.Trace(fmt.Sprintf("[MCP] Response for request %v: %s", req.ID, logger.TruncateForLog(string(respData), 2048)))
+		if hasSession {
 			select {
-			case ch <- string(respData):
+			case sess.ch <- string(respData):
 				logger.AddLog("INFO", fmt.Sprintf("Sent response to SSE session %s", sessionId))
 				logger.Trace(fmt.Sprintf("[MCP] SSE delivery to session %s: success", sessionId))
 				w.WriteHeader(http.StatusAccepted)
@@ -1999,8 +3442,9 @@ func (g *McpGateway) handleMessage(w http.ResponseWriter, r *http.Request) {
 
 	// Fallback/Legacy: send response in the HTTP body (Streamable HTTP style)
 	logger.AddLog("INFO", fmt.Sprintf("Sending MCP response in HTTP body (Profile: %s)", id))
-	respData, _ := json.Marshal(resp)
-	logger.Trace(fmt.Sprintf("[MCP] Response for request %v: %s", req.ID, logger.TruncateForLog(string(respData), 2048)))
+	if hasSession {
+		sess.transcribe("out", respData)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(respData)
 }
@@ -2014,6 +3458,24 @@ type ProfileManager struct {
 	registryDir string
 	clientsDir  string
 	customTools []discovery.ToolDefinition
+
+	// index answers registry lookups for the control API. It is never used to
+	// activate servers, so handlers don't need a throwaway engine per request.
+	index       *discovery.DiscoveryEngine
+	credentials *integration.CredentialManager
+
+	// workspaceRoot holds one scratch directory per profile; "" disables them
+	workspaceRoot string
+	// homeRoot holds the isolated homes of servers, per profile
+	homeRoot string
+	// packageDir holds installed binary packages, per server and version
+	packageDir string
+	// metrics records activation outcomes per registry entry for every engine
+	metrics *discovery.ActivationMetrics
+	// subscribers are told about profile updates (see profile_changes.go)
+	subscribers []func(ProfileChange)
+	// hooks runs the profiles' hooks; nil runs none
+	hooks *hooks.Runner
 }
 
 func NewProfileManager(initial []profile.Profile, wasmDir string, registryDir string, clientsDir string) *ProfileManager {
@@ -2024,13 +3486,302 @@ func NewProfileManager(initial []profile.Profile, wasmDir string, registryDir st
 		registryDir: registryDir,
 		clientsDir:  clientsDir,
 		customTools: []discovery.ToolDefinition{},
+		index:       discovery.NewDiscoveryEngine(context.Background(), wasmDir, registryDir),
+		credentials: integration.NewCredentialManager(),
+		metrics:     discovery.NewActivationMetrics(""),
 	}
 	for _, p := range initial {
-		pm.engines[p.ID] = discovery.NewDiscoveryEngine(context.Background(), wasmDir, registryDir)
+		pm.engines[p.ID] = pm.newEngine(p)
 	}
 	return pm
 }
 
+// newEngine creates a profile engine using the profile's credentials.
+func (pm *ProfileManager) newEngine(p profile.Profile) *discovery.DiscoveryEngine {
+	engine := discovery.NewDiscoveryEngine(context.Background(), pm.wasmDir, pm.registryDir)
+	engine.SetCredentialManager(pm.profileCredentials(p))
+	engine.SetWorkspaceDir(pm.workspaceDir(p.ID))
+	engine.SetHomeIsolation(pm.homeDir(p.ID), p.IsolateHome)
+	engine.SetPackageDir(pm.packageDir)
+	engine.SetProfileID(p.ID)
+	engine.SetRegistryOverlay(p.ID)
+	engine.SetActivationMetrics(pm.metrics)
+	engine.SetEnv(p.Env)
+	configureEngine(engine, p)
+	pm.startHooks(engine, p.ID)
+	return engine
+}
+
+// startHooks lets the profile's hooks run before its servers start, and
+// runs its profile.started hooks; pm.mu must be held.
+func (pm *ProfileManager) startHooks(engine *discovery.DiscoveryEngine, profileID string) {
+	if pm.hooks == nil {
+		return
+	}
+	runner := pm.hooks
+	engine.SetBeforeActivateHook(func(profileID, serverName string) error {
+		return runner.Run(hooks.Event{Type: profile.HookServerActivating, Profile: profileID, Server: serverName})
+	})
+	runner.Fire(hooks.Event{Type: profile.HookProfileStarted, Profile: profileID})
+}
+
+// SetHooks sets the runner of the profiles' hooks. The profiles start being
+// served now, so their profile.started hooks run.
+func (pm *ProfileManager) SetHooks(r *hooks.Runner) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.hooks = r
+	for id, engine := range pm.engines {
+		pm.startHooks(engine, id)
+	}
+}
+
+// Hooks returns the runner of the profiles' hooks, nil if there is none.
+func (pm *ProfileManager) Hooks() *hooks.Runner {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.hooks
+}
+
+// profileCredentials returns the credential manager for p: the instance's,
+// or a namespace of its own when p has isolated credentials, reading the
+// identities p selects. The first time a profile is isolated, the
+// instance's credentials are copied into it.
+func (pm *ProfileManager) profileCredentials(p profile.Profile) *integration.CredentialManager {
+	credentials := pm.credentials
+	if p.IsolatedCredentials {
+		credentials = credentials.ForProfile(p.ID)
+		pm.migrateCredentials(credentials, nil)
+	}
+	if len(p.Identities) > 0 {
+		credentials = credentials.WithIdentities(p.Identities)
+	}
+	return credentials
+}
+
+// migrateCredentials copies the credentials of every registry tool, and
+// extra, into credentials' namespace from the one enclosing it.
+func (pm *ProfileManager) migrateCredentials(credentials *integration.CredentialManager, extra map[string][]string) {
+	creds := make(map[string][]string, len(extra))
+	for tool, envVars := range extra {
+		creds[tool] = envVars
+	}
+	for _, td := range pm.index.Find("") {
+		if names := integration.EnvVarNames(td.Authorization); len(names) > 0 {
+			creds[td.Name] = names
+		}
+	}
+	copied, err := credentials.Migrate(creds)
+	if err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("Failed to migrate credentials into %s: %v", credentials.Namespace(), err))
+	}
+	if copied > 0 {
+		logger.AddLog("INFO", fmt.Sprintf("Copied %d credentials into %s", copied, credentials.Namespace()))
+	}
+}
+
+// SetWorkspaceRoot enables per-profile workspaces under root, one directory
+// per profile ID.
+func (pm *ProfileManager) SetWorkspaceRoot(root string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.workspaceRoot = root
+	for id, engine := range pm.engines {
+		engine.SetWorkspaceDir(pm.workspaceDir(id))
+	}
+}
+
+func (pm *ProfileManager) workspaceDir(profileID string) string {
+	if pm.workspaceRoot == "" {
+		return ""
+	}
+	return filepath.Join(pm.workspaceRoot, profileID)
+}
+
+// SetActivationMetrics replaces the activation metrics shared by every
+// engine, e.g. with ones kept in a file.
+func (pm *ProfileManager) SetActivationMetrics(m *discovery.ActivationMetrics) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.metrics = m
+	for _, engine := range pm.engines {
+		engine.SetActivationMetrics(m)
+	}
+}
+
+// ActivationMetrics returns the activation metrics shared by every engine.
+func (pm *ProfileManager) ActivationMetrics() *discovery.ActivationMetrics {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.metrics
+}
+
+// SetHomeRoot sets where isolated servers get their temporary homes, one
+// directory per profile ID; "" disables isolation.
+func (pm *ProfileManager) SetHomeRoot(root string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.homeRoot = root
+	for _, p := range pm.profiles {
+		if engine, ok := pm.engines[p.ID]; ok {
+			engine.SetHomeIsolation(pm.homeDir(p.ID), p.IsolateHome)
+		}
+	}
+}
+
+func (pm *ProfileManager) homeDir(profileID string) string {
+	if pm.homeRoot == "" {
+		return ""
+	}
+	return filepath.Join(pm.homeRoot, profileID)
+}
+
+// SetPackageDir sets where binary packages are installed.
+func (pm *ProfileManager) SetPackageDir(dir string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.packageDir = dir
+	for _, engine := range pm.engines {
+		engine.SetPackageDir(dir)
+	}
+}
+
+// SetCredentialManager replaces the credential manager shared by every engine.
+func (pm *ProfileManager) SetCredentialManager(credentials *integration.CredentialManager) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.credentials = credentials
+	pm.rebindCredentials()
+}
+
+// SetInstanceID namespaces the keychain credentials by instanceID (see
+// Settings.InstanceID). The shared credentials are copied into a new
+// namespace the first time it is used.
+func (pm *ProfileManager) SetInstanceID(instanceID string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if pm.credentials.Namespace() == pm.credentials.ForInstance(instanceID).Namespace() {
+		return
+	}
+	pm.credentials = pm.credentials.ForInstance(instanceID)
+	pm.migrateCredentials(pm.credentials, map[string][]string{
+		"mcp-scooter:ai_primary":  {"MCP_SCOOTER_PRIMARY_AI_KEY"},
+		"mcp-scooter:ai_fallback": {"MCP_SCOOTER_FALLBACK_AI_KEY"},
+	})
+	pm.rebindCredentials()
+}
+
+// rebindCredentials gives every engine its profile's credential manager.
+// Callers must hold pm.mu.
+func (pm *ProfileManager) rebindCredentials() {
+	for _, p := range pm.profiles {
+		if engine, ok := pm.engines[p.ID]; ok {
+			engine.SetCredentialManager(pm.profileCredentials(p))
+		}
+	}
+}
+
+// Credentials returns the instance's credential manager.
+func (pm *ProfileManager) Credentials() *integration.CredentialManager {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.credentials
+}
+
+// ToolCredentials returns the credential manager for toolName's credentials
+// in the profile with the given ID (the instance's when id is empty). A
+// non-empty identity overrides the one the profile selects for the tool.
+func (pm *ProfileManager) ToolCredentials(id, toolName, identity string) (*integration.CredentialManager, bool) {
+	credentials, ok := pm.ProfileCredentials(id)
+	if ok && identity != "" {
+		credentials = credentials.WithIdentities(map[string]string{toolName: identity})
+	}
+	return credentials, ok
+}
+
+// ProfileCredentials returns the credential manager of the profile with the
+// given ID, or the instance's when id is empty.
+func (pm *ProfileManager) ProfileCredentials(id string) (*integration.CredentialManager, bool) {
+	if id == "" {
+		return pm.Credentials(), true
+	}
+	p, ok := pm.GetProfile(id)
+	if !ok {
+		return nil, false
+	}
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.profileCredentials(p), true
+}
+
+// Catalog returns every registry entry, with custom tools registered at runtime
+// taking precedence over entries of the same name.
+func (pm *ProfileManager) Catalog() []discovery.ToolDefinition {
+	registryTools := pm.index.Find("")
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	tools := make([]discovery.ToolDefinition, len(registryTools), len(registryTools)+len(pm.customTools))
+	copy(tools, registryTools)
+	for _, custom := range pm.customTools {
+		replaced := false
+		for i := range tools {
+			if tools[i].Name == custom.Name {
+				tools[i] = custom
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			tools = append(tools, custom)
+		}
+	}
+	for i := range tools {
+		if stats, ok := pm.metrics.Stats(tools[i].Name); ok {
+			tools[i].Activation = &stats
+		}
+	}
+	return tools
+}
+
+// FindTool looks up a single catalog entry by server name.
+func (pm *ProfileManager) FindTool(name string) (*discovery.ToolDefinition, bool) {
+	for _, td := range pm.Catalog() {
+		if td.Name == name {
+			return &td, true
+		}
+	}
+	return nil, false
+}
+
+// ReloadIndex re-reads the registry used for control API lookups.
+func (pm *ProfileManager) ReloadIndex() error {
+	return pm.index.ReloadRegistry()
+}
+
+// ReloadRegistries re-reads the registry for control API lookups and every
+// profile's engine, after an entry on disk changed.
+func (pm *ProfileManager) ReloadRegistries() {
+	if err := pm.ReloadIndex(); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("Failed to refresh registry index: %v", err))
+	}
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	for profileID, engine := range pm.engines {
+		if err := engine.ReloadRegistry(); err != nil {
+			logger.AddLog("WARN", fmt.Sprintf("Failed to reload registry for profile '%s': %v", profileID, err))
+		}
+	}
+}
+
 func (pm *ProfileManager) GetProfiles() []profile.Profile {
 	pm.mu.RLock()
 	defer pm.mu.RUnlock()
@@ -2059,6 +3810,9 @@ func (pm *ProfileManager) ClearProfiles() {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
+	for _, engine := range pm.engines {
+		engine.Stop()
+	}
 	pm.profiles = []profile.Profile{}
 	pm.engines = make(map[string]*discovery.DiscoveryEngine)
 }
@@ -2074,14 +3828,29 @@ func (pm *ProfileManager) AddProfile(p profile.Profile) error {
 	}
 
 	pm.profiles = append(pm.profiles, p)
-	pm.engines[p.ID] = discovery.NewDiscoveryEngine(context.Background(), pm.wasmDir, pm.registryDir)
+	pm.engines[p.ID] = pm.newEngine(p)
 	return nil
 }
 
+// UpdateProfile replaces the profile oldID with p and reconfigures its
+// engine right away: environment, disabled tools and policies. Active
+// servers whose environment changed are restarted in the background.
+// Subscribers are told what changed.
 func (pm *ProfileManager) UpdateProfile(oldID string, p profile.Profile) error {
 	pm.mu.Lock()
-	defer pm.mu.Unlock()
+	change, engine, err := pm.updateProfileLocked(oldID, p)
+	pm.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	pm.publish(change)
+	if engine != nil && len(change.Restarting) > 0 {
+		pm.restartServers(engine, p.ID, change.Restarting)
+	}
+	return nil
+}
 
+func (pm *ProfileManager) updateProfileLocked(oldID string, p profile.Profile) (ProfileChange, *discovery.DiscoveryEngine, error) {
 	for i, existing := range pm.profiles {
 		if existing.ID == oldID {
 			// If ID changed, update the engines map
@@ -2089,20 +3858,32 @@ func (pm *ProfileManager) UpdateProfile(oldID string, p profile.Profile) error {
 				// Check if new ID already exists
 				for _, other := range pm.profiles {
 					if other.ID == p.ID {
-						return fmt.Errorf("profile with ID '%s' already exists", p.ID)
+						return ProfileChange{}, nil, fmt.Errorf("profile with ID '%s' already exists", p.ID)
 					}
 				}
 				// Move engine to new ID
 				if engine, ok := pm.engines[oldID]; ok {
 					pm.engines[p.ID] = engine
 					delete(pm.engines, oldID)
+
+					// The workspace follows the profile
+					if dir := pm.workspaceDir(p.ID); dir != "" {
+						if err := os.Rename(pm.workspaceDir(oldID), dir); err != nil && !os.IsNotExist(err) {
+							logger.AddLog("WARN", fmt.Sprintf("Failed to move workspace of profile '%s': %v", oldID, err))
+						}
+						engine.SetWorkspaceDir(dir)
+					}
 				}
 			}
 			pm.profiles[i] = p
-			return nil
+			engine, ok := pm.engines[p.ID]
+			if !ok {
+				return ProfileChange{ProfileID: p.ID, Fields: changedFields(existing, p)}, nil, nil
+			}
+			return pm.applyProfileLocked(engine, existing, p), engine, nil
 		}
 	}
-	return fmt.Errorf("profile not found")
+	return ProfileChange{}, nil, fmt.Errorf("profile not found")
 }
 
 func (pm *ProfileManager) RemoveProfile(id string) error {
@@ -2111,6 +3892,9 @@ func (pm *ProfileManager) RemoveProfile(id string) error {
 
 	for i, p := range pm.profiles {
 		if p.ID == id {
+			if engine, ok := pm.engines[id]; ok {
+				engine.Stop()
+			}
 			delete(pm.engines, id)
 			pm.profiles = append(pm.profiles[:i], pm.profiles[i+1:]...)
 			return nil
@@ -1,26 +1,55 @@
 package api
 
 import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/mcp-scooter/scooter/internal/demo"
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/integration"
 	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/mcp-scooter/scooter/internal/logger"
+	"github.com/mcp-scooter/scooter/internal/metrics"
+	"github.com/mcp-scooter/scooter/internal/version"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// newRequest is httptest.NewRequest sending paths to this machine: the
+// servers refuse requests for other host names, such as its example.com.
+func newRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	if strings.HasPrefix(target, "/") {
+		req.Host = "localhost:6200"
+	}
+	return req
+}
+
 func TestMcpGatewaySSE(t *testing.T) {
 	pm := NewProfileManager(nil, ".", ".", ".")
 	p := profile.Profile{ID: "test"}
 	pm.AddProfile(p)
 	settings := profile.DefaultSettings()
-	gw := NewMcpGateway(pm, &settings)
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(settings))
 	
-	req := httptest.NewRequest("GET", "/profiles/test/sse", nil)
+	req := newRequest("GET", "/profiles/test/sse", nil)
 	w := httptest.NewRecorder()
 
 	// Use a context with timeout to stop the SSE handler
@@ -39,18 +68,18 @@ func TestMcpGatewaySSE(t *testing.T) {
 func TestControlServerCRUD(t *testing.T) {
 	pm := NewProfileManager(nil, ".", ".", ".")
 	settings := profile.DefaultSettings()
-	srv := NewControlServer(nil, pm, &settings, false)
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(settings), false)
 
 	// 1. Create Profile
 	p := profile.Profile{ID: "work"}
 	pJSON, _ := json.Marshal(p)
-	req := httptest.NewRequest("POST", "/api/profiles", strings.NewReader(string(pJSON)))
+	req := newRequest("POST", "/api/profiles", strings.NewReader(string(pJSON)))
 	w := httptest.NewRecorder()
 	srv.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusCreated, w.Code)
 
 	// 2. Get Profiles
-	req = httptest.NewRequest("GET", "/api/profiles", nil)
+	req = newRequest("GET", "/api/profiles", nil)
 	w = httptest.NewRecorder()
 	srv.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -71,21 +100,2399 @@ func TestControlServerCRUD(t *testing.T) {
 		"old_id":  "work",
 		"profile": p,
 	})
-	req = httptest.NewRequest("PUT", "/api/profiles", strings.NewReader(string(pJSON)))
+	req = newRequest("PUT", "/api/profiles", strings.NewReader(string(pJSON)))
 	w = httptest.NewRecorder()
 	srv.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	// 4. Delete Profile
-	req = httptest.NewRequest("DELETE", "/api/profiles?id=work", nil)
+	req = newRequest("DELETE", "/api/profiles?id=work", nil)
 	w = httptest.NewRecorder()
 	srv.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusNoContent, w.Code)
 
 	// Verify empty
-	req = httptest.NewRequest("GET", "/api/profiles", nil)
+	req = newRequest("GET", "/api/profiles", nil)
 	w = httptest.NewRecorder()
 	srv.ServeHTTP(w, req)
 	json.NewDecoder(w.Body).Decode(&resp)
 	assert.Empty(t, resp.Profiles)
 }
+
+func TestUpdateProfileKeepsAPIKey(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work", APIKey: "profile-key"})
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(profile.DefaultSettings()), false)
+
+	update := func(p profile.Profile) int {
+		body, _ := json.Marshal(map[string]interface{}{"old_id": "work", "profile": p})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newRequest("PUT", "/api/profiles", bytes.NewReader(body)))
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, update(profile.Profile{ID: "work", RemoteServerURL: "http://remote"}))
+	p, _ := pm.GetProfile("work")
+	assert.Equal(t, "http://remote", p.RemoteServerURL)
+	assert.Equal(t, "profile-key", p.APIKey, "leaving api_key out keeps the key")
+
+	require.Equal(t, http.StatusOK, update(profile.Profile{ID: "work", APIKey: "****-key"}))
+	p, _ = pm.GetProfile("work")
+	assert.Equal(t, "profile-key", p.APIKey, "keys only change through regenerate-key")
+}
+
+func TestMcpGatewayProfileAPIKey(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	pm.AddProfile(profile.Profile{ID: "private", APIKey: "profile-key"})
+	settings := profile.DefaultSettings()
+	settings.GatewayAPIKey = "global-key"
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(settings))
+
+	post := func(path, key string) int {
+		body := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+		req := newRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post("/profiles/private/message", "global-key"))
+	assert.NotEqual(t, http.StatusUnauthorized, post("/profiles/private/message", "profile-key"))
+	assert.NotEqual(t, http.StatusUnauthorized, post("/profiles/work/message", "global-key"))
+	assert.Equal(t, http.StatusUnauthorized, post("/message", "profile-key"))
+}
+
+func TestProfileManagerCatalog(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.customTools = append(pm.customTools,
+		discovery.ToolDefinition{Name: "scooter_find", Description: "overridden"},
+		discovery.ToolDefinition{Name: "my-tool"},
+	)
+
+	td, ok := pm.FindTool("scooter_find")
+	assert.True(t, ok)
+	assert.Equal(t, "overridden", td.Description)
+
+	_, ok = pm.FindTool("my-tool")
+	assert.True(t, ok)
+
+	_, ok = pm.FindTool("missing")
+	assert.False(t, ok)
+
+	// Overrides are applied to a copy; the shared index is untouched
+	for _, builtin := range pm.index.Find("") {
+		assert.NotEqual(t, "overridden", builtin.Description)
+	}
+}
+
+func TestControlServerProfilesQuery(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	for _, id := range []string{"work", "work-eu", "work-us", "personal"} {
+		pm.AddProfile(profile.Profile{ID: id})
+	}
+	settings := profile.DefaultSettings()
+	settings.GatewayAPIKey = "secret"
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(settings), false)
+
+	get := func(url string) (int, map[string]json.RawMessage) {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newRequest("GET", url, nil))
+		var resp map[string]json.RawMessage
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp
+	}
+	ids := func(raw json.RawMessage) []string {
+		var items []map[string]interface{}
+		json.Unmarshal(raw, &items)
+		out := []string{}
+		for _, item := range items {
+			out = append(out, item["id"].(string))
+		}
+		return out
+	}
+
+	code, resp := get("/api/profiles")
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotContains(t, resp, "settings")
+	assert.Equal(t, "4", string(resp["total"]))
+
+	_, resp = get("/api/profiles?include=settings")
+	assert.Contains(t, string(resp["settings"]), "secret")
+
+	_, resp = get("/api/profiles?prefix=work-&fields=id")
+	assert.Equal(t, []string{"work-eu", "work-us"}, ids(resp["profiles"]))
+	var items []map[string]interface{}
+	json.Unmarshal(resp["profiles"], &items)
+	assert.Len(t, items[0], 1)
+
+	_, resp = get("/api/profiles?limit=3")
+	assert.Equal(t, []string{"work", "work-eu", "work-us"}, ids(resp["profiles"]))
+	assert.Equal(t, "3", string(resp["next_offset"]))
+	_, resp = get("/api/profiles?offset=3&limit=3")
+	assert.Equal(t, []string{"personal"}, ids(resp["profiles"]))
+	assert.NotContains(t, resp, "next_offset")
+
+	_, resp = get("/api/status?running=true&prefix=per")
+	assert.Equal(t, []string{"personal"}, ids(resp["profiles"]))
+
+	code, _ = get("/api/status?running=maybe")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = get("/api/profiles?limit=-1")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestControlServerStatus(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	settings := profile.DefaultSettings()
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(settings), false)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newRequest("GET", "/api/status", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var status struct {
+		SchemaVersion int          `json:"schema_version"`
+		Running       bool         `json:"running"`
+		Version       version.Info `json:"version"`
+		Ports         struct {
+			Control int `json:"control"`
+			Gateway int `json:"gateway"`
+		} `json:"ports"`
+		Profiles []struct {
+			ID           string                   `json:"id"`
+			Health       string                   `json:"health"`
+			Servers      []discovery.ServerStatus `json:"servers"`
+			RecentErrors []discovery.CallError    `json:"recent_errors"`
+		} `json:"profiles"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&status))
+	assert.Equal(t, statusSchemaVersion, status.SchemaVersion)
+	assert.True(t, status.Running)
+	assert.Equal(t, version.Version, status.Version.Version)
+	assert.Equal(t, settings.ControlPort, status.Ports.Control)
+	assert.Equal(t, settings.McpPort, status.Ports.Gateway)
+	if assert.Len(t, status.Profiles, 1) {
+		assert.Equal(t, "ok", status.Profiles[0].Health)
+		assert.NotNil(t, status.Profiles[0].Servers)
+		assert.NotNil(t, status.Profiles[0].RecentErrors)
+	}
+}
+
+func TestControlServerToolAuth(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work", Env: map[string]string{"ACME_TOKEN": "from-profile"}})
+	pm.customTools = append(pm.customTools, discovery.ToolDefinition{
+		Name: "acme",
+		Authorization: &registry.Authorization{
+			Type:     registry.AuthAPIKey,
+			Required: true,
+			HelpURL:  "https://acme.example/keys",
+			EnvVars: []registry.EnvVarDef{
+				{Name: "ACME_TOKEN", DisplayName: "Token", Secret: true, Required: true},
+				{Name: "ACME_REGION", Required: true, Default: "us"},
+				{Name: "ACME_ORG", Required: true},
+			},
+		},
+	})
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(profile.DefaultSettings()), false)
+
+	get := func(url string) (*httptest.ResponseRecorder, integration.AuthStatus) {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newRequest("GET", url, nil))
+		var status integration.AuthStatus
+		json.Unmarshal(w.Body.Bytes(), &status)
+		return w, status
+	}
+
+	w, status := get("/api/tools/acme/auth?profile=work")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, registry.AuthAPIKey, status.Type)
+	assert.Equal(t, "https://acme.example/keys", status.HelpURL)
+	assert.False(t, status.HasRequired)
+	assert.Equal(t, []string{"ACME_ORG"}, status.Missing)
+
+	sources := map[string]integration.CredentialSource{}
+	for _, c := range status.Credentials {
+		sources[c.Name] = c.Source
+	}
+	assert.Equal(t, integration.SourceProfileEnv, sources["ACME_TOKEN"])
+	assert.Equal(t, integration.SourceDefault, sources["ACME_REGION"])
+	assert.Equal(t, integration.SourceMissing, sources["ACME_ORG"])
+	assert.NotContains(t, w.Body.String(), "from-profile")
+
+	w, _ = get("/api/tools/acme/auth?profile=nope")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w, _ = get("/api/tools/nope/auth")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestControlServerCredentialIdentities(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.SetCredentialManager(integration.NewMemoryCredentialManager())
+	pm.AddProfile(profile.Profile{ID: "work", Identities: map[string]string{"github": "work"}})
+	pm.AddProfile(profile.Profile{ID: "home"})
+	pm.customTools = append(pm.customTools, discovery.ToolDefinition{
+		Name: "github",
+		Authorization: &registry.Authorization{
+			Type:     registry.AuthAPIKey,
+			Required: true,
+			EnvVar:   "GITHUB_TOKEN",
+		},
+	})
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(profile.DefaultSettings()), false)
+
+	store := func(body string) int {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newRequest("POST", "/api/credentials", strings.NewReader(body)))
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, store(`{"tool_name":"github","env_var":"GITHUB_TOKEN","value":"personal-token"}`))
+	assert.Equal(t, http.StatusOK, store(`{"tool_name":"github","env_var":"GITHUB_TOKEN","value":"work-token","identity":"work"}`))
+	assert.Equal(t, http.StatusBadRequest, store(`{"tool_name":"github","env_var":"GITHUB_TOKEN","value":"x","identity":"a:b"}`))
+
+	token := func(profileID string) string {
+		engine, _ := pm.GetEngine(profileID)
+		value, _ := engine.GetCredentialManager().GetCredential("github", "GITHUB_TOKEN")
+		return value
+	}
+	assert.Equal(t, "work-token", token("work"))
+	assert.Equal(t, "personal-token", token("home"))
+
+	// Switching the profile's identity takes effect on its engine
+	require.NoError(t, pm.UpdateProfile("home", profile.Profile{ID: "home", Identities: map[string]string{"github": "work"}}))
+	assert.Equal(t, "work-token", token("home"))
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newRequest("GET", "/api/credentials/check?tool_name=github&profile=work&identity=other", nil))
+	assert.Contains(t, w.Body.String(), `"has_required":false`)
+}
+
+func TestControlServerEnvPreview(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.SetCredentialManager(integration.NewMemoryCredentialManager())
+	pm.AddProfile(profile.Profile{ID: "work", Env: map[string]string{"ACME_REGION": "eu", "ACME_TOKEN": "profile-token-1234"}})
+	require.NoError(t, pm.Credentials().SetCredential("acme", "ACME_TOKEN", "keychain-token-5678"))
+	engine, _ := pm.GetEngine("work")
+	engine.Register(discovery.ToolDefinition{
+		Name:    "acme",
+		Runtime: &registry.Runtime{Transport: registry.TransportStdio, Command: "acme", Env: map[string]string{"ACME_REGION": "us", "ACME_MODE": "fast"}},
+		Authorization: &registry.Authorization{
+			Type:   registry.AuthAPIKey,
+			EnvVar: "ACME_TOKEN",
+		},
+	})
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(profile.DefaultSettings()), false)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newRequest("GET", "/api/tools/acme/env-preview?profile=work", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var preview struct {
+		Env []discovery.EnvVar `json:"env"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	assert.Equal(t, []discovery.EnvVar{
+		{Name: "ACME_MODE", Value: "fast", Source: discovery.EnvSourceRegistry},
+		{Name: "ACME_REGION", Value: "****", Source: discovery.EnvSourceProfile, Overridden: []discovery.EnvSource{discovery.EnvSourceRegistry}},
+		{Name: "ACME_TOKEN", Value: "****5678", Source: discovery.EnvSourceKeychain, Overridden: []discovery.EnvSource{discovery.EnvSourceProfile}},
+	}, preview.Env)
+	assert.NotContains(t, w.Body.String(), "keychain-token")
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, newRequest("GET", "/api/tools/nope/env-preview?profile=work", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, newRequest("GET", "/api/tools/acme/env-preview", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAccessGroups(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	pm.AddProfile(profile.Profile{ID: "kids", AllowTools: []string{"safe-search", "shell"}})
+	settings := profile.DefaultSettings()
+	settings.GatewayAPIKey = "admin-key"
+	settings.AccessGroups = []profile.AccessGroup{
+		{Name: "kids", APIKey: "kid-key", Profiles: []string{"kids"}, Tools: []string{"safe-search"}},
+		{Name: "ops", APIKey: "ops-key", Control: true},
+	}
+	provider := profile.NewSettingsProvider(settings)
+	gw := NewMcpGateway(pm, provider)
+	srv := NewControlServer(nil, pm, provider, false)
+
+	call := func(handler http.Handler, method, path, key, body string) *httptest.ResponseRecorder {
+		req := newRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+
+	assert.Equal(t, http.StatusForbidden, call(gw, "POST", "/profiles/work/message", "kid-key", ping).Code)
+	assert.NotEqual(t, http.StatusForbidden, call(gw, "POST", "/profiles/kids/message", "kid-key", ping).Code)
+	assert.NotEqual(t, http.StatusUnauthorized, call(gw, "POST", "/profiles/work/message", "admin-key", ping).Code)
+
+	activate := func(tool string) JSONRPCResponse {
+		body := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"scooter_activate","arguments":{"tool_name":"` + tool + `"}}}`
+		var resp JSONRPCResponse
+		json.NewDecoder(call(gw, "POST", "/profiles/kids/message", "kid-key", body).Body).Decode(&resp)
+		return resp
+	}
+	resp := activate("shell")
+	if assert.NotNil(t, resp.Error) {
+		assert.Contains(t, resp.Error.Message, "not available to this API key")
+	}
+	resp = activate("safe-search")
+	if resp.Error != nil {
+		assert.NotContains(t, resp.Error.Message, "not available to this API key")
+	}
+
+	// Builtins that reach into servers keep to the group's too
+	builtin := func(name, args string) JSONRPCResponse {
+		body := `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"` + name + `","arguments":` + args + `}}`
+		var resp JSONRPCResponse
+		json.NewDecoder(call(gw, "POST", "/profiles/kids/message", "kid-key", body).Body).Decode(&resp)
+		return resp
+	}
+	for _, c := range []struct{ name, args string }{
+		{"scooter_env", `{"tool_name":"shell"}`},
+		{"scooter_expand", `{"tool_name":"exec"}`},
+		{"scooter_deactivate", `{"tool_name":"shell"}`},
+		{"scooter_deactivate", `{"all":true}`},
+		{"scooter_history", `{}`},
+		{"scooter_workspace", `{"action":"clear"}`},
+	} {
+		resp := builtin(c.name, c.args)
+		if assert.NotNil(t, resp.Error, "%s %s", c.name, c.args) {
+			assert.Contains(t, resp.Error.Message, "not available to this API key")
+		}
+	}
+	resp = builtin("scooter_env", `{"tool_name":"safe-search"}`)
+	if resp.Error != nil {
+		assert.NotContains(t, resp.Error.Message, "not available to this API key")
+	}
+
+	assert.Equal(t, http.StatusForbidden, call(srv, "GET", "/api/profiles", "kid-key", "").Code)
+	assert.Equal(t, http.StatusOK, call(srv, "GET", "/api/profiles", "ops-key", "").Code)
+
+	// Group keys are viewers unless made operators, and never admins
+	assert.Equal(t, http.StatusForbidden, call(srv, "PUT", "/api/settings", "ops-key", `{"control_port":6200}`).Code)
+	assert.Equal(t, http.StatusForbidden, call(srv, "POST", "/api/control-tokens", "ops-key", `{"name":"mine","role":"admin"}`).Code)
+	assert.Equal(t, http.StatusForbidden, call(srv, "POST", "/api/reload", "ops-key", "").Code)
+	provider.Update(func(s *profile.Settings) {
+		s.AccessGroups = []profile.AccessGroup{{Name: "ops", APIKey: "ops-key", Control: true, ControlRole: profile.RoleAdmin}}
+	})
+	assert.Equal(t, http.StatusForbidden, call(srv, "PUT", "/api/settings", "ops-key", `{"control_port":6200}`).Code)
+
+	// A group only reaches its own profiles and tools
+	provider.Update(func(s *profile.Settings) {
+		s.AccessGroups = []profile.AccessGroup{{Name: "kids", APIKey: "kid-key", Profiles: []string{"kids"}, Tools: []string{"safe-search"}, Control: true, ControlRole: profile.RoleOperator}}
+	})
+	assert.Equal(t, http.StatusForbidden, call(srv, "PUT", "/api/profiles", "kid-key", `{"profile":{"id":"work"}}`).Code)
+	assert.Equal(t, http.StatusForbidden, call(srv, "DELETE", "/api/profiles?id=work", "kid-key", "").Code)
+	assert.Equal(t, http.StatusForbidden, call(srv, "GET", "/api/sessions", "kid-key", "").Code)
+	assert.Equal(t, http.StatusForbidden, call(srv, "POST", "/api/tools/call", "kid-key", `{"profile":"work","server":"safe-search","tool":"search"}`).Code)
+	assert.Equal(t, http.StatusForbidden, call(srv, "POST", "/api/tools/call", "kid-key", `{"profile":"kids","server":"shell","tool":"exec"}`).Code)
+	assert.Equal(t, http.StatusForbidden, call(srv, "POST", "/api/tools/call", "kid-key", `{"profile":"kids","server":"safe-search","tool":"scooter_activate"}`).Code)
+	assert.Equal(t, http.StatusForbidden, call(srv, "POST", "/api/tools/activate", "kid-key", `{"profile":"kids","server":"shell"}`).Code)
+	assert.Equal(t, http.StatusForbidden, call(srv, "POST", "/api/tools/deactivate", "kid-key", `{"profile":"work","server":"safe-search"}`).Code)
+	// Allowed, but the server isn't running
+	assert.Equal(t, http.StatusNotFound, call(srv, "POST", "/api/tools/call", "kid-key", `{"profile":"kids","server":"safe-search","tool":"search"}`).Code)
+}
+
+func TestGatewayKeyRouting(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	pm.AddProfile(profile.Profile{ID: "kids", BuiltinPrefix: "kid_"})
+	pm.AddProfile(profile.Profile{ID: "personal", APIKey: "personal-key"})
+	settings := profile.DefaultSettings()
+	settings.GatewayAPIKey = "admin-key"
+	settings.AccessGroups = []profile.AccessGroup{
+		{Name: "kids", APIKey: "kid-key", Profiles: []string{"kids"}, DefaultProfile: "kids"},
+	}
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(settings))
+
+	// firstTool returns the first tool the root route lists for key
+	firstTool := func(key string) (int, string) {
+		req := newRequest("POST", "/message", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+		var resp struct {
+			Result struct {
+				Tools []struct {
+					Name string `json:"name"`
+				} `json:"tools"`
+			} `json:"result"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Result.Tools) == 0 {
+			return w.Code, ""
+		}
+		return w.Code, resp.Result.Tools[0].Name
+	}
+
+	code, tool := firstTool("kid-key")
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, strings.HasPrefix(tool, "kid_"), tool)
+
+	code, _ = firstTool("personal-key")
+	assert.Equal(t, http.StatusUnauthorized, code, "profile keys are only valid on their profile's path")
+
+	code, tool = firstTool("admin-key")
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, strings.HasPrefix(tool, "scooter_"), tool)
+
+	code, _ = firstTool("wrong-key")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestGatewayHostRouting(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	pm.AddProfile(profile.Profile{ID: "kids", BuiltinPrefix: "kid_", Hosts: []string{"kids.internal"}})
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(profile.DefaultSettings()))
+
+	firstTool := func(host, path string) string {
+		req := newRequest("POST", path, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+		req.Host = host
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+		var resp struct {
+			Result struct {
+				Tools []struct {
+					Name string `json:"name"`
+				} `json:"tools"`
+			} `json:"result"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Result.Tools) == 0 {
+			return ""
+		}
+		return resp.Result.Tools[0].Name
+	}
+
+	for _, host := range []string{"kids.scooter.localhost:6277", "KIDS.localhost", "kids.internal:6277"} {
+		assert.True(t, strings.HasPrefix(firstTool(host, "/message"), "kid_"), host)
+	}
+	assert.True(t, strings.HasPrefix(firstTool("nobody.localhost:6277", "/message"), "scooter_"), "unknown profiles fall back to work")
+	assert.True(t, strings.HasPrefix(firstTool("127.0.0.1:6277", "/message"), "scooter_"))
+	assert.True(t, strings.HasPrefix(firstTool("kids.localhost", "/profiles/work/message"), "scooter_"), "the path wins over the host")
+}
+
+func TestGatewayCallHistory(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work", CallHistory: true})
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(profile.DefaultSettings()))
+	sess := newGatewaySession("s1", "work", "test")
+	gw.sseSessions["s1"] = sess
+
+	call := func(name, args string) map[string]interface{} {
+		body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"` + name + `","arguments":` + args + `}}`
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, newRequest("POST", "/profiles/work/message?sessionId=s1", strings.NewReader(body)))
+		require.Equal(t, http.StatusAccepted, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(<-sess.ch), &resp))
+		return resp
+	}
+
+	call("scooter_list_active", `{}`)
+	call("scooter_deactivate", `{"tool_name":""}`)
+
+	resp := call("scooter_history", `{"limit":5}`)
+	text := resp["result"].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	var history struct {
+		Calls []callRecord `json:"calls"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(text), &history))
+	require.Len(t, history.Calls, 2, "scooter_history itself isn't recorded")
+	assert.Equal(t, "scooter_deactivate", history.Calls[0].Tool)
+	assert.False(t, history.Calls[0].Success)
+	assert.Equal(t, `{"tool_name":""}`, history.Calls[0].Arguments)
+	assert.Equal(t, "scooter_list_active", history.Calls[1].Tool)
+	assert.True(t, history.Calls[1].Success)
+
+	// Profiles that haven't opted in neither list nor answer it
+	pm.UpdateProfile("work", profile.Profile{ID: "work"})
+	resp = call("scooter_history", `{}`)
+	assert.Contains(t, resp["error"].(map[string]interface{})["message"], "not enabled")
+}
+
+func TestGatewayToolMigration(t *testing.T) {
+	registryDir, wasmDir := t.TempDir(), t.TempDir()
+	require.NoError(t, demo.Install(registryDir, wasmDir, "scooter"))
+	pm := NewProfileManager(nil, wasmDir, registryDir, ".")
+	pm.AddProfile(profile.Profile{
+		ID:           "work",
+		AllowTools:   []string{demo.WASMServerName},
+		AutoActivate: true,
+		ToolMigrations: []profile.ToolMigration{
+			{From: "old_hello", Server: demo.WASMServerName, Tool: "demo_hello_wasm", RenameArguments: map[string]string{"name": ""}},
+		},
+	})
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(profile.DefaultSettings()))
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"old_hello","arguments":{"name":"x"}}}`
+	w := httptest.NewRecorder()
+	gw.ServeHTTP(w, newRequest("POST", "/profiles/work/message", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Hello from WebAssembly")
+	assert.NotContains(t, w.Body.String(), `"error"`)
+}
+
+func TestGatewayTraceID(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(profile.DefaultSettings()))
+
+	call := func(params string) map[string]interface{} {
+		body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":` + params + `}`
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, newRequest("POST", "/profiles/work/message", strings.NewReader(body)))
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	traceOf := func(obj interface{}, key string) string {
+		meta, _ := obj.(map[string]interface{})[key].(map[string]interface{})
+		id, _ := meta["traceId"].(string)
+		return id
+	}
+
+	resp := call(`{"name":"scooter_list_active","arguments":{}}`)
+	assert.Len(t, traceOf(resp["result"], "_meta"), 32)
+
+	resp = call(`{"name":"scooter_list_active","arguments":{},"_meta":{"traceId":"client-trace"}}`)
+	assert.Equal(t, "client-trace", traceOf(resp["result"], "_meta"))
+
+	resp = call(`{"name":"no_such_tool","arguments":{},"_meta":{"traceId":"failed-call"}}`)
+	assert.Equal(t, "failed-call", traceOf(resp["error"], "data"))
+}
+
+func TestStdioBridge(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	settings := profile.NewSettingsProvider(profile.DefaultSettings())
+	gw := NewMcpGateway(pm, settings)
+	srv := httptest.NewServer(gw)
+	defer srv.Close()
+
+	port, err := strconv.Atoi(srv.URL[strings.LastIndex(srv.URL, ":")+1:])
+	require.NoError(t, err)
+	settings.Update(func(s *profile.Settings) { s.McpPort = port })
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- RunStdioBridge(context.Background(), srv.URL+"/profiles/work/sse", "", inR, outW) }()
+
+	fmt.Fprintln(inW, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	line, err := bufio.NewReader(outR).ReadString('\n')
+	require.NoError(t, err)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(line), &resp))
+	assert.Equal(t, float64(1), resp["id"])
+	assert.Equal(t, "mcp-scooter", resp["result"].(map[string]interface{})["serverInfo"].(map[string]interface{})["name"])
+
+	inW.Close()
+	assert.NoError(t, <-done, "bridge stops cleanly at end of input")
+}
+
+func TestOnboardingDemo(t *testing.T) {
+	registryDir := t.TempDir()
+	wasmDir := t.TempDir()
+	pm := NewProfileManager(nil, wasmDir, registryDir, ".")
+	settings := profile.NewSettingsProvider(profile.DefaultSettings())
+	srv := NewControlServer(nil, pm, settings, true)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newRequest("POST", "/api/onboarding/demo", nil))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	p, ok := pm.GetProfile(demo.ProfileID)
+	require.True(t, ok)
+	assert.True(t, p.AutoActivate)
+	assert.FileExists(t, filepath.Join(registryDir, "custom", demo.ServerName+".json"))
+	assert.Equal(t, profile.StepPickTools, settings.Get().Onboarding.Current(), "create_profile is done")
+
+	// The WASM sample works without any credentials
+	engine, ok := pm.GetEngine(demo.ProfileID)
+	require.True(t, ok)
+	require.NoError(t, engine.Add(demo.WASMServerName))
+	result, err := engine.CallTool("demo_hello_wasm", map[string]interface{}{})
+	require.NoError(t, err)
+	data, _ := json.Marshal(result)
+	assert.Contains(t, string(data), "Hello from WebAssembly")
+
+	// Running it again refreshes the existing profile
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, newRequest("POST", "/api/onboarding/demo", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, pm.GetProfiles(), 1)
+}
+
+func TestControlServerClientStatus(t *testing.T) {
+	clientsDir := t.TempDir()
+	configDir := t.TempDir()
+	writeClient := func(id, config string) {
+		path := filepath.Join(configDir, id+".json")
+		if config != "" {
+			require.NoError(t, os.WriteFile(path, []byte(config), 0644))
+		}
+		def := map[string]interface{}{
+			"id":   id,
+			"name": id,
+			"detection": map[string]interface{}{
+				"paths":        map[string][]string{"all": {configDir}},
+				"config_paths": map[string][]string{"all": {path}},
+			},
+		}
+		data, _ := json.Marshal(def)
+		require.NoError(t, os.WriteFile(filepath.Join(clientsDir, id+".json"), data, 0644))
+	}
+	writeClient("good", `{"mcpServers": {"mcp-scooter": {"url": "http://127.0.0.1:6277/profiles/dev/sse", "headers": {"Authorization": "Bearer gw-key"}}}}`)
+	writeClient("stale", `{"context_servers": {"mcp-scooter": {"url": "http://127.0.0.1:7000/sse"}}}`)
+	writeClient("missing", `{"mcpServers": {"other": {"url": "http://example.com"}}}`)
+
+	pm := NewProfileManager(nil, ".", ".", clientsDir)
+	pm.AddProfile(profile.Profile{ID: "dev"})
+	settings := profile.DefaultSettings()
+	settings.GatewayAPIKey = "gw-key"
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(settings), false)
+
+	req := newRequest("GET", "/api/clients/status", nil)
+	req.Header.Set("Authorization", "Bearer gw-key")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Clients []ClientStatus `json:"clients"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	statuses := map[string]ClientStatus{}
+	for _, st := range body.Clients {
+		statuses[st.ID] = st
+	}
+
+	good := statuses["good"]
+	assert.True(t, good.Configured)
+	assert.False(t, good.Stale)
+	assert.Equal(t, "dev", good.Profile)
+	assert.Nil(t, good.Repair)
+
+	stale := statuses["stale"]
+	assert.True(t, stale.Stale)
+	assert.ElementsMatch(t, []string{"wrong_port", "unknown_profile", "api_key_mismatch"}, stale.Problems)
+	if assert.NotNil(t, stale.Repair) {
+		assert.Equal(t, "/api/clients/sync", stale.Repair.Path)
+		assert.Equal(t, "stale", stale.Repair.Body["target"])
+	}
+
+	missing := statuses["missing"]
+	assert.True(t, missing.Installed)
+	assert.False(t, missing.Configured)
+	assert.NotNil(t, missing.Repair)
+}
+
+func TestActivationLimiter(t *testing.T) {
+	l := newActivationLimiter(2, 50*time.Millisecond)
+	assert.True(t, l.Allow("work"))
+	assert.True(t, l.Allow("work"))
+	assert.False(t, l.Allow("work"))
+	assert.True(t, l.Allow("personal"), "limits are per profile")
+
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, l.Allow("work"))
+}
+
+func TestSessionsEndpoint(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	settings := profile.NewSettingsProvider(profile.DefaultSettings())
+	gw := NewMcpGateway(pm, settings)
+	control := NewControlServer(nil, pm, settings, false)
+	control.SetGateway(gw)
+	srv := httptest.NewServer(gw)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/profiles/work/sse")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	endpoint := make(chan string, 1)
+	streamDone := make(chan struct{})
+	go func() {
+		readSSE(resp.Body, func(event, data string) error {
+			if event == "endpoint" {
+				endpoint <- data
+			}
+			return nil
+		})
+		close(streamDone)
+	}()
+	sessionURL := <-endpoint
+	sessionID := sessionURL[strings.Index(sessionURL, "sessionId=")+len("sessionId="):]
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"clientInfo":{"name":"test-client","version":"1.2.3"}}}`
+	post, err := http.Post(srv.URL+"/profiles/work/sse?sessionId="+sessionID, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	post.Body.Close()
+
+	w := httptest.NewRecorder()
+	control.ServeHTTP(w, newRequest("GET", "/api/sessions", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Sessions []SessionInfo `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Sessions, 1)
+	sess := list.Sessions[0]
+	assert.Equal(t, sessionID, sess.ID)
+	assert.Equal(t, "work", sess.Profile)
+	assert.Equal(t, "test-client", sess.ClientName)
+	assert.Equal(t, "1.2.3", sess.ClientVersion)
+	assert.Equal(t, 1, sess.MessagesIn)
+
+	w = httptest.NewRecorder()
+	control.ServeHTTP(w, newRequest("DELETE", "/api/sessions/"+sessionID, nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	select {
+	case <-streamDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream was not closed")
+	}
+
+	w = httptest.NewRecorder()
+	control.ServeHTTP(w, newRequest("DELETE", "/api/sessions/"+sessionID, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	assert.Equal(t, 1, gw.DisconnectCounts()["closed_by_api"])
+}
+
+func TestSessionExpiry(t *testing.T) {
+	sess := newGatewaySession("s1", "work", "")
+	start := sess.connectedAt
+
+	reason := func(now time.Time, idle, lifetime time.Duration) string {
+		_, r := sess.expired(now, idle, lifetime)
+		return r
+	}
+	assert.Empty(t, reason(start.Add(time.Minute), 5*time.Minute, time.Hour))
+	assert.Contains(t, reason(start.Add(6*time.Minute), 5*time.Minute, time.Hour), "idle")
+	assert.Empty(t, reason(start.Add(6*time.Minute), 0, time.Hour), "0 disables the idle limit")
+
+	// Client messages keep the session alive, but not past its lifetime
+	sess.received()
+	assert.Empty(t, reason(sess.lastActivity.Add(time.Minute), 5*time.Minute, time.Hour))
+	cause, msg := sess.expired(start.Add(2*time.Hour), 0, time.Hour)
+	assert.Equal(t, causeLifetime, cause)
+	assert.Contains(t, msg, "maximum session lifetime")
+
+	sess.close(causeIdle, "first")
+	sess.close(causeControlAPI, "second")
+	<-sess.done
+	assert.Equal(t, causeIdle, sess.closeCause)
+	assert.Equal(t, "first", sess.closeReason)
+}
+
+func TestSSEHeartbeat(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	cfg := profile.DefaultSettings()
+	cfg.HeartbeatSeconds = 1
+	cfg.LegacyPulseClients = []string{"Old-Client"}
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(cfg))
+	srv := httptest.NewServer(gw)
+	t.Cleanup(srv.Close) // runs after the streams are closed
+
+	// open connects a session as client and returns the first line of its
+	// first heartbeat
+	open := func(client string) string {
+		resp, err := http.Get(srv.URL + "/profiles/work/sse")
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		lines := bufio.NewScanner(resp.Body)
+		var sessionURL string
+		for sessionURL == "" && lines.Scan() {
+			if data, ok := strings.CutPrefix(lines.Text(), "data: "); ok {
+				sessionURL = data
+			}
+		}
+		body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"clientInfo":{"name":"` + client + `"}}}`
+		post, err := http.Post(srv.URL+"/profiles/work/sse?"+sessionURL[strings.Index(sessionURL, "sessionId="):], "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		post.Body.Close()
+		for lines.Scan() {
+			line := lines.Text()
+			if line == ": ping" || line == "event: pulse" {
+				return line
+			}
+		}
+		return ""
+	}
+
+	assert.Equal(t, ": ping", open("test-client"), "keep-alives are SSE comments")
+	assert.Equal(t, "event: pulse", open("old-client"), "listed clients get pulse events")
+}
+
+func TestSessionTranscript(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	settings := profile.NewSettingsProvider(profile.DefaultSettings())
+	gw := NewMcpGateway(pm, settings)
+	control := NewControlServer(nil, pm, settings, false)
+	control.SetGateway(gw)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		control.ServeHTTP(w, newRequest("GET", url, nil))
+		return w
+	}
+
+	// Not recorded unless session_transcripts is on
+	plain := newGatewaySession("s0", "work", "")
+	plain.transcribe("in", []byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	gw.keepTranscript(plain)
+	assert.Equal(t, http.StatusNotFound, get("/api/sessions/s0/transcript").Code)
+
+	sess := newGatewaySession("s1", "work", "")
+	sess.startTranscript()
+	sess.transcribe("in", []byte(`{"jsonrpc":"2.0","id":"a","method":"tools/call","params":{"name":"scooter_find"}}`))
+	sess.transcribe("in", []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+	sess.transcribe("out", []byte(`{"jsonrpc":"2.0","id":"a","result":{"content":[]}}`))
+	gw.keepTranscript(sess)
+
+	w := get("/api/sessions/s1/transcript")
+	require.Equal(t, http.StatusOK, w.Code)
+	var tr Transcript
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tr))
+	assert.Equal(t, "work", tr.Session.Profile)
+	assert.NotNil(t, tr.EndedAt)
+	require.Len(t, tr.Entries, 3)
+	assert.Equal(t, "request", tr.Entries[0].Kind)
+	assert.Equal(t, "notification", tr.Entries[1].Kind)
+	resp := tr.Entries[2]
+	assert.Equal(t, "response", resp.Kind)
+	assert.Equal(t, "out", resp.Direction)
+	assert.Equal(t, "tools/call", resp.Method, "responses take their request's method")
+	assert.NotNil(t, resp.DurationMs)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":"a","result":{"content":[]}}`, string(resp.Message))
+
+	w = get("/api/sessions/s1/transcript?format=markdown")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "# Session s1")
+	assert.Contains(t, w.Body.String(), "## 3. response `tools/call` #\"a\", scooter → client")
+
+	assert.Equal(t, http.StatusBadRequest, get("/api/sessions/s1/transcript?format=html").Code)
+}
+
+func TestGatewayEchoesRequestIDs(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(profile.DefaultSettings()))
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, newRequest("POST", "/profiles/work/message", strings.NewReader(body)))
+		return w
+	}
+
+	for _, id := range []string{`"abc"`, `""`, `0`, `-1`, `9007199254740993`, `1.5`, `null`} {
+		t.Run(id, func(t *testing.T) {
+			w := post(`{"jsonrpc":"2.0","id":` + id + `,"method":"tools/list"}`)
+			require.Equal(t, http.StatusOK, w.Code)
+			var resp struct {
+				ID     json.RawMessage `json:"id"`
+				Result json.RawMessage `json:"result"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, id, string(resp.ID), "id is echoed byte for byte")
+			assert.NotEmpty(t, resp.Result)
+		})
+	}
+
+	// A message without an id is a notification and gets no response
+	w := post(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// Objects, arrays and booleans are not valid ids
+	for _, id := range []string{`{}`, `[1]`, `true`} {
+		w := post(`{"jsonrpc":"2.0","id":` + id + `,"method":"tools/list"}`)
+		var resp JSONRPCResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Error, id)
+		assert.Equal(t, InvalidRequest, resp.Error.Code)
+		assert.Nil(t, resp.ID)
+	}
+}
+
+func TestGatewayPing(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(profile.DefaultSettings()))
+
+	w := httptest.NewRecorder()
+	gw.ServeHTTP(w, newRequest("POST", "/profiles/work/message", strings.NewReader(`{"jsonrpc":"2.0","id":"p1","method":"ping"}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":"p1","result":{}}`, w.Body.String())
+}
+
+func TestGatewayBuiltinPrefix(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work", BuiltinPrefix: "mcp_"})
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(profile.DefaultSettings()))
+
+	post := func(body string) map[string]interface{} {
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, newRequest("POST", "/profiles/work/message", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	resp := post(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	var names []string
+	for _, tool := range resp["result"].(map[string]interface{})["tools"].([]interface{}) {
+		tool := tool.(map[string]interface{})
+		names = append(names, tool["name"].(string))
+		assert.NotContains(t, tool["description"], "scooter_add", "descriptions use the profile's prefix")
+	}
+	assert.Contains(t, names, "mcp_find")
+	assert.Contains(t, names, "mcp_add")
+	assert.NotContains(t, names, "scooter_find")
+
+	resp = post(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"mcp_list_active","arguments":{}}}`)
+	assert.Nil(t, resp["error"])
+	assert.NotNil(t, resp["result"])
+}
+
+func TestGatewayToolsListPagination(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	settings := profile.DefaultSettings()
+	settings.ToolsPageSize = 3
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(settings))
+
+	list := func(cursor string) map[string]interface{} {
+		body := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+		if cursor != "" {
+			body = `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{"cursor":"` + cursor + `"}}`
+		}
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, newRequest("POST", "/profiles/work/message", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	seen := map[string]bool{}
+	cursor, pages := "", 0
+	for {
+		result := list(cursor)["result"].(map[string]interface{})
+		tools := result["tools"].([]interface{})
+		assert.LessOrEqual(t, len(tools), 3)
+		for _, tool := range tools {
+			name := tool.(map[string]interface{})["name"].(string)
+			assert.False(t, seen[name], "%s returned twice", name)
+			seen[name] = true
+		}
+		pages++
+		next, _ := result["nextCursor"].(string)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	assert.Greater(t, pages, 1)
+	assert.True(t, seen["scooter_find"])
+	assert.True(t, seen["scooter_add"])
+
+	resp := list("not-a-cursor")
+	require.NotNil(t, resp["error"])
+	assert.EqualValues(t, InvalidParams, resp["error"].(map[string]interface{})["code"])
+}
+
+func TestGatewayCompressesLargeResponses(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	settings := profile.DefaultSettings()
+	settings.CompressResponses = true
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(settings))
+
+	post := func(body, acceptEncoding string) *httptest.ResponseRecorder {
+		req := newRequest("POST", "/profiles/work/message", strings.NewReader(body))
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w
+	}
+
+	w := post(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, "br, gzip")
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	zr, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	var resp map[string]interface{}
+	require.NoError(t, json.NewDecoder(zr).Decode(&resp))
+	assert.NotEmpty(t, resp["result"].(map[string]interface{})["tools"])
+
+	w = post(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, "deflate")
+	assert.Equal(t, "deflate", w.Header().Get("Content-Encoding"))
+
+	w = post(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, "gzip;q=0")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+
+	// Small responses aren't worth compressing
+	w = post(`{"jsonrpc":"2.0","id":2,"method":"ping"}`, "gzip")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":2,"result":{}}`, w.Body.String())
+
+	// Neither are SSE streams
+	srv := httptest.NewServer(gw)
+	defer srv.Close()
+	req, _ := http.NewRequest("GET", srv.URL+"/profiles/work/sse", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	stream, err := http.DefaultTransport.RoundTrip(req)
+	require.NoError(t, err)
+	defer stream.Body.Close()
+	assert.Empty(t, stream.Header.Get("Content-Encoding"))
+	assert.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+}
+
+func TestHTTPServerTimeoutsSpareSSE(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(profile.DefaultSettings()))
+
+	cfg := profile.DefaultSettings().HTTPServer
+	cfg.ReadTimeoutSeconds, cfg.WriteTimeoutSeconds = 1, 1
+	srv := httptest.NewUnstartedServer(nil)
+	srv.Config = NewHTTPServer("", gw, cfg)
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/profiles/work/sse")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	events := make(chan string, 4)
+	go readSSE(resp.Body, func(event, data string) error {
+		events <- event + " " + data
+		return nil
+	})
+	endpoint := <-events
+	sessionID := endpoint[strings.Index(endpoint, "sessionId=")+len("sessionId="):]
+
+	// Outlive both timeouts, then make sure the stream still delivers
+	time.Sleep(2500 * time.Millisecond)
+	post, err := http.Post(srv.URL+"/profiles/work/sse?sessionId="+sessionID, "application/json",
+		strings.NewReader(`{"jsonrpc":"2.0","id":7,"method":"ping"}`))
+	require.NoError(t, err)
+	post.Body.Close()
+	require.Equal(t, http.StatusAccepted, post.StatusCode)
+
+	select {
+	case msg := <-events:
+		assert.Contains(t, msg, `"id":7`)
+	case <-time.After(2 * time.Second):
+		t.Fatal("SSE stream stopped delivering after the server timeouts")
+	}
+}
+
+func TestReloadConfiguration(t *testing.T) {
+	dir := t.TempDir()
+	store := profile.NewStore(filepath.Join(dir, "profiles.yaml"), filepath.Join(dir, "settings.yaml"))
+	settings := profile.DefaultSettings()
+	require.NoError(t, store.Save([]profile.Profile{{ID: "work"}, {ID: "old"}}, settings))
+
+	profiles, loaded, err := store.Load()
+	require.NoError(t, err)
+	pm := NewProfileManager(profiles, ".", ".", ".")
+	provider := profile.NewSettingsProvider(loaded)
+	gw := NewMcpGateway(pm, provider)
+	control := NewControlServer(store, pm, provider, false)
+	control.SetGateway(gw)
+	workEngine, _ := pm.GetEngine("work")
+
+	settings.ToolsPageSize = 10
+	require.NoError(t, store.Save([]profile.Profile{{ID: "work", AutoActivate: true}, {ID: "new"}}, settings))
+
+	w := httptest.NewRecorder()
+	control.ServeHTTP(w, newRequest("POST", "/api/reload", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var result ReloadResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, []string{"new"}, result.Added)
+	assert.Equal(t, []string{"old"}, result.Removed)
+	assert.Equal(t, []string{"work"}, result.Updated)
+	assert.True(t, result.SettingsChanged)
+
+	engine, ok := pm.GetEngine("work")
+	require.True(t, ok)
+	assert.Same(t, workEngine, engine, "existing profiles keep their engine")
+	p, _ := pm.GetProfile("work")
+	assert.True(t, p.AutoActivate)
+	_, ok = pm.GetEngine("old")
+	assert.False(t, ok)
+	_, ok = pm.GetEngine("new")
+	assert.True(t, ok)
+	assert.Equal(t, 10, provider.Get().ToolsPageSize)
+
+	// A broken file leaves the running configuration alone
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "profiles.yaml"), []byte("profiles: [\n"), 0644))
+	w = httptest.NewRecorder()
+	control.ServeHTTP(w, newRequest("POST", "/api/reload", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	_, ok = pm.GetEngine("new")
+	assert.True(t, ok)
+}
+
+// BenchmarkGatewayMessage measures the gateway's JSON-RPC round trip for a
+// cheap request and for the larger tools/list response.
+func BenchmarkGatewayMessage(b *testing.B) {
+	logger.SetConsoleLevel("OFF")
+	defer logger.SetConsoleLevel("")
+
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(profile.DefaultSettings()))
+
+	for _, method := range []string{"ping", "tools/list"} {
+		body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `"}`
+		b.Run(method, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				gw.ServeHTTP(w, newRequest("POST", "/profiles/work/message", strings.NewReader(body)))
+				if w.Code != http.StatusOK {
+					b.Fatalf("status %d", w.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateProfileReconfiguresEngine(t *testing.T) {
+	registryDir, wasmDir := t.TempDir(), t.TempDir()
+	require.NoError(t, demo.Install(registryDir, wasmDir, "scooter"))
+	pm := NewProfileManager(nil, wasmDir, registryDir, ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	engine, _ := pm.GetEngine("work")
+	require.NoError(t, engine.Add(demo.WASMServerName))
+
+	changes := make(chan ProfileChange, 4)
+	pm.Subscribe(func(c ProfileChange) { changes <- c })
+	next := func() ProfileChange {
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(10 * time.Second):
+			t.Fatal("no profile change published")
+			return ProfileChange{}
+		}
+	}
+
+	require.NoError(t, pm.UpdateProfile("work", profile.Profile{
+		ID:                  "work",
+		Env:                 map[string]string{"DEMO_TOKEN": "abc"},
+		DisabledSystemTools: []string{"scooter_find"},
+	}))
+	assert.True(t, engine.IsToolDisabled("scooter_find"), "applied before the next gateway call")
+	change := next()
+	assert.ElementsMatch(t, []string{"env", "disabled_system_tools"}, change.Fields)
+	assert.Equal(t, []string{demo.WASMServerName}, change.Restarting)
+	assert.True(t, change.ToolsChanged())
+
+	change = next()
+	assert.Equal(t, []string{demo.WASMServerName}, change.Restarted)
+	assert.True(t, engine.IsActive(demo.WASMServerName))
+
+	// Without an env change nothing restarts
+	require.NoError(t, pm.UpdateProfile("work", profile.Profile{ID: "work", Env: map[string]string{"DEMO_TOKEN": "abc"}}))
+	change = next()
+	assert.Equal(t, []string{"disabled_system_tools"}, change.Fields)
+	assert.Empty(t, change.Restarting)
+	assert.False(t, engine.IsToolDisabled("scooter_find"))
+}
+
+func TestAllowToolsExpressions(t *testing.T) {
+	registryDir, wasmDir := t.TempDir(), t.TempDir()
+	require.NoError(t, demo.Install(registryDir, wasmDir, "scooter"))
+	pm := NewProfileManager(nil, wasmDir, registryDir, ".")
+	pm.AddProfile(profile.Profile{ID: "work", AllowTools: []string{"tag:wasm"}, AutoActivate: true})
+	settings := profile.NewSettingsProvider(profile.DefaultSettings())
+	gw := NewMcpGateway(pm, settings)
+
+	engine, _ := pm.GetEngine("work")
+	assert.True(t, engine.IsAllowed([]string{"tag:wasm"}, demo.WASMServerName))
+	assert.False(t, engine.IsAllowed([]string{"tag:wasm"}, demo.ServerName))
+	assert.False(t, engine.IsAllowed([]string{"*"}, "scooter_find"), "builtins are not matched")
+	assert.Equal(t, []string{demo.ServerName, demo.WASMServerName}, engine.AllowedServers([]string{demo.ServerName, "scooter-demo-*"}))
+
+	// The expression lets the gateway auto-activate the WASM demo
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"demo_hello_wasm","arguments":{}}}`
+	w := httptest.NewRecorder()
+	gw.ServeHTTP(w, newRequest("POST", "/profiles/work/message", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Hello from WebAssembly")
+
+	srv := NewControlServer(nil, pm, settings, false)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, newRequest("GET", "/api/allow-tools/preview?expr=category:utility&expr=nope-*", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var preview struct {
+		Expressions []struct {
+			Expr    string   `json:"expr"`
+			Servers []string `json:"servers"`
+		} `json:"expressions"`
+		Servers []string `json:"servers"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	require.Len(t, preview.Expressions, 2)
+	assert.ElementsMatch(t, []string{demo.ServerName, demo.WASMServerName}, preview.Expressions[0].Servers)
+	assert.Empty(t, preview.Expressions[1].Servers)
+	assert.Len(t, preview.Servers, 2)
+}
+
+func TestControlTokenRoles(t *testing.T) {
+	pm := NewProfileManager(nil, t.TempDir(), t.TempDir(), ".")
+	pm.AddProfile(profile.Profile{ID: "work", Env: map[string]string{"API_TOKEN": "hunter2"}})
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(profile.DefaultSettings()), false)
+	srv.SetInternalToken("desktop-token")
+
+	// httptest requests come from 192.0.2.1, another machine
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := newRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	// Without tokens the API is open, as before
+	w := do("POST", "/api/control-tokens", "", `{"name":"dashboard","role":"viewer"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	viewer := created.Token
+	require.NotEmpty(t, viewer)
+
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/api/status", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/api/status", "wrong", "").Code)
+
+	assert.Equal(t, http.StatusOK, do("GET", "/api/status", viewer, "").Code)
+	assert.Equal(t, http.StatusOK, do("GET", "/api/logs", viewer, "").Code)
+	w = do("GET", "/api/profiles", viewer, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "hunter2")
+	assert.Contains(t, w.Body.String(), "API_TOKEN")
+	assert.Equal(t, http.StatusForbidden, do("GET", "/api/profiles?include=settings", viewer, "").Code)
+	assert.Equal(t, http.StatusForbidden, do("GET", "/api/settings", viewer, "").Code)
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/tools/activate", viewer, `{}`).Code)
+	assert.Equal(t, http.StatusForbidden, do("PUT", "/api/profiles", viewer, `{}`).Code)
+	assert.Equal(t, http.StatusForbidden, do("GET", "/api/control-tokens", viewer, "").Code)
+
+	// A viewer on this machine can't drop its token to become admin
+	req := newRequest("PUT", "/api/settings", strings.NewReader(`{"control_port":6200}`))
+	req.RemoteAddr = "127.0.0.1:50000"
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Scooter Desktop is the owner by its internal token
+	local := func(method, path, internal, body string) *httptest.ResponseRecorder {
+		req := newRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = "[::1]:50000"
+		req.Header.Set(InternalTokenHeader, internal)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusUnauthorized, local("GET", "/api/control-tokens", "wrong", "").Code)
+	w = local("GET", "/api/control-tokens", "desktop-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"role":"viewer"`)
+	assert.NotContains(t, w.Body.String(), viewer, "tokens are masked once created")
+
+	w = do("POST", "/api/control-tokens", viewer, `{"name":"ops","role":"operator"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = local("POST", "/api/control-tokens", "desktop-token", `{"name":"admin","role":"admin"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	admin := created.Token
+
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/api/control-tokens", admin, `{"name":"x","role":"root"}`).Code)
+	assert.Equal(t, http.StatusConflict, do("POST", "/api/control-tokens", admin, `{"name":"dashboard","role":"admin"}`).Code)
+
+	// Probing runs any command, so operators can't
+	w = do("POST", "/api/control-tokens", admin, `{"name":"ops","role":"operator"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/probe", created.Token, `{"command":"true"}`).Code)
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/tools/verify", created.Token, `{"tool_name":"x"}`).Code)
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/api/control-tokens/dashboard", admin, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/api/status", viewer, "").Code)
+
+	// A reset keeps the tokens, so the API doesn't open up to everyone
+	require.Equal(t, http.StatusOK, do("POST", "/api/reset", admin, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/api/status", "", "").Code)
+	assert.Equal(t, http.StatusOK, do("GET", "/api/status", admin, "").Code)
+}
+
+func TestGatewayRateLimit(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{
+		ID:         "work",
+		RateLimit:  profile.RateLimit{PerMinute: 3},
+		ToolQuotas: map[string]profile.RateLimit{"scooter_find": {PerHour: 1}},
+	})
+	settings := profile.NewSettingsProvider(profile.DefaultSettings())
+	gw := NewMcpGateway(pm, settings)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	gw.limits.now = func() time.Time { return now }
+
+	call := func(tool string) map[string]interface{} {
+		w := httptest.NewRecorder()
+		body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"` + tool + `","arguments":{}}}`
+		gw.ServeHTTP(w, newRequest("POST", "/profiles/work/message", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	rpcError := func(resp map[string]interface{}) map[string]interface{} {
+		require.NotNil(t, resp["error"], "call should be refused")
+		return resp["error"].(map[string]interface{})
+	}
+
+	assert.Nil(t, call("scooter_find")["error"])
+	e := rpcError(call("scooter_find"))
+	assert.Equal(t, float64(RateLimited), e["code"])
+	assert.Equal(t, float64(3600), e["data"].(map[string]interface{})["retry_after"])
+	assert.Equal(t, "scooter_find", e["data"].(map[string]interface{})["limit"])
+
+	now = now.Add(10 * time.Second)
+	assert.Nil(t, call("scooter_list_active")["error"])
+	assert.Nil(t, call("scooter_list_active")["error"])
+	e = rpcError(call("scooter_list_active"))
+	assert.Equal(t, float64(50), e["data"].(map[string]interface{})["retry_after"])
+	assert.Equal(t, "profile", e["data"].(map[string]interface{})["limit"])
+	assert.Contains(t, e["message"], "try again in 50 seconds")
+
+	srv := NewControlServer(nil, pm, settings, false)
+	srv.SetGateway(gw)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newRequest("GET", "/api/status", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var status struct {
+		Profiles []struct {
+			RateLimit *RateLimitStatus `json:"rate_limit"`
+		} `json:"profiles"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	require.Len(t, status.Profiles, 1)
+	rl := status.Profiles[0].RateLimit
+	require.NotNil(t, rl)
+	assert.Equal(t, 3, rl.CallsLastMinute)
+	assert.Equal(t, 2, rl.Rejected)
+	assert.Equal(t, RateLimitUsage{Limit: profile.RateLimit{PerHour: 1}, CallsLastMinute: 1, CallsLastHour: 1}, rl.Tools["scooter_find"])
+
+	// The oldest call leaves the minute window
+	now = now.Add(51 * time.Second)
+	assert.Nil(t, call("scooter_list_active")["error"])
+}
+
+func TestTunnelHandler(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "home"})
+	pm.AddProfile(profile.Profile{ID: "work", APIKey: "work-key"})
+	settings := profile.NewSettingsProvider(profile.DefaultSettings())
+	gw := NewMcpGateway(pm, settings)
+
+	do := func(profileID, path, key string, internal bool) *httptest.ResponseRecorder {
+		req := newRequest("POST", path, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		if internal {
+			req.Header.Set("X-Scooter-Internal", "true")
+		}
+		w := httptest.NewRecorder()
+		gw.TunnelHandler(profileID).ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, do("home", "/message", "", true).Code, "no key is configured for home")
+	settings.Update(func(s *profile.Settings) { s.GatewayAPIKey = "gateway-key" })
+	assert.Equal(t, http.StatusUnauthorized, do("home", "/message", "", true).Code, "internal requests still need a key")
+	assert.Equal(t, http.StatusOK, do("home", "/message", "gateway-key", false).Code)
+
+	assert.Equal(t, http.StatusUnauthorized, do("work", "/profiles/work/message", "gateway-key", false).Code, "work has its own key")
+	assert.Equal(t, http.StatusOK, do("work", "/profiles/work/message", "work-key", false).Code)
+	assert.Equal(t, http.StatusNotFound, do("work", "/profiles/home/message", "gateway-key", false).Code, "only the tunnel's profile is reachable")
+	assert.Equal(t, http.StatusNotFound, do("work", "/api/status", "work-key", false).Code)
+}
+
+func TestUserScopes(t *testing.T) {
+	dir := t.TempDir()
+	store := profile.NewStore(filepath.Join(dir, "profiles.yaml"), filepath.Join(dir, "settings.yaml"))
+	pm := NewProfileManager(nil, t.TempDir(), t.TempDir(), ".")
+	pm.SetCredentialManager(integration.NewMemoryCredentialManager())
+	pm.AddProfile(profile.Profile{ID: "owner"})
+	settings := profile.DefaultSettings()
+	settings.ControlTokens = []profile.ControlToken{
+		{Name: "owner", Token: "owner-token", Role: profile.RoleAdmin},
+		{Name: "alice", Token: "alice-token", Role: profile.RoleAdmin, User: "alice"},
+	}
+	provider := profile.NewSettingsProvider(settings)
+	srv := NewControlServer(store, pm, provider, false)
+	gateway := NewMcpGateway(pm, provider)
+	srv.SetGateway(gateway)
+	users := NewUsers(filepath.Join(dir, "users"), pm, store, provider)
+	defer users.Close()
+	srv.SetUsers(users)
+	gateway.SetUsers(users)
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := newRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("POST", "/api/profiles", "alice-token", `{"id":"work"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created profile.Profile
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.APIKey, "users' profiles get a key")
+	assert.FileExists(t, filepath.Join(dir, "users", "alice", "profiles.yaml"))
+	assert.NoFileExists(t, filepath.Join(dir, "profiles.yaml"))
+
+	w = do("GET", "/api/profiles", "alice-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"work"`)
+	assert.NotContains(t, w.Body.String(), `"owner"`)
+	w = do("GET", "/api/profiles", "owner-token", "")
+	assert.Contains(t, w.Body.String(), `"owner"`)
+	assert.NotContains(t, w.Body.String(), `"work"`)
+	_, ok := pm.GetEngine("work")
+	assert.False(t, ok, "engines are partitioned")
+
+	// The daemon's settings, logs and tokens are the owner's
+	assert.Equal(t, http.StatusForbidden, do("GET", "/api/settings", "alice-token", "").Code)
+	assert.Equal(t, http.StatusForbidden, do("GET", "/api/logs", "alice-token", "").Code)
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/control-tokens", "alice-token", `{"name":"x","role":"admin"}`).Code)
+	assert.Equal(t, http.StatusOK, do("GET", "/api/settings", "owner-token", "").Code)
+
+	// Probes run commands and write the registry, reloads rebuild the
+	// owner's index: none are for users
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/probe", "alice-token", `{"command":"true","save":true}`).Code)
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/tools/verify", "alice-token", `{"tool_name":"x"}`).Code)
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/reload", "alice-token", "").Code)
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/tools/refresh", "alice-token", "").Code)
+
+	// Tool parameters are kept per user
+	w = do("PUT", "/api/tool-params", "alice-token", `{"tool_name":"github","parameters":{"q":"alice"}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.FileExists(t, filepath.Join(dir, "users", "alice", "tool-params.json"))
+	assert.NoFileExists(t, filepath.Join(dir, "tool-params.json"))
+
+	// Hooks would run commands as the owner, so users can't set them
+	hook := `"hooks":[{"events":["profile.started"],"command":"touch"}]`
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/profiles", "alice-token", `{"id":"hooked",`+hook+`}`).Code)
+	assert.Equal(t, http.StatusForbidden, do("PUT", "/api/profiles", "alice-token", `{"profile":{"id":"work",`+hook+`}}`).Code)
+	alice, err := users.scope("alice")
+	require.NoError(t, err)
+	assert.Nil(t, alice.manager.Hooks())
+	p, _ := alice.manager.GetProfile("work")
+	assert.Empty(t, p.Hooks)
+
+	// So would an env: it reaches every server process
+	env := `"env":{"LD_PRELOAD":"/tmp/evil.so"}`
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/profiles", "alice-token", `{"id":"preloaded",`+env+`}`).Code)
+	assert.Equal(t, http.StatusForbidden, do("PUT", "/api/profiles", "alice-token", `{"profile":{"id":"work",`+env+`}}`).Code)
+	p, _ = alice.manager.GetProfile("work")
+	assert.Empty(t, p.Env)
+
+	// Every user is on loopback, so it no longer stands for the owner
+	req := newRequest("GET", "/api/settings", nil)
+	req.RemoteAddr = "127.0.0.1:50000"
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Credentials go to the user's keychain namespace
+	w = do("POST", "/api/credentials", "alice-token", `{"tool_name":"github","env_var":"GITHUB_TOKEN","value":"alice-gh"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	_, err = pm.Credentials().GetCredential("github", "GITHUB_TOKEN")
+	assert.Error(t, err, "the owner doesn't see users' credentials")
+	secret, _ := alice.manager.Credentials().GetCredential("github", "GITHUB_TOKEN")
+	assert.Equal(t, "alice-gh", secret)
+
+	// MCP clients reach the user's profiles under /users/<name>/ with its key
+	gw := func(path, key string) int {
+		req := newRequest("POST", path, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+		req.Header.Set("X-Scooter-Internal", "true")
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, gw("/users/alice/profiles/work/message", ""))
+	assert.Equal(t, http.StatusOK, gw("/users/alice/profiles/work/message", created.APIKey))
+	assert.Equal(t, http.StatusNotFound, gw("/users/bob/profiles/work/message", created.APIKey))
+}
+
+func TestOAuthFlow(t *testing.T) {
+	var grants []string
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		grants = append(grants, r.Form.Get("grant_type"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			if r.Form.Get("code") != "the-code" || r.Form.Get("code_verifier") == "" {
+				http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+				return
+			}
+			// Expires within the refresh margin, so the next activation refreshes it
+			fmt.Fprint(w, `{"access_token":"first","refresh_token":"refresh-1","token_type":"bearer","expires_in":30}`)
+		case "refresh_token":
+			fmt.Fprint(w, `{"access_token":"second","token_type":"bearer","expires_in":3600}`)
+		}
+	}))
+	defer provider.Close()
+
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.SetCredentialManager(integration.NewMemoryCredentialManager())
+	auth := &registry.Authorization{Type: registry.AuthOAuth2, OAuth: &registry.OAuthConfig{
+		AuthorizationURL: provider.URL + "/authorize",
+		TokenURL:         provider.URL + "/token",
+		Scopes:           []string{"read"},
+		ClientIDEnv:      "DRIVE_CLIENT_ID",
+		TokenEnv:         "DRIVE_TOKEN",
+	}}
+	pm.customTools = append(pm.customTools, discovery.ToolDefinition{Name: "drive", Authorization: auth})
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(profile.DefaultSettings()), false)
+
+	req := newRequest("POST", "/api/credentials/oauth/start", strings.NewReader(`{"tool_name":"drive","client_id":"client-1"}`))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var started struct {
+		AuthURL string `json:"auth_url"`
+		State   string `json:"state"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &started))
+	assert.Contains(t, started.AuthURL, provider.URL+"/authorize?")
+	assert.Contains(t, started.AuthURL, "code_challenge_method=S256")
+	assert.Contains(t, started.AuthURL, "state="+started.State)
+
+	callback := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newRequest("GET", "/api/credentials/oauth/callback?"+query, nil))
+		return w
+	}
+	assert.Equal(t, http.StatusBadRequest, callback("state=forged&code=the-code").Code)
+	w = callback("state=" + started.State + "&code=the-code")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusBadRequest, callback("state="+started.State+"&code=the-code").Code, "states are single-use")
+
+	creds := pm.Credentials()
+	token, _ := creds.GetCredential("drive", "DRIVE_TOKEN")
+	assert.Equal(t, "first", token)
+	clientID, _ := creds.OAuthClient("drive", auth.OAuth)
+	assert.Equal(t, "client-1", clientID)
+
+	// The token is refreshed before it is handed to a worker
+	env, err := creds.GetCredentialsForTool("drive", auth)
+	require.NoError(t, err)
+	assert.Equal(t, "second", env["DRIVE_TOKEN"])
+	assert.Equal(t, []string{"authorization_code", "refresh_token"}, grants)
+	env, err = creds.GetCredentialsForTool("drive", auth)
+	require.NoError(t, err)
+	assert.Equal(t, "second", env["DRIVE_TOKEN"])
+	assert.Len(t, grants, 2, "a fresh token isn't refreshed")
+}
+
+func TestCollectArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	wasmDir := filepath.Join(dir, "wasm")
+	pm := NewProfileManager(nil, wasmDir, t.TempDir(), ".")
+	pm.SetHomeRoot(filepath.Join(dir, "homes"))
+	pm.SetWorkspaceRoot(filepath.Join(dir, "workspaces"))
+	pm.AddProfile(profile.Profile{ID: "work"})
+	pm.customTools = append(pm.customTools, discovery.ToolDefinition{Name: "known"})
+
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	write := func(rel string, age time.Time) {
+		path := filepath.Join(dir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("0123456789"), 0644))
+		require.NoError(t, os.Chtimes(path, age, age))
+	}
+	write("wasm/known.wasm", old)
+	write("wasm/removed.wasm", old)
+	write("wasm/fresh.wasm", time.Now())
+	write("wasm/notes.txt", old)
+	write("homes/gone/srv/.config", old)
+	write("homes/work/crashed/.config", old)
+	write("workspaces/gone/out.txt", old)
+	write("workspaces/work/out.txt", old)
+
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(profile.DefaultSettings()), false)
+	gc := func(query string) discovery.GCReport {
+		req := newRequest("POST", "/api/gc"+query, nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var report discovery.GCReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return report
+	}
+	paths := func(report discovery.GCReport) []string {
+		out := []string{}
+		for _, a := range report.Removed {
+			rel, _ := filepath.Rel(dir, a.Path)
+			out = append(out, filepath.ToSlash(rel))
+		}
+		return out
+	}
+
+	report := gc("?dry_run=true")
+	assert.True(t, report.DryRun)
+	assert.ElementsMatch(t, []string{"wasm/removed.wasm", "homes/gone", "homes/work/crashed", "workspaces/gone"}, paths(report))
+	assert.Equal(t, int64(40), report.ReclaimedBytes)
+	assert.FileExists(t, filepath.Join(wasmDir, "removed.wasm"), "a dry run removes nothing")
+
+	report = gc("")
+	assert.False(t, report.DryRun)
+	assert.Len(t, report.Removed, 4)
+	assert.NoFileExists(t, filepath.Join(wasmDir, "removed.wasm"))
+	assert.NoDirExists(t, filepath.Join(dir, "homes", "gone"))
+	assert.NoDirExists(t, filepath.Join(dir, "workspaces", "gone"))
+	assert.FileExists(t, filepath.Join(wasmDir, "known.wasm"))
+	assert.FileExists(t, filepath.Join(wasmDir, "notes.txt"))
+	assert.FileExists(t, filepath.Join(dir, "workspaces", "work", "out.txt"))
+
+	report = gc("?retention_hours=0")
+	assert.Equal(t, []string{"wasm/fresh.wasm"}, paths(report), "no retention removes recent orphans too")
+
+	req := newRequest("POST", "/api/gc?retention_hours=-1", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCustomToolRegistration(t *testing.T) {
+	registryDir := t.TempDir()
+	pm := NewProfileManager(nil, t.TempDir(), registryDir, ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(profile.DefaultSettings()), false)
+
+	do := func(method, path, ifMatch, body string) *httptest.ResponseRecorder {
+		req := newRequest(method, path, strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	entry := func(description string) string {
+		return fmt.Sprintf(`{"name":"notes","version":"1.0.0","title":"Notes","description":%q,
+			"category":"productivity","source":"local","authorization":{"type":"none"},
+			"tools":[{"name":"add_note","description":"Adds a note to the notebook","inputSchema":{"type":"object"}}],
+			"runtime":{"transport":"stdio","command":"notes-mcp"}}`, description)
+	}
+
+	w := do("POST", "/api/tools", "", `{"name":"Bad Name","tools":[]}`)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var invalid struct {
+		Errors []registry.ValidationError `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &invalid))
+	fields := []string{}
+	for _, e := range invalid.Errors {
+		fields = append(fields, e.Field)
+	}
+	assert.Contains(t, fields, "version")
+	assert.Contains(t, fields, "tools")
+	assert.NoFileExists(t, discovery.CustomEntryPath(registryDir, "", "Bad Name"))
+
+	w = do("POST", "/api/tools", "", entry("Keeps notes for later"))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	created := w.Header().Get("ETag")
+	require.NotEmpty(t, created)
+	assert.FileExists(t, discovery.CustomEntryPath(registryDir, "", "notes"))
+	engine, _ := pm.GetEngine("work")
+	require.Len(t, engine.Find("notes"), 1, "engines are reloaded")
+
+	w = do("GET", "/api/tools/notes/entry", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, created, w.Header().Get("ETag"))
+
+	w = do("PUT", "/api/tools/notes", created, entry("Keeps notes for much later"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	updated := w.Header().Get("ETag")
+	assert.NotEqual(t, created, updated)
+	assert.Equal(t, "Keeps notes for much later", engine.Find("notes")[0].Description)
+
+	// A second writer still holding the first version is refused
+	w = do("PUT", "/api/tools/notes", created, entry("Keeps notes for another day"))
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	w = do("DELETE", "/api/tools?name=notes", created, "")
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = do("PUT", "/api/tools/other", "", entry("Keeps notes for later"))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "the body must name the same entry")
+
+	w = do("DELETE", "/api/tools?name=notes", updated, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.NoFileExists(t, discovery.CustomEntryPath(registryDir, "", "notes"))
+	assert.Empty(t, engine.Find("notes"))
+}
+
+func TestProfileRegistryOverlay(t *testing.T) {
+	registryDir := t.TempDir()
+	pm := NewProfileManager(nil, t.TempDir(), registryDir, ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	pm.AddProfile(profile.Profile{ID: "lab"})
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(profile.DefaultSettings()), false)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+	entry := func(description string) string {
+		return fmt.Sprintf(`{"name":"scratch","version":"1.0.0","title":"Scratch","description":%q,
+			"category":"development","source":"local","authorization":{"type":"none"},
+			"tools":[{"name":"try_it","description":"Tries an experiment out","inputSchema":{"type":"object"}}],
+			"runtime":{"transport":"stdio","command":"scratch-mcp"}}`, description)
+	}
+	work, _ := pm.GetEngine("work")
+	lab, _ := pm.GetEngine("lab")
+	scratch := func(engine *discovery.DiscoveryEngine) *discovery.ToolDefinition {
+		for _, td := range engine.Find("scratch") {
+			if td.Name == "scratch" {
+				return &td
+			}
+		}
+		return nil
+	}
+
+	w := do("POST", "/api/tools?profile=lab", entry("Only the lab sees this"))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.FileExists(t, discovery.CustomEntryPath(registryDir, "lab", "scratch"))
+	require.NotNil(t, scratch(lab))
+	assert.Equal(t, "lab", scratch(lab).Overlay)
+	assert.Nil(t, scratch(work), "other profiles don't see the overlay")
+
+	// The overlay wins over a shared entry of the same name, for its profile only
+	w = do("POST", "/api/tools", entry("Everyone sees this"))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "Only the lab sees this", scratch(lab).Description)
+	assert.Equal(t, "Everyone sees this", scratch(work).Description)
+
+	var listed struct {
+		Tools []discovery.ToolDefinition `json:"tools"`
+	}
+	w = do("GET", "/api/tools?profile=lab", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	for _, td := range listed.Tools {
+		if td.Name == "scratch" {
+			assert.Equal(t, "lab", td.Overlay)
+		}
+	}
+
+	w = do("GET", "/api/tools/scratch/entry?profile=lab", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Only the lab sees this")
+	assert.Equal(t, http.StatusNotFound, do("POST", "/api/tools?profile=gone", entry("x")).Code)
+
+	w = do("DELETE", "/api/tools?name=scratch&profile=lab", "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "Everyone sees this", scratch(lab).Description)
+	assert.FileExists(t, discovery.CustomEntryPath(registryDir, "", "scratch"))
+}
+
+func TestRegistryOverrides(t *testing.T) {
+	registryDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(registryDir, "official"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(registryDir, "official", "files.json"), []byte(`{
+		"name":"files","version":"1.0.0","title":"Files","description":"Reads and writes local files",
+		"category":"utility","source":"official","authorization":{"type":"none"},
+		"tools":[{"name":"read_file","description":"Reads a file from disk","inputSchema":{"type":"object"}}],
+		"package":{"type":"npm","name":"files-mcp"},
+		"runtime":{"transport":"stdio","command":"npx","args":["-y","files-mcp"]}}`), 0644))
+
+	pm := NewProfileManager(nil, t.TempDir(), registryDir, ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(profile.DefaultSettings()), false)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+	engine, _ := pm.GetEngine("work")
+	args := func() []string {
+		for _, td := range engine.Find("files") {
+			if td.Name == "files" {
+				return td.Runtime.Args
+			}
+		}
+		return nil
+	}
+	require.Equal(t, []string{"-y", "files-mcp"}, args())
+
+	w := do("PUT", "/api/tools/files/override", `{"runtime":{"args":["-y","files-mcp","--read-only"]}}`)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.Equal(t, []string{"-y", "files-mcp", "--read-only"}, args())
+
+	var layers discovery.DefinitionLayers
+	w = do("GET", "/api/tools/files/definition", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &layers))
+	assert.Equal(t, []string{"-y", "files-mcp"}, layers.Base.Runtime.Args)
+	assert.Equal(t, []string{"-y", "files-mcp", "--read-only"}, layers.Effective.Runtime.Args)
+	assert.True(t, layers.Effective.Overridden)
+	assert.Equal(t, "npx", layers.Effective.Runtime.Command, "unpatched fields are kept")
+
+	// The official file can change upstream without losing the override
+	entry, err := os.ReadFile(filepath.Join(registryDir, "official", "files.json"))
+	require.NoError(t, err)
+	entry = bytes.Replace(entry, []byte(`"npx"`), []byte(`"bunx"`), 1)
+	require.NoError(t, os.WriteFile(filepath.Join(registryDir, "official", "files.json"), entry, 0644))
+	pm.ReloadRegistries()
+	w = do("GET", "/api/tools/files/definition", "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &layers))
+	assert.Equal(t, "bunx", layers.Effective.Runtime.Command)
+	assert.Equal(t, []string{"-y", "files-mcp", "--read-only"}, layers.Effective.Runtime.Args)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do("PUT", "/api/tools/files/override", `{"name":"other"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do("PUT", "/api/tools/files/override", `{"tools":null}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do("PUT", "/api/tools/files/override", `[1]`).Code)
+
+	w = do("DELETE", "/api/tools/files/override", "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, []string{"-y", "files-mcp"}, args())
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/tools/missing/definition", "").Code)
+}
+
+func TestRegistrySearch(t *testing.T) {
+	registryDir := t.TempDir()
+	write := func(path, name, category, description string) {
+		full := filepath.Join(registryDir, path, name+".json")
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(fmt.Sprintf(`{"name":%q,"version":"1.0.0","title":%q,
+			"description":%q,"category":%q,"source":"local","authorization":{"type":"none"},
+			"tools":[{"name":"run","description":"Runs the thing","inputSchema":{"type":"object"}}],
+			"runtime":{"transport":"stdio","command":"x"}}`, name, name, description, category)), 0644))
+	}
+	write("official", "postgres", "database", "Query Postgres databases")
+	write("official", "github", "development", "Work with GitHub repositories")
+	write("custom/lab", "pg-experiment", "database", "An experimental Postgres server")
+
+	pm := NewProfileManager(nil, t.TempDir(), registryDir, ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	pm.AddProfile(profile.Profile{ID: "lab"})
+	srv := NewControlServer(nil, pm, profile.NewSettingsProvider(profile.DefaultSettings()), false)
+
+	search := func(query string) []string {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newRequest("GET", "/api/registry?"+query, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var entries []registry.MCPEntry
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+		names := []string{}
+		for _, e := range entries {
+			names = append(names, e.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"github", "postgres"}, search(""), "builtins aren't listed")
+	assert.Equal(t, []string{"postgres"}, search("q=postgres"))
+	assert.Equal(t, []string{"postgres", "pg-experiment"}, search("q=postgres&profile=lab"))
+	assert.Equal(t, []string{"github"}, search("category=development"))
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newRequest("GET", "/api/registry?profile=gone", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, newRequest("POST", "/api/tools/deactivate", strings.NewReader(`{"profile":"work","server":"postgres"}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code, "the server isn't active")
+}
+
+func TestProfileBundle(t *testing.T) {
+	// machine returns a control server with its own configuration and registry
+	machine := func(profiles []profile.Profile, key string) (*ControlServer, *profile.Store, string) {
+		dir, registryDir := t.TempDir(), t.TempDir()
+		store := profile.NewStore(filepath.Join(dir, "profiles.yaml"), filepath.Join(dir, "settings.yaml"))
+		settings := profile.DefaultSettings()
+		settings.GatewayAPIKey = key
+		settings.AccessGroups = []profile.AccessGroup{{Name: "ci", APIKey: key + "-ci"}}
+		require.NoError(t, store.Save(profiles, settings))
+		pm := NewProfileManager(profiles, t.TempDir(), registryDir, ".")
+		return NewControlServer(store, pm, profile.NewSettingsProvider(settings), false), store, registryDir
+	}
+
+	src, srcStore, srcRegistry := machine([]profile.Profile{
+		{ID: "work", APIKey: "work-key", Env: map[string]string{"GITHUB_TOKEN": "ghp_x"}},
+		{ID: "personal", APIKey: "personal-key", Env: map[string]string{"NOTES_DIR": "/notes", "EXTRA": "x"}},
+	}, "source-key")
+	src.settings.Update(func(s *profile.Settings) { s.EnableHooks = true })
+	require.NoError(t, srcStore.SaveSettings(src.settings.Get()))
+	require.NoError(t, os.MkdirAll(filepath.Join(srcRegistry, "custom", "work"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcRegistry, "custom", "work", "scratch.json"), []byte(`{"name":"scratch","version":"1.0.0","title":"Scratch","description":"Scratch server for experiments",
+		"category":"development","source":"local","authorization":{"type":"none"},
+		"tools":[{"name":"try_it","description":"Tries an experiment out","inputSchema":{"type":"object"}}],
+		"runtime":{"transport":"stdio","command":"scratch-mcp"}}`), 0644))
+	require.NoError(t, srcStore.SaveToolParams(map[string]map[string]interface{}{"try_it": {"n": 1.0}}))
+
+	w := httptest.NewRecorder()
+	src.ServeHTTP(w, newRequest("GET", "/api/profiles/export", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	archive := w.Body.Bytes()
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		for _, secret := range []string{"source-key", "work-key", "ghp_x", "personal-key", "/notes"} {
+			assert.NotContains(t, string(data), secret, "%s leaks a secret", f.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{"manifest.json", "profiles.yaml", "settings.yaml", "tool-params.json", "registry/custom/work/scratch.json"}, names)
+
+	dst, dstStore, dstRegistry := machine([]profile.Profile{{ID: "personal", APIKey: "mine", Env: map[string]string{"NOTES_DIR": "/home/notes"}}}, "target-key")
+	w = httptest.NewRecorder()
+	dst.ServeHTTP(w, newRequest("POST", "/api/profiles/import", bytes.NewReader(archive)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result ImportResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, []string{"work", "personal"}, result.Profiles)
+	assert.Equal(t, 1, result.CustomEntries)
+	assert.Equal(t, []string{"work"}, result.Reload.Added)
+
+	profiles, settings, err := dstStore.Load()
+	require.NoError(t, err)
+	require.Len(t, profiles, 2, "existing profiles are kept")
+	assert.Equal(t, "personal", profiles[0].ID)
+	assert.Equal(t, "mine", profiles[0].APIKey, "a replaced profile keeps its secrets")
+	assert.Equal(t, map[string]string{"NOTES_DIR": "/home/notes"}, profiles[0].Env)
+	assert.Equal(t, "work", profiles[1].ID)
+	assert.NotEmpty(t, profiles[1].APIKey)
+	assert.NotEqual(t, bundleSecret, profiles[1].APIKey, "new profiles get a new key")
+	assert.Empty(t, profiles[1].Env, "secrets left out of the bundle aren't set")
+	assert.False(t, settings.EnableHooks, "an import doesn't turn hooks on")
+	assert.Equal(t, "target-key", settings.GatewayAPIKey, "the machine keeps its secrets")
+	assert.Equal(t, "target-key-ci", settings.AccessGroups[0].APIKey)
+	params, err := dstStore.LoadToolParams()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, params["try_it"]["n"])
+	assert.FileExists(t, filepath.Join(dstRegistry, "custom", "work", "scratch.json"))
+
+	work, ok := dst.manager.GetEngine("work")
+	require.True(t, ok)
+	found := false
+	for _, td := range work.Find("scratch") {
+		found = found || td.Name == "scratch"
+	}
+	assert.True(t, found, "the overlay entry is loaded")
+
+	// Bundles with unknown files are rejected before anything is written
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create("../profiles.yaml")
+	f.Write([]byte("profiles: []"))
+	zw.Close()
+	w = httptest.NewRecorder()
+	dst.ServeHTTP(w, newRequest("POST", "/api/profiles/import", &buf))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	// bundleOf zips files in order
+	bundleOf := func(files ...string) *bytes.Buffer {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for i := 0; i < len(files); i += 2 {
+			f, _ := zw.Create(files[i])
+			f.Write([]byte(files[i+1]))
+		}
+		zw.Close()
+		return &buf
+	}
+
+	// A failing step undoes the files written before it
+	w = httptest.NewRecorder()
+	dst.ServeHTTP(w, newRequest("POST", "/api/profiles/import", bundleOf(
+		"registry/custom/probe.json", `{"name":"probe","version":"1.0.0","title":"Probe","description":"Probe server for experiments",
+			"category":"development","source":"local","authorization":{"type":"none"},
+			"tools":[{"name":"probe_it","description":"Probes something out","inputSchema":{"type":"object"}}],
+			"runtime":{"transport":"stdio","command":"probe-mcp"}}`,
+		"registry/overrides/probe.json", `{"name":"renamed"}`,
+	)))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NoFileExists(t, filepath.Join(dstRegistry, "custom", "probe.json"))
+	assert.NoFileExists(t, filepath.Join(dstRegistry, "overrides", "probe.json"))
+
+	// Files are not truncated to fit: a bundle expanding past the limit is refused
+	w = httptest.NewRecorder()
+	dst.ServeHTTP(w, newRequest("POST", "/api/profiles/import", bundleOf(
+		"tool-params.json", `{"big":{"s":"`+strings.Repeat("x", maxBundleBytes)+`"}}`,
+	)))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "exceed")
+	params, err = dstStore.LoadToolParams()
+	require.NoError(t, err)
+	assert.NotContains(t, params, "big")
+}
+
+func TestToolPresets(t *testing.T) {
+	server := func() *ControlServer {
+		dir := t.TempDir()
+		store := profile.NewStore(filepath.Join(dir, "profiles.yaml"), filepath.Join(dir, "settings.yaml"))
+		pm := NewProfileManager(nil, ".", ".", ".")
+		pm.AddProfile(profile.Profile{ID: "work"})
+		return NewControlServer(store, pm, profile.NewSettingsProvider(profile.DefaultSettings()), false)
+	}
+	do := func(srv *ControlServer, method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	alice := server()
+	require.Equal(t, http.StatusNoContent, do(alice, "PUT", "/api/tool-presets/search/rust", `{"query":"rust","count":5}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(alice, "PUT", "/api/tool-presets/search/bad%20name", `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(alice, "PUT", "/api/tool-presets/search/list", `[1]`).Code)
+
+	args, err := alice.presetArguments("search", "rust", map[string]interface{}{"count": 1})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"query": "rust", "count": 1}, args, "call arguments override the preset")
+
+	w := do(alice, "POST", "/api/tools/call", `{"profile":"work","server":"web","tool":"search","preset":"missing"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "no such preset")
+
+	w = do(alice, "GET", "/api/tool-presets/export", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	exported := w.Body.String()
+
+	bob := server()
+	require.Equal(t, http.StatusNoContent, do(bob, "PUT", "/api/tool-presets/search/rust", `{"query":"mine"}`).Code)
+	w = do(bob, "POST", "/api/tool-presets/import", exported)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"imported":[],"skipped":["search/rust"]}`, w.Body.String())
+
+	w = do(bob, "POST", "/api/tool-presets/import?replace=true", exported)
+	assert.JSONEq(t, `{"imported":["search/rust"],"skipped":[]}`, w.Body.String())
+	w = do(bob, "GET", "/api/tool-presets?tool=search", "")
+	assert.JSONEq(t, `{"presets":{"search":{"rust":{"query":"rust","count":5}}}}`, w.Body.String())
+
+	assert.Equal(t, http.StatusNoContent, do(bob, "DELETE", "/api/tool-presets/search/rust", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(bob, "DELETE", "/api/tool-presets/search/rust", "").Code)
+}
+
+func TestToolPresetsPerUser(t *testing.T) {
+	dir := t.TempDir()
+	store := profile.NewStore(filepath.Join(dir, "profiles.yaml"), filepath.Join(dir, "settings.yaml"))
+	pm := NewProfileManager(nil, t.TempDir(), t.TempDir(), ".")
+	pm.SetCredentialManager(integration.NewMemoryCredentialManager())
+	settings := profile.DefaultSettings()
+	settings.ControlTokens = []profile.ControlToken{
+		{Name: "owner", Token: "owner-token", Role: profile.RoleAdmin},
+		{Name: "alice", Token: "alice-token", Role: profile.RoleOperator, User: "alice"},
+		{Name: "bob", Token: "bob-token", Role: profile.RoleOperator, User: "bob"},
+	}
+	provider := profile.NewSettingsProvider(settings)
+	srv := NewControlServer(store, pm, provider, false)
+	users := NewUsers(filepath.Join(dir, "users"), pm, store, provider)
+	defer users.Close()
+	srv.SetUsers(users)
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := newRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	list := func(token string) string {
+		w := do("GET", "/api/tool-presets", token, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return w.Body.String()
+	}
+
+	require.Equal(t, http.StatusNoContent, do("PUT", "/api/tool-presets/search/rust", "alice-token", `{"query":"alice"}`).Code)
+	require.Equal(t, http.StatusNoContent, do("PUT", "/api/tool-presets/search/rust", "owner-token", `{"query":"owner"}`).Code)
+	assert.FileExists(t, filepath.Join(dir, "users", "alice", "tool-presets.json"))
+	assert.JSONEq(t, `{"presets":{}}`, list("bob-token"))
+	assert.JSONEq(t, `{"presets":{"search":{"rust":{"query":"owner"}}}}`, list("owner-token"))
+
+	// Bob's import and deletes stay in his scope
+	doc := `{"format":1,"presets":{"search":{"rust":{"query":"bob"}}}}`
+	w := do("POST", "/api/tool-presets/import?replace=true", "bob-token", doc)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/api/tool-presets/search/rust", "bob-token", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/api/tool-presets/search/rust", "bob-token", "").Code)
+	assert.JSONEq(t, `{"presets":{"search":{"rust":{"query":"alice"}}}}`, list("alice-token"))
+	assert.JSONEq(t, `{"presets":{"search":{"rust":{"query":"owner"}}}}`, list("owner-token"))
+}
+
+func TestInternalToken(t *testing.T) {
+	dir := t.TempDir()
+	token, err := WriteInternalToken(dir)
+	require.NoError(t, err)
+	info, err := os.Stat(filepath.Join(dir, InternalTokenFile))
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+	again, err := WriteInternalToken(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, token, again, "each run has its own token")
+
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work", APIKey: "work-key"})
+	gw := NewMcpGateway(pm, profile.NewSettingsProvider(profile.DefaultSettings()))
+	gw.SetInternalToken(again)
+
+	post := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := newRequest("POST", "/profiles/work/message", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusOK, post(map[string]string{InternalTokenHeader: again}).Code)
+	assert.Equal(t, http.StatusUnauthorized, post(nil).Code)
+	assert.Equal(t, http.StatusOK, post(map[string]string{"Authorization": "Bearer work-key"}).Code)
+
+	// Spoofed internal requests are refused even with a valid key
+	w := post(map[string]string{InternalTokenHeader: token, "Authorization": "Bearer work-key"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "wrong internal token")
+	w = post(map[string]string{"X-Scooter-Internal": "true"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "no longer accepted")
+	assert.Equal(t, 3, gw.DisconnectCounts()["auth_failed"])
+}
+
+func TestCORSPolicy(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	settings := profile.NewSettingsProvider(profile.DefaultSettings())
+	srv := NewControlServer(nil, pm, settings, false)
+	gw := NewMcpGateway(pm, settings)
+
+	send := func(h http.Handler, method, path, origin string) *httptest.ResponseRecorder {
+		req := newRequest(method, path, nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if method == "OPTIONS" {
+			req.Header.Set("Access-Control-Request-Method", "POST")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// The desktop app and local pages are allowed by default
+	for _, origin := range []string{"tauri://localhost", "http://tauri.localhost", "http://localhost:1420"} {
+		w := send(srv, "GET", "/api/settings", origin)
+		assert.Equal(t, http.StatusOK, w.Code, origin)
+		assert.Equal(t, origin, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+	w := send(srv, "OPTIONS", "/api/settings", "http://localhost:1420")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), InternalTokenHeader)
+
+	// Any other website is refused, on both servers
+	w = send(srv, "GET", "/api/settings", "https://evil.example")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, http.StatusForbidden, send(srv, "OPTIONS", "/api/settings", "https://evil.example").Code)
+	assert.Equal(t, http.StatusForbidden, send(gw, "POST", "/profiles/work/message", "https://evil.example").Code)
+	assert.Equal(t, http.StatusForbidden, send(srv, "GET", "/api/settings", "http://localhost.evil.example:80").Code)
+
+	// Clients that aren't browsers send no Origin
+	assert.Equal(t, http.StatusOK, send(srv, "GET", "/api/settings", "").Code)
+
+	settings.Update(func(s *profile.Settings) {
+		s.CORS = profile.CORSSettings{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true, MaxAgeSeconds: 60}
+	})
+	w = send(gw, "OPTIONS", "/profiles/work/sse", "https://APP.example.com")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "60", w.Header().Get("Access-Control-Max-Age"))
+	assert.Equal(t, http.StatusForbidden, send(srv, "GET", "/api/settings", "http://localhost:1420").Code)
+
+	// Same-origin requests pass only on a loopback name; a DNS-rebinding
+	// page sends a Host matching its Origin
+	sameHost := func(host string) int {
+		req := newRequest("GET", "http://"+host+"/api/settings", nil)
+		req.Header.Set("Origin", "http://"+host)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, sameHost("127.0.0.1:6200"))
+	assert.Equal(t, http.StatusOK, sameHost("localhost:6200"))
+	assert.Equal(t, http.StatusForbidden, sameHost("evil.example:6200"))
+
+	// Its requests without an Origin are refused by their Host, on both
+	// servers
+	rebound := func(h http.Handler, method, path string) int {
+		req := newRequest(method, path, nil)
+		req.Host = "evil.example"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusForbidden, rebound(srv, "GET", "/api/profiles"))
+	assert.Equal(t, http.StatusForbidden, rebound(srv, "GET", "/api/logs"))
+	assert.Equal(t, http.StatusForbidden, rebound(gw, "POST", "/profiles/work/message"))
+
+	// Other machines reach the servers by address, or by the names in the
+	// settings and profiles
+	forHost := func(host string) int {
+		req := newRequest("GET", "/api/status", nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, forHost("192.168.1.20:6200"))
+	assert.Equal(t, http.StatusOK, forHost("[fe80::1]:6200"))
+	assert.Equal(t, http.StatusForbidden, forHost("scooter-host:6200"))
+	assert.Equal(t, http.StatusForbidden, forHost("WORK.internal"))
+	settings.Update(func(s *profile.Settings) { s.AllowedHosts = []string{"scooter-host"} })
+	pm.AddProfile(profile.Profile{ID: "remote", Hosts: []string{"work.internal"}})
+	assert.Equal(t, http.StatusOK, forHost("scooter-host:6200"))
+	assert.Equal(t, http.StatusOK, forHost("WORK.internal"))
+
+	// The settings API refuses a policy browsers would reject
+	body := `{"control_port":6200,"cors":{"allowed_origins":["*"],"allow_credentials":true}}`
+	req := newRequest("PUT", "/api/settings", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "allow_credentials")
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	settings := profile.NewSettingsProvider(profile.DefaultSettings())
+	gw := NewMcpGateway(pm, settings)
+	srv := NewControlServer(nil, pm, settings, false)
+	srv.SetGateway(gw)
+
+	before := metrics.GatewayRequests.Value("ping")
+	req := newRequest("POST", "/profiles/work/message", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	gw.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, before+1, metrics.GatewayRequests.Value("ping"))
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, metrics.ContentType, w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, `scooter_gateway_requests_total{method="ping"}`)
+	assert.Contains(t, body, "# TYPE scooter_tool_call_duration_seconds histogram")
+	assert.Contains(t, body, `scooter_active_servers{profile="work"} 0`)
+	assert.Contains(t, body, "# TYPE scooter_sse_clients gauge")
+}
+
+func TestPanicRecovery(t *testing.T) {
+	pm := NewProfileManager(nil, ".", ".", ".")
+	pm.AddProfile(profile.Profile{ID: "work"})
+	settings := profile.NewSettingsProvider(profile.DefaultSettings())
+	srv := NewControlServer(nil, pm, settings, false)
+	gw := NewMcpGateway(pm, settings)
+	srv.mux.HandleFunc("GET /api/boom", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		panic("boom")
+	})
+	gw.mux.HandleFunc("POST /boom", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	gw.mux.HandleFunc("GET /stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: partial\n\n"))
+		panic("boom")
+	})
+	before := metrics.Panics.Value("control")
+	lastEvent := events.Default.LastID()
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newRequest("GET", "/api/boom", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"internal server error"}`, w.Body.String())
+	assert.Equal(t, before+1, metrics.Panics.Value("control"))
+
+	recorded, _ := events.Default.Since(lastEvent, 0)
+	require.NotEmpty(t, recorded)
+	ev := recorded[len(recorded)-1]
+	assert.Equal(t, events.HandlerPanicked, ev.Type)
+	assert.Equal(t, "boom", ev.Data["error"])
+	assert.Contains(t, ev.Data["stack"], "TestPanicRecovery")
+
+	req := newRequest("POST", "/boom", strings.NewReader(`{}`))
+	req.Header.Set("Authorization", "Bearer "+settings.Get().GatewayAPIKey)
+	w = httptest.NewRecorder()
+	gw.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, float64(InternalError), resp["error"].(map[string]interface{})["code"])
+
+	// A response that already started is only cut short
+	w = httptest.NewRecorder()
+	gw.ServeHTTP(w, newRequest("GET", "/stream", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "data: partial\n\n", w.Body.String())
+
+	// A response still buffered for compression hasn't started yet
+	settings.Update(func(s *profile.Settings) { s.CompressResponses = true })
+	srv.mux.HandleFunc("GET /api/half", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"partial":`))
+		panic("boom")
+	})
+	req = newRequest("GET", "/api/half", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error":"internal server error"}`, w.Body.String())
+
+	// Aborting a response on purpose is not a crash
+	srv.mux.HandleFunc("GET /api/abort", func(w http.ResponseWriter, r *http.Request) { panic(http.ErrAbortHandler) })
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		srv.ServeHTTP(httptest.NewRecorder(), newRequest("GET", "/api/abort", nil))
+	})
+}
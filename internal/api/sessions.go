@@ -0,0 +1,263 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// gatewaySession is one connected SSE client. Responses and notifications
+// for it are queued on ch; closing done ends its stream.
+type gatewaySession struct {
+	id          string
+	profileID   string
+	remoteAddr  string
+	connectedAt time.Time
+	ch          chan string
+
+	done        chan struct{}
+	closeOnce   sync.Once
+	closeCause  disconnectCause // why done was closed; read after <-done
+	closeReason string          // details of closeCause
+
+	mu            sync.Mutex
+	clientName    string
+	clientVersion string
+	lastActivity  time.Time
+	messagesIn    int
+	messagesOut   int
+	history       []callRecord // tool calls, oldest first, for scooter_history
+	transcript    *transcript  // nil unless session_transcripts is on (see transcripts.go)
+}
+
+func newGatewaySession(id, profileID, remoteAddr string) *gatewaySession {
+	now := time.Now()
+	return &gatewaySession{
+		id:           id,
+		profileID:    profileID,
+		remoteAddr:   remoteAddr,
+		connectedAt:  now,
+		lastActivity: now,
+		ch:           make(chan string, 10),
+		done:         make(chan struct{}),
+	}
+}
+
+// disconnectCause classifies why an SSE session ended, for logs and the
+// disconnect counts in GET /api/sessions.
+type disconnectCause string
+
+const (
+	causeClientClosed   disconnectCause = "client_closed" // the client closed the connection
+	causeWriteError     disconnectCause = "write_error"   // writing to the stream failed, e.g. a reset
+	causeIdle           disconnectCause = "idle_timeout"
+	causeLifetime       disconnectCause = "max_lifetime"
+	causeControlAPI     disconnectCause = "closed_by_api"
+	causeShutdown       disconnectCause = "server_shutdown"
+	causeProfileRemoved disconnectCause = "profile_removed"
+	causeAuthFailed     disconnectCause = "auth_failed" // rejected before a session started
+)
+
+// SessionInfo describes a connected client for GET /api/sessions.
+type SessionInfo struct {
+	ID            string    `json:"id"`
+	Profile       string    `json:"profile"`
+	ClientName    string    `json:"client_name,omitempty"`
+	ClientVersion string    `json:"client_version,omitempty"`
+	RemoteAddr    string    `json:"remote_addr,omitempty"`
+	ConnectedAt   time.Time `json:"connected_at"`
+	LastActivity  time.Time `json:"last_activity"`
+	MessagesIn    int       `json:"messages_in"`
+	MessagesOut   int       `json:"messages_out"`
+}
+
+// received records a message POSTed by the client.
+func (s *gatewaySession) received() {
+	s.mu.Lock()
+	s.messagesIn++
+	s.lastActivity = time.Now()
+	s.mu.Unlock()
+}
+
+// sent records a message written to the client's stream.
+func (s *gatewaySession) sent() {
+	s.mu.Lock()
+	s.messagesOut++
+	s.mu.Unlock()
+}
+
+// setClientInfo records the clientInfo the client sent in initialize.
+func (s *gatewaySession) setClientInfo(name, version string) {
+	s.mu.Lock()
+	s.clientName = name
+	s.clientVersion = version
+	s.mu.Unlock()
+}
+
+// close ends the session's stream. It is safe to call more than once; the
+// first cause wins.
+func (s *gatewaySession) close(cause disconnectCause, reason string) {
+	s.closeOnce.Do(func() {
+		s.closeCause = cause
+		s.closeReason = reason
+		close(s.done)
+	})
+}
+
+// expired returns why the session should be closed at now, or "" if it may
+// stay open. Only messages from the client count as activity; the gateway's
+// own keep-alives don't, so a client that vanished without closing the
+// connection is eventually noticed. Zero limits are disabled.
+func (s *gatewaySession) expired(now time.Time, idle, lifetime time.Duration) (disconnectCause, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lifetime > 0 && now.Sub(s.connectedAt) >= lifetime {
+		return causeLifetime, fmt.Sprintf("reached the maximum session lifetime of %s", lifetime)
+	}
+	if idle > 0 && now.Sub(s.lastActivity) >= idle {
+		return causeIdle, fmt.Sprintf("idle for %s", now.Sub(s.lastActivity).Round(time.Second))
+	}
+	return "", ""
+}
+
+// countDisconnect records why a session ended, or why a client was turned
+// away before it had one.
+func (g *McpGateway) countDisconnect(cause disconnectCause) {
+	g.disconnectsMu.Lock()
+	g.disconnects[cause]++
+	g.disconnectsMu.Unlock()
+}
+
+// DisconnectCounts returns how many sessions ended for each cause since start.
+func (g *McpGateway) DisconnectCounts() map[string]int {
+	g.disconnectsMu.Lock()
+	defer g.disconnectsMu.Unlock()
+	counts := make(map[string]int, len(g.disconnects))
+	for cause, n := range g.disconnects {
+		counts[string(cause)] = n
+	}
+	return counts
+}
+
+// Shutdown closes every SSE session so clients learn the gateway is going
+// away instead of seeing a reset.
+func (g *McpGateway) Shutdown() {
+	g.sseClientsMu.RLock()
+	defer g.sseClientsMu.RUnlock()
+	for _, sess := range g.sseSessions {
+		sess.close(causeShutdown, "the gateway is shutting down")
+	}
+}
+
+// legacyPulse reports whether the session's client is listed in clients and
+// so gets "pulse" events rather than keep-alive comments.
+func (s *gatewaySession) legacyPulse(clients []string) bool {
+	s.mu.Lock()
+	name := s.clientName
+	s.mu.Unlock()
+	if name == "" {
+		return false
+	}
+	for _, c := range clients {
+		if strings.EqualFold(c, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionLimits returns the idle timeout and maximum lifetime of SSE sessions.
+func sessionLimits(settings profile.Settings) (idle, lifetime time.Duration) {
+	return time.Duration(settings.SessionIdleMinutes) * time.Minute,
+		time.Duration(settings.SessionMaxLifetimeHours) * time.Hour
+}
+
+func (s *gatewaySession) info() SessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SessionInfo{
+		ID:            s.id,
+		Profile:       s.profileID,
+		ClientName:    s.clientName,
+		ClientVersion: s.clientVersion,
+		RemoteAddr:    s.remoteAddr,
+		ConnectedAt:   s.connectedAt,
+		LastActivity:  s.lastActivity,
+		MessagesIn:    s.messagesIn,
+		MessagesOut:   s.messagesOut,
+	}
+}
+
+// session returns the SSE session with the given ID.
+func (g *McpGateway) session(id string) (*gatewaySession, bool) {
+	g.sseClientsMu.RLock()
+	defer g.sseClientsMu.RUnlock()
+	sess, ok := g.sseSessions[id]
+	return sess, ok
+}
+
+// Sessions lists the connected SSE clients, oldest first.
+func (g *McpGateway) Sessions() []SessionInfo {
+	g.sseClientsMu.RLock()
+	sessions := make([]SessionInfo, 0, len(g.sseSessions))
+	for _, sess := range g.sseSessions {
+		sessions = append(sessions, sess.info())
+	}
+	g.sseClientsMu.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ConnectedAt.Before(sessions[j].ConnectedAt)
+	})
+	return sessions
+}
+
+// CloseSession force-disconnects a session, reporting whether it existed.
+func (g *McpGateway) CloseSession(id string) bool {
+	sess, ok := g.session(id)
+	if ok {
+		sess.close(causeControlAPI, "disconnected from the control API")
+	}
+	return ok
+}
+
+func (s *ControlServer) handleGetSessions(w http.ResponseWriter, r *http.Request) {
+	sessions := []SessionInfo{}
+	disconnects := map[string]int{}
+	if s.gateway != nil {
+		sessions = s.gateway.Sessions()
+		disconnects = s.gateway.DisconnectCounts()
+	}
+	if profileID := r.URL.Query().Get("profile"); profileID != "" {
+		filtered := sessions[:0]
+		for _, sess := range sessions {
+			if sess.Profile == profileID {
+				filtered = append(filtered, sess)
+			}
+		}
+		sessions = filtered
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessions":    sessions,
+		"total":       len(sessions),
+		"disconnects": disconnects,
+	})
+}
+
+func (s *ControlServer) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if s.gateway == nil || !s.gateway.CloseSession(id) {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	logger.AddLog("INFO", fmt.Sprintf("Session %s disconnected from the control API", id))
+	w.WriteHeader(http.StatusNoContent)
+}
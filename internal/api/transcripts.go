@@ -0,0 +1,253 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Transcripts record an SSE session's messages, when the settings'
+// session_transcripts is on at connect time. Each session keeps at most
+// maxTranscriptEntries; the transcripts of the last maxEndedTranscripts
+// sessions stay readable after they disconnect, until the daemon exits.
+const (
+	maxTranscriptEntries = 5000
+	maxEndedTranscripts  = 20
+)
+
+// TranscriptEntry is one message of a session.
+type TranscriptEntry struct {
+	Seq       int       `json:"seq"`
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"` // "in" (from the client) or "out"
+	Kind      string    `json:"kind"`      // "request", "response" or "notification"
+	Method    string    `json:"method,omitempty"`
+	// ID is the JSON-RPC id of requests and responses
+	ID json.RawMessage `json:"id,omitempty"`
+	// DurationMs is how long a response took since its request arrived
+	DurationMs *int64          `json:"duration_ms,omitempty"`
+	Message    json.RawMessage `json:"message"`
+}
+
+// Transcript is GET /api/sessions/{id}/transcript.
+type Transcript struct {
+	Session SessionInfo       `json:"session"`
+	EndedAt *time.Time        `json:"ended_at,omitempty"`
+	Dropped int               `json:"dropped,omitempty"` // Messages past maxTranscriptEntries
+	Entries []TranscriptEntry `json:"entries"`
+}
+
+// transcript is the recording of one session, guarded by its session's mu.
+type transcript struct {
+	entries []TranscriptEntry
+	dropped int
+	pending map[string]pendingRequest // request id -> when it arrived
+	endedAt *time.Time
+}
+
+type pendingRequest struct {
+	method string
+	at     time.Time
+}
+
+// transcribe records a message to or from the client, if the session is
+// being transcribed.
+func (s *gatewaySession) transcribe(direction string, message []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.transcript
+	if t == nil {
+		return
+	}
+	if len(t.entries) >= maxTranscriptEntries {
+		t.dropped++
+		return
+	}
+
+	var head struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	json.Unmarshal(message, &head)
+	if string(head.ID) == "null" {
+		head.ID = nil
+	}
+	entry := TranscriptEntry{
+		Seq:       len(t.entries) + t.dropped + 1,
+		Time:      time.Now().UTC(),
+		Direction: direction,
+		Method:    head.Method,
+		ID:        head.ID,
+		Message:   append(json.RawMessage(nil), message...),
+	}
+	switch {
+	case head.Method != "" && head.ID != nil:
+		entry.Kind = "request"
+		t.pending[direction+string(head.ID)] = pendingRequest{method: head.Method, at: entry.Time}
+	case head.Method != "":
+		entry.Kind = "notification"
+	default:
+		entry.Kind = "response"
+		// Answers the other side's request with the same id
+		other := "in"
+		if direction == "in" {
+			other = "out"
+		}
+		if req, ok := t.pending[other+string(head.ID)]; ok {
+			delete(t.pending, other+string(head.ID))
+			entry.Method = req.method
+			ms := entry.Time.Sub(req.at).Milliseconds()
+			entry.DurationMs = &ms
+		}
+	}
+	t.entries = append(t.entries, entry)
+}
+
+// transcribing reports whether the session records its messages.
+func (s *gatewaySession) transcribing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.transcript != nil
+}
+
+// startTranscript makes the session record its messages.
+func (s *gatewaySession) startTranscript() {
+	s.mu.Lock()
+	s.transcript = &transcript{pending: make(map[string]pendingRequest)}
+	s.mu.Unlock()
+}
+
+// endTranscript marks the transcript finished, reporting whether there is one.
+func (s *gatewaySession) endTranscript() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.transcript == nil {
+		return false
+	}
+	now := time.Now().UTC()
+	s.transcript.endedAt = &now
+	return true
+}
+
+// Transcript returns a copy of the session's transcript, if it has one.
+func (s *gatewaySession) Transcript() (Transcript, bool) {
+	info := s.info()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.transcript == nil {
+		return Transcript{}, false
+	}
+	return Transcript{
+		Session: info,
+		EndedAt: s.transcript.endedAt,
+		Dropped: s.transcript.dropped,
+		Entries: append([]TranscriptEntry{}, s.transcript.entries...),
+	}, true
+}
+
+// keepTranscript keeps a disconnected session's transcript readable,
+// forgetting the oldest beyond maxEndedTranscripts.
+func (g *McpGateway) keepTranscript(sess *gatewaySession) {
+	if !sess.endTranscript() {
+		return
+	}
+	g.transcriptsMu.Lock()
+	defer g.transcriptsMu.Unlock()
+	g.ended = append(g.ended, sess)
+	if n := len(g.ended); n > maxEndedTranscripts {
+		g.ended = append([]*gatewaySession(nil), g.ended[n-maxEndedTranscripts:]...)
+	}
+}
+
+// SessionTranscript returns the transcript of a connected or recently
+// disconnected session.
+func (g *McpGateway) SessionTranscript(id string) (Transcript, bool) {
+	if sess, ok := g.session(id); ok {
+		return sess.Transcript()
+	}
+	g.transcriptsMu.Lock()
+	defer g.transcriptsMu.Unlock()
+	for _, sess := range g.ended {
+		if sess.id == id {
+			return sess.Transcript()
+		}
+	}
+	return Transcript{}, false
+}
+
+// handleGetTranscript returns a session's transcript as JSON, or with
+// ?format=markdown as a document for postmortems.
+func (s *ControlServer) handleGetTranscript(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "markdown" {
+		http.Error(w, fmt.Sprintf("invalid format %q, expected json or markdown", format), http.StatusBadRequest)
+		return
+	}
+	var t Transcript
+	ok := false
+	if s.gateway != nil {
+		t, ok = s.gateway.SessionTranscript(r.PathValue("id"))
+	}
+	if !ok {
+		http.Error(w, "no transcript for this session (is session_transcripts on?)", http.StatusNotFound)
+		return
+	}
+
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte(t.Markdown()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// Markdown renders the transcript with one section per message.
+func (t Transcript) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Session %s\n\n", t.Session.ID)
+	fmt.Fprintf(&b, "- Profile: %s\n", t.Session.Profile)
+	if t.Session.ClientName != "" {
+		fmt.Fprintf(&b, "- Client: %s %s\n", t.Session.ClientName, t.Session.ClientVersion)
+	}
+	fmt.Fprintf(&b, "- Connected: %s\n", t.Session.ConnectedAt.UTC().Format(time.RFC3339))
+	if t.EndedAt != nil {
+		fmt.Fprintf(&b, "- Ended: %s (%s)\n", t.EndedAt.Format(time.RFC3339), t.EndedAt.Sub(t.Session.ConnectedAt).Round(time.Second))
+	}
+	fmt.Fprintf(&b, "- Messages: %d", len(t.Entries))
+	if t.Dropped > 0 {
+		fmt.Fprintf(&b, " (%d more not recorded)", t.Dropped)
+	}
+	b.WriteString("\n")
+
+	for _, e := range t.Entries {
+		arrow := "client → scooter"
+		if e.Direction == "out" {
+			arrow = "scooter → client"
+		}
+		title := e.Kind
+		if e.Method != "" {
+			title += " `" + e.Method + "`"
+		}
+		if e.ID != nil {
+			title += " #" + string(e.ID)
+		}
+		fmt.Fprintf(&b, "\n## %d. %s, %s\n\n", e.Seq, title, arrow)
+		fmt.Fprintf(&b, "%s", e.Time.Format("15:04:05.000"))
+		if e.DurationMs != nil {
+			fmt.Fprintf(&b, ", took %d ms", *e.DurationMs)
+		}
+		b.WriteString("\n\n```json\n")
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, e.Message, "", "  ") == nil {
+			b.Write(pretty.Bytes())
+		} else {
+			b.Write(e.Message)
+		}
+		b.WriteString("\n```\n")
+	}
+	return b.String()
+}
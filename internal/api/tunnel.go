@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+type publicBaseContextKey struct{}
+
+// publicBase returns the scheme and host clients reach the gateway at when
+// it isn't the local port, e.g. through a tunnel relay.
+func publicBase(ctx context.Context) (string, bool) {
+	base, ok := ctx.Value(publicBaseContextKey{}).(string)
+	return base, ok
+}
+
+// TunnelHandler serves requests arriving through a tunnel relay. Only the
+// profile's endpoints are reachable, at /sse and /message or under
+// /profiles/{id}, and every request needs an API key: requests are refused
+// while neither the profile nor the gateway has one.
+func (g *McpGateway) TunnelHandler(profileID string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only Scooter Desktop on this machine may skip authentication
+		stripInternalHeaders(r)
+
+		p, ok := g.manager.GetProfile(profileID)
+		if !ok {
+			http.Error(w, "Profile not found", http.StatusNotFound)
+			return
+		}
+		if p.APIKey == "" && g.settings.Get().GatewayAPIKey == "" {
+			logger.AddLog("WARN", "[Tunnel] Refused a request: set an api_key on profile '"+profileID+"' or gateway_api_key to use the tunnel")
+			http.Error(w, "The tunnel requires an API key; none is configured", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method != http.MethodOptions && requestAPIKey(r) == "" {
+			g.rejectAuth(r, "no API key (tunnel)")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		prefix := "/profiles/" + profileID + "/"
+		switch {
+		case r.URL.Path == "/sse" || r.URL.Path == "/message":
+			r.URL.Path = strings.TrimSuffix(prefix, "/") + r.URL.Path
+		case strings.HasPrefix(r.URL.Path, prefix):
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		r.URL.RawPath = ""
+
+		// Relays only serve TLS
+		ctx := context.WithValue(r.Context(), publicBaseContextKey{}, "https://"+r.Host)
+		g.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/i18n"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// Users partitions a daemon shared by several people, e.g. one running as
+// a service on a shared workstation. A control token with a user reaches
+// only that user's scope: profiles, tool parameters and presets kept under
+// users/<name>/, a keychain namespace of its own and engines of its own.
+// Their MCP clients connect under /users/<name>/ on the gateway, with the
+// profile's API key.
+//
+// Settings, the registry and the daemon itself (logs, events, probes,
+// reloads, shutdown) stay with the owner: tokens without a user, and this
+// machine's requests without a token.
+type Users struct {
+	root     string
+	base     *ProfileManager
+	store    *profile.Store
+	settings *profile.SettingsProvider
+
+	mu     sync.Mutex
+	scopes map[string]*userScope
+}
+
+// userScope is one user's part of the daemon.
+type userScope struct {
+	store   *profile.Store
+	manager *ProfileManager
+	gateway *McpGateway
+	control *ControlServer
+}
+
+// NewUsers creates the user scopes of a daemon whose owner has base and
+// store. Users' files go in root.
+func NewUsers(root string, base *ProfileManager, store *profile.Store, settings *profile.SettingsProvider) *Users {
+	return &Users{root: root, base: base, store: store, settings: settings, scopes: map[string]*userScope{}}
+}
+
+// Dir returns the config root of a user.
+func (u *Users) Dir(user string) string {
+	return filepath.Join(u.root, user)
+}
+
+// scope returns user's scope, loading their profiles and starting their
+// engines the first time. Only users named by a control token have one.
+func (u *Users) scope(user string) (*userScope, error) {
+	if !profile.ValidUserName(user) || !u.settings.Get().HasUser(user) {
+		return nil, fmt.Errorf("no user %q", user)
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if sc, ok := u.scopes[user]; ok {
+		return sc, nil
+	}
+
+	dir := u.Dir(user)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create config root of user %q: %w", user, err)
+	}
+	var settingsPath string
+	if u.store != nil {
+		settingsPath = u.store.GetSettingsPath()
+	}
+	store := profile.NewStore(filepath.Join(dir, "profiles.yaml"), settingsPath)
+	store.SetDataDir(dir)
+	profiles, _, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profiles of user %q: %w", user, err)
+	}
+	// Users may not set an env (see rejectUserExec); drop one written to
+	// the file by hand
+	for i, p := range profiles {
+		if len(p.Env) > 0 {
+			logger.AddLog("WARN", fmt.Sprintf("[Users] Ignoring the env of profile '%s' of user '%s': only the daemon's owner may set it", p.ID, user))
+			profiles[i].Env = nil
+		}
+	}
+
+	// No hooks runner: hooks run commands as the daemon's owner (see
+	// rejectUserExec)
+	manager := u.base.forUser(user, dir)
+	manager.SyncProfiles(profiles)
+
+	sc := &userScope{store: store, manager: manager}
+	sc.gateway = NewMcpGateway(manager, u.settings)
+	sc.gateway.user = user
+	sc.control = NewControlServer(store, manager, u.settings, false)
+	sc.control.user = user
+	sc.control.SetGateway(sc.gateway)
+	u.scopes[user] = sc
+
+	logger.AddLog("INFO", fmt.Sprintf("[Users] Loaded %d profile(s) of user '%s' from %s", len(profiles), user, dir))
+	return sc, nil
+}
+
+// Close stops every user's engines.
+func (u *Users) Close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, sc := range u.scopes {
+		sc.manager.ClearProfiles()
+	}
+	u.scopes = map[string]*userScope{}
+}
+
+// forUser returns an empty manager for user that shares pm's registry,
+// installed packages and activation metrics, with workspaces and homes
+// under dir and credentials in the user's keychain namespace.
+func (pm *ProfileManager) forUser(user, dir string) *ProfileManager {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	m := &ProfileManager{
+		profiles:    []profile.Profile{},
+		engines:     make(map[string]*discovery.DiscoveryEngine),
+		wasmDir:     pm.wasmDir,
+		registryDir: pm.registryDir,
+		clientsDir:  pm.clientsDir,
+		packageDir:  pm.packageDir,
+		customTools: []discovery.ToolDefinition{},
+		index:       pm.index,
+		credentials: pm.credentials.Instance().ForUser(user),
+		metrics:     pm.metrics,
+	}
+	if pm.workspaceRoot != "" {
+		m.workspaceRoot = filepath.Join(dir, "workspaces")
+	}
+	if pm.homeRoot != "" {
+		m.homeRoot = filepath.Join(dir, "homes")
+	}
+	return m
+}
+
+// serveUser hands a gateway request under /users/<name>/ to that user's
+// gateway.
+func (g *McpGateway) serveUser(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/users/")
+	user, path, _ := strings.Cut(rest, "/")
+	if g.users == nil {
+		http.NotFound(w, r)
+		return
+	}
+	sc, err := g.users.scope(user)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	// Only Scooter Desktop on this machine, as the owner, may skip
+	// authentication
+	stripInternalHeaders(r)
+	r.URL.Path = "/" + path
+	r.URL.RawPath = ""
+	sc.gateway.ServeHTTP(w, r)
+}
+
+// controlUser returns the user of the control token a request presented,
+// "" for the owner's tokens and requests without one.
+func (s *ControlServer) controlUser(r *http.Request) string {
+	t, _ := s.settings.Get().ControlTokenFor(requestAPIKey(r))
+	return t.User
+}
+
+// serveUser hands an authenticated control API request to user's scope.
+func (s *ControlServer) serveUser(w http.ResponseWriter, r *http.Request, user string) {
+	if s.users == nil {
+		http.Error(w, "User scopes are not available", http.StatusServiceUnavailable)
+		return
+	}
+	sc, err := s.users.scope(user)
+	if err != nil {
+		logger.AddLog("ERROR", fmt.Sprintf("[Users] %v", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sc.control.mux.ServeHTTP(w, r)
+}
+
+// rejectUserExec refuses a profile with hooks or env saved through a
+// user's scope, reporting whether it did. Both run code as the daemon's
+// owner: hooks are commands, and the env reaches every server process,
+// where LD_PRELOAD, NODE_OPTIONS or PATH do the same. Users' servers get
+// their secrets from the user's keychain namespace instead.
+func (s *ControlServer) rejectUserExec(w http.ResponseWriter, r *http.Request, p profile.Profile) bool {
+	if s.user == "" {
+		return false
+	}
+	field := ""
+	switch {
+	case len(p.Hooks) > 0:
+		field = "hooks"
+	case len(p.Env) > 0:
+		field = "env"
+	default:
+		return false
+	}
+	logger.AddLog("WARN", fmt.Sprintf("Rejected %s on profile '%s' from user '%s': only the daemon's owner may set %s", field, p.ID, s.user, field))
+	http.Error(w, field+" can only be set by the daemon's owner", http.StatusForbidden)
+	return true
+}
+
+// ownerOnly wraps a handler for the daemon's own settings, logs and
+// lifecycle, which users' tokens may not reach whatever their role.
+func (s *ControlServer) ownerOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.user != "" {
+			logger.AddLog("WARN", fmt.Sprintf("Rejected %s %s from user '%s': only the daemon's owner may", r.Method, r.URL.Path, s.user))
+			http.Error(w, i18n.T(s.locale(r), "api.forbidden"), http.StatusForbidden)
+			return
+		}
+		h(w, r)
+	}
+}
@@ -0,0 +1,219 @@
+// Package audit keeps an append-only record of every tool call: which
+// profile called which tool, a hash of the arguments, how long the call took
+// and whether it failed. Unlike the debug log it is never trimmed or
+// rewritten, and it holds no argument values.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// Outcomes of a call.
+const (
+	Success = "success"
+	Failure = "error"
+)
+
+// Sources of a call.
+const (
+	SourceGateway = "gateway" // tools/call from an MCP client
+	SourceControl = "control" // POST /api/tools/call
+)
+
+// Record is one tool call.
+type Record struct {
+	Time          time.Time `json:"time"`
+	Source        string    `json:"source"`
+	Profile       string    `json:"profile"`
+	Server        string    `json:"server,omitempty"`
+	Tool          string    `json:"tool"`
+	ArgumentsHash string    `json:"arguments_hash"`
+	DurationMs    int64     `json:"duration_ms"`
+	Outcome       string    `json:"outcome"`
+	Error         string    `json:"error,omitempty"`
+	TraceID       string    `json:"trace_id,omitempty"`
+}
+
+// NewRecord describes a call of tool with args that took duration and
+// returned err.
+func NewRecord(source, profileID, server, tool string, args map[string]interface{}, duration time.Duration, err error) Record {
+	r := Record{
+		Time:          time.Now().UTC(),
+		Source:        source,
+		Profile:       profileID,
+		Server:        server,
+		Tool:          tool,
+		ArgumentsHash: HashArguments(args),
+		DurationMs:    duration.Milliseconds(),
+		Outcome:       Success,
+	}
+	if err != nil {
+		r.Outcome = Failure
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// HashArguments returns the SHA-256 of args' JSON encoding, so identical
+// calls can be told apart from different ones without storing the values.
+// Map keys are encoded in sorted order, so the hash is stable.
+func HashArguments(args map[string]interface{}) string {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%v", args))
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Query selects records. Zero fields match everything.
+type Query struct {
+	Profile  string
+	Profiles []string // One of these, when set
+	Tool     string
+	Outcome  string
+	Since    time.Time // Inclusive
+	Until    time.Time // Exclusive
+	Limit    int       // Newest records kept; <= 0 means DefaultLimit
+}
+
+// DefaultLimit is how many records a query returns unless it sets Limit.
+const DefaultLimit = 100
+
+// MaxLimit is the most records a query returns.
+const MaxLimit = 1000
+
+// Match reports whether r passes the query's filters.
+func (q Query) Match(r Record) bool {
+	switch {
+	case q.Profile != "" && r.Profile != q.Profile,
+		len(q.Profiles) > 0 && !slices.Contains(q.Profiles, r.Profile),
+		q.Tool != "" && r.Tool != q.Tool,
+		q.Outcome != "" && r.Outcome != q.Outcome,
+		!q.Since.IsZero() && r.Time.Before(q.Since),
+		!q.Until.IsZero() && !r.Time.Before(q.Until):
+		return false
+	}
+	return true
+}
+
+// Log is an audit log backed by a JSON-lines file.
+type Log struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// Default is the audit log of tool calls.
+var Default = &Log{}
+
+// Append adds r to the default log.
+func Append(r Record) {
+	Default.Append(r)
+}
+
+// Open appends records to the file at path, creating it if needed.
+func (l *Log) Open(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.path, l.file = path, f
+	return nil
+}
+
+// Close stops recording.
+func (l *Log) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+// Append writes r to the log. Records are dropped while the log is closed.
+func (l *Log) Append(r Record) {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		logger.AddLog("ERROR", fmt.Sprintf("[Audit] Failed to write audit log: %v", err))
+	}
+}
+
+// Query returns the newest records matching q, oldest first, and whether
+// older matching records were left out because of the limit.
+func (l *Log) Query(q Query) (records []Record, truncated bool, err error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	l.mu.Lock()
+	path := l.path
+	l.mu.Unlock()
+	records = []Record{}
+	if path == "" {
+		return records, false, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return records, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer f.Close()
+
+	// Keep the last limit matches in a ring
+	ring := make([]Record, 0, limit)
+	start := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r Record
+		if json.Unmarshal(scanner.Bytes(), &r) != nil || !q.Match(r) {
+			continue
+		}
+		if len(ring) < limit {
+			ring = append(ring, r)
+			continue
+		}
+		ring[start] = r
+		start = (start + 1) % limit
+		truncated = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	records = append(records, ring[start:]...)
+	records = append(records, ring[:start]...)
+	return records, truncated, nil
+}
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tools(records []Record) []string {
+	out := []string{}
+	for _, r := range records {
+		out = append(out, r.Tool)
+	}
+	return out
+}
+
+func TestHashArguments(t *testing.T) {
+	a := HashArguments(map[string]interface{}{"path": "/tmp", "depth": 2})
+	b := HashArguments(map[string]interface{}{"depth": 2, "path": "/tmp"})
+	assert.Equal(t, a, b, "key order does not matter")
+	assert.NotEqual(t, a, HashArguments(map[string]interface{}{"path": "/etc", "depth": 2}))
+	assert.Equal(t, HashArguments(nil), HashArguments(map[string]interface{}{}))
+	assert.Contains(t, a, "sha256:")
+	assert.NotContains(t, a, "/tmp")
+}
+
+func TestLog_Query(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l := &Log{}
+	require.NoError(t, l.Open(path))
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	add := func(minute int, profile, tool string, err error) {
+		r := NewRecord(SourceGateway, profile, "srv", tool, nil, time.Millisecond, err)
+		r.Time = base.Add(time.Duration(minute) * time.Minute)
+		l.Append(r)
+	}
+	add(0, "work", "read_file", nil)
+	add(1, "work", "write_file", errors.New("denied"))
+	add(2, "home", "read_file", nil)
+	add(3, "work", "read_file", nil)
+	l.Close()
+	add(4, "work", "dropped", nil)
+
+	// Reopening appends to the same file
+	require.NoError(t, l.Open(path))
+	add(5, "work", "search", nil)
+	defer l.Close()
+
+	all, truncated, err := l.Query(Query{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read_file", "write_file", "read_file", "read_file", "search"}, tools(all))
+	assert.False(t, truncated)
+
+	failed, _, err := l.Query(Query{Outcome: Failure})
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, "denied", failed[0].Error)
+
+	got, _, err := l.Query(Query{Profile: "work", Tool: "read_file"})
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	got, _, err = l.Query(Query{Profiles: []string{"home"}})
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+
+	got, _, err = l.Query(Query{Since: base.Add(time.Minute), Until: base.Add(3 * time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"write_file", "read_file"}, tools(got))
+
+	got, truncated, err = l.Query(Query{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read_file", "search"}, tools(got), "the newest records are kept")
+	assert.True(t, truncated)
+}
@@ -0,0 +1,372 @@
+// Package bench drives a running gateway with concurrent tool calls and
+// reports throughput, latency percentiles and error rates.
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Call is one kind of tool call in the benchmark mix.
+type Call struct {
+	Name      string                 `json:"name"` // label in the report
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Weight    int                    `json:"weight"`
+}
+
+// Predefined calls for the --mix flag. "server" calls the demo server's echo
+// tool, a real stdio round trip that needs no credentials.
+var (
+	BuiltinCall = Call{Name: "builtin", Tool: "scooter_list_active", Arguments: map[string]interface{}{}}
+	FindCall    = Call{Name: "find", Tool: "scooter_find", Arguments: map[string]interface{}{"query": "demo"}}
+	ServerCall  = Call{Name: "server", Tool: "demo_echo", Arguments: map[string]interface{}{"message": "bench"}}
+
+	// ServerSetup activates the demo server before any server calls
+	ServerSetup = Call{Name: "setup", Tool: "scooter_activate", Arguments: map[string]interface{}{"tool_name": "scooter-demo"}}
+)
+
+// Config describes a benchmark run.
+type Config struct {
+	GatewayURL  string // e.g. http://127.0.0.1:6277
+	Profile     string
+	APIKey      string
+	Concurrency int
+	Requests    int           // total calls; 0 runs for Duration instead
+	Duration    time.Duration // used when Requests is 0
+	Timeout     time.Duration // per call
+	Calls       []Call
+	Setup       []Call // called once, in order, before the run
+}
+
+// Latency summarises call durations in milliseconds.
+type Latency struct {
+	Min  float64 `json:"min_ms"`
+	Mean float64 `json:"mean_ms"`
+	P50  float64 `json:"p50_ms"`
+	P90  float64 `json:"p90_ms"`
+	P99  float64 `json:"p99_ms"`
+	Max  float64 `json:"max_ms"`
+}
+
+// CallStats are the results of one kind of call.
+type CallStats struct {
+	Name      string  `json:"name"`
+	Tool      string  `json:"tool"`
+	Calls     int     `json:"calls"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	Latency   Latency `json:"latency"`
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	StartedAt   time.Time      `json:"started_at"`
+	DurationMs  int64          `json:"duration_ms"`
+	Concurrency int            `json:"concurrency"`
+	Calls       int            `json:"calls"`
+	Errors      int            `json:"errors"`
+	ErrorRate   float64        `json:"error_rate"`
+	Throughput  float64        `json:"calls_per_second"`
+	Latency     Latency        `json:"latency"`
+	ByCall      []CallStats    `json:"by_call"`
+	ErrorCounts map[string]int `json:"error_counts,omitempty"` // error message -> occurrences
+}
+
+// sample is the outcome of one call.
+type sample struct {
+	call     int // index into Config.Calls
+	duration time.Duration
+	err      string
+}
+
+// Run performs the benchmark. It fails only if the configuration is invalid
+// or a setup call fails; errors during the run are counted in the report.
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	if cfg.Concurrency <= 0 {
+		return nil, fmt.Errorf("concurrency must be positive")
+	}
+	if cfg.Requests <= 0 && cfg.Duration <= 0 {
+		return nil, fmt.Errorf("set a number of requests or a duration")
+	}
+	schedule := weightedSchedule(cfg.Calls)
+	if len(schedule) == 0 {
+		return nil, fmt.Errorf("no calls to make")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	r := &runner{
+		cfg:    cfg,
+		url:    strings.TrimRight(cfg.GatewayURL, "/") + "/profiles/" + cfg.Profile + "/message",
+		client: &http.Client{Timeout: cfg.Timeout, Transport: &http.Transport{MaxIdleConnsPerHost: cfg.Concurrency}},
+	}
+	for _, call := range cfg.Setup {
+		if err := r.call(ctx, call); err != nil {
+			return nil, fmt.Errorf("setup call %s failed: %w", call.Tool, err)
+		}
+	}
+
+	if cfg.Duration > 0 && cfg.Requests <= 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	var (
+		next    atomic.Int64
+		mu      sync.Mutex
+		samples []sample
+		wg      sync.WaitGroup
+	)
+	started := time.Now()
+	for w := 0; w < cfg.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local []sample
+			for ctx.Err() == nil {
+				n := next.Add(1) - 1
+				if cfg.Requests > 0 && n >= int64(cfg.Requests) {
+					break
+				}
+				idx := schedule[n%int64(len(schedule))]
+				callStart := time.Now()
+				err := r.call(ctx, cfg.Calls[idx])
+				if err != nil && ctx.Err() != nil {
+					break // cut off by the end of the run, not a real failure
+				}
+				s := sample{call: idx, duration: time.Since(callStart)}
+				if err != nil {
+					s.err = err.Error()
+				}
+				local = append(local, s)
+			}
+			mu.Lock()
+			samples = append(samples, local...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return buildReport(cfg, started, time.Since(started), samples), nil
+}
+
+type runner struct {
+	cfg    Config
+	url    string
+	client *http.Client
+	id     atomic.Int64
+}
+
+// call sends one tools/call and reports transport, JSON-RPC and tool errors.
+func (r *runner) call(ctx context.Context, call Call) error {
+	body, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      r.id.Add(1),
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": call.Tool, "arguments": call.Arguments},
+	})
+	req, err := http.NewRequestWithContext(ctx, "POST", r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.cfg.APIKey)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var rpc struct {
+		Result *struct {
+			IsError bool `json:"isError"`
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &rpc); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if rpc.Error != nil {
+		return fmt.Errorf("%s", rpc.Error.Message)
+	}
+	if rpc.Result != nil && rpc.Result.IsError {
+		msg := "tool returned an error"
+		if len(rpc.Result.Content) > 0 {
+			msg = rpc.Result.Content[0].Text
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	return nil
+}
+
+// weightedSchedule interleaves call indexes in proportion to their weights,
+// so every window of the schedule has the requested mix. Calls with no
+// weight count once.
+func weightedSchedule(calls []Call) []int {
+	weights := make([]int, len(calls))
+	total := 0
+	for i, c := range calls {
+		weights[i] = c.Weight
+		if weights[i] <= 0 {
+			weights[i] = 1
+		}
+		total += weights[i]
+	}
+	// Smooth weighted round robin
+	schedule := make([]int, 0, total)
+	current := make([]int, len(calls))
+	for n := 0; n < total; n++ {
+		best := 0
+		for i := range calls {
+			current[i] += weights[i]
+			if current[i] > current[best] {
+				best = i
+			}
+		}
+		current[best] -= total
+		schedule = append(schedule, best)
+	}
+	return schedule
+}
+
+func buildReport(cfg Config, started time.Time, elapsed time.Duration, samples []sample) *Report {
+	report := &Report{
+		StartedAt:   started.UTC(),
+		DurationMs:  elapsed.Milliseconds(),
+		Concurrency: cfg.Concurrency,
+		Calls:       len(samples),
+		ErrorCounts: map[string]int{},
+	}
+
+	all := make([]time.Duration, 0, len(samples))
+	perCall := make([][]time.Duration, len(cfg.Calls))
+	errorsPerCall := make([]int, len(cfg.Calls))
+	for _, s := range samples {
+		all = append(all, s.duration)
+		perCall[s.call] = append(perCall[s.call], s.duration)
+		if s.err != "" {
+			report.Errors++
+			errorsPerCall[s.call]++
+			report.ErrorCounts[s.err]++
+		}
+	}
+
+	report.Latency = summarise(all)
+	report.ErrorRate = rate(report.Errors, report.Calls)
+	if elapsed > 0 {
+		report.Throughput = float64(report.Calls) / elapsed.Seconds()
+	}
+	for i, c := range cfg.Calls {
+		report.ByCall = append(report.ByCall, CallStats{
+			Name:      c.Name,
+			Tool:      c.Tool,
+			Calls:     len(perCall[i]),
+			Errors:    errorsPerCall[i],
+			ErrorRate: rate(errorsPerCall[i], len(perCall[i])),
+			Latency:   summarise(perCall[i]),
+		})
+	}
+	return report
+}
+
+func rate(errors, calls int) float64 {
+	if calls == 0 {
+		return 0
+	}
+	return float64(errors) / float64(calls)
+}
+
+// summarise computes latency statistics using nearest-rank percentiles.
+func summarise(durations []time.Duration) Latency {
+	if len(durations) == 0 {
+		return Latency{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		return ms(sorted[rank])
+	}
+	return Latency{
+		Min:  ms(sorted[0]),
+		Mean: ms(total / time.Duration(len(sorted))),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P99:  percentile(0.99),
+		Max:  ms(sorted[len(sorted)-1]),
+	}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// Markdown renders the report as a Markdown document.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Scooter benchmark\n\n")
+	fmt.Fprintf(&b, "- Started: %s\n", r.StartedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Duration: %.2fs\n", float64(r.DurationMs)/1000)
+	fmt.Fprintf(&b, "- Concurrency: %d\n", r.Concurrency)
+	fmt.Fprintf(&b, "- Calls: %d (%.1f/s)\n", r.Calls, r.Throughput)
+	fmt.Fprintf(&b, "- Errors: %d (%.2f%%)\n\n", r.Errors, r.ErrorRate*100)
+
+	fmt.Fprintf(&b, "| Call | Tool | Calls | Errors | p50 ms | p90 ms | p99 ms | max ms |\n")
+	fmt.Fprintf(&b, "|---|---|---:|---:|---:|---:|---:|---:|\n")
+	row := func(name, tool string, calls, errors int, l Latency) {
+		fmt.Fprintf(&b, "| %s | %s | %d | %d | %.2f | %.2f | %.2f | %.2f |\n", name, tool, calls, errors, l.P50, l.P90, l.P99, l.Max)
+	}
+	for _, c := range r.ByCall {
+		row(c.Name, c.Tool, c.Calls, c.Errors, c.Latency)
+	}
+	row("**all**", "", r.Calls, r.Errors, r.Latency)
+
+	if len(r.ErrorCounts) > 0 {
+		messages := make([]string, 0, len(r.ErrorCounts))
+		for msg := range r.ErrorCounts {
+			messages = append(messages, msg)
+		}
+		sort.Slice(messages, func(i, j int) bool { return r.ErrorCounts[messages[i]] > r.ErrorCounts[messages[j]] })
+		fmt.Fprintf(&b, "\n## Errors\n\n")
+		for _, msg := range messages {
+			fmt.Fprintf(&b, "- %d × %s\n", r.ErrorCounts[msg], msg)
+		}
+	}
+	return b.String()
+}
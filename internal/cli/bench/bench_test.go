@@ -0,0 +1,85 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeightedSchedule(t *testing.T) {
+	schedule := weightedSchedule([]Call{{Name: "a", Weight: 3}, {Name: "b", Weight: 1}})
+	assert.Equal(t, []int{0, 0, 1, 0}, schedule)
+}
+
+func TestSummarise(t *testing.T) {
+	var durations []time.Duration
+	for i := 1; i <= 100; i++ {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	l := summarise(durations)
+	assert.Equal(t, 1.0, l.Min)
+	assert.Equal(t, 50.0, l.P50)
+	assert.Equal(t, 90.0, l.P90)
+	assert.Equal(t, 99.0, l.P99)
+	assert.Equal(t, 100.0, l.Max)
+	assert.Equal(t, 50.5, l.Mean)
+}
+
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string]int{}
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/profiles/work/message", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var req struct {
+			Params struct {
+				Name string `json:"name"`
+			} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		seen[req.Params.Name]++
+		mu.Unlock()
+
+		if req.Params.Name == "demo_echo" {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"isError":true,"content":[{"type":"text","text":"boom"}]}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`))
+	}))
+	defer gateway.Close()
+
+	builtin, server := BuiltinCall, ServerCall
+	builtin.Weight, server.Weight = 3, 1
+	report, err := Run(context.Background(), Config{
+		GatewayURL:  gateway.URL,
+		Profile:     "work",
+		APIKey:      "secret",
+		Concurrency: 4,
+		Requests:    40,
+		Calls:       []Call{builtin, server},
+		Setup:       []Call{ServerSetup},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 40, report.Calls)
+	assert.Equal(t, 10, report.Errors)
+	assert.Equal(t, 0.25, report.ErrorRate)
+	assert.Equal(t, 30, report.ByCall[0].Calls)
+	assert.Equal(t, 0, report.ByCall[0].Errors)
+	assert.Equal(t, 10, report.ByCall[1].Errors)
+	assert.Equal(t, map[string]int{"boom": 10}, report.ErrorCounts)
+	assert.Equal(t, 1, seen["scooter_activate"])
+
+	md := report.Markdown()
+	assert.Contains(t, md, "| server | demo_echo | 10 | 10 |")
+	assert.True(t, strings.Contains(md, "10 × boom"))
+}
@@ -4,18 +4,23 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/mcp-scooter/scooter/internal/domain/profile"
 	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/version"
 )
 
 type ControlClient struct {
-	baseURL string
-	apiKey  string
-	client  *http.Client
-	timeout time.Duration
+	baseURL       string
+	apiKey        string
+	internalToken string
+	client        *http.Client
+	timeout       time.Duration
 }
 
 func NewControlClient(baseURL, apiKey string, timeout time.Duration) *ControlClient {
@@ -29,10 +34,27 @@ func NewControlClient(baseURL, apiKey string, timeout time.Duration) *ControlCli
 	}
 }
 
+// SetInternalToken makes requests without an API key present the daemon's
+// internal token, which only its owner can read, so they are the owner's.
+func (c *ControlClient) SetInternalToken(token string) {
+	c.internalToken = token
+}
+
+// authorize adds the client's credentials to req.
+func (c *ControlClient) authorize(req *http.Request) {
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	} else if c.internalToken != "" {
+		req.Header.Set("X-Scooter-Internal-Token", c.internalToken)
+	}
+}
+
 func (c *ControlClient) ListProfiles() ([]profile.Profile, error) {
-	var profiles []profile.Profile
-	err := c.get("/api/profiles", &profiles)
-	return profiles, err
+	var resp struct {
+		Profiles []profile.Profile `json:"profiles"`
+	}
+	err := c.get("/api/profiles", &resp)
+	return resp.Profiles, err
 }
 
 func (c *ControlClient) GetProfile(id string) (*profile.Profile, error) {
@@ -41,15 +63,29 @@ func (c *ControlClient) GetProfile(id string) (*profile.Profile, error) {
 	return &p, err
 }
 
-func (c *ControlClient) ListTools() ([]registry.Tool, error) {
-	var tools []registry.Tool
-	err := c.get("/api/tools", &tools)
-	return tools, err
+// ListTools returns the tools of a server's registry entry.
+func (c *ControlClient) ListTools(server, profileID string) ([]registry.Tool, error) {
+	entries, err := c.FindTools(server, profileID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Name == server {
+			return e.Tools, nil
+		}
+	}
+	return nil, fmt.Errorf("server '%s' not found", server)
 }
 
-func (c *ControlClient) FindTools(query string) ([]registry.MCPEntry, error) {
+// FindTools searches the registry as the profile sees it; "" searches the
+// shared catalog.
+func (c *ControlClient) FindTools(query, profileID string) ([]registry.MCPEntry, error) {
+	params := url.Values{"q": {query}}
+	if profileID != "" {
+		params.Set("profile", profileID)
+	}
 	var entries []registry.MCPEntry
-	err := c.get(fmt.Sprintf("/api/registry?q=%s", query), &entries)
+	err := c.get("/api/registry?"+params.Encode(), &entries)
 	return entries, err
 }
 
@@ -61,6 +97,15 @@ func (c *ControlClient) ActivateTool(server string, profileID string) error {
 	return c.post("/api/tools/activate", body, nil)
 }
 
+// DeactivateTool stops a server in a profile; "" is the daemon's active profile.
+func (c *ControlClient) DeactivateTool(server string, profileID string) error {
+	body := map[string]string{
+		"server":  server,
+		"profile": profileID,
+	}
+	return c.post("/api/tools/deactivate", body, nil)
+}
+
 type CallResult struct {
 	Content []ContentBlock `json:"content"`
 	IsError bool           `json:"isError"`
@@ -72,44 +117,161 @@ type ContentBlock struct {
 	Data interface{} `json:"data,omitempty"`
 }
 
-func (c *ControlClient) CallTool(server, tool string, args map[string]interface{}, profileID string) (*CallResult, error) {
+// CallTool calls a tool with args on top of the tool's saved preset, if
+// preset isn't "".
+func (c *ControlClient) CallTool(server, tool string, args map[string]interface{}, preset, profileID string) (*CallResult, error) {
 	body := map[string]interface{}{
 		"server":    server,
 		"tool":      tool,
 		"arguments": args,
 		"profile":   profileID,
 	}
+	if preset != "" {
+		body["preset"] = preset
+	}
 	var result CallResult
 	err := c.post("/api/tools/call", body, &result)
 	return &result, err
 }
 
+// Status is the GET /api/status payload, schema version 2.
 type Status struct {
-	Running       bool     `json:"running"`
-	Version       string   `json:"version"`
-	Uptime        string   `json:"uptime"`
-	ActiveProfile string   `json:"activeProfile"`
-	ActiveServers []string `json:"activeServers"`
+	SchemaVersion int             `json:"schema_version"`
+	Running       bool            `json:"running"`
+	Version       version.Info    `json:"version"`
+	StartedAt     time.Time       `json:"started_at"`
+	UptimeSeconds int64           `json:"uptime_seconds"`
+	ActiveProfile string          `json:"active_profile_id"`
+	Profiles      []ProfileStatus `json:"profiles"`
 	Ports         struct {
 		Control int `json:"control"`
 		Gateway int `json:"gateway"`
 	} `json:"ports"`
 }
 
+// Uptime is how long the daemon has been running.
+func (s *Status) Uptime() time.Duration {
+	return time.Duration(s.UptimeSeconds) * time.Second
+}
+
+// ActiveServers lists the servers of every profile, prefixed with the
+// profile ID when there is more than one profile.
+func (s *Status) ActiveServers() []string {
+	servers := []string{}
+	for _, p := range s.Profiles {
+		for _, server := range p.Servers {
+			name := server.Server
+			if len(s.Profiles) > 1 {
+				name = p.ID + "/" + name
+			}
+			servers = append(servers, name)
+		}
+	}
+	return servers
+}
+
+// ProfileStatus is one profile's engine in a Status.
+type ProfileStatus struct {
+	ID           string         `json:"id"`
+	Running      bool           `json:"running"`
+	Health       string         `json:"health"`
+	Servers      []ServerStatus `json:"servers"`
+	RecentErrors []ServerError  `json:"recent_errors"`
+}
+
+// ServerStatus is the state of one MCP server: "ready", "starting",
+// "unhealthy", "crashed", "restarting" or "failed".
+type ServerStatus struct {
+	Server   string `json:"server"`
+	State    string `json:"state"`
+	Tools    int    `json:"tools,omitempty"`
+	Restarts int    `json:"restarts,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ServerError is a recent failed tool call or activation.
+type ServerError struct {
+	Server string    `json:"server"`
+	Tool   string    `json:"tool,omitempty"`
+	Error  string    `json:"error"`
+	Time   time.Time `json:"time"`
+}
+
 func (c *ControlClient) GetStatus() (*Status, error) {
 	var status Status
 	err := c.get("/api/status", &status)
 	return &status, err
 }
 
+func (c *ControlClient) GetVersion() (*version.Info, error) {
+	var info version.Info
+	err := c.get("/api/version", &info)
+	return &info, err
+}
+
+// Reload asks the daemon to re-read its configuration and registry.
+func (c *ControlClient) Reload() error {
+	return c.post("/api/reload", nil, nil)
+}
+
+// ImportResult is what importing a bundle changed.
+type ImportResult struct {
+	Profiles      []string `json:"profiles"`
+	CustomEntries int      `json:"custom_entries"`
+	Overrides     int      `json:"overrides"`
+	ToolParams    int      `json:"tool_params"`
+	ToolPresets   int      `json:"tool_presets"`
+	Settings      bool     `json:"settings"`
+}
+
+// ExportBundle returns the daemon's setup as a zip archive.
+func (c *ControlClient) ExportBundle() ([]byte, error) {
+	resp, err := c.send("GET", "/api/profiles/export", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// ImportBundle applies a bundle made by ExportBundle.
+func (c *ControlClient) ImportBundle(bundle []byte) (*ImportResult, error) {
+	resp, err := c.send("POST", "/api/profiles/import", "application/zip", bytes.NewReader(bundle))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result ImportResult
+	return &result, json.NewDecoder(resp.Body).Decode(&result)
+}
+
+// send makes a request, returning an error for any status but 200.
+func (c *ControlClient) send(method, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	c.authorize(req)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, statusError(resp)
+	}
+	return resp, nil
+}
+
 func (c *ControlClient) get(path string, v interface{}) error {
 	req, err := http.NewRequest("GET", c.baseURL+path, nil)
 	if err != nil {
 		return err
 	}
-	if c.apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	}
+	c.authorize(req)
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return err
@@ -117,7 +279,7 @@ func (c *ControlClient) get(path string, v interface{}) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return statusError(resp)
 	}
 
 	return json.NewDecoder(resp.Body).Decode(v)
@@ -133,9 +295,7 @@ func (c *ControlClient) post(path string, body interface{}, v interface{}) error
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	if c.apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	}
+	c.authorize(req)
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return err
@@ -143,7 +303,7 @@ func (c *ControlClient) post(path string, body interface{}, v interface{}) error
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return statusError(resp)
 	}
 
 	if v != nil {
@@ -151,3 +311,13 @@ func (c *ControlClient) post(path string, body interface{}, v interface{}) error
 	}
 	return nil
 }
+
+// statusError describes a failed response with the daemon's message, if it
+// sent one.
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		return fmt.Errorf("unexpected status code: %d: %s", resp.StatusCode, msg)
+	}
+	return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+}
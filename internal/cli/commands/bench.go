@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/cli/bench"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchGateway     string
+	benchAPIKey      string
+	benchConcurrency int
+	benchRequests    int
+	benchDuration    time.Duration
+	benchMix         string
+	benchFormat      string
+	benchOutput      string
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Load test the MCP gateway with concurrent tool calls",
+	Long: `Drives the gateway with concurrent tools/call requests and reports throughput,
+latency percentiles and error rates.
+
+The --mix flag weights the kinds of call:
+  builtin  scooter_list_active, answered by Scooter itself
+  find     scooter_find, a registry search
+  server   demo_echo on the demo server, a full stdio round trip
+
+For example --mix builtin=80,server=20. Server calls activate the demo server
+first, so install it from onboarding (POST /api/onboarding/demo) and run with
+--profile demo.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		calls, err := parseBenchMix(benchMix)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg := bench.Config{
+			GatewayURL:  benchGateway,
+			Profile:     profile,
+			APIKey:      benchAPIKey,
+			Concurrency: benchConcurrency,
+			Requests:    benchRequests,
+			Duration:    benchDuration,
+			Timeout:     time.Duration(timeout) * time.Millisecond,
+			Calls:       calls,
+		}
+		if cmd.Flags().Changed("duration") && !cmd.Flags().Changed("requests") {
+			cfg.Requests = 0
+		}
+		for _, c := range calls {
+			if c.Name == bench.ServerCall.Name {
+				cfg.Setup = append(cfg.Setup, bench.ServerSetup)
+				break
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		report, err := bench.Run(ctx, cfg)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		format := benchFormat
+		if jsonOutput {
+			format = "json"
+		}
+		var out string
+		switch format {
+		case "json":
+			data, _ := json.MarshalIndent(report, "", "  ")
+			out = string(data) + "\n"
+		default:
+			out = report.Markdown()
+		}
+
+		if benchOutput != "" {
+			if err := os.WriteFile(benchOutput, []byte(out), 0644); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Report written to %s\n", benchOutput)
+			return
+		}
+		fmt.Print(out)
+	},
+}
+
+// parseBenchMix parses "builtin=80,server=20" into weighted calls.
+func parseBenchMix(mix string) ([]bench.Call, error) {
+	known := map[string]bench.Call{
+		bench.BuiltinCall.Name: bench.BuiltinCall,
+		bench.FindCall.Name:    bench.FindCall,
+		bench.ServerCall.Name:  bench.ServerCall,
+	}
+	var calls []bench.Call
+	for _, part := range strings.Split(mix, ",") {
+		name, weight, hasWeight := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		call, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown call kind %q (use builtin, find or server)", name)
+		}
+		call.Weight = 1
+		if hasWeight {
+			w, err := strconv.Atoi(weight)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid weight for %s: %q", name, weight)
+			}
+			if w == 0 {
+				continue
+			}
+			call.Weight = w
+		}
+		calls = append(calls, call)
+	}
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("the mix selects no calls")
+	}
+	return calls, nil
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+	benchCmd.Flags().StringVar(&benchGateway, "gateway", "http://127.0.0.1:6277", "gateway URL")
+	benchCmd.Flags().StringVar(&benchAPIKey, "api-key", os.Getenv("SCOOTER_API_KEY"), "gateway API key (default $SCOOTER_API_KEY)")
+	benchCmd.Flags().IntVarP(&benchConcurrency, "concurrency", "c", 10, "number of concurrent callers")
+	benchCmd.Flags().IntVarP(&benchRequests, "requests", "n", 1000, "total number of calls")
+	benchCmd.Flags().DurationVarP(&benchDuration, "duration", "d", 0, "run for this long instead of a number of calls (e.g. 30s)")
+	benchCmd.Flags().StringVar(&benchMix, "mix", "builtin=80,server=20", "weighted mix of call kinds")
+	benchCmd.Flags().StringVar(&benchFormat, "format", "markdown", "report format: markdown or json")
+	benchCmd.Flags().StringVarP(&benchOutput, "output", "o", "", "write the report to a file")
+}
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Save profiles, settings and custom registry entries to a bundle",
+	Long: `Writes the daemon's profiles, settings, tool parameters and custom registry
+entries to a zip file (default scooter-<date>.zip), to set up another machine
+with "scooter import". Machine secrets such as the gateway key and control
+tokens are left out, but profile environment values are included: keep the
+bundle private.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		formatter := newFormatter()
+		file := fmt.Sprintf("scooter-%s.zip", time.Now().Format("2006-01-02"))
+		if len(args) == 1 {
+			file = args[0]
+		}
+
+		data, err := controlClient(0).ExportBundle()
+		if err != nil {
+			fail(formatter, err)
+		}
+		if err := os.WriteFile(file, data, 0600); err != nil {
+			fail(formatter, err)
+		}
+		if jsonOutput {
+			out, _ := json.Marshal(map[string]string{"status": "exported", "file": file})
+			fmt.Println(string(out))
+		} else {
+			color.Green("Exported setup to %s", file)
+		}
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Apply a bundle made by scooter export",
+	Long: `Applies a bundle: its profiles replace those with the same ID, its settings
+replace the current ones but keep this machine's secrets, and its registry
+entries and tool parameters are added. The daemon reloads its configuration.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		formatter := newFormatter()
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			fail(formatter, err)
+		}
+
+		result, err := controlClient(0).ImportBundle(data)
+		if err != nil {
+			fail(formatter, err)
+		}
+		if jsonOutput {
+			out, _ := json.MarshalIndent(result, "", "  ")
+			fmt.Println(string(out))
+			return
+		}
+		color.Green("Imported %s", args[0])
+		fmt.Printf("  Profiles:       %s\n", strings.Join(result.Profiles, ", "))
+		fmt.Printf("  Custom entries: %d\n", result.CustomEntries)
+		fmt.Printf("  Overrides:      %d\n", result.Overrides)
+		fmt.Printf("  Tool params:    %d\n", result.ToolParams)
+		fmt.Printf("  Settings:       %v\n", result.Settings)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd, importCmd)
+}
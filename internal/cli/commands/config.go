@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	domainprofile "github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+var configStrict bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and validate Scooter configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate profiles.yaml and settings.yaml",
+	Run: func(cmd *cobra.Command, args []string) {
+		appDir := domainprofile.ConfigDir()
+		store := domainprofile.NewStore(
+			filepath.Join(appDir, "profiles.yaml"),
+			filepath.Join(appDir, "settings.yaml"),
+		)
+
+		known := map[string]bool{}
+		engine := discovery.NewDiscoveryEngine(context.Background(), filepath.Join(appDir, "wasm"), filepath.Join(appDir, "registry"))
+		for _, td := range engine.Find("") {
+			known[td.Name] = true
+		}
+		engine.Stop()
+
+		report := store.Validate(known)
+		failed := report.Err(configStrict) != nil
+
+		if jsonOutput {
+			data, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			locale := i18n.FromEnv()
+			for _, issue := range report.Errors {
+				color.Red("%s", i18n.T(locale, "cli.config.error", issue))
+			}
+			for _, issue := range report.Warnings {
+				color.Yellow("%s", i18n.T(locale, "cli.config.warning", issue))
+			}
+			if !failed {
+				color.Green("%s", i18n.T(locale, "cli.config.valid", appDir))
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	configValidateCmd.Flags().BoolVar(&configStrict, "strict", false, "treat warnings as errors")
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
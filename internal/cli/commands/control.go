@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/cli/client"
+	domainprofile "github.com/mcp-scooter/scooter/internal/domain/profile"
+	"gopkg.in/yaml.v3"
+)
+
+// controlClient connects to the local daemon at the control_port of its
+// settings. It authenticates with --token or $SCOOTER_CONTROL_TOKEN;
+// without either, the daemon's owner is recognised by the internal token
+// the daemon writes to the config directory, which only they can read.
+func controlClient(timeout time.Duration) *client.ControlClient {
+	dir := domainprofile.ConfigDir()
+	return newControlClient(controlURL(dir), dir, timeout)
+}
+
+// newControlClient connects to baseURL with the credentials of a daemon
+// whose config directory is dir.
+func newControlClient(baseURL, dir string, timeout time.Duration) *client.ControlClient {
+	token := controlToken
+	if token == "" {
+		token = os.Getenv("SCOOTER_CONTROL_TOKEN")
+	}
+	c := client.NewControlClient(baseURL, token, timeout)
+	if internal, err := os.ReadFile(filepath.Join(dir, "internal-token")); err == nil {
+		c.SetInternalToken(strings.TrimSpace(string(internal)))
+	}
+	return c
+}
+
+// controlURL returns the address of the control API of the daemon whose
+// config directory is dir.
+func controlURL(dir string) string {
+	port := domainprofile.DefaultSettings().ControlPort
+	if data, err := os.ReadFile(filepath.Join(dir, "settings.yaml")); err == nil {
+		var config domainprofile.SettingsConfig
+		if yaml.Unmarshal(data, &config) == nil && config.Settings.ControlPort != 0 {
+			port = config.Settings.ControlPort
+		}
+	}
+	return fmt.Sprintf("http://localhost:%d", port)
+}
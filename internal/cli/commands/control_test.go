@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControlURL(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, "http://localhost:6200", controlURL(dir), "the default port without settings")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.yaml"), []byte("settings:\n  control_port: 7300\n"), 0600))
+	assert.Equal(t, "http://localhost:7300", controlURL(dir))
+}
+
+func TestControlClientCredentials(t *testing.T) {
+	var auth, internal string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, internal = r.Header.Get("Authorization"), r.Header.Get("X-Scooter-Internal-Token")
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "internal-token"), []byte("owner-token\n"), 0600))
+
+	// The owner's CLI needs no token
+	t.Setenv("SCOOTER_CONTROL_TOKEN", "")
+	_, err := newControlClient(srv.URL, dir, 0).GetStatus()
+	require.NoError(t, err)
+	assert.Empty(t, auth)
+	assert.Equal(t, "owner-token", internal)
+
+	t.Setenv("SCOOTER_CONTROL_TOKEN", "env-token")
+	_, err = newControlClient(srv.URL, dir, 0).GetStatus()
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer env-token", auth)
+	assert.Empty(t, internal)
+
+	controlToken = "flag-token"
+	defer func() { controlToken = "" }()
+	_, err = newControlClient(srv.URL, dir, 0).GetStatus()
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer flag-token", auth)
+}
@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	domainprofile "github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	probeTransport string
+	probeCall      string
+	probeArgs      string
+	probeHeaders   []string
+	probeEnv       []string
+	probeSave      bool
+	probeRegistry  string
+	probeEntry     discovery.EntryOptions
+)
+
+var probeCmd = &cobra.Command{
+	Use:   "probe <command|url> [args...]",
+	Short: "Check that an MCP server works with Scooter",
+	Long: `Connects to any MCP server without adding it to the registry, performs the
+handshake, lists its tools, resources and prompts, and prints a compatibility
+report. Exits with status 1 if a required step fails.
+
+With --save, a server that passes is written to the custom registry as a new
+entry, with the tools and schemas it listed. Variables given with --env become
+credentials the entry asks for; their values are not saved, and headers keep
+their ${VAR} references.
+
+A URL is reached over HTTP (streamable HTTP, falling back to HTTP+SSE, unless
+--transport says which); anything else is run as a stdio command. Put -- before
+the command so its own flags are not read as probe's.
+
+Examples:
+  scooter probe -- npx -y @modelcontextprotocol/server-filesystem /tmp
+  scooter probe https://mcp.example.com/mcp --header "Authorization=Bearer \${TOKEN}" --env TOKEN=abc
+  scooter probe --call echo --args '{"message":"hi"}' -- python server.py
+  scooter probe --save --name my-server -- python server.py`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := discovery.ProbeOptions{
+			Transport: registry.TransportType(probeTransport),
+			Timeout:   time.Duration(timeout) * time.Millisecond,
+			CallTool:  probeCall,
+		}
+		if strings.HasPrefix(args[0], "http://") || strings.HasPrefix(args[0], "https://") {
+			if len(args) > 1 {
+				fmt.Println("Error: a URL takes no further arguments")
+				os.Exit(1)
+			}
+			opts.URL = args[0]
+		} else {
+			opts.Command, opts.Args = args[0], args[1:]
+		}
+
+		var err error
+		if opts.Headers, err = parseKeyValues(probeHeaders, "--header"); err == nil {
+			opts.Env, err = parseKeyValues(probeEnv, "--env")
+		}
+		if err == nil && probeArgs != "" {
+			if err = json.Unmarshal([]byte(probeArgs), &opts.CallArguments); err != nil {
+				err = fmt.Errorf("--args must be a JSON object: %w", err)
+			}
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		report, err := discovery.Probe(ctx, opts)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput {
+			data, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Print(report.Text())
+		}
+		if !report.Compatible {
+			os.Exit(1)
+		}
+		if probeSave {
+			saveProbeEntry(report)
+		}
+	},
+}
+
+// saveProbeEntry writes a registry entry for the probed server to the
+// custom registry and asks a running daemon to load it.
+func saveProbeEntry(report *discovery.ProbeReport) {
+	entry, err := report.RegistryEntry(probeEntry)
+	if err != nil {
+		fmt.Printf("Error: cannot register the server: %v\n", err)
+		os.Exit(1)
+	}
+	registryDir := probeRegistry
+	if registryDir == "" {
+		registryDir = filepath.Join(domainprofile.ConfigDir(), "registry")
+	}
+	path, result, err := discovery.SaveRegistryEntry(registryDir, entry, probeEntry.Overwrite)
+	if err != nil {
+		if result != nil {
+			for _, e := range result.Errors {
+				fmt.Printf("  %s\n", e)
+			}
+		}
+		if errors.Is(err, discovery.ErrEntryExists) {
+			err = fmt.Errorf("%w (use --overwrite or --name)", err)
+		}
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\nSaved registry entry %q to %s\n", entry.Name, path)
+
+	c := controlClient(2*time.Second)
+	if err := c.Reload(); err != nil {
+		fmt.Println("Scooter is not running; the entry is loaded when it starts.")
+	}
+}
+
+// parseKeyValues turns repeated KEY=VALUE flags into a map.
+func parseKeyValues(pairs []string, flag string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	m := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("%s expects KEY=VALUE, got %q", flag, pair)
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+func init() {
+	rootCmd.AddCommand(probeCmd)
+	probeCmd.Flags().StringVar(&probeTransport, "transport", "", "transport for a URL: sse or streamable-http (default: detect)")
+	probeCmd.Flags().StringVar(&probeCall, "call", "", "also call this tool")
+	probeCmd.Flags().StringVar(&probeArgs, "args", "", "JSON arguments for --call")
+	probeCmd.Flags().StringArrayVar(&probeHeaders, "header", nil, "HTTP header KEY=VALUE; values may use ${VAR} from --env (repeatable)")
+	probeCmd.Flags().StringArrayVar(&probeEnv, "env", nil, "environment variable KEY=VALUE for the server (repeatable)")
+	probeCmd.Flags().BoolVar(&probeSave, "save", false, "save a registry entry for the server if it passes")
+	probeCmd.Flags().StringVar(&probeEntry.Name, "name", "", "name of the saved entry (default: from the server's name)")
+	probeCmd.Flags().StringVar(&probeEntry.Title, "title", "", "title of the saved entry")
+	probeCmd.Flags().StringVar(&probeEntry.Description, "description", "", "description of the saved entry")
+	probeCmd.Flags().StringVar((*string)(&probeEntry.Category), "category", "", "category of the saved entry (default: custom)")
+	probeCmd.Flags().BoolVar(&probeEntry.Overwrite, "overwrite", false, "replace an existing entry with the same name")
+	probeCmd.Flags().StringVar(&probeRegistry, "registry", "", "registry directory (default: the config directory's registry)")
+}
@@ -6,7 +6,6 @@ import (
 	"os"
 
 	"github.com/fatih/color"
-	"github.com/mcp-scooter/scooter/internal/cli/client"
 	"github.com/mcp-scooter/scooter/internal/cli/errors"
 	"github.com/mcp-scooter/scooter/internal/cli/output"
 	"github.com/spf13/cobra"
@@ -21,7 +20,7 @@ var profileListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all profiles",
 	Run: func(cmd *cobra.Command, args []string) {
-		c := client.NewControlClient("http://localhost:6200", "", 0)
+		c := controlClient(0)
 		
 		var fmtMode output.OutputFormat = output.FormatText
 		if jsonOutput {
@@ -51,7 +50,7 @@ var profileShowCmd = &cobra.Command{
 	Use:   "show [id]",
 	Short: "Show profile details",
 	Run: func(cmd *cobra.Command, args []string) {
-		c := client.NewControlClient("http://localhost:6200", "", 0)
+		c := controlClient(0)
 		
 		var fmtMode output.OutputFormat = output.FormatText
 		if jsonOutput {
@@ -15,6 +15,8 @@ var (
 	rawOutput  bool
 	directMode bool
 	timeout    int
+	// controlToken authenticates with the daemon's control API
+	controlToken string
 )
 
 var rootCmd = &cobra.Command{
@@ -49,4 +51,5 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&rawOutput, "raw", false, "raw output (no formatting)")
 	rootCmd.PersistentFlags().BoolVar(&directMode, "direct", false, "direct mode (no daemon, for headless servers)")
 	rootCmd.PersistentFlags().IntVar(&timeout, "timeout", 30000, "request timeout in milliseconds")
+	rootCmd.PersistentFlags().StringVar(&controlToken, "token", "", "control API token (default $SCOOTER_CONTROL_TOKEN)")
 }
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mcp-scooter/scooter/internal/update"
+	"github.com/mcp-scooter/scooter/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateCheckOnly bool
+	updateRollback  bool
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update the scooter CLI to the latest release",
+	Long: `Downloads the latest release for this platform, verifies its checksum
+and signature against the release key embedded in the build, and replaces
+the running binary. Builds without a release key can only check for
+updates. The previous binary is kept so --rollback can restore it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		u, err := update.New(update.CLIBinary)
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+
+		if updateRollback {
+			if err := update.Rollback(u.ExePath); err != nil {
+				color.Red("Rollback failed: %v", err)
+				os.Exit(1)
+			}
+			color.Green("Restored the previous version.")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		release, newer, err := u.Check(ctx)
+		if err != nil {
+			color.Red("Could not check for updates: %v", err)
+			os.Exit(1)
+		}
+		if !newer {
+			fmt.Printf("Already up to date (%s).\n", version.Version)
+			return
+		}
+		if updateCheckOnly {
+			color.Green("Update available: %s -> %s", version.Version, release.Version())
+			return
+		}
+
+		if !update.Enabled() {
+			color.Red("Update available (%s), but %v", release.Version(), update.ErrNoPublicKey)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Updating %s -> %s...\n", version.Version, release.Version())
+		if err := u.Update(ctx, release); err != nil {
+			color.Red("Update failed: %v", err)
+			os.Exit(1)
+		}
+		color.Green("Updated to %s. Run 'scooter self-update --rollback' to undo.", release.Version())
+	},
+}
+
+func init() {
+	selfUpdateCmd.Flags().BoolVar(&updateCheckOnly, "check", false, "only check whether an update is available")
+	selfUpdateCmd.Flags().BoolVar(&updateRollback, "rollback", false, "restore the binary replaced by the last update")
+	rootCmd.AddCommand(selfUpdateCmd)
+}
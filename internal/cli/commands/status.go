@@ -6,9 +6,9 @@ import (
 	"os"
 
 	"github.com/fatih/color"
-	"github.com/mcp-scooter/scooter/internal/cli/client"
 	"github.com/mcp-scooter/scooter/internal/cli/errors"
 	"github.com/mcp-scooter/scooter/internal/cli/output"
+	"github.com/mcp-scooter/scooter/internal/i18n"
 	"github.com/spf13/cobra"
 )
 
@@ -16,7 +16,7 @@ var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show Scooter daemon status",
 	Run: func(cmd *cobra.Command, args []string) {
-		c := client.NewControlClient("http://localhost:6200", "", 0)
+		c := controlClient(0)
 		
 		var fmtMode output.OutputFormat = output.FormatText
 		if jsonOutput {
@@ -34,14 +34,30 @@ var statusCmd = &cobra.Command{
 			data, _ := json.MarshalIndent(status, "", "  ")
 			fmt.Println(string(data))
 		} else {
-			color.Cyan("Scooter Daemon Status:")
+			color.Cyan("%s", i18n.T(i18n.FromEnv(), "cli.status.title"))
 			fmt.Printf("  Running: %v\n", status.Running)
-			fmt.Printf("  Version: %s\n", status.Version)
-			fmt.Printf("  Uptime:  %s\n", status.Uptime)
+			fmt.Printf("  Version: %s (%s)\n", status.Version.Version, status.Version.Commit)
+			fmt.Printf("  Uptime:  %s\n", status.Uptime())
 			fmt.Printf("  Profile: %s\n", status.ActiveProfile)
-			fmt.Printf("  Active Servers: %v\n", status.ActiveServers)
 			fmt.Printf("  Control API:    :%d\n", status.Ports.Control)
 			fmt.Printf("  MCP Gateway:    :%d\n", status.Ports.Gateway)
+			for _, p := range status.Profiles {
+				fmt.Printf("  Profile %s: %s\n", p.ID, p.Health)
+				for _, server := range p.Servers {
+					line := fmt.Sprintf("    %-24s %s", server.Server, server.State)
+					if server.Restarts > 0 {
+						line += fmt.Sprintf(", %d restart(s) this hour", server.Restarts)
+					}
+					if server.Error != "" {
+						line += " (" + server.Error + ")"
+					}
+					fmt.Println(line)
+				}
+				if len(p.RecentErrors) > 0 {
+					last := p.RecentErrors[0]
+					fmt.Printf("    Last error: %s: %s\n", last.Server, last.Error)
+				}
+			}
 		}
 	},
 }
@@ -0,0 +1,220 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mcp-scooter/scooter/internal/cli/errors"
+	"github.com/mcp-scooter/scooter/internal/cli/output"
+	"github.com/spf13/cobra"
+)
+
+// The tools command group drives a profile's servers through the control
+// API. The top-level list, find, activate and call commands are shortcuts
+// for the same subcommands.
+
+var toolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "Search, activate and call MCP servers through the daemon",
+}
+
+var toolsListCmd = &cobra.Command{
+	Use:   "list [server]",
+	Short: "List registry entries, active servers (--active) or a server's tools",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runList,
+}
+
+var toolsFindCmd = &cobra.Command{
+	Use:   "find <query>",
+	Short: "Search for tools by capability or server name",
+	Args:  cobra.ExactArgs(1),
+	Run:   runFind,
+}
+
+var toolsActivateCmd = &cobra.Command{
+	Use:   "activate <server>",
+	Short: "Activate an MCP server",
+	Args:  cobra.ExactArgs(1),
+	Run:   runActivate,
+}
+
+var toolsDeactivateCmd = &cobra.Command{
+	Use:   "deactivate <server>",
+	Short: "Stop an active MCP server",
+	Args:  cobra.ExactArgs(1),
+	Run:   runDeactivate,
+}
+
+var toolsCallCmd = &cobra.Command{
+	Use:   "call <server>.<tool> [key=value...]",
+	Short: "Call an MCP tool and print its result",
+	Long: `Calls a tool and waits for its result. Values that parse as JSON (numbers,
+booleans, arrays, objects) are passed as such; anything else is a string.
+With --preset, the arguments of a saved preset are used, with key=value
+arguments overriding them.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runCall,
+}
+
+func init() {
+	rootCmd.AddCommand(toolsCmd)
+	toolsCmd.AddCommand(toolsListCmd, toolsFindCmd, toolsActivateCmd, toolsDeactivateCmd, toolsCallCmd)
+	toolsListCmd.Flags().BoolVar(&listActive, "active", false, "list only active servers")
+	toolsCallCmd.Flags().BoolVar(&autoActivate, "auto-activate", true, "automatically activate server if not active")
+	toolsCallCmd.Flags().StringVar(&callPreset, "preset", "", "start from the tool's saved preset of this name; key=value arguments override it")
+}
+
+// targetProfile is the --profile flag if it was given, otherwise "" for the
+// daemon's active profile.
+func targetProfile(cmd *cobra.Command) string {
+	if cmd.Flags().Changed("profile") {
+		return profile
+	}
+	return ""
+}
+
+func newFormatter() *output.Formatter {
+	var fmtMode output.OutputFormat = output.FormatText
+	if jsonOutput {
+		fmtMode = output.FormatJSON
+	} else if rawOutput {
+		fmtMode = output.FormatRaw
+	}
+	return output.NewFormatter(fmtMode, true)
+}
+
+// fail prints err and exits.
+func fail(formatter *output.Formatter, err error) {
+	fmt.Println(formatter.FormatError(errors.Classify(err)))
+	os.Exit(1)
+}
+
+func runList(cmd *cobra.Command, args []string) {
+	c := controlClient(0)
+	formatter := newFormatter()
+
+	switch {
+	case len(args) == 1:
+		tools, err := c.ListTools(args[0], targetProfile(cmd))
+		if err != nil {
+			fail(formatter, err)
+		}
+		formatter.FormatTools(tools)
+	case listActive:
+		status, err := c.GetStatus()
+		if err != nil {
+			fail(formatter, err)
+		}
+		servers := status.ActiveServers()
+		if jsonOutput {
+			data, _ := json.MarshalIndent(servers, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+		for _, name := range servers {
+			fmt.Println(name)
+		}
+	default:
+		entries, err := c.FindTools("", targetProfile(cmd))
+		if err != nil {
+			fail(formatter, err)
+		}
+		formatter.FormatServers(entries)
+	}
+}
+
+func runFind(cmd *cobra.Command, args []string) {
+	c := controlClient(0)
+	formatter := newFormatter()
+
+	entries, err := c.FindTools(args[0], targetProfile(cmd))
+	if err != nil {
+		fail(formatter, err)
+	}
+	formatter.FormatServers(entries)
+}
+
+func runActivate(cmd *cobra.Command, args []string) {
+	c := controlClient(0)
+	formatter := newFormatter()
+
+	serverName := args[0]
+	if err := c.ActivateTool(serverName, targetProfile(cmd)); err != nil {
+		fail(formatter, err)
+	}
+	if jsonOutput {
+		fmt.Println(`{"status": "activated", "server": "` + serverName + `"}`)
+	} else {
+		color.Green("Successfully activated server: %s", serverName)
+	}
+}
+
+func runDeactivate(cmd *cobra.Command, args []string) {
+	c := controlClient(0)
+	formatter := newFormatter()
+
+	serverName := args[0]
+	if err := c.DeactivateTool(serverName, targetProfile(cmd)); err != nil {
+		fail(formatter, err)
+	}
+	if jsonOutput {
+		fmt.Println(`{"status": "deactivated", "server": "` + serverName + `"}`)
+	} else {
+		color.Green("Deactivated server: %s", serverName)
+	}
+}
+
+func runCall(cmd *cobra.Command, args []string) {
+	c := controlClient(0)
+	formatter := newFormatter()
+	profileID := targetProfile(cmd)
+
+	serverName, toolName, ok := strings.Cut(args[0], ".")
+	if !ok || serverName == "" || toolName == "" {
+		fmt.Printf("Error: Invalid target format. Use server.tool\n")
+		os.Exit(1)
+	}
+	toolArgs, err := parseToolArgs(args[1:])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	res, err := c.CallTool(serverName, toolName, toolArgs, callPreset, profileID)
+	if err != nil && autoActivate && errors.Classify(err).Kind == errors.ErrorKindNotFound {
+		if err = c.ActivateTool(serverName, profileID); err == nil {
+			res, err = c.CallTool(serverName, toolName, toolArgs, callPreset, profileID)
+		}
+	}
+	if err != nil {
+		fail(formatter, err)
+	}
+
+	fmt.Println(formatter.FormatResult(output.NewCallResult(res)))
+	if res.IsError {
+		os.Exit(1)
+	}
+}
+
+// parseToolArgs turns key=value arguments into tool arguments, decoding
+// values that are valid JSON.
+func parseToolArgs(args []string) (map[string]interface{}, error) {
+	toolArgs := make(map[string]interface{}, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid argument %q, expected key=value", arg)
+		}
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+			toolArgs[key] = decoded
+		} else {
+			toolArgs[key] = value
+		}
+	}
+	return toolArgs, nil
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mcp-scooter/scooter/internal/i18n"
+	"github.com/mcp-scooter/scooter/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var checkUpdates bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show version information; --check looks for updates",
+	Run: func(cmd *cobra.Command, args []string) {
+		local := version.Get()
+
+		// The daemon may be running a different build than this CLI
+		c := controlClient(2*time.Second)
+		daemon, daemonErr := c.GetVersion()
+
+		var latest *version.Release
+		var latestErr error
+		if checkUpdates {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			latest, latestErr = version.LatestRelease(ctx, http.DefaultClient)
+		}
+
+		if jsonOutput {
+			out := map[string]interface{}{
+				"cli": local,
+			}
+			if daemonErr == nil {
+				out["daemon"] = daemon
+			}
+			if latest != nil {
+				out["latest"] = latest.Version()
+				out["update_available"] = version.IsNewer(latest.Version(), local.Version)
+			}
+			data, _ := json.MarshalIndent(out, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		locale := i18n.FromEnv()
+		color.Cyan("MCP Scooter")
+		fmt.Printf("  Version:    %s\n", local.Version)
+		fmt.Printf("  Commit:     %s\n", local.Commit)
+		fmt.Printf("  Built:      %s\n", local.BuildDate)
+		fmt.Printf("  Go:         %s (%s)\n", local.GoVersion, local.Platform)
+		if daemonErr == nil {
+			fmt.Printf("  Daemon:     %s (commit %s)\n", daemon.Version, daemon.Commit)
+		} else {
+			fmt.Printf("  Daemon:     %s\n", i18n.T(locale, "cli.version.daemon_down"))
+		}
+
+		if !checkUpdates {
+			return
+		}
+		switch {
+		case latestErr != nil:
+			color.Yellow("\n%s", i18n.T(locale, "cli.version.check_fail", latestErr))
+		case version.IsNewer(latest.Version(), local.Version):
+			color.Green("\n%s", i18n.T(locale, "cli.version.update", local.Version, latest.Version()))
+			fmt.Printf("  %s\n", latest.HTMLURL)
+		default:
+			fmt.Println("\n" + i18n.T(locale, "cli.version.latest"))
+		}
+	},
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&checkUpdates, "check", false, "check GitHub releases for a newer version")
+	rootCmd.AddCommand(versionCmd)
+}
@@ -0,0 +1,232 @@
+// Package demo provides the offline example tools behind the onboarding demo
+// profile: a stdio MCP server built into the Scooter binary (echo and current
+// time) and a tiny WASM module. Neither needs credentials or network access,
+// so a new user's first tools/list and tools/call work out of the box.
+package demo
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+)
+
+const (
+	// ProfileID is the profile created by POST /api/onboarding/demo.
+	ProfileID = "demo"
+	// ServerName is the stdio demo server, run as `scooter --demo-server`.
+	ServerName = "scooter-demo"
+	// WASMServerName is the WASM sample; its module is written to the wasm dir.
+	WASMServerName = "scooter-demo-wasm"
+
+	// ServerFlag makes the Scooter binary serve the demo tools over stdio.
+	ServerFlag = "--demo-server"
+)
+
+// helloWASM is hello.wat assembled; see that file for the source.
+//
+//go:embed hello.wasm
+var helloWASM []byte
+
+var echoTool = registry.Tool{
+	Name:        "demo_echo",
+	Title:       "Echo",
+	Description: "Returns the message it is given. Handy for checking that tool calls reach Scooter.",
+	InputSchema: &registry.JSONSchema{
+		Type: "object",
+		Properties: map[string]registry.PropertySchema{
+			"message": {Type: "string", Description: "Text to echo back"},
+		},
+		Required: []string{"message"},
+	},
+	SampleInput: map[string]interface{}{"message": "Hello, Scooter!"},
+	Annotations: &registry.ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true},
+}
+
+var timeTool = registry.Tool{
+	Name:        "demo_time",
+	Title:       "Current Time",
+	Description: "Returns the current date and time, optionally in an IANA time zone such as Europe/Paris.",
+	InputSchema: &registry.JSONSchema{
+		Type: "object",
+		Properties: map[string]registry.PropertySchema{
+			"timezone": {Type: "string", Description: "IANA time zone name; defaults to the local zone"},
+		},
+	},
+	SampleInput: map[string]interface{}{"timezone": "UTC"},
+	Annotations: &registry.ToolAnnotations{ReadOnlyHint: true},
+}
+
+var helloTool = registry.Tool{
+	Name:        "demo_hello_wasm",
+	Title:       "Hello from WASM",
+	Description: "Returns a greeting from a sandboxed WebAssembly module.",
+	InputSchema: &registry.JSONSchema{Type: "object"},
+	Annotations: &registry.ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true},
+}
+
+// Entries returns the registry entries of the demo tools. executable is the
+// Scooter binary that serves the stdio demo.
+func Entries(executable string) []registry.MCPEntry {
+	return []registry.MCPEntry{
+		{
+			Name:        ServerName,
+			Version:     "1.0.0",
+			Title:       "Scooter Demo",
+			Description: "Offline example tools (echo and current time) to try Scooter without any API keys",
+			Category:    registry.CategoryUtility,
+			Source:      registry.SourceLocal,
+			Tags:        []string{"demo", "example", "offline"},
+			Auth:        &registry.Authorization{Type: registry.AuthNone},
+			Tools:       []registry.Tool{echoTool, timeTool},
+			Runtime: &registry.Runtime{
+				Transport: registry.TransportStdio,
+				Command:   executable,
+				Args:      []string{ServerFlag},
+			},
+		},
+		{
+			Name:        WASMServerName,
+			Version:     "1.0.0",
+			Title:       "Scooter Demo (WASM)",
+			Description: "A WebAssembly sample tool that runs in Scooter's sandbox",
+			Category:    registry.CategoryUtility,
+			Source:      registry.SourceLocal,
+			Tags:        []string{"demo", "example", "offline", "wasm"},
+			Auth:        &registry.Authorization{Type: registry.AuthNone},
+			Tools:       []registry.Tool{helloTool},
+		},
+	}
+}
+
+// Install writes the demo registry entries to registryDir/custom and the WASM
+// module to wasmDir. Existing demo files are overwritten.
+func Install(registryDir, wasmDir, executable string) error {
+	customDir := filepath.Join(registryDir, "custom")
+	if err := os.MkdirAll(customDir, 0755); err != nil {
+		return err
+	}
+	for _, entry := range Entries(executable) {
+		data, err := json.MarshalIndent(entry, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(customDir, entry.Name+".json"), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(wasmDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(wasmDir, WASMServerName+".wasm"), helloWASM, 0644)
+}
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ServeStdio runs the demo MCP server, reading newline-delimited JSON-RPC
+// from in and writing responses to out until in is closed.
+func ServeStdio(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	enc := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		var req rpcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			enc.Encode(rpcMessage{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: -32700, Message: "Parse error"}})
+			continue
+		}
+		if len(req.ID) == 0 {
+			continue // Notifications need no answer
+		}
+
+		resp := rpcMessage{JSONRPC: "2.0", ID: req.ID}
+		result, rpcErr := handle(req.Method, req.Params)
+		if rpcErr != nil {
+			resp.Error = rpcErr
+		} else {
+			resp.Result = result
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func handle(method string, params json.RawMessage) (interface{}, *rpcError) {
+	switch method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": "2024-11-05",
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": ServerName, "version": "1.0.0"},
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": []registry.Tool{echoTool, timeTool}}, nil
+	case "tools/call":
+		var call struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		if err := json.Unmarshal(params, &call); err != nil {
+			return nil, &rpcError{Code: -32602, Message: "Invalid params"}
+		}
+		text, err := callTool(call.Name, call.Arguments)
+		if err != nil {
+			return map[string]interface{}{
+				"content": []map[string]string{{"type": "text", "text": err.Error()}},
+				"isError": true,
+			}, nil
+		}
+		return map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": text}},
+		}, nil
+	default:
+		return nil, &rpcError{Code: -32601, Message: fmt.Sprintf("Method not found: %s", method)}
+	}
+}
+
+func callTool(name string, args map[string]interface{}) (string, error) {
+	switch name {
+	case echoTool.Name:
+		message, _ := args["message"].(string)
+		if message == "" {
+			return "", fmt.Errorf("message is required")
+		}
+		return message, nil
+	case timeTool.Name:
+		now := time.Now()
+		if tz, _ := args["timezone"].(string); tz != "" {
+			loc, err := time.LoadLocation(tz)
+			if err != nil {
+				return "", fmt.Errorf("unknown time zone %q", tz)
+			}
+			now = now.In(loc)
+		}
+		return now.Format(time.RFC3339), nil
+	default:
+		return "", fmt.Errorf("unknown tool: %s", name)
+	}
+}
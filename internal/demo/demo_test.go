@@ -0,0 +1,45 @@
+package demo_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mcp-scooter/scooter/internal/demo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeStdio(t *testing.T) {
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"demo_echo","arguments":{"message":"hi"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"demo_time","arguments":{"timezone":"Not/AZone"}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"resources/list"}`,
+	}, "\n")
+
+	var out strings.Builder
+	require.NoError(t, demo.ServeStdio(strings.NewReader(in), &out))
+
+	var responses []map[string]interface{}
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &resp))
+		responses = append(responses, resp)
+	}
+	require.Len(t, responses, 5, "the notification gets no response")
+
+	text := func(resp map[string]interface{}) string {
+		content := resp["result"].(map[string]interface{})["content"].([]interface{})
+		return content[0].(map[string]interface{})["text"].(string)
+	}
+	assert.Len(t, responses[1]["result"].(map[string]interface{})["tools"], 2)
+	assert.Equal(t, "hi", text(responses[2]))
+	assert.Equal(t, true, responses[3]["result"].(map[string]interface{})["isError"])
+	assert.Contains(t, text(responses[3]), "Not/AZone")
+	assert.Equal(t, float64(-32601), responses[4]["error"].(map[string]interface{})["code"])
+}
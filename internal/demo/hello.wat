@@ -0,0 +1,15 @@
+;; Demo WASM tool for the onboarding demo profile. It answers any tools/call
+;; request on stdin with a fixed greeting on stdout, using only WASI fd_write.
+;; Assemble with: wat2wasm hello.wat -o hello.wasm
+(module
+  (import "wasi_snapshot_preview1" "fd_write"
+    (func $fd_write (param i32 i32 i32 i32) (result i32)))
+  (memory (export "memory") 1)
+
+  ;; The response, at offset 16. The iovec pointing at it lives at offset 0.
+  (data (i32.const 16) "{\"jsonrpc\":\"2.0\",\"id\":0,\"result\":{\"content\":[{\"type\":\"text\",\"text\":\"Hello from WebAssembly! This tool ran inside Scooter's sandboxed WASM runtime.\"}]}}\n")
+
+  (func (export "_start")
+    (i32.store (i32.const 0) (i32.const 16))  ;; iov_base
+    (i32.store (i32.const 4) (i32.const 152)) ;; iov_len
+    (drop (call $fd_write (i32.const 1) (i32.const 0) (i32.const 1) (i32.const 8)))))
@@ -0,0 +1,133 @@
+package discovery
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// ActivationCallback is called when a background activation started by
+// AddAsync finishes. err is nil if the server is now active.
+type ActivationCallback func(serverName string, err error)
+
+// SetActivationCallback sets the function told about finished background activations.
+func (e *DiscoveryEngine) SetActivationCallback(cb ActivationCallback) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.activationCallback = cb
+}
+
+// BeforeActivateHook runs before a server of the profile starts; an error
+// stops the activation.
+type BeforeActivateHook func(profileID, serverName string) error
+
+// SetBeforeActivateHook sets the function run before each server start.
+func (e *DiscoveryEngine) SetBeforeActivateHook(h BeforeActivateHook) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.beforeActivate = h
+}
+
+// AddAsync starts activating a server in the background and returns at once.
+// It reports false if the server is already active or being activated. The
+// activation callback is told the outcome; a failure is also kept for
+// scooter_list_active until the next attempt.
+func (e *DiscoveryEngine) AddAsync(serverName string) bool {
+	e.mu.Lock()
+	if _, ok := e.activeServers[serverName]; ok {
+		e.mu.Unlock()
+		return false
+	}
+	if _, ok := e.activating[serverName]; ok {
+		e.mu.Unlock()
+		return false
+	}
+	if _, ok := e.asyncStarts[serverName]; ok {
+		e.mu.Unlock()
+		return false
+	}
+	e.asyncStarts[serverName] = time.Now()
+	delete(e.activationFailures, serverName)
+	e.mu.Unlock()
+
+	go func() {
+		err := e.Add(serverName)
+
+		e.mu.Lock()
+		delete(e.asyncStarts, serverName)
+		if err != nil {
+			e.activationFailures[serverName] = err.Error()
+		}
+		cb := e.activationCallback
+		e.mu.Unlock()
+
+		if err != nil {
+			logger.AddLog("ERROR", fmt.Sprintf("[Discovery] Background activation of %s failed: %v", serverName, err))
+		} else {
+			logger.AddLog("INFO", fmt.Sprintf("[Discovery] Background activation of %s finished", serverName))
+		}
+		if cb != nil {
+			cb(serverName, err)
+		}
+	}()
+	return true
+}
+
+// inRegistry reports whether serverName has a registry entry.
+func (e *DiscoveryEngine) inRegistry(serverName string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, td := range e.registry {
+		if td.Name == serverName {
+			return true
+		}
+	}
+	return false
+}
+
+// ActivationStatus describes a server that is starting or failed to start
+// in the background.
+type ActivationStatus struct {
+	Server    string        `json:"server"`
+	Status    string        `json:"status"` // "activating" or "failed"
+	ElapsedMs int64         `json:"elapsed_ms,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	elapsed   time.Duration // for sorting
+}
+
+// PendingActivations lists servers that are still starting, followed by
+// background activations that failed.
+func (e *DiscoveryEngine) PendingActivations() []ActivationStatus {
+	e.mu.RLock()
+	now := time.Now()
+	starts := make(map[string]time.Time, len(e.activating)+len(e.asyncStarts))
+	for name, pending := range e.activating {
+		starts[name] = pending.started
+	}
+	for name, started := range e.asyncStarts {
+		if _, ok := starts[name]; !ok || started.Before(starts[name]) {
+			starts[name] = started
+		}
+	}
+	var statuses []ActivationStatus
+	for name, started := range starts {
+		elapsed := now.Sub(started)
+		statuses = append(statuses, ActivationStatus{
+			Server:    name,
+			Status:    "activating",
+			ElapsedMs: elapsed.Milliseconds(),
+			elapsed:   elapsed,
+		})
+	}
+	var failures []ActivationStatus
+	for name, msg := range e.activationFailures {
+		failures = append(failures, ActivationStatus{Server: name, Status: "failed", Error: msg})
+	}
+	e.mu.RUnlock()
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].elapsed > statuses[j].elapsed })
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Server < failures[j].Server })
+	return append(statuses, failures...)
+}
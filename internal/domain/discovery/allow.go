@@ -0,0 +1,74 @@
+package discovery
+
+import (
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+)
+
+// ResolveAllowTools expands a profile's AllowTools against defs. Server
+// names are kept as given, even ones defs lack; expressions such as brave-*
+// or category:search add the entries they match, in defs order. Builtin
+// tools are always available and never matched by expressions.
+func ResolveAllowTools(allow []string, defs []ToolDefinition) []string {
+	seen := make(map[string]bool)
+	var servers []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			servers = append(servers, name)
+		}
+	}
+	for _, entry := range allow {
+		if !profile.IsAllowExpression(entry) {
+			add(entry)
+			continue
+		}
+		for _, name := range MatchAllowEntry(entry, defs) {
+			add(name)
+		}
+	}
+	return servers
+}
+
+// MatchAllowEntry returns the registry entries in defs an AllowTools entry
+// matches.
+func MatchAllowEntry(entry string, defs []ToolDefinition) []string {
+	var names []string
+	for _, td := range defs {
+		if td.Source == "builtin" {
+			continue
+		}
+		if profile.MatchAllowEntry(entry, td.Name, td.Category, td.Tags) {
+			names = append(names, td.Name)
+		}
+	}
+	return names
+}
+
+// IsAllowed reports whether AllowTools entries allow serverName, resolving
+// expressions against the engine's registry as it is now.
+func (e *DiscoveryEngine) IsAllowed(allow []string, serverName string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, entry := range allow {
+		if entry == serverName {
+			return true
+		}
+		if !profile.IsAllowExpression(entry) {
+			continue
+		}
+		for _, td := range e.registry {
+			if td.Name == serverName && td.Source != "builtin" && profile.MatchAllowEntry(entry, td.Name, td.Category, td.Tags) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AllowedServers resolves AllowTools entries against the engine's registry
+// (see ResolveAllowTools).
+func (e *DiscoveryEngine) AllowedServers(allow []string) []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return ResolveAllowTools(allow, e.registry)
+}
@@ -0,0 +1,52 @@
+package discovery
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CredentialsDeepLink opens the desktop app's credential form for a tool.
+const CredentialsDeepLink = "scooter://settings/credentials?tool="
+
+// AuthRequiredError is returned when a server can't be activated because
+// credentials its registry entry marks as required are not configured.
+type AuthRequiredError struct {
+	Server      string
+	DisplayName string
+	HelpURL     string
+	Missing     []string
+}
+
+func (e *AuthRequiredError) Error() string {
+	msg := fmt.Sprintf("%s needs credentials that are not configured (%s). Add them in MCP Scooter: %s",
+		e.Server, strings.Join(e.Missing, ", "), e.DeepLink())
+	if e.HelpURL != "" {
+		msg += fmt.Sprintf(". Get them at %s", e.HelpURL)
+	}
+	return msg
+}
+
+// DeepLink returns the scooter:// link that opens the credential form
+// pre-filled for the server.
+func (e *AuthRequiredError) DeepLink() string {
+	return CredentialsDeepLink + url.QueryEscape(e.Server)
+}
+
+// checkCredentials returns an AuthRequiredError if the server's required
+// credentials are missing from both the keychain and the profile env.
+func (e *DiscoveryEngine) checkCredentials(td *ToolDefinition) error {
+	if e.credentials == nil || td.Authorization == nil || !td.Authorization.Required {
+		return nil
+	}
+	status := e.credentials.ResolveAuth(td.Name, td.Authorization, e.env)
+	if status.HasRequired {
+		return nil
+	}
+	return &AuthRequiredError{
+		Server:      td.Name,
+		DisplayName: status.DisplayName,
+		HelpURL:     status.HelpURL,
+		Missing:     status.Missing,
+	}
+}
@@ -3,13 +3,17 @@ package discovery
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/mcp-scooter/scooter/internal/domain/integration"
 	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/i18n"
 	"github.com/mcp-scooter/scooter/internal/logger"
 )
 
@@ -22,6 +26,11 @@ import (
 // - scooter_activate: Turn on a tool server for the current session
 // - scooter_deactivate: Turn off a tool server
 // - scooter_list_active: List currently active tool servers
+// - scooter_workspace: Inspect and clean up the profile's scratch directory
+// - scooter_env: Show the environment a server would be started with
+// - scooter_history: Recent tool calls of the session (opt-in, answered by the gateway)
+// - scooter_result: Read a result that was truncated or summarised for size
+// - scooter_expand: Full definition of a tool minified in tools/list
 //
 // Note: scooter_ai (AI-powered intent routing) is planned for a future release.
 func PrimordialTools() []ToolDefinition {
@@ -36,7 +45,7 @@ func PrimordialTools() []ToolDefinition {
 			Tools: []registry.Tool{
 				{
 					Name:        "scooter_find",
-					Description: "Search the Local Registry and Community Catalog for MCP tools. Returns tool names, descriptions, and available sub-tools. Use this to discover what tools can be activated.",
+					Description: "Search the Local Registry and Community Catalog for MCP tools. Returns tool names, descriptions, and available sub-tools, plus whether each server is already active, which credentials it needs and whether they are configured, how long its last activation took, and its reliability: the share of activations that succeeded. Use this to discover what tools can be activated.",
 					InputSchema: &registry.JSONSchema{
 						Type: "object",
 						Properties: map[string]registry.PropertySchema{
@@ -44,6 +53,34 @@ func PrimordialTools() []ToolDefinition {
 								Type:        "string",
 								Description: "Search query to find tools (e.g., 'search', 'database', 'github'). Leave empty to list all available tools.",
 							},
+							"category": {
+								Type:        "string",
+								Description: "Only return tools in this category (e.g., 'search', 'database', 'development').",
+							},
+							"tags": {
+								Type:        "array",
+								Description: "Only return tools that have all of these tags.",
+								Items:       &registry.PropertySchema{Type: "string"},
+							},
+							"limit": {
+								Type:        "integer",
+								Description: fmt.Sprintf("Maximum number of tools to return (default %d, max %d). Narrow the query instead of raising this for large catalogs.", defaultFindLimit, maxFindLimit),
+								Minimum:     intPtr(1),
+								Maximum:     intPtr(maxFindLimit),
+							},
+							"include_deprecated": {
+								Type:        "boolean",
+								Description: "If true, also return deprecated tools. Deprecated tools are hidden by default.",
+							},
+							"min_reliability": {
+								Type:        "number",
+								Description: "Only return tools whose share of successful activations is at least this (0-1). Tools never activated have no reliability and are left out.",
+							},
+							"sort": {
+								Type:        "string",
+								Description: "Order of the results: 'relevance' (default) or 'reliability' for the most reliable tools first.",
+								Enum:        []string{"relevance", "reliability"},
+							},
 						},
 					},
 				},
@@ -67,13 +104,14 @@ func PrimordialTools() []ToolDefinition {
 								Type:        "string",
 								Description: "The name of the tool/server to activate (e.g., 'brave-search', 'github'). Use the server name, not individual function names.",
 							},
+							"tool_names": toolNamesProperty,
+							"async":      asyncProperty,
 						},
-						Required: []string{"tool_name"},
 					},
 				},
 				{
 					Name:        "scooter_add",
-					Description: "Alias for scooter_activate. Turn on an MCP tool server for the current session.",
+					Description: "Alias for scooter_activate. Turn on an MCP tool server for the current session. Pass tool_names to turn on several at once.",
 					InputSchema: &registry.JSONSchema{
 						Type: "object",
 						Properties: map[string]registry.PropertySchema{
@@ -81,8 +119,9 @@ func PrimordialTools() []ToolDefinition {
 								Type:        "string",
 								Description: "The name of the tool/server to activate (e.g., 'brave-search', 'github').",
 							},
+							"tool_names": toolNamesProperty,
+							"async":      asyncProperty,
 						},
-						Required: []string{"tool_name"},
 					},
 				},
 			},
@@ -132,31 +171,227 @@ func PrimordialTools() []ToolDefinition {
 				},
 			},
 		},
+		{
+			Name:        "scooter_status",
+			Title:       "Status",
+			Description: "Report the health of active tool servers, queued calls, recent errors and context use.",
+			Category:    "system",
+			Source:      "builtin",
+			Installed:   true,
+			Tools: []registry.Tool{
+				{
+					Name:        "scooter_status",
+					Description: "Check Scooter's health: each server's state (ready, starting, unhealthy, failed), calls waiting on servers, the last errors, and an estimate of how many tokens the current tool list uses against the context budget. Use it when calls fail or hang, or before activating more servers.",
+					InputSchema: &registry.JSONSchema{
+						Type:       "object",
+						Properties: map[string]registry.PropertySchema{},
+					},
+					Annotations: &registry.ToolAnnotations{ReadOnlyHint: true},
+				},
+			},
+		},
+		{
+			Name:        "scooter_workspace",
+			Title:       "Workspace",
+			Description: "Inspect and clean up the profile's scratch directory.",
+			Category:    "system",
+			Source:      "builtin",
+			Installed:   true,
+			Tools: []registry.Tool{
+				{
+					Name:        "scooter_workspace",
+					Description: "Manage this profile's scratch directory, where tools put downloads and temporary files (servers see it as $SCOOTER_WORKSPACE). Files are pruned automatically when they get old or the workspace exceeds its quota.",
+					InputSchema: &registry.JSONSchema{
+						Type: "object",
+						Properties: map[string]registry.PropertySchema{
+							"action": {
+								Type:        "string",
+								Description: "'info' (default) shows usage and quota, 'list' also lists files, 'delete' removes one path, 'prune' applies retention and quota now, 'clear' removes everything.",
+								Enum:        []string{"info", "list", "delete", "prune", "clear"},
+							},
+							"path": {
+								Type:        "string",
+								Description: "Path relative to the workspace, for 'delete'.",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "scooter_history",
+			Title:       "Call History",
+			Description: "List the tool calls made earlier in this session.",
+			Category:    "system",
+			Source:      "builtin",
+			Installed:   true,
+			Tools: []registry.Tool{
+				{
+					Name:        "scooter_history",
+					Description: "List the latest tool calls of this session, newest first: tool name, truncated arguments, whether it succeeded and when. Use it to check what you already did before repeating a call.",
+					InputSchema: &registry.JSONSchema{
+						Type: "object",
+						Properties: map[string]registry.PropertySchema{
+							"limit": {
+								Type:        "integer",
+								Description: "How many calls to return (default 10, at most 50).",
+							},
+						},
+					},
+					Annotations: &registry.ToolAnnotations{ReadOnlyHint: true},
+				},
+			},
+		},
+		{
+			Name:        "scooter_env",
+			Title:       "Environment Preview",
+			Description: "Show the environment variables a server would be started with and where each comes from.",
+			Category:    "system",
+			Source:      "builtin",
+			Installed:   true,
+			Tools: []registry.Tool{
+				{
+					Name:        "scooter_env",
+					Description: "Show the environment variables a server would be started with, without starting it: the registry's runtime env, the workspace, the profile env and keychain credentials, in increasing precedence. Secret values are masked. Use it when a server doesn't pick up an API key.",
+					InputSchema: &registry.JSONSchema{
+						Type: "object",
+						Properties: map[string]registry.PropertySchema{
+							"tool_name": {
+								Type:        "string",
+								Description: "The server name, e.g. 'github'.",
+							},
+						},
+						Required: []string{"tool_name"},
+					},
+					Annotations: &registry.ToolAnnotations{ReadOnlyHint: true},
+				},
+			},
+		},
+		{
+			Name:        "scooter_result",
+			Title:       "Read Full Result",
+			Description: "Page through a tool result that was truncated or summarised because it exceeded the profile's size budget.",
+			Category:    "system",
+			Source:      "builtin",
+			Installed:   true,
+			Tools: []registry.Tool{
+				{
+					Name:        "scooter_result",
+					Description: "Read part of a tool result that was truncated or summarised because it was too large. Pass the handle from the shortened result; use next_offset from each response to continue.",
+					InputSchema: &registry.JSONSchema{
+						Type: "object",
+						Properties: map[string]registry.PropertySchema{
+							"handle": {
+								Type:        "string",
+								Description: "The result handle, e.g. 'res_1a2b3c4d5e6f7a8b'.",
+							},
+							"offset": {
+								Type:        "integer",
+								Description: "Byte offset to start reading at (default 0).",
+							},
+							"length": {
+								Type:        "integer",
+								Description: "Maximum bytes to return (default: the profile's max_result_bytes).",
+							},
+						},
+						Required: []string{"handle"},
+					},
+					Annotations: &registry.ToolAnnotations{ReadOnlyHint: true},
+				},
+			},
+		},
+		{
+			Name:        "scooter_expand",
+			Title:       "Expand Tool Schema",
+			Description: "Show the full definition of a tool whose schema was shortened in tools/list.",
+			Category:    "system",
+			Source:      "builtin",
+			Installed:   true,
+			Tools: []registry.Tool{
+				{
+					Name:        "scooter_expand",
+					Description: "Return the full definition of an active tool: complete descriptions, every enum value, the sample input and all annotations. Use it when a shortened tool description in the tool list isn't enough to call the tool correctly.",
+					InputSchema: &registry.JSONSchema{
+						Type: "object",
+						Properties: map[string]registry.PropertySchema{
+							"tool_name": {
+								Type:        "string",
+								Description: "The tool name, e.g. 'create_issue'.",
+							},
+						},
+						Required: []string{"tool_name"},
+					},
+					Annotations: &registry.ToolAnnotations{ReadOnlyHint: true},
+				},
+			},
+		},
 	}
 }
 
 // HandleBuiltinTool handles calls to the primordial tools.
-// Simplified to just 4 core tools: scooter_find, scooter_activate, scooter_deactivate, scooter_list_active
+// Core tools: scooter_find, scooter_activate, scooter_deactivate, scooter_list_active, scooter_workspace, scooter_env, scooter_result, scooter_expand
 func (e *DiscoveryEngine) HandleBuiltinTool(name string, params map[string]interface{}) (interface{}, error) {
 	e.mu.RLock()
 	isDisabled := e.disabledTools[name]
 	e.mu.RUnlock()
 
 	if isDisabled {
-		return nil, fmt.Errorf("tool is disabled: %s", name)
+		return nil, errors.New(e.msg("builtin.tool_disabled", name))
 	}
 
 	switch name {
 	case "scooter_find":
 		query, _ := params["query"].(string)
-		results := e.Find(query)
+		category, _ := params["category"].(string)
+		includeDeprecated, _ := params["include_deprecated"].(bool)
+		limit := defaultFindLimit
+		if l, ok := params["limit"].(float64); ok && l >= 1 {
+			limit = int(l)
+		}
+		if limit > maxFindLimit {
+			limit = maxFindLimit
+		}
+		results := e.Search(SearchOptions{Query: query, Category: category, Tags: stringList(params["tags"])})
+		reliability := e.ActivationMetrics().All()
+		filter := ReliabilityFilter{}
+		if min, ok := params["min_reliability"].(float64); ok {
+			filter.Min = &min
+		}
+		if sortBy, _ := params["sort"].(string); sortBy == "reliability" {
+			filter.Sort = "reliability"
+		}
+		results = filter.Apply(results, reliability)
+
+		// Snapshot what the hints below need so each entry doesn't take the lock
+		e.mu.RLock()
+		active := make(map[string]bool, len(e.activeServers))
+		for serverName := range e.activeServers {
+			active[serverName] = true
+		}
+		activationTimes := make(map[string]time.Duration, len(e.activationTimes))
+		for serverName, d := range e.activationTimes {
+			activationTimes[serverName] = d
+		}
+		env := e.env
+		credentials := e.credentials
+		e.mu.RUnlock()
 		
 		// Format results to show available tools for each server
 		formatted := make([]map[string]interface{}, 0, len(results))
+		total := 0
 		for _, td := range results {
 			if td.Source == "builtin" {
 				continue // Skip builtins in find results - they're always available
 			}
+
+			deprecation := td.DeprecationNotice()
+			if deprecation != "" && !includeDeprecated {
+				continue
+			}
+			total++
+			if len(formatted) >= limit {
+				continue // Keep counting so the agent knows there is more
+			}
 			
 			toolNames := make([]string, 0, len(td.Tools))
 			for _, t := range td.Tools {
@@ -181,6 +416,33 @@ func (e *DiscoveryEngine) HandleBuiltinTool(name string, params map[string]inter
 				"tools":       toolNames,
 				"source":      string(td.Source),
 			}
+			if deprecation != "" {
+				entry["deprecated"] = true
+				entry["deprecation_message"] = deprecation
+			}
+
+			// Hints so agents can tell up front whether activation will work and how long it takes
+			entry["active"] = active[td.Name]
+			entry["requires_auth"] = false
+			if td.Authorization != nil && td.Authorization.Type != registry.AuthNone && credentials != nil {
+				status := credentials.ResolveAuth(td.Name, td.Authorization, env)
+				entry["requires_auth"] = status.Required
+				entry["auth"] = findAuthHint(status)
+			}
+			if d, ok := activationTimes[td.Name]; ok {
+				entry["activation_ms"] = d.Milliseconds()
+			}
+			if stats, ok := reliability[td.Name]; ok {
+				entry["reliability"] = stats.Reliability
+				entry["activation_attempts"] = stats.Attempts
+				if stats.MedianStartupMs > 0 {
+					entry["median_activation_ms"] = stats.MedianStartupMs
+				}
+				if stats.Quarantined {
+					// scooter_add refuses it until the user unquarantines it
+					entry["quarantined"] = stats.QuarantineReason
+				}
+			}
 			
 			// Log for debugging
 			logger.AddLog("DEBUG", fmt.Sprintf("scooter_find: adding tool %s", td.Name))
@@ -189,74 +451,25 @@ func (e *DiscoveryEngine) HandleBuiltinTool(name string, params map[string]inter
 		}
 		
 		// Return as a map with a key to be more standard
-		return map[string]interface{}{
+		response := map[string]interface{}{
 			"tools": formatted,
-		}, nil
-		
-	case "scooter_activate", "scooter_add":
-		tool, ok := params["tool_name"].(string)
-		if !ok {
-			return nil, fmt.Errorf("tool_name is required")
+			"total": total,
 		}
-		
-		// Check if tool is already active (already "on")
-		activeServers := e.ListActive()
-		alreadyActive := false
-		for _, s := range activeServers {
-			if s == tool {
-				alreadyActive = true
-				break
-			}
-		}
-
-		if alreadyActive {
-			// Get the tools that are already available
-			availableTools := e.GetActiveToolsForServer(tool)
-			toolNames := make([]string, 0, len(availableTools))
-			toolSchemas := make([]map[string]interface{}, 0, len(availableTools))
-			for _, t := range availableTools {
-				toolNames = append(toolNames, t.Name)
-				toolSchema := buildToolSchema(t)
-				toolSchemas = append(toolSchemas, toolSchema)
-			}
-			
-		// Build clear instructions for calling tools directly
-		return map[string]interface{}{
-			"status":          "already_active",
-			"activated_from":  tool,
-			"available_tools": toolNames,
-			"tool_count":      len(toolNames),
-			"tool_schemas":    toolSchemas,
-			"next_step":       fmt.Sprintf("Call any of these tools DIRECTLY by name: %v", toolNames),
-			"important":       "Do NOT use 'scooter_call'. Just call the tool directly, e.g., brave_web_search({\"query\": \"...\"})",
-		}, nil
-		}
-
-		err := e.Add(tool)
-		if err != nil {
-			return nil, err
+		if total > len(formatted) {
+			response["truncated"] = true
 		}
+		return response, nil
 		
-		// Get the tools that are now available from this server
-		availableTools := e.GetActiveToolsForServer(tool)
-		toolNames := make([]string, 0, len(availableTools))
-		toolSchemas := make([]map[string]interface{}, 0, len(availableTools))
-		for _, t := range availableTools {
-			toolNames = append(toolNames, t.Name)
-			toolSchema := buildToolSchema(t)
-			toolSchemas = append(toolSchemas, toolSchema)
+	case "scooter_activate", "scooter_add":
+		targets := ActivationTargets(params)
+		if len(targets) == 0 {
+			return nil, errors.New(e.msg("builtin.tool_name_required"))
 		}
-		
-		// Build clear instructions for calling tools directly
-		return map[string]interface{}{
-			"status":          "activated",
-			"activated_from":  tool,
-			"available_tools": toolNames,
-			"tool_count":      len(toolNames),
-			"tool_schemas":    toolSchemas,
-			"next_step":       fmt.Sprintf("Call any of these tools DIRECTLY by name: %v", toolNames),
-			"important":       "Do NOT use 'scooter_call'. Just call the tool directly, e.g., brave_web_search({\"query\": \"...\"})",
-		}, nil
+		async, _ := params["async"].(bool)
+		if _, batch := params["tool_names"]; !batch && len(targets) == 1 {
+			return e.activateServer(targets[0], async)
+		}
+		return e.activateServers(targets, async), nil
 
 	case "scooter_deactivate":
 		all, _ := params["all"].(bool)
@@ -269,12 +482,12 @@ func (e *DiscoveryEngine) HandleBuiltinTool(name string, params map[string]inter
 			}
 			return map[string]interface{}{
 				"status":  "off",
-				"message": "All tool servers have been deactivated.",
+				"message": e.msg("builtin.deactivate.all"),
 			}, nil
 		}
 
 		if tool == "" {
-			return nil, fmt.Errorf("tool_name is required unless 'all' is true")
+			return nil, errors.New(e.msg("builtin.tool_name_or_all"))
 		}
 
 		err := e.Remove(tool)
@@ -285,7 +498,7 @@ func (e *DiscoveryEngine) HandleBuiltinTool(name string, params map[string]inter
 		return map[string]interface{}{
 			"status":  "off",
 			"server":  tool,
-			"message": fmt.Sprintf("Server '%s' has been deactivated.", tool),
+			"message": e.msg("builtin.deactivate.one", tool),
 		}, nil
 
 	case "scooter_list_active":
@@ -305,17 +518,268 @@ func (e *DiscoveryEngine) HandleBuiltinTool(name string, params map[string]inter
 			})
 		}
 
-		return map[string]interface{}{
+		result := map[string]interface{}{
 			"active_servers": activeInfo,
 			"count":          len(activeServers),
-		}, nil
+		}
+		// Servers started with async: true that aren't ready (or failed)
+		if pending := e.PendingActivations(); len(pending) > 0 {
+			result["pending"] = pending
+		}
+		return result, nil
+
+	case "scooter_status":
+		return e.statusReport(), nil
+
+	case "scooter_workspace":
+		return e.handleWorkspaceTool(params)
+
+	case "scooter_env":
+		serverName, _ := params["tool_name"].(string)
+		if serverName == "" {
+			return nil, errors.New(e.msg("builtin.env.tool_name_required"))
+		}
+		vars, err := e.PreviewEnv(serverName)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"server": serverName, "env": vars}, nil
+
+	case "scooter_result":
+		return e.handleResultTool(params)
+
+	case "scooter_expand":
+		return e.handleExpandTool(params)
 
 	default:
-		return nil, fmt.Errorf("unknown builtin tool: %s", name)
+		return nil, errors.New(e.msg("builtin.unknown_builtin_tool", name))
 	}
 }
 
 
+// msg returns a builtin tool message in the configured locale.
+const (
+	defaultFindLimit = 20
+	maxFindLimit     = 100
+)
+
+func intPtr(n int) *int { return &n }
+
+// stringList accepts a JSON array of strings or a comma-separated string.
+func stringList(v interface{}) []string {
+	var out []string
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+	case []string:
+		out = v
+	case string:
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// findAuthHint condenses an AuthStatus into what scooter_find reports: the
+// credentials a server reads and whether each one is already configured.
+func findAuthHint(status integration.AuthStatus) map[string]interface{} {
+	creds := make([]map[string]interface{}, 0, len(status.Credentials))
+	for _, c := range status.Credentials {
+		cred := map[string]interface{}{
+			"env_var":    c.Name,
+			"required":   c.Required,
+			"configured": c.Source != integration.SourceMissing,
+		}
+		if c.DisplayName != "" {
+			cred["display_name"] = c.DisplayName
+		}
+		creds = append(creds, cred)
+	}
+	hint := map[string]interface{}{
+		"type":               string(status.Type),
+		"credentials":        creds,
+		"credentials_stored": status.HasRequired,
+	}
+	if status.DisplayName != "" {
+		hint["display_name"] = status.DisplayName
+	}
+	if len(status.Missing) > 0 {
+		hint["missing"] = status.Missing
+	}
+	return hint
+}
+
+// toolNamesProperty lets scooter_activate and scooter_add start several
+// servers in one call.
+var toolNamesProperty = registry.PropertySchema{
+	Type:        "array",
+	Description: "Several tool/server names to activate at once, concurrently. Each gets its own result; one failing doesn't stop the others.",
+	Items:       &registry.PropertySchema{Type: "string"},
+}
+
+// asyncProperty lets agents start slow servers without waiting for them.
+var asyncProperty = registry.PropertySchema{
+	Type:        "boolean",
+	Description: "If true, return immediately with status 'activating'. A tools/list_changed notification follows once the server is ready; scooter_list_active shows progress.",
+}
+
+// ActivationTargets returns the servers a scooter_activate or scooter_add
+// call names: tool_name as a string or an array, plus any tool_names.
+// Duplicates and empty names are dropped.
+func ActivationTargets(params map[string]interface{}) []string {
+	var targets []string
+	seen := map[string]bool{}
+	add := func(v interface{}) {
+		switch v := v.(type) {
+		case string:
+			if v != "" && !seen[v] {
+				seen[v] = true
+				targets = append(targets, v)
+			}
+		case []interface{}:
+			for _, item := range v {
+				if name, ok := item.(string); ok && name != "" && !seen[name] {
+					seen[name] = true
+					targets = append(targets, name)
+				}
+			}
+		case []string:
+			for _, name := range v {
+				if name != "" && !seen[name] {
+					seen[name] = true
+					targets = append(targets, name)
+				}
+			}
+		}
+	}
+	add(params["tool_name"])
+	add(params["tool_names"])
+	return targets
+}
+
+// activateServer activates one server and describes the tools it provides.
+// With async set it only starts the activation, unless the server is
+// already active.
+func (e *DiscoveryEngine) activateServer(tool string, async bool) (map[string]interface{}, error) {
+	status := "activated"
+	if e.IsActive(tool) {
+		status = "already_active"
+	} else if async {
+		if !e.inRegistry(tool) {
+			return nil, fmt.Errorf("server not found in registry: %s", tool)
+		}
+		e.AddAsync(tool)
+		return map[string]interface{}{
+			"status":         "activating",
+			"activated_from": tool,
+			"next_step":      e.msg("builtin.activate.async", tool),
+		}, nil
+	} else if err := e.Add(tool); err != nil {
+		return nil, err
+	}
+
+	// Get the tools that are now available from this server
+	availableTools := e.GetActiveToolsForServer(tool)
+	toolNames := make([]string, 0, len(availableTools))
+	toolSchemas := make([]map[string]interface{}, 0, len(availableTools))
+	for _, t := range availableTools {
+		toolNames = append(toolNames, t.Name)
+		toolSchemas = append(toolSchemas, buildToolSchema(t))
+	}
+
+	// Build clear instructions for calling tools directly
+	result := map[string]interface{}{
+		"status":          status,
+		"activated_from":  tool,
+		"available_tools": toolNames,
+		"tool_count":      len(toolNames),
+		"tool_schemas":    toolSchemas,
+		"next_step":       e.msg("builtin.activate.next_step", toolNames),
+		"important":       e.msg("builtin.activate.important"),
+	}
+	if notice := e.DeprecationNotice(tool); notice != "" {
+		if status == "activated" {
+			logger.AddLog("WARN", fmt.Sprintf("Activated deprecated server '%s'", tool))
+		}
+		result["deprecation_warning"] = notice
+	}
+	return result, nil
+}
+
+// activateServers activates several servers concurrently. A failure doesn't
+// stop the others; each server gets its own entry in "results".
+func (e *DiscoveryEngine) activateServers(tools []string, async bool) map[string]interface{} {
+	results := make([]map[string]interface{}, len(tools))
+	var wg sync.WaitGroup
+	for i, tool := range tools {
+		wg.Add(1)
+		go func(i int, tool string) {
+			defer wg.Done()
+			result, err := e.activateServer(tool, async)
+			if err != nil {
+				result = map[string]interface{}{
+					"status":         "failed",
+					"activated_from": tool,
+					"error":          err.Error(),
+				}
+			}
+			results[i] = result
+		}(i, tool)
+	}
+	wg.Wait()
+
+	var allTools []string
+	failed, pending := 0, 0
+	for _, result := range results {
+		switch result["status"] {
+		case "failed":
+			failed++
+			continue
+		case "activating":
+			pending++
+			continue
+		}
+		if names, ok := result["available_tools"].([]string); ok {
+			allTools = append(allTools, names...)
+		}
+	}
+
+	status := "activated"
+	switch {
+	case failed == len(tools):
+		status = "failed"
+	case failed > 0:
+		status = "partial"
+	case pending > 0:
+		status = "activating"
+	}
+	response := map[string]interface{}{
+		"status":    status,
+		"results":   results,
+		"succeeded": len(tools) - failed,
+		"failed":    failed,
+	}
+	if len(allTools) > 0 {
+		response["next_step"] = e.msg("builtin.activate.next_step", allTools)
+		response["important"] = e.msg("builtin.activate.important")
+	}
+	return response
+}
+
+func (e *DiscoveryEngine) msg(key string, args ...interface{}) string {
+	e.mu.RLock()
+	locale := e.settings.Locale
+	e.mu.RUnlock()
+	return i18n.T(locale, key, args...)
+}
+
 // buildToolSchema creates a comprehensive schema for a tool that agents can use to understand
 // how to call it correctly. This includes the full input schema with types, descriptions,
 // required fields, and constraints.
@@ -404,13 +868,13 @@ func buildToolSchema(t registry.Tool) map[string]interface{} {
 // getAIRoutingCredentials retrieves AI routing credentials from keychain.
 func (e *DiscoveryEngine) getAIRoutingCredentials() (provider, model, key string, isFallback bool) {
 	// Try primary first
-	primaryKey, err := e.credentials.GetCredential("mcp-scooter:ai_primary", "MCP_SCOOTER_PRIMARY_AI_KEY")
+	primaryKey, err := e.credentials.Instance().GetCredential("mcp-scooter:ai_primary", "MCP_SCOOTER_PRIMARY_AI_KEY")
 	if err == nil && e.settings.PrimaryAIProvider != "" {
 		return e.settings.PrimaryAIProvider, e.settings.PrimaryAIModel, primaryKey, false
 	}
 
 	// Try fallback
-	fallbackKey, err := e.credentials.GetCredential("mcp-scooter:ai_fallback", "MCP_SCOOTER_FALLBACK_AI_KEY")
+	fallbackKey, err := e.credentials.Instance().GetCredential("mcp-scooter:ai_fallback", "MCP_SCOOTER_FALLBACK_AI_KEY")
 	if err == nil && e.settings.FallbackAIProvider != "" {
 		return e.settings.FallbackAIProvider, e.settings.FallbackAIModel, fallbackKey, true
 	}
@@ -418,16 +882,20 @@ func (e *DiscoveryEngine) getAIRoutingCredentials() (provider, model, key string
 	return "", "", "", false
 }
 
-// callInternalAI calls the appropriate AI provider.
-func (e *DiscoveryEngine) callInternalAI(provider, model, key, prompt string) (map[string]interface{}, error) {
+// routerSystemPrompt is the system message of intent routing requests.
+const routerSystemPrompt = "You are a JSON-only tool router. Return only valid JSON objects."
+
+// callInternalAI calls the appropriate AI provider. maxTokens caps the
+// response length; 0 leaves it to the provider.
+func (e *DiscoveryEngine) callInternalAI(provider, model, key, system, prompt string, maxTokens int) (map[string]interface{}, error) {
 	var response map[string]interface{}
 	var err error
 
 	switch provider {
 	case "gemini":
-		response, err = e.callGemini(model, key, prompt)
+		response, err = e.callGemini(model, key, system, prompt, maxTokens)
 	case "openrouter":
-		response, err = e.callOpenRouter(model, key, prompt)
+		response, err = e.callOpenRouter(model, key, system, prompt, maxTokens)
 	default:
 		return nil, fmt.Errorf("unsupported AI provider: %s", provider)
 	}
@@ -436,7 +904,7 @@ func (e *DiscoveryEngine) callInternalAI(provider, model, key, prompt string) (m
 }
 
 // callGemini calls the Gemini API.
-func (e *DiscoveryEngine) callGemini(model, key, prompt string) (map[string]interface{}, error) {
+func (e *DiscoveryEngine) callGemini(model, key, system, prompt string, maxTokens int) (map[string]interface{}, error) {
 	// Build request payload for Gemini API
 	reqBody := map[string]interface{}{
 		"contents": []map[string]interface{}{
@@ -447,6 +915,14 @@ func (e *DiscoveryEngine) callGemini(model, key, prompt string) (map[string]inte
 			},
 		},
 	}
+	if system != "" {
+		reqBody["systemInstruction"] = map[string]interface{}{
+			"parts": []map[string]interface{}{{"text": system}},
+		}
+	}
+	if maxTokens > 0 {
+		reqBody["generationConfig"] = map[string]interface{}{"maxOutputTokens": maxTokens}
+	}
 
 	jsonData, _ := json.Marshal(reqBody)
 	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", model, key)
@@ -472,15 +948,18 @@ func (e *DiscoveryEngine) callGemini(model, key, prompt string) (map[string]inte
 }
 
 // callOpenRouter calls the OpenRouter API (OpenAI-compatible).
-func (e *DiscoveryEngine) callOpenRouter(model, key, prompt string) (map[string]interface{}, error) {
+func (e *DiscoveryEngine) callOpenRouter(model, key, system, prompt string, maxTokens int) (map[string]interface{}, error) {
 	// Build request payload for OpenAI-compatible API
 	reqBody := map[string]interface{}{
 		"model": model,
 		"messages": []map[string]interface{}{
-			{"role": "system", "content": "You are a JSON-only tool router. Return only valid JSON objects."},
+			{"role": "system", "content": system},
 			{"role": "user", "content": prompt},
 		},
 	}
+	if maxTokens > 0 {
+		reqBody["max_tokens"] = maxTokens
+	}
 
 	jsonData, _ := json.Marshal(reqBody)
 	url := "https://openrouter.ai/api/v1/chat/completions"
@@ -509,6 +988,40 @@ func (e *DiscoveryEngine) callOpenRouter(model, key, prompt string) (map[string]
 	return orResp, nil
 }
 
+// aiResponseText extracts the generated text from a provider response, or ""
+// if there is none.
+func aiResponseText(provider string, response map[string]interface{}) string {
+	if provider == "openrouter" {
+		// OpenRouter returns { "choices": [ { "message": { "content": "..." } } ] }
+		if choices, ok := response["choices"].([]interface{}); ok && len(choices) > 0 {
+			if choice, ok := choices[0].(map[string]interface{}); ok {
+				if message, ok := choice["message"].(map[string]interface{}); ok {
+					if content, ok := message["content"].(string); ok {
+						return content
+					}
+				}
+			}
+		}
+		return ""
+	}
+
+	// Gemini returns { "candidates": [ { "content": { "parts": [ { "text": "..." } ] } } ] }
+	if candidates, ok := response["candidates"].([]interface{}); ok && len(candidates) > 0 {
+		if candidate, ok := candidates[0].(map[string]interface{}); ok {
+			if content, ok := candidate["content"].(map[string]interface{}); ok {
+				if parts, ok := content["parts"].([]interface{}); ok && len(parts) > 0 {
+					if part, ok := parts[0].(map[string]interface{}); ok {
+						if text, ok := part["text"].(string); ok {
+							return text
+						}
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
 // handleSemanticDispatch uses AI to route user intent to appropriate tool.
 func (e *DiscoveryEngine) handleSemanticDispatch(intent string) (interface{}, error) {
 	// Get AI routing credentials
@@ -537,13 +1050,13 @@ func (e *DiscoveryEngine) handleSemanticDispatch(intent string) (interface{}, er
 	)
 
 	// Try primary provider first
-	response, err := e.callInternalAI(provider, model, key, prompt)
+	response, err := e.callInternalAI(provider, model, key, routerSystemPrompt, prompt, 0)
 	if err != nil {
 		// Try fallback if primary fails
 		logger.AddLog("ERROR", fmt.Sprintf("Primary AI provider failed: %v, trying fallback", err))
 		provider, model, key, _ = e.getAIRoutingCredentials()
 		if key != "" {
-			response, err = e.callInternalAI(provider, model, key, prompt)
+			response, err = e.callInternalAI(provider, model, key, routerSystemPrompt, prompt, 0)
 			if err != nil {
 				return nil, fmt.Errorf("both primary and fallback AI providers failed: %w", err)
 			}
@@ -553,36 +1066,7 @@ func (e *DiscoveryEngine) handleSemanticDispatch(intent string) (interface{}, er
 	// Parse JSON response from AI
 	var routingDecision map[string]interface{}
 
-	// Handle different response formats
-	var jsonText string
-	if provider == "openrouter" {
-		// OpenRouter returns { "choices": [ { "message": { "content": "..." } } ] }
-		if choices, ok := response["choices"].([]interface{}); ok && len(choices) > 0 {
-			if choice, ok := choices[0].(map[string]interface{}); ok {
-				if message, ok := choice["message"].(map[string]interface{}); ok {
-					if content, ok := message["content"].(string); ok {
-						jsonText = content
-					}
-				}
-			}
-		}
-	} else {
-		// Gemini returns { "candidates": [ { "content": { "parts": [ { "text": "..." } ] } } ] }
-		if candidates, ok := response["candidates"].([]interface{}); ok && len(candidates) > 0 {
-			if candidate, ok := candidates[0].(map[string]interface{}); ok {
-				if content, ok := candidate["content"].(map[string]interface{}); ok {
-					if parts, ok := content["parts"].([]interface{}); ok && len(parts) > 0 {
-						if part, ok := parts[0].(map[string]interface{}); ok {
-							if text, ok := part["text"].(string); ok {
-								jsonText = text
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-
+	jsonText := aiResponseText(provider, response)
 	if jsonText == "" {
 		return nil, fmt.Errorf("failed to extract JSON response from AI provider")
 	}
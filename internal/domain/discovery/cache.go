@@ -0,0 +1,151 @@
+package discovery
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Results of tools annotated readOnlyHint or idempotentHint are reused for
+// the TTL their entry's runtime.cache sets, keyed by server, tool and
+// arguments. Only successful results are cached, per engine so profiles
+// never see each other's results. A server's results are dropped when it
+// stops, and DELETE /api/cache drops them on demand.
+
+// maxCacheEntries bounds an engine's cache; the entry closest to expiring
+// is evicted first.
+const maxCacheEntries = 1000
+
+// CacheStats counts an engine's response cache lookups.
+type CacheStats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Entries int   `json:"entries"`
+}
+
+// cachedResult keeps a result encoded, so callers each get their own copy.
+type cachedResult struct {
+	result  []byte
+	expires time.Time
+}
+
+// resultCache is guarded by its own mutex so lookups don't contend with e.mu.
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResult
+	hits    int64
+	misses  int64
+}
+
+func newResultCache() *resultCache {
+	return &resultCache{entries: make(map[string]cachedResult)}
+}
+
+// cacheKey identifies a call. JSON objects are encoded with sorted keys, so
+// argument order doesn't matter.
+func cacheKey(serverName, toolName string, params map[string]interface{}) (string, bool) {
+	args, err := json.Marshal(params)
+	if err != nil {
+		return "", false
+	}
+	return serverName + "\x00" + toolName + "\x00" + string(args), true
+}
+
+func (c *resultCache) get(key string, now time.Time) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if ok && now.Before(entry.expires) {
+		var result interface{}
+		if json.Unmarshal(entry.result, &result) == nil {
+			c.hits++
+			return result, true
+		}
+	}
+	if ok {
+		delete(c.entries, key)
+	}
+	c.misses++
+	return nil, false
+}
+
+func (c *resultCache) put(key string, result interface{}, expires time.Time) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxCacheEntries {
+		oldest := ""
+		for k, e := range c.entries {
+			if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = cachedResult{result: data, expires: expires}
+}
+
+// isErrorResult reports whether a tools/call result is a tool error, which
+// isn't cached.
+func isErrorResult(result interface{}) bool {
+	m, ok := result.(map[string]interface{})
+	return ok && m["isError"] == true
+}
+
+// clear drops the results of serverName, or all with "", returning how many.
+func (c *resultCache) clear(serverName string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for k := range c.entries {
+		if serverName == "" || strings.HasPrefix(k, serverName+"\x00") {
+			delete(c.entries, k)
+			n++
+		}
+	}
+	return n
+}
+
+// cacheTTL returns how long results of a tool may be reused, 0 if they
+// must not be cached.
+func (e *DiscoveryEngine) cacheTTL(serverName, toolName string) time.Duration {
+	seconds := 0
+	e.mu.RLock()
+	for _, td := range e.registry {
+		if td.Name == serverName && td.Runtime != nil && td.Runtime.Cache != nil {
+			seconds = td.Runtime.Cache.TTLSeconds
+			if ttl, ok := td.Runtime.Cache.ToolTTLSeconds[toolName]; ok {
+				seconds = ttl
+			}
+			break
+		}
+	}
+	e.mu.RUnlock()
+	if seconds <= 0 {
+		return 0
+	}
+
+	// Caching a call with side effects would skip them
+	tool := e.findTool(toolName)
+	if tool == nil || tool.Annotations == nil || !(tool.Annotations.ReadOnlyHint || tool.Annotations.IdempotentHint) {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// CacheStats returns the engine's response cache counters.
+func (e *DiscoveryEngine) CacheStats() CacheStats {
+	e.cache.mu.Lock()
+	defer e.cache.mu.Unlock()
+	return CacheStats{Hits: e.cache.hits, Misses: e.cache.misses, Entries: len(e.cache.entries)}
+}
+
+// ClearCache drops the cached results of serverName, or of every server
+// with "", returning how many were dropped.
+func (e *DiscoveryEngine) ClearCache(serverName string) int {
+	return e.cache.clear(serverName)
+}
@@ -0,0 +1,103 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingServer answers every call with the number of calls so far.
+type countingServer struct {
+	fakeServer
+	tools []registry.Tool
+	calls int
+}
+
+func (c *countingServer) CallToolWithMeta(string, map[string]interface{}, map[string]interface{}) (*registry.JSONRPCResponse, error) {
+	c.calls++
+	return &registry.JSONRPCResponse{JSONRPC: "2.0", Result: map[string]interface{}{"calls": float64(c.calls)}}, nil
+}
+
+func (c *countingServer) GetTools() []registry.Tool { return c.tools }
+
+func cacheEngine(t *testing.T, policy *registry.CachePolicy, w *countingServer) *DiscoveryEngine {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	e := NewDiscoveryEngine(ctx, "", "")
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.registry = append(e.registry, ToolDefinition{Name: "docs", Runtime: &registry.Runtime{Cache: policy}})
+	e.activeServers["docs"] = w
+	for _, tool := range w.tools {
+		e.toolToServer[tool.Name] = "docs"
+	}
+	return e
+}
+
+func TestCacheKey_IgnoresArgumentOrder(t *testing.T) {
+	a, ok := cacheKey("docs", "search", map[string]interface{}{"q": "go", "limit": 5})
+	require.True(t, ok)
+	b, _ := cacheKey("docs", "search", map[string]interface{}{"limit": 5, "q": "go"})
+	c, _ := cacheKey("docs", "search", map[string]interface{}{"limit": 6, "q": "go"})
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+}
+
+func TestResultCache_ExpiresAndClears(t *testing.T) {
+	c := newResultCache()
+	now := time.Now()
+	c.put("docs\x00search\x00{}", map[string]interface{}{"n": 1}, now.Add(time.Minute))
+	c.put("web\x00fetch\x00{}", "page", now.Add(time.Minute))
+
+	got, ok := c.get("docs\x00search\x00{}", now)
+	require.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"n": float64(1)}, got)
+
+	_, ok = c.get("docs\x00search\x00{}", now.Add(2*time.Minute))
+	assert.False(t, ok, "expired results are not returned")
+	assert.Equal(t, int64(1), c.hits)
+	assert.Equal(t, int64(1), c.misses)
+
+	assert.Equal(t, 1, c.clear("web"))
+	assert.Empty(t, c.entries)
+}
+
+func TestCallTool_CachesReadOnlyTools(t *testing.T) {
+	w := &countingServer{fakeServer: fakeServer{running: true}, tools: []registry.Tool{
+		{Name: "search", Annotations: &registry.ToolAnnotations{ReadOnlyHint: true}},
+		{Name: "write"},
+		{Name: "lookup", Annotations: &registry.ToolAnnotations{IdempotentHint: true}},
+	}}
+	e := cacheEngine(t, &registry.CachePolicy{TTLSeconds: 60, ToolTTLSeconds: map[string]int{"lookup": 0}}, w)
+	args := map[string]interface{}{"q": "go"}
+
+	first, err := e.CallTool("search", args)
+	require.NoError(t, err)
+	second, err := e.CallTool("search", map[string]interface{}{"q": "go"})
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, w.calls)
+
+	// Other arguments, a tool with side effects and a tool whose TTL is
+	// turned off all reach the server
+	_, err = e.CallTool("search", map[string]interface{}{"q": "rust"})
+	require.NoError(t, err)
+	e.CallTool("write", args)
+	e.CallTool("write", args)
+	e.CallTool("lookup", args)
+	e.CallTool("lookup", args)
+	assert.Equal(t, 6, w.calls)
+
+	stats := e.CacheStats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.Equal(t, 2, stats.Entries)
+
+	assert.Equal(t, 2, e.ClearCache("docs"))
+	e.CallTool("search", args)
+	assert.Equal(t, 7, w.calls)
+}
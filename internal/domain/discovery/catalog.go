@@ -0,0 +1,221 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+)
+
+// The registry files are parsed once and shared by every engine: each
+// profile has its own engine, and the control API keeps another as a search
+// index, so without sharing every one of them would read and decode every
+// file. A file is parsed again only when its size or modification time
+// changes, so a reload after verifying one tool re-parses just that file.
+//
+// Shared definitions must be treated as immutable; engines copy the
+// ToolDefinition values but not the slices and pointers inside them.
+
+// catalogFile is a parsed registry file.
+type catalogFile struct {
+	size    int64
+	modTime time.Time
+	subdir  string
+	entry   *registry.MCPEntry // As in the file, for overrides (see overrides.go)
+	def     ToolDefinition
+	err     error
+}
+
+var catalog = struct {
+	sync.Mutex
+	dirs map[string]map[string]catalogFile // registry dir -> file path -> parsed file
+}{dirs: make(map[string]map[string]catalogFile)}
+
+// registrySubdirs are scanned in order; later entries replace earlier ones
+// with the same name.
+var registrySubdirs = []string{"official", "custom"}
+
+// OverlayDir returns the directory of a profile's registry overlay: custom
+// entries only that profile's engine sees, on top of the shared catalog.
+func OverlayDir(registryDir, profileID string) string {
+	return filepath.Join(registryDir, "custom", profileID)
+}
+
+// loadCatalog returns the definitions in registryDir's official and custom
+// subdirectories, in file order, with their overrides applied. Changed
+// files are read and parsed in parallel.
+func loadCatalog(registryDir string) []ToolDefinition {
+	return applyOverrides(registryDir, catalogFiles(registryDir))
+}
+
+// LoadOverlay returns the definitions in a profile's registry overlay, in
+// file order, with their overrides applied.
+func LoadOverlay(registryDir, profileID string) []ToolDefinition {
+	defs := applyOverrides(registryDir, overlayFiles(registryDir, profileID))
+	for i := range defs {
+		defs[i].Overlay = profileID
+	}
+	return defs
+}
+
+// catalogFiles returns the parsed files of the shared catalog.
+func catalogFiles(registryDir string) []catalogFile {
+	dirs := make([]string, len(registrySubdirs))
+	for i, subdir := range registrySubdirs {
+		dirs[i] = filepath.Join(registryDir, subdir)
+	}
+	return loadDefinitions(registryDir, dirs, registrySubdirs)
+}
+
+// overlayFiles returns the parsed files of a profile's registry overlay.
+func overlayFiles(registryDir, profileID string) []catalogFile {
+	dir := OverlayDir(registryDir, profileID)
+	return loadDefinitions(dir, []string{dir}, []string{"custom"})
+}
+
+// loadDefinitions returns the files in dirs that parsed, whose entries are
+// read as found in the matching subdirs, caching them under key.
+func loadDefinitions(key string, dirs, subdirs []string) []catalogFile {
+	catalog.Lock()
+	defer catalog.Unlock()
+
+	cached := catalog.dirs[key]
+	current := make(map[string]catalogFile, len(cached))
+
+	type job struct {
+		path, subdir string
+		size         int64
+		modTime      time.Time
+	}
+	var order []string
+	var jobs []job
+	for i, dirPath := range dirs {
+		subdir := subdirs[i]
+		files, err := os.ReadDir(dirPath)
+		if err != nil {
+			// Missing directories are normal for fresh installs
+			continue
+		}
+		for _, file := range files {
+			// Subdirectories of custom are profile overlays
+			if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+				continue
+			}
+			path := filepath.Join(dirPath, file.Name())
+			order = append(order, path)
+
+			info, err := file.Info()
+			if err != nil {
+				jobs = append(jobs, job{path: path, subdir: subdir})
+				continue
+			}
+			if c, ok := cached[path]; ok && c.size == info.Size() && c.modTime.Equal(info.ModTime()) {
+				current[path] = c
+				continue
+			}
+			jobs = append(jobs, job{path: path, subdir: subdir, size: info.Size(), modTime: info.ModTime()})
+		}
+	}
+
+	if len(jobs) > 0 {
+		parsed := make([]catalogFile, len(jobs))
+		workers := runtime.GOMAXPROCS(0)
+		if workers > len(jobs) {
+			workers = len(jobs)
+		}
+		next := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					j := jobs[i]
+					entry, def, err := readDefinition(j.path, j.subdir)
+					parsed[i] = catalogFile{size: j.size, modTime: j.modTime, subdir: j.subdir, entry: entry, def: def, err: err}
+				}
+			}()
+		}
+		for i := range jobs {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+
+		for i, j := range jobs {
+			if parsed[i].err != nil {
+				fmt.Printf("Warning: %v\n", parsed[i].err)
+			}
+			current[j.path] = parsed[i]
+		}
+	}
+	catalog.dirs[key] = current
+
+	files := make([]catalogFile, 0, len(order))
+	for _, path := range order {
+		if f := current[path]; f.err == nil {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// readDefinition reads and parses one registry file found in subdir.
+func readDefinition(path, subdir string) (*registry.MCPEntry, ToolDefinition, error) {
+	name := filepath.Base(filepath.Dir(path)) + "/" + filepath.Base(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ToolDefinition{}, fmt.Errorf("failed to read tool definition %s: %w", name, err)
+	}
+
+	// Use the full MCPEntry from registry package for thoroughness
+	var entry registry.MCPEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, ToolDefinition{}, fmt.Errorf("failed to parse tool definition %s: %w", name, err)
+	}
+
+	return &entry, DefinitionFromEntry(&entry, subdir), nil
+}
+
+// DefinitionFromEntry converts a registry entry found in subdir (official
+// or custom) to a definition.
+func DefinitionFromEntry(entry *registry.MCPEntry, subdir string) ToolDefinition {
+	source := string(entry.Source)
+	if source == "" {
+		if subdir == "official" {
+			source = "official"
+		} else {
+			source = "custom"
+		}
+	}
+
+	td := ToolDefinition{
+		Name:           entry.Name,
+		Title:          entry.Title,
+		Version:        entry.Version,
+		Description:    entry.Description,
+		Category:       string(entry.Category),
+		Source:         source,
+		Icon:           entry.Icon,
+		IconBackground: entry.IconBackground,
+		About:          entry.About,
+		Tags:           entry.Tags,
+		Homepage:       entry.Homepage,
+		Repository:     entry.Repository,
+		Documentation:  entry.Docs,
+		Authorization:  entry.Auth,
+		Runtime:        entry.Runtime,
+		Tools:          entry.Tools,
+		Package:        entry.Package,
+		Metadata:       entry.Metadata,
+	}
+	if entry.Metadata != nil {
+		td.VerifiedAt = entry.Metadata.VerifiedAt
+	}
+	return td
+}
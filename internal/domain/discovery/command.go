@@ -0,0 +1,72 @@
+package discovery
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// On Windows, runtime commands such as npx are usually .cmd shims. Those run
+// through cmd.exe, which parses its command line differently from other
+// programs: arguments quoted the usual way break on spaces and on characters
+// like & or ^. PrepareCommand (command_windows.go) runs them through
+// "cmd.exe /d /s /c" with a command line built here, quoted the way cmd.exe
+// and the program behind the shim both understand.
+
+// cmdMetaChars are characters cmd.exe treats specially unless escaped with ^.
+var cmdMetaChars = regexp.MustCompile("([()\\][%!^\"`<>&|;, *?])")
+
+// cmdShimPattern matches the shims npm installs into node_modules/.bin,
+// which pass their arguments through cmd.exe a second time.
+var cmdShimPattern = regexp.MustCompile(`(?i)node_modules[\\/]\.bin[\\/][^\\/]+\.cmd$`)
+
+// isBatchFile reports whether path is a script run by cmd.exe.
+func isBatchFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".cmd" || ext == ".bat"
+}
+
+// batchCommandLine returns the full command line that makes comspec run
+// script with args, each reaching the program as a single argument.
+func batchCommandLine(comspec, script string, args []string) string {
+	doubleEscape := cmdShimPattern.MatchString(script)
+	parts := []string{cmdMetaChars.ReplaceAllString(script, "^$1")}
+	for _, arg := range args {
+		parts = append(parts, escapeBatchArg(arg, doubleEscape))
+	}
+	if strings.ContainsAny(comspec, " \t") {
+		comspec = `"` + comspec + `"`
+	}
+	return comspec + ` /d /s /c "` + strings.Join(parts, " ") + `"`
+}
+
+// escapeBatchArg quotes arg for the program's own argument parser, then
+// escapes the result for cmd.exe; twice for npm shims.
+func escapeBatchArg(arg string, doubleEscape bool) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	backslashes := 0
+	for _, r := range arg {
+		switch r {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			// Backslashes before a quote are doubled, and the quote escaped
+			b.WriteString(strings.Repeat(`\`, 2*backslashes+1))
+		default:
+			b.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		backslashes = 0
+		b.WriteRune(r)
+	}
+	// Trailing backslashes would escape the closing quote
+	b.WriteString(strings.Repeat(`\`, 2*backslashes))
+	b.WriteByte('"')
+
+	escaped := cmdMetaChars.ReplaceAllString(b.String(), "^$1")
+	if doubleEscape {
+		escaped = cmdMetaChars.ReplaceAllString(escaped, "^$1")
+	}
+	return escaped
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package discovery
+
+import "os/exec"
+
+// PrepareCommand is a no-op outside Windows, where programs receive their
+// arguments as an array.
+func PrepareCommand(cmd *exec.Cmd) {}
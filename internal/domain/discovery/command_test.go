@@ -0,0 +1,71 @@
+package discovery
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchCommandLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		comspec string
+		script  string
+		args    []string
+		want    string
+	}{
+		{
+			name:    "spaces in script and arguments",
+			comspec: `C:\Windows\system32\cmd.exe`,
+			script:  `C:\Program Files\nodejs\npx.cmd`,
+			args:    []string{"-y", "@modelcontextprotocol/server-filesystem", `C:\My Documents\notes`},
+			want:    `C:\Windows\system32\cmd.exe /d /s /c "C:\Program^ Files\nodejs\npx.cmd ^"-y^" ^"@modelcontextprotocol/server-filesystem^" ^"C:\My^ Documents\notes^""`,
+		},
+		{
+			name:    "quotes, trailing backslashes and metacharacters",
+			comspec: `C:\Program Files\cmd.exe`,
+			script:  `C:\tools\run.bat`,
+			args:    []string{`say "hi"`, `C:\dir\`, "a&b|c"},
+			want:    `"C:\Program Files\cmd.exe" /d /s /c "C:\tools\run.bat ^"say^ \^"hi\^"^" ^"C:\dir\\^" ^"a^&b^|c^""`,
+		},
+		{
+			name:    "npm shims are escaped twice",
+			comspec: "cmd.exe",
+			script:  `C:\proj\node_modules\.bin\server.cmd`,
+			args:    []string{"a b"},
+			want:    `cmd.exe /d /s /c "C:\proj\node_modules\.bin\server.cmd ^^^"a^^^ b^^^""`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, batchCommandLine(tt.comspec, tt.script, tt.args))
+		})
+	}
+
+	assert.True(t, isBatchFile(`C:\nodejs\NPX.CMD`))
+	assert.True(t, isBatchFile("run.bat"))
+	assert.False(t, isBatchFile(`C:\Program Files\nodejs\node.exe`))
+}
+
+func TestPrepareCommand_Windows(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("cmd.exe is only available on Windows")
+	}
+	dir := filepath.Join(t.TempDir(), "dir with spaces")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	script := filepath.Join(dir, "echo args.cmd")
+	require.NoError(t, os.WriteFile(script, []byte("@echo off\r\n:loop\r\nif \"%~1\"==\"\" goto :eof\r\necho [%~1]\r\nshift\r\ngoto loop\r\n"), 0755))
+
+	cmd := exec.Command(script, "plain", "with space", `C:\Program Files\x`)
+	PrepareCommand(cmd)
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(string(out), "\r\n", "\n")), "\n")
+	assert.Equal(t, []string{"[plain]", "[with space]", `[C:\Program Files\x]`}, lines)
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package discovery
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// PrepareCommand makes cmd run a .cmd or .bat file through cmd.exe with
+// correctly escaped arguments (see command.go). Other programs are left
+// alone.
+func PrepareCommand(cmd *exec.Cmd) {
+	if cmd.Err != nil || !isBatchFile(cmd.Path) {
+		return
+	}
+	comspec := os.Getenv("ComSpec")
+	if comspec == "" {
+		comspec = "cmd.exe"
+	}
+	if path, err := exec.LookPath(comspec); err == nil {
+		comspec = path
+	}
+
+	script, args := cmd.Path, cmd.Args[1:]
+	cmd.Path = comspec
+	cmd.Args = []string{comspec}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// CmdLine is passed to the process verbatim instead of being built from Args
+	cmd.SysProcAttr.CmdLine = batchCommandLine(comspec, script, args)
+}
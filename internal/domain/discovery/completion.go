@@ -0,0 +1,172 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// maxCompletionValues is the most values a completion result may carry.
+const maxCompletionValues = 100
+
+// completionTimeout bounds a completion request to a downstream server.
+const completionTimeout = 5 * time.Second
+
+// completingWorker is a worker that can forward completion/complete to its
+// server: StdioWorker and RemoteWorker.
+type completingWorker interface {
+	Capabilities() map[string]interface{}
+	Complete(params json.RawMessage, timeout time.Duration) (*registry.JSONRPCResponse, error)
+}
+
+// Completion reference types. "ref/tool" is a Scooter extension that asks
+// for values of a tool argument; prompts and resources are standard MCP.
+const (
+	RefPrompt   = "ref/prompt"
+	RefResource = "ref/resource"
+	RefTool     = "ref/tool"
+)
+
+// CompletionRequest is the params of completion/complete.
+type CompletionRequest struct {
+	Ref struct {
+		Type string `json:"type"`
+		Name string `json:"name,omitempty"`
+		URI  string `json:"uri,omitempty"`
+	} `json:"ref"`
+	Argument struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"argument"`
+}
+
+// Completion is the completion member of a completion/complete result.
+type Completion struct {
+	Values  []string `json:"values"`
+	Total   int      `json:"total,omitempty"`
+	HasMore bool     `json:"hasMore,omitempty"`
+}
+
+// Complete answers a completion/complete request. Builtin tool arguments are
+// completed locally; other requests go to the active servers that advertise
+// the completions capability and their suggestions are merged.
+func (e *DiscoveryEngine) Complete(raw json.RawMessage) (Completion, error) {
+	var req CompletionRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return Completion{}, fmt.Errorf("invalid completion request: %w", err)
+	}
+
+	if req.Ref.Type == RefTool && isPrimordial(req.Ref.Name) {
+		return newCompletion(e.completeBuiltin(req.Ref.Name, req.Argument.Name), req.Argument.Value), nil
+	}
+
+	// A tool belongs to exactly one server; prompts and resources could be
+	// anyone's, so ask every server that can answer.
+	var workers map[string]ToolWorker
+	e.mu.RLock()
+	switch req.Ref.Type {
+	case RefTool:
+		if serverName, ok := e.toolToServer[req.Ref.Name]; ok {
+			if worker, ok := e.activeServers[serverName]; ok {
+				workers = map[string]ToolWorker{serverName: worker}
+			}
+		}
+	case RefPrompt, RefResource:
+		workers = make(map[string]ToolWorker, len(e.activeServers))
+		for name, worker := range e.activeServers {
+			workers[name] = worker
+		}
+	default:
+		e.mu.RUnlock()
+		return Completion{}, fmt.Errorf("unknown completion reference type %q", req.Ref.Type)
+	}
+	e.mu.RUnlock()
+
+	var values []string
+	for serverName, worker := range workers {
+		completer, ok := worker.(completingWorker)
+		if !ok {
+			continue
+		}
+		if _, ok := completer.Capabilities()["completions"]; !ok {
+			continue
+		}
+		resp, err := completer.Complete(raw, completionTimeout)
+		if err == nil && resp.Error != nil {
+			err = fmt.Errorf("%s", resp.Error.Message)
+		}
+		if err != nil {
+			logger.AddLog("WARN", fmt.Sprintf("[Discovery] Completion from %s failed: %v", serverName, err))
+			continue
+		}
+		var result struct {
+			Completion Completion `json:"completion"`
+		}
+		b, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(b, &result); err == nil {
+			values = append(values, result.Completion.Values...)
+		}
+	}
+	// Servers already filtered by the typed value
+	return newCompletion(values, ""), nil
+}
+
+// completeBuiltin lists candidate values for a builtin tool argument.
+func (e *DiscoveryEngine) completeBuiltin(tool, argument string) []string {
+	switch {
+	case (tool == "scooter_activate" || tool == "scooter_add") && (argument == "tool_name" || argument == "tool_names"):
+		var names []string
+		e.mu.RLock()
+		for _, td := range e.registry {
+			if td.Source != "builtin" && td.DeprecationNotice() == "" {
+				names = append(names, td.Name)
+			}
+		}
+		e.mu.RUnlock()
+		return names
+	case tool == "scooter_deactivate" && argument == "tool_name":
+		return e.ListActive()
+	}
+	return nil
+}
+
+// isPrimordial reports whether name is one of the builtin tools. Some
+// builtins (scooter_add) are listed under another definition's Tools.
+func isPrimordial(name string) bool {
+	for _, td := range PrimordialTools() {
+		for _, t := range td.Tools {
+			if t.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newCompletion keeps the values starting with prefix (case-insensitively),
+// sorted and de-duplicated, up to the protocol's limit of 100.
+func newCompletion(values []string, prefix string) Completion {
+	prefix = strings.ToLower(prefix)
+	seen := make(map[string]bool, len(values))
+	matches := []string{}
+	for _, v := range values {
+		if seen[v] || !strings.HasPrefix(strings.ToLower(v), prefix) {
+			continue
+		}
+		seen[v] = true
+		matches = append(matches, v)
+	}
+	sort.Strings(matches)
+
+	c := Completion{Values: matches, Total: len(matches)}
+	if len(matches) > maxCompletionValues {
+		c.Values = matches[:maxCompletionValues]
+		c.HasMore = true
+	}
+	return c
+}
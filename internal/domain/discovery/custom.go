@@ -0,0 +1,158 @@
+package discovery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+)
+
+// Entries in registry/custom are written by the control API, probes and
+// verification alike. Writes are serialised and atomic, so a reader or a
+// crash never sees half a file, and each file has a version, a hash of its
+// content, that an update can require to detect concurrent edits.
+
+// ErrInvalidEntry is returned when an entry fails registry validation; the
+// validation result holds the field errors.
+var ErrInvalidEntry = errors.New("registry entry is not valid")
+
+// ErrEntryChanged is returned when a custom entry's version no longer
+// matches the one an update was based on.
+var ErrEntryChanged = errors.New("registry entry was changed since it was read")
+
+var customMu sync.Mutex
+
+// CustomEntryPath returns the file of the custom entry name, in the
+// overlay of the profile overlay or, if that is "", the shared registry.
+func CustomEntryPath(registryDir, overlay, name string) string {
+	if overlay != "" {
+		return filepath.Join(OverlayDir(registryDir, overlay), name+".json")
+	}
+	return filepath.Join(registryDir, "custom", name+".json")
+}
+
+// entryVersion returns the version of a file's content, as an HTTP entity tag.
+func entryVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// ReadCustomEntry returns a custom entry of an overlay ("" for the shared
+// registry) and its version.
+func ReadCustomEntry(registryDir, overlay, name string) (*registry.MCPEntry, string, error) {
+	data, err := os.ReadFile(CustomEntryPath(registryDir, overlay, name))
+	if err != nil {
+		return nil, "", err
+	}
+	var entry registry.MCPEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, "", fmt.Errorf("failed to parse custom entry %s: %w", name, err)
+	}
+	return &entry, entryVersion(data), nil
+}
+
+// currentVersion returns the version of the file at path, "" if there is none.
+func currentVersion(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return entryVersion(data), nil
+}
+
+// checkVersion compares the version of path with ifMatch: "" matches
+// anything, "*" any existing file, and anything else that exact version.
+func checkVersion(path, ifMatch string) error {
+	if ifMatch == "" {
+		return nil
+	}
+	current, err := currentVersion(path)
+	if err != nil {
+		return err
+	}
+	if current == "" || (ifMatch != "*" && ifMatch != current) {
+		return ErrEntryChanged
+	}
+	return nil
+}
+
+// WriteCustomEntry validates entry and writes it to an overlay ("" for the
+// shared custom registry) if its file's version matches ifMatch (see
+// checkVersion), returning the new version. Validation problems are
+// returned in the result with ErrInvalidEntry.
+func WriteCustomEntry(registryDir, overlay string, entry *registry.MCPEntry, ifMatch string) (string, *registry.ValidationResult, error) {
+	result := registry.ValidateCustom(entry)
+	if !result.Valid {
+		return "", result, fmt.Errorf("%w: %v", ErrInvalidEntry, result.Errors)
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return "", result, err
+	}
+
+	customMu.Lock()
+	defer customMu.Unlock()
+	path := CustomEntryPath(registryDir, overlay, entry.Name)
+	if err := checkVersion(path, ifMatch); err != nil {
+		return "", result, err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return "", result, err
+	}
+	return entryVersion(data), result, nil
+}
+
+// RemoveCustomEntry deletes a custom entry of an overlay ("" for the shared
+// registry) if its file's version matches ifMatch. A missing entry is not
+// an error unless ifMatch is set.
+func RemoveCustomEntry(registryDir, overlay, name, ifMatch string) error {
+	customMu.Lock()
+	defer customMu.Unlock()
+	path := CustomEntryPath(registryDir, overlay, name)
+	if err := checkVersion(path, ifMatch); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// writeFileAtomic replaces path with data through a temporary file in the
+// same directory.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/mcp-scooter/scooter/internal/domain/integration"
 	"github.com/mcp-scooter/scooter/internal/domain/profile"
 	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/events"
 	"github.com/mcp-scooter/scooter/internal/logger"
 )
 
@@ -29,6 +31,7 @@ type PersistentWorker interface {
 	ToolWorker
 	Start(env map[string]string) error
 	CallTool(name string, arguments map[string]interface{}) (*registry.JSONRPCResponse, error)
+	CallToolWithMeta(name string, arguments, meta map[string]interface{}) (*registry.JSONRPCResponse, error)
 	IsRunning() bool
 	GetTools() []registry.Tool
 	RefreshTools() error
@@ -56,6 +59,22 @@ type ToolDefinition struct {
 	Package       *registry.Package      `json:"package,omitempty"`
 	Metadata      *registry.Metadata     `json:"metadata,omitempty"`
 	VerifiedAt    string                 `json:"verified_at,omitempty"`
+	Overlay       string                 `json:"overlay,omitempty"`    // Profile whose registry overlay defines it
+	Overridden    bool                   `json:"overridden,omitempty"` // Changed by registry/overrides (see overrides.go)
+	Activation    *ActivationStats       `json:"activation,omitempty"` // Filled in by catalog listings
+}
+
+// DeprecationNotice returns the agent-facing warning for a deprecated registry
+// entry, or an empty string if the entry is not deprecated.
+func (td ToolDefinition) DeprecationNotice() string {
+	if td.Metadata == nil || !td.Metadata.Deprecated {
+		return ""
+	}
+	notice := fmt.Sprintf("Warning: '%s' is deprecated.", td.Name)
+	if td.Metadata.DeprecationMessage != nil && *td.Metadata.DeprecationMessage != "" {
+		notice += " " + *td.Metadata.DeprecationMessage
+	}
+	return notice
 }
 
 // CleanupCallback is called when a tool is auto-unloaded due to inactivity.
@@ -68,34 +87,117 @@ type DiscoveryEngine struct {
 	toolToServer    map[string]string     // toolName -> serverName
 	lastUsed        map[string]time.Time
 	registry        []ToolDefinition
+	registered      map[string]ToolDefinition // In-memory registrations kept across reloads
 	wasmDir         string
 	registryDir     string
 	env             map[string]string
 	disabledTools   map[string]bool
 	ctx             context.Context
+	cancel          context.CancelFunc
 	credentials     *integration.CredentialManager
 	cleanupCallback CleanupCallback
+	beforeActivate  BeforeActivateHook // Runs before each server start
 	settings        profile.Settings // AI routing configuration
+	strictOutput    bool             // Fail calls whose structured output violates outputSchema
+	stats           map[string]*CallStats
+	activationTimes map[string]time.Duration // serverName -> duration of the last successful Add
+	activating      map[string]*pendingActivation // serverName -> Add in progress
+
+	// Background activations (see AddAsync)
+	asyncStarts        map[string]time.Time // serverName -> when AddAsync started it
+	activationFailures map[string]string    // serverName -> error of the last failed AddAsync
+	activationCallback ActivationCallback
+
+	// For scooter_status
+	inFlight     map[string]int // serverName -> calls waiting on it
+	recentErrors []CallError
+	health       map[string]serverHealth // serverName -> last health check (see health.go)
+	restarts     map[string]*restartState // serverName -> automatic restarts
+	workspaceDir    string                   // Profile scratch directory, "" if disabled
+	profileID       string                   // Profile the engine serves, for the event journal
+
+	// Oversized results (see results.go)
+	resultBudget     int                     // Max bytes of a server result, 0 for no limit
+	summarizeResults bool                    // Summarise oversized results with the AI provider
+	results          map[string]storedResult // handle -> full text
+	resultOrder      []string                // handles, oldest first
+
+	// Isolated homes (see home.go)
+	homeDir    string            // Parent of the isolated homes, "" if disabled
+	isolateAll bool              // Isolate every server, not just those whose entry asks
+	homes      map[string]string // serverName -> home of a running server
+
+	packageDir string // Where binary packages are installed (see install.go)
+	overlay    string // Profile whose registry overlay is loaded, "" for none
+
+	metrics *ActivationMetrics // Activation outcomes per registry entry (see reliability.go)
+	cache   *resultCache       // Results of read-only tools (see cache.go)
+
+	// Downstream logging, guarded by logMu rather than mu (see serverNotificationHandler)
+	logMu       sync.RWMutex
+	logLevels   map[string]string // serverName -> minimum MCP log level
+	logCallback LogCallback
 }
 
 func NewDiscoveryEngine(ctx context.Context, wasmDir string, registryDir string) *DiscoveryEngine {
+	ctx, cancel := context.WithCancel(ctx)
 	e := &DiscoveryEngine{
 		activeServers: make(map[string]ToolWorker),
 		toolToServer:  make(map[string]string),
 		lastUsed:      make(map[string]time.Time),
 		registry:      PrimordialTools(),
+		registered:    make(map[string]ToolDefinition),
 		wasmDir:       wasmDir,
 		registryDir:   registryDir,
 		env:           make(map[string]string),
 		disabledTools: make(map[string]bool),
 		ctx:           ctx,
-			credentials:   integration.NewCredentialManager(),
+		cancel:        cancel,
+		credentials:   integration.NewCredentialManager(),
+		stats:         make(map[string]*CallStats),
+
+		activationTimes: make(map[string]time.Duration),
+		activating:      make(map[string]*pendingActivation),
+
+		asyncStarts:        make(map[string]time.Time),
+		activationFailures: make(map[string]string),
+		inFlight:           make(map[string]int),
+		health:             make(map[string]serverHealth),
+		restarts:           make(map[string]*restartState),
+		homes:              make(map[string]string),
+		metrics:            NewActivationMetrics(""),
+		cache:              newResultCache(),
 	}
 	e.loadRegistry()
 	go e.monitor()
+	go e.supervise()
 	return e
 }
 
+// Stop shuts the engine down: the idle monitor exits and every active server is closed.
+// The engine must not be used afterwards.
+func (e *DiscoveryEngine) Stop() {
+	e.cancel()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for name, worker := range e.activeServers {
+		worker.Close()
+		delete(e.activeServers, name)
+		e.removeHomeLocked(name)
+	}
+	e.toolToServer = make(map[string]string)
+	e.lastUsed = make(map[string]time.Time)
+}
+
+// SetCredentialManager replaces the engine's credential manager so several
+// engines can share one keychain client.
+func (e *DiscoveryEngine) SetCredentialManager(cm *integration.CredentialManager) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.credentials = cm
+}
+
 // SetCleanupCallback sets the callback function called when tools are auto-unloaded.
 func (e *DiscoveryEngine) SetCleanupCallback(cb CleanupCallback) {
 	e.mu.Lock()
@@ -136,6 +238,8 @@ func (e *DiscoveryEngine) GetActiveToolsForServer(serverName string) []registry.
 
 // GetCredentialManager returns the credential manager for external access.
 func (e *DiscoveryEngine) GetCredentialManager() *integration.CredentialManager {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	return e.credentials
 }
 
@@ -163,6 +267,14 @@ func (e *DiscoveryEngine) IsToolDisabled(name string) bool {
 	return e.disabledTools[name]
 }
 
+// SetStrictOutputSchema controls whether outputSchema mismatches fail the call
+// (true) or are only logged as warnings (false).
+func (e *DiscoveryEngine) SetStrictOutputSchema(strict bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.strictOutput = strict
+}
+
 // SetSettings updates the AI routing configuration.
 func (e *DiscoveryEngine) SetSettings(settings profile.Settings) {
 	e.mu.Lock()
@@ -170,6 +282,10 @@ func (e *DiscoveryEngine) SetSettings(settings profile.Settings) {
 	e.settings = settings
 }
 
+// loadRegistry rebuilds the registry from the primordial tools, the JSON files
+// on disk and any in-memory registrations. The new slice replaces the old one
+// in a single assignment, so entries whose files were deleted or renamed are
+// dropped instead of lingering from a previous load.
 func (e *DiscoveryEngine) loadRegistry() {
 	if e.registryDir == "" {
 		return
@@ -178,69 +294,72 @@ func (e *DiscoveryEngine) loadRegistry() {
 	// Reset toolToServer map to ensure fresh mappings from disk
 	e.toolToServer = make(map[string]string)
 
-	// Scan official and custom subdirectories
-	subdirs := []string{"official", "custom"}
-	for _, subdir := range subdirs {
-		dirPath := filepath.Join(e.registryDir, subdir)
-		files, err := os.ReadDir(dirPath)
-		if err != nil {
-			// Skip missing directories silently or log if not just "not found"
-			continue
+	fresh := PrimordialTools()
+	index := make(map[string]int, len(fresh))
+	for i, td := range fresh {
+		index[td.Name] = i
+	}
+	upsert := func(td ToolDefinition) {
+		if i, ok := index[td.Name]; ok {
+			fresh[i] = td
+			return
 		}
+		index[td.Name] = len(fresh)
+		fresh = append(fresh, td)
+	}
 
-		for _, file := range files {
-			if filepath.Ext(file.Name()) == ".json" {
-				data, err := os.ReadFile(filepath.Join(dirPath, file.Name()))
-				if err != nil {
-					fmt.Printf("Warning: failed to read tool definition %s/%s: %v\n", subdir, file.Name(), err)
-					continue
-				}
+	// Parsed definitions are shared with the other engines (see catalog.go)
+	for _, td := range loadCatalog(e.registryDir) {
+		upsert(td)
+	}
 
-				// Use the full MCPEntry from registry package for thoroughness
-				var entry registry.MCPEntry
-				if err := json.Unmarshal(data, &entry); err != nil {
-					fmt.Printf("Warning: failed to parse tool definition %s/%s: %v\n", subdir, file.Name(), err)
-					continue
-				}
+	// The profile's overlay wins over the shared catalog
+	if e.overlay != "" {
+		for _, td := range LoadOverlay(e.registryDir, e.overlay) {
+			upsert(td)
+		}
+	}
 
-				source := string(entry.Source)
-				if source == "" {
-					if subdir == "official" {
-						source = "official"
-					} else {
-						source = "custom"
-					}
-				}
+	// Tools registered at runtime win over disk entries, as they did when
+	// Register was called after the initial load
+	names := make([]string, 0, len(e.registered))
+	for name := range e.registered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		upsert(e.registered[name])
+	}
 
-				td := ToolDefinition{
-					Name:          entry.Name,
-					Title:         entry.Title,
-					Version:       entry.Version,
-					Description:   entry.Description,
-					Category:      string(entry.Category),
-					Source:        source,
-					Icon:          entry.Icon,
-					IconBackground: entry.IconBackground,
-					About:         entry.About,
-					Tags:          entry.Tags,
-					Homepage:      entry.Homepage,
-					Repository:    entry.Repository,
-					Documentation: entry.Docs,
-					Authorization: entry.Auth,
-					Runtime:       entry.Runtime,
-					Tools:         entry.Tools,
-					Package:       entry.Package,
-					Metadata:      entry.Metadata,
-				}
-				if entry.Metadata != nil {
-					td.VerifiedAt = entry.Metadata.VerifiedAt
-				}
-				e.registerUnlocked(td)
-			}
-		}
+	e.registry = fresh
+	for _, td := range fresh {
+		logDeprecated(td)
+	}
+}
+
+// loggedDeprecated holds the entries whose deprecation has been logged, so
+// each is logged once rather than by every engine and reload.
+var loggedDeprecated sync.Map
+
+// logDeprecated logs, once per entry, that a registry entry is deprecated.
+func logDeprecated(td ToolDefinition) {
+	if td.DeprecationNotice() == "" {
+		return
+	}
+	if _, logged := loggedDeprecated.LoadOrStore(td.Name, true); !logged {
+		logger.AddLog("WARN", fmt.Sprintf("Registry entry '%s' is deprecated", td.Name))
 	}
 }
 
+// SetRegistryOverlay loads the registry overlay of profileID (see
+// OverlayDir) on top of the shared catalog; "" loads none.
+func (e *DiscoveryEngine) SetRegistryOverlay(profileID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.overlay = profileID
+	e.loadRegistry()
+}
+
 // registerUnlocked adds a new tool definition to the registry without taking the lock.
 func (e *DiscoveryEngine) registerUnlocked(td ToolDefinition) {
 	// Check for duplicates
@@ -324,11 +443,28 @@ func (e *DiscoveryEngine) ReloadRegistry() error {
 
 // Find searches for tools in the registry.
 func (e *DiscoveryEngine) Find(query string) []ToolDefinition {
+	if query == "" {
+		e.mu.RLock()
+		defer e.mu.RUnlock()
+
+		// Return all tools for management purposes
+		return e.registry
+	}
+	return e.Search(SearchOptions{Query: query})
+}
+
+// DeprecationNotice returns the deprecation warning for a registry entry,
+// or an empty string if the server is unknown or not deprecated.
+func (e *DiscoveryEngine) DeprecationNotice(serverName string) string {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
-	// Return all tools for management purposes
-	return e.registry
+	for _, td := range e.registry {
+		if td.Name == serverName {
+			return td.DeprecationNotice()
+		}
+	}
+	return ""
 }
 
 // ListTools returns tools available for the AI agent (filtering out disabled ones).
@@ -349,6 +485,7 @@ func (e *DiscoveryEngine) ListTools() []ToolDefinition {
 func (e *DiscoveryEngine) Register(td ToolDefinition) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
+	e.registered[td.Name] = td
 	e.registerUnlocked(td)
 }
 
@@ -379,18 +516,33 @@ func (e *DiscoveryEngine) GetServerForTool(toolName string) (string, bool) {
 }
 
 // Add installs and activates a tool.
+//
+// The engine lock is released while the server starts, so several servers
+// can be activated at once. Concurrent calls for the same server wait for
+// the first one and share its result.
 func (e *DiscoveryEngine) Add(serverName string) error {
 	e.mu.Lock()
 	
-	e.lastUsed[serverName] = time.Now()
+	started := time.Now()
+	e.lastUsed[serverName] = started
 	if _, ok := e.activeServers[serverName]; ok {
 		e.mu.Unlock()
 		return nil // Already active
 	}
+	if pending, ok := e.activating[serverName]; ok {
+		e.mu.Unlock()
+		<-pending.done
+		return pending.err
+	}
+	if reason, ok := e.metrics.Quarantined(serverName); ok {
+		e.mu.Unlock()
+		return fmt.Errorf("%w: %s (%s). Fix it, then unquarantine it with POST /api/tools/%s/unquarantine",
+			ErrQuarantined, serverName, reason, serverName)
+	}
 
 	// Check quotas before activating
 	maxServers := e.settings.MaxActiveServers
-	if maxServers > 0 && len(e.activeServers) >= maxServers {
+	if maxServers > 0 && len(e.activeServers)+len(e.activating) >= maxServers {
 		if e.settings.QuotaPolicy == "block" {
 			active := make([]string, 0, len(e.activeServers))
 			for name := range e.activeServers {
@@ -423,13 +575,17 @@ func (e *DiscoveryEngine) Add(serverName string) error {
 				if worker, ok := e.activeServers[oldestServer]; ok {
 					worker.Close()
 					delete(e.activeServers, oldestServer)
+					e.removeHomeLocked(oldestServer)
 					delete(e.lastUsed, oldestServer)
+					delete(e.health, oldestServer)
 					for toolName, sName := range e.toolToServer {
 						if sName == oldestServer {
 							delete(e.toolToServer, toolName)
 						}
 					}
 					
+					e.recordEventLocked(events.ServerDeactivated, oldestServer, map[string]interface{}{"reason": "evicted"})
+
 					// Notify callback if set
 					if e.cleanupCallback != nil {
 						// Use a goroutine to avoid deadlock if callback calls back into engine
@@ -444,43 +600,191 @@ func (e *DiscoveryEngine) Add(serverName string) error {
 	var targetDef *ToolDefinition
 	for i := range e.registry {
 		if e.registry[i].Name == serverName {
-			targetDef = &e.registry[i]
+			def := e.registry[i]
+			targetDef = &def // A copy: the registry may be reloaded while we start
 			break
 		}
 	}
 	if targetDef == nil {
-		return fmt.Errorf("server not found in registry: %s", serverName)
+		err := fmt.Errorf("server not found in registry: %s", serverName)
+		e.noteErrorLocked(serverName, "", err)
+		e.mu.Unlock()
+		return err
+	}
+	if err := e.checkCredentials(targetDef); err != nil {
+		e.noteErrorLocked(serverName, "", err)
+		e.mu.Unlock()
+		return err
 	}
 
-	// Build environment with credentials from keychain
+	// Isolated home, registry env, then the workspace, profile env and keychain credentials
 	toolEnv := make(map[string]string)
-	
-	// Start with profile env
-	for k, v := range e.env {
-		toolEnv[k] = v
+	for _, v := range e.resolveEnv(targetDef) {
+		toolEnv[v.Name] = v.Value
+		if v.Source == EnvSourceKeychain {
+			fmt.Printf("[Discovery] Injected credential %s for %s\n", v.Name, serverName)
+		}
 	}
-	
-	// Layer in secure credentials from keychain
-	if e.credentials != nil && targetDef.Authorization != nil {
-		creds, err := e.credentials.GetCredentialsForTool(serverName, targetDef.Authorization)
-		if err != nil {
-			fmt.Printf("[Discovery] Warning: failed to get credentials for %s: %v\n", serverName, err)
+	if home := e.isolatedHome(targetDef); home != "" {
+		if err := prepareHome(home); err != nil {
+			err = fmt.Errorf("failed to create isolated home for %s: %w", serverName, err)
+			e.noteErrorLocked(serverName, "", err)
+			e.mu.Unlock()
+			return err
+		}
+		e.homes[serverName] = home
+	}
+	if e.credentials != nil && targetDef.Authorization != nil && e.credentials.Identity(serverName) != "" {
+		logger.AddLog("INFO", fmt.Sprintf("[Discovery] Using credential identity '%s' for %s", e.credentials.Identity(serverName), serverName))
+	}
+
+	// Registry entries may set their own start-up budget; the rest get the
+	// configured default
+	startTimeout := time.Duration(e.settings.ActivationTimeoutSeconds) * time.Second
+	if targetDef.Runtime != nil && targetDef.Runtime.Timeout > 0 {
+		startTimeout = time.Duration(targetDef.Runtime.Timeout) * time.Millisecond
+	}
+
+	pending := &pendingActivation{done: make(chan struct{}), started: started}
+	e.activating[serverName] = pending
+	beforeActivate, profileID := e.beforeActivate, e.profileID
+	e.mu.Unlock()
+
+	// A failing hook isn't the server's fault, so it doesn't count towards
+	// quarantine
+	var hookErr error
+	if beforeActivate != nil {
+		hookErr = beforeActivate(profileID, serverName)
+	}
+	var worker ToolWorker
+	var toolNames []string
+	err := hookErr
+	if err == nil {
+		worker, toolNames, err = e.startWorker(serverName, targetDef, toolEnv, startTimeout)
+	}
+
+	e.mu.Lock()
+	delete(e.activating, serverName)
+	if err != nil {
+		e.removeHomeLocked(serverName)
+		e.noteErrorLocked(serverName, "", err)
+		e.recordEventLocked(events.ServerActivationFailed, serverName, map[string]interface{}{"error": err.Error()})
+	} else {
+		delete(e.activationFailures, serverName)
+		for _, name := range toolNames {
+			e.toolToServer[name] = serverName
+		}
+		e.activeServers[serverName] = worker
+		fmt.Printf("[Discovery] Activated server: %s\n", serverName)
+		fmt.Printf("[Discovery] Current toolToServer mappings: %v\n", e.toolToServer)
+		e.activationTimes[serverName] = time.Since(started)
+		e.recordEventLocked(events.ServerActivated, serverName, map[string]interface{}{
+			"tools":       len(toolNames),
+			"duration_ms": time.Since(started).Milliseconds(),
+		})
+	}
+	metrics, quarantineAfter := e.metrics, e.settings.QuarantineAfterFailures
+	e.mu.Unlock()
+	if hookErr == nil && metrics.Record(serverName, time.Since(started), err, quarantineAfter) {
+		logger.AddLog("WARN", fmt.Sprintf("[Discovery] Quarantined %s after %d consecutive failed activations", serverName, quarantineAfter))
+		e.mu.Lock()
+		e.recordEventLocked(events.ServerQuarantined, serverName, map[string]interface{}{"error": err.Error()})
+		e.mu.Unlock()
+	}
+
+	pending.err = err
+	close(pending.done)
+	return err
+}
+
+// pendingActivation lets concurrent Adds of one server share its start-up.
+// err is set before done is closed.
+type pendingActivation struct {
+	done    chan struct{}
+	err     error
+	started time.Time
+}
+
+// startWorker starts a server's process or loads its WASM module, returning
+// the worker and the names of the tools it provides. startTimeout bounds a
+// stdio server's handshake; 0 keeps the worker default. It runs without the
+// engine lock held.
+func (e *DiscoveryEngine) startWorker(serverName string, targetDef *ToolDefinition, toolEnv map[string]string, startTimeout time.Duration) (ToolWorker, []string, error) {
+	var toolNames []string
+
+	// Download the package's WASM module or binary if it isn't there yet
+	binary, err := e.installPackage(serverName, targetDef)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to install package for MCP server %s: %w", serverName, err)
+	}
+
+	// Handle docker packages, whatever their transport
+	if targetDef.Package != nil && targetDef.Package.Type == registry.PackageDocker {
+		dockerWorker := NewDockerWorker(e.ctx, e.settings.DockerCommand, serverName, DockerImage(targetDef.Package), targetDef.Runtime)
+		readyDelay := time.Duration(0)
+		if targetDef.Runtime != nil {
+			readyDelay = time.Duration(targetDef.Runtime.ReadyDelay) * time.Millisecond
+		}
+		dockerWorker.SetStartupOptions(startTimeout, readyDelay)
+		dockerWorker.SetNotificationHandler(e.serverNotificationHandler(serverName))
+		dockerWorker.SetExitHandler(e.serverExitHandler(serverName))
+
+		if err := dockerWorker.Start(toolEnv); err != nil {
+			return nil, nil, fmt.Errorf("failed to start container for MCP server %s: %w", serverName, err)
+		}
+
+		if minLevel := e.minLogLevel(serverName); minLevel != "" {
+			if _, ok := dockerWorker.Capabilities()["logging"]; ok {
+				if err := dockerWorker.SetLogLevel(minLevel); err != nil {
+					logger.AddLog("WARN", fmt.Sprintf("[Discovery] Failed to set log level for %s: %v", serverName, err))
+				}
+			}
+		}
+
+		serverTools := dockerWorker.GetTools()
+		if len(serverTools) > 0 {
+			logger.AddLog("INFO", fmt.Sprintf("[Discovery] Server %s in container %s reports %d tools", serverName, dockerWorker.Container(), len(serverTools)))
+			for _, tool := range serverTools {
+				toolNames = append(toolNames, tool.Name)
+			}
 		} else {
-			for k, v := range creds {
-				toolEnv[k] = v
-				fmt.Printf("[Discovery] Injected credential %s for %s\n", k, serverName)
+			for _, tool := range targetDef.Tools {
+				toolNames = append(toolNames, tool.Name)
 			}
 		}
+		return dockerWorker, toolNames, nil
 	}
 
-	var worker ToolWorker
-	// Handle Stdio transport (e.g., npx, python, etc.)
-	if targetDef.Runtime != nil && targetDef.Runtime.Transport == registry.TransportStdio {
-		stdioWorker := NewStdioWorker(e.ctx, targetDef.Runtime.Command, targetDef.Runtime.Args)
+	// Handle Stdio transport (e.g., npx, python, etc.), and installed
+	// binaries, which run as the command unless the entry names another
+	if binary != "" || (targetDef.Runtime != nil && targetDef.Runtime.Transport == registry.TransportStdio) {
+		command, args, readyDelay := binary, []string(nil), 0
+		if rt := targetDef.Runtime; rt != nil {
+			if rt.Command != "" {
+				command = rt.Command
+			}
+			args, readyDelay = rt.Args, rt.ReadyDelay
+		}
+		stdioWorker := NewStdioWorker(e.ctx, command, args)
+		stdioWorker.SetStartupOptions(
+			startTimeout,
+			time.Duration(readyDelay)*time.Millisecond,
+		)
+		stdioWorker.SetNotificationHandler(e.serverNotificationHandler(serverName))
+		stdioWorker.SetExitHandler(e.serverExitHandler(serverName))
 		
 		// Start the persistent server process with initialize handshake
 		if err := stdioWorker.Start(toolEnv); err != nil {
-			return fmt.Errorf("failed to start MCP server %s: %w", serverName, err)
+			return nil, nil, fmt.Errorf("failed to start MCP server %s: %w", serverName, err)
+		}
+
+		// Servers that support logging filter at the source
+		if minLevel := e.minLogLevel(serverName); minLevel != "" {
+			if _, ok := stdioWorker.Capabilities()["logging"]; ok {
+				if err := stdioWorker.SetLogLevel(minLevel); err != nil {
+					logger.AddLog("WARN", fmt.Sprintf("[Discovery] Failed to set log level for %s: %v", serverName, err))
+				}
+			}
 		}
 		
 		// Update tool mappings from server's actual tools if available
@@ -489,38 +793,65 @@ func (e *DiscoveryEngine) Add(serverName string) error {
 			fmt.Printf("[Discovery] Server %s reports %d tools\n", serverName, len(serverTools))
 			for _, tool := range serverTools {
 				fmt.Printf("[Discovery] Mapping tool '%s' -> server '%s'\n", tool.Name, serverName)
-				e.toolToServer[tool.Name] = serverName
+				toolNames = append(toolNames, tool.Name)
 			}
 		} else {
 			// Fall back to registry-defined tools
 			for _, tool := range targetDef.Tools {
 				fmt.Printf("[Discovery] Mapping registry tool '%s' -> server '%s'\n", tool.Name, serverName)
-				e.toolToServer[tool.Name] = serverName
+				toolNames = append(toolNames, tool.Name)
 			}
 		}
 		
-		worker = stdioWorker
-	} else {
-		// Default to WASM
-		wasmWorker := NewWASMWorker(e.ctx)
-		wasmPath := filepath.Join(e.wasmDir, fmt.Sprintf("%s.wasm", serverName))
-		if err := wasmWorker.Load(wasmPath); err != nil {
-			return fmt.Errorf("failed to load wasm tool %s: %w", serverName, err)
+		return stdioWorker, toolNames, nil
+	}
+
+	// Handle remote servers (HTTP, SSE, streamable HTTP)
+	if targetDef.Runtime != nil && targetDef.Runtime.Transport.IsRemote() {
+		remoteWorker := NewRemoteWorker(e.ctx, targetDef.Runtime.URL, targetDef.Runtime.Transport, targetDef.Runtime.Headers)
+		remoteWorker.SetStartupOptions(startTimeout)
+		remoteWorker.SetNotificationHandler(e.serverNotificationHandler(serverName))
+
+		// Connect and perform the initialize handshake; toolEnv fills in headers
+		if err := remoteWorker.Start(toolEnv); err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to MCP server %s: %w", serverName, err)
 		}
-		worker = wasmWorker
-		
+
+		if minLevel := e.minLogLevel(serverName); minLevel != "" {
+			if _, ok := remoteWorker.Capabilities()["logging"]; ok {
+				if err := remoteWorker.SetLogLevel(minLevel); err != nil {
+					logger.AddLog("WARN", fmt.Sprintf("[Discovery] Failed to set log level for %s: %v", serverName, err))
+				}
+			}
+		}
+
+		serverTools := remoteWorker.GetTools()
+		if len(serverTools) > 0 {
+			logger.AddLog("INFO", fmt.Sprintf("[Discovery] Remote server %s reports %d tools", serverName, len(serverTools)))
+			for _, tool := range serverTools {
+				toolNames = append(toolNames, tool.Name)
+			}
+		} else {
+			for _, tool := range targetDef.Tools {
+				toolNames = append(toolNames, tool.Name)
+			}
+		}
+		return remoteWorker, toolNames, nil
+	}
+
+	// Default to WASM
+	wasmWorker := NewWASMWorker(e.ctx)
+	wasmPath := filepath.Join(e.wasmDir, fmt.Sprintf("%s.wasm", serverName))
+	if err := wasmWorker.Load(wasmPath); err != nil {
+		return nil, nil, fmt.Errorf("failed to load wasm tool %s: %w", serverName, err)
+	}
+
 	// Use registry-defined tools for WASM
 	for _, tool := range targetDef.Tools {
 		fmt.Printf("[Discovery] Mapping WASM tool '%s' -> server '%s'\n", tool.Name, serverName)
-		e.toolToServer[tool.Name] = serverName
+		toolNames = append(toolNames, tool.Name)
 	}
-}
-
-	e.activeServers[serverName] = worker
-	fmt.Printf("[Discovery] Activated server: %s\n", serverName)
-	fmt.Printf("[Discovery] Current toolToServer mappings: %v\n", e.toolToServer)
-	e.mu.Unlock()
-	return nil
+	return wasmWorker, toolNames, nil
 }
 
 // Remove unloads a tool.
@@ -531,7 +862,10 @@ func (e *DiscoveryEngine) Remove(serverName string) error {
 	if worker, ok := e.activeServers[serverName]; ok {
 		worker.Close()
 		delete(e.activeServers, serverName)
+		e.removeHomeLocked(serverName)
 		delete(e.lastUsed, serverName)
+		delete(e.health, serverName)
+		e.cache.clear(serverName)
 
 		// Remove tool mappings
 		for toolName, sName := range e.toolToServer {
@@ -539,11 +873,21 @@ func (e *DiscoveryEngine) Remove(serverName string) error {
 				delete(e.toolToServer, toolName)
 			}
 		}
+		e.recordEventLocked(events.ServerDeactivated, serverName, nil)
 		return nil
 	}
 	return fmt.Errorf("server not found: %s", serverName)
 }
 
+// Restart stops an active server and starts it again, so it picks up a
+// changed environment. Its tools are unavailable in between.
+func (e *DiscoveryEngine) Restart(serverName string) error {
+	if err := e.Remove(serverName); err != nil {
+		return err
+	}
+	return e.Add(serverName)
+}
+
 // ListActive returns names of currently loaded servers.
 func (e *DiscoveryEngine) ListActive() []string {
 	e.mu.RLock()
@@ -556,10 +900,26 @@ func (e *DiscoveryEngine) ListActive() []string {
 	return active
 }
 
+// IsActive reports whether a server is loaded.
+func (e *DiscoveryEngine) IsActive(serverName string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	_, ok := e.activeServers[serverName]
+	return ok
+}
+
 // CallTool executes a tool (builtin or WASM/Stdio) and returns the result.
 func (e *DiscoveryEngine) CallTool(name string, params map[string]interface{}) (interface{}, error) {
+	return e.CallToolWithMeta(name, params, nil)
+}
+
+// CallToolWithMeta executes a tool like CallTool and forwards meta to the
+// downstream server as params._meta. Builtin tools ignore it.
+func (e *DiscoveryEngine) CallToolWithMeta(name string, params, meta map[string]interface{}) (result interface{}, err error) {
+	trace := traceTag(meta)
+
 	// 1. Try built-in tools
-	result, err := e.HandleBuiltinTool(name, params)
+	result, err = e.HandleBuiltinTool(name, params)
 	if err == nil {
 		return result, nil
 	}
@@ -591,16 +951,27 @@ func (e *DiscoveryEngine) CallTool(name string, params map[string]interface{}) (
 
 	if active {
 		e.MarkUsed(serverName)
+		ttl := e.cacheTTL(serverName, name)
+		key, cacheable := cacheKey(serverName, name, params)
+		cacheable = cacheable && ttl > 0
+		if cacheable {
+			if cached, ok := e.cache.get(key, time.Now()); ok {
+				fmt.Printf("[Discovery]%s Tool '%s' answered from cache\n", trace, name)
+				return e.applyResultBudget(name, cached), nil
+			}
+		}
 		startTime := time.Now()
+		e.beginCall(serverName)
+		defer func() { e.endCall(serverName, name, startTime, err) }()
 
 		// Check if this is a persistent worker (StdioWorker)
 		if persistentWorker, ok := worker.(PersistentWorker); ok {
 			// Use the direct CallTool method for persistent workers
-			resp, err := persistentWorker.CallTool(name, params)
+			resp, err := e.callWithRetry(serverName, persistentWorker, name, params, meta)
 			duration := time.Since(startTime)
 
 			if err != nil {
-				fmt.Printf("[Discovery] Tool execution failed for '%s': %v\n", name, err)
+				fmt.Printf("[Discovery]%s Tool execution failed for '%s': %v\n", trace, name, err)
 				return nil, fmt.Errorf("tool execution failed: %w", err)
 			}
 
@@ -616,8 +987,15 @@ func (e *DiscoveryEngine) CallTool(name string, params map[string]interface{}) (
 				return nil, fmt.Errorf("tool error: %s (code: %d)", errMsg, resp.Error.Code)
 			}
 
-			fmt.Printf("[Discovery] Tool '%s' executed successfully in %v\n", name, duration)
-			return resp.Result, nil
+			if err := e.checkOutputSchema(name, resp.Result); err != nil {
+				return nil, err
+			}
+
+			fmt.Printf("[Discovery]%s Tool '%s' executed successfully in %v\n", trace, name, duration)
+			if cacheable && !isErrorResult(resp.Result) {
+				e.cache.put(key, resp.Result, time.Now().Add(ttl))
+			}
+			return e.applyResultBudget(name, resp.Result), nil
 		}
 
 		// Fall back to Execute pattern for WASM workers
@@ -629,9 +1007,11 @@ func (e *DiscoveryEngine) CallTool(name string, params map[string]interface{}) (
 		callParams := struct {
 			Name      string                 `json:"name"`
 			Arguments map[string]interface{} `json:"arguments"`
+			Meta      map[string]interface{} `json:"_meta,omitempty"`
 		}{
 			Name:      name,
 			Arguments: params,
+			Meta:      meta,
 		}
 		req.Params, _ = json.Marshal(callParams)
 
@@ -648,25 +1028,32 @@ func (e *DiscoveryEngine) CallTool(name string, params map[string]interface{}) (
 		e.mu.RUnlock()
 
 		if err := worker.Execute(stdin, &stdout, currentEnv); err != nil {
-			fmt.Printf("[Discovery] Tool execution failed for '%s': %v\n", name, err)
+			e.recordCall(serverName, 0, true)
+			fmt.Printf("[Discovery]%s Tool execution failed for '%s': %v\n", trace, name, err)
 			return nil, fmt.Errorf("tool execution failed: %w", err)
 		}
 
 		duration := time.Since(startTime)
-		fmt.Printf("[Discovery] Tool '%s' response received in %v: %s\n", name, duration, stdout.String())
+		fmt.Printf("[Discovery]%s Tool '%s' response received in %v: %s\n", trace, name, duration, stdout.String())
 
 		var resp registry.JSONRPCResponse
-		if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		err := json.Unmarshal(stdout.Bytes(), &resp)
+		e.recordCall(serverName, 0, err == nil && resp.Error != nil)
+		if err != nil {
 			// Some servers might output extra logs before the JSON, 
 			// but for this simple implementation we expect clean JSON.
-			return stdout.String(), nil
+			return e.applyResultBudget(name, stdout.String()), nil
 		}
 
 		if resp.Error != nil {
 			return nil, fmt.Errorf("tool error: %s (code: %d)", resp.Error.Message, resp.Error.Code)
 		}
 
-		return resp.Result, nil
+		if err := e.checkOutputSchema(name, resp.Result); err != nil {
+			return nil, err
+		}
+
+		return e.applyResultBudget(name, resp.Result), nil
 	}
 
 	return nil, fmt.Errorf("tool not found: %s", name)
@@ -679,6 +1066,83 @@ func (e *DiscoveryEngine) MarkUsed(serverName string) {
 	e.lastUsed[serverName] = time.Now()
 }
 
+// findTool returns the definition of a tool provided by an active server,
+// preferring what the running server reported over the registry copy.
+func (e *DiscoveryEngine) findTool(toolName string) *registry.Tool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	serverName, ok := e.toolToServer[toolName]
+	if !ok {
+		return nil
+	}
+
+	if worker, ok := e.activeServers[serverName]; ok {
+		if pw, ok := worker.(PersistentWorker); ok {
+			for _, t := range pw.GetTools() {
+				if t.Name == toolName {
+					tool := t
+					return &tool
+				}
+			}
+		}
+	}
+
+	for _, td := range e.registry {
+		if td.Name != serverName {
+			continue
+		}
+		for _, t := range td.Tools {
+			if t.Name == toolName {
+				tool := t
+				return &tool
+			}
+		}
+	}
+	return nil
+}
+
+// checkOutputSchema validates the structuredContent of a tool result against the
+// tool's outputSchema. Mismatches are logged, and returned as an error in strict mode.
+func (e *DiscoveryEngine) checkOutputSchema(toolName string, result interface{}) error {
+	resMap, ok := result.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	structured, ok := resMap["structuredContent"]
+	if !ok {
+		return nil
+	}
+
+	tool := e.findTool(toolName)
+	if tool == nil || tool.OutputSchema == nil {
+		return nil
+	}
+
+	errs := registry.ValidateValue(tool.OutputSchema, structured)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, 0, len(errs))
+	for _, ve := range errs {
+		msgs = append(msgs, ve.Error())
+	}
+	summary := strings.Join(msgs, "; ")
+
+	e.mu.RLock()
+	strict := e.strictOutput
+	e.mu.RUnlock()
+
+	if strict {
+		logger.AddLog("ERROR", fmt.Sprintf("[Discovery] Output of '%s' does not match its outputSchema: %s", toolName, summary))
+		return fmt.Errorf("tool output does not match outputSchema: %s", summary)
+	}
+
+	logger.AddLog("WARN", fmt.Sprintf("[Discovery] Output of '%s' does not match its outputSchema: %s", toolName, summary))
+	return nil
+}
+
 // getToolSchema returns a human-readable schema hint for a tool.
 func (e *DiscoveryEngine) getToolSchema(toolName string) string {
 	e.mu.RLock()
@@ -733,6 +1197,7 @@ func (e *DiscoveryEngine) monitor() {
 		select {
 		case <-ticker.C:
 			e.cleanup()
+			e.pruneWorkspace()
 		case <-e.ctx.Done():
 			return
 		}
@@ -766,7 +1231,9 @@ func (e *DiscoveryEngine) cleanup() {
 				fmt.Printf("Auto-unloading inactive tool: %s\n", name)
 				worker.Close()
 				delete(e.activeServers, name)
+				e.removeHomeLocked(name)
 				delete(e.lastUsed, name)
+				delete(e.health, name)
 
 				// Remove tool mappings
 				for toolName, sName := range e.toolToServer {
@@ -804,14 +1271,23 @@ func VerifyMCPTool(ctx context.Context, toolDef *ToolDefinition, env map[string]
 		return nil, fmt.Errorf("tool has no runtime configuration")
 	}
 
-	if toolDef.Runtime.Transport != registry.TransportStdio {
-		return nil, fmt.Errorf("only stdio transport is supported for verification (got: %s)", toolDef.Runtime.Transport)
-	}
-
 	logger.AddLog("INFO", fmt.Sprintf("[Verify] Creating temporary worker for '%s'", toolDef.Name))
 
-	// Create a temporary stdio worker
-	worker := NewStdioWorker(ctx, toolDef.Runtime.Command, toolDef.Runtime.Args)
+	// Create a temporary worker
+	var worker PersistentWorker
+	serverInfo := map[string]interface{}{
+		"command": toolDef.Runtime.Command,
+		"args":    toolDef.Runtime.Args,
+	}
+	switch {
+	case toolDef.Runtime.Transport == registry.TransportStdio:
+		worker = NewStdioWorker(ctx, toolDef.Runtime.Command, toolDef.Runtime.Args)
+	case toolDef.Runtime.Transport.IsRemote():
+		worker = NewRemoteWorker(ctx, toolDef.Runtime.URL, toolDef.Runtime.Transport, toolDef.Runtime.Headers)
+		serverInfo = map[string]interface{}{"url": toolDef.Runtime.URL}
+	default:
+		return nil, fmt.Errorf("only stdio and remote transports are supported for verification (got: %s)", toolDef.Runtime.Transport)
+	}
 
 	// Start the server (this performs the initialize handshake)
 	logger.AddLog("INFO", fmt.Sprintf("[Verify] Starting server process..."))
@@ -836,10 +1312,7 @@ func VerifyMCPTool(ctx context.Context, toolDef *ToolDefinition, env map[string]
 	}
 
 	return &VerifyResult{
-		ServerInfo: map[string]interface{}{
-			"command": toolDef.Runtime.Command,
-			"args":    toolDef.Runtime.Args,
-		},
+		ServerInfo:  serverInfo,
 		ServerTools: serverTools,
 	}, nil
 }
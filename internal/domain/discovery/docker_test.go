@@ -0,0 +1,77 @@
+package discovery_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerImage(t *testing.T) {
+	assert.Equal(t, "mcp/fetch:1.2", discovery.DockerImage(&registry.Package{Image: "mcp/fetch", Version: "1.2"}))
+	assert.Equal(t, "mcp/fetch:latest", discovery.DockerImage(&registry.Package{Image: "mcp/fetch:latest", Version: "1.2"}))
+	assert.Equal(t, "localhost:5000/fetch:1.2", discovery.DockerImage(&registry.Package{Image: "localhost:5000/fetch", Version: "1.2"}))
+	assert.Equal(t, "mcp/fetch@sha256:ab", discovery.DockerImage(&registry.Package{Image: "mcp/fetch@sha256:ab", Version: "1.2"}))
+	assert.Equal(t, "mcp/fetch", discovery.DockerImage(&registry.Package{Image: "mcp/fetch"}))
+}
+
+func TestEngine_AddDockerServer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake docker CLI is a shell script")
+	}
+	// The fake CLI logs its arguments and runs the helper server for `run`
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "docker.log")
+	fake := filepath.Join(dir, "docker")
+	require.NoError(t, os.WriteFile(fake, []byte(`#!/bin/sh
+echo "$@" >> "`+logPath+`"
+case "$1" in
+  image) exit 1 ;;
+  run) exec "`+os.Args[0]+`" -test.run='^TestHelperMCPServer$' ;;
+esac
+exit 0
+`), 0755))
+
+	registryDir := t.TempDir()
+	path := filepath.Join(registryDir, "custom", "contained.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"name": "contained",
+		"description": "test entry",
+		"package": {"type": "docker", "image": "example/contained", "version": "2.0"},
+		"runtime": {"transport": "stdio", "args": ["--verbose"], "isolateHome": true}
+	}`), 0644))
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), "", registryDir)
+	defer engine.Stop()
+	settings := profile.DefaultSettings()
+	settings.DockerCommand = fake
+	engine.SetSettings(settings)
+	engine.SetHomeIsolation(t.TempDir(), false)
+	engine.SetEnv(map[string]string{"SCOOTER_TEST_SERVER": "plain"})
+
+	require.NoError(t, engine.Add("contained"))
+	result, err := engine.CallTool("echo", map[string]interface{}{"message": "hi"})
+	require.NoError(t, err)
+	assert.NotNil(t, result)
+	require.NoError(t, engine.Remove("contained"))
+
+	data, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 4, string(data))
+	assert.Equal(t, "image inspect example/contained:2.0", lines[0])
+	assert.Equal(t, "pull example/contained:2.0", lines[1], "missing images are pulled")
+	assert.Regexp(t, `^run --rm --name (scooter-contained-\w+) --label scooter.server=contained -e SCOOTER_TEST_SERVER -i example/contained:2.0 --verbose$`, lines[2])
+	assert.NotContains(t, lines[2], "HOME", "containers are not home-isolated")
+	container := strings.Fields(lines[2])[3]
+	assert.Equal(t, "rm -f "+container, lines[3], "the container is removed")
+}
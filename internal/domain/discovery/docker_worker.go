@@ -0,0 +1,323 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// =============================================================================
+// DockerWorker - Containerised MCP Servers
+// =============================================================================
+//
+// Registry entries with a docker package run their image in a container
+// that lives as long as the server is active:
+//
+//   stdio      `docker run -i` is started like any stdio server; the
+//              container's stdin and stdout carry the JSON-RPC messages.
+//   http/sse   The container is started detached with the port of the
+//              runtime url published on 127.0.0.1, and a RemoteWorker
+//              connects to it. The url names the server inside the
+//              container, e.g. http://localhost:8080/mcp.
+//
+// The image is pulled when it isn't present. Environment variables reach
+// the container as `-e NAME`, so their values are taken from the docker
+// CLI's environment and never appear on a command line. runtime.args are
+// passed to the image. Close removes the container.
+//
+// =============================================================================
+
+// DefaultDockerCommand is the container CLI used unless settings name
+// another, such as podman.
+const DefaultDockerCommand = "docker"
+
+// dockerPullTimeout bounds pulling an image, which can take far longer than
+// a server's start-up.
+const dockerPullTimeout = 10 * time.Minute
+
+// DockerWorker handles a persistent MCP server running in a container.
+type DockerWorker struct {
+	PersistentWorker // The StdioWorker or RemoteWorker talking to the container
+
+	ctx        context.Context
+	docker     string
+	image      string
+	container  string // Name of the running container
+	serverName string
+	runtime    registry.Runtime
+	env        map[string]string // Also for the CLI itself, e.g. DOCKER_HOST
+
+	timeout             time.Duration
+	readyDelay          time.Duration
+	notificationHandler NotificationHandler
+	exitHandler         func(err error)
+}
+
+// NewDockerWorker creates a worker running image for serverName with the
+// docker CLI at docker ("" for DefaultDockerCommand). runtime may be nil
+// for a stdio server without arguments.
+func NewDockerWorker(ctx context.Context, docker, serverName, image string, runtime *registry.Runtime) *DockerWorker {
+	if docker == "" {
+		docker = DefaultDockerCommand
+	}
+	w := &DockerWorker{
+		ctx:        ctx,
+		docker:     docker,
+		image:      image,
+		serverName: serverName,
+		container:  containerName(serverName),
+		timeout:    30 * time.Second,
+	}
+	if runtime != nil {
+		w.runtime = *runtime
+	}
+	return w
+}
+
+// DockerImage returns the image reference of a docker package, adding
+// pkg.Version as the tag when the image has none.
+func DockerImage(pkg *registry.Package) string {
+	image := pkg.Image
+	if pkg.Version == "" || strings.Contains(image, "@") {
+		return image
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image // Already tagged
+	}
+	return image + ":" + pkg.Version
+}
+
+var containerNameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// containerName is a unique container name for serverName, so a container
+// left behind by a crash never blocks the next start.
+func containerName(serverName string) string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return "scooter-" + containerNameUnsafe.ReplaceAllString(serverName, "-") + "-" + hex.EncodeToString(b)
+}
+
+// SetStartupOptions sets the handshake budget and, for stdio servers, the
+// pause after the initialized notification.
+func (w *DockerWorker) SetStartupOptions(timeout, readyDelay time.Duration) {
+	if timeout > 0 {
+		w.timeout = timeout
+	}
+	w.readyDelay = readyDelay
+}
+
+// SetNotificationHandler installs the handler for server notifications.
+func (w *DockerWorker) SetNotificationHandler(h NotificationHandler) {
+	w.notificationHandler = h
+}
+
+// SetExitHandler installs the handler called when a stdio container exits
+// on its own.
+func (w *DockerWorker) SetExitHandler(h func(err error)) {
+	w.exitHandler = h
+}
+
+// Container returns the name of the worker's container.
+func (w *DockerWorker) Container() string {
+	return w.container
+}
+
+// Start pulls the image if needed, starts the container and performs the
+// MCP handshake. env is passed into the container.
+func (w *DockerWorker) Start(env map[string]string) error {
+	w.env = env
+	// Registry validation checks the image; this keeps any other caller
+	// from passing the docker CLI a flag
+	if w.image == "" || strings.HasPrefix(w.image, "-") {
+		return fmt.Errorf("invalid image reference %q", w.image)
+	}
+	if err := w.ensureImage(); err != nil {
+		return err
+	}
+	if w.runtime.Transport.IsRemote() {
+		return w.startRemote(env)
+	}
+
+	args := append(w.runArgs(env), "-i", w.image)
+	worker := NewStdioWorker(w.ctx, w.docker, append(args, w.runtime.Args...))
+	worker.SetStartupOptions(w.timeout, w.readyDelay)
+	if w.notificationHandler != nil {
+		worker.SetNotificationHandler(w.notificationHandler)
+	}
+	if w.exitHandler != nil {
+		worker.SetExitHandler(w.exitHandler)
+	}
+	w.PersistentWorker = worker
+	if err := worker.Start(env); err != nil {
+		w.removeContainer()
+		return err
+	}
+	return nil
+}
+
+// startRemote runs the container detached, publishing the runtime url's
+// port, and connects to it once the server inside accepts the handshake.
+func (w *DockerWorker) startRemote(env map[string]string) error {
+	u, err := url.Parse(w.runtime.URL)
+	if err != nil {
+		return fmt.Errorf("invalid runtime url: %w", err)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	args := append(w.runArgs(env), "-d", "-p", "127.0.0.1::"+port, w.image)
+	if _, err := w.run(w.timeout, append(args, w.runtime.Args...)...); err != nil {
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+	out, err := w.run(w.timeout, "port", w.container, port+"/tcp")
+	if err != nil {
+		w.removeContainer()
+		return fmt.Errorf("failed to find the published port: %w", err)
+	}
+	// One line per address, e.g. "127.0.0.1:49153"
+	hostPort, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	if _, p, err := net.SplitHostPort(strings.TrimSpace(hostPort)); err == nil {
+		hostPort = net.JoinHostPort("127.0.0.1", p)
+	}
+	u.Host = hostPort
+	logger.AddLog("INFO", fmt.Sprintf("[Docker] Container %s serves %s at %s", w.container, w.serverName, u))
+
+	// The server inside may take a moment to listen
+	deadline := time.Now().Add(w.timeout)
+	for {
+		worker := NewRemoteWorker(w.ctx, u.String(), w.runtime.Transport, w.runtime.Headers)
+		worker.SetStartupOptions(time.Until(deadline))
+		if w.notificationHandler != nil {
+			worker.SetNotificationHandler(w.notificationHandler)
+		}
+		err := worker.Start(env)
+		if err == nil {
+			w.PersistentWorker = worker
+			return nil
+		}
+		worker.Close()
+		if time.Now().Add(500 * time.Millisecond).After(deadline) {
+			w.removeContainer()
+			return err
+		}
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-w.ctx.Done():
+			w.removeContainer()
+			return w.ctx.Err()
+		}
+	}
+}
+
+// runArgs are the `docker run` arguments common to both transports.
+func (w *DockerWorker) runArgs(env map[string]string) []string {
+	args := []string{"run", "--rm", "--name", w.container, "--label", "scooter.server=" + w.serverName}
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		args = append(args, "-e", name)
+	}
+	return args
+}
+
+// ensureImage pulls the image unless it is already present.
+func (w *DockerWorker) ensureImage() error {
+	if _, err := w.run(w.timeout, "image", "inspect", w.image); err == nil {
+		return nil
+	}
+	logger.AddLog("INFO", fmt.Sprintf("[Docker] Pulling %s for %s", w.image, w.serverName))
+	if _, err := w.run(dockerPullTimeout, "pull", w.image); err != nil {
+		return fmt.Errorf("failed to pull %s: %w", w.image, err)
+	}
+	return nil
+}
+
+// run runs the docker CLI with args and returns its output. Errors include
+// what the CLI printed.
+func (w *DockerWorker) run(timeout time.Duration, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(w.ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, w.docker, args...)
+	PrepareCommand(cmd)
+	cmd.Env = os.Environ()
+	for k, v := range w.env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// removeContainer force-removes the container. Stopping the docker CLI of
+// a stdio container doesn't stop the container itself.
+func (w *DockerWorker) removeContainer() {
+	if _, err := w.run(10*time.Second, "rm", "-f", w.container); err != nil {
+		logger.AddLog("DEBUG", fmt.Sprintf("[Docker] Removing container %s: %v", w.container, err))
+	}
+}
+
+// Close ends the session and removes the container.
+func (w *DockerWorker) Close() error {
+	if w.PersistentWorker != nil {
+		w.PersistentWorker.Close()
+	}
+	w.removeContainer()
+	return nil
+}
+
+// IsRunning reports whether the container's server completed the handshake.
+func (w *DockerWorker) IsRunning() bool {
+	return w.PersistentWorker != nil && w.PersistentWorker.IsRunning()
+}
+
+// Capabilities returns the capabilities from the initialize response.
+func (w *DockerWorker) Capabilities() map[string]interface{} {
+	if c, ok := w.PersistentWorker.(interface{ Capabilities() map[string]interface{} }); ok {
+		return c.Capabilities()
+	}
+	return nil
+}
+
+// SetLogLevel asks the server to send log notifications at level and above.
+func (w *DockerWorker) SetLogLevel(level string) error {
+	if l, ok := w.PersistentWorker.(interface{ SetLogLevel(string) error }); ok {
+		return l.SetLogLevel(level)
+	}
+	return fmt.Errorf("server does not support logging")
+}
+
+// Complete forwards a completion/complete request.
+func (w *DockerWorker) Complete(params json.RawMessage, timeout time.Duration) (*registry.JSONRPCResponse, error) {
+	if c, ok := w.PersistentWorker.(completingWorker); ok {
+		return c.Complete(params, timeout)
+	}
+	return nil, fmt.Errorf("server not initialized")
+}
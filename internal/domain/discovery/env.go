@@ -0,0 +1,157 @@
+package discovery
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"sort"
+
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// EnvSource names the layer that set a server environment variable.
+type EnvSource string
+
+// Sources in order of increasing precedence.
+const (
+	EnvSourceIsolation EnvSource = "isolation" // an isolated HOME (see home.go)
+	EnvSourceRegistry  EnvSource = "registry"  // runtime.env of the registry entry
+	EnvSourceWorkspace EnvSource = "workspace" // the profile's scratch directory
+	EnvSourceProfile   EnvSource = "profile"   // the profile's env
+	EnvSourceKeychain  EnvSource = "keychain"  // stored credentials
+)
+
+// EnvVar is one variable of the environment a server is started with.
+type EnvVar struct {
+	Name   string    `json:"name"`
+	Value  string    `json:"value"`
+	Source EnvSource `json:"source"`
+	// Overridden lists the lower-precedence sources that also set the
+	// variable, in order.
+	Overridden []EnvSource `json:"overridden,omitempty"`
+}
+
+// resolveEnv merges the environment td's server runs with on top of
+// Scooter's own, sorted by name. Callers must hold e.mu.
+func (e *DiscoveryEngine) resolveEnv(td *ToolDefinition) []EnvVar {
+	vars := map[string]*EnvVar{}
+	set := func(name, value string, source EnvSource) {
+		if v, ok := vars[name]; ok {
+			v.Overridden = append(v.Overridden, v.Source)
+			v.Value, v.Source = value, source
+			return
+		}
+		vars[name] = &EnvVar{Name: name, Value: value, Source: source}
+	}
+
+	if home := e.isolatedHome(td); home != "" {
+		for k, v := range homeEnv(home) {
+			set(k, v, EnvSourceIsolation)
+		}
+	}
+
+	if td.Runtime != nil {
+		for k, v := range td.Runtime.Env {
+			set(k, v, EnvSourceRegistry)
+		}
+	}
+
+	// Point servers at the profile's scratch space unless the profile overrides it
+	if e.workspaceDir != "" {
+		if err := os.MkdirAll(e.workspaceDir, 0755); err != nil {
+			logger.AddLog("WARN", fmt.Sprintf("[Discovery] Failed to create workspace %s: %v", e.workspaceDir, err))
+		} else {
+			set(WorkspaceEnvVar, e.workspaceDir, EnvSourceWorkspace)
+		}
+	}
+
+	for k, v := range e.env {
+		set(k, v, EnvSourceProfile)
+	}
+
+	if e.credentials != nil && td.Authorization != nil {
+		creds, err := e.credentials.GetCredentialsForTool(td.Name, td.Authorization)
+		if err != nil {
+			logger.AddLog("WARN", fmt.Sprintf("[Discovery] Credentials of %s: %v", td.Name, err))
+		}
+		for k, v := range creds {
+			set(k, v, EnvSourceKeychain)
+		}
+	}
+
+	out := make([]EnvVar, 0, len(vars))
+	for _, v := range vars {
+		out = append(out, *v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// UpdateEnv replaces the profile environment like SetEnv and returns the
+// active servers whose environment it changes. They keep the one they were
+// started with until restarted.
+func (e *DiscoveryEngine) UpdateEnv(env map[string]string) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	before := make(map[string]map[string]string, len(e.activeServers))
+	for i := range e.registry {
+		if _, ok := e.activeServers[e.registry[i].Name]; ok {
+			before[e.registry[i].Name] = envValues(e.resolveEnv(&e.registry[i]))
+		}
+	}
+	e.env = env
+
+	var changed []string
+	for i := range e.registry {
+		name := e.registry[i].Name
+		if old, ok := before[name]; ok && !maps.Equal(old, envValues(e.resolveEnv(&e.registry[i]))) {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func envValues(vars []EnvVar) map[string]string {
+	values := make(map[string]string, len(vars))
+	for _, v := range vars {
+		values[v.Name] = v.Value
+	}
+	return values
+}
+
+// PreviewEnv returns the environment Add would start serverName with,
+// without starting it. Values from the profile and the keychain are masked;
+// Scooter's own environment, which servers inherit underneath, is not
+// listed.
+func (e *DiscoveryEngine) PreviewEnv(serverName string) ([]EnvVar, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for i := range e.registry {
+		if e.registry[i].Name == serverName {
+			vars := e.resolveEnv(&e.registry[i])
+			for i := range vars {
+				if vars[i].Source == EnvSourceProfile || vars[i].Source == EnvSourceKeychain {
+					vars[i].Value = MaskSecret(vars[i].Value)
+				}
+			}
+			return vars, nil
+		}
+	}
+	return nil, fmt.Errorf("server not found in registry: %s", serverName)
+}
+
+// MaskSecret hides a secret value, keeping the last four characters of long
+// ones so users can tell keys apart.
+func MaskSecret(value string) string {
+	switch {
+	case value == "":
+		return ""
+	case len(value) < 12:
+		return "****"
+	default:
+		return "****" + value[len(value)-4:]
+	}
+}
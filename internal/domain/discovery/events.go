@@ -0,0 +1,51 @@
+package discovery
+
+import (
+	"fmt"
+
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/mcp-scooter/scooter/internal/logger"
+	"github.com/mcp-scooter/scooter/internal/metrics"
+)
+
+// SetProfileID names the profile the engine serves in journal events.
+func (e *DiscoveryEngine) SetProfileID(id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.profileID = id
+}
+
+// recordEventLocked adds a server event to the journal; e.mu must be held.
+func (e *DiscoveryEngine) recordEventLocked(eventType, serverName string, data map[string]interface{}) {
+	events.Record(eventType, e.profileID, serverName, data)
+	switch eventType {
+	case events.ServerRestarted:
+		metrics.ServerRestarts.Inc(serverName)
+	case events.ServerCrashed:
+		metrics.Errors.Inc(metrics.ErrorServerCrashed)
+	case events.ServerActivationFailed:
+		metrics.Errors.Inc(metrics.ErrorActivationFailed)
+	}
+}
+
+// serverExitHandler records a server process that died while active. The
+// server stays in the active set, reported as crashed, until it is removed
+// or restarted, automatically (see health.go) or by a retried call.
+func (e *DiscoveryEngine) serverExitHandler(serverName string) func(err error) {
+	return func(err error) {
+		msg := "process exited"
+		if err != nil {
+			msg = err.Error()
+		}
+		logger.AddLog("ERROR", fmt.Sprintf("[Discovery] Server %s exited unexpectedly: %s", serverName, msg))
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if _, active := e.activeServers[serverName]; !active {
+			return
+		}
+		e.noteErrorLocked(serverName, "", fmt.Errorf("server exited: %s", msg))
+		e.recordEventLocked(events.ServerCrashed, serverName, map[string]interface{}{"error": msg})
+		e.scheduleRestartLocked(serverName, e.activeServers[serverName], "crashed")
+	}
+}
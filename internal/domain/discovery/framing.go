@@ -0,0 +1,97 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxFramedMessage guards against a bogus Content-Length header.
+const maxFramedMessage = 64 << 20
+
+// readMessage returns the next JSON-RPC message an MCP server wrote to stdout.
+// Both newline-delimited JSON and Content-Length framed messages (as used by
+// LSP-style servers) are accepted; framed reports which one was seen. Any other
+// output, such as a banner printed before the server starts speaking JSON-RPC,
+// is passed to skip and ignored.
+func readMessage(r *bufio.Reader, skip func(line string)) (msg []byte, framed bool, err error) {
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(bytes.TrimSpace(line)) == 0) {
+			return nil, false, err
+		}
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) == 0 {
+			continue
+		}
+
+		if length, ok := contentLength(trimmed); ok {
+			body, err := readFramedBody(r, length)
+			return body, true, err
+		}
+
+		if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+			return trimmed, false, nil
+		}
+
+		if skip != nil {
+			skip(string(trimmed))
+		}
+		if err == io.EOF {
+			return nil, false, err
+		}
+	}
+}
+
+// contentLength parses a "Content-Length: N" header line.
+func contentLength(line []byte) (int, bool) {
+	name, value, ok := strings.Cut(string(line), ":")
+	if !ok || !strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// readFramedBody skips the remaining headers up to the blank separator line
+// and reads a body of the given length.
+func readFramedBody(r *bufio.Reader, length int) ([]byte, error) {
+	if length > maxFramedMessage {
+		return nil, fmt.Errorf("framed message too large: %d bytes", length)
+	}
+	for {
+		header, err := r.ReadBytes('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message headers: %w", err)
+		}
+		if len(bytes.TrimSpace(header)) == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read framed message: %w", err)
+	}
+	return body, nil
+}
+
+// writeMessage writes a JSON-RPC message using newline-delimited JSON, or
+// Content-Length framing when the server has been seen to use it.
+func writeMessage(w io.Writer, msg []byte, framed bool) error {
+	if framed {
+		if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(msg)); err != nil {
+			return err
+		}
+		_, err := w.Write(msg)
+		return err
+	}
+	_, err := w.Write(append(msg, '\n'))
+	return err
+}
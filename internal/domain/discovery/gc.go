@@ -0,0 +1,95 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Artifact is a file or directory left in the app directory by something
+// that no longer exists, e.g. the WASM module of a removed registry entry.
+type Artifact struct {
+	Path     string    `json:"path"`
+	Bytes    int64     `json:"bytes"`
+	Modified time.Time `json:"modified"`
+	Reason   string    `json:"reason"`
+}
+
+// GCReport is the outcome of a garbage collection.
+type GCReport struct {
+	Removed        []Artifact `json:"removed"`
+	ReclaimedBytes int64      `json:"reclaimed_bytes"`
+	Errors         []string   `json:"errors,omitempty"`
+	DryRun         bool       `json:"dry_run"`
+}
+
+// GC removes orphaned artifacts that haven't been modified for Retention.
+// With DryRun set it only reports what it would remove.
+type GC struct {
+	Retention time.Duration
+	DryRun    bool
+	Now       time.Time
+}
+
+// Collect removes the entries of dir that orphan gives a reason for. A
+// directory counts as modified when a file in it was. A missing dir is not
+// an error.
+func (gc GC) Collect(dir string, orphan func(d fs.DirEntry) string, report *GCReport) {
+	report.DryRun = gc.DryRun
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			report.Errors = append(report.Errors, err.Error())
+		}
+		return
+	}
+	for _, entry := range entries {
+		reason := orphan(entry)
+		if reason == "" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		size, modified := treeStats(path)
+		if gc.Now.Sub(modified) < gc.Retention {
+			continue
+		}
+		if !gc.DryRun {
+			if err := os.RemoveAll(path); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("failed to remove %s: %v", path, err))
+				continue
+			}
+		}
+		report.Removed = append(report.Removed, Artifact{Path: path, Bytes: size, Modified: modified, Reason: reason})
+		report.ReclaimedBytes += size
+	}
+}
+
+// treeStats returns the total size of the regular files under path and
+// the newest of their modification times, or path's own if it holds none.
+func treeStats(path string) (int64, time.Time) {
+	var size int64
+	var newest time.Time
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // Removed while walking
+		}
+		size += info.Size()
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	if newest.IsZero() {
+		if info, err := os.Lstat(path); err == nil {
+			newest = info.ModTime()
+		}
+	}
+	return size, newest
+}
@@ -0,0 +1,260 @@
+package discovery
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// Active servers are health checked on the interval of their entry's
+// runtime.healthCheck, by ping or tools/list. A server that crashes, or
+// fails enough checks in a row, is restarted after an exponential backoff,
+// at most settings.MaxRestartsPerHour times an hour.
+
+const (
+	superviseTick         = 5 * time.Second
+	defaultHealthInterval = time.Minute
+	minHealthInterval     = time.Second
+	defaultFailureLimit   = 3
+	restartBackoff        = time.Second
+	maxRestartBackoff     = 2 * time.Minute
+)
+
+// restartState tracks the automatic restarts of one server.
+type restartState struct {
+	times     []time.Time // Restarts within the last hour, oldest first
+	pending   bool        // A restart is waiting out its backoff
+	exhausted bool        // The hourly cap was reached and reported
+}
+
+// healthPolicy is how a server is checked.
+type healthPolicy struct {
+	interval     time.Duration // 0 never checks
+	method       string
+	failureLimit int
+}
+
+// healthPolicyLocked returns the health check policy of a server's entry;
+// e.mu must be held.
+func (e *DiscoveryEngine) healthPolicyLocked(serverName string) healthPolicy {
+	policy := healthPolicy{interval: defaultHealthInterval, method: "ping", failureLimit: defaultFailureLimit}
+	var hc *registry.HealthCheck
+	for _, td := range e.registry {
+		if td.Name == serverName && td.Runtime != nil {
+			hc = td.Runtime.HealthCheck
+			break
+		}
+	}
+	if hc == nil {
+		return policy
+	}
+	if !hc.Enabled {
+		policy.interval = 0
+		return policy
+	}
+	if hc.Interval > 0 {
+		policy.interval = time.Duration(hc.Interval) * time.Millisecond
+		if policy.interval < minHealthInterval {
+			policy.interval = minHealthInterval
+		}
+	}
+	if hc.Method != "" {
+		policy.method = hc.Method
+	}
+	if hc.FailureThreshold > 0 {
+		policy.failureLimit = hc.FailureThreshold
+	}
+	return policy
+}
+
+// supervise runs health checks until the engine stops.
+func (e *DiscoveryEngine) supervise() {
+	ticker := time.NewTicker(superviseTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.checkServers(time.Now())
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkServers health checks every active server whose interval has passed
+// since its last check, and schedules restarts for servers that crashed or
+// failed too many checks. Servers are checked concurrently so one hung
+// server doesn't delay the rest.
+func (e *DiscoveryEngine) checkServers(now time.Time) {
+	type target struct {
+		worker ToolWorker
+		policy healthPolicy
+	}
+	targets := make(map[string]target)
+
+	e.mu.Lock()
+	for name, worker := range e.activeServers {
+		if pw, ok := worker.(PersistentWorker); ok && !pw.IsRunning() {
+			e.scheduleRestartLocked(name, worker, "crashed")
+			continue
+		}
+		policy := e.healthPolicyLocked(name)
+		if policy.interval == 0 {
+			continue
+		}
+		if last, ok := e.health[name]; ok && now.Sub(last.checked) < policy.interval {
+			continue
+		}
+		if st := e.restarts[name]; st != nil && st.pending {
+			continue
+		}
+		targets[name] = target{worker, policy}
+	}
+	e.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for name, t := range targets {
+		wg.Add(1)
+		go func(name string, t target) {
+			defer wg.Done()
+			started := time.Now()
+			err := checkWorker(t.worker, t.policy.method)
+			health := serverHealth{checked: time.Now(), latency: time.Since(started)}
+
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			if current, active := e.activeServers[name]; !active || current != t.worker {
+				return
+			}
+			if err != nil {
+				health.err = err.Error()
+				health.failures = e.health[name].failures + 1
+				logger.AddLog("WARN", fmt.Sprintf("[Discovery] Server %s failed its health check (%d/%d): %v", name, health.failures, t.policy.failureLimit, err))
+			}
+			e.health[name] = health
+			if health.failures >= t.policy.failureLimit {
+				e.scheduleRestartLocked(name, t.worker, "unresponsive")
+			}
+		}(name, t)
+	}
+	wg.Wait()
+}
+
+// checkWorker runs one health check. Workers that support neither method
+// always pass.
+func checkWorker(worker ToolWorker, method string) error {
+	if pw, ok := worker.(PersistentWorker); ok && method == "tools/list" {
+		done := make(chan error, 1)
+		go func() { done <- pw.RefreshTools() }()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(pingTimeout):
+			return fmt.Errorf("tools/list timed out after %v", pingTimeout)
+		}
+	}
+	if p, ok := worker.(pinger); ok {
+		return p.Ping(pingTimeout)
+	}
+	return nil
+}
+
+// restartsLocked returns the restart state of a server with restarts older
+// than an hour dropped; e.mu must be held.
+func (e *DiscoveryEngine) restartsLocked(serverName string, now time.Time) *restartState {
+	st, ok := e.restarts[serverName]
+	if !ok {
+		st = &restartState{}
+		e.restarts[serverName] = st
+	}
+	keep := 0
+	for keep < len(st.times) && now.Sub(st.times[keep]) >= time.Hour {
+		keep++
+	}
+	st.times = st.times[keep:]
+	return st
+}
+
+// scheduleRestartLocked restarts worker after a backoff that doubles with
+// each restart in the last hour, unless a restart is already pending, the
+// hourly cap is reached, or the server was removed or replaced meanwhile.
+// e.mu must be held.
+func (e *DiscoveryEngine) scheduleRestartLocked(serverName string, worker ToolWorker, reason string) {
+	limit := e.settings.MaxRestartsPerHour
+	if limit <= 0 {
+		return
+	}
+	now := time.Now()
+	st := e.restartsLocked(serverName, now)
+	if st.pending {
+		return
+	}
+	if len(st.times) >= limit {
+		if !st.exhausted {
+			st.exhausted = true
+			err := fmt.Errorf("restarted %d times in the last hour; not restarting again", len(st.times))
+			logger.AddLog("ERROR", fmt.Sprintf("[Discovery] Server %s %s: %v", serverName, reason, err))
+			e.noteErrorLocked(serverName, "", err)
+		}
+		return
+	}
+	st.exhausted = false
+
+	delay := restartBackoff << len(st.times)
+	if delay > maxRestartBackoff {
+		delay = maxRestartBackoff
+	}
+	attempt := len(st.times) + 1
+	st.times = append(st.times, now)
+	st.pending = true
+	logger.AddLog("WARN", fmt.Sprintf("[Discovery] Server %s %s; restarting in %v (%d/%d this hour)", serverName, reason, delay, attempt, limit))
+
+	go func() {
+		select {
+		case <-time.After(delay):
+		case <-e.ctx.Done():
+			return
+		}
+
+		e.mu.Lock()
+		st.pending = false
+		current, active := e.activeServers[serverName]
+		e.mu.Unlock()
+		if !active || current != worker {
+			return
+		}
+
+		err := e.Restart(serverName)
+		data := map[string]interface{}{"reason": reason, "attempt": attempt}
+		if err != nil {
+			data["error"] = err.Error()
+			logger.AddLog("ERROR", fmt.Sprintf("[Discovery] Failed to restart %s: %v", serverName, err))
+		} else {
+			logger.AddLog("INFO", fmt.Sprintf("[Discovery] Restarted %s", serverName))
+		}
+		e.mu.Lock()
+		e.recordEventLocked(events.ServerRestarted, serverName, data)
+		e.mu.Unlock()
+	}()
+}
+
+// restartStatusLocked returns how often a server was restarted in the last
+// hour and whether a restart is pending; e.mu must be held.
+func (e *DiscoveryEngine) restartStatusLocked(serverName string, now time.Time) (int, bool) {
+	st, ok := e.restarts[serverName]
+	if !ok {
+		return 0, false
+	}
+	count := 0
+	for _, t := range st.times {
+		if now.Sub(t) < time.Hour {
+			count++
+		}
+	}
+	return count, st.pending
+}
@@ -0,0 +1,101 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer is a persistent worker whose pings and liveness are scripted.
+type fakeServer struct {
+	running bool
+	pingErr error
+}
+
+func (f *fakeServer) Execute(io.Reader, io.Writer, map[string]string) error { return nil }
+func (f *fakeServer) Close() error                                          { return nil }
+func (f *fakeServer) Start(map[string]string) error                         { return nil }
+func (f *fakeServer) CallTool(string, map[string]interface{}) (*registry.JSONRPCResponse, error) {
+	return nil, nil
+}
+func (f *fakeServer) CallToolWithMeta(string, map[string]interface{}, map[string]interface{}) (*registry.JSONRPCResponse, error) {
+	return nil, nil
+}
+func (f *fakeServer) IsRunning() bool                  { return f.running }
+func (f *fakeServer) GetTools() []registry.Tool        { return nil }
+func (f *fakeServer) RefreshTools() error              { return f.pingErr }
+func (f *fakeServer) Ping(timeout time.Duration) error { return f.pingErr }
+
+func healthEngine(t *testing.T, maxRestarts int, workers map[string]*fakeServer) *DiscoveryEngine {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	e := NewDiscoveryEngine(ctx, "", "")
+	e.SetSettings(profile.Settings{MaxRestartsPerHour: maxRestarts})
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for name, w := range workers {
+		e.registry = append(e.registry, ToolDefinition{Name: name, Runtime: &registry.Runtime{
+			HealthCheck: &registry.HealthCheck{Enabled: true, Interval: 1000, FailureThreshold: 2},
+		}})
+		e.activeServers[name] = w
+	}
+	return e
+}
+
+func statusOf(e *DiscoveryEngine, name string) ServerStatus {
+	for _, s := range e.ServerStatuses() {
+		if s.Server == name {
+			return s
+		}
+	}
+	return ServerStatus{}
+}
+
+func TestHealthCheck_RestartsUnresponsiveServer(t *testing.T) {
+	e := healthEngine(t, 5, map[string]*fakeServer{"flaky": {running: true, pingErr: errors.New("timed out")}})
+
+	now := time.Now()
+	e.checkServers(now)
+	assert.Equal(t, "unhealthy", statusOf(e, "flaky").State)
+
+	// Not due again until the entry's interval has passed
+	e.checkServers(now.Add(500 * time.Millisecond))
+	assert.Equal(t, 1, e.health["flaky"].failures)
+
+	e.checkServers(now.Add(2 * time.Second))
+	status := statusOf(e, "flaky")
+	assert.Equal(t, "restarting", status.State)
+	assert.Equal(t, 1, status.Restarts)
+
+	// The entry can't be started again, so the restart leaves it inactive
+	require.Eventually(t, func() bool { return !e.IsActive("flaky") }, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestHealthCheck_RestartCap(t *testing.T) {
+	e := healthEngine(t, 1, map[string]*fakeServer{"dead": {running: false}})
+	e.restarts["dead"] = &restartState{times: []time.Time{time.Now().Add(-time.Minute)}}
+
+	e.checkServers(time.Now())
+	status := statusOf(e, "dead")
+	assert.Equal(t, "crashed", status.State)
+	assert.Equal(t, 1, status.Restarts)
+	require.NotEmpty(t, e.RecentErrors())
+	assert.Contains(t, e.RecentErrors()[0].Error, "not restarting again")
+
+	// Restarts older than an hour no longer count
+	e.restarts["dead"].times[0] = time.Now().Add(-2 * time.Hour)
+	e.checkServers(time.Now())
+	assert.Equal(t, "restarting", statusOf(e, "dead").State)
+
+	// Disabled when the cap is 0
+	off := healthEngine(t, 0, map[string]*fakeServer{"dead": {running: false}})
+	off.checkServers(time.Now())
+	assert.Equal(t, "crashed", statusOf(off, "dead").State)
+}
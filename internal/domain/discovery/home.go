@@ -0,0 +1,92 @@
+package discovery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// Servers with an isolated home (see SetHomeIsolation and the registry's
+// runtime.isolateHome) start with HOME and the XDG base directories pointing
+// into a directory of their own, so caches and config written by npx or pip
+// packages stay out of the user's home. The directory is created fresh on
+// each activation and deleted when the server stops. Variables the registry
+// entry or profile set explicitly, such as a shared XDG_CACHE_HOME, are kept.
+
+// SetHomeIsolation sets the directory under which isolated servers get their
+// homes, one subdirectory per server, and whether every server of the
+// profile is isolated rather than only those whose registry entry asks for
+// it. An empty dir disables isolation.
+func (e *DiscoveryEngine) SetHomeIsolation(dir string, all bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.homeDir = dir
+	e.isolateAll = all
+}
+
+// isolatedHome returns the home directory td's server runs with, or "" if
+// it isn't isolated. Callers must hold e.mu.
+func (e *DiscoveryEngine) isolatedHome(td *ToolDefinition) string {
+	if e.homeDir == "" || td.Source == "builtin" {
+		return ""
+	}
+	// Containers have a filesystem of their own
+	if td.Package != nil && td.Package.Type == registry.PackageDocker {
+		return ""
+	}
+	if !e.isolateAll && (td.Runtime == nil || !td.Runtime.IsolateHome) {
+		return ""
+	}
+	return filepath.Join(e.homeDir, td.Name)
+}
+
+// homeEnv is the environment pointing a process at home.
+func homeEnv(home string) map[string]string {
+	if runtime.GOOS == "windows" {
+		return map[string]string{
+			"USERPROFILE":  home,
+			"APPDATA":      filepath.Join(home, "AppData", "Roaming"),
+			"LOCALAPPDATA": filepath.Join(home, "AppData", "Local"),
+			"TEMP":         filepath.Join(home, "AppData", "Local", "Temp"),
+			"TMP":          filepath.Join(home, "AppData", "Local", "Temp"),
+		}
+	}
+	return map[string]string{
+		"HOME":            home,
+		"XDG_CONFIG_HOME": filepath.Join(home, ".config"),
+		"XDG_DATA_HOME":   filepath.Join(home, ".local", "share"),
+		"XDG_STATE_HOME":  filepath.Join(home, ".local", "state"),
+		"XDG_CACHE_HOME":  filepath.Join(home, ".cache"),
+	}
+}
+
+// prepareHome empties and creates an isolated home, including the
+// directories homeEnv points into.
+func prepareHome(home string) error {
+	if err := os.RemoveAll(home); err != nil {
+		return err
+	}
+	for _, dir := range homeEnv(home) {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+	return os.MkdirAll(home, 0700)
+}
+
+// removeHomeLocked deletes the isolated home of a stopped server, if it has
+// one. Callers must hold e.mu.
+func (e *DiscoveryEngine) removeHomeLocked(serverName string) {
+	home, ok := e.homes[serverName]
+	if !ok {
+		return
+	}
+	delete(e.homes, serverName)
+	if err := os.RemoveAll(home); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("[Discovery] Failed to remove isolated home of %s: %v", serverName, err))
+	}
+}
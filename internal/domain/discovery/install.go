@@ -0,0 +1,133 @@
+package discovery
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/download"
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// PlatformKey returns the name binary packages give this platform in their
+// platforms map, e.g. linux-x64 or darwin-arm64.
+func PlatformKey() string {
+	arch := runtime.GOARCH
+	if arch == "amd64" {
+		arch = "x64"
+	}
+	return runtime.GOOS + "-" + arch
+}
+
+// PackageVersionDir names the directory a binary package's version is
+// installed in under <package dir>/<server>.
+func PackageVersionDir(pkg *registry.Package) string {
+	if pkg.Version == "" {
+		return "current"
+	}
+	return pkg.Version
+}
+
+// SetPackageDir sets where binary packages are installed, one directory
+// per server and version; "" leaves binary packages uninstallable.
+func (e *DiscoveryEngine) SetPackageDir(dir string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.packageDir = dir
+}
+
+// installPackage downloads the WASM module or binary of an entry whose
+// package has one, unless it is already there. It returns the binary's path,
+// "" for other packages. Runs without the engine lock held.
+func (e *DiscoveryEngine) installPackage(serverName string, td *ToolDefinition) (string, error) {
+	pkg := td.Package
+	if pkg == nil {
+		return "", nil
+	}
+	e.mu.RLock()
+	packageDir := e.packageDir
+	e.mu.RUnlock()
+
+	switch pkg.Type {
+	case registry.PackageWASM:
+		if pkg.URL == "" {
+			return "", nil
+		}
+		src := download.Source{URLs: append([]string{pkg.URL}, pkg.Mirrors...), SHA256: pkg.SHA256}
+		return "", e.fetchPackage(serverName, src, filepath.Join(e.wasmDir, serverName+".wasm"))
+
+	case registry.PackageBinary:
+		bin, ok := pkg.Platforms[PlatformKey()]
+		if !ok {
+			return "", fmt.Errorf("%s has no binary for %s", serverName, PlatformKey())
+		}
+		if packageDir == "" {
+			return "", fmt.Errorf("binary packages are not available: no package directory")
+		}
+		// Binaries are run as they are, so never without checking them
+		if bin.SHA256 == "" {
+			return "", fmt.Errorf("%s has no sha256 for its %s binary", serverName, PlatformKey())
+		}
+		name := serverName
+		if u, err := url.Parse(bin.URL); err == nil && path.Base(u.Path) != "/" && path.Base(u.Path) != "." {
+			name = path.Base(u.Path)
+		}
+		dest := filepath.Join(packageDir, serverName, PackageVersionDir(pkg), name)
+		if !withinDir(filepath.Join(packageDir, serverName), dest) {
+			return "", fmt.Errorf("%s: package version %q or file name %q leaves the package directory", serverName, pkg.Version, name)
+		}
+		src := download.Source{URLs: append([]string{bin.URL}, bin.Mirrors...), SHA256: bin.SHA256}
+		if err := e.fetchPackage(serverName, src, dest); err != nil {
+			return "", err
+		}
+		if err := os.Chmod(dest, 0755); err != nil {
+			return "", err
+		}
+		return dest, nil
+	}
+	return "", nil
+}
+
+// withinDir reports whether path is inside dir.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// fetchPackage downloads a package file, recording its progress in the
+// event journal.
+func (e *DiscoveryEngine) fetchPackage(serverName string, src download.Source, dest string) error {
+	e.mu.RLock()
+	profileID := e.profileID
+	e.mu.RUnlock()
+
+	var started bool
+	err := download.Default.Fetch(e.ctx, src, dest, func(p download.Progress) {
+		if !started {
+			started = true
+			logger.AddLog("INFO", fmt.Sprintf("[Discovery] Downloading %s for %s", p.URL, serverName))
+		}
+		events.Record(events.PackageDownload, profileID, serverName, map[string]interface{}{
+			"status": "downloading",
+			"url":    p.URL,
+			"bytes":  p.Bytes,
+			"total":  p.Total,
+		})
+	})
+	if err != nil {
+		logger.AddLog("ERROR", fmt.Sprintf("[Discovery] %v", err))
+		events.Record(events.PackageDownload, profileID, serverName, map[string]interface{}{"status": "failed", "error": err.Error()})
+		return err
+	}
+	if started {
+		logger.AddLog("INFO", fmt.Sprintf("[Discovery] Installed %s", dest))
+		events.Record(events.PackageDownload, profileID, serverName, map[string]interface{}{"status": "installed", "path": dest})
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package discovery
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithinDir(t *testing.T) {
+	dir := filepath.Join("packages", "tool")
+	assert.True(t, withinDir(dir, filepath.Join(dir, "1.0.0", "tool")))
+	assert.False(t, withinDir(dir, filepath.Join(dir, "..", "..", ".local", "bin", "tool")))
+	assert.False(t, withinDir(dir, filepath.Join(dir, "1.0.0", "..", "..")))
+	assert.False(t, withinDir(dir, dir))
+}
+
+func TestInstallPackage_BinaryNeedsChecksum(t *testing.T) {
+	e := NewDiscoveryEngine(context.Background(), "", "")
+	e.SetPackageDir(t.TempDir())
+	td := &ToolDefinition{Package: &registry.Package{
+		Type:      registry.PackageBinary,
+		Platforms: map[string]registry.PlatformBinary{PlatformKey(): {URL: "https://example.invalid/tool"}},
+	}}
+	_, err := e.installPackage("tool", td)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sha256")
+}
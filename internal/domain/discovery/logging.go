@@ -0,0 +1,130 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// mcpLogLevels ranks the syslog-style levels used by MCP logging.
+var mcpLogLevels = map[string]int{
+	"debug":     0,
+	"info":      1,
+	"notice":    2,
+	"warning":   3,
+	"error":     4,
+	"critical":  5,
+	"alert":     6,
+	"emergency": 7,
+}
+
+// IsLogLevel reports whether level is a valid MCP logging level.
+func IsLogLevel(level string) bool {
+	_, ok := mcpLogLevels[strings.ToLower(level)]
+	return ok
+}
+
+// LogLevelAtLeast reports whether an MCP log level is at or above min.
+// Unknown levels are treated as "info"; an empty min lets everything through.
+func LogLevelAtLeast(level, min string) bool {
+	if min == "" {
+		return true
+	}
+	rank := func(l string) int {
+		if r, ok := mcpLogLevels[strings.ToLower(l)]; ok {
+			return r
+		}
+		return mcpLogLevels["info"]
+	}
+	return rank(level) >= rank(min)
+}
+
+// loggerLevel maps an MCP log level onto the levels used by the logger package.
+func loggerLevel(level string) string {
+	rank, ok := mcpLogLevels[strings.ToLower(level)]
+	switch {
+	case !ok:
+		return "INFO"
+	case rank >= mcpLogLevels["error"]:
+		return "ERROR"
+	case rank == mcpLogLevels["warning"]:
+		return "WARN"
+	case rank == mcpLogLevels["debug"]:
+		return "DEBUG"
+	default:
+		return "INFO"
+	}
+}
+
+// LogMessage is a notifications/message sent by a downstream server.
+type LogMessage struct {
+	Level  string      `json:"level"`
+	Logger string      `json:"logger,omitempty"`
+	Data   interface{} `json:"data"`
+}
+
+// LogCallback is called for every server log message that passes the
+// server's minimum level.
+type LogCallback func(serverName string, msg LogMessage)
+
+// SetLogCallback sets the function that receives downstream log messages.
+func (e *DiscoveryEngine) SetLogCallback(cb LogCallback) {
+	e.logMu.Lock()
+	defer e.logMu.Unlock()
+	e.logCallback = cb
+}
+
+// SetLogLevels sets the minimum MCP log level per server name. Messages below
+// it are dropped, and servers that support logging are asked not to send them.
+func (e *DiscoveryEngine) SetLogLevels(levels map[string]string) {
+	e.logMu.Lock()
+	defer e.logMu.Unlock()
+	e.logLevels = levels
+}
+
+func (e *DiscoveryEngine) minLogLevel(serverName string) string {
+	e.logMu.RLock()
+	defer e.logMu.RUnlock()
+	return e.logLevels[serverName]
+}
+
+// serverNotificationHandler handles notifications from a downstream server.
+// It runs on the worker's read loop, so it must not take e.mu: Add holds it
+// for the whole handshake.
+func (e *DiscoveryEngine) serverNotificationHandler(serverName string) NotificationHandler {
+	return func(method string, params json.RawMessage) {
+		if method != "notifications/message" {
+			logger.AddLog("DEBUG", fmt.Sprintf("[%s] Received notification %s", serverName, method))
+			return
+		}
+
+		var msg LogMessage
+		if err := json.Unmarshal(params, &msg); err != nil {
+			logger.AddLog("WARN", fmt.Sprintf("[%s] Malformed log notification: %v", serverName, err))
+			return
+		}
+		if !LogLevelAtLeast(msg.Level, e.minLogLevel(serverName)) {
+			return
+		}
+
+		source := serverName
+		if msg.Logger != "" {
+			source += "/" + msg.Logger
+		}
+		text, ok := msg.Data.(string)
+		if !ok {
+			data, _ := json.Marshal(msg.Data)
+			text = string(data)
+		}
+		logger.AddLog(loggerLevel(msg.Level), fmt.Sprintf("[%s] %s", source, logger.TruncateForLog(text, 2048)))
+
+		e.logMu.RLock()
+		cb := e.logCallback
+		e.logMu.RUnlock()
+		if cb != nil {
+			cb(serverName, msg)
+		}
+	}
+}
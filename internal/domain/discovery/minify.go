@@ -0,0 +1,127 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+)
+
+const (
+	// DefaultMinifyDescriptionChars is how long minified descriptions are
+	// when the profile doesn't say.
+	DefaultMinifyDescriptionChars = 120
+	// maxMinifiedEnum is the longest enum a minified schema keeps.
+	maxMinifiedEnum = 8
+)
+
+// MinifyTool returns a copy of t for a compact tools/list: descriptions are
+// cut to maxChars characters, enums longer than maxMinifiedEnum are dropped
+// with a note in the description, and the sample input and Scooter-specific
+// annotations are left out. The standard MCP behaviour hints are kept.
+// scooter_expand returns the full definition.
+func MinifyTool(t registry.Tool, maxChars int) registry.Tool {
+	if maxChars <= 0 {
+		maxChars = DefaultMinifyDescriptionChars
+	}
+	t.Description = shortenDescription(t.Description, maxChars)
+	t.SampleInput = nil
+	if a := t.Annotations; a != nil {
+		t.Annotations = &registry.ToolAnnotations{
+			ReadOnlyHint:    a.ReadOnlyHint,
+			DestructiveHint: a.DestructiveHint,
+			IdempotentHint:  a.IdempotentHint,
+			OpenWorldHint:   a.OpenWorldHint,
+		}
+	}
+	if s := t.InputSchema; s != nil {
+		t.InputSchema = &registry.JSONSchema{
+			Type:       s.Type,
+			Properties: minifyProperties(s.Properties, maxChars),
+			Required:   s.Required,
+		}
+		if s.Items != nil {
+			items := minifyProperty(*s.Items, maxChars)
+			t.InputSchema.Items = &items
+		}
+	}
+	return t
+}
+
+// minifyProperties minifies a copy of props; the originals may be shared
+// with the registry catalog.
+func minifyProperties(props map[string]registry.PropertySchema, maxChars int) map[string]registry.PropertySchema {
+	if props == nil {
+		return nil
+	}
+	out := make(map[string]registry.PropertySchema, len(props))
+	for name, p := range props {
+		out[name] = minifyProperty(p, maxChars)
+	}
+	return out
+}
+
+func minifyProperty(p registry.PropertySchema, maxChars int) registry.PropertySchema {
+	p.Description = shortenDescription(p.Description, maxChars)
+	if len(p.Enum) > maxMinifiedEnum {
+		note := fmt.Sprintf("one of %d values, see scooter_expand", len(p.Enum))
+		if p.Description != "" {
+			p.Description += " (" + note + ")"
+		} else {
+			p.Description = strings.ToUpper(note[:1]) + note[1:]
+		}
+		p.Enum = nil
+	}
+	if p.Items != nil {
+		items := minifyProperty(*p.Items, maxChars)
+		p.Items = &items
+	}
+	p.Properties = minifyProperties(p.Properties, maxChars)
+	return p
+}
+
+// shortenDescription keeps the first line of s, cut to maxChars characters.
+func shortenDescription(s string, maxChars int) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	if utf8.RuneCountInString(s) <= maxChars {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimRight(string(runes[:maxChars-1]), " ,.;:") + "…"
+}
+
+// ActiveTool returns the full definition of an active server's tool and the
+// server that provides it.
+func (e *DiscoveryEngine) ActiveTool(name string) (registry.Tool, string, bool) {
+	e.mu.RLock()
+	serverName, ok := e.toolToServer[name]
+	e.mu.RUnlock()
+	if !ok {
+		return registry.Tool{}, "", false
+	}
+	for _, t := range e.GetActiveToolsForServer(serverName) {
+		if t.Name == name {
+			return t, serverName, true
+		}
+	}
+	return registry.Tool{}, "", false
+}
+
+// handleExpandTool implements scooter_expand: the unminified definition of
+// an active tool.
+func (e *DiscoveryEngine) handleExpandTool(params map[string]interface{}) (interface{}, error) {
+	name, _ := params["tool_name"].(string)
+	if name == "" {
+		return nil, errors.New(e.msg("builtin.expand.tool_name_required"))
+	}
+	tool, serverName, ok := e.ActiveTool(name)
+	if !ok {
+		return nil, errors.New(e.msg("builtin.expand.not_active", name))
+	}
+	return map[string]interface{}{"server": serverName, "tool": tool}, nil
+}
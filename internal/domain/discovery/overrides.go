@@ -0,0 +1,207 @@
+package discovery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+)
+
+// Overrides change registry entries without editing their files: the JSON
+// merge patch (RFC 7396) in registry/overrides/<name>.json is applied to the
+// entry <name> whenever the registry is loaded, so an official entry keeps
+// both its upstream updates and the local change. A patch that would rename
+// the entry or make it invalid is ignored with a warning.
+
+// OverridePath returns the file of the override of entry name.
+func OverridePath(registryDir, name string) string {
+	return filepath.Join(registryDir, "overrides", name+".json")
+}
+
+// readOverrides returns the patches in registryDir/overrides by entry name.
+func readOverrides(registryDir string) map[string][]byte {
+	dir := filepath.Join(registryDir, "overrides")
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	overrides := make(map[string][]byte, len(files))
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), ".json")
+		if file.IsDir() || !ok {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			fmt.Printf("Warning: failed to read override %s: %v\n", file.Name(), err)
+			continue
+		}
+		overrides[name] = data
+	}
+	return overrides
+}
+
+// applyOverrides returns the definitions of files, overridden where there
+// is an override for them.
+func applyOverrides(registryDir string, files []catalogFile) []ToolDefinition {
+	overrides := readOverrides(registryDir)
+	defs := make([]ToolDefinition, 0, len(files))
+	for _, f := range files {
+		def := f.def
+		if patch, ok := overrides[def.Name]; ok {
+			if patched, err := overrideDefinition(f, patch); err != nil {
+				fmt.Printf("Warning: ignoring override of %s: %v\n", def.Name, err)
+			} else {
+				def = patched
+			}
+		}
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+// overrideDefinition applies patch to the entry of f.
+func overrideDefinition(f catalogFile, patch []byte) (ToolDefinition, error) {
+	base, err := json.Marshal(f.entry)
+	if err != nil {
+		return ToolDefinition{}, err
+	}
+	merged, err := MergePatch(base, patch)
+	if err != nil {
+		return ToolDefinition{}, err
+	}
+	var entry registry.MCPEntry
+	if err := json.Unmarshal(merged, &entry); err != nil {
+		return ToolDefinition{}, fmt.Errorf("patched entry: %w", err)
+	}
+	if entry.Name != f.entry.Name {
+		return ToolDefinition{}, fmt.Errorf("an override can't rename %s", f.entry.Name)
+	}
+	if result := registry.ValidateCustom(&entry); !result.Valid {
+		return ToolDefinition{}, fmt.Errorf("%w: %v", ErrInvalidEntry, result.Errors)
+	}
+	td := DefinitionFromEntry(&entry, f.subdir)
+	td.Overridden = true
+	return td, nil
+}
+
+// MergePatch applies a JSON merge patch (RFC 7396) to doc: objects are
+// merged key by key, null removes a key, and anything else replaces the
+// value, arrays included.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+	var d interface{}
+	if len(doc) > 0 {
+		if err := json.Unmarshal(doc, &d); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(mergeValue(d, p))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	fields, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	merged, ok := target.(map[string]interface{})
+	if !ok {
+		merged = make(map[string]interface{}, len(fields))
+	}
+	for key, value := range fields {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = mergeValue(merged[key], value)
+	}
+	return merged
+}
+
+// DefinitionLayers is an entry as one engine loads it.
+type DefinitionLayers struct {
+	Base      ToolDefinition  `json:"base"`               // As in its file
+	Effective ToolDefinition  `json:"effective"`          // With the override applied
+	Override  json.RawMessage `json:"override,omitempty"` // The merge patch, if there is one
+	Error     string          `json:"error,omitempty"`    // Why the override is ignored
+}
+
+// LoadDefinitionLayers returns the entry name as the engine of the profile
+// overlay ("" for none) loads it, before and after its override. It returns
+// fs.ErrNotExist if no registry file defines the entry.
+func LoadDefinitionLayers(registryDir, overlay, name string) (*DefinitionLayers, error) {
+	files := catalogFiles(registryDir)
+	shared := len(files)
+	if overlay != "" {
+		files = append(files, overlayFiles(registryDir, overlay)...)
+	}
+	found := -1
+	for i := range files {
+		if files[i].def.Name == name {
+			found = i
+		}
+	}
+	if found < 0 {
+		return nil, fs.ErrNotExist
+	}
+
+	f := files[found]
+	layers := &DefinitionLayers{Base: f.def, Effective: f.def}
+	if patch, err := os.ReadFile(OverridePath(registryDir, name)); err == nil {
+		layers.Override = patch
+		if td, err := overrideDefinition(f, patch); err != nil {
+			layers.Error = err.Error()
+		} else {
+			layers.Effective = td
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if found >= shared {
+		layers.Base.Overlay = overlay
+		layers.Effective.Overlay = overlay
+	}
+	return layers, nil
+}
+
+// WriteOverride saves patch as the override of entry name. The patch must be
+// a JSON object, and must leave the shared entry of that name, if there is
+// one, valid; otherwise the error wraps ErrInvalidEntry.
+func WriteOverride(registryDir, name string, patch []byte) error {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(patch, &fields); err != nil {
+		return fmt.Errorf("%w: an override must be a JSON object: %v", ErrInvalidEntry, err)
+	}
+	for _, f := range catalogFiles(registryDir) {
+		if f.def.Name != name {
+			continue
+		}
+		if _, err := overrideDefinition(f, patch); err != nil {
+			if !errors.Is(err, ErrInvalidEntry) {
+				err = fmt.Errorf("%w: %v", ErrInvalidEntry, err)
+			}
+			return err
+		}
+	}
+	customMu.Lock()
+	defer customMu.Unlock()
+	return writeFileAtomic(OverridePath(registryDir, name), patch)
+}
+
+// RemoveOverride deletes the override of entry name, if there is one.
+func RemoveOverride(registryDir, name string) error {
+	customMu.Lock()
+	defer customMu.Unlock()
+	if err := os.Remove(OverridePath(registryDir, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
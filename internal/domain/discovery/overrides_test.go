@@ -0,0 +1,30 @@
+package discovery_test
+
+import (
+	"testing"
+
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":["c","d"]}`, `{"a":["c","d"]}`},
+		{`{"a":{"b":"c","d":"e"}}`, `{"a":{"b":"f","d":null}}`, `{"a":{"b":"f"}}`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`["a"]`, `{"a":"b"}`, `{"a":"b"}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+	}
+	for _, c := range cases {
+		got, err := discovery.MergePatch([]byte(c.doc), []byte(c.patch))
+		require.NoError(t, err)
+		assert.JSONEq(t, c.want, string(got), "%s + %s", c.doc, c.patch)
+	}
+
+	_, err := discovery.MergePatch([]byte(`{}`), []byte(`{`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package discovery
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+)
+
+// builtinReplacer rewrites builtin tool names from the default prefix to
+// prefix. Longer names go first so a name that extends another is replaced
+// whole.
+func builtinReplacer(prefix string) *strings.Replacer {
+	var names []string
+	for _, td := range PrimordialTools() {
+		for _, t := range td.Tools {
+			names = append(names, t.Name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	pairs := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		pairs = append(pairs, name, prefix+strings.TrimPrefix(name, profile.DefaultBuiltinPrefix))
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// WithBuiltinPrefix returns the tools of a builtin definition renamed to use
+// prefix instead of "scooter_". Descriptions are rewritten too, since they
+// tell agents which other builtin to call next.
+func WithBuiltinPrefix(td ToolDefinition, prefix string) []registry.Tool {
+	if prefix == "" || prefix == profile.DefaultBuiltinPrefix {
+		return td.Tools
+	}
+	r := builtinReplacer(prefix)
+	tools := make([]registry.Tool, len(td.Tools))
+	for i, t := range td.Tools {
+		t.Name = r.Replace(t.Name)
+		t.Description = r.Replace(t.Description)
+		if t.InputSchema != nil {
+			schema := *t.InputSchema
+			schema.Properties = make(map[string]registry.PropertySchema, len(t.InputSchema.Properties))
+			for name, prop := range t.InputSchema.Properties {
+				prop.Description = r.Replace(prop.Description)
+				schema.Properties[name] = prop
+			}
+			t.InputSchema = &schema
+		}
+		tools[i] = t
+	}
+	return tools
+}
+
+// RenameBuiltins rewrites mentions of builtin tools in text, such as a
+// builtin's result telling the agent to call scooter_add, to use prefix.
+func RenameBuiltins(text, prefix string) string {
+	if prefix == "" || prefix == profile.DefaultBuiltinPrefix {
+		return text
+	}
+	return builtinReplacer(prefix).Replace(text)
+}
+
+// IsBuiltinTool reports whether name is a builtin tool's default name.
+func IsBuiltinTool(name string) bool {
+	return isPrimordial(name)
+}
+
+// CanonicalBuiltinName maps a builtin tool name using prefix back to its
+// "scooter_" name. Other names, including builtins already called by their
+// default name, are returned unchanged.
+func CanonicalBuiltinName(name, prefix string) string {
+	if prefix == "" || prefix == profile.DefaultBuiltinPrefix || !strings.HasPrefix(name, prefix) {
+		return name
+	}
+	canonical := profile.DefaultBuiltinPrefix + strings.TrimPrefix(name, prefix)
+	if isPrimordial(canonical) {
+		return canonical
+	}
+	return name
+}
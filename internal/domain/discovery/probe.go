@@ -0,0 +1,412 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+)
+
+// Probe connects to an arbitrary MCP server, outside any profile, and
+// reports how well it follows the protocol. It backs "scooter probe" and
+// POST /api/probe, for trying a server before writing a registry entry.
+
+// Probe check statuses.
+const (
+	ProbeStatusPass = "pass"
+	ProbeStatusWarn = "warn"
+	ProbeStatusFail = "fail"
+	ProbeStatusSkip = "skip"
+)
+
+// DefaultProbeTimeout bounds the handshake and each request of a probe.
+const DefaultProbeTimeout = 30 * time.Second
+
+// toolNamePattern is what MCP clients commonly accept as a tool name.
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ProbeOptions selects the server to probe: a command for stdio, or a URL.
+type ProbeOptions struct {
+	Command   string                 `json:"command,omitempty"`
+	Args      []string               `json:"args,omitempty"`
+	URL       string                 `json:"url,omitempty"`
+	Transport registry.TransportType `json:"transport,omitempty"` // For URLs: sse, streamable-http, or "" to detect
+	Headers   map[string]string      `json:"headers,omitempty"`
+	Env       map[string]string      `json:"env,omitempty"`
+	Timeout   time.Duration          `json:"-"`
+
+	// CallTool, if set, is called with CallArguments after listing
+	CallTool      string                 `json:"tool,omitempty"`
+	CallArguments map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// ProbeCheck is the outcome of one step of a probe.
+type ProbeCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+// ProbeTool summarises one tool the server listed.
+type ProbeTool struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Issues      []string `json:"issues,omitempty"`
+}
+
+// ProbeItem is a resource or prompt the server listed.
+type ProbeItem struct {
+	Name        string `json:"name"`
+	URI         string `json:"uri,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// ProbeReport is the result of a probe.
+type ProbeReport struct {
+	Target          string                    `json:"target"`
+	Transport       registry.TransportType    `json:"transport"`
+	ServerInfo      map[string]interface{}    `json:"server_info,omitempty"`
+	ProtocolVersion string                    `json:"protocol_version,omitempty"`
+	Capabilities    map[string]interface{}    `json:"capabilities,omitempty"`
+	Tools           []ProbeTool               `json:"tools"`
+	Resources       []ProbeItem               `json:"resources"`
+	Prompts         []ProbeItem               `json:"prompts"`
+	ToolCall        *registry.JSONRPCResponse `json:"tool_call,omitempty"`
+	Checks          []ProbeCheck              `json:"checks"`
+	Compatible      bool                      `json:"compatible"`
+
+	options     ProbeOptions
+	definitions []registry.Tool // Tools as parsed into registry form, for RegistryEntry
+}
+
+// probeClient is what a probe needs from StdioWorker and RemoteWorker.
+type probeClient interface {
+	Start(env map[string]string) error
+	Request(method string, params interface{}, timeout time.Duration) (*registry.JSONRPCResponse, error)
+	Capabilities() map[string]interface{}
+	ServerInfo() (map[string]interface{}, string)
+	Ping(timeout time.Duration) error
+	Close() error
+}
+
+// Probe connects to the server in opts, performs the handshake, lists its
+// tools, resources and prompts, optionally calls one tool, and reports each
+// step. Only invalid options return an error; a server that misbehaves
+// produces a report with failed checks and Compatible false.
+func Probe(ctx context.Context, opts ProbeOptions) (*ProbeReport, error) {
+	if (opts.Command == "") == (opts.URL == "") {
+		return nil, fmt.Errorf("exactly one of command or url is required")
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultProbeTimeout
+	}
+
+	report := &ProbeReport{Tools: []ProbeTool{}, Resources: []ProbeItem{}, Prompts: []ProbeItem{}, options: opts}
+	var client probeClient
+	if opts.URL != "" {
+		if !strings.HasPrefix(opts.URL, "http://") && !strings.HasPrefix(opts.URL, "https://") {
+			return nil, fmt.Errorf("url must start with http:// or https://")
+		}
+		switch opts.Transport {
+		case "", registry.TransportSSE, registry.TransportHTTP, registry.TransportStreamableHTTP:
+		default:
+			return nil, fmt.Errorf("invalid transport for a url: %s", opts.Transport)
+		}
+		report.Target = opts.URL
+		worker := NewRemoteWorker(ctx, opts.URL, opts.Transport, opts.Headers)
+		worker.SetStartupOptions(timeout)
+		client = worker
+		defer func() { report.Transport = worker.Transport() }()
+	} else {
+		report.Target = strings.TrimSpace(opts.Command + " " + strings.Join(opts.Args, " "))
+		report.Transport = registry.TransportStdio
+		worker := NewStdioWorker(ctx, opts.Command, opts.Args)
+		worker.SetStartupOptions(timeout, 0)
+		client = worker
+	}
+
+	check := func(name string, fn func() (string, string)) {
+		start := time.Now()
+		status, detail := fn()
+		report.Checks = append(report.Checks, ProbeCheck{
+			Name:       name,
+			Status:     status,
+			DurationMs: time.Since(start).Milliseconds(),
+			Detail:     detail,
+		})
+	}
+
+	started := false
+	check("initialize", func() (string, string) {
+		if err := client.Start(opts.Env); err != nil {
+			return ProbeStatusFail, err.Error()
+		}
+		started = true
+		return ProbeStatusPass, ""
+	})
+	if !started {
+		client.Close()
+		return report, nil
+	}
+	defer client.Close()
+
+	report.Capabilities = client.Capabilities()
+	report.ServerInfo, report.ProtocolVersion = client.ServerInfo()
+	check("server_info", func() (string, string) {
+		var problems []string
+		if name, _ := report.ServerInfo["name"].(string); name == "" {
+			problems = append(problems, "serverInfo.name is missing")
+		}
+		if report.ProtocolVersion == "" {
+			problems = append(problems, "protocolVersion is missing")
+		} else if report.ProtocolVersion != mcpProtocolVersion {
+			problems = append(problems, fmt.Sprintf("negotiated protocol version %s, requested %s", report.ProtocolVersion, mcpProtocolVersion))
+		}
+		if len(problems) > 0 {
+			return ProbeStatusWarn, strings.Join(problems, "; ")
+		}
+		return ProbeStatusPass, ""
+	})
+
+	check("tools/list", func() (string, string) {
+		items, err := probeList(client, "tools/list", "tools", timeout)
+		if err != nil {
+			return probeListFailure("tools", report.Capabilities, err)
+		}
+		issues := 0
+		for _, item := range items {
+			tool := lintProbeTool(item)
+			var def registry.Tool
+			data, _ := json.Marshal(item)
+			if err := json.Unmarshal(data, &def); err != nil {
+				tool.Issues = append(tool.Issues, fmt.Sprintf("definition does not fit a registry entry: %v", err))
+			} else {
+				report.definitions = append(report.definitions, def)
+			}
+			issues += len(tool.Issues)
+			report.Tools = append(report.Tools, tool)
+		}
+		detail := fmt.Sprintf("%d tools", len(report.Tools))
+		if issues > 0 {
+			return ProbeStatusWarn, fmt.Sprintf("%s, %d definition issues", detail, issues)
+		}
+		return ProbeStatusPass, detail
+	})
+
+	check("resources/list", func() (string, string) {
+		items, err := probeList(client, "resources/list", "resources", timeout)
+		if err != nil {
+			return probeListFailure("resources", report.Capabilities, err)
+		}
+		for _, item := range items {
+			report.Resources = append(report.Resources, probeItem(item))
+		}
+		return ProbeStatusPass, fmt.Sprintf("%d resources", len(report.Resources))
+	})
+
+	check("prompts/list", func() (string, string) {
+		items, err := probeList(client, "prompts/list", "prompts", timeout)
+		if err != nil {
+			return probeListFailure("prompts", report.Capabilities, err)
+		}
+		for _, item := range items {
+			report.Prompts = append(report.Prompts, probeItem(item))
+		}
+		return ProbeStatusPass, fmt.Sprintf("%d prompts", len(report.Prompts))
+	})
+
+	check("ping", func() (string, string) {
+		if err := client.Ping(timeout); err != nil {
+			// ping is optional for servers; many older ones lack it
+			return ProbeStatusWarn, err.Error()
+		}
+		return ProbeStatusPass, ""
+	})
+
+	if opts.CallTool != "" {
+		check("tools/call "+opts.CallTool, func() (string, string) {
+			args := opts.CallArguments
+			if args == nil {
+				args = map[string]interface{}{}
+			}
+			resp, err := client.Request("tools/call", map[string]interface{}{"name": opts.CallTool, "arguments": args}, timeout)
+			if err != nil {
+				return ProbeStatusFail, err.Error()
+			}
+			report.ToolCall = resp
+			if resp.Error != nil {
+				return ProbeStatusFail, fmt.Sprintf("%s (code: %d)", resp.Error.Message, resp.Error.Code)
+			}
+			result, _ := resp.Result.(map[string]interface{})
+			if _, ok := result["content"].([]interface{}); !ok {
+				return ProbeStatusFail, "result has no content array"
+			}
+			if isError, _ := result["isError"].(bool); isError {
+				return ProbeStatusWarn, "tool reported an error: " + contentText(result)
+			}
+			return ProbeStatusPass, ""
+		})
+	}
+
+	report.Compatible = true
+	for _, c := range report.Checks {
+		if c.Status == ProbeStatusFail {
+			report.Compatible = false
+		}
+	}
+	return report, nil
+}
+
+// probeList fetches every page of a list method and returns the items
+// under key.
+func probeList(client probeClient, method, key string, timeout time.Duration) ([]map[string]interface{}, error) {
+	var items []map[string]interface{}
+	cursor := ""
+	for {
+		var params interface{}
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		resp, err := client.Request(method, params, timeout)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Error != nil {
+			return nil, fmt.Errorf("%s (code: %d)", resp.Error.Message, resp.Error.Code)
+		}
+		var page map[string]json.RawMessage
+		data, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("result is not an object: %w", err)
+		}
+		var pageItems []map[string]interface{}
+		if err := json.Unmarshal(page[key], &pageItems); err != nil || page[key] == nil {
+			return nil, fmt.Errorf("result has no %s array", key)
+		}
+		var next string
+		json.Unmarshal(page["nextCursor"], &next)
+		items = append(items, pageItems...)
+		if next == "" || next == cursor {
+			return items, nil
+		}
+		cursor = next
+	}
+}
+
+// probeListFailure grades a failed list: servers need not implement lists
+// for capabilities they don't advertise.
+func probeListFailure(capability string, capabilities map[string]interface{}, err error) (string, string) {
+	if _, advertised := capabilities[capability]; !advertised {
+		return ProbeStatusSkip, fmt.Sprintf("%s capability not advertised (%v)", capability, err)
+	}
+	return ProbeStatusFail, err.Error()
+}
+
+// lintProbeTool summarises a tool definition and notes what clients are
+// likely to reject.
+func lintProbeTool(item map[string]interface{}) ProbeTool {
+	tool := ProbeTool{}
+	tool.Name, _ = item["name"].(string)
+	tool.Description, _ = item["description"].(string)
+	if tool.Name == "" {
+		tool.Issues = append(tool.Issues, "name is missing")
+	} else if !toolNamePattern.MatchString(tool.Name) {
+		tool.Issues = append(tool.Issues, "name should match ^[a-zA-Z0-9_-]{1,64}$")
+	}
+	if strings.TrimSpace(tool.Description) == "" {
+		tool.Issues = append(tool.Issues, "description is missing")
+	}
+	schema, ok := item["inputSchema"].(map[string]interface{})
+	switch {
+	case item["inputSchema"] == nil:
+		tool.Issues = append(tool.Issues, "inputSchema is missing")
+	case !ok:
+		tool.Issues = append(tool.Issues, "inputSchema is not an object")
+	case schema["type"] != "object":
+		tool.Issues = append(tool.Issues, `inputSchema.type should be "object"`)
+	}
+	return tool
+}
+
+func probeItem(item map[string]interface{}) ProbeItem {
+	var p ProbeItem
+	p.Name, _ = item["name"].(string)
+	p.URI, _ = item["uri"].(string)
+	p.Description, _ = item["description"].(string)
+	return p
+}
+
+// Text renders the report for a terminal.
+func (r *ProbeReport) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Target:    %s\n", r.Target)
+	fmt.Fprintf(&b, "Transport: %s\n", r.Transport)
+	if name, _ := r.ServerInfo["name"].(string); name != "" {
+		version, _ := r.ServerInfo["version"].(string)
+		fmt.Fprintf(&b, "Server:    %s %s\n", name, version)
+	}
+	if r.ProtocolVersion != "" {
+		fmt.Fprintf(&b, "Protocol:  %s\n", r.ProtocolVersion)
+	}
+
+	b.WriteString("\nChecks:\n")
+	for _, c := range r.Checks {
+		fmt.Fprintf(&b, "  [%s] %-24s %5dms", strings.ToUpper(c.Status), c.Name, c.DurationMs)
+		if c.Detail != "" {
+			fmt.Fprintf(&b, "  %s", c.Detail)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Tools) > 0 {
+		b.WriteString("\nTools:\n")
+		for _, t := range r.Tools {
+			fmt.Fprintf(&b, "  %s\n", t.Name)
+			for _, issue := range t.Issues {
+				fmt.Fprintf(&b, "    ! %s\n", issue)
+			}
+		}
+	}
+	if len(r.Resources) > 0 {
+		b.WriteString("\nResources:\n")
+		for _, res := range r.Resources {
+			fmt.Fprintf(&b, "  %s  %s\n", res.Name, res.URI)
+		}
+	}
+	if len(r.Prompts) > 0 {
+		b.WriteString("\nPrompts:\n")
+		for _, p := range r.Prompts {
+			fmt.Fprintf(&b, "  %s\n", p.Name)
+		}
+	}
+	if r.ToolCall != nil && r.ToolCall.Error == nil {
+		result, _ := r.ToolCall.Result.(map[string]interface{})
+		fmt.Fprintf(&b, "\nTool result:\n  %s\n", strings.ReplaceAll(contentText(result), "\n", "\n  "))
+	}
+
+	if r.Compatible {
+		b.WriteString("\nResult: compatible\n")
+	} else {
+		b.WriteString("\nResult: NOT compatible\n")
+	}
+	return b.String()
+}
+
+// contentText joins the text items of a tools/call result.
+func contentText(result map[string]interface{}) string {
+	content, _ := result["content"].([]interface{})
+	var parts []string
+	for _, c := range content {
+		item, _ := c.(map[string]interface{})
+		if text, ok := item["text"].(string); ok {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,220 @@
+package discovery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+)
+
+// A successful probe has everything a registry entry needs: how to start or
+// reach the server and the tools it offers, with schemas. RegistryEntry
+// turns the report into one and SaveRegistryEntry writes it to
+// registry/custom, so an ad-hoc server becomes a catalog entry.
+
+// ErrEntryExists is returned by SaveRegistryEntry when the custom registry
+// already has an entry with the name and overwriting was not asked for.
+var ErrEntryExists = errors.New("registry entry already exists")
+
+var (
+	entryNameCleaner = regexp.MustCompile(`[^a-z0-9]+`)
+	semverPattern    = regexp.MustCompile(`^\d+\.\d+\.\d+(-[a-zA-Z0-9.]+)?$`)
+)
+
+// EntryOptions overrides what RegistryEntry derives from the probe. Empty
+// fields are derived.
+type EntryOptions struct {
+	Name        string            `json:"name,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Category    registry.Category `json:"category,omitempty"`
+	Overwrite   bool              `json:"overwrite,omitempty"`
+}
+
+// RegistryEntry builds a custom registry entry from a compatible probe of a
+// stdio command or a remote URL. Environment variables the probe was given
+// become credentials the entry asks for; their values are not kept, and
+// headers keep their ${VAR} references.
+func (r *ProbeReport) RegistryEntry(opts EntryOptions) (*registry.MCPEntry, error) {
+	if !r.Compatible {
+		return nil, fmt.Errorf("the server did not pass the probe")
+	}
+	if len(r.definitions) == 0 {
+		return nil, fmt.Errorf("the server listed no usable tools")
+	}
+
+	serverName, _ := r.ServerInfo["name"].(string)
+	pkg := packageFromCommand(r.options.Command, r.options.Args)
+
+	name := opts.Name
+	if name == "" {
+		name = serverName
+		if name == "" && pkg != nil {
+			name = pkg.Name
+		}
+		if name == "" && r.options.URL != "" {
+			if u, err := url.Parse(r.options.URL); err == nil {
+				name = u.Hostname()
+			}
+		}
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(r.options.Command), filepath.Ext(r.options.Command))
+		}
+		name = entryName(name)
+	}
+
+	title := opts.Title
+	if title == "" {
+		title, _ = r.ServerInfo["title"].(string)
+	}
+	if title == "" {
+		title = serverName
+	}
+	if title == "" {
+		title = name
+	}
+
+	description := opts.Description
+	if description == "" {
+		description = fmt.Sprintf("Registered from a probe of %s", r.Target)
+	}
+
+	category := opts.Category
+	if category == "" {
+		category = registry.CategoryCustom
+	}
+
+	version, _ := r.ServerInfo["version"].(string)
+	if !semverPattern.MatchString(version) {
+		version = "1.0.0"
+	}
+
+	auth := &registry.Authorization{Type: registry.AuthNone}
+	if len(r.options.Env) > 0 {
+		auth = &registry.Authorization{Type: registry.AuthCustom, Required: true}
+		for _, key := range slices.Sorted(maps.Keys(r.options.Env)) {
+			auth.EnvVars = append(auth.EnvVars, registry.EnvVarDef{
+				Name:        key,
+				DisplayName: key,
+				Secret:      true,
+				Required:    true,
+			})
+		}
+	}
+
+	runtime := &registry.Runtime{
+		Transport: registry.TransportStdio,
+		Command:   r.options.Command,
+		Args:      r.options.Args,
+	}
+	if r.options.URL != "" {
+		runtime = &registry.Runtime{
+			Transport: r.Transport,
+			URL:       r.options.URL,
+			Headers:   r.options.Headers,
+		}
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	return &registry.MCPEntry{
+		Name:        name,
+		Version:     version,
+		Title:       title,
+		Description: description,
+		Category:    category,
+		Source:      registry.SourceLocal,
+		Auth:        auth,
+		Tools:       r.definitions,
+		Package:     pkg,
+		Runtime:     runtime,
+		Metadata:    &registry.Metadata{Created: now, VerifiedAt: now},
+	}, nil
+}
+
+// SaveRegistryEntry validates entry and writes it to registryDir/custom,
+// returning the file's path. Validation problems are returned in the
+// result with an error.
+func SaveRegistryEntry(registryDir string, entry *registry.MCPEntry, overwrite bool) (string, *registry.ValidationResult, error) {
+	result := registry.ValidateCustom(entry)
+	if !result.Valid {
+		return "", result, fmt.Errorf("the generated entry is not valid: %v", result.Errors)
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return "", result, err
+	}
+
+	customMu.Lock()
+	defer customMu.Unlock()
+	path := CustomEntryPath(registryDir, "", entry.Name)
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return "", result, fmt.Errorf("%w: %s", ErrEntryExists, path)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return "", result, err
+	}
+	return path, result, nil
+}
+
+// packageFromCommand recognises package runners: npx and bunx run npm
+// packages, uvx and pipx PyPI ones. Other commands have no package.
+func packageFromCommand(command string, args []string) *registry.Package {
+	runner := strings.ToLower(strings.TrimSuffix(filepath.Base(command), filepath.Ext(command)))
+	if runner == "pipx" && len(args) > 0 && args[0] == "run" {
+		args = args[1:]
+	}
+	var spec string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			spec = arg
+			break
+		}
+	}
+	if spec == "" {
+		return nil
+	}
+
+	switch runner {
+	case "npx", "bunx", "pnpx":
+		pkg := &registry.Package{Type: registry.PackageNPM, Name: spec}
+		// The version follows the last @, which is not the scope's
+		if i := strings.LastIndex(spec, "@"); i > 0 {
+			pkg.Name, pkg.Version = spec[:i], spec[i+1:]
+		}
+		return pkg
+	case "uvx", "pipx":
+		pkg := &registry.Package{Type: registry.PackagePyPI, Name: spec}
+		if name, version, ok := strings.Cut(spec, "=="); ok {
+			pkg.Name, pkg.Version = name, version
+		}
+		return pkg
+	}
+	return nil
+}
+
+// entryName turns a server or package name into a registry name:
+// lowercase letters, digits and hyphens, starting with a letter.
+func entryName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:] // Drop an npm scope or path
+	}
+	name = strings.Trim(entryNameCleaner.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	name = strings.TrimLeft(name, "0123456789-")
+	if len(name) < 2 {
+		name = "probed-server"
+	}
+	if len(name) > 64 {
+		name = strings.TrimRight(name[:64], "-")
+	}
+	return name
+}
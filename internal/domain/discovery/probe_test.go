@@ -0,0 +1,297 @@
+package discovery_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probeCheck(t *testing.T, report *discovery.ProbeReport, name string) discovery.ProbeCheck {
+	t.Helper()
+	for _, c := range report.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("no %s check in %+v", name, report.Checks)
+	return discovery.ProbeCheck{}
+}
+
+func TestProbe_Stdio(t *testing.T) {
+	report, err := discovery.Probe(context.Background(), discovery.ProbeOptions{
+		Command:  os.Args[0],
+		Args:     []string{"-test.run=^TestHelperMCPServer$"},
+		Env:      map[string]string{"SCOOTER_TEST_SERVER": "plain"},
+		Timeout:  10 * time.Second,
+		CallTool: "echo",
+	})
+	require.NoError(t, err)
+
+	assert.True(t, report.Compatible)
+	assert.Equal(t, registry.TransportStdio, report.Transport)
+	assert.Equal(t, "2024-11-05", report.ProtocolVersion)
+	assert.Equal(t, discovery.ProbeStatusPass, probeCheck(t, report, "initialize").Status)
+	// The helper's echo tool has no inputSchema
+	assert.Equal(t, discovery.ProbeStatusWarn, probeCheck(t, report, "tools/list").Status)
+	require.Len(t, report.Tools, 1)
+	assert.Contains(t, report.Tools[0].Issues, "inputSchema is missing")
+	// resources are not advertised, so an unusable answer is not a failure
+	assert.Equal(t, discovery.ProbeStatusSkip, probeCheck(t, report, "resources/list").Status)
+	assert.Equal(t, discovery.ProbeStatusPass, probeCheck(t, report, "tools/call echo").Status)
+	assert.Contains(t, report.Text(), "Result: compatible")
+}
+
+func TestProbe_StdioHandshakeFailure(t *testing.T) {
+	report, err := discovery.Probe(context.Background(), discovery.ProbeOptions{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestHelperMCPServer$"},
+		Env:     map[string]string{"SCOOTER_TEST_SERVER": "mute"},
+		Timeout: 500 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.False(t, report.Compatible)
+	assert.Equal(t, discovery.ProbeStatusFail, probeCheck(t, report, "initialize").Status)
+}
+
+// fakeHTTPServer is an MCP server over streamable HTTP or, with sse set,
+// the older HTTP+SSE transport only.
+func fakeHTTPServer(t *testing.T, sse bool) *httptest.Server {
+	answer := func(body []byte) []byte {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.Unmarshal(body, &req)
+		if req.ID == nil {
+			return nil
+		}
+		var result interface{}
+		switch req.Method {
+		case "initialize":
+			result = map[string]interface{}{
+				"protocolVersion": "2024-11-05",
+				"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}, "prompts": map[string]interface{}{}},
+				"serverInfo":      map[string]interface{}{"name": "fake", "version": "1.0"},
+			}
+		case "tools/list":
+			result = map[string]interface{}{"tools": []map[string]interface{}{{
+				"name": "greet", "description": "Say hello to someone",
+				"inputSchema": map[string]interface{}{"type": "object"},
+			}}}
+		case "prompts/list":
+			result = map[string]interface{}{"prompts": []map[string]interface{}{{"name": "review"}}}
+		case "ping":
+			result = map[string]interface{}{}
+		case "tools/call":
+			result = map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": "hello from fake"}}}
+		default:
+			resp, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{"code": -32601, "message": "Method not found"}})
+			return resp
+		}
+		resp, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+		return resp
+	}
+
+	// Each SSE connection is a session with its own message queue
+	var mu sync.Mutex
+	sessions := make(map[string]chan []byte)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /mcp", func(w http.ResponseWriter, r *http.Request) {
+		if sse {
+			http.Error(w, "use the SSE endpoint", http.StatusMethodNotAllowed)
+			return
+		}
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var body json.RawMessage
+		json.NewDecoder(r.Body).Decode(&body)
+		resp := answer(body)
+		w.Header().Set("Mcp-Session-Id", "session-1")
+		if resp == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		// Answer as a one-event stream, which clients must accept
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: message\ndata: %s\n\n", resp)
+	})
+	mux.HandleFunc("GET /mcp", func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		messages := make(chan []byte, 16)
+		mu.Lock()
+		session := fmt.Sprint(len(sessions) + 1)
+		sessions[session] = messages
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: endpoint\ndata: /messages?session=%s\n\n", session)
+		flusher.Flush()
+		for {
+			select {
+			case msg := <-messages:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("POST /messages", func(w http.ResponseWriter, r *http.Request) {
+		var body json.RawMessage
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		messages := sessions[r.URL.Query().Get("session")]
+		mu.Unlock()
+		if resp := answer(body); resp != nil && messages != nil {
+			messages <- resp
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestProbe_HTTP(t *testing.T) {
+	tests := []struct {
+		name string
+		sse  bool
+		want registry.TransportType
+	}{
+		{"streamable HTTP", false, registry.TransportStreamableHTTP},
+		{"falls back to HTTP+SSE", true, registry.TransportSSE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fakeHTTPServer(t, tt.sse)
+			report, err := discovery.Probe(context.Background(), discovery.ProbeOptions{
+				URL:     srv.URL + "/mcp",
+				Headers: map[string]string{"Authorization": "Bearer ${TOKEN}"},
+				Env:     map[string]string{"TOKEN": "secret"},
+				Timeout: 5 * time.Second,
+			})
+			require.NoError(t, err)
+
+			assert.True(t, report.Compatible, report.Text())
+			assert.Equal(t, tt.want, report.Transport)
+			assert.Equal(t, "fake", report.ServerInfo["name"])
+			require.Len(t, report.Tools, 1)
+			assert.Empty(t, report.Tools[0].Issues)
+			assert.Len(t, report.Prompts, 1)
+			assert.Equal(t, discovery.ProbeStatusSkip, probeCheck(t, report, "resources/list").Status)
+			assert.Equal(t, discovery.ProbeStatusPass, probeCheck(t, report, "ping").Status)
+		})
+	}
+}
+
+func TestProbe_InvalidOptions(t *testing.T) {
+	_, err := discovery.Probe(context.Background(), discovery.ProbeOptions{})
+	assert.Error(t, err)
+	_, err = discovery.Probe(context.Background(), discovery.ProbeOptions{Command: "x", URL: "http://localhost"})
+	assert.Error(t, err)
+	_, err = discovery.Probe(context.Background(), discovery.ProbeOptions{URL: "ftp://example.com"})
+	assert.Error(t, err)
+}
+
+func TestProbe_RegistryEntry(t *testing.T) {
+	probe := func(mode string) *discovery.ProbeReport {
+		report, err := discovery.Probe(context.Background(), discovery.ProbeOptions{
+			Command: os.Args[0],
+			Args:    []string{"-test.run=^TestHelperMCPServer$"},
+			Env:     map[string]string{"SCOOTER_TEST_SERVER": mode},
+			Timeout: 10 * time.Second,
+		})
+		require.NoError(t, err)
+		return report
+	}
+	registryDir := t.TempDir()
+
+	entry, err := probe("full").RegistryEntry(discovery.EntryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "helper-server", entry.Name)
+	assert.Equal(t, "0.3.1", entry.Version)
+	assert.Equal(t, registry.SourceLocal, entry.Source)
+	assert.Equal(t, os.Args[0], entry.Runtime.Command)
+	require.Len(t, entry.Tools, 1)
+	assert.Equal(t, []string{"message"}, entry.Tools[0].InputSchema.Required)
+	// The probe's variables become credentials, without their values
+	require.Len(t, entry.Auth.EnvVars, 1)
+	assert.Equal(t, "SCOOTER_TEST_SERVER", entry.Auth.EnvVars[0].Name)
+
+	path, result, err := discovery.SaveRegistryEntry(registryDir, entry, false)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, filepath.Join(registryDir, "custom", "helper-server.json"), path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"full"`)
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), t.TempDir(), registryDir)
+	var names []string
+	for _, td := range engine.Find("") {
+		names = append(names, td.Name)
+	}
+	assert.Contains(t, names, "helper-server", "saved entry is in the catalog")
+
+	_, _, err = discovery.SaveRegistryEntry(registryDir, entry, false)
+	assert.ErrorIs(t, err, discovery.ErrEntryExists)
+	_, _, err = discovery.SaveRegistryEntry(registryDir, entry, true)
+	assert.NoError(t, err)
+
+	// The plain helper's tool has no schema and too short a description
+	entry, err = probe("plain").RegistryEntry(discovery.EntryOptions{Name: "plain-helper"})
+	require.NoError(t, err)
+	_, result, err = discovery.SaveRegistryEntry(registryDir, entry, false)
+	assert.Error(t, err)
+	assert.False(t, result.Valid)
+	assert.NoFileExists(t, filepath.Join(registryDir, "custom", "plain-helper.json"))
+}
+
+func TestEngine_AddRemoteServer(t *testing.T) {
+	for _, sse := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sse=%v", sse), func(t *testing.T) {
+			srv := fakeHTTPServer(t, sse)
+			report, err := discovery.Probe(context.Background(), discovery.ProbeOptions{
+				URL:     srv.URL + "/mcp",
+				Headers: map[string]string{"Authorization": "Bearer ${TOKEN}"},
+				Env:     map[string]string{"TOKEN": "secret"},
+				Timeout: 5 * time.Second,
+			})
+			require.NoError(t, err)
+
+			// The entry keeps the URL and the header template, not the token
+			entry, err := report.RegistryEntry(discovery.EntryOptions{Name: "remote-fake"})
+			require.NoError(t, err)
+			assert.Equal(t, report.Transport, entry.Runtime.Transport)
+			assert.Equal(t, srv.URL+"/mcp", entry.Runtime.URL)
+			assert.Nil(t, entry.Package)
+			registryDir := t.TempDir()
+			path, _, err := discovery.SaveRegistryEntry(registryDir, entry, false)
+			require.NoError(t, err)
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.NotContains(t, string(data), "Bearer secret")
+
+			engine := discovery.NewDiscoveryEngine(context.Background(), t.TempDir(), registryDir)
+			engine.SetEnv(map[string]string{"TOKEN": "secret"})
+			require.NoError(t, engine.Add("remote-fake"))
+			defer engine.Remove("remote-fake")
+			assert.True(t, engine.IsActive("remote-fake"))
+
+			result, err := engine.CallTool("greet", map[string]interface{}{})
+			require.NoError(t, err)
+			out, _ := json.Marshal(result)
+			assert.Contains(t, string(out), "hello from fake")
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package discovery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// ActivationMetrics records how often each registry entry starts, across
+// every profile, so entries that never come up can be spotted in the
+// catalog. Entries that keep failing are quarantined: Add refuses them
+// until they are unquarantined. With a path, the record survives restarts.
+type ActivationMetrics struct {
+	mu      sync.Mutex
+	path    string // "" keeps the metrics in memory
+	entries map[string]*activationRecord
+}
+
+// maxStartupSamples is how many recent start-up times the median is taken over.
+const maxStartupSamples = 20
+
+type activationRecord struct {
+	Attempts            int        `json:"attempts"`
+	Successes           int        `json:"successes"`
+	ConsecutiveFailures int        `json:"consecutive_failures,omitempty"`
+	StartupMs           []int64    `json:"startup_ms,omitempty"` // Recent successful start-ups, oldest first
+	LastError           string     `json:"last_error,omitempty"`
+	LastAttempt         time.Time  `json:"last_attempt"`
+	QuarantineReason    string     `json:"quarantine_reason,omitempty"` // "" when not quarantined
+	QuarantinedAt       *time.Time `json:"quarantined_at,omitempty"`
+}
+
+// ActivationStats summarises the activations of one registry entry.
+type ActivationStats struct {
+	Attempts        int       `json:"attempts"`
+	Successes       int       `json:"successes"`
+	Failures        int       `json:"failures"`
+	Reliability     float64   `json:"reliability"` // Successes / attempts, 0-1
+	MedianStartupMs int64     `json:"median_startup_ms,omitempty"`
+	LastError       string    `json:"last_error,omitempty"`
+	LastAttempt     time.Time `json:"last_attempt"`
+
+	ConsecutiveFailures int        `json:"consecutive_failures,omitempty"`
+	Quarantined         bool       `json:"quarantined,omitempty"`
+	QuarantineReason    string     `json:"quarantine_reason,omitempty"`
+	QuarantinedAt       *time.Time `json:"quarantined_at,omitempty"`
+}
+
+// ErrQuarantined is returned by Add for a quarantined registry entry.
+var ErrQuarantined = errors.New("server is quarantined")
+
+// NewActivationMetrics returns metrics kept in the JSON file at path, or in
+// memory if path is "". A missing or unreadable file starts empty.
+func NewActivationMetrics(path string) *ActivationMetrics {
+	m := &ActivationMetrics{path: path, entries: make(map[string]*activationRecord)}
+	if path == "" {
+		return m
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.AddLog("WARN", fmt.Sprintf("[Discovery] Failed to read activation metrics: %v", err))
+		}
+		return m
+	}
+	if err := json.Unmarshal(data, &m.entries); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("[Discovery] Ignoring corrupt activation metrics %s: %v", path, err))
+		m.entries = make(map[string]*activationRecord)
+	}
+	return m
+}
+
+// Record notes one activation attempt of serverName that took d and failed
+// with err, or succeeded if err is nil. After quarantineAfter consecutive
+// failures (0 never) the entry is quarantined and Record returns true.
+func (m *ActivationMetrics) Record(serverName string, d time.Duration, err error, quarantineAfter int) (quarantined bool) {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.entries[serverName]
+	if !ok {
+		rec = &activationRecord{}
+		m.entries[serverName] = rec
+	}
+	rec.Attempts++
+	rec.LastAttempt = time.Now().UTC()
+	if err != nil {
+		rec.LastError = err.Error()
+		rec.ConsecutiveFailures++
+		if quarantineAfter > 0 && rec.ConsecutiveFailures >= quarantineAfter && rec.QuarantineReason == "" {
+			now := rec.LastAttempt
+			rec.QuarantineReason = fmt.Sprintf("%d consecutive failed activations, last: %s", rec.ConsecutiveFailures, rec.LastError)
+			rec.QuarantinedAt = &now
+			quarantined = true
+		}
+	} else {
+		rec.Successes++
+		rec.ConsecutiveFailures = 0
+		rec.LastError = ""
+		rec.StartupMs = append(rec.StartupMs, d.Milliseconds())
+		if n := len(rec.StartupMs); n > maxStartupSamples {
+			rec.StartupMs = append([]int64(nil), rec.StartupMs[n-maxStartupSamples:]...)
+		}
+	}
+	m.saveLocked()
+	return quarantined
+}
+
+// Quarantined returns why serverName is quarantined, and false if it is not.
+func (m *ActivationMetrics) Quarantined(serverName string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rec, ok := m.entries[serverName]; ok && rec.QuarantineReason != "" {
+		return rec.QuarantineReason, true
+	}
+	return "", false
+}
+
+// Unquarantine lets serverName be activated again, with its failure streak
+// reset. It returns false if the entry was not quarantined.
+func (m *ActivationMetrics) Unquarantine(serverName string) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.entries[serverName]
+	if !ok || rec.QuarantineReason == "" {
+		return false
+	}
+	rec.QuarantineReason = ""
+	rec.QuarantinedAt = nil
+	rec.ConsecutiveFailures = 0
+	m.saveLocked()
+	return true
+}
+
+// Stats returns the summary for serverName, and false if it was never
+// activated.
+func (m *ActivationMetrics) Stats(serverName string) (ActivationStats, bool) {
+	if m == nil {
+		return ActivationStats{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.entries[serverName]
+	if !ok || rec.Attempts == 0 {
+		return ActivationStats{}, false
+	}
+	return rec.stats(), true
+}
+
+// All returns the summaries of every entry that was ever activated.
+func (m *ActivationMetrics) All() map[string]ActivationStats {
+	if m == nil {
+		return map[string]ActivationStats{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]ActivationStats, len(m.entries))
+	for name, rec := range m.entries {
+		if rec.Attempts > 0 {
+			out[name] = rec.stats()
+		}
+	}
+	return out
+}
+
+func (rec *activationRecord) stats() ActivationStats {
+	s := ActivationStats{
+		Attempts:    rec.Attempts,
+		Successes:   rec.Successes,
+		Failures:    rec.Attempts - rec.Successes,
+		Reliability: math.Round(float64(rec.Successes)/float64(rec.Attempts)*100) / 100,
+		LastError:   rec.LastError,
+		LastAttempt: rec.LastAttempt,
+
+		ConsecutiveFailures: rec.ConsecutiveFailures,
+		Quarantined:         rec.QuarantineReason != "",
+		QuarantineReason:    rec.QuarantineReason,
+		QuarantinedAt:       rec.QuarantinedAt,
+	}
+	if n := len(rec.StartupMs); n > 0 {
+		sorted := append([]int64(nil), rec.StartupMs...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		if n%2 == 1 {
+			s.MedianStartupMs = sorted[n/2]
+		} else {
+			s.MedianStartupMs = (sorted[n/2-1] + sorted[n/2]) / 2
+		}
+	}
+	return s
+}
+
+// saveLocked writes the metrics file; m.mu must be held. Failures are
+// logged: losing metrics must not fail an activation.
+func (m *ActivationMetrics) saveLocked() {
+	if m.path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(m.entries, "", "  ")
+	if err == nil {
+		os.MkdirAll(filepath.Dir(m.path), 0755)
+		tmp := m.path + ".tmp"
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, m.path)
+		}
+	}
+	if err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("[Discovery] Failed to save activation metrics: %v", err))
+	}
+}
+
+// SetActivationMetrics shares metrics with other engines; by default each
+// engine keeps its own in memory.
+func (e *DiscoveryEngine) SetActivationMetrics(m *ActivationMetrics) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.metrics = m
+}
+
+// ActivationMetrics returns the metrics the engine records into.
+func (e *DiscoveryEngine) ActivationMetrics() *ActivationMetrics {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.metrics
+}
+
+// ReliabilityFilter selects and orders catalog entries by activation
+// reliability. Entries never activated have no reliability: bounds exclude
+// them and sorting puts them last.
+type ReliabilityFilter struct {
+	Min, Max *float64 // Inclusive bounds, nil for none
+	Sort     string   // "", "reliability" (most reliable first) or "-reliability"
+}
+
+// Apply filters and sorts defs using stats, keeping the order of equals.
+func (f ReliabilityFilter) Apply(defs []ToolDefinition, stats map[string]ActivationStats) []ToolDefinition {
+	if f.Min == nil && f.Max == nil && f.Sort == "" {
+		return defs
+	}
+	out := make([]ToolDefinition, 0, len(defs))
+	for _, td := range defs {
+		s, ok := stats[td.Name]
+		if f.Min != nil && (!ok || s.Reliability < *f.Min) {
+			continue
+		}
+		if f.Max != nil && (!ok || s.Reliability > *f.Max) {
+			continue
+		}
+		out = append(out, td)
+	}
+	if f.Sort == "reliability" || f.Sort == "-reliability" {
+		desc := f.Sort == "reliability"
+		sort.SliceStable(out, func(i, j int) bool {
+			si, iok := stats[out[i].Name]
+			sj, jok := stats[out[j].Name]
+			if iok != jok {
+				return iok // Unknown last
+			}
+			if !iok || si.Reliability == sj.Reliability {
+				return false
+			}
+			if desc {
+				return si.Reliability > sj.Reliability
+			}
+			return si.Reliability < sj.Reliability
+		})
+	}
+	return out
+}
@@ -0,0 +1,705 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/logger"
+	"github.com/mcp-scooter/scooter/internal/version"
+)
+
+// =============================================================================
+// RemoteWorker - Remote MCP Servers
+// =============================================================================
+//
+// RemoteWorker talks to an MCP server over HTTP instead of a child process.
+// It backs registry entries whose runtime has a url and probes of URLs.
+// Two transports exist:
+//
+//   streamable HTTP  Every message is POSTed to the server URL. The answer is
+//                    the response body, either JSON or a short SSE stream.
+//                    The server may hand out an Mcp-Session-Id to send back.
+//   HTTP+SSE         The client GETs an SSE stream whose first "endpoint"
+//                    event names the URL to POST messages to. Answers arrive
+//                    on the stream as "message" events.
+//
+// With no transport configured, streamable HTTP is tried first and HTTP+SSE
+// used if the server rejects the initialize POST with a 4xx status, as the
+// MCP specification recommends for backwards compatibility.
+//
+// =============================================================================
+
+// RemoteWorker handles a persistent MCP server reached over HTTP.
+type RemoteWorker struct {
+	url       string
+	transport registry.TransportType // "" until detected
+	headers   map[string]string      // Values may reference ${VAR} from Start's env
+	client    *http.Client
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	mu              sync.Mutex
+	initialized     bool
+	requestID       int64
+	timeout         time.Duration
+	env             map[string]string
+	sessionID       string // Mcp-Session-Id (streamable HTTP)
+	endpoint        string // Where messages are POSTed (HTTP+SSE)
+	tools           []registry.Tool
+	capabilities    map[string]interface{}
+	serverInfo      map[string]interface{}
+	protocolVersion string
+
+	notificationHandler NotificationHandler
+
+	// HTTP+SSE: responses arrive on the stream and are routed by id
+	streamMu  sync.Mutex
+	pending   map[string]chan *registry.JSONRPCResponse
+	streamErr error
+	stream    io.Closer
+}
+
+// NewRemoteWorker creates a worker for the MCP server at serverURL. transport
+// is registry.TransportSSE, registry.TransportStreamableHTTP (or
+// TransportHTTP), or "" to detect it.
+func NewRemoteWorker(ctx context.Context, serverURL string, transport registry.TransportType, headers map[string]string) *RemoteWorker {
+	ctx, cancel := context.WithCancel(ctx)
+	if transport == registry.TransportHTTP {
+		transport = registry.TransportStreamableHTTP
+	}
+	return &RemoteWorker{
+		url:       serverURL,
+		transport: transport,
+		headers:   headers,
+		client:    &http.Client{},
+		ctx:       ctx,
+		cancel:    cancel,
+		timeout:   defaultStartTimeout,
+		pending:   make(map[string]chan *registry.JSONRPCResponse),
+	}
+}
+
+// SetStartupOptions sets the budget for the handshake.
+func (w *RemoteWorker) SetStartupOptions(timeout time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if timeout > 0 {
+		w.timeout = timeout
+	}
+}
+
+// SetNotificationHandler installs the handler for server notifications.
+func (w *RemoteWorker) SetNotificationHandler(h NotificationHandler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.notificationHandler = h
+}
+
+// Transport returns the transport in use, once Start has detected it.
+func (w *RemoteWorker) Transport() registry.TransportType {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.transport
+}
+
+// Start connects and performs the initialize handshake. env fills in
+// ${VAR} references in the configured headers, e.g. an API key.
+func (w *RemoteWorker) Start(env map[string]string) error {
+	w.mu.Lock()
+	if w.initialized {
+		w.mu.Unlock()
+		return nil
+	}
+	w.env = env
+	transport := w.transport
+	deadline := time.Now().Add(w.timeout)
+	w.mu.Unlock()
+
+	initialize := func() (*registry.JSONRPCResponse, error) {
+		params := map[string]interface{}{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]interface{}{},
+			"clientInfo": map[string]string{
+				"name":    "mcp-scooter",
+				"version": version.Version,
+			},
+		}
+		return w.Request("initialize", params, time.Until(deadline))
+	}
+
+	var resp *registry.JSONRPCResponse
+	var err error
+	if transport != registry.TransportSSE {
+		w.setTransport(registry.TransportStreamableHTTP)
+		resp, err = initialize()
+		var statusErr *httpStatusError
+		if transport == "" && errors.As(err, &statusErr) && statusErr.code >= 400 && statusErr.code < 500 {
+			logger.AddLog("INFO", fmt.Sprintf("[RemoteWorker] %s rejected streamable HTTP (%d), trying HTTP+SSE", w.url, statusErr.code))
+			transport = registry.TransportSSE
+		}
+	}
+	if transport == registry.TransportSSE {
+		w.setTransport(registry.TransportSSE)
+		if err = w.openStream(deadline); err == nil {
+			resp, err = initialize()
+		}
+	}
+	if err != nil {
+		w.closeStream()
+		return &HandshakeError{Phase: PhaseInitialize, Err: err}
+	}
+	if resp.Error != nil {
+		w.closeStream()
+		return &HandshakeError{Phase: PhaseInitialize, Err: fmt.Errorf("%s (code: %d)", resp.Error.Message, resp.Error.Code)}
+	}
+
+	var initResult initializeResult
+	resultBytes, _ := json.Marshal(resp.Result)
+	json.Unmarshal(resultBytes, &initResult)
+	w.mu.Lock()
+	w.capabilities = initResult.Capabilities
+	w.serverInfo = initResult.ServerInfo
+	w.protocolVersion = initResult.ProtocolVersion
+	w.initialized = true
+	w.mu.Unlock()
+
+	if err := w.notify("notifications/initialized"); err != nil {
+		w.Close()
+		return &HandshakeError{Phase: PhaseInitialized, Err: err}
+	}
+	if err := w.RefreshTools(); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("[RemoteWorker] %v", &HandshakeError{Phase: PhaseToolsList, Err: err}))
+	}
+	return nil
+}
+
+func (w *RemoteWorker) setTransport(t registry.TransportType) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.transport = t
+}
+
+// ServerInfo returns the serverInfo and protocol version the server sent in
+// its initialize response.
+func (w *RemoteWorker) ServerInfo() (map[string]interface{}, string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.serverInfo, w.protocolVersion
+}
+
+// Capabilities returns the capabilities from the initialize response.
+func (w *RemoteWorker) Capabilities() map[string]interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.capabilities
+}
+
+// GetTools returns the tools fetched from the server.
+func (w *RemoteWorker) GetTools() []registry.Tool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.tools
+}
+
+// RefreshTools re-fetches every page of the server's tools.
+func (w *RemoteWorker) RefreshTools() error {
+	var tools []registry.Tool
+	cursor := ""
+	for {
+		var params interface{}
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		resp, err := w.Request("tools/list", params, 30*time.Second)
+		if err != nil {
+			return err
+		}
+		if resp.Error != nil {
+			return fmt.Errorf("tools/list error: %s", resp.Error.Message)
+		}
+		var page struct {
+			Tools      []registry.Tool `json:"tools"`
+			NextCursor string          `json:"nextCursor"`
+		}
+		data, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(data, &page); err != nil {
+			return fmt.Errorf("failed to parse tools/list result: %w", err)
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	w.mu.Lock()
+	w.tools = tools
+	w.mu.Unlock()
+	return nil
+}
+
+// CallTool calls a tool on the server.
+func (w *RemoteWorker) CallTool(name string, arguments map[string]interface{}) (*registry.JSONRPCResponse, error) {
+	return w.CallToolWithMeta(name, arguments, nil)
+}
+
+// CallToolWithMeta calls a tool, sending meta as params._meta.
+func (w *RemoteWorker) CallToolWithMeta(name string, arguments, meta map[string]interface{}) (*registry.JSONRPCResponse, error) {
+	if !w.IsRunning() {
+		return nil, fmt.Errorf("server not initialized")
+	}
+	params := struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+		Meta      map[string]interface{} `json:"_meta,omitempty"`
+	}{Name: name, Arguments: arguments, Meta: meta}
+	return w.Request("tools/call", params, 60*time.Second)
+}
+
+// Execute implements ToolWorker for callers that speak JSON-RPC on streams.
+func (w *RemoteWorker) Execute(stdin io.Reader, stdout io.Writer, env map[string]string) error {
+	if err := w.Start(env); err != nil {
+		return err
+	}
+	var req registry.JSONRPCRequest
+	if err := json.NewDecoder(stdin).Decode(&req); err != nil {
+		return fmt.Errorf("failed to decode request: %w", err)
+	}
+	var params interface{}
+	if len(req.Params) > 0 {
+		params = req.Params
+	}
+	resp, err := w.Request(req.Method, params, 60*time.Second)
+	if err != nil {
+		return err
+	}
+	resp.ID = req.ID
+	return json.NewEncoder(stdout).Encode(resp)
+}
+
+// Ping sends an MCP ping and waits up to timeout for the answer.
+func (w *RemoteWorker) Ping(timeout time.Duration) error {
+	if !w.IsRunning() {
+		return fmt.Errorf("server is not running")
+	}
+	_, err := w.Request("ping", nil, timeout)
+	return err
+}
+
+// SetLogLevel asks the server to send log notifications at level and above.
+func (w *RemoteWorker) SetLogLevel(level string) error {
+	resp, err := w.Request("logging/setLevel", map[string]string{"level": level}, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("logging/setLevel error: %s", resp.Error.Message)
+	}
+	return nil
+}
+
+// Complete forwards a completion/complete request.
+func (w *RemoteWorker) Complete(params json.RawMessage, timeout time.Duration) (*registry.JSONRPCResponse, error) {
+	if !w.IsRunning() {
+		return nil, fmt.Errorf("server not initialized")
+	}
+	if _, ok := w.Capabilities()["completions"]; !ok {
+		return nil, fmt.Errorf("server does not support completions")
+	}
+	return w.Request("completion/complete", params, timeout)
+}
+
+// IsRunning reports whether the handshake has completed.
+func (w *RemoteWorker) IsRunning() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.initialized
+}
+
+// Close ends the session. Streamable HTTP sessions are deleted on the
+// server; an HTTP+SSE stream is closed.
+func (w *RemoteWorker) Close() error {
+	w.mu.Lock()
+	wasRunning := w.initialized
+	w.initialized = false
+	sessionID := w.sessionID
+	transport := w.transport
+	w.mu.Unlock()
+
+	if wasRunning && transport == registry.TransportStreamableHTTP && sessionID != "" {
+		req, err := http.NewRequest(http.MethodDelete, w.url, nil)
+		if err == nil {
+			w.setHeaders(req)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			if resp, err := w.client.Do(req.WithContext(ctx)); err == nil {
+				resp.Body.Close()
+			}
+			cancel()
+		}
+	}
+	w.closeStream()
+	w.cancel()
+	return nil
+}
+
+// Request sends any request and waits up to timeout for the answer.
+func (w *RemoteWorker) Request(method string, params interface{}, timeout time.Duration) (*registry.JSONRPCResponse, error) {
+	w.mu.Lock()
+	w.requestID++
+	req := registry.JSONRPCRequest{JSONRPC: "2.0", ID: w.requestID, Method: method}
+	transport := w.transport
+	w.mu.Unlock()
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		req.Params = data
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(w.ctx, timeout)
+	defer cancel()
+	if transport == registry.TransportSSE {
+		return w.requestOverStream(ctx, req.ID, body)
+	}
+	return w.post(ctx, body, idKey(req.ID))
+}
+
+// notify sends a notification, which has no answer.
+func (w *RemoteWorker) notify(method string) error {
+	body, _ := json.Marshal(registry.JSONRPCRequest{JSONRPC: "2.0", Method: method})
+	ctx, cancel := context.WithTimeout(w.ctx, 10*time.Second)
+	defer cancel()
+	if w.Transport() == registry.TransportSSE {
+		return w.postToEndpoint(ctx, body)
+	}
+	_, err := w.post(ctx, body, "")
+	return err
+}
+
+// httpStatusError is an unexpected HTTP status from the server.
+type httpStatusError struct {
+	code int
+	body string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("server returned HTTP %d: %s", e.code, e.body)
+}
+
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &httpStatusError{code: resp.StatusCode, body: strings.TrimSpace(string(body))}
+}
+
+// setHeaders adds the configured headers, with ${VAR} expanded from the
+// env passed to Start, and the session ID.
+func (w *RemoteWorker) setHeaders(req *http.Request) {
+	w.mu.Lock()
+	env, sessionID := w.env, w.sessionID
+	w.mu.Unlock()
+	for k, v := range w.headers {
+		req.Header.Set(k, os.Expand(v, func(name string) string { return env[name] }))
+	}
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+}
+
+// post sends one message over streamable HTTP and returns the response to
+// the request with id key, if any.
+func (w *RemoteWorker) post(ctx context.Context, body []byte, key string) (*registry.JSONRPCResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	w.setHeaders(req)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, statusError(resp)
+	}
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		w.mu.Lock()
+		w.sessionID = id
+		w.mu.Unlock()
+	}
+	if key == "" {
+		return nil, nil // A notification: 202 Accepted, no body
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		var out registry.JSONRPCResponse
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return &out, nil
+	}
+
+	// The answer is one of the events; others are notifications or requests
+	var out *registry.JSONRPCResponse
+	errFound := errors.New("found")
+	err = readEventStream(resp.Body, func(event, data string) error {
+		if resp := w.routeStreamMessage(data); resp != nil && idKey(resp.ID) == key {
+			out = resp
+			return errFound
+		}
+		return nil
+	})
+	if out != nil {
+		return out, nil
+	}
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	return nil, fmt.Errorf("stream ended without a response: %w", err)
+}
+
+// routeStreamMessage returns data as a response, or answers or logs it if it
+// is a server request or notification.
+func (w *RemoteWorker) routeStreamMessage(data string) *registry.JSONRPCResponse {
+	var in incomingMessage
+	if err := json.Unmarshal([]byte(data), &in); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("[RemoteWorker] Ignoring unparseable message from %s: %v", w.url, err))
+		return nil
+	}
+	hasID := len(in.ID) > 0 && string(in.ID) != "null"
+	switch {
+	case hasID && in.Method != "":
+		go w.answerServerRequest(in)
+	case hasID:
+		var resp registry.JSONRPCResponse
+		if err := json.Unmarshal([]byte(data), &resp); err == nil {
+			resp.ID = in.ID
+			return &resp
+		}
+	default:
+		w.mu.Lock()
+		handler := w.notificationHandler
+		w.mu.Unlock()
+		if handler != nil {
+			handler(in.Method, in.Params)
+		} else {
+			logger.AddLog("DEBUG", fmt.Sprintf("[RemoteWorker] Received notification %s from %s", in.Method, w.url))
+		}
+	}
+	return nil
+}
+
+// answerServerRequest answers ping and refuses any other server request.
+func (w *RemoteWorker) answerServerRequest(in incomingMessage) {
+	resp := registry.JSONRPCResponse{JSONRPC: "2.0", ID: in.ID}
+	if in.Method == "ping" {
+		resp.Result = map[string]interface{}{}
+	} else {
+		resp.Error = &registry.JSONRPCError{Code: registry.MethodNotFound, Message: fmt.Sprintf("Method not supported by client: %s", in.Method)}
+	}
+	body, _ := json.Marshal(resp)
+	ctx, cancel := context.WithTimeout(w.ctx, 10*time.Second)
+	defer cancel()
+	if w.Transport() == registry.TransportSSE {
+		w.postToEndpoint(ctx, body)
+		return
+	}
+	w.post(ctx, body, "")
+}
+
+// openStream opens the HTTP+SSE stream and waits for the endpoint event.
+func (w *RemoteWorker) openStream(deadline time.Time) error {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodGet, w.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	w.setHeaders(req)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return statusError(resp)
+	}
+	w.streamMu.Lock()
+	w.stream = resp.Body
+	w.streamErr = nil
+	w.streamMu.Unlock()
+
+	endpoint := make(chan string, 1)
+	go func() {
+		err := readEventStream(resp.Body, func(event, data string) error {
+			if event == "endpoint" {
+				select {
+				case endpoint <- data:
+				default:
+				}
+				return nil
+			}
+			if out := w.routeStreamMessage(data); out != nil {
+				key := idKey(out.ID)
+				w.streamMu.Lock()
+				ch, ok := w.pending[key]
+				delete(w.pending, key)
+				w.streamMu.Unlock()
+				if ok {
+					ch <- out
+				}
+			}
+			return nil
+		})
+		if err == nil {
+			err = fmt.Errorf("server closed the event stream: %w", io.EOF)
+		}
+		w.streamMu.Lock()
+		w.streamErr = err
+		for key, ch := range w.pending {
+			close(ch)
+			delete(w.pending, key)
+		}
+		w.streamMu.Unlock()
+		close(endpoint)
+	}()
+
+	select {
+	case data, ok := <-endpoint:
+		if !ok {
+			return w.streamFailure()
+		}
+		ref, err := url.Parse(strings.TrimSpace(data))
+		if err != nil {
+			return fmt.Errorf("invalid endpoint %q: %w", data, err)
+		}
+		base, _ := url.Parse(w.url)
+		w.mu.Lock()
+		w.endpoint = base.ResolveReference(ref).String()
+		w.mu.Unlock()
+		return nil
+	case <-time.After(time.Until(deadline)):
+		return fmt.Errorf("no endpoint event received")
+	}
+}
+
+func (w *RemoteWorker) streamFailure() error {
+	w.streamMu.Lock()
+	defer w.streamMu.Unlock()
+	if w.streamErr != nil {
+		return w.streamErr
+	}
+	return errors.New("event stream closed")
+}
+
+func (w *RemoteWorker) closeStream() {
+	w.streamMu.Lock()
+	defer w.streamMu.Unlock()
+	if w.stream != nil {
+		w.stream.Close()
+		w.stream = nil
+	}
+}
+
+// requestOverStream POSTs a request to the HTTP+SSE endpoint and waits for
+// its answer on the stream.
+func (w *RemoteWorker) requestOverStream(ctx context.Context, id interface{}, body []byte) (*registry.JSONRPCResponse, error) {
+	key := idKey(id)
+	ch := make(chan *registry.JSONRPCResponse, 1)
+	w.streamMu.Lock()
+	if w.stream == nil || w.streamErr != nil {
+		w.streamMu.Unlock()
+		return nil, w.streamFailure()
+	}
+	w.pending[key] = ch
+	w.streamMu.Unlock()
+	defer func() {
+		w.streamMu.Lock()
+		delete(w.pending, key)
+		w.streamMu.Unlock()
+	}()
+
+	if err := w.postToEndpoint(ctx, body); err != nil {
+		return nil, err
+	}
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, w.streamFailure()
+		}
+		return resp, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timeout waiting for response: %w", ctx.Err())
+	}
+}
+
+func (w *RemoteWorker) postToEndpoint(ctx context.Context, body []byte) error {
+	w.mu.Lock()
+	endpoint := w.endpoint
+	w.mu.Unlock()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	w.setHeaders(req)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// readEventStream parses a text/event-stream and calls fn for every event
+// until fn returns an error or the stream ends.
+func readEventStream(r io.Reader, fn func(event, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if event == "" {
+					event = "message"
+				}
+				if err := fn(event, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	return scanner.Err()
+}
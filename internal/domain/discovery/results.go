@@ -0,0 +1,233 @@
+package discovery
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// Results over the profile's size budget (see SetResultBudget) don't reach
+// the agent in full. They are replaced by a truncated copy, or an AI summary,
+// and a handle; the full text stays on the engine so scooter_result can page
+// through it.
+
+const (
+	// maxStoredResults is how many oversized results an engine keeps.
+	maxStoredResults = 20
+	// defaultResultPage is how much scooter_result returns without a budget.
+	defaultResultPage = 16 << 10
+	// maxSummaryInput bounds the text sent to the AI provider.
+	maxSummaryInput = 200 << 10
+	// ResultMetaKey carries the handle of a replaced result in its _meta.
+	ResultMetaKey = "scooter/result"
+)
+
+// defaultSummaryPrompt is used when settings.summary_prompt is empty.
+const defaultSummaryPrompt = "You summarise tool output for an AI agent that cannot read all of it. " +
+	"Keep identifiers, numbers, URLs, file paths and error messages verbatim, " +
+	"and say briefly what kinds of detail were left out."
+
+// storedResult is the full text of a result that exceeded the budget.
+type storedResult struct {
+	tool string
+	text string
+}
+
+// SetResultBudget sets the size budget of server tool results in bytes of
+// JSON (0 disables it) and whether oversized results are summarised by the
+// configured AI provider rather than truncated.
+func (e *DiscoveryEngine) SetResultBudget(maxBytes int, summarize bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resultBudget = maxBytes
+	e.summarizeResults = summarize
+}
+
+// applyResultBudget returns result unchanged if it fits the budget, and
+// otherwise a shortened result pointing at the stored original.
+func (e *DiscoveryEngine) applyResultBudget(tool string, result interface{}) interface{} {
+	e.mu.RLock()
+	budget, summarize := e.resultBudget, e.summarizeResults
+	e.mu.RUnlock()
+	if budget <= 0 {
+		return result
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil || len(data) <= budget {
+		return result
+	}
+
+	text := resultText(result, data)
+	handle := e.storeResult(tool, text)
+
+	var body, note string
+	summarized := false
+	if summarize {
+		summary, err := e.summarizeResult(tool, text)
+		if err != nil {
+			logger.AddLog("WARN", fmt.Sprintf("[Discovery] Could not summarise result of %s, truncating instead: %v", tool, err))
+		} else {
+			body, summarized = summary, true
+			note = e.msg("builtin.result.summarized", len(text), handle)
+		}
+	}
+	if !summarized {
+		body = truncateUTF8(text, budget)
+		note = e.msg("builtin.result.truncated", len(body), len(text), handle, len(body))
+	}
+
+	logger.AddLog("INFO", fmt.Sprintf("[Discovery] Result of %s (%d bytes) exceeded the %d-byte budget; stored as %s", tool, len(data), budget, handle))
+	out := map[string]interface{}{
+		"content": []map[string]interface{}{
+			{"type": "text", "text": body},
+			{"type": "text", "text": note},
+		},
+		"_meta": map[string]interface{}{
+			ResultMetaKey: map[string]interface{}{
+				"handle":     handle,
+				"size":       len(text),
+				"summarized": summarized,
+			},
+		},
+	}
+	if m, ok := result.(map[string]interface{}); ok && m["isError"] == true {
+		out["isError"] = true
+	}
+	return out
+}
+
+// resultText is the text of an MCP result's text content, or its JSON if it
+// has none.
+func resultText(result interface{}, data []byte) string {
+	m, ok := result.(map[string]interface{})
+	if !ok {
+		if s, ok := result.(string); ok {
+			return s
+		}
+		return string(data)
+	}
+	items, _ := m["content"].([]interface{})
+	var parts []string
+	for _, item := range items {
+		c, _ := item.(map[string]interface{})
+		if text, ok := c["text"].(string); ok && c["type"] == "text" {
+			parts = append(parts, text)
+		}
+	}
+	if len(parts) == 0 {
+		return string(data)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// storeResult keeps text under a new handle, dropping the oldest stored
+// result beyond maxStoredResults.
+func (e *DiscoveryEngine) storeResult(tool, text string) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	handle := "res_" + hex.EncodeToString(b)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.results == nil {
+		e.results = make(map[string]storedResult)
+	}
+	if len(e.resultOrder) == maxStoredResults {
+		delete(e.results, e.resultOrder[0])
+		e.resultOrder = e.resultOrder[1:]
+	}
+	e.results[handle] = storedResult{tool: tool, text: text}
+	e.resultOrder = append(e.resultOrder, handle)
+	return handle
+}
+
+// summarizeResult asks the configured AI provider to summarise text.
+func (e *DiscoveryEngine) summarizeResult(tool, text string) (string, error) {
+	e.mu.RLock()
+	system, maxTokens := e.settings.SummaryPrompt, e.settings.SummaryMaxTokens
+	e.mu.RUnlock()
+	if system == "" {
+		system = defaultSummaryPrompt
+	}
+
+	provider, model, key, _ := e.getAIRoutingCredentials()
+	if key == "" {
+		return "", errors.New("no AI provider is configured")
+	}
+
+	prompt := fmt.Sprintf("Output of the tool %s:\n\n%s", tool, truncateUTF8(text, maxSummaryInput))
+	response, err := e.callInternalAI(provider, model, key, system, prompt, maxTokens)
+	if err != nil {
+		return "", err
+	}
+	summary := strings.TrimSpace(aiResponseText(provider, response))
+	if summary == "" {
+		return "", errors.New("the AI provider returned no text")
+	}
+	return summary, nil
+}
+
+// handleResultTool implements scooter_result: a page of a stored result.
+func (e *DiscoveryEngine) handleResultTool(params map[string]interface{}) (interface{}, error) {
+	handle, _ := params["handle"].(string)
+	if handle == "" {
+		return nil, errors.New(e.msg("builtin.result.handle_required"))
+	}
+
+	e.mu.RLock()
+	stored, ok := e.results[handle]
+	budget := e.resultBudget
+	e.mu.RUnlock()
+	if !ok {
+		return nil, errors.New(e.msg("builtin.result.not_found", handle))
+	}
+
+	offset := 0
+	if v, ok := params["offset"].(float64); ok && v > 0 {
+		offset = int(v)
+	}
+	if offset > len(stored.text) {
+		offset = len(stored.text)
+	}
+	for offset > 0 && offset < len(stored.text) && !utf8.RuneStart(stored.text[offset]) {
+		offset--
+	}
+	length := budget
+	if length <= 0 {
+		length = defaultResultPage
+	}
+	if v, ok := params["length"].(float64); ok && v > 0 {
+		length = int(v)
+	}
+
+	page := truncateUTF8(stored.text[offset:], length)
+	response := map[string]interface{}{
+		"handle": handle,
+		"tool":   stored.tool,
+		"offset": offset,
+		"size":   len(stored.text),
+		"text":   page,
+	}
+	if end := offset + len(page); end < len(stored.text) {
+		response["next_offset"] = end
+	}
+	return response, nil
+}
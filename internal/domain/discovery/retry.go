@@ -0,0 +1,157 @@
+package discovery
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// Retry defaults used when a policy leaves a field unset.
+const (
+	defaultInitialBackoff = 200 * time.Millisecond
+	defaultMaxBackoff     = 5 * time.Second
+	defaultMultiplier     = 2.0
+)
+
+// CallStats counts the tool calls an engine has made to one server.
+type CallStats struct {
+	Calls    int64 `json:"calls"`
+	Failures int64 `json:"failures"`
+	Retries  int64 `json:"retries"`
+}
+
+// Stats returns a snapshot of the per-server call counters.
+func (e *DiscoveryEngine) Stats() map[string]CallStats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	out := make(map[string]CallStats, len(e.stats))
+	for name, s := range e.stats {
+		out[name] = *s
+	}
+	return out
+}
+
+func (e *DiscoveryEngine) recordCall(serverName string, retries int, failed bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, ok := e.stats[serverName]
+	if !ok {
+		s = &CallStats{}
+		e.stats[serverName] = s
+	}
+	s.Calls++
+	s.Retries += int64(retries)
+	if failed {
+		s.Failures++
+	}
+}
+
+// retryPolicy returns the policy that applies to a tool, or nil if failed
+// calls to it must not be retried.
+func (e *DiscoveryEngine) retryPolicy(serverName, toolName string) *registry.RetryPolicy {
+	var policy *registry.RetryPolicy
+	e.mu.RLock()
+	for _, td := range e.registry {
+		if td.Name == serverName && td.Runtime != nil {
+			policy = td.Runtime.Retry
+			break
+		}
+	}
+	e.mu.RUnlock()
+
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return nil
+	}
+	if len(policy.Tools) > 0 {
+		listed := false
+		for _, t := range policy.Tools {
+			if t == toolName {
+				listed = true
+				break
+			}
+		}
+		if !listed {
+			return nil
+		}
+	}
+
+	// Retrying a call that may have had side effects is never safe
+	tool := e.findTool(toolName)
+	if tool == nil || tool.Annotations == nil || !(tool.Annotations.ReadOnlyHint || tool.Annotations.IdempotentHint) {
+		return nil
+	}
+	return policy
+}
+
+// backoff returns the delay before retry n (starting at 1).
+func backoff(policy *registry.RetryPolicy, n int) time.Duration {
+	delay := defaultInitialBackoff
+	if policy.InitialBackoffMs > 0 {
+		delay = time.Duration(policy.InitialBackoffMs) * time.Millisecond
+	}
+	limit := defaultMaxBackoff
+	if policy.MaxBackoffMs > 0 {
+		limit = time.Duration(policy.MaxBackoffMs) * time.Millisecond
+	}
+	multiplier := defaultMultiplier
+	if policy.Multiplier >= 1 {
+		multiplier = policy.Multiplier
+	}
+
+	for i := 1; i < n && delay < limit; i++ {
+		delay = time.Duration(float64(delay) * multiplier)
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// callWithRetry calls a tool on a persistent worker, retrying transport
+// failures according to the server's retry policy. A worker that died is
+// restarted before the next attempt.
+func (e *DiscoveryEngine) callWithRetry(serverName string, worker PersistentWorker, name string, params, meta map[string]interface{}) (*registry.JSONRPCResponse, error) {
+	policy := e.retryPolicy(serverName, name)
+	attempts := 1
+	if policy != nil {
+		attempts = policy.MaxAttempts
+	}
+
+	retries := 0
+	for attempt := 1; ; attempt++ {
+		resp, err := worker.CallToolWithMeta(name, params, meta)
+		if err == nil || attempt >= attempts {
+			e.recordCall(serverName, retries, err != nil || resp.Error != nil)
+			return resp, err
+		}
+
+		delay := backoff(policy, attempt)
+		logger.AddLog("WARN", fmt.Sprintf("[Discovery]%s Call to '%s' on '%s' failed (attempt %d/%d): %v; retrying in %v", traceTag(meta), name, serverName, attempt, attempts, err, delay))
+		select {
+		case <-time.After(delay):
+		case <-e.ctx.Done():
+			e.recordCall(serverName, retries, true)
+			return nil, err
+		}
+		retries++
+
+		if !worker.IsRunning() {
+			e.Remove(serverName)
+			if addErr := e.Add(serverName); addErr != nil {
+				e.recordCall(serverName, retries, true)
+				return nil, fmt.Errorf("%w (restart failed: %v)", err, addErr)
+			}
+			restarted, ok := e.GetWorker(serverName)
+			pw, isPersistent := restarted.(PersistentWorker)
+			if !ok || !isPersistent {
+				e.recordCall(serverName, retries, true)
+				return nil, err
+			}
+			worker = pw
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer fails its first calls with a transport error, dying with the
+// first failure if dies is set.
+type flakyServer struct {
+	fakeServer
+	tools    []registry.Tool
+	failures int
+	dies     bool
+	calls    int
+}
+
+func (f *flakyServer) CallToolWithMeta(string, map[string]interface{}, map[string]interface{}) (*registry.JSONRPCResponse, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		if f.dies {
+			f.running = false
+		}
+		return nil, errors.New("broken pipe")
+	}
+	return &registry.JSONRPCResponse{JSONRPC: "2.0", Result: map[string]interface{}{"ok": true}}, nil
+}
+
+func (f *flakyServer) GetTools() []registry.Tool { return f.tools }
+
+var (
+	readOnlyTool = registry.Tool{Name: "search", Annotations: &registry.ToolAnnotations{ReadOnlyHint: true}}
+	writeTool    = registry.Tool{Name: "send", Annotations: &registry.ToolAnnotations{}}
+)
+
+// retryEngine returns an engine running w as "mail" with the retry policy.
+// The entry's runtime starts the stdio helper server if it is restarted.
+func retryEngine(t *testing.T, policy *registry.RetryPolicy, w *flakyServer) *DiscoveryEngine {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	e := NewDiscoveryEngine(ctx, "", "")
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.registry = append(e.registry, ToolDefinition{Name: "mail", Tools: w.tools, Runtime: &registry.Runtime{
+		Transport: registry.TransportStdio,
+		Command:   os.Args[0],
+		Args:      []string{"-test.run=^TestHelperMCPServer$"},
+		Env:       map[string]string{"SCOOTER_TEST_SERVER": "full"},
+		Retry:     policy,
+	}})
+	e.activeServers["mail"] = w
+	for _, tool := range w.tools {
+		e.toolToServer[tool.Name] = "mail"
+	}
+	return e
+}
+
+func TestBackoff_ScheduleAndCap(t *testing.T) {
+	policy := &registry.RetryPolicy{InitialBackoffMs: 100, MaxBackoffMs: 1000, Multiplier: 3}
+	var got []time.Duration
+	for n := 1; n <= 5; n++ {
+		got = append(got, backoff(policy, n))
+	}
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond, time.Second, time.Second}, got)
+
+	defaults := &registry.RetryPolicy{}
+	assert.Equal(t, 200*time.Millisecond, backoff(defaults, 1))
+	assert.Equal(t, 400*time.Millisecond, backoff(defaults, 2))
+	assert.Equal(t, 5*time.Second, backoff(defaults, 10))
+}
+
+func TestRetryPolicy_Eligibility(t *testing.T) {
+	w := &flakyServer{fakeServer: fakeServer{running: true}, tools: []registry.Tool{readOnlyTool, writeTool, {Name: "list"}}}
+	e := retryEngine(t, &registry.RetryPolicy{MaxAttempts: 3}, w)
+	assert.NotNil(t, e.retryPolicy("mail", "search"))
+	assert.Nil(t, e.retryPolicy("mail", "send"), "tools with side effects are never retried")
+	assert.Nil(t, e.retryPolicy("mail", "list"), "tools without annotations may have side effects")
+
+	// Only listed tools are retried
+	e = retryEngine(t, &registry.RetryPolicy{MaxAttempts: 3, Tools: []string{"list"}}, w)
+	assert.Nil(t, e.retryPolicy("mail", "search"))
+
+	e = retryEngine(t, &registry.RetryPolicy{MaxAttempts: 1}, w)
+	assert.Nil(t, e.retryPolicy("mail", "search"), "a single attempt is no retry")
+}
+
+func TestCallWithRetry(t *testing.T) {
+	policy := &registry.RetryPolicy{MaxAttempts: 3, InitialBackoffMs: 1}
+
+	w := &flakyServer{fakeServer: fakeServer{running: true}, tools: []registry.Tool{readOnlyTool, writeTool}, failures: 2}
+	e := retryEngine(t, policy, w)
+	resp, err := e.callWithRetry("mail", w, "search", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ok": true}, resp.Result)
+	assert.Equal(t, 3, w.calls)
+	assert.Equal(t, CallStats{Calls: 1, Retries: 2}, e.Stats()["mail"])
+
+	// Failed calls to a tool with side effects are returned as they are
+	w = &flakyServer{fakeServer: fakeServer{running: true}, tools: []registry.Tool{readOnlyTool, writeTool}, failures: 1}
+	e = retryEngine(t, policy, w)
+	_, err = e.callWithRetry("mail", w, "send", nil, nil)
+	assert.EqualError(t, err, "broken pipe")
+	assert.Equal(t, 1, w.calls)
+	assert.Equal(t, CallStats{Calls: 1, Failures: 1}, e.Stats()["mail"])
+
+	// So are the last attempt's failures
+	w = &flakyServer{fakeServer: fakeServer{running: true}, tools: []registry.Tool{readOnlyTool}, failures: 5}
+	e = retryEngine(t, policy, w)
+	_, err = e.callWithRetry("mail", w, "search", nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 3, w.calls)
+	assert.Equal(t, CallStats{Calls: 1, Failures: 1, Retries: 2}, e.Stats()["mail"])
+}
+
+func TestCallWithRetry_RestartsDeadWorker(t *testing.T) {
+	// The helper server's echo tool stands in for the restarted server's
+	echo := registry.Tool{Name: "echo", Annotations: &registry.ToolAnnotations{IdempotentHint: true}}
+	w := &flakyServer{fakeServer: fakeServer{running: true}, tools: []registry.Tool{echo}, failures: 1, dies: true}
+	e := retryEngine(t, &registry.RetryPolicy{MaxAttempts: 2, InitialBackoffMs: 1}, w)
+	t.Cleanup(func() { e.Remove("mail") })
+
+	resp, err := e.callWithRetry("mail", w, "echo", map[string]interface{}{"message": "hi"}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, 1, w.calls, "the retry goes to the new worker")
+
+	restarted, ok := e.GetWorker("mail")
+	require.True(t, ok)
+	assert.NotSame(t, w, restarted)
+	assert.IsType(t, &StdioWorker{}, restarted)
+	assert.Equal(t, int64(1), e.Stats()["mail"].Retries)
+}
@@ -0,0 +1,196 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// =============================================================================
+// Message Router
+// =============================================================================
+//
+// MCP is bidirectional: besides answering our requests, a server may send its
+// own requests (ping, roots/list, sampling/createMessage, ...) and
+// notifications (logging, list_changed, progress) at any time. A single read
+// loop owns the child's stdout and routes every message:
+//
+//   id + method   → server request   → answered by handleServerRequest
+//   id, no method → response         → delivered to the waiting sendRequest
+//   method, no id → notification     → passed to the notification handler
+//
+// =============================================================================
+
+// ServerRequestHandler answers a request a downstream server sent to Scooter.
+// It returns either a result or an error for the response.
+type ServerRequestHandler func(method string, params json.RawMessage) (interface{}, *registry.JSONRPCError)
+
+// NotificationHandler receives notifications sent by a downstream server.
+type NotificationHandler func(method string, params json.RawMessage)
+
+// SetRequestHandler installs the handler for server-initiated requests other
+// than ping, which is always answered directly.
+func (w *StdioWorker) SetRequestHandler(h ServerRequestHandler) {
+	w.routerMu.Lock()
+	defer w.routerMu.Unlock()
+	w.requestHandler = h
+}
+
+// SetNotificationHandler installs the handler for server notifications.
+func (w *StdioWorker) SetNotificationHandler(h NotificationHandler) {
+	w.routerMu.Lock()
+	defer w.routerMu.Unlock()
+	w.notificationHandler = h
+}
+
+// SetExitHandler installs a handler called when a running server's output
+// ends without Close having been called, which means the process died.
+func (w *StdioWorker) SetExitHandler(h func(err error)) {
+	w.routerMu.Lock()
+	defer w.routerMu.Unlock()
+	w.exitHandler = h
+}
+
+// incomingMessage has the fields needed to classify any JSON-RPC message.
+type incomingMessage struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// idKey normalises a JSON-RPC id so a response can be matched to its request.
+func idKey(id interface{}) string {
+	switch v := id.(type) {
+	case json.RawMessage:
+		return string(bytes.TrimSpace(v))
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+// readLoop reads every message from the server until stdout closes. Pending
+// requests are then failed with the read error.
+func (w *StdioWorker) readLoop(stdout *bufio.Reader) {
+	var readErr error
+	for {
+		msg, framed, err := readMessage(stdout, func(skipped string) {
+			logger.AddLog("DEBUG", fmt.Sprintf("[%s] Ignoring non-JSON output on stdout: %s", w.command, logger.TruncateForLog(skipped, 512)))
+		})
+		if err != nil {
+			readErr = err
+			break
+		}
+		if framed {
+			w.framed.Store(true)
+		}
+		w.route(msg)
+	}
+
+	if readErr == io.EOF {
+		readErr = fmt.Errorf("server closed stdout: %w", io.EOF)
+	}
+	w.routerMu.Lock()
+	w.readErr = readErr
+	for key, ch := range w.pending {
+		close(ch)
+		delete(w.pending, key)
+	}
+	onExit := w.exitHandler
+	w.routerMu.Unlock()
+
+	// Close clears initialized before stopping the process, and a failed
+	// start never sets it, so only an unexpected exit gets here running
+	w.mu.Lock()
+	crashed := w.initialized
+	w.initialized = false
+	w.mu.Unlock()
+	if crashed && onExit != nil {
+		onExit(readErr)
+	}
+}
+
+func (w *StdioWorker) route(msg []byte) {
+	var in incomingMessage
+	if err := json.Unmarshal(msg, &in); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("[%s] Ignoring unparseable message: %v", w.command, err))
+		return
+	}
+	hasID := len(in.ID) > 0 && string(in.ID) != "null"
+
+	switch {
+	case hasID && in.Method != "":
+		go w.handleServerRequest(in)
+
+	case hasID:
+		var resp registry.JSONRPCResponse
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			logger.AddLog("WARN", fmt.Sprintf("[%s] Failed to parse response: %v", w.command, err))
+			return
+		}
+		key := idKey(in.ID)
+		w.routerMu.Lock()
+		ch, ok := w.pending[key]
+		delete(w.pending, key)
+		w.routerMu.Unlock()
+		if !ok {
+			logger.AddLog("WARN", fmt.Sprintf("[%s] Dropping response for unknown request %s", w.command, key))
+			return
+		}
+		ch <- &resp
+
+	case in.Method != "":
+		w.routerMu.Lock()
+		handler := w.notificationHandler
+		w.routerMu.Unlock()
+		if handler != nil {
+			handler(in.Method, in.Params)
+		} else {
+			logger.AddLog("DEBUG", fmt.Sprintf("[%s] Received notification %s", w.command, in.Method))
+		}
+	}
+}
+
+// handleServerRequest answers a request initiated by the server.
+func (w *StdioWorker) handleServerRequest(in incomingMessage) {
+	resp := registry.JSONRPCResponse{JSONRPC: "2.0", ID: in.ID}
+
+	switch in.Method {
+	case "ping":
+		resp.Result = map[string]interface{}{}
+	default:
+		w.routerMu.Lock()
+		handler := w.requestHandler
+		w.routerMu.Unlock()
+
+		if handler == nil {
+			resp.Error = &registry.JSONRPCError{Code: registry.MethodNotFound, Message: fmt.Sprintf("Method not supported by client: %s", in.Method)}
+		} else if result, rpcErr := handler(in.Method, in.Params); rpcErr != nil {
+			resp.Error = rpcErr
+		} else {
+			resp.Result = result
+		}
+	}
+
+	logger.AddLog("DEBUG", fmt.Sprintf("[%s] Answered server request %s (%s)", w.command, string(in.ID), in.Method))
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	if err := w.write(data); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("[%s] Failed to answer server request %s: %v", w.command, in.Method, err))
+	}
+}
+
+// write sends one message to the server's stdin. Requests, notifications and
+// answers to server requests share the pipe, so writes are serialised.
+func (w *StdioWorker) write(msg []byte) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	return writeMessage(w.stdin, msg, w.framed.Load())
+}
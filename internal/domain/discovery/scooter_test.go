@@ -2,13 +2,25 @@ package discovery_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/mcp-scooter/scooter/internal/demo"
 	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/integration"
 	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockWorker for testing
@@ -64,3 +76,638 @@ func TestEngine_Settings_Propagation(t *testing.T) {
 	
 	// We can't easily check the private settings field, but we can verify SetSettings doesn't panic
 }
+
+func TestEngine_HandleBuiltinTool_FindHidesDeprecated(t *testing.T) {
+	engine := discovery.NewDiscoveryEngine(context.Background(), "", "")
+	msg := "Use new-search instead."
+	engine.Register(discovery.ToolDefinition{
+		Name:     "old-search",
+		Source:   "local",
+		Metadata: &registry.Metadata{Deprecated: true, DeprecationMessage: &msg},
+	})
+
+	names := func(res interface{}) map[string]map[string]interface{} {
+		out := map[string]map[string]interface{}{}
+		for _, entry := range res.(map[string]interface{})["tools"].([]map[string]interface{}) {
+			out[entry["name"].(string)] = entry
+		}
+		return out
+	}
+
+	res, err := engine.HandleBuiltinTool("scooter_find", map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.NotContains(t, names(res), "old-search")
+
+	res, err = engine.HandleBuiltinTool("scooter_find", map[string]interface{}{"include_deprecated": true})
+	assert.NoError(t, err)
+	entry, ok := names(res)["old-search"]
+	assert.True(t, ok)
+	assert.Equal(t, true, entry["deprecated"])
+	assert.Equal(t, "Warning: 'old-search' is deprecated. Use new-search instead.", entry["deprecation_message"])
+}
+
+func TestEngine_HandleBuiltinTool_FindAuthHints(t *testing.T) {
+	engine := discovery.NewDiscoveryEngine(context.Background(), "", "")
+	engine.Register(discovery.ToolDefinition{
+		Name:   "keyed-search",
+		Source: "local",
+		Authorization: &registry.Authorization{
+			Type:        registry.AuthAPIKey,
+			Required:    true,
+			EnvVar:      "KEYED_SEARCH_API_KEY",
+			DisplayName: "Keyed Search API Key",
+		},
+	})
+	engine.Register(discovery.ToolDefinition{Name: "open-search", Source: "local"})
+
+	find := func() map[string]map[string]interface{} {
+		res, err := engine.HandleBuiltinTool("scooter_find", map[string]interface{}{"query": "search"})
+		require.NoError(t, err)
+		out := map[string]map[string]interface{}{}
+		for _, entry := range res.(map[string]interface{})["tools"].([]map[string]interface{}) {
+			out[entry["name"].(string)] = entry
+		}
+		return out
+	}
+
+	entries := find()
+	assert.Equal(t, false, entries["open-search"]["requires_auth"])
+	assert.NotContains(t, entries["open-search"], "auth")
+	assert.Equal(t, false, entries["open-search"]["active"])
+
+	keyed := entries["keyed-search"]
+	assert.Equal(t, true, keyed["requires_auth"])
+	auth := keyed["auth"].(map[string]interface{})
+	assert.Equal(t, false, auth["credentials_stored"])
+	assert.Equal(t, []string{"KEYED_SEARCH_API_KEY"}, auth["missing"])
+
+	engine.SetEnv(map[string]string{"KEYED_SEARCH_API_KEY": "secret"})
+	auth = find()["keyed-search"]["auth"].(map[string]interface{})
+	assert.Equal(t, true, auth["credentials_stored"])
+	assert.NotContains(t, auth, "missing")
+}
+
+func TestEngine_HandleBuiltinTool_FindFilters(t *testing.T) {
+	engine := discovery.NewDiscoveryEngine(context.Background(), "", "")
+	engine.Register(discovery.ToolDefinition{Name: "pg", Source: "local", Category: "database", Tags: []string{"sql", "postgres"}})
+	engine.Register(discovery.ToolDefinition{Name: "sqlite", Source: "local", Category: "database", Tags: []string{"sql"}})
+	engine.Register(discovery.ToolDefinition{Name: "web-search", Source: "local", Category: "search", Description: "Search the web, including sql docs"})
+
+	find := func(params map[string]interface{}) ([]string, map[string]interface{}) {
+		res, err := engine.HandleBuiltinTool("scooter_find", params)
+		require.NoError(t, err)
+		out := res.(map[string]interface{})
+		var names []string
+		for _, entry := range out["tools"].([]map[string]interface{}) {
+			names = append(names, entry["name"].(string))
+		}
+		return names, out
+	}
+
+	names, _ := find(map[string]interface{}{"category": "Database"})
+	assert.ElementsMatch(t, []string{"pg", "sqlite"}, names)
+
+	names, _ = find(map[string]interface{}{"tags": []interface{}{"sql", "postgres"}})
+	assert.Equal(t, []string{"pg"}, names)
+
+	// Name matches rank above description matches
+	names, _ = find(map[string]interface{}{"query": "sql"})
+	assert.Equal(t, []string{"sqlite", "pg", "web-search"}, names)
+
+	names, out := find(map[string]interface{}{"query": "sql", "limit": float64(1)})
+	assert.Equal(t, []string{"sqlite"}, names)
+	assert.Equal(t, 3, out["total"])
+	assert.Equal(t, true, out["truncated"])
+}
+
+func writeRegistryEntry(t *testing.T, dir, subdir, file, name string) {
+	t.Helper()
+	path := filepath.Join(dir, subdir, file)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(`{"name": "`+name+`", "description": "test entry"}`), 0644))
+}
+
+func registryNames(engine *discovery.DiscoveryEngine) map[string]int {
+	names := map[string]int{}
+	for _, td := range engine.Find("") {
+		names[td.Name]++
+	}
+	return names
+}
+
+func TestEngine_ReloadRegistry_DropsDeletedEntries(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistryEntry(t, dir, "official", "alpha.json", "alpha")
+	writeRegistryEntry(t, dir, "custom", "beta.json", "beta")
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), "", dir)
+	defer engine.Stop()
+	before := len(engine.Find(""))
+	assert.Contains(t, registryNames(engine), "beta")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "custom", "beta.json")))
+	require.NoError(t, engine.ReloadRegistry())
+
+	names := registryNames(engine)
+	assert.Contains(t, names, "alpha")
+	assert.NotContains(t, names, "beta")
+	assert.Len(t, engine.Find(""), before-1)
+
+	// Reloading an unchanged tree must not grow the registry
+	require.NoError(t, engine.ReloadRegistry())
+	assert.Len(t, engine.Find(""), before-1)
+}
+
+func TestEngine_ReloadRegistry_Rename(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistryEntry(t, dir, "custom", "old.json", "old-name")
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), "", dir)
+	defer engine.Stop()
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "custom", "old.json")))
+	writeRegistryEntry(t, dir, "custom", "new.json", "new-name")
+	require.NoError(t, engine.ReloadRegistry())
+
+	names := registryNames(engine)
+	assert.NotContains(t, names, "old-name")
+	assert.Equal(t, 1, names["new-name"])
+}
+
+func TestEngine_ReloadRegistry_KeepsRuntimeRegistrations(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistryEntry(t, dir, "official", "alpha.json", "alpha")
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), "", dir)
+	defer engine.Stop()
+	engine.Register(discovery.ToolDefinition{Name: "in-memory", Source: "local"})
+
+	require.NoError(t, engine.ReloadRegistry())
+
+	names := registryNames(engine)
+	assert.Equal(t, 1, names["in-memory"])
+	assert.Equal(t, 1, names["alpha"])
+	assert.Equal(t, 1, names["scooter_find"])
+}
+
+func TestEngine_ReloadRegistry_EditedEntry(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistryEntry(t, dir, "custom", "alpha.json", "alpha")
+
+	first := discovery.NewDiscoveryEngine(context.Background(), "", dir)
+	defer first.Stop()
+	second := discovery.NewDiscoveryEngine(context.Background(), "", dir)
+	defer second.Stop()
+
+	path := filepath.Join(dir, "custom", "alpha.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"name": "alpha", "description": "edited entry"}`), 0644))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	require.NoError(t, first.ReloadRegistry())
+
+	for _, engine := range []*discovery.DiscoveryEngine{first, second} {
+		require.NoError(t, engine.ReloadRegistry())
+		for _, td := range engine.Find("") {
+			if td.Name == "alpha" {
+				assert.Equal(t, "edited entry", td.Description)
+			}
+		}
+	}
+}
+
+// BenchmarkNewDiscoveryEngine measures engine start-up against a registry
+// of 300 entries, both the first engine (cold) and later profiles sharing
+// the parsed registry (warm).
+func BenchmarkNewDiscoveryEngine(b *testing.B) {
+	populate := func(dir string) {
+		for i := 0; i < 300; i++ {
+			entry := fmt.Sprintf(`{"name": "server-%d", "description": "benchmark entry", "tools": [`+
+				`{"name": "search", "description": "Search things", "inputSchema": {"type": "object", "properties": {"query": {"type": "string"}, "limit": {"type": "integer"}}, "required": ["query"]}},`+
+				`{"name": "fetch", "description": "Fetch a thing", "inputSchema": {"type": "object", "properties": {"id": {"type": "string"}}}}]}`, i)
+			path := filepath.Join(dir, "official", fmt.Sprintf("server-%d.json", i))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				b.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(entry), 0644); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("cold", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			dir := b.TempDir()
+			populate(dir)
+			b.StartTimer()
+			discovery.NewDiscoveryEngine(context.Background(), "", dir).Stop()
+		}
+	})
+
+	b.Run("warm", func(b *testing.B) {
+		dir := b.TempDir()
+		populate(dir)
+		discovery.NewDiscoveryEngine(context.Background(), "", dir).Stop()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			discovery.NewDiscoveryEngine(context.Background(), "", dir).Stop()
+		}
+	})
+}
+
+func TestLogLevelAtLeast(t *testing.T) {
+	assert.True(t, discovery.LogLevelAtLeast("error", "warning"))
+	assert.True(t, discovery.LogLevelAtLeast("warning", "warning"))
+	assert.False(t, discovery.LogLevelAtLeast("info", "warning"))
+	assert.True(t, discovery.LogLevelAtLeast("debug", ""), "no minimum lets everything through")
+	assert.True(t, discovery.LogLevelAtLeast("EMERGENCY", "alert"))
+	assert.False(t, discovery.IsLogLevel("verbose"))
+}
+
+func TestWorkspace_Prune(t *testing.T) {
+	ws := discovery.Workspace{Dir: t.TempDir(), QuotaBytes: 10, MaxAge: time.Hour}
+	now := time.Now()
+	write := func(rel string, size int, age time.Duration) {
+		path := filepath.Join(ws.Dir, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, make([]byte, size), 0644))
+		require.NoError(t, os.Chtimes(path, now.Add(-age), now.Add(-age)))
+	}
+	write("stale/old.bin", 1, 2*time.Hour)
+	write("a.bin", 6, 30*time.Minute)
+	write("b.bin", 6, time.Minute)
+
+	removed, err := ws.Prune(now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stale/old.bin", "a.bin"}, removed, "expired first, then oldest until under quota")
+
+	files, total, err := ws.Files()
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, int64(6), total)
+	assert.NoDirExists(t, filepath.Join(ws.Dir, "stale"))
+}
+
+func TestWorkspace_Resolve(t *testing.T) {
+	ws := discovery.Workspace{Dir: t.TempDir()}
+	path, err := ws.Resolve("downloads/file.zip")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(ws.Dir, "downloads", "file.zip"), path)
+
+	for _, bad := range []string{"", "../escape", "a/../../escape", ".", string(filepath.Separator) + "etc"} {
+		_, err := ws.Resolve(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestEngine_Add_MissingCredentials(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "custom", "keyed.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"name": "keyed",
+		"description": "test entry",
+		"authorization": {
+			"type": "api_key",
+			"required": true,
+			"env_var": "KEYED_TEST_API_KEY",
+			"help_url": "https://example.com/keys"
+		},
+		"runtime": {"transport": "stdio", "command": "does-not-exist-scooter-test"}
+	}`), 0644))
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), "", dir)
+	defer engine.Stop()
+
+	err := engine.Add("keyed")
+	var authErr *discovery.AuthRequiredError
+	require.ErrorAs(t, err, &authErr)
+	assert.Equal(t, []string{"KEYED_TEST_API_KEY"}, authErr.Missing)
+	assert.Equal(t, "https://example.com/keys", authErr.HelpURL)
+	assert.Equal(t, "scooter://settings/credentials?tool=keyed", authErr.DeepLink())
+	assert.Contains(t, err.Error(), authErr.DeepLink())
+
+	// With the key in the profile env, activation gets past the check
+	engine.SetEnv(map[string]string{"KEYED_TEST_API_KEY": "secret"})
+	err = engine.Add("keyed")
+	assert.False(t, errors.As(err, &authErr), "unexpected auth error: %v", err)
+}
+
+func TestEngine_HandleBuiltinTool_AddBatch(t *testing.T) {
+	registryDir, wasmDir := t.TempDir(), t.TempDir()
+	require.NoError(t, demo.Install(registryDir, wasmDir, "scooter"))
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), wasmDir, registryDir)
+	defer engine.Stop()
+
+	res, err := engine.HandleBuiltinTool("scooter_add", map[string]interface{}{
+		"tool_names": []interface{}{demo.WASMServerName, "no-such-server", demo.WASMServerName},
+	})
+	require.NoError(t, err)
+	out := res.(map[string]interface{})
+	assert.Equal(t, "partial", out["status"])
+	assert.Equal(t, 1, out["succeeded"])
+	assert.Equal(t, 1, out["failed"])
+
+	results := out["results"].([]map[string]interface{})
+	require.Len(t, results, 2, "duplicates are dropped")
+	assert.Equal(t, "activated", results[0]["status"])
+	assert.Equal(t, "failed", results[1]["status"])
+	assert.Contains(t, results[1]["error"], "no-such-server")
+	assert.True(t, engine.IsActive(demo.WASMServerName))
+
+	// A single name keeps the original response shape
+	res, err = engine.HandleBuiltinTool("scooter_add", map[string]interface{}{"tool_name": demo.WASMServerName})
+	require.NoError(t, err)
+	assert.Equal(t, "already_active", res.(map[string]interface{})["status"])
+}
+
+func TestEngine_HandleBuiltinTool_AddAsync(t *testing.T) {
+	registryDir, wasmDir := t.TempDir(), t.TempDir()
+	require.NoError(t, demo.Install(registryDir, wasmDir, "scooter"))
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), wasmDir, registryDir)
+	defer engine.Stop()
+	done := make(chan error, 1)
+	engine.SetActivationCallback(func(serverName string, err error) {
+		assert.Equal(t, demo.WASMServerName, serverName)
+		done <- err
+	})
+
+	res, err := engine.HandleBuiltinTool("scooter_add", map[string]interface{}{"tool_name": demo.WASMServerName, "async": true})
+	require.NoError(t, err)
+	assert.Equal(t, "activating", res.(map[string]interface{})["status"])
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("activation did not finish")
+	}
+	assert.True(t, engine.IsActive(demo.WASMServerName))
+
+	res, err = engine.HandleBuiltinTool("scooter_list_active", nil)
+	require.NoError(t, err)
+	assert.NotContains(t, res.(map[string]interface{}), "pending")
+
+	// Unknown servers fail up front rather than in the background
+	_, err = engine.HandleBuiltinTool("scooter_add", map[string]interface{}{"tool_name": "no-such-server", "async": true})
+	assert.Error(t, err)
+}
+
+func TestEngine_HandleBuiltinTool_Status(t *testing.T) {
+	registryDir, wasmDir := t.TempDir(), t.TempDir()
+	require.NoError(t, demo.Install(registryDir, wasmDir, "scooter"))
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), wasmDir, registryDir)
+	defer engine.Stop()
+	settings := profile.DefaultSettings()
+	settings.ToolContextBudgetTokens = 1000000
+	engine.SetSettings(settings)
+
+	require.NoError(t, engine.Add(demo.WASMServerName))
+	_, err := engine.CallTool("demo_hello_wasm", map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Error(t, engine.Add("no-such-server"))
+
+	res, err := engine.HandleBuiltinTool("scooter_status", nil)
+	require.NoError(t, err)
+	status := res.(map[string]interface{})
+
+	servers := status["servers"].([]discovery.ServerStatus)
+	require.Len(t, servers, 1)
+	assert.Equal(t, demo.WASMServerName, servers[0].Server)
+	assert.Equal(t, "ready", servers[0].State)
+	assert.Equal(t, 0, status["queued_calls"])
+
+	errs := status["recent_errors"].([]discovery.CallError)
+	require.NotEmpty(t, errs)
+	assert.Equal(t, "no-such-server", errs[0].Server)
+
+	budget := status["context"].(map[string]interface{})
+	assert.Greater(t, budget["estimated_tokens"], 0)
+	assert.Less(t, budget["remaining_tokens"], 1000000)
+}
+
+func TestEngine_Complete_Builtin(t *testing.T) {
+	registryDir, wasmDir := t.TempDir(), t.TempDir()
+	require.NoError(t, demo.Install(registryDir, wasmDir, "scooter"))
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), wasmDir, registryDir)
+	defer engine.Stop()
+
+	prefix := strings.ToUpper(demo.WASMServerName[:3])
+	c, err := engine.Complete(json.RawMessage(`{"ref":{"type":"ref/tool","name":"scooter_add"},"argument":{"name":"tool_name","value":"` + prefix + `"}}`))
+	require.NoError(t, err)
+	assert.Contains(t, c.Values, demo.WASMServerName)
+	for _, v := range c.Values {
+		assert.True(t, strings.HasPrefix(strings.ToUpper(v), prefix), v)
+	}
+
+	c, err = engine.Complete(json.RawMessage(`{"ref":{"type":"ref/tool","name":"scooter_deactivate"},"argument":{"name":"tool_name","value":""}}`))
+	require.NoError(t, err)
+	assert.Empty(t, c.Values, "nothing is active yet")
+
+	_, err = engine.Complete(json.RawMessage(`{"ref":{"type":"ref/nothing"},"argument":{"name":"x","value":""}}`))
+	assert.Error(t, err)
+}
+
+func TestEngine_ResultBudget(t *testing.T) {
+	registryDir, wasmDir := t.TempDir(), t.TempDir()
+	require.NoError(t, demo.Install(registryDir, wasmDir, "scooter"))
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), wasmDir, registryDir)
+	defer engine.Stop()
+	engine.SetCredentialManager(integration.NewMemoryCredentialManager())
+	require.NoError(t, engine.Add(demo.WASMServerName))
+
+	full, err := engine.CallTool("demo_hello_wasm", map[string]interface{}{})
+	require.NoError(t, err)
+	data, _ := json.Marshal(full)
+
+	// Without an AI provider the summary falls back to truncation
+	engine.SetResultBudget(8, true)
+	res, err := engine.CallTool("demo_hello_wasm", map[string]interface{}{})
+	require.NoError(t, err)
+	out := res.(map[string]interface{})
+	content := out["content"].([]map[string]interface{})
+	require.Len(t, content, 2)
+	assert.Len(t, content[0]["text"], 8)
+	meta := out["_meta"].(map[string]interface{})[discovery.ResultMetaKey].(map[string]interface{})
+	assert.Equal(t, false, meta["summarized"])
+	handle := meta["handle"].(string)
+	assert.Contains(t, content[1]["text"], handle)
+
+	var text string
+	offset := 0.0
+	for {
+		page, err := engine.HandleBuiltinTool("scooter_result", map[string]interface{}{"handle": handle, "offset": offset})
+		require.NoError(t, err)
+		p := page.(map[string]interface{})
+		text += p["text"].(string)
+		next, ok := p["next_offset"].(int)
+		if !ok {
+			break
+		}
+		offset = float64(next)
+	}
+	assert.Equal(t, meta["size"], len(text))
+	assert.True(t, len(data) > 8)
+
+	_, err = engine.HandleBuiltinTool("scooter_result", map[string]interface{}{"handle": "res_missing"})
+	assert.Error(t, err)
+
+	// Results within the budget pass through unchanged
+	engine.SetResultBudget(len(data), false)
+	res, err = engine.CallTool("demo_hello_wasm", map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, full, res)
+}
+
+func TestMinifyTool(t *testing.T) {
+	levels := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}
+	tool := registry.Tool{
+		Name:        "search",
+		Description: strings.Repeat("Searches everything. ", 20) + "\nMore details follow.",
+		InputSchema: &registry.JSONSchema{
+			Type: "object",
+			Properties: map[string]registry.PropertySchema{
+				"level": {Type: "string", Description: "Level", Enum: levels},
+				"sort":  {Type: "string", Enum: []string{"asc", "desc"}},
+			},
+			Required: []string{"level"},
+		},
+		SampleInput: map[string]interface{}{"level": "a"},
+		Annotations: &registry.ToolAnnotations{ReadOnlyHint: true, RateLimit: "10/min"},
+	}
+
+	min := discovery.MinifyTool(tool, 40)
+	assert.Equal(t, 40, len([]rune(min.Description)))
+	assert.True(t, strings.HasSuffix(min.Description, "…"))
+	assert.Nil(t, min.SampleInput)
+	assert.Equal(t, &registry.ToolAnnotations{ReadOnlyHint: true}, min.Annotations)
+	assert.Nil(t, min.InputSchema.Properties["level"].Enum)
+	assert.Equal(t, "Level (one of 9 values, see scooter_expand)", min.InputSchema.Properties["level"].Description)
+	assert.Equal(t, []string{"asc", "desc"}, min.InputSchema.Properties["sort"].Enum)
+	assert.Equal(t, []string{"level"}, min.InputSchema.Required)
+
+	// The original, which may be shared with the catalog, is untouched
+	assert.Equal(t, levels, tool.InputSchema.Properties["level"].Enum)
+	assert.Equal(t, "10/min", tool.Annotations.RateLimit)
+}
+
+func TestEngine_IsolatedHome(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("checks the XDG variables")
+	}
+	registryDir, wasmDir, homes := t.TempDir(), t.TempDir(), t.TempDir()
+	require.NoError(t, demo.Install(registryDir, wasmDir, "scooter"))
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), wasmDir, registryDir)
+	defer engine.Stop()
+	engine.SetCredentialManager(integration.NewMemoryCredentialManager())
+	engine.SetHomeIsolation(homes, true)
+	engine.SetEnv(map[string]string{"XDG_CACHE_HOME": "/shared/cache"})
+
+	home := filepath.Join(homes, demo.WASMServerName)
+	vars, err := engine.PreviewEnv(demo.WASMServerName)
+	require.NoError(t, err)
+	sources := map[string]discovery.EnvVar{}
+	for _, v := range vars {
+		sources[v.Name] = v
+	}
+	assert.Equal(t, home, sources["HOME"].Value)
+	assert.Equal(t, discovery.EnvSourceIsolation, sources["HOME"].Source)
+	assert.Equal(t, filepath.Join(home, ".config"), sources["XDG_CONFIG_HOME"].Value)
+	assert.Equal(t, discovery.EnvSourceProfile, sources["XDG_CACHE_HOME"].Source, "explicit cache dirs are kept")
+
+	require.NoError(t, engine.Add(demo.WASMServerName))
+	assert.DirExists(t, filepath.Join(home, ".config"))
+	require.NoError(t, engine.Remove(demo.WASMServerName))
+	assert.NoDirExists(t, home)
+
+	// Without profile-wide isolation only entries asking for it are isolated
+	engine.SetHomeIsolation(homes, false)
+	vars, err = engine.PreviewEnv(demo.WASMServerName)
+	require.NoError(t, err)
+	for _, v := range vars {
+		assert.NotEqual(t, discovery.EnvSourceIsolation, v.Source)
+	}
+}
+
+func TestEngine_ActivationMetrics(t *testing.T) {
+	registryDir, wasmDir := t.TempDir(), t.TempDir()
+	// The stdio demo server's command does not exist, so it never starts
+	require.NoError(t, demo.Install(registryDir, wasmDir, filepath.Join(t.TempDir(), "missing-scooter")))
+	metricsPath := filepath.Join(t.TempDir(), "activation-metrics.json")
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), wasmDir, registryDir)
+	defer engine.Stop()
+	engine.SetActivationMetrics(discovery.NewActivationMetrics(metricsPath))
+
+	assert.Error(t, engine.Add(demo.ServerName))
+	require.NoError(t, engine.Add(demo.WASMServerName))
+	require.NoError(t, engine.Remove(demo.WASMServerName))
+	require.NoError(t, engine.Add(demo.WASMServerName))
+
+	find := func(params map[string]interface{}) []map[string]interface{} {
+		result, err := engine.HandleBuiltinTool("scooter_find", params)
+		require.NoError(t, err)
+		return result.(map[string]interface{})["tools"].([]map[string]interface{})
+	}
+	tools := find(map[string]interface{}{"query": "demo", "sort": "reliability"})
+	require.Len(t, tools, 2)
+	assert.Equal(t, demo.WASMServerName, tools[0]["name"])
+	assert.Equal(t, 1.0, tools[0]["reliability"])
+	assert.Equal(t, 2, tools[0]["activation_attempts"])
+	assert.Equal(t, 0.0, tools[1]["reliability"])
+
+	tools = find(map[string]interface{}{"query": "demo", "min_reliability": 0.5})
+	require.Len(t, tools, 1)
+	assert.Equal(t, demo.WASMServerName, tools[0]["name"])
+
+	// The record survives a restart
+	stats, ok := discovery.NewActivationMetrics(metricsPath).Stats(demo.ServerName)
+	require.True(t, ok)
+	assert.Equal(t, 1, stats.Attempts)
+	assert.Equal(t, 1, stats.Failures)
+	assert.Contains(t, stats.LastError, "missing-scooter")
+}
+
+func TestEngine_QuarantineAfterRepeatedFailures(t *testing.T) {
+	registryDir, wasmDir := t.TempDir(), t.TempDir()
+	require.NoError(t, demo.Install(registryDir, wasmDir, filepath.Join(t.TempDir(), "missing-scooter")))
+
+	engine := discovery.NewDiscoveryEngine(context.Background(), wasmDir, registryDir)
+	defer engine.Stop()
+	settings := profile.DefaultSettings()
+	settings.QuarantineAfterFailures = 2
+	engine.SetSettings(settings)
+
+	for i := 0; i < 2; i++ {
+		err := engine.Add(demo.ServerName)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, discovery.ErrQuarantined)
+	}
+	err := engine.Add(demo.ServerName)
+	assert.ErrorIs(t, err, discovery.ErrQuarantined)
+	stats, _ := engine.ActivationMetrics().Stats(demo.ServerName)
+	assert.Equal(t, 2, stats.Attempts, "a quarantined entry is not started")
+	assert.True(t, stats.Quarantined)
+	assert.Contains(t, stats.QuarantineReason, "missing-scooter")
+
+	result, err := engine.HandleBuiltinTool("scooter_find", map[string]interface{}{"query": "demo"})
+	require.NoError(t, err)
+	for _, tool := range result.(map[string]interface{})["tools"].([]map[string]interface{}) {
+		if tool["name"] == demo.ServerName {
+			assert.NotEmpty(t, tool["quarantined"])
+		}
+	}
+
+	require.True(t, engine.ActivationMetrics().Unquarantine(demo.ServerName))
+	assert.False(t, engine.ActivationMetrics().Unquarantine(demo.ServerName))
+	err = engine.Add(demo.ServerName)
+	assert.NotErrorIs(t, err, discovery.ErrQuarantined, "tried again after unquarantining")
+	stats, _ = engine.ActivationMetrics().Stats(demo.ServerName)
+	assert.False(t, stats.Quarantined)
+	assert.Equal(t, 1, stats.ConsecutiveFailures)
+}
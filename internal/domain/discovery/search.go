@@ -0,0 +1,89 @@
+package discovery
+
+import (
+	"sort"
+	"strings"
+)
+
+// SearchOptions narrows a registry search. Empty fields match everything.
+type SearchOptions struct {
+	Query    string   // Case-insensitive text matched against names, descriptions, tags and tool names
+	Category string   // Exact category, case-insensitive
+	Tags     []string // Every tag must be present, case-insensitive
+}
+
+// Search returns the registry entries matching opts. Entries whose name matches
+// the query come first; otherwise registry order is kept.
+func (e *DiscoveryEngine) Search(opts SearchOptions) []ToolDefinition {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	query := strings.ToLower(strings.TrimSpace(opts.Query))
+	type match struct {
+		td    ToolDefinition
+		score int
+	}
+	var matches []match
+	for _, td := range e.registry {
+		if opts.Category != "" && !strings.EqualFold(td.Category, opts.Category) {
+			continue
+		}
+		if !hasTags(td.Tags, opts.Tags) {
+			continue
+		}
+		score := searchScore(td, query)
+		if score == 0 {
+			continue
+		}
+		matches = append(matches, match{td, score})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	results := make([]ToolDefinition, len(matches))
+	for i, m := range matches {
+		results[i] = m.td
+	}
+	return results
+}
+
+// searchScore ranks how well an entry matches a lower-cased query; 0 means no match.
+func searchScore(td ToolDefinition, query string) int {
+	if query == "" {
+		return 1
+	}
+	name := strings.ToLower(td.Name)
+	switch {
+	case name == query:
+		return 4
+	case strings.Contains(name, query), strings.Contains(strings.ToLower(td.Title), query):
+		return 3
+	}
+
+	fields := []string{td.Description, td.Category}
+	fields = append(fields, td.Tags...)
+	for _, t := range td.Tools {
+		fields = append(fields, t.Name, t.Description)
+	}
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), query) {
+			return 1
+		}
+	}
+	return 0
+}
+
+func hasTags(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if strings.EqualFold(h, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
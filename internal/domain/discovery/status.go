@@ -0,0 +1,207 @@
+package discovery
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/metrics"
+)
+
+// pingTimeout bounds each health check of a downstream server.
+const pingTimeout = 10 * time.Second
+
+// pinger is implemented by workers that answer MCP ping requests.
+type pinger interface {
+	Ping(timeout time.Duration) error
+}
+
+// serverHealth is the outcome of the last health check of a server.
+type serverHealth struct {
+	checked  time.Time
+	latency  time.Duration
+	err      string
+	failures int // Failed checks in a row
+}
+
+// maxRecentErrors is how many failures scooter_status reports.
+const maxRecentErrors = 10
+
+// CallError is a recent failed tool call or activation.
+type CallError struct {
+	Server string    `json:"server"`
+	Tool   string    `json:"tool,omitempty"` // empty for activation failures
+	Error  string    `json:"error"`
+	Time   time.Time `json:"time"`
+}
+
+// beginCall counts a tool call that is waiting on a server.
+func (e *DiscoveryEngine) beginCall(serverName string) {
+	e.mu.Lock()
+	e.inFlight[serverName]++
+	e.mu.Unlock()
+}
+
+// endCall finishes a call started with beginCall at started, remembering
+// err if any.
+func (e *DiscoveryEngine) endCall(serverName, toolName string, started time.Time, err error) {
+	metrics.ToolCallDuration.Observe(time.Since(started).Seconds(), serverName, toolName)
+	if err != nil {
+		metrics.Errors.Inc(metrics.ErrorToolCall)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.inFlight[serverName] <= 1 {
+		delete(e.inFlight, serverName)
+	} else {
+		e.inFlight[serverName]--
+	}
+	if err != nil {
+		e.noteErrorLocked(serverName, toolName, err)
+	}
+}
+
+// noteErrorLocked records a failure; e.mu must be held.
+func (e *DiscoveryEngine) noteErrorLocked(serverName, toolName string, err error) {
+	e.recentErrors = append(e.recentErrors, CallError{
+		Server: serverName,
+		Tool:   toolName,
+		Error:  err.Error(),
+		Time:   time.Now().UTC(),
+	})
+	if n := len(e.recentErrors); n > maxRecentErrors {
+		e.recentErrors = append([]CallError(nil), e.recentErrors[n-maxRecentErrors:]...)
+	}
+}
+
+// RecentErrors returns the last failed calls and activations, newest first.
+func (e *DiscoveryEngine) RecentErrors() []CallError {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	out := make([]CallError, 0, len(e.recentErrors))
+	for i := len(e.recentErrors) - 1; i >= 0; i-- {
+		out = append(out, e.recentErrors[i])
+	}
+	return out
+}
+
+// ServerStatus is one server in scooter_status and GET /api/status.
+type ServerStatus struct {
+	Server string `json:"server"`
+	// State is "ready", "starting", "unhealthy" (the last health check
+	// failed), "crashed" (the process exited), "restarting" (an automatic
+	// restart is waiting out its backoff) or "failed" (a background
+	// activation failed).
+	State     string `json:"state"`
+	Tools     int    `json:"tools,omitempty"`
+	Queued    int    `json:"queued_calls,omitempty"`
+	ElapsedMs int64  `json:"elapsed_ms,omitempty"`
+	// PingMs is the round trip of the last health check, if one was made
+	PingMs int64 `json:"ping_ms,omitempty"`
+	// Restarts counts the automatic restarts in the last hour
+	Restarts int    `json:"restarts,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ServerStatuses reports the state of every active server, sorted by name,
+// followed by the activations still starting or failed.
+func (e *DiscoveryEngine) ServerStatuses() []ServerStatus {
+	now := time.Now()
+	e.mu.RLock()
+	servers := make([]ServerStatus, 0, len(e.activeServers))
+	for name, worker := range e.activeServers {
+		state, errMsg := "ready", ""
+		health, pinged := e.health[name]
+		restarts, restarting := e.restartStatusLocked(name, now)
+		if restarting {
+			state = "restarting"
+		} else if pw, ok := worker.(PersistentWorker); ok && !pw.IsRunning() {
+			state = "crashed"
+		} else if pinged && health.err != "" {
+			state, errMsg = "unhealthy", health.err
+		}
+		tools := 0
+		for _, server := range e.toolToServer {
+			if server == name {
+				tools++
+			}
+		}
+		status := ServerStatus{Server: name, State: state, Tools: tools, Queued: e.inFlight[name], Restarts: restarts, Error: errMsg}
+		if pinged && health.err == "" {
+			status.PingMs = health.latency.Milliseconds()
+		}
+		servers = append(servers, status)
+	}
+	e.mu.RUnlock()
+
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Server < servers[j].Server })
+	for _, pending := range e.PendingActivations() {
+		state := "starting"
+		if pending.Status == "failed" {
+			state = "failed"
+		}
+		servers = append(servers, ServerStatus{
+			Server:    pending.Server,
+			State:     state,
+			ElapsedMs: pending.ElapsedMs,
+			Error:     pending.Error,
+		})
+	}
+	return servers
+}
+
+// statusReport builds the scooter_status result: a compact view of the
+// engine's health for agents.
+func (e *DiscoveryEngine) statusReport() map[string]interface{} {
+	servers := e.ServerStatuses()
+	queued := 0
+	for _, s := range servers {
+		queued += s.Queued
+	}
+	e.mu.RLock()
+	budget := e.settings.ToolContextBudgetTokens
+	e.mu.RUnlock()
+
+	used := e.toolContextTokens()
+	contextInfo := map[string]interface{}{
+		"estimated_tokens": used,
+	}
+	if budget > 0 {
+		remaining := budget - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		contextInfo["budget_tokens"] = budget
+		contextInfo["remaining_tokens"] = remaining
+	}
+
+	return map[string]interface{}{
+		"servers":       servers,
+		"queued_calls":  queued,
+		"recent_errors": e.RecentErrors(),
+		"context":       contextInfo,
+	}
+}
+
+// toolContextTokens estimates how many tokens the current tools/list costs
+// an agent: enabled builtins plus every active server's tools, at roughly
+// four bytes of JSON per token.
+func (e *DiscoveryEngine) toolContextTokens() int {
+	size := 0
+	for _, td := range PrimordialTools() {
+		if e.IsToolDisabled(td.Name) {
+			continue
+		}
+		for _, t := range td.Tools {
+			b, _ := json.Marshal(t)
+			size += len(b)
+		}
+	}
+	for _, server := range e.ListActive() {
+		for _, t := range e.GetActiveToolsForServer(server) {
+			b, _ := json.Marshal(t)
+			size += len(b)
+		}
+	}
+	return (size + 3) / 4
+}
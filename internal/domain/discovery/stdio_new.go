@@ -10,10 +10,12 @@ import (
 	"os/exec"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mcp-scooter/scooter/internal/domain/registry"
 	"github.com/mcp-scooter/scooter/internal/logger"
+	"github.com/mcp-scooter/scooter/internal/version"
 )
 
 // =============================================================================
@@ -70,14 +72,69 @@ type StdioWorker struct {
 	// I/O pipes to the child process (protected by mu)
 	stdin  io.WriteCloser // We write JSON-RPC requests here → child's stdin
 	stdout *bufio.Reader  // We read JSON-RPC responses here ← child's stdout
+	framed atomic.Bool    // Server uses Content-Length framing instead of NDJSON
 
 	// Synchronization
 	mu          sync.Mutex // Protects all mutable state below
 	initialized bool       // True after successful MCP handshake
 	requestID   int64      // Auto-incrementing JSON-RPC request ID
 
+	// Message routing (see router.go). routerMu is independent of mu so the
+	// read loop never waits on a caller that holds mu while awaiting a response.
+	writeMu             sync.Mutex
+	routerMu            sync.Mutex
+	pending             map[string]chan *registry.JSONRPCResponse // request id -> waiting sendRequest
+	readErr             error                                     // Set once the read loop has stopped
+	requestHandler      ServerRequestHandler
+	notificationHandler NotificationHandler
+	exitHandler         func(err error) // Called when a running server dies
+
+	// Startup behaviour (set before Start)
+	timeout    time.Duration // Budget for the handshake and readiness checks
+	readyDelay time.Duration // Pause after "initialized" before the first request
+	phase      atomic.Value  // Current handshake phase, for error reporting
+
 	// Cached data from the MCP server
-	tools []registry.Tool // Tool definitions fetched from the server
+	tools           []registry.Tool        // Tool definitions fetched from the server
+	capabilities    map[string]interface{} // Capabilities from the initialize response
+	serverInfo      map[string]interface{} // serverInfo from the initialize response
+	protocolVersion string                 // Protocol version the server answered with
+}
+
+// Handshake phases reported in HandshakeError.
+const (
+	PhaseSpawn       = "spawn"
+	PhaseInitialize  = "initialize"
+	PhaseInitialized = "initialized notification"
+	PhaseToolsList   = "tools/list"
+)
+
+// defaultStartTimeout applies when the registry entry sets no runtime timeout.
+// npx can be slow on Windows, especially on first run.
+const defaultStartTimeout = 60 * time.Second
+
+// mcpProtocolVersion is the MCP protocol version Scooter asks servers for.
+const mcpProtocolVersion = "2024-11-05"
+
+// initializeResult is the part of the initialize response Scooter keeps.
+type initializeResult struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ServerInfo      map[string]interface{} `json:"serverInfo"`
+}
+
+// HandshakeError reports which step of the MCP handshake failed.
+type HandshakeError struct {
+	Phase string
+	Err   error
+}
+
+func (e *HandshakeError) Error() string {
+	return fmt.Sprintf("MCP handshake failed during %s: %v", e.Phase, e.Err)
+}
+
+func (e *HandshakeError) Unwrap() error {
+	return e.Err
 }
 
 // NewStdioWorker creates a new StdioWorker but does NOT start the process.
@@ -88,7 +145,50 @@ func NewStdioWorker(ctx context.Context, command string, args []string) *StdioWo
 		args:      args,
 		ctx:       ctx,
 		requestID: 1, // JSON-RPC IDs start at 1
+		timeout:   defaultStartTimeout,
+		pending:   make(map[string]chan *registry.JSONRPCResponse),
+	}
+}
+
+// SetStartupOptions configures the handshake for servers that need it: timeout
+// bounds the whole handshake including readiness retries, and readyDelay is a
+// pause after the initialized notification for servers that reject requests
+// until it has settled. Zero values keep the defaults. Call before Start.
+func (w *StdioWorker) SetStartupOptions(timeout, readyDelay time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if timeout > 0 {
+		w.timeout = timeout
+	}
+	w.readyDelay = readyDelay
+}
+
+// Capabilities returns the capabilities the server announced in its
+// initialize response.
+func (w *StdioWorker) Capabilities() map[string]interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.capabilities
+}
+
+// ServerInfo returns the serverInfo and protocol version the server sent in
+// its initialize response.
+func (w *StdioWorker) ServerInfo() (map[string]interface{}, string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.serverInfo, w.protocolVersion
+}
+
+func (w *StdioWorker) setPhase(phase string) {
+	w.phase.Store(phase)
+}
+
+func (w *StdioWorker) currentPhase() string {
+	phase, _ := w.phase.Load().(string)
+	if phase == "" {
+		return PhaseSpawn
 	}
+	return phase
 }
 
 // =============================================================================
@@ -128,9 +228,12 @@ func (w *StdioWorker) Start(env map[string]string) error {
 
 	// Store environment variables for later use
 	w.env = env
+	timeout := w.timeout
+	w.setPhase(PhaseSpawn)
 
 	// Create the command with context (allows cancellation)
 	w.cmd = exec.CommandContext(w.ctx, w.command, w.args...)
+	PrepareCommand(w.cmd) // .cmd shims such as npx on Windows
 
 	// -------------------------------------------------------------------------
 	// Set up stdin pipe: We write JSON-RPC requests here
@@ -216,6 +319,9 @@ func (w *StdioWorker) Start(env map[string]string) error {
 		return fmt.Errorf("failed to start MCP server: %w", err)
 	}
 
+	// From here on the read loop owns stdout
+	go w.readLoop(w.stdout)
+
 	// =========================================================================
 	// PHASE 2: MCP Handshake (mutex RELEASED)
 	// =========================================================================
@@ -232,9 +338,10 @@ func (w *StdioWorker) Start(env map[string]string) error {
 
 	// Run the handshake in a goroutine so we can race against:
 	// 1. Critical errors from stderr
-	// 2. A timeout (60 seconds for slow npx downloads on Windows)
+	// 2. A timeout (Runtime.Timeout, or 60 seconds for slow npx downloads on Windows)
+	deadline := time.Now().Add(timeout)
 	go func() {
-		resChan <- handshakeResult{err: w.initializeHandshake()}
+		resChan <- handshakeResult{err: w.initializeHandshake(deadline)}
 	}()
 
 	// -------------------------------------------------------------------------
@@ -250,7 +357,7 @@ func (w *StdioWorker) Start(env map[string]string) error {
 				w.cmd.Process.Kill()
 			}
 			w.mu.Unlock()
-			return fmt.Errorf("MCP initialize handshake failed: %w", res.err)
+			return res.err
 		}
 		// Handshake succeeded - fall through to set initialized=true
 
@@ -261,16 +368,16 @@ func (w *StdioWorker) Start(env map[string]string) error {
 			w.cmd.Process.Kill()
 		}
 		w.mu.Unlock()
-		return fmt.Errorf("MCP server failed with critical error: %s", critLine)
+		return &HandshakeError{Phase: w.currentPhase(), Err: fmt.Errorf("server reported a critical error: %s", critLine)}
 
-	case <-time.After(60 * time.Second):
+	case <-time.After(timeout):
 		// Timeout - npx can be slow on Windows, especially first run
 		w.mu.Lock()
 		if w.cmd != nil && w.cmd.Process != nil {
 			w.cmd.Process.Kill()
 		}
 		w.mu.Unlock()
-		return fmt.Errorf("MCP server timed out during initialization")
+		return &HandshakeError{Phase: w.currentPhase(), Err: fmt.Errorf("timed out after %v", timeout)}
 	}
 
 	// =========================================================================
@@ -306,46 +413,66 @@ func (w *StdioWorker) Start(env map[string]string) error {
 
 // initializeHandshake performs the MCP protocol initialization sequence.
 // This is called WITHOUT the mutex held (from a goroutine in Start()).
-func (w *StdioWorker) initializeHandshake() error {
+// Errors are returned as *HandshakeError naming the failed phase.
+func (w *StdioWorker) initializeHandshake(deadline time.Time) error {
 	// -------------------------------------------------------------------------
 	// Step 1: Send "initialize" request
 	// -------------------------------------------------------------------------
 	// This tells the server who we are and what protocol version we support.
+	w.setPhase(PhaseInitialize)
 	initReq := registry.JSONRPCRequest{
 		JSONRPC: "2.0",
 		ID:      w.nextID(),
 		Method:  "initialize",
 	}
 	initParams := map[string]interface{}{
-		"protocolVersion": "2024-11-05", // MCP protocol version
+		"protocolVersion": mcpProtocolVersion,
 		"capabilities":    map[string]interface{}{},
 		"clientInfo": map[string]string{
 			"name":    "mcp-scooter",
-			"version": "0.1.0",
+			"version": version.Version,
 		},
 	}
 	initReq.Params, _ = json.Marshal(initParams)
 
 	resp, err := w.sendRequest(initReq)
 	if err != nil {
-		return fmt.Errorf("initialize request failed: %w", err)
+		return &HandshakeError{Phase: PhaseInitialize, Err: err}
 	}
 	if resp.Error != nil {
-		return fmt.Errorf("initialize error: %s (code: %d)", resp.Error.Message, resp.Error.Code)
+		return &HandshakeError{Phase: PhaseInitialize, Err: fmt.Errorf("%s (code: %d)", resp.Error.Message, resp.Error.Code)}
 	}
 
+	// Keep the server's capabilities; strict servers only accept requests
+	// for what they announced
+	var initResult initializeResult
+	resultBytes, _ := json.Marshal(resp.Result)
+	json.Unmarshal(resultBytes, &initResult)
+	w.mu.Lock()
+	w.capabilities = initResult.Capabilities
+	w.serverInfo = initResult.ServerInfo
+	w.protocolVersion = initResult.ProtocolVersion
+	readyDelay := w.readyDelay
+	w.mu.Unlock()
+
 	// -------------------------------------------------------------------------
 	// Step 2: Send "initialized" notification
 	// -------------------------------------------------------------------------
 	// This is a notification (no response expected) that tells the server
 	// we've processed its initialize response and are ready to proceed.
+	w.setPhase(PhaseInitialized)
 	initializedNotif := registry.JSONRPCRequest{
 		JSONRPC: "2.0",
 		Method:  "notifications/initialized",
 		// Note: No ID field for notifications
 	}
 	if err := w.sendNotification(initializedNotif); err != nil {
-		return fmt.Errorf("initialized notification failed: %w", err)
+		return &HandshakeError{Phase: PhaseInitialized, Err: err}
+	}
+
+	// Some servers reject requests until "initialized" has settled
+	if readyDelay > 0 {
+		time.Sleep(readyDelay)
 	}
 
 	// -------------------------------------------------------------------------
@@ -353,58 +480,95 @@ func (w *StdioWorker) initializeHandshake() error {
 	// -------------------------------------------------------------------------
 	// This is optional - some servers might not support tools/list.
 	// We don't fail initialization if this fails.
-	if err := w.fetchTools(); err != nil {
-		fmt.Printf("[StdioWorker] Warning: failed to fetch tools: %v\n", err)
+	w.setPhase(PhaseToolsList)
+	if err := w.fetchToolsUntil(deadline, initResult.Capabilities); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("[StdioWorker] %v", &HandshakeError{Phase: PhaseToolsList, Err: err}))
 	}
 
 	return nil
 }
 
-// fetchTools retrieves the list of available tools from the MCP server.
-// Some servers need a moment after initialization before they're ready,
-// so we retry up to 3 times with a 500ms delay.
+// fetchTools retrieves the list of available tools from the MCP server,
+// retrying a few times for servers that need a moment after initialization.
 func (w *StdioWorker) fetchTools() error {
+	return w.fetchToolsUntil(time.Now().Add(2*time.Second), nil)
+}
+
+// fetchToolsUntil retrieves the list of available tools, retrying with
+// exponential backoff until the deadline. A server whose capabilities don't
+// include tools is only asked once.
+func (w *StdioWorker) fetchToolsUntil(deadline time.Time, capabilities map[string]interface{}) error {
+	_, hasTools := capabilities["tools"]
+	retry := capabilities == nil || hasTools
+
 	var lastErr error
+	delay := 250 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		tools, err := w.listAllTools()
+		if err == nil {
+			w.tools = tools
+			logger.AddLog("INFO", fmt.Sprintf("[StdioWorker] Discovered %d tools from server", len(w.tools)))
+			return nil
+		}
+		lastErr = err
+
+		if !retry || time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("%w (after %d attempts)", lastErr, attempt)
+		}
+		logger.AddLog("INFO", fmt.Sprintf("[StdioWorker] tools/list failed, retrying in %v... (%v)", delay, lastErr))
+		time.Sleep(delay)
+		if delay < 2*time.Second {
+			delay *= 2
+		}
+	}
+}
+
+// maxToolPages stops following nextCursor from a server that never stops
+// returning one.
+const maxToolPages = 100
 
-	for attempt := 0; attempt < 3; attempt++ {
+// listAllTools sends tools/list, following nextCursor until the server has
+// returned every page.
+func (w *StdioWorker) listAllTools() ([]registry.Tool, error) {
+	var tools []registry.Tool
+	cursor := ""
+	for page := 0; page < maxToolPages; page++ {
 		req := registry.JSONRPCRequest{
 			JSONRPC: "2.0",
 			ID:      w.nextID(),
 			Method:  "tools/list",
 		}
+		if cursor != "" {
+			req.Params, _ = json.Marshal(map[string]string{"cursor": cursor})
+		}
 
 		resp, err := w.sendRequest(req)
-		if err == nil {
-			if resp.Error != nil {
-				lastErr = fmt.Errorf("tools/list error: %s", resp.Error.Message)
-			} else {
-				// Parse the tools from the response
-				if resp.Result != nil {
-					var result struct {
-						Tools []registry.Tool `json:"tools"`
-					}
-					// Re-marshal and unmarshal to convert interface{} to struct
-					resultBytes, _ := json.Marshal(resp.Result)
-					if err := json.Unmarshal(resultBytes, &result); err == nil {
-						w.tools = result.Tools
-						logger.AddLog("INFO", fmt.Sprintf("[StdioWorker] Discovered %d tools from server", len(w.tools)))
-						return nil
-					}
-				}
-				return nil
-			}
-		} else {
-			lastErr = err
+		if err != nil {
+			return nil, err
 		}
-
-		// Retry with delay (except on last attempt)
-		if attempt < 2 {
-			logger.AddLog("INFO", fmt.Sprintf("[StdioWorker] tools/list failed, retrying in 500ms... (%v)", lastErr))
-			time.Sleep(500 * time.Millisecond)
+		if resp.Error != nil {
+			return nil, fmt.Errorf("tools/list error: %s", resp.Error.Message)
+		}
+		if resp.Result == nil {
+			return tools, nil
+		}
+		var result struct {
+			Tools      []registry.Tool `json:"tools"`
+			NextCursor string          `json:"nextCursor"`
+		}
+		// Re-marshal and unmarshal to convert interface{} to struct
+		resultBytes, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(resultBytes, &result); err != nil {
+			return tools, nil
 		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = result.NextCursor
 	}
-
-	return lastErr
+	logger.AddLog("WARN", fmt.Sprintf("[StdioWorker] Stopped after %d pages of tools/list", maxToolPages))
+	return tools, nil
 }
 
 // =============================================================================
@@ -456,12 +620,15 @@ func (w *StdioWorker) Execute(stdin io.Reader, stdout io.Writer, env map[string]
 		return fmt.Errorf("failed to decode request: %w", err)
 	}
 
-	// Assign a new request ID and forward to the MCP server
+	// Assign a new request ID for the downstream leg; the caller gets its
+	// own ID back so responses match the request it sent.
+	clientID := req.ID
 	req.ID = w.nextID()
 	resp, err := w.sendRequest(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request to MCP server: %w", err)
 	}
+	resp.ID = clientID
 
 	// Write the response back
 	encoder := json.NewEncoder(stdout)
@@ -478,6 +645,12 @@ func (w *StdioWorker) Execute(stdin io.Reader, stdout io.Writer, env map[string]
 //	    "count": 10,
 //	})
 func (w *StdioWorker) CallTool(name string, arguments map[string]interface{}) (*registry.JSONRPCResponse, error) {
+	return w.CallToolWithMeta(name, arguments, nil)
+}
+
+// CallToolWithMeta calls a tool like CallTool, sending meta as the request's
+// params._meta (e.g. a trace ID). A nil meta omits the field.
+func (w *StdioWorker) CallToolWithMeta(name string, arguments, meta map[string]interface{}) (*registry.JSONRPCResponse, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -494,15 +667,65 @@ func (w *StdioWorker) CallTool(name string, arguments map[string]interface{}) (*
 	callParams := struct {
 		Name      string                 `json:"name"`
 		Arguments map[string]interface{} `json:"arguments"`
+		Meta      map[string]interface{} `json:"_meta,omitempty"`
 	}{
 		Name:      name,
 		Arguments: arguments,
+		Meta:      meta,
 	}
 	req.Params, _ = json.Marshal(callParams)
 
 	return w.sendRequest(req)
 }
 
+// Request sends any request to the running server, e.g. resources/list, and
+// waits up to timeout for the answer. Like Ping it doesn't wait for
+// in-flight tool calls.
+func (w *StdioWorker) Request(method string, params interface{}, timeout time.Duration) (*registry.JSONRPCResponse, error) {
+	w.mu.Lock()
+	if !w.initialized {
+		w.mu.Unlock()
+		return nil, fmt.Errorf("server not initialized")
+	}
+	req := registry.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      w.nextID(),
+		Method:  method,
+	}
+	w.mu.Unlock()
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		req.Params = data
+	}
+	return w.sendRequestTimeout(req, timeout)
+}
+
+// SetLogLevel asks the server to only send log messages at or above level
+// (logging/setLevel). Only servers with the logging capability support it.
+func (w *StdioWorker) SetLogLevel(level string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	req := registry.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      w.nextID(),
+		Method:  "logging/setLevel",
+	}
+	req.Params, _ = json.Marshal(map[string]string{"level": level})
+
+	resp, err := w.sendRequest(req)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("logging/setLevel error: %s", resp.Error.Message)
+	}
+	return nil
+}
+
 // =============================================================================
 // Low-Level I/O Methods
 // =============================================================================
@@ -512,67 +735,64 @@ func (w *StdioWorker) CallTool(name string, arguments map[string]interface{}) (*
 //
 // Data flow:
 //   1. Marshal request to JSON
-//   2. Write to child's stdin (with newline delimiter)
-//   3. Read response from child's stdout (newline-delimited)
-//   4. Unmarshal response JSON
+//   2. Register the request ID with the router (see router.go)
+//   3. Write to child's stdin (newline-delimited, or Content-Length framed
+//      once the server has answered that way)
+//   4. Wait for the read loop to deliver the response with the same ID
 //
 // Timeout: 60 seconds (some tools like web search can be slow)
 func (w *StdioWorker) sendRequest(req registry.JSONRPCRequest) (*registry.JSONRPCResponse, error) {
-	// -------------------------------------------------------------------------
-	// Write the request to the child's stdin
-	// -------------------------------------------------------------------------
+	return w.sendRequestTimeout(req, 60*time.Second)
+}
+
+// sendRequestTimeout is sendRequest with a custom response timeout.
+func (w *StdioWorker) sendRequestTimeout(req registry.JSONRPCRequest, timeout time.Duration) (*registry.JSONRPCResponse, error) {
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
-	// MCP uses newline-delimited JSON
-	reqBytes = append(reqBytes, '\n')
-
-	startTime := time.Now()
-	if _, err := w.stdin.Write(reqBytes); err != nil {
-		return nil, fmt.Errorf("failed to write request: %w", err)
-	}
-
-	logger.AddLog("INFO", fmt.Sprintf("[%s] Sent request %v (%s), waiting for response...", w.command, req.ID, req.Method))
 
 	// -------------------------------------------------------------------------
-	// Read the response from the child's stdout (with timeout)
+	// Register for the response before writing so it can't be missed
 	// -------------------------------------------------------------------------
-	// We use channels and a goroutine to implement the timeout because
-	// bufio.Reader.ReadBytes() is blocking.
+	key := idKey(req.ID)
 	responseChan := make(chan *registry.JSONRPCResponse, 1)
-	errorChan := make(chan error, 1)
+	w.routerMu.Lock()
+	if w.readErr != nil {
+		err := w.readErr
+		w.routerMu.Unlock()
+		return nil, err
+	}
+	w.pending[key] = responseChan
+	w.routerMu.Unlock()
+	defer func() {
+		w.routerMu.Lock()
+		delete(w.pending, key)
+		w.routerMu.Unlock()
+	}()
 
-	go func() {
-		// Read until newline (JSON-RPC response delimiter)
-		line, err := w.stdout.ReadBytes('\n')
-		if err != nil {
-			errorChan <- err
-			return
-		}
+	startTime := time.Now()
+	if err := w.write(reqBytes); err != nil {
+		return nil, fmt.Errorf("failed to write request: %w", err)
+	}
 
-		// Parse the JSON response
-		var resp registry.JSONRPCResponse
-		if err := json.Unmarshal(line, &resp); err != nil {
-			errorChan <- fmt.Errorf("failed to parse response: %w", err)
-			return
-		}
-		responseChan <- &resp
-	}()
+	logger.AddLog("INFO", fmt.Sprintf("[%s] Sent request %v (%s), waiting for response...", w.command, req.ID, req.Method))
 
-	// Wait for response, error, timeout, or context cancellation
+	// Wait for response, read failure, timeout, or context cancellation
 	select {
-	case resp := <-responseChan:
+	case resp, ok := <-responseChan:
 		duration := time.Since(startTime)
+		if !ok {
+			w.routerMu.Lock()
+			err := w.readErr
+			w.routerMu.Unlock()
+			logger.AddLog("ERROR", fmt.Sprintf("[%s] Error reading response for %v after %v: %v", w.command, req.ID, duration, err))
+			return nil, err
+		}
 		logger.AddLog("INFO", fmt.Sprintf("[%s] Received response for %v in %v", w.command, req.ID, duration))
 		return resp, nil
 
-	case err := <-errorChan:
-		duration := time.Since(startTime)
-		logger.AddLog("ERROR", fmt.Sprintf("[%s] Error reading response for %v after %v: %v", w.command, req.ID, duration, err))
-		return nil, err
-
-	case <-time.After(60 * time.Second):
+	case <-time.After(timeout):
 		duration := time.Since(startTime)
 		logger.AddLog("ERROR", fmt.Sprintf("[%s] Timeout waiting for response for %v (%s) after %v", w.command, req.ID, req.Method, duration))
 		return nil, fmt.Errorf("timeout waiting for response after %v", duration)
@@ -590,10 +810,7 @@ func (w *StdioWorker) sendNotification(req registry.JSONRPCRequest) error {
 	if err != nil {
 		return err
 	}
-	reqBytes = append(reqBytes, '\n')
-
-	_, err = w.stdin.Write(reqBytes)
-	return err
+	return w.write(reqBytes)
 }
 
 // nextID returns the next JSON-RPC request ID.
@@ -608,6 +825,51 @@ func (w *StdioWorker) nextID() int64 {
 // Lifecycle Methods
 // =============================================================================
 
+// Ping sends an MCP ping and waits up to timeout for the answer. A server
+// that replies with an error (e.g. it doesn't implement ping) is alive too.
+//
+// The worker lock is only held to take a request ID, so a ping is answered
+// even while a slow tool call is in flight.
+func (w *StdioWorker) Ping(timeout time.Duration) error {
+	w.mu.Lock()
+	if !w.initialized || w.cmd == nil || w.cmd.Process == nil {
+		w.mu.Unlock()
+		return fmt.Errorf("server is not running")
+	}
+	req := registry.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      w.nextID(),
+		Method:  "ping",
+	}
+	w.mu.Unlock()
+
+	_, err := w.sendRequestTimeout(req, timeout)
+	return err
+}
+
+// Complete forwards a completion/complete request. Like Ping it doesn't wait
+// for in-flight tool calls; completions are requested as the user types.
+func (w *StdioWorker) Complete(params json.RawMessage, timeout time.Duration) (*registry.JSONRPCResponse, error) {
+	w.mu.Lock()
+	if !w.initialized {
+		w.mu.Unlock()
+		return nil, fmt.Errorf("server not initialized")
+	}
+	if _, ok := w.capabilities["completions"]; !ok {
+		w.mu.Unlock()
+		return nil, fmt.Errorf("server does not support completions")
+	}
+	req := registry.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      w.nextID(),
+		Method:  "completion/complete",
+		Params:  params,
+	}
+	w.mu.Unlock()
+
+	return w.sendRequestTimeout(req, timeout)
+}
+
 // IsRunning returns whether the server process is running and initialized.
 // Thread-safe.
 func (w *StdioWorker) IsRunning() bool {
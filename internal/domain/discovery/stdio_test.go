@@ -0,0 +1,224 @@
+package discovery_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHelperMCPServer is not a real test: it is re-executed by the stdio tests
+// as a fake MCP server. SCOOTER_TEST_SERVER selects how noisy it is:
+//
+//	banner  prints non-JSON lines before and between responses
+//	framed  answers with Content-Length framed messages
+//	strict  rejects tools/list until 300ms after the initialized notification
+//	mute    never answers initialize
+//	chatty  sends its own requests and a notification before answering tools/call
+//	full    describes its tool completely, as a registry entry requires
+func TestHelperMCPServer(t *testing.T) {
+	mode := os.Getenv("SCOOTER_TEST_SERVER")
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+
+	in := bufio.NewReader(os.Stdin)
+	out := os.Stdout
+	if mode == "banner" {
+		fmt.Fprintln(out, "Starting fake MCP server v1.0 ...")
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "INFO: listening on stdio")
+	}
+
+	var initializedAt time.Time
+	for {
+		msg, err := readHelperRequest(in)
+		if err != nil {
+			return
+		}
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if json.Unmarshal(msg, &req) != nil || req.ID == nil {
+			if req.Method == "notifications/initialized" {
+				initializedAt = time.Now()
+			}
+			continue // notification
+		}
+		if mode == "mute" {
+			continue
+		}
+
+		if mode == "strict" && req.Method == "tools/list" && (initializedAt.IsZero() || time.Since(initializedAt) < 300*time.Millisecond) {
+			resp, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{"code": -32002, "message": "server not initialized"}})
+			fmt.Fprintf(out, "%s\n", resp)
+			continue
+		}
+
+		var result interface{}
+		switch {
+		case mode == "chatty" && req.Method == "tools/call":
+			fmt.Fprintln(out, `{"jsonrpc":"2.0","method":"notifications/message","params":{"level":"info","data":"working"}}`)
+			fmt.Fprintln(out, `{"jsonrpc":"2.0","id":"srv-1","method":"ping"}`)
+			fmt.Fprintln(out, `{"jsonrpc":"2.0","id":"srv-2","method":"sampling/createMessage","params":{}}`)
+			answers := map[string]string{}
+			for len(answers) < 2 {
+				msg, err := readHelperRequest(in)
+				if err != nil {
+					return
+				}
+				var answer struct {
+					ID     string          `json:"id"`
+					Result json.RawMessage `json:"result"`
+				}
+				json.Unmarshal(msg, &answer)
+				answers[answer.ID] = string(answer.Result)
+			}
+			text := fmt.Sprintf("ping=%s sampling=%s", answers["srv-1"], answers["srv-2"])
+			result = map[string]interface{}{"content": []map[string]string{{"type": "text", "text": text}}}
+		case mode == "full" && req.Method == "initialize":
+			result = map[string]interface{}{"protocolVersion": "2024-11-05", "capabilities": map[string]interface{}{"tools": map[string]interface{}{}}, "serverInfo": map[string]interface{}{"name": "Helper Server", "version": "0.3.1"}}
+		case req.Method == "initialize":
+			result = map[string]interface{}{"protocolVersion": "2024-11-05", "capabilities": map[string]interface{}{"tools": map[string]interface{}{}}}
+		case mode == "full" && req.Method == "tools/list":
+			result = map[string]interface{}{"tools": []map[string]interface{}{{
+				"name": "echo", "description": "Echo the message back",
+				"inputSchema": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"message": map[string]interface{}{"type": "string"}}, "required": []string{"message"}},
+			}}}
+		case req.Method == "tools/list":
+			result = map[string]interface{}{"tools": []map[string]interface{}{{"name": "echo", "description": "Echo"}}}
+		default:
+			result = map[string]interface{}{"content": []map[string]string{{"type": "text", "text": "ok"}}}
+		}
+		resp, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+
+		switch mode {
+		case "framed":
+			fmt.Fprintf(out, "Content-Length: %d\r\nContent-Type: application/json\r\n\r\n%s", len(resp), resp)
+		case "banner":
+			fmt.Fprintf(out, "[debug] handling %s\n", req.Method)
+			fmt.Fprintf(out, "%s\n", resp)
+		default:
+			fmt.Fprintf(out, "%s\n", resp)
+		}
+	}
+}
+
+// readHelperRequest reads one NDJSON or Content-Length framed request.
+func readHelperRequest(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if n, ok := strings.CutPrefix(strings.TrimSpace(line), "Content-Length:"); ok {
+		length, _ := strconv.Atoi(strings.TrimSpace(n))
+		for {
+			header, err := r.ReadString('\n')
+			if err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(header) == "" {
+				break
+			}
+		}
+		body := make([]byte, length)
+		_, err := io.ReadFull(r, body)
+		return body, err
+	}
+	return []byte(line), nil
+}
+
+func newHelperServer(t *testing.T) *discovery.StdioWorker {
+	t.Helper()
+	w := discovery.NewStdioWorker(context.Background(), os.Args[0], []string{"-test.run=^TestHelperMCPServer$"})
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+func startHelperServer(t *testing.T, mode string) *discovery.StdioWorker {
+	t.Helper()
+	w := newHelperServer(t)
+	require.NoError(t, w.Start(map[string]string{"SCOOTER_TEST_SERVER": mode}))
+	return w
+}
+
+func TestStdioWorker_NoisyServers(t *testing.T) {
+	for _, mode := range []string{"plain", "banner", "framed"} {
+		t.Run(mode, func(t *testing.T) {
+			w := startHelperServer(t, mode)
+
+			tools := w.GetTools()
+			require.Len(t, tools, 1)
+			assert.Equal(t, "echo", tools[0].Name)
+
+			// Several calls in a row: framing must stay in sync
+			for i := 0; i < 3; i++ {
+				resp, err := w.CallTool("echo", map[string]interface{}{"i": i})
+				require.NoError(t, err)
+				assert.Nil(t, resp.Error)
+				assert.NotNil(t, resp.Result)
+			}
+		})
+	}
+}
+
+func TestStdioWorker_StrictServerReadiness(t *testing.T) {
+	w := newHelperServer(t)
+	w.SetStartupOptions(5*time.Second, 0)
+	require.NoError(t, w.Start(map[string]string{"SCOOTER_TEST_SERVER": "strict"}))
+
+	// tools/list is retried until the server is ready
+	require.Len(t, w.GetTools(), 1)
+	assert.Contains(t, w.Capabilities(), "tools")
+}
+
+func TestStdioWorker_HandshakePhaseInError(t *testing.T) {
+	w := newHelperServer(t)
+	w.SetStartupOptions(300*time.Millisecond, 0)
+	err := w.Start(map[string]string{"SCOOTER_TEST_SERVER": "mute"})
+
+	var handshakeErr *discovery.HandshakeError
+	require.ErrorAs(t, err, &handshakeErr)
+	assert.Equal(t, discovery.PhaseInitialize, handshakeErr.Phase)
+	assert.Contains(t, err.Error(), "timed out")
+}
+
+func TestStdioWorker_ServerInitiatedMessages(t *testing.T) {
+	w := newHelperServer(t)
+	var mu sync.Mutex
+	var notifications []string
+	w.SetNotificationHandler(func(method string, params json.RawMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		notifications = append(notifications, method)
+	})
+	w.SetRequestHandler(func(method string, params json.RawMessage) (interface{}, *registry.JSONRPCError) {
+		return map[string]string{"handled": method}, nil
+	})
+	require.NoError(t, w.Start(map[string]string{"SCOOTER_TEST_SERVER": "chatty"}))
+
+	resp, err := w.CallTool("echo", nil)
+	require.NoError(t, err)
+	require.Nil(t, resp.Error)
+
+	text := resp.Result.(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})["text"]
+	assert.Equal(t, `ping={} sampling={"handled":"sampling/createMessage"}`, text)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"notifications/message"}, notifications)
+}
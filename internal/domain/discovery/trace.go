@@ -0,0 +1,29 @@
+package discovery
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// TraceMetaKey is the _meta key carrying the gateway's trace ID on requests
+// sent to downstream servers and on results returned to clients.
+const TraceMetaKey = "traceId"
+
+// NewTraceID returns a random 16-byte hex trace ID.
+func NewTraceID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// traceTag formats the trace ID in meta for log lines, or returns "" if there is none.
+func traceTag(meta map[string]interface{}) string {
+	if id, ok := meta[TraceMetaKey].(string); ok && id != "" {
+		return fmt.Sprintf(" [trace %s]", id)
+	}
+	return ""
+}
@@ -0,0 +1,239 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// WorkspaceEnvVar tells started servers where their profile's scratch directory is.
+const WorkspaceEnvVar = "SCOOTER_WORKSPACE"
+
+// Workspace is a profile's scratch directory for tool downloads and temp files.
+type Workspace struct {
+	Dir        string
+	QuotaBytes int64         // 0 means unlimited
+	MaxAge     time.Duration // Files untouched for longer are pruned; 0 keeps them
+}
+
+// WorkspaceFile is a file in a workspace, with its path relative to the workspace.
+type WorkspaceFile struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// Files returns every regular file in the workspace, oldest first, and their total size.
+func (w Workspace) Files() ([]WorkspaceFile, int64, error) {
+	var files []WorkspaceFile
+	var total int64
+	err := filepath.WalkDir(w.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // Removed while walking
+		}
+		rel, _ := filepath.Rel(w.Dir, path)
+		files = append(files, WorkspaceFile{Path: filepath.ToSlash(rel), Size: info.Size(), Modified: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].Modified.Before(files[j].Modified) })
+	return files, total, err
+}
+
+// Resolve returns the absolute path of a workspace-relative path, refusing
+// anything that would escape the workspace.
+func (w Workspace) Resolve(rel string) (string, error) {
+	if rel == "" || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("path must be relative to the workspace: %q", rel)
+	}
+	path := filepath.Join(w.Dir, filepath.FromSlash(rel))
+	if path == w.Dir || !strings.HasPrefix(path, w.Dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("path is outside the workspace: %q", rel)
+	}
+	return path, nil
+}
+
+// Prune removes files older than MaxAge, then the oldest remaining files until
+// the workspace fits its quota. It returns the removed paths.
+func (w Workspace) Prune(now time.Time) ([]string, error) {
+	files, total, err := w.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, f := range files {
+		expired := w.MaxAge > 0 && now.Sub(f.Modified) > w.MaxAge
+		overQuota := w.QuotaBytes > 0 && total > w.QuotaBytes
+		if !expired && !overQuota {
+			continue
+		}
+		if err := os.Remove(filepath.Join(w.Dir, filepath.FromSlash(f.Path))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, err
+		}
+		total -= f.Size
+		removed = append(removed, f.Path)
+	}
+	removeEmptyDirs(w.Dir)
+	return removed, nil
+}
+
+// Clear removes everything in the workspace but keeps the directory itself.
+func (w Workspace) Clear() error {
+	entries, err := os.ReadDir(w.Dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(w.Dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeEmptyDirs deletes empty subdirectories of root, deepest first.
+func removeEmptyDirs(root string) {
+	var dirs []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != root {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i]) // Fails harmlessly on non-empty directories
+	}
+}
+
+// SetWorkspaceDir sets the profile's scratch directory. Servers started
+// afterwards get it in SCOOTER_WORKSPACE; an empty dir disables workspaces.
+func (e *DiscoveryEngine) SetWorkspaceDir(dir string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.workspaceDir = dir
+}
+
+// Workspace returns the profile's workspace with the current quota settings.
+func (e *DiscoveryEngine) Workspace() (Workspace, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.workspaceUnlocked()
+}
+
+func (e *DiscoveryEngine) workspaceUnlocked() (Workspace, bool) {
+	if e.workspaceDir == "" {
+		return Workspace{}, false
+	}
+	return Workspace{
+		Dir:        e.workspaceDir,
+		QuotaBytes: int64(e.settings.WorkspaceQuotaMB) << 20,
+		MaxAge:     time.Duration(e.settings.WorkspaceRetentionHours) * time.Hour,
+	}, true
+}
+
+// pruneWorkspace enforces the workspace's retention and quota. Called from the
+// monitor loop without e.mu held.
+func (e *DiscoveryEngine) pruneWorkspace() {
+	ws, ok := e.Workspace()
+	if !ok {
+		return
+	}
+	removed, err := ws.Prune(time.Now())
+	if err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("[Discovery] Failed to prune workspace %s: %v", ws.Dir, err))
+	}
+	if len(removed) > 0 {
+		logger.AddLog("INFO", fmt.Sprintf("[Discovery] Pruned %d file(s) from workspace %s", len(removed), ws.Dir))
+	}
+}
+
+// handleWorkspaceTool implements the scooter_workspace builtin.
+func (e *DiscoveryEngine) handleWorkspaceTool(params map[string]interface{}) (interface{}, error) {
+	ws, ok := e.Workspace()
+	if !ok {
+		return nil, errors.New(e.msg("builtin.workspace.disabled"))
+	}
+	if err := os.MkdirAll(ws.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	action, _ := params["action"].(string)
+	switch action {
+	case "", "info", "list":
+		files, total, err := ws.Files()
+		if err != nil {
+			return nil, err
+		}
+		result := map[string]interface{}{
+			"path":        ws.Dir,
+			"env_var":     WorkspaceEnvVar,
+			"used_bytes":  total,
+			"quota_bytes": ws.QuotaBytes,
+			"file_count":  len(files),
+		}
+		if ws.MaxAge > 0 {
+			result["retention_hours"] = int(ws.MaxAge / time.Hour)
+		}
+		if action == "list" {
+			if files == nil {
+				files = []WorkspaceFile{}
+			}
+			result["files"] = files
+		}
+		return result, nil
+
+	case "delete":
+		rel, _ := params["path"].(string)
+		if rel == "" {
+			return nil, errors.New(e.msg("builtin.workspace.path_required"))
+		}
+		path, err := ws.Resolve(rel)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"deleted": rel}, nil
+
+	case "clear":
+		if err := ws.Clear(); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"cleared": true}, nil
+
+	case "prune":
+		removed, err := ws.Prune(time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if removed == nil {
+			removed = []string{}
+		}
+		return map[string]interface{}{"removed": removed}, nil
+
+	default:
+		return nil, errors.New(e.msg("builtin.workspace.unknown_action", action))
+	}
+}
@@ -2,59 +2,33 @@ package integration
 
 import (
 	"encoding/json"
-	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 )
 
 // ClaudeIntegration handles configuring Claude Desktop to use MCP Scooter.
-type ClaudeIntegration struct{}
+type ClaudeIntegration struct {
+	// GOOS selects the platform whose config location is used; empty means
+	// the running OS. Tests set it to exercise every platform.
+	GOOS string
+	// Transport is used for both Claude Desktop and Claude Code.
+	Transport Transport
+}
 
-// Configure adds the MCP Scooter server to Claude Desktop's config file.
+// Configure adds the MCP Scooter server to Claude Desktop's config file,
+// keeping the rest of the file intact.
 func (c *ClaudeIntegration) Configure(port int, profileID string, apiKey string) error {
 	path, err := c.findConfig()
 	if err != nil {
 		return err
 	}
 
-	var config struct {
-		McpServers map[string]interface{} `json:"mcpServers"`
-	}
-
-	data, err := os.ReadFile(path)
-	if err == nil {
-		json.Unmarshal(data, &config)
-	}
-
-	if config.McpServers == nil {
-		config.McpServers = make(map[string]interface{})
-	}
-
-	// Add or update MCP Scooter entry for Claude
-	url := fmt.Sprintf("http://127.0.0.1:%d/profiles/%s/sse", port, profileID)
-	if profileID == "work" {
-		url = fmt.Sprintf("http://127.0.0.1:%d/sse", port)
-	}
-
-	serverConfig := map[string]interface{}{
-		"type": "sse",
-		"url":  url,
-	}
-
-	if apiKey != "" {
-		serverConfig["headers"] = map[string]string{
-			"Authorization": "Bearer " + apiKey,
-		}
-	}
-
-	config.McpServers["mcp-scooter"] = serverConfig
-
-	newData, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return err
-	}
+	serverConfig := serverEntry(c.Transport, port, profileID, apiKey, "url", true)
 
-	return os.WriteFile(path, newData, 0644)
+	return updateServers(path, KeyMcpServers, func(servers map[string]interface{}) {
+		servers["mcp-scooter"] = serverConfig
+	})
 }
 
 // ConfigureCode adds the MCP Scooter server to Claude Code's settings file.
@@ -77,21 +51,7 @@ func (c *ClaudeIntegration) ConfigureCode(port int, profileID string, apiKey str
 		config.McpServers = make(map[string]interface{})
 	}
 
-	url := fmt.Sprintf("http://127.0.0.1:%d/profiles/%s/sse", port, profileID)
-	if profileID == "work" {
-		url = fmt.Sprintf("http://127.0.0.1:%d/sse", port)
-	}
-
-	serverConfig := map[string]interface{}{
-		"type": "sse",
-		"url":  url,
-	}
-
-	if apiKey != "" {
-		serverConfig["headers"] = map[string]string{
-			"Authorization": "Bearer " + apiKey,
-		}
-	}
+	serverConfig := serverEntry(c.Transport, port, profileID, apiKey, "url", true)
 
 	config.McpServers["mcp-scooter"] = serverConfig
 
@@ -104,18 +64,51 @@ func (c *ClaudeIntegration) ConfigureCode(port int, profileID string, apiKey str
 }
 
 func (c *ClaudeIntegration) findConfig() (string, error) {
-	appData := os.Getenv("APPDATA")
-	if appData == "" {
-		home, _ := os.UserHomeDir()
-		appData = filepath.Join(home, "AppData", "Roaming")
+	dir, err := c.configDir()
+	if err != nil {
+		return "", err
 	}
 
-	path := filepath.Join(appData, "Claude", "claude_desktop_config.json")
-
 	// Create directory if it doesn't exist
-	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
 
-	return path, nil
+	return filepath.Join(dir, "claude_desktop_config.json"), nil
+}
+
+// configDir returns Claude Desktop's settings directory:
+//
+//	Windows: %APPDATA%\Claude
+//	macOS:   ~/Library/Application Support/Claude
+//	Linux:   $XDG_CONFIG_HOME/Claude, defaulting to ~/.config/Claude
+func (c *ClaudeIntegration) configDir() (string, error) {
+	goos := c.GOOS
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	switch goos {
+	case "windows":
+		appData := os.Getenv("APPDATA")
+		if appData == "" {
+			appData = filepath.Join(home, "AppData", "Roaming")
+		}
+		return filepath.Join(appData, "Claude"), nil
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "Claude"), nil
+	default:
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			configHome = filepath.Join(home, ".config")
+		}
+		return filepath.Join(configHome, "Claude"), nil
+	}
 }
 
 func (c *ClaudeIntegration) findCodeConfig() (string, error) {
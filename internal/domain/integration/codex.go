@@ -1,15 +1,30 @@
 package integration
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/pelletier/go-toml/v2"
 )
 
 // CodexIntegration handles configuring Codex to use MCP Scooter.
-type CodexIntegration struct{}
+//
+// config.toml is edited in place: only the [mcp_servers.mcp-scooter] table is
+// rewritten, so the user's other settings and comments are kept.
+type CodexIntegration struct {
+	// Transport goes into the [mcp_servers.mcp-scooter] table.
+	Transport Transport
+}
+
+// codexTable is the TOML table holding the MCP Scooter entry.
+const codexTable = "mcp_servers.mcp-scooter"
+
+// legacyCodexTable is where older versions of Scooter wrote the entry.
+const legacyCodexTable = "mcpServers.mcp-scooter"
 
 // Configure adds the MCP Scooter server to Codex's config.toml.
 func (c *CodexIntegration) Configure(port int, profileID string, apiKey string) error {
@@ -18,48 +33,37 @@ func (c *CodexIntegration) Configure(port int, profileID string, apiKey string)
 		return err
 	}
 
-	var config map[string]interface{}
-
 	data, err := os.ReadFile(path)
-	if err == nil {
-		toml.Unmarshal(data, &config)
-	}
-
-	if config == nil {
-		config = make(map[string]interface{})
+	if err != nil && !os.IsNotExist(err) {
+		return err
 	}
 
-	mcpServers, ok := config["mcpServers"].(map[string]interface{})
-	if !ok {
-		mcpServers = make(map[string]interface{})
-		config["mcpServers"] = mcpServers
+	// Refuse to edit a file we can't parse rather than risk mangling it
+	var existing map[string]interface{}
+	if err := toml.Unmarshal(data, &existing); err != nil {
+		return fmt.Errorf("%s is not valid TOML: %w", path, err)
 	}
 
-	// Add or update MCP Scooter entry
-	url := fmt.Sprintf("http://127.0.0.1:%d/profiles/%s/sse", port, profileID)
-	if profileID == "work" {
-		url = fmt.Sprintf("http://127.0.0.1:%d/sse", port)
+	block := []string{"[" + codexTable + "]"}
+	entry := serverEntry(c.Transport, port, profileID, apiKey, "url", true)
+	for _, key := range sortedKeys(entry) {
+		block = append(block, key+" = "+tomlValue(entry[key]))
 	}
 
-	serverConfig := map[string]interface{}{
-		"type": "sse",
-		"url":  url,
-	}
+	lines := splitLines(string(data))
+	lines = removeTOMLTable(lines, legacyCodexTable)
+	lines = replaceTOMLTable(lines, codexTable, block)
+	out := strings.Join(lines, "\n") + "\n"
 
-	if apiKey != "" {
-		serverConfig["headers"] = map[string]string{
-			"Authorization": "Bearer " + apiKey,
-		}
+	// Make sure the edit produced what we meant to write
+	var check struct {
+		McpServers map[string]map[string]interface{} `toml:"mcp_servers"`
 	}
-
-	mcpServers["mcp-scooter"] = serverConfig
-
-	newData, err := toml.Marshal(config)
-	if err != nil {
-		return err
+	if err := toml.Unmarshal([]byte(out), &check); err != nil || check.McpServers["mcp-scooter"] == nil {
+		return fmt.Errorf("could not update %s safely; add the [%s] table by hand", path, codexTable)
 	}
 
-	return os.WriteFile(path, newData, 0644)
+	return os.WriteFile(path, []byte(out), 0644)
 }
 
 func (c *CodexIntegration) findConfig() (string, error) {
@@ -73,3 +77,132 @@ func (c *CodexIntegration) findConfig() (string, error) {
 
 	return filepath.Join(codexDir, "config.toml"), nil
 }
+
+// tomlString quotes s as a TOML basic string. JSON string escapes are a
+// subset of TOML's.
+func tomlString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+// tomlValue renders the value types used in server entries.
+func tomlValue(v interface{}) string {
+	switch v := v.(type) {
+	case []string:
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = tomlString(s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	case map[string]string:
+		pairs := make([]string, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			pairs = append(pairs, tomlString(key)+" = "+tomlString(v[key]))
+		}
+		return "{ " + strings.Join(pairs, ", ") + " }"
+	default:
+		return tomlString(fmt.Sprint(v))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimRight(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// tomlHeader returns the table name of a [table] or [[array]] header line,
+// with whitespace and quotes around the key parts removed.
+func tomlHeader(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if i := strings.Index(line, "#"); i >= 0 && !strings.Contains(line[:i], `"`) {
+		line = strings.TrimSpace(line[:i])
+	}
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return "", false
+	}
+	name := strings.Trim(line, "[]")
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, "."), true
+}
+
+// tomlTableSpans returns the [start, end) line ranges of table and its
+// subtables. Comments directly above the next header belong to that header.
+func tomlTableSpans(lines []string, table string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, line := range lines {
+		name, ok := tomlHeader(line)
+		if !ok {
+			continue
+		}
+		inTable := name == table || strings.HasPrefix(name, table+".")
+		switch {
+		case inTable && start < 0:
+			start = i
+		case !inTable && start >= 0:
+			end := i
+			for end > start+1 && isTOMLTrivia(lines[end-1]) {
+				end--
+			}
+			spans = append(spans, [2]int{start, end})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		end := len(lines)
+		for end > start+1 && strings.TrimSpace(lines[end-1]) == "" {
+			end--
+		}
+		spans = append(spans, [2]int{start, end})
+	}
+	return spans
+}
+
+func isTOMLTrivia(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == "" || strings.HasPrefix(line, "#")
+}
+
+// removeTOMLTable drops table and its subtables from lines.
+func removeTOMLTable(lines []string, table string) []string {
+	spans := tomlTableSpans(lines, table)
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] > spans[j][0] })
+	for _, span := range spans {
+		lines = append(lines[:span[0]:span[0]], lines[span[1]:]...)
+	}
+	return lines
+}
+
+// replaceTOMLTable swaps table (and its subtables) for block, or appends
+// block at the end of the file if the table isn't there yet.
+func replaceTOMLTable(lines []string, table string, block []string) []string {
+	spans := tomlTableSpans(lines, table)
+	if len(spans) == 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		return append(lines, block...)
+	}
+
+	at := spans[0][0]
+	lines = removeTOMLTable(lines, table)
+	out := make([]string, 0, len(lines)+len(block))
+	out = append(out, lines[:at]...)
+	out = append(out, block...)
+	return append(out, lines[at:]...)
+}
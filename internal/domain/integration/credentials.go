@@ -2,6 +2,8 @@ package integration
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/mcp-scooter/scooter/internal/domain/registry"
 )
@@ -9,6 +11,16 @@ import (
 // CredentialManager handles secure credential storage and retrieval for MCP tools.
 type CredentialManager struct {
 	keychain *Keychain
+
+	// instance, user and profile namespace the keychain keys; all empty is
+	// the shared namespace every earlier version used.
+	instance string
+	user     string
+	profile  string
+
+	// identities selects a named identity per tool name, e.g. github: work.
+	// Tools without one use their default credentials.
+	identities map[string]string
 }
 
 // NewCredentialManager creates a new credential manager.
@@ -18,8 +30,151 @@ func NewCredentialManager() *CredentialManager {
 	}
 }
 
+// NewMemoryCredentialManager creates a credential manager that never reads or
+// writes the OS keychain.
+func NewMemoryCredentialManager() *CredentialManager {
+	return &CredentialManager{
+		keychain: NewMemoryKeychain("mcp-scooter"),
+	}
+}
+
+// ForInstance returns a manager over the same keychain whose keys are
+// namespaced by instanceID, so several Scooter installs sharing one OS
+// keychain don't overwrite each other's credentials. An empty ID is the
+// shared namespace.
+func (c *CredentialManager) ForInstance(instanceID string) *CredentialManager {
+	return &CredentialManager{keychain: c.keychain, instance: instanceID}
+}
+
+// ForUser returns a manager whose keys are namespaced by user within c's
+// instance namespace, so the users of a shared daemon never see each other's
+// credentials. Unlike an instance namespace, a user's starts out empty.
+func (c *CredentialManager) ForUser(user string) *CredentialManager {
+	return &CredentialManager{keychain: c.keychain, instance: c.instance, user: user}
+}
+
+// ForProfile returns a manager whose keys are namespaced by profileID within
+// c's instance (and user) namespace, for profiles that use other accounts
+// than the rest.
+func (c *CredentialManager) ForProfile(profileID string) *CredentialManager {
+	return &CredentialManager{keychain: c.keychain, instance: c.instance, user: c.user, profile: profileID}
+}
+
+// Instance returns the manager of c's instance (and user) namespace, without
+// the profile namespace. Credentials that belong to Scooter itself, like the
+// AI routing keys, live there.
+func (c *CredentialManager) Instance() *CredentialManager {
+	if c.profile == "" && len(c.identities) == 0 {
+		return c
+	}
+	return &CredentialManager{keychain: c.keychain, instance: c.instance, user: c.user}
+}
+
+// WithIdentities returns a manager that reads and writes the credentials of
+// the named identity selected for each tool in identities (tool name to
+// identity name), so one tool can have e.g. "work" and "personal" accounts.
+func (c *CredentialManager) WithIdentities(identities map[string]string) *CredentialManager {
+	return &CredentialManager{keychain: c.keychain, instance: c.instance, user: c.user, profile: c.profile, identities: identities}
+}
+
+// Identity returns the identity selected for toolName, or "" for its
+// default credentials.
+func (c *CredentialManager) Identity(toolName string) string {
+	return c.identities[toolName]
+}
+
+// Namespace returns the keychain namespace, e.g.
+// "instance=laptop/user=alice/profile=work", or "" for the shared one.
+func (c *CredentialManager) Namespace() string {
+	var parts []string
+	if c.instance != "" {
+		parts = append(parts, "instance="+c.instance)
+	}
+	if c.user != "" {
+		parts = append(parts, "user="+c.user)
+	}
+	if c.profile != "" {
+		parts = append(parts, "profile="+c.profile)
+	}
+	return strings.Join(parts, "/")
+}
+
+// key returns the keychain ID of a tool's credential in c's namespace. A
+// selected identity is stored as "<tool>/<identity>".
+func (c *CredentialManager) key(toolName, envVar string) string {
+	if identity := c.identities[toolName]; identity != "" {
+		toolName += "/" + identity
+	}
+	if ns := c.Namespace(); ns != "" {
+		return fmt.Sprintf("%s:%s:%s", ns, toolName, envVar)
+	}
+	return fmt.Sprintf("%s:%s", toolName, envVar)
+}
+
+// migratedMarker is the tool name of the entry recording that a namespace
+// was already migrated.
+const migratedMarker = ".migrated"
+
+// Migrate copies the credentials listed in creds (tool name to env var
+// names) from the enclosing namespace into c's: a profile's from its
+// instance, an instance's from the shared namespace. Credentials already set
+// in c's namespace are kept. It runs once per namespace, so a credential
+// deleted afterwards isn't copied back. It returns how many were copied.
+// Nothing is copied into a user's namespace from outside it.
+func (c *CredentialManager) Migrate(creds map[string][]string) (int, error) {
+	if c.Namespace() == "" || (c.user != "" && c.profile == "") {
+		return 0, nil
+	}
+	marker := c.key(migratedMarker, "at")
+	if done, _ := c.keychain.GetSecret(marker); done != "" {
+		return 0, nil
+	}
+
+	parent := c.ForInstance("")
+	if c.profile != "" {
+		parent = c.Instance()
+	}
+	copied := 0
+	for toolName, envVars := range creds {
+		for _, envVar := range envVars {
+			if existing, _ := c.keychain.GetSecret(c.key(toolName, envVar)); existing != "" {
+				continue
+			}
+			secret, _ := c.keychain.GetSecret(parent.key(toolName, envVar))
+			if secret == "" {
+				continue
+			}
+			if err := c.keychain.SetSecret(c.key(toolName, envVar), secret); err != nil {
+				return copied, fmt.Errorf("failed to migrate %s for %s: %w", envVar, toolName, err)
+			}
+			copied++
+		}
+	}
+	return copied, c.keychain.SetSecret(marker, time.Now().UTC().Format(time.RFC3339))
+}
+
+// EnvVarNames lists the environment variables an authorization config reads
+// from the keychain.
+func EnvVarNames(auth *registry.Authorization) []string {
+	if auth == nil {
+		return nil
+	}
+	var names []string
+	if auth.EnvVar != "" {
+		names = append(names, auth.EnvVar)
+	}
+	for _, envDef := range auth.EnvVars {
+		names = append(names, envDef.Name)
+	}
+	if auth.OAuth != nil && auth.OAuth.TokenEnv != "" {
+		names = append(names, auth.OAuth.TokenEnv)
+	}
+	return names
+}
+
 // GetCredentialsForTool retrieves credentials for a tool based on its authorization config.
-// Returns a map of environment variable names to values.
+// Returns a map of environment variable names to values. A failed OAuth token
+// refresh is returned as the error, along with the credentials found.
 func (c *CredentialManager) GetCredentialsForTool(toolName string, auth *registry.Authorization) (map[string]string, error) {
 	creds := make(map[string]string)
 
@@ -29,7 +184,7 @@ func (c *CredentialManager) GetCredentialsForTool(toolName string, auth *registr
 
 	// Handle single env_var (most common case)
 	if auth.EnvVar != "" {
-		secret, err := c.keychain.GetSecret(fmt.Sprintf("%s:%s", toolName, auth.EnvVar))
+		secret, err := c.keychain.GetSecret(c.key(toolName, auth.EnvVar))
 		if err == nil && secret != "" {
 			creds[auth.EnvVar] = secret
 		}
@@ -37,36 +192,43 @@ func (c *CredentialManager) GetCredentialsForTool(toolName string, auth *registr
 
 	// Handle multiple env_vars (for tools with complex auth)
 	for _, envDef := range auth.EnvVars {
-		secret, err := c.keychain.GetSecret(fmt.Sprintf("%s:%s", toolName, envDef.Name))
+		secret, err := c.keychain.GetSecret(c.key(toolName, envDef.Name))
 		if err == nil && secret != "" {
 			creds[envDef.Name] = secret
 		}
 	}
 
-	// Handle OAuth tokens
+	// Handle OAuth tokens, refreshing them first if they are about to expire
+	var refreshErr error
 	if auth.OAuth != nil && auth.OAuth.TokenEnv != "" {
-		token, err := c.keychain.GetSecret(fmt.Sprintf("%s:%s", toolName, auth.OAuth.TokenEnv))
+		refreshErr = c.refreshOAuthToken(toolName, auth.OAuth)
+		token, err := c.keychain.GetSecret(c.key(toolName, auth.OAuth.TokenEnv))
 		if err == nil && token != "" {
 			creds[auth.OAuth.TokenEnv] = token
 		}
+		if auth.OAuth.RefreshTokenEnv != "" {
+			if refresh, _ := c.keychain.GetSecret(c.key(toolName, auth.OAuth.RefreshTokenEnv)); refresh != "" {
+				creds[auth.OAuth.RefreshTokenEnv] = refresh
+			}
+		}
 	}
 
-	return creds, nil
+	return creds, refreshErr
 }
 
 // SetCredential stores a credential securely in the keychain.
 func (c *CredentialManager) SetCredential(toolName, envVar, value string) error {
-	return c.keychain.SetSecret(fmt.Sprintf("%s:%s", toolName, envVar), value)
+	return c.keychain.SetSecret(c.key(toolName, envVar), value)
 }
 
 // GetCredential retrieves a single credential from the keychain.
 func (c *CredentialManager) GetCredential(toolName, envVar string) (string, error) {
-	return c.keychain.GetSecret(fmt.Sprintf("%s:%s", toolName, envVar))
+	return c.keychain.GetSecret(c.key(toolName, envVar))
 }
 
 // DeleteCredential removes a credential from the keychain.
 func (c *CredentialManager) DeleteCredential(toolName, envVar string) error {
-	return c.keychain.RemoveSecret(fmt.Sprintf("%s:%s", toolName, envVar))
+	return c.keychain.RemoveSecret(c.key(toolName, envVar))
 }
 
 // HasRequiredCredentials checks if all required credentials are present.
@@ -79,7 +241,7 @@ func (c *CredentialManager) HasRequiredCredentials(toolName string, auth *regist
 
 	// Check single env_var
 	if auth.EnvVar != "" {
-		secret, _ := c.keychain.GetSecret(fmt.Sprintf("%s:%s", toolName, auth.EnvVar))
+		secret, _ := c.keychain.GetSecret(c.key(toolName, auth.EnvVar))
 		if secret == "" {
 			missing = append(missing, auth.EnvVar)
 		}
@@ -88,7 +250,7 @@ func (c *CredentialManager) HasRequiredCredentials(toolName string, auth *regist
 	// Check multiple env_vars
 	for _, envDef := range auth.EnvVars {
 		if envDef.Required {
-			secret, _ := c.keychain.GetSecret(fmt.Sprintf("%s:%s", toolName, envDef.Name))
+			secret, _ := c.keychain.GetSecret(c.key(toolName, envDef.Name))
 			if secret == "" {
 				missing = append(missing, envDef.Name)
 			}
@@ -97,3 +259,111 @@ func (c *CredentialManager) HasRequiredCredentials(toolName string, auth *regist
 
 	return len(missing) == 0, missing
 }
+
+// CredentialSource says where the value for a credential comes from when the
+// tool is activated.
+type CredentialSource string
+
+const (
+	SourceKeychain   CredentialSource = "keychain"
+	SourceProfileEnv CredentialSource = "profile_env"
+	SourceDefault    CredentialSource = "default"
+	SourceMissing    CredentialSource = "missing"
+)
+
+// CredentialStatus describes one credential a tool accepts. It never carries
+// the secret value itself.
+type CredentialStatus struct {
+	Name        string           `json:"name"`
+	DisplayName string           `json:"display_name,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Secret      bool             `json:"secret"`
+	Required    bool             `json:"required"`
+	Options     []string         `json:"options,omitempty"`
+	Source      CredentialSource `json:"source"`
+}
+
+// AuthStatus is the resolved authorization picture for a tool.
+type AuthStatus struct {
+	Type        registry.AuthType  `json:"type"`
+	Required    bool               `json:"required"`
+	Recommended bool               `json:"recommended,omitempty"`
+	DisplayName string             `json:"display_name,omitempty"`
+	Description string             `json:"description,omitempty"`
+	HelpURL     string             `json:"help_url,omitempty"`
+	Provider    string             `json:"provider,omitempty"`
+	Scopes      []string           `json:"scopes,omitempty"`
+	Credentials []CredentialStatus `json:"credentials"`
+	HasRequired bool               `json:"has_required"`
+	Missing     []string           `json:"missing"`
+}
+
+// ResolveAuth reports, for every credential a tool's authorization config
+// declares, whether a value is available and where it comes from. Keychain
+// values take precedence over profileEnv, matching activation.
+func (c *CredentialManager) ResolveAuth(toolName string, auth *registry.Authorization, profileEnv map[string]string) AuthStatus {
+	status := AuthStatus{
+		Type:        registry.AuthNone,
+		Credentials: []CredentialStatus{},
+		HasRequired: true,
+		Missing:     []string{},
+	}
+	if auth == nil {
+		return status
+	}
+
+	status.Type = auth.Type
+	status.Required = auth.Required
+	status.Recommended = auth.Recommended
+	status.DisplayName = auth.DisplayName
+	status.Description = auth.Description
+	status.HelpURL = auth.HelpURL
+	status.Provider = auth.Provider
+	status.Scopes = auth.Scopes
+
+	resolve := func(cs CredentialStatus, def string) {
+		if secret, _ := c.keychain.GetSecret(c.key(toolName, cs.Name)); secret != "" {
+			cs.Source = SourceKeychain
+		} else if profileEnv[cs.Name] != "" {
+			cs.Source = SourceProfileEnv
+		} else if def != "" {
+			cs.Source = SourceDefault
+		} else {
+			cs.Source = SourceMissing
+			if auth.Required && cs.Required {
+				status.Missing = append(status.Missing, cs.Name)
+			}
+		}
+		status.Credentials = append(status.Credentials, cs)
+	}
+
+	if auth.EnvVar != "" {
+		resolve(CredentialStatus{
+			Name:        auth.EnvVar,
+			DisplayName: auth.DisplayName,
+			Description: auth.Description,
+			Secret:      true,
+			Required:    auth.Required,
+		}, "")
+	}
+	for _, envDef := range auth.EnvVars {
+		resolve(CredentialStatus{
+			Name:        envDef.Name,
+			DisplayName: envDef.DisplayName,
+			Description: envDef.Description,
+			Secret:      envDef.Secret,
+			Required:    envDef.Required,
+			Options:     envDef.Options,
+		}, envDef.Default)
+	}
+	if auth.OAuth != nil && auth.OAuth.TokenEnv != "" {
+		resolve(CredentialStatus{
+			Name:     auth.OAuth.TokenEnv,
+			Secret:   true,
+			Required: auth.Required,
+		}, "")
+	}
+
+	status.HasRequired = len(status.Missing) == 0
+	return status
+}
@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DetectionRules describe how to find a client on this machine. They live in
+// the client definition files so new clients don't need code changes.
+//
+// Path entries may start with ~ and reference environment variables as
+// ${VAR} or %VAR%; they may also be glob patterns. Map keys are GOOS values
+// ("windows", "darwin", "linux") or "all".
+type DetectionRules struct {
+	// Paths whose existence means the client is installed.
+	Paths map[string][]string `json:"paths,omitempty"`
+	// ConfigPaths are candidate MCP config files; the first existing one is reported.
+	ConfigPaths map[string][]string `json:"config_paths,omitempty"`
+	// Command is run to detect CLI clients and read their version, e.g. ["claude", "--version"].
+	Command []string `json:"command,omitempty"`
+}
+
+// Detection is what a Detector found out about a client.
+type Detection struct {
+	Installed  bool   `json:"installed"`
+	Version    string `json:"version,omitempty"`
+	ConfigPath string `json:"config_path,omitempty"`
+	// Method says how the client was found: "command" or "path".
+	Method string `json:"method,omitempty"`
+}
+
+// Detector evaluates DetectionRules for one operating system.
+type Detector struct {
+	GOOS           string
+	CommandTimeout time.Duration
+}
+
+// NewDetector returns a Detector for the running OS.
+func NewDetector() *Detector {
+	return &Detector{GOOS: runtime.GOOS, CommandTimeout: 3 * time.Second}
+}
+
+var (
+	versionPattern = regexp.MustCompile(`\d+\.\d+(?:\.\d+)?(?:[-+][0-9A-Za-z.-]+)?`)
+	percentVar     = regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_]*)%`)
+)
+
+// Detect applies rules and reports whether the client is installed, its
+// version when a command probe succeeds, and its MCP config path.
+func (d *Detector) Detect(rules DetectionRules) Detection {
+	var det Detection
+
+	if len(rules.Command) > 0 {
+		if version, ok := d.probe(rules.Command); ok {
+			det.Installed = true
+			det.Version = version
+			det.Method = "command"
+		}
+	}
+
+	if !det.Installed {
+		if paths := d.existing(rules.Paths); len(paths) > 0 {
+			det.Installed = true
+			det.Method = "path"
+		}
+	}
+
+	if configs := d.existing(rules.ConfigPaths); len(configs) > 0 {
+		det.ConfigPath = configs[0]
+	}
+	return det
+}
+
+// probe runs a version command and extracts the first version-looking string.
+func (d *Detector) probe(command []string) (string, bool) {
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return "", false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d.CommandTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, command[0], command[1:]...).Output()
+	if err != nil {
+		return "", false
+	}
+	return versionPattern.FindString(string(out)), true
+}
+
+// existing returns the paths from the rules for this OS that exist.
+func (d *Detector) existing(byOS map[string][]string) []string {
+	var found []string
+	for _, pattern := range append(byOS["all"], byOS[d.GOOS]...) {
+		path, ok := ExpandPath(pattern)
+		if !ok {
+			continue
+		}
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			continue
+		}
+		found = append(found, matches...)
+	}
+	return found
+}
+
+// ExpandPath resolves ~ and environment variables in a detection path. It
+// returns false if the path references an unset variable, which would
+// otherwise turn into a bogus relative or root path.
+func ExpandPath(path string) (string, bool) {
+	ok := true
+	lookup := func(name string) string {
+		v := os.Getenv(name)
+		if v == "" {
+			ok = false
+		}
+		return v
+	}
+
+	path = percentVar.ReplaceAllStringFunc(path, func(m string) string {
+		return lookup(strings.Trim(m, "%"))
+	})
+	path = os.Expand(path, lookup)
+
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return filepath.Clean(filepath.FromSlash(path)), ok
+}
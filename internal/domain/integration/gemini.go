@@ -2,13 +2,14 @@ package integration
 
 import (
 	"encoding/json"
-	"fmt"
 	"os"
 	"path/filepath"
 )
 
 // GeminiIntegration handles configuring Google Antigravity and Gemini CLI.
-type GeminiIntegration struct{}
+type GeminiIntegration struct {
+	Transport Transport
+}
 
 // Configure adds the MCP Scooter server to Gemini's settings.json.
 func (g *GeminiIntegration) Configure(port int, profileID string, apiKey string) error {
@@ -31,21 +32,7 @@ func (g *GeminiIntegration) Configure(port int, profileID string, apiKey string)
 	}
 
 	// Add or update MCP Scooter entry
-	url := fmt.Sprintf("http://127.0.0.1:%d/profiles/%s/sse", port, profileID)
-	if profileID == "work" {
-		url = fmt.Sprintf("http://127.0.0.1:%d/sse", port)
-	}
-
-	serverConfig := map[string]interface{}{
-		"type": "sse",
-		"url":  url,
-	}
-
-	if apiKey != "" {
-		serverConfig["headers"] = map[string]string{
-			"Authorization": "Bearer " + apiKey,
-		}
-	}
+	serverConfig := serverEntry(g.Transport, port, profileID, apiKey, "url", true)
 
 	config.McpServers["mcp-scooter"] = serverConfig
 
@@ -0,0 +1,117 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Server map keys used by the JSON config layouts GenericJSONIntegration supports.
+const (
+	KeyMcpServers     = "mcpServers"      // Cursor, Claude, Gemini, Windsurf and most others
+	KeyServers        = "servers"         // VS Code style
+	KeyContextServers = "context_servers" // Zed style
+)
+
+// GenericJSONIntegration configures any client that lists MCP servers in a
+// JSON file, for clients without a dedicated integration.
+type GenericJSONIntegration struct {
+	// Path is the absolute path of the client's JSON config file.
+	Path string
+	// Key is the top-level object holding the servers: mcpServers, servers or context_servers.
+	Key string
+	// URLField names the URL property of a server entry; defaults to "url".
+	URLField string
+	// Transport is spelled out in the entry unless the layout infers it.
+	Transport Transport
+}
+
+// Validate checks that the integration points at a usable JSON config.
+func (g *GenericJSONIntegration) Validate() error {
+	if g.Path == "" || !filepath.IsAbs(g.Path) {
+		return fmt.Errorf("config path must be absolute")
+	}
+	if !strings.EqualFold(filepath.Ext(g.Path), ".json") {
+		return fmt.Errorf("config path must be a .json file")
+	}
+	switch g.Key {
+	case KeyMcpServers, KeyServers, KeyContextServers:
+		return nil
+	default:
+		return fmt.Errorf("unknown server key %q (use %s, %s or %s)", g.Key, KeyMcpServers, KeyServers, KeyContextServers)
+	}
+}
+
+// Configure adds the MCP Scooter server to the config file.
+func (g *GenericJSONIntegration) Configure(port int, profileID string, apiKey string) error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+
+	urlField := g.URLField
+	if urlField == "" {
+		urlField = "url"
+	}
+	// Zed infers the transport; the other layouts expect it spelled out
+	typed := g.Key != KeyContextServers && urlField == "url"
+	serverConfig := serverEntry(g.Transport, port, profileID, apiKey, urlField, typed)
+
+	if err := os.MkdirAll(filepath.Dir(g.Path), 0755); err != nil {
+		return err
+	}
+	return updateServers(g.Path, g.Key, func(servers map[string]interface{}) {
+		servers["mcp-scooter"] = serverConfig
+	})
+}
+
+// Remove deletes the MCP Scooter server from the config file, if present.
+func (g *GenericJSONIntegration) Remove() error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(g.Path); os.IsNotExist(err) {
+		return nil
+	}
+	return updateServers(g.Path, g.Key, func(servers map[string]interface{}) {
+		delete(servers, "mcp-scooter")
+	})
+}
+
+// gatewayURL returns the SSE endpoint of a profile; "work" is also served at /sse.
+func gatewayURL(port int, profileID string) string {
+	if profileID == "work" {
+		return fmt.Sprintf("http://127.0.0.1:%d/sse", port)
+	}
+	return fmt.Sprintf("http://127.0.0.1:%d/profiles/%s/sse", port, profileID)
+}
+
+// updateServers applies fn to the servers object stored under key in a JSON
+// config file, keeping any other servers and keys.
+func updateServers(path, key string, fn func(servers map[string]interface{})) error {
+	var config map[string]interface{}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		json.Unmarshal(data, &config)
+	}
+
+	if config == nil {
+		config = make(map[string]interface{})
+	}
+
+	servers, ok := config[key].(map[string]interface{})
+	if !ok {
+		servers = make(map[string]interface{})
+		config[key] = servers
+	}
+	fn(servers)
+
+	newData, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, newData, 0644)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mcp-scooter/scooter/internal/domain/integration"
@@ -117,12 +118,63 @@ func TestCodexIntegration(t *testing.T) {
 	err = toml.Unmarshal(data, &config)
 	require.NoError(t, err)
 
-	mcpServers := config["mcpServers"].(map[string]interface{})
+	mcpServers := config["mcp_servers"].(map[string]interface{})
 	scooter := mcpServers["mcp-scooter"].(map[string]interface{})
 	assert.Equal(t, "sse", scooter["type"])
 	assert.Equal(t, "http://127.0.0.1:6277/sse", scooter["url"])
 }
 
+func TestCodexIntegrationPreservesConfig(t *testing.T) {
+	home, cleanup := setupTestHome(t)
+	defer cleanup()
+
+	path := filepath.Join(home, ".codex", "config.toml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	existing := `# My Codex settings
+model = "o3" # favourite
+
+[mcpServers.mcp-scooter]
+type = "sse"
+url = "http://127.0.0.1:1111/sse"
+
+[mcp_servers.mcp-scooter]
+url = "http://127.0.0.1:1111/sse"
+
+[mcp_servers.mcp-scooter.headers]
+Authorization = "Bearer stale"
+
+# Docs server
+[mcp_servers.docs]
+command = "docs-mcp"
+`
+	require.NoError(t, os.WriteFile(path, []byte(existing), 0644))
+
+	c := &integration.CodexIntegration{}
+	require.NoError(t, c.Configure(6277, "dev", "secret"))
+	require.NoError(t, c.Configure(6277, "dev", "secret"), "configuring twice is idempotent")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	text := string(data)
+	assert.Contains(t, text, "# My Codex settings")
+	assert.Contains(t, text, `model = "o3" # favourite`)
+	assert.Contains(t, text, "# Docs server\n[mcp_servers.docs]")
+	assert.NotContains(t, text, "mcpServers")
+	assert.NotContains(t, text, "stale")
+	assert.Equal(t, 1, strings.Count(text, "[mcp_servers.mcp-scooter]"))
+
+	var config map[string]interface{}
+	require.NoError(t, toml.Unmarshal(data, &config))
+	servers := config["mcp_servers"].(map[string]interface{})
+	assert.Equal(t, "docs-mcp", servers["docs"].(map[string]interface{})["command"])
+	scooter := servers["mcp-scooter"].(map[string]interface{})
+	assert.Equal(t, "http://127.0.0.1:6277/profiles/dev/sse", scooter["url"])
+	assert.Equal(t, "Bearer secret", scooter["headers"].(map[string]interface{})["Authorization"])
+
+	require.NoError(t, os.WriteFile(path, []byte("model = [unclosed"), 0644))
+	assert.Error(t, c.Configure(6277, "dev", ""), "invalid TOML is left alone")
+}
+
 func TestZedIntegration(t *testing.T) {
 	home, cleanup := setupTestHome(t)
 	defer cleanup()
@@ -145,6 +197,151 @@ func TestZedIntegration(t *testing.T) {
 	assert.Equal(t, "http://127.0.0.1:6277/sse", scooter["url"])
 }
 
+func TestZedIntegrationPreservesComments(t *testing.T) {
+	home, cleanup := setupTestHome(t)
+	defer cleanup()
+
+	path := filepath.Join(home, ".config", "zed", "settings.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+
+	cases := map[string]string{
+		"no servers": `// Zed settings
+{
+  "theme": "One Dark", // dark mode
+  /* fonts */
+  "buffer_font_size": 15,
+}
+`,
+		"other servers": `// Zed settings
+{
+  "theme": "One Dark", // dark mode
+  "context_servers": {
+    // keep me
+    "other": {"command": {"path": "other"}},
+  },
+}
+`,
+		"existing entry": `// Zed settings
+{
+  "theme": "One Dark", // dark mode
+  "context_servers": {
+    "mcp-scooter": {"url": "http://127.0.0.1:1111/sse"}
+  }
+}
+`,
+	}
+
+	z := &integration.ZedIntegration{}
+	for name, existing := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(path, []byte(existing), 0644))
+			require.NoError(t, z.Configure(6277, "work", ""))
+			require.NoError(t, z.Configure(6277, "work", ""))
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			text := string(data)
+			assert.Contains(t, text, "// Zed settings")
+			assert.Contains(t, text, `"theme": "One Dark", // dark mode`)
+			assert.Equal(t, 1, strings.Count(text, `"mcp-scooter"`))
+			assert.NotContains(t, text, "1111")
+			if strings.Contains(existing, "keep me") {
+				assert.Contains(t, text, "// keep me")
+				assert.Contains(t, text, `"other"`)
+			}
+			assert.Contains(t, text, `"url": "http://127.0.0.1:6277/sse"`)
+		})
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte(`["not", "an", "object"]`), 0644))
+	assert.Error(t, z.Configure(6277, "work", ""))
+}
+
+func TestJetBrainsIntegration(t *testing.T) {
+	home, cleanup := setupTestHome(t)
+	defer cleanup()
+
+	j := &integration.JetBrainsIntegration{}
+	assert.Error(t, j.Configure(6277, "work", ""), "no IDE installed")
+
+	root := filepath.Join(home, ".config", "JetBrains")
+	for _, dir := range []string{"GoLand2025.1", "PyCharm2024.3", "Toolbox"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+	existing := `{"mcpServers": {"other": {"command": "other-server"}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(root, "GoLand2025.1", "mcp.json"), []byte(existing), 0644))
+
+	require.NoError(t, j.Configure(6277, "dev", "secret"))
+
+	read := func(ide string) map[string]interface{} {
+		data, err := os.ReadFile(filepath.Join(root, ide, "mcp.json"))
+		require.NoError(t, err)
+		var config struct {
+			McpServers map[string]interface{} `json:"mcpServers"`
+		}
+		require.NoError(t, json.Unmarshal(data, &config))
+		return config.McpServers
+	}
+	for _, ide := range []string{"GoLand2025.1", "PyCharm2024.3"} {
+		scooter := read(ide)["mcp-scooter"].(map[string]interface{})
+		assert.Equal(t, "http://127.0.0.1:6277/profiles/dev/sse", scooter["url"])
+		assert.Equal(t, "Bearer secret", scooter["headers"].(map[string]interface{})["Authorization"])
+	}
+	assert.NoFileExists(t, filepath.Join(root, "Toolbox", "mcp.json"))
+
+	require.NoError(t, j.Remove())
+	servers := read("GoLand2025.1")
+	assert.NotContains(t, servers, "mcp-scooter")
+	assert.Contains(t, servers, "other")
+}
+
+func TestWindsurfIntegration(t *testing.T) {
+	home, cleanup := setupTestHome(t)
+	defer cleanup()
+
+	w := &integration.WindsurfIntegration{}
+	require.NoError(t, w.Configure(6277, "work", ""))
+
+	data, err := os.ReadFile(filepath.Join(home, ".codeium", "windsurf", "mcp_config.json"))
+	require.NoError(t, err)
+
+	var config struct {
+		McpServers map[string]map[string]interface{} `json:"mcpServers"`
+	}
+	require.NoError(t, json.Unmarshal(data, &config))
+	assert.Equal(t, "http://127.0.0.1:6277/sse", config.McpServers["mcp-scooter"]["serverUrl"])
+	assert.NotContains(t, config.McpServers["mcp-scooter"], "url")
+}
+
+func TestGenericJSONIntegration(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "client", "config.json")
+
+	for _, bad := range []*integration.GenericJSONIntegration{
+		{Path: "relative.json", Key: integration.KeyMcpServers},
+		{Path: filepath.Join(dir, "config.yaml"), Key: integration.KeyMcpServers},
+		{Path: path, Key: "tools"},
+	} {
+		assert.Error(t, bad.Configure(6277, "work", ""), bad.Path)
+	}
+
+	g := &integration.GenericJSONIntegration{Path: path, Key: integration.KeyServers}
+	require.NoError(t, g.Configure(6277, "dev", "secret"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var config map[string]map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &config))
+	scooter := config["servers"]["mcp-scooter"]
+	assert.Equal(t, "sse", scooter["type"])
+	assert.Equal(t, "http://127.0.0.1:6277/profiles/dev/sse", scooter["url"])
+
+	require.NoError(t, g.Remove())
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "mcp-scooter")
+}
+
 func TestProfileIntegration(t *testing.T) {
 	home, cleanup := setupTestHome(t)
 	defer cleanup()
@@ -169,3 +366,169 @@ func TestProfileIntegration(t *testing.T) {
 	headers := scooter["headers"].(map[string]interface{})
 	assert.Equal(t, "Bearer test-api-key", headers["Authorization"])
 }
+
+func TestDetector(t *testing.T) {
+	home, cleanup := setupTestHome(t)
+	defer cleanup()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".config", "Acme"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".config", "Acme", "mcp.json"), []byte("{}"), 0644))
+
+	rules := integration.DetectionRules{
+		Paths: map[string][]string{
+			"linux":   {"~/.config/Acme"},
+			"windows": {"%SCOOTER_UNSET_VAR%/Acme"},
+		},
+		ConfigPaths: map[string][]string{"all": {"~/.config/Acme/*.json"}},
+		Command:     []string{"scooter-no-such-client", "--version"},
+	}
+
+	det := (&integration.Detector{GOOS: "linux"}).Detect(rules)
+	assert.True(t, det.Installed)
+	assert.Equal(t, "path", det.Method)
+	assert.Equal(t, filepath.Join(home, ".config", "Acme", "mcp.json"), det.ConfigPath)
+
+	// An unset variable must not expand to a path that happens to exist
+	det = (&integration.Detector{GOOS: "windows"}).Detect(rules)
+	assert.False(t, det.Installed)
+	assert.Empty(t, det.Version)
+}
+
+func TestIntegrationTransports(t *testing.T) {
+	home, cleanup := setupTestHome(t)
+	defer cleanup()
+
+	read := func() map[string]interface{} {
+		data, err := os.ReadFile(filepath.Join(home, ".cursor", "mcp.json"))
+		require.NoError(t, err)
+		var config map[string]map[string]map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &config))
+		return config["mcpServers"]["mcp-scooter"]
+	}
+
+	c := &integration.CursorIntegration{Transport: integration.TransportHTTP}
+	require.NoError(t, c.Configure(6277, "dev", "secret"))
+	entry := read()
+	assert.Equal(t, "http", entry["type"])
+	assert.Equal(t, "http://127.0.0.1:6277/profiles/dev/sse", entry["url"])
+
+	c.Transport = integration.TransportStdio
+	require.NoError(t, c.Configure(6277, "dev", "secret"))
+	entry = read()
+	assert.Equal(t, "stdio", entry["type"])
+	assert.NotEmpty(t, entry["command"])
+	assert.Equal(t, []interface{}{"--bridge", "http://127.0.0.1:6277/profiles/dev/sse"}, entry["args"])
+	assert.Equal(t, "secret", entry["env"].(map[string]interface{})[integration.BridgeAPIKeyEnv])
+	assert.Nil(t, entry["url"])
+
+	codex := &integration.CodexIntegration{Transport: integration.TransportStdio}
+	require.NoError(t, codex.Configure(6277, "work", "secret"))
+	data, err := os.ReadFile(filepath.Join(home, ".codex", "config.toml"))
+	require.NoError(t, err)
+	var config map[string]map[string]map[string]interface{}
+	require.NoError(t, toml.Unmarshal(data, &config))
+	assert.Equal(t, []interface{}{"--bridge", "http://127.0.0.1:6277/sse"}, config["mcp_servers"]["mcp-scooter"]["args"])
+
+	for name, want := range map[string]integration.Transport{"": "", "SSE": "sse", "streamable-http": "http", "stdio": "stdio"} {
+		got, err := integration.ParseTransport(name)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err = integration.ParseTransport("websocket")
+	assert.Error(t, err)
+}
+
+func TestClaudeDesktopIntegration(t *testing.T) {
+	for goos, rel := range map[string]string{
+		"windows": filepath.Join("AppData", "Roaming", "Claude", "claude_desktop_config.json"),
+		"darwin":  filepath.Join("Library", "Application Support", "Claude", "claude_desktop_config.json"),
+		"linux":   filepath.Join(".config", "Claude", "claude_desktop_config.json"),
+	} {
+		t.Run(goos, func(t *testing.T) {
+			home, cleanup := setupTestHome(t)
+			defer cleanup()
+			t.Setenv("XDG_CONFIG_HOME", "")
+
+			path := filepath.Join(home, rel)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, os.WriteFile(path, []byte(`{"globalShortcut": "Ctrl+Space"}`), 0644))
+
+			c := &integration.ClaudeIntegration{GOOS: goos}
+			require.NoError(t, c.Configure(6277, "work", "key"))
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			var config struct {
+				GlobalShortcut string                            `json:"globalShortcut"`
+				McpServers     map[string]map[string]interface{} `json:"mcpServers"`
+			}
+			require.NoError(t, json.Unmarshal(data, &config))
+			assert.Equal(t, "http://127.0.0.1:6277/sse", config.McpServers["mcp-scooter"]["url"])
+			assert.Equal(t, "Ctrl+Space", config.GlobalShortcut, "other settings are kept")
+		})
+	}
+}
+
+func TestCredentialNamespaces(t *testing.T) {
+	shared := integration.NewMemoryCredentialManager()
+	require.NoError(t, shared.SetCredential("github", "GITHUB_TOKEN", "shared-token"))
+	require.NoError(t, shared.SetCredential("brave", "BRAVE_API_KEY", "brave-key"))
+
+	instance := shared.ForInstance("laptop")
+	assert.Equal(t, "instance=laptop", instance.Namespace())
+	_, err := instance.GetCredential("github", "GITHUB_TOKEN")
+	assert.Error(t, err, "namespaced keys don't see shared entries before migration")
+
+	creds := map[string][]string{"github": {"GITHUB_TOKEN"}, "brave": {"BRAVE_API_KEY"}}
+	copied, err := instance.Migrate(creds)
+	require.NoError(t, err)
+	assert.Equal(t, 2, copied)
+
+	work := instance.ForProfile("work")
+	assert.Equal(t, "instance=laptop/profile=work", work.Namespace())
+	require.NoError(t, work.SetCredential("github", "GITHUB_TOKEN", "work-token"))
+	copied, err = work.Migrate(creds)
+	require.NoError(t, err)
+	assert.Equal(t, 1, copied, "the profile's own token is kept")
+
+	token, _ := work.GetCredential("github", "GITHUB_TOKEN")
+	assert.Equal(t, "work-token", token)
+	token, _ = instance.GetCredential("github", "GITHUB_TOKEN")
+	assert.Equal(t, "shared-token", token)
+	assert.Same(t, instance.Instance(), instance)
+	assert.Equal(t, "instance=laptop", work.Instance().Namespace())
+
+	// Migration runs once, so deleted credentials stay deleted
+	require.NoError(t, work.DeleteCredential("brave", "BRAVE_API_KEY"))
+	copied, err = work.Migrate(creds)
+	require.NoError(t, err)
+	assert.Zero(t, copied)
+	_, err = work.GetCredential("brave", "BRAVE_API_KEY")
+	assert.Error(t, err)
+}
+
+func TestCredentialUserNamespaces(t *testing.T) {
+	instance := integration.NewMemoryCredentialManager().ForInstance("server")
+	require.NoError(t, instance.SetCredential("github", "GITHUB_TOKEN", "owner-token"))
+
+	alice := instance.ForUser("alice")
+	assert.Equal(t, "instance=server/user=alice", alice.Namespace())
+	copied, err := alice.Migrate(map[string][]string{"github": {"GITHUB_TOKEN"}})
+	require.NoError(t, err)
+	assert.Zero(t, copied, "users never inherit the owner's credentials")
+	_, err = alice.GetCredential("github", "GITHUB_TOKEN")
+	assert.Error(t, err)
+
+	require.NoError(t, alice.SetCredential("github", "GITHUB_TOKEN", "alice-token"))
+	work := alice.ForProfile("work")
+	assert.Equal(t, "instance=server/user=alice/profile=work", work.Namespace())
+	assert.Equal(t, alice.Namespace(), work.Instance().Namespace())
+	copied, err = work.Migrate(map[string][]string{"github": {"GITHUB_TOKEN"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, copied, "a user's profile copies from the user")
+	token, _ := work.GetCredential("github", "GITHUB_TOKEN")
+	assert.Equal(t, "alice-token", token)
+
+	_, err = instance.ForUser("bob").GetCredential("github", "GITHUB_TOKEN")
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// JetBrainsIntegration handles configuring JetBrains IDEs (AI Assistant) to use MCP Scooter.
+//
+// Every installed IDE keeps its settings in a versioned directory such as
+// IntelliJIdea2025.1 or PyCharm2024.3 under the JetBrains config root; AI
+// Assistant reads MCP servers from mcp.json in that directory.
+type JetBrainsIntegration struct {
+	// Transport is written to the mcp.json of every IDE alike.
+	Transport Transport
+}
+
+// ideDirPattern matches per-IDE config directories ("GoLand2025.1") and skips
+// shared ones like "Toolbox" or "consentOptions".
+var ideDirPattern = regexp.MustCompile(`^[A-Za-z]+\d{4}\.\d+$`)
+
+// Configure adds the MCP Scooter server to the mcp.json of every installed JetBrains IDE.
+func (j *JetBrainsIntegration) Configure(port int, profileID string, apiKey string) error {
+	dirs, err := j.IDEDirs()
+	if err != nil {
+		return err
+	}
+	if len(dirs) == 0 {
+		return fmt.Errorf("no JetBrains IDE configuration found; start the IDE once and try again")
+	}
+
+	serverConfig := serverEntry(j.Transport, port, profileID, apiKey, "url", false)
+
+	for _, dir := range dirs {
+		err := updateServers(filepath.Join(dir, "mcp.json"), KeyMcpServers, func(servers map[string]interface{}) {
+			servers["mcp-scooter"] = serverConfig
+		})
+		if err != nil {
+			return fmt.Errorf("failed to configure %s: %w", filepath.Base(dir), err)
+		}
+	}
+	return nil
+}
+
+// Remove deletes the MCP Scooter server from every installed JetBrains IDE.
+func (j *JetBrainsIntegration) Remove() error {
+	dirs, err := j.IDEDirs()
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, "mcp.json")
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		err := updateServers(path, KeyMcpServers, func(servers map[string]interface{}) {
+			delete(servers, "mcp-scooter")
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %w", filepath.Base(dir), err)
+		}
+	}
+	return nil
+}
+
+// IDEDirs returns the config directory of every installed JetBrains IDE.
+func (j *JetBrainsIntegration) IDEDirs() ([]string, error) {
+	roots, err := j.configRoots()
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	for _, root := range roots {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && ideDirPattern.MatchString(entry.Name()) {
+				dirs = append(dirs, filepath.Join(root, entry.Name()))
+			}
+		}
+	}
+	return dirs, nil
+}
+
+func (j *JetBrainsIntegration) configRoots() ([]string, error) {
+	var roots []string
+
+	// Windows
+	if appData := os.Getenv("APPDATA"); appData != "" {
+		roots = append(roots, filepath.Join(appData, "JetBrains"))
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// macOS, then Linux
+	roots = append(roots,
+		filepath.Join(home, "Library", "Application Support", "JetBrains"),
+		filepath.Join(home, ".config", "JetBrains"),
+	)
+	return roots, nil
+}
@@ -0,0 +1,240 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Targeted edits of JSON-with-comments files (Zed's settings.json and
+// similar). Unlike unmarshal/marshal, setJSONCValue only rewrites the value it
+// is asked to set, so comments, key order and formatting elsewhere survive.
+
+// jsoncMember is one key of a JSONC object, with byte offsets into the source.
+type jsoncMember struct {
+	key        string
+	valueStart int
+	valueEnd   int
+}
+
+type jsoncScanner struct {
+	src []byte
+	pos int
+}
+
+func (s *jsoncScanner) errorf(format string, args ...interface{}) error {
+	line := 1 + strings.Count(string(s.src[:s.pos]), "\n")
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips whitespace and // or /* */ comments.
+func (s *jsoncScanner) skipSpace() {
+	for s.pos < len(s.src) {
+		switch c := s.src[s.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			s.pos++
+		case c == '/' && s.pos+1 < len(s.src) && s.src[s.pos+1] == '/':
+			for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+				s.pos++
+			}
+		case c == '/' && s.pos+1 < len(s.src) && s.src[s.pos+1] == '*':
+			end := strings.Index(string(s.src[s.pos+2:]), "*/")
+			if end < 0 {
+				s.pos = len(s.src)
+				return
+			}
+			s.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+func (s *jsoncScanner) scanString() (string, error) {
+	start := s.pos
+	s.pos++ // opening quote
+	for s.pos < len(s.src) {
+		switch s.src[s.pos] {
+		case '\\':
+			s.pos += 2
+		case '"':
+			s.pos++
+			var str string
+			if err := json.Unmarshal(s.src[start:s.pos], &str); err != nil {
+				return "", s.errorf("invalid string")
+			}
+			return str, nil
+		default:
+			s.pos++
+		}
+	}
+	return "", s.errorf("unterminated string")
+}
+
+// skipValue moves past one JSON value of any type.
+func (s *jsoncScanner) skipValue() error {
+	s.skipSpace()
+	if s.pos >= len(s.src) {
+		return s.errorf("unexpected end of file")
+	}
+	switch s.src[s.pos] {
+	case '"':
+		_, err := s.scanString()
+		return err
+	case '{':
+		_, err := s.scanObject()
+		return err
+	case '[':
+		s.pos++
+		for {
+			s.skipSpace()
+			if s.pos >= len(s.src) {
+				return s.errorf("unterminated array")
+			}
+			if s.src[s.pos] == ']' {
+				s.pos++
+				return nil
+			}
+			if err := s.skipValue(); err != nil {
+				return err
+			}
+			s.skipSpace()
+			if s.pos < len(s.src) && s.src[s.pos] == ',' {
+				s.pos++
+			}
+		}
+	default:
+		// Number, true, false or null
+		start := s.pos
+		for s.pos < len(s.src) && !strings.ContainsRune(" \t\r\n,}]/", rune(s.src[s.pos])) {
+			s.pos++
+		}
+		if s.pos == start {
+			return s.errorf("unexpected %q", s.src[s.pos])
+		}
+		return nil
+	}
+}
+
+// scanObject reads the object starting at s.pos and returns its members.
+// Trailing commas are accepted, as in JSONC.
+func (s *jsoncScanner) scanObject() ([]jsoncMember, error) {
+	s.pos++ // {
+	var members []jsoncMember
+	for {
+		s.skipSpace()
+		if s.pos >= len(s.src) {
+			return nil, s.errorf("unterminated object")
+		}
+		if s.src[s.pos] == '}' {
+			s.pos++
+			return members, nil
+		}
+		if s.src[s.pos] != '"' {
+			return nil, s.errorf("expected object key")
+		}
+		key, err := s.scanString()
+		if err != nil {
+			return nil, err
+		}
+		s.skipSpace()
+		if s.pos >= len(s.src) || s.src[s.pos] != ':' {
+			return nil, s.errorf("expected ':' after %q", key)
+		}
+		s.pos++
+		s.skipSpace()
+		m := jsoncMember{key: key, valueStart: s.pos}
+		if err := s.skipValue(); err != nil {
+			return nil, err
+		}
+		m.valueEnd = s.pos
+		members = append(members, m)
+		s.skipSpace()
+		if s.pos < len(s.src) && s.src[s.pos] == ',' {
+			s.pos++
+		}
+	}
+}
+
+// lineIndent returns the leading whitespace of the line containing pos.
+func lineIndent(src []byte, pos int) string {
+	start := strings.LastIndexByte(string(src[:pos]), '\n') + 1
+	end := start
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return string(src[start:end])
+}
+
+// setJSONCValue sets the value at path (a chain of object keys) in a JSONC
+// document, creating intermediate objects as needed, and returns the edited
+// document. Only the affected value is rewritten.
+func setJSONCValue(src []byte, path []string, value interface{}) ([]byte, error) {
+	s := &jsoncScanner{src: src}
+	s.skipSpace()
+	if s.pos >= len(src) {
+		// Empty file: write a fresh document
+		for i := len(path) - 1; i >= 0; i-- {
+			value = map[string]interface{}{path[i]: value}
+		}
+		out, err := json.MarshalIndent(value, "", "  ")
+		return append(out, '\n'), err
+	}
+	if src[s.pos] != '{' {
+		return nil, s.errorf("expected a JSON object")
+	}
+	return setInObject(s, path, value)
+}
+
+func setInObject(s *jsoncScanner, path []string, value interface{}) ([]byte, error) {
+	src := s.src
+	open := s.pos
+	members, err := s.scanObject()
+	if err != nil {
+		return nil, err
+	}
+	closeBrace := s.pos - 1
+
+	for _, m := range members {
+		if m.key != path[0] {
+			continue
+		}
+		if len(path) > 1 && src[m.valueStart] == '{' {
+			return setInObject(&jsoncScanner{src: src, pos: m.valueStart}, path[1:], value)
+		}
+		// Replace the existing value (or a non-object in the way of the path)
+		for i := len(path) - 1; i >= 1; i-- {
+			value = map[string]interface{}{path[i]: value}
+		}
+		rendered, err := json.MarshalIndent(value, lineIndent(src, m.valueStart), "  ")
+		if err != nil {
+			return nil, err
+		}
+		return splice(src, m.valueStart, m.valueEnd, string(rendered)), nil
+	}
+
+	// Key not present: insert it as the first member of the object
+	for i := len(path) - 1; i >= 1; i-- {
+		value = map[string]interface{}{path[i]: value}
+	}
+	braceIndent := lineIndent(src, open)
+	indent := braceIndent + "  "
+	rendered, err := json.MarshalIndent(value, indent, "  ")
+	if err != nil {
+		return nil, err
+	}
+	key, _ := json.Marshal(path[0])
+	member := fmt.Sprintf("\n%s%s: %s", indent, key, rendered)
+
+	if len(members) == 0 {
+		return splice(src, open+1, closeBrace, member+"\n"+braceIndent), nil
+	}
+	return splice(src, open+1, open+1, member+","), nil
+}
+
+func splice(src []byte, start, end int, text string) []byte {
+	out := make([]byte, 0, len(src)-(end-start)+len(text))
+	out = append(out, src[:start]...)
+	out = append(out, text...)
+	return append(out, src[end:]...)
+}
@@ -2,6 +2,7 @@ package integration
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/danieljoos/wincred"
 )
@@ -9,6 +10,10 @@ import (
 // Keychain handles secure storage of credentials.
 type Keychain struct {
 	prefix string
+
+	// memory, when set, holds the secrets instead of the OS keychain
+	memory map[string]string
+	mu     sync.RWMutex
 }
 
 // NewKeychain creates a new keychain manager.
@@ -16,8 +21,20 @@ func NewKeychain(prefix string) *Keychain {
 	return &Keychain{prefix: prefix}
 }
 
+// NewMemoryKeychain creates a keychain that keeps secrets in memory only,
+// for ephemeral runs that must not touch the user's credentials.
+func NewMemoryKeychain(prefix string) *Keychain {
+	return &Keychain{prefix: prefix, memory: map[string]string{}}
+}
+
 // SetSecret stores a secret in the Windows Credential Manager.
 func (k *Keychain) SetSecret(id, secret string) error {
+	if k.memory != nil {
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		k.memory[fmt.Sprintf("%s:%s", k.prefix, id)] = secret
+		return nil
+	}
 	cred := wincred.NewGenericCredential(fmt.Sprintf("%s:%s", k.prefix, id))
 	cred.CredentialBlob = []byte(secret)
 	cred.Persist = wincred.PersistSession
@@ -26,6 +43,15 @@ func (k *Keychain) SetSecret(id, secret string) error {
 
 // GetSecret retrieves a secret from the Windows Credential Manager.
 func (k *Keychain) GetSecret(id string) (string, error) {
+	if k.memory != nil {
+		k.mu.RLock()
+		defer k.mu.RUnlock()
+		secret, ok := k.memory[fmt.Sprintf("%s:%s", k.prefix, id)]
+		if !ok {
+			return "", fmt.Errorf("secret %s not found", id)
+		}
+		return secret, nil
+	}
 	cred, err := wincred.GetGenericCredential(fmt.Sprintf("%s:%s", k.prefix, id))
 	if err != nil {
 		return "", err
@@ -35,6 +61,12 @@ func (k *Keychain) GetSecret(id string) (string, error) {
 
 // RemoveSecret deletes a secret from the Windows Credential Manager.
 func (k *Keychain) RemoveSecret(id string) error {
+	if k.memory != nil {
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		delete(k.memory, fmt.Sprintf("%s:%s", k.prefix, id))
+		return nil
+	}
 	cred, err := wincred.GetGenericCredential(fmt.Sprintf("%s:%s", k.prefix, id))
 	if err != nil {
 		return err
@@ -5,29 +5,39 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
-	"net/http"
+	"os"
 	"time"
 
+	"github.com/mcp-scooter/scooter/internal/domain/registry"
 	"golang.org/x/oauth2"
 )
 
+// oauthRefreshMargin is how long before it expires a token is refreshed.
+const oauthRefreshMargin = time.Minute
+
+// oauthRefreshTimeout bounds a token refresh at activation.
+const oauthRefreshTimeout = 30 * time.Second
+
 // OAuthHandler handles PKCE OAuth 2.1 flows.
 type OAuthHandler struct {
 	config *oauth2.Config
 }
 
-func NewOAuthHandler(clientID, clientSecret, authURL, tokenURL string, scopes []string) *OAuthHandler {
+// NewOAuthHandler creates a handler for the provider of a registry entry's
+// OAuth config, redirecting the browser to redirectURL.
+func NewOAuthHandler(cfg *registry.OAuthConfig, clientID, clientSecret, redirectURL string) *OAuthHandler {
 	return &OAuthHandler{
 		config: &oauth2.Config{
 			ClientID:     clientID,
 			ClientSecret: clientSecret,
 			Endpoint: oauth2.Endpoint{
-				AuthURL:  authURL,
-				TokenURL: tokenURL,
+				AuthURL:  cfg.AuthorizationURL,
+				TokenURL: cfg.TokenURL,
 			},
-			RedirectURL: "http://localhost:6299/callback",
-			Scopes:      scopes,
+			RedirectURL: redirectURL,
+			Scopes:      cfg.Scopes,
 		},
 	}
 }
@@ -45,57 +55,124 @@ func GeneratePKCE() (verifier, challenge string, err error) {
 	return verifier, challenge, nil
 }
 
-// Login initiates the OAuth flow and captures the token.
-func (h *OAuthHandler) Login(ctx context.Context) (*oauth2.Token, error) {
-	verifier, challenge, err := GeneratePKCE()
-	if err != nil {
-		return nil, err
-	}
-
-	state := "random-state" // Should be dynamic in real implementation
-	authURL := h.config.AuthCodeURL(state,
+// AuthCodeURL returns the provider URL the user logs in at. state comes back
+// with the callback; challenge is the PKCE challenge of the flow's verifier.
+func (h *OAuthHandler) AuthCodeURL(state, challenge string) string {
+	return h.config.AuthCodeURL(state,
+		oauth2.AccessTypeOffline,
 		oauth2.SetAuthURLParam("code_challenge", challenge),
 		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
 	)
+}
 
-	fmt.Printf("Please log in at: %s\n", authURL)
+// Exchange trades the code the callback received for a token.
+func (h *OAuthHandler) Exchange(ctx context.Context, code, verifier string) (*oauth2.Token, error) {
+	return h.config.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", verifier))
+}
 
-	// Start temporary server for callback
-	codeChan := make(chan string)
-	errChan := make(chan error)
-	srv := &http.Server{Addr: ":6299"}
+// Refresh gets a new token with a refresh token.
+func (h *OAuthHandler) Refresh(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	return h.config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+}
 
-	http.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		query := r.URL.Query()
-		if query.Get("state") != state {
-			errChan <- fmt.Errorf("invalid state")
-			return
-		}
-		code := query.Get("code")
-		if code == "" {
-			errChan <- fmt.Errorf("no code received")
-			return
+// OAuth tokens are kept in the keychain under the tool name: the access
+// token as TokenEnv, the refresh token as RefreshTokenEnv (or
+// <TokenEnv>_REFRESH when the entry doesn't name one), the expiry as
+// <TokenEnv>_EXPIRES_AT, and the client credentials as ClientIDEnv and
+// ClientSecretEnv so the token can be refreshed later.
+
+func oauthRefreshKey(cfg *registry.OAuthConfig) string {
+	if cfg.RefreshTokenEnv != "" {
+		return cfg.RefreshTokenEnv
+	}
+	return cfg.TokenEnv + "_REFRESH"
+}
+
+func oauthExpiryKey(cfg *registry.OAuthConfig) string {
+	return cfg.TokenEnv + "_EXPIRES_AT"
+}
+
+func oauthClientIDKey(cfg *registry.OAuthConfig) string {
+	if cfg.ClientIDEnv != "" {
+		return cfg.ClientIDEnv
+	}
+	return "OAUTH_CLIENT_ID"
+}
+
+func oauthClientSecretKey(cfg *registry.OAuthConfig) string {
+	if cfg.ClientSecretEnv != "" {
+		return cfg.ClientSecretEnv
+	}
+	return "OAUTH_CLIENT_SECRET"
+}
+
+// OAuthClient returns the client ID and secret stored for toolName, falling
+// back to the process environment.
+func (c *CredentialManager) OAuthClient(toolName string, cfg *registry.OAuthConfig) (id, secret string) {
+	lookup := func(name string) string {
+		if v, _ := c.keychain.GetSecret(c.key(toolName, name)); v != "" {
+			return v
 		}
-		fmt.Fprintln(w, "Authentication successful! You can close this window.")
-		codeChan <- code
-	})
+		return os.Getenv(name)
+	}
+	return lookup(oauthClientIDKey(cfg)), lookup(oauthClientSecretKey(cfg))
+}
 
-	go func() {
-		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
-			errChan <- err
+// SetOAuthToken stores a token obtained for toolName, with the client
+// credentials that obtained it. A token without a refresh token keeps the
+// stored one, as providers don't always return a new one on refresh.
+func (c *CredentialManager) SetOAuthToken(toolName string, cfg *registry.OAuthConfig, token *oauth2.Token, clientID, clientSecret string) error {
+	if cfg.TokenEnv == "" {
+		return errors.New("the registry entry's oauth config has no token_env")
+	}
+	values := []struct{ name, value string }{
+		{cfg.TokenEnv, token.AccessToken},
+		{oauthRefreshKey(cfg), token.RefreshToken},
+		{oauthClientIDKey(cfg), clientID},
+		{oauthClientSecretKey(cfg), clientSecret},
+	}
+	for _, v := range values {
+		if v.value == "" {
+			continue
+		}
+		if err := c.keychain.SetSecret(c.key(toolName, v.name), v.value); err != nil {
+			return fmt.Errorf("failed to store %s: %w", v.name, err)
 		}
-	}()
-
-	defer srv.Shutdown(ctx)
-
-	select {
-	case code := <-codeChan:
-		return h.config.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", verifier))
-	case err := <-errChan:
-		return nil, err
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case <-time.After(5 * time.Minute):
-		return nil, fmt.Errorf("timeout")
 	}
+
+	expiryKey := c.key(toolName, oauthExpiryKey(cfg))
+	if token.Expiry.IsZero() {
+		c.keychain.RemoveSecret(expiryKey)
+		return nil
+	}
+	return c.keychain.SetSecret(expiryKey, token.Expiry.UTC().Format(time.RFC3339))
+}
+
+// refreshOAuthToken refreshes toolName's stored token if it expires soon and
+// can be refreshed.
+func (c *CredentialManager) refreshOAuthToken(toolName string, cfg *registry.OAuthConfig) error {
+	expires, _ := c.keychain.GetSecret(c.key(toolName, oauthExpiryKey(cfg)))
+	if expires == "" {
+		return nil
+	}
+	expiry, err := time.Parse(time.RFC3339, expires)
+	if err != nil || time.Until(expiry) > oauthRefreshMargin {
+		return nil
+	}
+	refresh, _ := c.keychain.GetSecret(c.key(toolName, oauthRefreshKey(cfg)))
+	if refresh == "" {
+		return fmt.Errorf("the OAuth token of %s expired and cannot be refreshed; log in again", toolName)
+	}
+
+	id, secret := c.OAuthClient(toolName, cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), oauthRefreshTimeout)
+	defer cancel()
+	token, err := NewOAuthHandler(cfg, id, secret, "").Refresh(ctx, refresh)
+	if err != nil {
+		return fmt.Errorf("failed to refresh the OAuth token of %s: %w", toolName, err)
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refresh
+	}
+	return c.SetOAuthToken(toolName, cfg, token, id, secret)
 }
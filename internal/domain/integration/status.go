@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ScooterEntry is the mcp-scooter server entry found in a client's config file.
+type ScooterEntry struct {
+	URL     string `json:"url"`
+	Port    int    `json:"port,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	// APIKey is the bearer token the client sends. Never serialized.
+	APIKey string `json:"-"`
+}
+
+// FindScooterEntry reads a client's JSON config and returns its mcp-scooter
+// entry, or nil if the file has none. A missing file is not an error.
+func FindScooterEntry(path string) (*ScooterEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	for _, key := range []string{KeyMcpServers, KeyServers, KeyContextServers} {
+		var servers map[string]struct {
+			URL       string            `json:"url"`
+			ServerURL string            `json:"serverUrl"`
+			Headers   map[string]string `json:"headers"`
+		}
+		if raw, ok := config[key]; !ok || json.Unmarshal(raw, &servers) != nil {
+			continue
+		}
+		server, ok := servers["mcp-scooter"]
+		if !ok {
+			continue
+		}
+
+		entry := &ScooterEntry{URL: server.URL}
+		if entry.URL == "" {
+			entry.URL = server.ServerURL
+		}
+		entry.Port, entry.Profile, _ = ParseGatewayURL(entry.URL)
+		for name, value := range server.Headers {
+			if strings.EqualFold(name, "Authorization") {
+				entry.APIKey = strings.TrimSpace(strings.TrimPrefix(value, "Bearer "))
+			}
+		}
+		return entry, nil
+	}
+	return nil, nil
+}
+
+// ParseGatewayURL extracts the port and profile from a gateway SSE URL as
+// written by the integrations. /sse is the "work" profile.
+func ParseGatewayURL(raw string) (port int, profileID string, ok bool) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return 0, "", false
+	}
+	port, _ = strconv.Atoi(u.Port())
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "sse":
+		return port, "work", true
+	case len(parts) == 3 && parts[0] == "profiles" && parts[2] == "sse":
+		return port, parts[1], true
+	}
+	return port, "", false
+}
@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Transport is how a client connects to the Scooter gateway. Integrations
+// write the one in their Transport field to the client's config; the zero
+// value means TransportSSE.
+type Transport string
+
+const (
+	// TransportSSE is the legacy HTTP+SSE transport. It is the default.
+	TransportSSE Transport = "sse"
+	// TransportHTTP is streamable HTTP: the client POSTs to the same endpoint.
+	TransportHTTP Transport = "http"
+	// TransportStdio launches the Scooter binary as a stdio bridge to the gateway.
+	TransportStdio Transport = "stdio"
+)
+
+// BridgeAPIKeyEnv is the environment variable the stdio bridge reads the
+// gateway API key from, so the key doesn't show up in process listings.
+const BridgeAPIKeyEnv = "SCOOTER_API_KEY"
+
+// ParseTransport validates a transport name. The empty string means the
+// integration's default; "streamable-http" is accepted as an alias of "http".
+func ParseTransport(name string) (Transport, error) {
+	switch t := Transport(strings.ToLower(strings.TrimSpace(name))); t {
+	case "":
+		return "", nil
+	case TransportSSE, TransportHTTP, TransportStdio:
+		return t, nil
+	case "streamable-http", "streamable_http":
+		return TransportHTTP, nil
+	default:
+		return "", fmt.Errorf("unknown transport %q (use sse, http or stdio)", name)
+	}
+}
+
+// bridgeCommand is the executable clients launch for TransportStdio: the
+// running Scooter binary.
+func bridgeCommand() string {
+	if exe, err := os.Executable(); err == nil {
+		return exe
+	}
+	return "scooter"
+}
+
+// serverEntry builds the mcp-scooter server entry for a transport.
+//
+// urlField names the URL property ("url" for most clients, "serverUrl" for
+// Windsurf). typed reports whether the client expects the transport spelled
+// out in a "type" property; Zed and Windsurf infer it.
+func serverEntry(t Transport, port int, profileID, apiKey, urlField string, typed bool) map[string]interface{} {
+	url := gatewayURL(port, profileID)
+
+	if t == TransportStdio {
+		entry := map[string]interface{}{
+			"command": bridgeCommand(),
+			"args":    []string{"--bridge", url},
+		}
+		if typed {
+			entry["type"] = string(TransportStdio)
+		}
+		if apiKey != "" {
+			entry["env"] = map[string]string{BridgeAPIKeyEnv: apiKey}
+		}
+		return entry
+	}
+
+	if t == "" {
+		t = TransportSSE
+	}
+	if urlField == "" {
+		urlField = "url"
+	}
+	entry := map[string]interface{}{
+		urlField: url,
+	}
+	if typed {
+		entry["type"] = string(t)
+	}
+	if apiKey != "" {
+		entry["headers"] = map[string]string{
+			"Authorization": "Bearer " + apiKey,
+		}
+	}
+	return entry
+}
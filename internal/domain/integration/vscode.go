@@ -2,13 +2,14 @@ package integration
 
 import (
 	"encoding/json"
-	"fmt"
 	"os"
 	"path/filepath"
 )
 
 // VSCodeIntegration handles configuring VS Code to use MCP Scooter.
-type VSCodeIntegration struct{}
+type VSCodeIntegration struct {
+	Transport Transport
+}
 
 // Configure adds the MCP Scooter server to VS Code's mcp.json.
 // Note: While the PRD mentions ~/.vscode/mcp.json, VS Code usually
@@ -35,21 +36,7 @@ func (v *VSCodeIntegration) Configure(port int, profileID string, apiKey string)
 	}
 
 	// Add or update MCP Scooter entry
-	url := fmt.Sprintf("http://127.0.0.1:%d/profiles/%s/sse", port, profileID)
-	if profileID == "work" {
-		url = fmt.Sprintf("http://127.0.0.1:%d/sse", port)
-	}
-
-	serverConfig := map[string]interface{}{
-		"type": "sse",
-		"url":  url,
-	}
-
-	if apiKey != "" {
-		serverConfig["headers"] = map[string]string{
-			"Authorization": "Bearer " + apiKey,
-		}
-	}
+	serverConfig := serverEntry(v.Transport, port, profileID, apiKey, "url", true)
 
 	config.McpServers["mcp-scooter"] = serverConfig
 
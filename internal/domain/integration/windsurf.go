@@ -0,0 +1,42 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WindsurfIntegration handles configuring Windsurf (Codeium) to use MCP Scooter.
+type WindsurfIntegration struct {
+	// Transport is passed on to the mcp_config.json entry.
+	Transport Transport
+}
+
+// Configure adds the MCP Scooter server to Windsurf's mcp_config.json.
+// Windsurf reads remote servers from "serverUrl" rather than "url".
+func (w *WindsurfIntegration) Configure(port int, profileID string, apiKey string) error {
+	path, err := w.findConfig()
+	if err != nil {
+		return err
+	}
+	g := &GenericJSONIntegration{Path: path, Key: KeyMcpServers, URLField: "serverUrl", Transport: w.Transport}
+	return g.Configure(port, profileID, apiKey)
+}
+
+// Remove deletes the MCP Scooter server from Windsurf's mcp_config.json.
+func (w *WindsurfIntegration) Remove() error {
+	path, err := w.findConfig()
+	if err != nil {
+		return err
+	}
+	g := &GenericJSONIntegration{Path: path, Key: KeyMcpServers, URLField: "serverUrl"}
+	return g.Remove()
+}
+
+func (w *WindsurfIntegration) findConfig() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	// Same location on every platform
+	return filepath.Join(home, ".codeium", "windsurf", "mcp_config.json"), nil
+}
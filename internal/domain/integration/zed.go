@@ -1,14 +1,16 @@
 package integration
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 )
 
 // ZedIntegration handles configuring Zed to use MCP Scooter.
-type ZedIntegration struct{}
+type ZedIntegration struct {
+	// Transport decides the entry's shape: a command or a url.
+	Transport Transport
+}
 
 // Configure adds the MCP Scooter server to Zed's settings.json.
 func (z *ZedIntegration) Configure(port int, profileID string, apiKey string) error {
@@ -17,45 +19,18 @@ func (z *ZedIntegration) Configure(port int, profileID string, apiKey string) er
 		return err
 	}
 
-	var config map[string]interface{}
+	// Zed infers the transport from the entry's shape
+	serverConfig := serverEntry(z.Transport, port, profileID, apiKey, "url", false)
 
+	// settings.json is JSONC; edit just our entry so comments survive
 	data, err := os.ReadFile(path)
-	if err == nil {
-		json.Unmarshal(data, &config)
-	}
-
-	if config == nil {
-		config = make(map[string]interface{})
-	}
-
-	// Zed uses "context_servers" key for MCP
-	contextServers, ok := config["context_servers"].(map[string]interface{})
-	if !ok {
-		contextServers = make(map[string]interface{})
-		config["context_servers"] = contextServers
-	}
-
-	// Add or update MCP Scooter entry
-	url := fmt.Sprintf("http://127.0.0.1:%d/profiles/%s/sse", port, profileID)
-	if profileID == "work" {
-		url = fmt.Sprintf("http://127.0.0.1:%d/sse", port)
-	}
-
-	serverConfig := map[string]interface{}{
-		"url": url,
-	}
-
-	if apiKey != "" {
-		serverConfig["headers"] = map[string]string{
-			"Authorization": "Bearer " + apiKey,
-		}
+	if err != nil && !os.IsNotExist(err) {
+		return err
 	}
 
-	contextServers["mcp-scooter"] = serverConfig
-
-	newData, err := json.MarshalIndent(config, "", "  ")
+	newData, err := setJSONCValue(data, []string{"context_servers", "mcp-scooter"}, serverConfig)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to update %s: %w", path, err)
 	}
 
 	return os.WriteFile(path, newData, 0644)
@@ -0,0 +1,59 @@
+package profile
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// AllowTools entries are server names or expressions matching registry
+// entries by their metadata:
+//
+//	brave-*          server names matching a glob (* ? and [...] as in path.Match)
+//	category:search  entries in a category
+//	tag:github       entries with a tag
+//
+// Categories and tags are compared case-insensitively.
+const (
+	allowCategoryPrefix = "category:"
+	allowTagPrefix      = "tag:"
+)
+
+// IsAllowExpression reports whether an AllowTools entry is an expression
+// rather than a server name.
+func IsAllowExpression(entry string) bool {
+	return strings.HasPrefix(entry, allowCategoryPrefix) ||
+		strings.HasPrefix(entry, allowTagPrefix) ||
+		strings.ContainsAny(entry, "*?[")
+}
+
+// MatchAllowEntry reports whether the AllowTools entry matches the registry
+// entry with the name, category and tags.
+func MatchAllowEntry(entry, name, category string, tags []string) bool {
+	switch {
+	case strings.HasPrefix(entry, allowCategoryPrefix):
+		want := strings.TrimPrefix(entry, allowCategoryPrefix)
+		return want != "" && strings.EqualFold(want, category)
+	case strings.HasPrefix(entry, allowTagPrefix):
+		want := strings.TrimPrefix(entry, allowTagPrefix)
+		return want != "" && slices.ContainsFunc(tags, func(tag string) bool { return strings.EqualFold(tag, want) })
+	case strings.ContainsAny(entry, "*?["):
+		matched, _ := path.Match(entry, name)
+		return matched
+	}
+	return entry == name
+}
+
+// ValidateAllowEntry returns why an AllowTools entry can never match, or ""
+// if it is fine.
+func ValidateAllowEntry(entry string) string {
+	switch {
+	case entry == allowCategoryPrefix || entry == allowTagPrefix:
+		return "expression has no value"
+	case strings.ContainsAny(entry, "*?[") && !strings.HasPrefix(entry, allowCategoryPrefix) && !strings.HasPrefix(entry, allowTagPrefix):
+		if _, err := path.Match(entry, ""); err != nil {
+			return "malformed pattern"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package profile_test
+
+import (
+	"testing"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchAllowEntry(t *testing.T) {
+	tags := []string{"GitHub", "vcs"}
+	tests := []struct {
+		entry string
+		want  bool
+	}{
+		{"brave-search", true},
+		{"brave", false},
+		{"brave-*", true},
+		{"*-search", true},
+		{"brave-?earch", true},
+		{"github-*", false},
+		{"category:search", true},
+		{"category:Search", true},
+		{"category:dev", false},
+		{"category:", false},
+		{"tag:github", true},
+		{"tag:docs", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, profile.MatchAllowEntry(tt.entry, "brave-search", "search", tags), tt.entry)
+	}
+
+	assert.True(t, profile.IsAllowExpression("tag:github"))
+	assert.False(t, profile.IsAllowExpression("brave-search"))
+	assert.Equal(t, "malformed pattern", profile.ValidateAllowEntry("brave-["))
+	assert.Equal(t, "expression has no value", profile.ValidateAllowEntry("tag:"))
+	assert.Empty(t, profile.ValidateAllowEntry("category:search"))
+}
@@ -0,0 +1,218 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConfigJSONEnv holds a whole configuration as JSON:
+// {"profiles": [...], "settings": {...}}, with the API's field names.
+const ConfigJSONEnv = "SCOOTER_CONFIG_JSON"
+
+// envPrefix starts every configuration variable. Settings use their YAML
+// key in upper case (SCOOTER_GATEWAY_API_KEY); profile fields use
+// SCOOTER_PROFILE_<ID>_<FIELD>, e.g. SCOOTER_PROFILE_WORK_ALLOW_TOOLS.
+const envPrefix = "SCOOTER_"
+
+// profileEnvFields are the profile fields settable from the environment.
+// Profile IDs are lower-cased; ENV_<NAME> sets one tool environment variable.
+var profileEnvFields = []string{"ALLOW_TOOLS", "DISABLED_SYSTEM_TOOLS", "API_KEY", "AUTO_ACTIVATE", "BUILTIN_PREFIX"}
+
+// Bootstrap writes profiles.yaml and settings.yaml from the environment on a
+// first boot, so CI can configure Scooter without writing YAML. It does
+// nothing if either file exists or no configuration variables are set, and
+// reports whether it wrote the files. environ is in os.Environ form.
+func (s *Store) Bootstrap(environ []string) (bool, error) {
+	for _, path := range []string{s.profilesPath, s.settingsPath} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			return false, nil
+		}
+	}
+
+	profiles, settings, found, err := configFromEnv(environ)
+	if err != nil || !found {
+		return false, err
+	}
+	if err := s.Save(profiles, settings); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// configFromEnv builds a configuration from SCOOTER_CONFIG_JSON and then
+// the individual variables, which take precedence. Variables are applied in
+// sorted order so the result doesn't depend on the environment's order.
+func configFromEnv(environ []string) (profiles []Profile, settings Settings, found bool, err error) {
+	settings = DefaultSettings()
+	vars := map[string]string{}
+	for _, kv := range environ {
+		if key, value, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(key, envPrefix) {
+			vars[key] = value
+		}
+	}
+
+	if blob, ok := vars[ConfigJSONEnv]; ok {
+		var config struct {
+			Profiles []Profile       `json:"profiles"`
+			Settings json.RawMessage `json:"settings"`
+		}
+		if err := json.Unmarshal([]byte(blob), &config); err != nil {
+			return nil, Settings{}, false, fmt.Errorf("%s: %w", ConfigJSONEnv, err)
+		}
+		if len(config.Settings) > 0 {
+			// Fields missing from the blob keep their defaults
+			if err := json.Unmarshal(config.Settings, &settings); err != nil {
+				return nil, Settings{}, false, fmt.Errorf("%s: settings: %w", ConfigJSONEnv, err)
+			}
+		}
+		profiles = config.Profiles
+		found = true
+	}
+
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := settingsEnvFields()
+	for _, key := range keys {
+		value := vars[key]
+		name := strings.TrimPrefix(key, envPrefix)
+		if rest, ok := strings.CutPrefix(name, "PROFILE_"); ok {
+			applied, err := applyProfileEnv(&profiles, rest, value)
+			if err != nil {
+				return nil, Settings{}, false, fmt.Errorf("%s: %w", key, err)
+			}
+			found = found || applied
+			continue
+		}
+		field, ok := fields[strings.ToLower(name)]
+		if !ok {
+			continue // SCOOTER_CONFIG_DIR and friends aren't settings
+		}
+		if err := setFromString(reflect.ValueOf(&settings).Elem().FieldByIndex(field), value); err != nil {
+			return nil, Settings{}, false, fmt.Errorf("%s: %w", key, err)
+		}
+		found = true
+	}
+	return profiles, settings, found, nil
+}
+
+// settingsEnvFields maps the YAML keys of the scalar and list settings to
+// their field index.
+func settingsEnvFields() map[string][]int {
+	fields := map[string][]int{}
+	t := reflect.TypeOf(Settings{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.String, reflect.Int, reflect.Bool:
+			fields[key] = f.Index
+		case reflect.Slice:
+			if f.Type.Elem().Kind() == reflect.String {
+				fields[key] = f.Index
+			}
+		}
+	}
+	return fields
+}
+
+// applyProfileEnv sets one profile field from a SCOOTER_PROFILE_ variable,
+// name being what follows that prefix. It reports false for names that
+// don't end in a known field.
+func applyProfileEnv(profiles *[]Profile, name, value string) (bool, error) {
+	var id, field, envName string
+	if before, after, ok := strings.Cut(name, "_ENV_"); ok && after != "" {
+		id, field, envName = before, "ENV", after
+	} else {
+		for _, f := range profileEnvFields {
+			if before, ok := strings.CutSuffix(name, "_"+f); ok {
+				id, field = before, f
+				break
+			}
+		}
+	}
+	if id == "" || field == "" {
+		return false, nil
+	}
+	id = strings.ToLower(id)
+
+	var p *Profile
+	for i := range *profiles {
+		if (*profiles)[i].ID == id {
+			p = &(*profiles)[i]
+			break
+		}
+	}
+	if p == nil {
+		*profiles = append(*profiles, Profile{ID: id})
+		p = &(*profiles)[len(*profiles)-1]
+	}
+
+	switch field {
+	case "ENV":
+		if p.Env == nil {
+			p.Env = map[string]string{}
+		}
+		p.Env[envName] = value
+	case "ALLOW_TOOLS":
+		p.AllowTools = splitList(value)
+	case "DISABLED_SYSTEM_TOOLS":
+		p.DisabledSystemTools = splitList(value)
+	case "API_KEY":
+		p.APIKey = value
+	case "BUILTIN_PREFIX":
+		p.BuiltinPrefix = value
+	case "AUTO_ACTIVATE":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, err
+		}
+		p.AutoActivate = b
+	}
+	return true, p.Validate()
+}
+
+// setFromString parses value into a string, int, bool or []string field.
+func setFromString(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		v.Set(reflect.ValueOf(splitList(value)))
+	}
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
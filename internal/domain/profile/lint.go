@@ -0,0 +1,127 @@
+package profile
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is how many known names an unknown one is matched with.
+const maxSuggestions = 3
+
+// LintServers checks the registry entries p names (allow_tools, the servers
+// of tool migrations and identities) against knownServers. Each unknown
+// name is a warning suggesting the closest known names, since a typo
+// silently makes a tool unavailable, as are allow_tools expressions that
+// are malformed or match nothing. Fields are relative to the profile.
+func (p Profile) LintServers(knownServers map[string]bool) []ConfigIssue {
+	var issues []ConfigIssue
+	check := func(field, name string) {
+		if name == "" || knownServers[name] {
+			return
+		}
+		issue := ConfigIssue{
+			Field:   field,
+			Message: fmt.Sprintf("%q is not in the registry", name),
+			Hint:    "check the spelling or add a registry entry for it",
+		}
+		if issue.Suggestions = SuggestNames(name, knownServers); len(issue.Suggestions) > 0 {
+			issue.Hint = fmt.Sprintf("did you mean %q?", issue.Suggestions[0])
+		}
+		issues = append(issues, issue)
+	}
+
+	for i, tool := range p.AllowTools {
+		field := fmt.Sprintf("allow_tools[%d]", i)
+		if !IsAllowExpression(tool) {
+			check(field, tool)
+			continue
+		}
+		// Categories and tags aren't known here; globs can be checked
+		if reason := ValidateAllowEntry(tool); reason != "" {
+			issues = append(issues, ConfigIssue{Field: field, Message: fmt.Sprintf("%q: %s", tool, reason)})
+		} else if !strings.Contains(tool, ":") && !matchesAny(tool, knownServers) {
+			issues = append(issues, ConfigIssue{Field: field, Message: fmt.Sprintf("%q matches no registry entry", tool)})
+		}
+	}
+	for i, m := range p.ToolMigrations {
+		check(fmt.Sprintf("tool_migrations[%d].server", i), m.Server)
+	}
+	servers := make([]string, 0, len(p.Identities))
+	for server := range p.Identities {
+		servers = append(servers, server)
+	}
+	slices.Sort(servers)
+	for _, server := range servers {
+		check("identities."+server, server)
+	}
+	return issues
+}
+
+func matchesAny(pattern string, names map[string]bool) bool {
+	for name := range names {
+		if MatchAllowEntry(pattern, name, "", nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// SuggestNames returns up to three of known closest to name, best first:
+// names within a few edits of it, then names containing it or contained in
+// it.
+func SuggestNames(name string, known map[string]bool) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+	lower := strings.ToLower(name)
+	limit := max(2, len(lower)/3)
+
+	var candidates []candidate
+	for k := range known {
+		kl := strings.ToLower(k)
+		d := editDistance(lower, kl)
+		if d > limit {
+			if len(lower) < 3 || !(strings.Contains(kl, lower) || strings.Contains(lower, kl)) {
+				continue
+			}
+			d = limit + 1 // After every near miss
+		}
+		candidates = append(candidates, candidate{k, d})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var names []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		names = append(names, candidates[i].name)
+	}
+	return names
+}
+
+// editDistance is the Levenshtein distance between a and b, in bytes.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
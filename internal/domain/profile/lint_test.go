@@ -0,0 +1,44 @@
+package profile_test
+
+import (
+	"testing"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfile_LintServers(t *testing.T) {
+	known := map[string]bool{"brave-search": true, "github": true, "github-enterprise": true, "context7": true}
+	p := profile.Profile{
+		ID:             "work",
+		AllowTools:     []string{"brave-search", "brave-serach", "githb", "zzzz"},
+		ToolMigrations: []profile.ToolMigration{{From: "old", Server: "context-7", Tool: "x"}},
+		Identities:     map[string]string{"github": "work"},
+	}
+
+	issues := p.LintServers(known)
+	require.Len(t, issues, 4)
+
+	assert.Equal(t, "allow_tools[1]", issues[0].Field)
+	assert.Equal(t, `"brave-serach" is not in the registry`, issues[0].Message)
+	assert.Equal(t, []string{"brave-search"}, issues[0].Suggestions)
+	assert.Equal(t, `did you mean "brave-search"?`, issues[0].Hint)
+
+	assert.Equal(t, "allow_tools[2]", issues[1].Field)
+	assert.Equal(t, []string{"github"}, issues[1].Suggestions)
+
+	assert.Equal(t, "allow_tools[3]", issues[2].Field)
+	assert.Empty(t, issues[2].Suggestions)
+	assert.Equal(t, "check the spelling or add a registry entry for it", issues[2].Hint)
+
+	assert.Equal(t, "tool_migrations[0].server", issues[3].Field)
+	assert.Equal(t, []string{"context7"}, issues[3].Suggestions)
+}
+
+func TestSuggestNames(t *testing.T) {
+	known := map[string]bool{"postgres": true, "postgres-readonly": true, "slack": true}
+	assert.Equal(t, []string{"postgres", "postgres-readonly"}, profile.SuggestNames("postgre", known))
+	assert.Equal(t, []string{"slack"}, profile.SuggestNames("Slak", known))
+	assert.Empty(t, profile.SuggestNames("xyz", known))
+}
@@ -0,0 +1,137 @@
+package profile
+
+import (
+	"fmt"
+	"time"
+)
+
+// OnboardingStep is one step of the first-run wizard.
+type OnboardingStep string
+
+const (
+	StepCreateProfile    OnboardingStep = "create_profile"
+	StepPickTools        OnboardingStep = "pick_tools"
+	StepStoreCredentials OnboardingStep = "store_credentials"
+	StepSyncClients      OnboardingStep = "sync_clients"
+	StepTestCall         OnboardingStep = "test_call"
+)
+
+// OnboardingSteps lists the wizard steps in the order they are taken.
+var OnboardingSteps = []OnboardingStep{
+	StepCreateProfile,
+	StepPickTools,
+	StepStoreCredentials,
+	StepSyncClients,
+	StepTestCall,
+}
+
+// skippableSteps are optional: a user may have no tools needing keys, or
+// prefer to configure clients by hand.
+var skippableSteps = map[OnboardingStep]bool{
+	StepStoreCredentials: true,
+	StepSyncClients:      true,
+	StepTestCall:         true,
+}
+
+// OnboardingState is the wizard's progress, persisted in settings.yaml so
+// the desktop app can resume where the user left off.
+type OnboardingState struct {
+	Completed []OnboardingStep `yaml:"completed,omitempty" json:"completed"`
+	Skipped   []OnboardingStep `yaml:"skipped,omitempty" json:"skipped"`
+	// ProfileID is the profile the wizard is setting up.
+	ProfileID string    `yaml:"profile_id,omitempty" json:"profile_id,omitempty"`
+	UpdatedAt time.Time `yaml:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+// IsOnboardingStep reports whether step is a known wizard step.
+func IsOnboardingStep(step OnboardingStep) bool {
+	return hasStep(OnboardingSteps, step)
+}
+
+// Skippable reports whether the wizard may move past step without doing it.
+func (step OnboardingStep) Skippable() bool {
+	return skippableSteps[step]
+}
+
+// Finished reports whether step was completed or skipped.
+func (o OnboardingState) Finished(step OnboardingStep) bool {
+	return hasStep(o.Completed, step) || hasStep(o.Skipped, step)
+}
+
+// Status describes step for the UI: "completed", "skipped", "current" or "pending".
+func (o OnboardingState) Status(step OnboardingStep) string {
+	switch {
+	case hasStep(o.Completed, step):
+		return "completed"
+	case hasStep(o.Skipped, step):
+		return "skipped"
+	case step == o.Current():
+		return "current"
+	default:
+		return "pending"
+	}
+}
+
+// Current returns the first step that is neither completed nor skipped, or
+// "" once the wizard is done.
+func (o OnboardingState) Current() OnboardingStep {
+	for _, step := range OnboardingSteps {
+		if !o.Finished(step) {
+			return step
+		}
+	}
+	return ""
+}
+
+// Done reports whether every step has been completed or skipped.
+func (o OnboardingState) Done() bool {
+	return o.Current() == ""
+}
+
+// Advance records step as completed (or skipped). Steps are taken in order,
+// but an already finished step may be redone.
+func (o *OnboardingState) Advance(step OnboardingStep, skip bool) error {
+	if !IsOnboardingStep(step) {
+		return fmt.Errorf("unknown onboarding step %q", step)
+	}
+	if skip && !step.Skippable() {
+		return fmt.Errorf("onboarding step %q cannot be skipped", step)
+	}
+	for _, earlier := range OnboardingSteps {
+		if earlier == step {
+			break
+		}
+		if !o.Finished(earlier) {
+			return fmt.Errorf("onboarding step %q must be finished before %q", earlier, step)
+		}
+	}
+
+	o.Completed = without(o.Completed, step)
+	o.Skipped = without(o.Skipped, step)
+	if skip {
+		o.Skipped = append(o.Skipped, step)
+	} else {
+		o.Completed = append(o.Completed, step)
+	}
+	o.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func hasStep(steps []OnboardingStep, step OnboardingStep) bool {
+	for _, s := range steps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+func without(steps []OnboardingStep, step OnboardingStep) []OnboardingStep {
+	out := steps[:0:0]
+	for _, s := range steps {
+		if s != step {
+			out = append(out, s)
+		}
+	}
+	return out
+}
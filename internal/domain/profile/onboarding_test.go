@@ -0,0 +1,35 @@
+package profile_test
+
+import (
+	"testing"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnboardingState_Advance(t *testing.T) {
+	var state profile.OnboardingState
+	assert.Equal(t, profile.StepCreateProfile, state.Current())
+
+	assert.Error(t, state.Advance(profile.StepPickTools, false), "steps are taken in order")
+	assert.Error(t, state.Advance(profile.StepCreateProfile, true), "creating a profile can't be skipped")
+	assert.Error(t, state.Advance("make_coffee", false))
+
+	require.NoError(t, state.Advance(profile.StepCreateProfile, false))
+	require.NoError(t, state.Advance(profile.StepPickTools, false))
+	require.NoError(t, state.Advance(profile.StepStoreCredentials, true))
+	assert.Equal(t, "skipped", state.Status(profile.StepStoreCredentials))
+	assert.Equal(t, "current", state.Status(profile.StepSyncClients))
+	assert.Equal(t, "pending", state.Status(profile.StepTestCall))
+
+	// A skipped step can be done later
+	require.NoError(t, state.Advance(profile.StepStoreCredentials, false))
+	assert.Equal(t, "completed", state.Status(profile.StepStoreCredentials))
+	assert.NotContains(t, state.Skipped, profile.StepStoreCredentials)
+
+	require.NoError(t, state.Advance(profile.StepSyncClients, true))
+	require.NoError(t, state.Advance(profile.StepTestCall, false))
+	assert.True(t, state.Done())
+	assert.Equal(t, profile.OnboardingStep(""), state.Current())
+}
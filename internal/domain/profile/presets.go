@@ -0,0 +1,84 @@
+package profile
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ToolPresets are named argument sets for tools, by tool name and then
+// preset name. Unlike tool-params.json, which holds the desktop app's last
+// test arguments, presets are meant to be shared: teams export and import
+// them to pass around working example invocations.
+type ToolPresets map[string]map[string]map[string]interface{}
+
+var presetNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// ValidatePresetName reports whether name can name a preset.
+func ValidatePresetName(name string) error {
+	if !presetNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid preset name %q: use up to 64 letters, digits, '_', '.' and '-'", name)
+	}
+	return nil
+}
+
+// Get returns the arguments of a tool's preset.
+func (p ToolPresets) Get(tool, name string) (map[string]interface{}, bool) {
+	args, ok := p[tool][name]
+	return args, ok
+}
+
+// Set stores the arguments of a tool's preset.
+func (p ToolPresets) Set(tool, name string, args map[string]interface{}) {
+	if p[tool] == nil {
+		p[tool] = make(map[string]map[string]interface{})
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	p[tool][name] = args
+}
+
+// Delete removes a tool's preset, reporting whether it existed.
+func (p ToolPresets) Delete(tool, name string) bool {
+	if _, ok := p[tool][name]; !ok {
+		return false
+	}
+	delete(p[tool], name)
+	if len(p[tool]) == 0 {
+		delete(p, tool)
+	}
+	return true
+}
+
+// Merge adds the presets of other, returning the ones added and the ones
+// skipped, as "tool/preset", because a preset of that name exists and
+// replace is false.
+func (p ToolPresets) Merge(other ToolPresets, replace bool) (added, skipped []string) {
+	added, skipped = []string{}, []string{}
+	for tool, presets := range other {
+		for name, args := range presets {
+			if _, exists := p.Get(tool, name); exists && !replace {
+				skipped = append(skipped, tool+"/"+name)
+				continue
+			}
+			p.Set(tool, name, args)
+			added = append(added, tool+"/"+name)
+		}
+	}
+	return added, skipped
+}
+
+// Validate checks the names of all presets.
+func (p ToolPresets) Validate() error {
+	for tool, presets := range p {
+		if tool == "" {
+			return fmt.Errorf("presets need a tool name")
+		}
+		for name := range presets {
+			if err := ValidatePresetName(name); err != nil {
+				return fmt.Errorf("%s: %w", tool, err)
+			}
+		}
+	}
+	return nil
+}
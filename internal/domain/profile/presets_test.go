@@ -0,0 +1,58 @@
+package profile_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolPresetsMerge(t *testing.T) {
+	presets := profile.ToolPresets{}
+	presets.Set("search", "rust", map[string]interface{}{"query": "rust"})
+
+	incoming := profile.ToolPresets{}
+	incoming.Set("search", "rust", map[string]interface{}{"query": "rust lang"})
+	incoming.Set("search", "go", map[string]interface{}{"query": "golang"})
+
+	added, skipped := presets.Merge(incoming, false)
+	assert.Equal(t, []string{"search/go"}, added)
+	assert.Equal(t, []string{"search/rust"}, skipped)
+	args, _ := presets.Get("search", "rust")
+	assert.Equal(t, "rust", args["query"], "existing presets are kept")
+
+	added, skipped = presets.Merge(incoming, true)
+	assert.Len(t, added, 2)
+	assert.Empty(t, skipped)
+	args, _ = presets.Get("search", "rust")
+	assert.Equal(t, "rust lang", args["query"])
+
+	assert.True(t, presets.Delete("search", "rust"))
+	assert.False(t, presets.Delete("search", "rust"))
+	assert.True(t, presets.Delete("search", "go"))
+	assert.Empty(t, presets, "tools without presets are dropped")
+}
+
+func TestToolPresetsValidate(t *testing.T) {
+	assert.NoError(t, profile.ToolPresets{"search": {"v1.example": {}}}.Validate())
+	assert.Error(t, profile.ToolPresets{"search": {"../x": {}}}.Validate())
+	assert.Error(t, profile.ToolPresets{"": {"ok": {}}}.Validate())
+}
+
+func TestStoreToolPresets(t *testing.T) {
+	dir := t.TempDir()
+	store := profile.NewStore(filepath.Join(dir, "profiles.yaml"), filepath.Join(dir, "settings.yaml"))
+
+	presets, err := store.LoadToolPresets()
+	require.NoError(t, err)
+	assert.Empty(t, presets)
+
+	presets.Set("search", "rust", map[string]interface{}{"query": "rust"})
+	require.NoError(t, store.SaveToolPresets(presets))
+	loaded, err := store.LoadToolPresets()
+	require.NoError(t, err)
+	assert.Equal(t, presets, loaded)
+	assert.FileExists(t, filepath.Join(dir, "tool-presets.json"))
+}
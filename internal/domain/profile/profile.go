@@ -1,14 +1,28 @@
 package profile
 
-import "errors"
+import (
+	"errors"
+	"path"
+	"regexp"
+	"slices"
+)
+
+// DefaultBuiltinPrefix is the name prefix of Scooter's builtin tools.
+const DefaultBuiltinPrefix = "scooter_"
+
+var builtinPrefixPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{0,31}$`)
 
 // Profile represents an isolated environment for MCP tools.
 type Profile struct {
 	// ID is the unique identifier for the profile (e.g., "work", "personal")
 	ID string `yaml:"id" json:"id"`
 
+	// APIKey optionally guards this profile's gateway endpoints. When set, it replaces
+	// Settings.GatewayAPIKey for requests routed to /profiles/{id}/...
+	APIKey string `yaml:"api_key,omitempty" json:"api_key,omitempty"`
+
 	// RemoteAuthMode determines how to authenticate with remote servers ("oauth2", "none", etc.)
-	// This is NOT for IDE-to-Scooter authentication (use Settings.GatewayAPIKey for that).
+	// This is NOT for IDE-to-Scooter authentication (use APIKey or Settings.GatewayAPIKey for that).
 	RemoteAuthMode string `yaml:"remote_auth_mode" json:"remote_auth_mode"`
 
 	// RemoteServerURL is the URL of the remote MCP server to proxy to.
@@ -19,12 +33,218 @@ type Profile struct {
 	// Use this for tool-specific API keys (e.g., BRAVE_API_KEY, GITHUB_TOKEN).
 	Env map[string]string `yaml:"env" json:"env"`
 
-	// AllowTools is a list of allowed tool names
+	// AllowTools lists the registry entries the profile may use, by name or
+	// by expression (brave-*, category:search, tag:github; see allow.go).
+	// Expressions are resolved against the registry each time they are checked.
 	AllowTools []string `yaml:"allow_tools" json:"allow_tools"`
 
 	// DisabledSystemTools is a list of builtin/system tool names that the user has disabled.
 	// By default, all system tools are enabled. This list tracks which ones are turned off.
 	DisabledSystemTools []string `yaml:"disabled_system_tools" json:"disabled_system_tools"`
+
+	// AutoActivate starts a server in AllowTools the first time one of its tools is
+	// called, instead of requiring scooter_activate first. Off by default to keep
+	// the tool list small.
+	AutoActivate bool `yaml:"auto_activate,omitempty" json:"auto_activate,omitempty"`
+
+	// LogLevels sets the minimum MCP log level (debug, info, notice, warning, error,
+	// critical, alert, emergency) per server name. Server log messages below it
+	// are dropped; servers without an entry log everything.
+	LogLevels map[string]string `yaml:"log_levels,omitempty" json:"log_levels,omitempty"`
+
+	// StrictOutputSchema turns structured results that don't match a tool's outputSchema
+	// into call errors. When false, mismatches are only logged as warnings.
+	StrictOutputSchema bool `yaml:"strict_output_schema" json:"strict_output_schema"`
+
+	// CallHistory enables the scooter_history builtin, which tells agents
+	// which tools they already called in the current session.
+	CallHistory bool `yaml:"call_history,omitempty" json:"call_history,omitempty"`
+
+	// MaxResultBytes is the size budget of a server tool result in bytes of
+	// JSON; 0 means no limit. Larger results are replaced by a truncated copy,
+	// or an AI summary with SummarizeResults, and a handle agents can pass to
+	// scooter_result to read the full payload.
+	MaxResultBytes int `yaml:"max_result_bytes,omitempty" json:"max_result_bytes,omitempty"`
+
+	// SummarizeResults summarises results over MaxResultBytes with the
+	// configured AI provider instead of truncating them.
+	SummarizeResults bool `yaml:"summarize_results,omitempty" json:"summarize_results,omitempty"`
+
+	// MinifySchemas shortens server tool definitions in tools/list to save
+	// context: descriptions are cut to MinifyDescriptionChars (default 120),
+	// long enums and sample inputs are dropped. scooter_expand returns a
+	// tool's full definition.
+	MinifySchemas          bool `yaml:"minify_schemas,omitempty" json:"minify_schemas,omitempty"`
+	MinifyDescriptionChars int  `yaml:"minify_description_chars,omitempty" json:"minify_description_chars,omitempty"`
+
+	// IsolatedCredentials keeps this profile's keychain credentials apart from
+	// other profiles', e.g. to use a different GitHub account at work. The
+	// shared credentials are copied in the first time it is turned on.
+	IsolatedCredentials bool `yaml:"isolated_credentials,omitempty" json:"isolated_credentials,omitempty"`
+
+	// IsolateHome starts every server of the profile with a temporary HOME
+	// and XDG directories of its own, deleted when the server stops, instead
+	// of only servers whose registry entry sets isolateHome.
+	IsolateHome bool `yaml:"isolate_home,omitempty" json:"isolate_home,omitempty"`
+
+	// Hosts are extra host names (e.g. work.internal) whose requests to the
+	// gateway's root /sse and /message routes this profile serves.
+	// <id>.scooter.localhost and <id>.localhost always work.
+	Hosts []string `yaml:"hosts,omitempty" json:"hosts,omitempty"`
+
+	// Identities selects a named credential identity per server name (e.g.
+	// github: work), for tools with several accounts stored. Servers without
+	// an entry use their default credentials.
+	Identities map[string]string `yaml:"identities,omitempty" json:"identities,omitempty"`
+
+	// ToolMigrations keep calls to retired tools working while agents catch
+	// up, e.g. brave_web_search after switching to exa. Each call through a
+	// migration is logged as a warning.
+	ToolMigrations []ToolMigration `yaml:"tool_migrations,omitempty" json:"tool_migrations,omitempty"`
+
+	// BuiltinPrefix replaces "scooter_" in the builtin tool names this profile's
+	// clients see (e.g. "mcp_" gives mcp_find, mcp_add), for clients that also
+	// talk to another gateway with similarly named tools. Empty keeps "scooter_".
+	BuiltinPrefix string `yaml:"builtin_prefix,omitempty" json:"builtin_prefix,omitempty"`
+
+	// RateLimit caps the tool calls of the profile's clients. ToolQuotas cap
+	// single tools or servers (e.g. brave_web_search or github) on top of it.
+	// Calls over a limit fail with a hint of when to retry.
+	RateLimit  RateLimit            `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"`
+	ToolQuotas map[string]RateLimit `yaml:"tool_quotas,omitempty" json:"tool_quotas,omitempty"`
+
+	// Hooks run local commands on the profile's lifecycle events, e.g. to
+	// start a VPN before a server activates. They only run when settings
+	// enable hooks.
+	Hooks []Hook `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+}
+
+// Hook events, besides the event journal's types (server.activated,
+// server.crashed, ...), which run hooks too.
+const (
+	// HookProfileStarted is when Scooter starts serving the profile.
+	HookProfileStarted = "profile.started"
+	// HookServerActivating is before a server starts; a failing hook stops
+	// the activation.
+	HookServerActivating = "server.activating"
+	// HookToolCalled is after a tool call completes.
+	HookToolCalled = "tool.called"
+)
+
+// DefaultHookTimeoutSeconds bounds a hook without a timeout of its own.
+const DefaultHookTimeoutSeconds = 30
+
+// Hook is a command run on profile events. It gets the event in
+// SCOOTER_EVENT, SCOOTER_PROFILE, SCOOTER_SERVER, SCOOTER_TOOL and
+// SCOOTER_ERROR, and its output goes to the log.
+type Hook struct {
+	// Name identifies the hook in logs; it defaults to the command.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Events run the hook, e.g. server.activating or tool.called.
+	Events []string `yaml:"events" json:"events"`
+	// Match limits the hook to events of these server or tool names (globs
+	// such as github* work); empty matches every event.
+	Match   []string `yaml:"match,omitempty" json:"match,omitempty"`
+	Command string   `yaml:"command" json:"command"`
+	Args    []string `yaml:"args,omitempty" json:"args,omitempty"`
+	// Env is added to Scooter's environment; the profile's env is not passed.
+	Env            map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	TimeoutSeconds int               `yaml:"timeout_seconds,omitempty" json:"timeout_seconds,omitempty"`
+}
+
+// DisplayName returns the name of the hook in logs.
+func (h Hook) DisplayName() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	return h.Command
+}
+
+// Matches reports whether the hook runs for event on one of names (the
+// server and tool the event is about; empty for profile events).
+func (h Hook) Matches(event string, names ...string) bool {
+	if !slices.Contains(h.Events, event) {
+		return false
+	}
+	if len(h.Match) == 0 {
+		return true
+	}
+	for _, pattern := range h.Match {
+		for _, name := range names {
+			if ok, _ := path.Match(pattern, name); ok && name != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RateLimit is a number of calls allowed per minute and per hour, both
+// sliding windows; 0 means no limit.
+type RateLimit struct {
+	PerMinute int `yaml:"per_minute,omitempty" json:"per_minute,omitempty"`
+	PerHour   int `yaml:"per_hour,omitempty" json:"per_hour,omitempty"`
+}
+
+// IsZero reports whether the limit allows any number of calls.
+func (l RateLimit) IsZero() bool {
+	return l.PerMinute <= 0 && l.PerHour <= 0
+}
+
+// ToolMigration redirects calls of a retired tool to its replacement.
+type ToolMigration struct {
+	// From is the retired tool name agents still call.
+	From string `yaml:"from" json:"from"`
+	// Server and Tool name the replacement, e.g. exa and web_search_exa.
+	Server string `yaml:"server" json:"server"`
+	Tool   string `yaml:"tool" json:"tool"`
+	// RenameArguments maps old argument names to new ones; an empty new name
+	// drops the argument. Unlisted arguments are passed on unchanged.
+	RenameArguments map[string]string `yaml:"rename_arguments,omitempty" json:"rename_arguments,omitempty"`
+	// SetArguments are added to every call, replacing any the agent sent.
+	SetArguments map[string]interface{} `yaml:"set_arguments,omitempty" json:"set_arguments,omitempty"`
+}
+
+// Apply translates the arguments of a call to m.From into arguments for
+// m.Tool.
+func (m ToolMigration) Apply(args map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(args)+len(m.SetArguments))
+	for k, v := range args {
+		if to, ok := m.RenameArguments[k]; ok {
+			if to == "" {
+				continue
+			}
+			k = to
+		}
+		out[k] = v
+	}
+	for k, v := range m.SetArguments {
+		out[k] = v
+	}
+	return out
+}
+
+// Migration returns the migration for calls to the tool name, if any.
+func (p Profile) Migration(name string) (ToolMigration, bool) {
+	for _, m := range p.ToolMigrations {
+		if m.From == name {
+			return m, true
+		}
+	}
+	return ToolMigration{}, false
+}
+
+// BuiltinToolPrefix returns the prefix of the profile's builtin tool names.
+func (p Profile) BuiltinToolPrefix() string {
+	if p.BuiltinPrefix == "" {
+		return DefaultBuiltinPrefix
+	}
+	return p.BuiltinPrefix
+}
+
+// ValidIdentity reports whether name can be used as a credential identity.
+func ValidIdentity(name string) bool {
+	return profileIDPattern.MatchString(name)
 }
 
 // Validate checks if the profile configuration is valid.
@@ -32,5 +252,8 @@ func (p Profile) Validate() error {
 	if p.ID == "" {
 		return errors.New("profile id is required")
 	}
+	if p.BuiltinPrefix != "" && !builtinPrefixPattern.MatchString(p.BuiltinPrefix) {
+		return errors.New("builtin prefix must start with a letter and contain only letters, digits, '_' and '-'")
+	}
 	return nil
 }
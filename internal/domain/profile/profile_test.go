@@ -51,6 +51,16 @@ func TestProfile_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:    "custom builtin prefix",
+			profile: profile.Profile{ID: "work", BuiltinPrefix: "mcp_"},
+			wantErr: false,
+		},
+		{
+			name:    "invalid builtin prefix",
+			profile: profile.Profile{ID: "work", BuiltinPrefix: "my tools/"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -64,3 +74,15 @@ func TestProfile_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestToolMigrationApply(t *testing.T) {
+	m := profile.ToolMigration{
+		From:            "brave_web_search",
+		Server:          "exa",
+		Tool:            "web_search_exa",
+		RenameArguments: map[string]string{"count": "numResults", "offset": ""},
+		SetArguments:    map[string]interface{}{"type": "auto"},
+	}
+	args := m.Apply(map[string]interface{}{"query": "go", "count": 5.0, "offset": 10.0, "type": "neural"})
+	assert.Equal(t, map[string]interface{}{"query": "go", "numResults": 5.0, "type": "auto"}, args)
+}
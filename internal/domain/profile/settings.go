@@ -2,7 +2,16 @@ package profile
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
 // Settings represents global application configuration.
@@ -13,19 +22,424 @@ type Settings struct {
 	GatewayAPIKey string `yaml:"gateway_api_key" json:"gateway_api_key"`
 	LastProfileID string `yaml:"last_profile_id,omitempty" json:"last_profile_id,omitempty"`
 	VerboseLogging bool `yaml:"verbose_logging" json:"verbose_logging"`
+
+	// ConsoleLogLevel is the lowest level printed to stdout: TRACE, DEBUG,
+	// INFO, WARN or ERROR, or OFF. Empty prints everything. The log file
+	// and the desktop app's log view are not affected.
+	ConsoleLogLevel string `yaml:"console_log_level,omitempty" json:"console_log_level,omitempty"`
+
+	// Locale selects the language of API and builtin tool messages (e.g. "en", "es").
+	// Requests with an Accept-Language header override it.
+	Locale string `yaml:"locale,omitempty" json:"locale,omitempty"`
+
+	// InstanceID namespaces this install's keychain credentials, for several
+	// Scooter instances sharing one OS keychain. Existing credentials are
+	// copied into the namespace the first time it is set.
+	InstanceID string `yaml:"instance_id,omitempty" json:"instance_id,omitempty"`
+
+	// AccessGroups map additional API keys to a subset of profiles and tools.
+	// A request presenting a group key is limited to what the group allows.
+	AccessGroups []AccessGroup `yaml:"access_groups,omitempty" json:"access_groups,omitempty"`
+
+	// ControlTokens grant role-scoped access to the control API. Once one
+	// exists, requests from other machines need a token. They are only
+	// changed through /api/control-tokens, so they are left out of the
+	// settings JSON.
+	ControlTokens []ControlToken `yaml:"control_tokens,omitempty" json:"-"`
+
+	// Notifications show native desktop notifications for critical events,
+	// so they are noticed while the desktop app is closed.
+	Notifications NotificationSettings `yaml:"notifications" json:"notifications"`
+
+	// EnableHooks lets profiles run their hooks, which execute local
+	// commands. Hooks never run unless this is set.
+	EnableHooks bool `yaml:"enable_hooks,omitempty" json:"enable_hooks"`
+
+	// Tunnel exposes one profile through a relay (cmd/scooter-relay), so it
+	// can be reached from another network. Changes apply on restart.
+	Tunnel TunnelSettings `yaml:"tunnel,omitempty" json:"tunnel"`
+
+	// AutoUpdate lets the standalone daemon install new releases in the background.
+	// The desktop app is updated by Tauri and ignores this flag.
+	AutoUpdate bool `yaml:"auto_update" json:"auto_update"`
+
+	// CompressResponses gzips (or deflates) large responses from the control
+	// API and the gateway for clients that accept it. SSE streams are never
+	// compressed.
+	CompressResponses bool `yaml:"compress_responses" json:"compress_responses"`
+
+	// HTTPServer tunes the gateway and control API listeners. Changes take
+	// effect on the next start.
+	HTTPServer HTTPServerSettings `yaml:"http_server" json:"http_server"`
+
+	// CORS decides which web pages may call the control API and the gateway
+	// from a browser. Requests without an Origin header, such as those of
+	// MCP clients and the CLI, are unaffected.
+	CORS CORSSettings `yaml:"cors,omitempty" json:"cors"`
+	// AllowedHosts are host names other than localhost (e.g. scooter-host)
+	// under which other machines reach the control API and the gateway.
+	// Requests for any other name are refused, so that web pages can't
+	// rebind their own name to this machine. IP addresses always work.
+	AllowedHosts []string `yaml:"allowed_hosts,omitempty" json:"allowed_hosts,omitempty"`
 	
 	// Tool lifecycle settings
 	AutoCleanupEnabled  bool   `yaml:"auto_cleanup_enabled" json:"auto_cleanup_enabled"`
 	AutoCleanupMinutes  int    `yaml:"auto_cleanup_minutes" json:"auto_cleanup_minutes"`
 	CleanupOnSession    bool   `yaml:"cleanup_on_session" json:"cleanup_on_session"`
 	MaxActiveServers    int    `yaml:"max_active_servers" json:"max_active_servers"`
+	// ActivationTimeoutSeconds bounds a server's start-up unless its registry
+	// entry sets runtime.timeout. Cold npx caches can need several minutes.
+	ActivationTimeoutSeconds int `yaml:"activation_timeout_seconds" json:"activation_timeout_seconds"`
+	// QuarantineAfterFailures quarantines a registry entry after this many
+	// consecutive failed activations, until it is unquarantined. 0 disables.
+	QuarantineAfterFailures int `yaml:"quarantine_after_failures" json:"quarantine_after_failures"`
+	// MaxRestartsPerHour caps how often a server that crashed or stopped
+	// answering health checks is restarted automatically. 0 disables
+	// automatic restarts.
+	MaxRestartsPerHour int `yaml:"max_restarts_per_hour" json:"max_restarts_per_hour"`
+	// DockerCommand is the container CLI that runs docker packages, e.g.
+	// podman. Empty uses docker.
+	DockerCommand string `yaml:"docker_command,omitempty" json:"docker_command,omitempty"`
+	// ToolContextBudgetTokens is how many tokens of tool definitions agents
+	// should keep in context; scooter_status reports what is left. 0 = no budget.
+	ToolContextBudgetTokens int `yaml:"tool_context_budget_tokens" json:"tool_context_budget_tokens"`
+	// ToolsPageSize splits the gateway's tools/list into pages of this many
+	// tools, fetched with nextCursor. 0 returns every tool at once.
+	ToolsPageSize int `yaml:"tools_page_size" json:"tools_page_size"`
 	QuotaPolicy         string `yaml:"quota_policy" json:"quota_policy"` // "block" or "evict"
+
+	// Per-profile scratch directories (scooter_workspace). 0 disables the limit.
+	WorkspaceQuotaMB        int `yaml:"workspace_quota_mb" json:"workspace_quota_mb"`
+	WorkspaceRetentionHours int `yaml:"workspace_retention_hours" json:"workspace_retention_hours"`
+
+	// ArtifactRetentionHours is how long orphaned artifacts in the app
+	// directory (WASM modules of removed registry entries, homes and
+	// workspaces of deleted profiles) are kept before the daily garbage
+	// collection removes them. 0 disables the daily collection.
+	ArtifactRetentionHours int `yaml:"artifact_retention_hours" json:"artifact_retention_hours"`
+
+	// SSE session limits. A session that posts no messages for
+	// SessionIdleMinutes, or stays open longer than SessionMaxLifetimeHours,
+	// is disconnected; clients reconnect on their own. 0 disables a limit.
+	SessionIdleMinutes      int `yaml:"session_idle_minutes" json:"session_idle_minutes"`
+	SessionMaxLifetimeHours int `yaml:"session_max_lifetime_hours" json:"session_max_lifetime_hours"`
+	// SessionTranscripts records every message of each SSE session, in
+	// order with timings, for GET /api/sessions/{id}/transcript. Transcripts
+	// hold tool arguments and results verbatim, so they are off by default.
+	SessionTranscripts bool `yaml:"session_transcripts" json:"session_transcripts"`
+
+	// HeartbeatSeconds is how often SSE streams get a ": ping" keep-alive
+	// comment. 0 disables keep-alives.
+	HeartbeatSeconds int `yaml:"heartbeat_seconds" json:"heartbeat_seconds"`
+	// LegacyPulseClients lists client names (the clientInfo.name sent in
+	// initialize) that still get the old "pulse" event instead of comments.
+	LegacyPulseClients []string `yaml:"legacy_pulse_clients,omitempty" json:"legacy_pulse_clients,omitempty"`
 	
+	// Onboarding is the first-run wizard's progress. It is only changed
+	// through /api/onboarding/state, so it is left out of the settings JSON.
+	Onboarding OnboardingState `yaml:"onboarding,omitempty" json:"-"`
+
 	// AI routing configuration
 	PrimaryAIProvider   string `yaml:"primary_ai_provider" json:"primary_ai_provider"`
 	PrimaryAIModel      string `yaml:"primary_ai_model" json:"primary_ai_model"`
 	FallbackAIProvider string `yaml:"fallback_ai_provider" json:"fallback_ai_provider"`
 	FallbackAIModel    string `yaml:"fallback_ai_model" json:"fallback_ai_model"`
+
+	// SummaryPrompt instructs the AI provider when summarising results over a
+	// profile's max_result_bytes; empty uses the built-in prompt.
+	SummaryPrompt string `yaml:"summary_prompt,omitempty" json:"summary_prompt,omitempty"`
+	// SummaryMaxTokens caps the length of those summaries.
+	SummaryMaxTokens int `yaml:"summary_max_tokens" json:"summary_max_tokens"`
+}
+
+// HTTPServerSettings configures the gateway and control API HTTP servers.
+// Timeouts are in seconds; 0 disables a timeout or limit.
+type HTTPServerSettings struct {
+	ReadHeaderTimeoutSeconds int `yaml:"read_header_timeout_seconds" json:"read_header_timeout_seconds"`
+	// ReadTimeoutSeconds bounds reading a whole request, body included.
+	ReadTimeoutSeconds int `yaml:"read_timeout_seconds" json:"read_timeout_seconds"`
+	// WriteTimeoutSeconds bounds writing a response. SSE streams are exempt,
+	// so it must cover the slowest tool call instead.
+	WriteTimeoutSeconds int `yaml:"write_timeout_seconds" json:"write_timeout_seconds"`
+	// IdleTimeoutSeconds closes keep-alive connections left unused this long.
+	IdleTimeoutSeconds int `yaml:"idle_timeout_seconds" json:"idle_timeout_seconds"`
+	MaxHeaderBytes     int `yaml:"max_header_bytes" json:"max_header_bytes"`
+	// MaxConnections caps simultaneous connections per server; further
+	// clients wait until one closes.
+	MaxConnections int `yaml:"max_connections" json:"max_connections"`
+
+	// TLSCertFile and TLSKeyFile serve the gateway over HTTPS, which also
+	// enables HTTP/2 so many SSE streams can share one connection.
+	TLSCertFile string `yaml:"tls_cert_file,omitempty" json:"tls_cert_file,omitempty"`
+	TLSKeyFile  string `yaml:"tls_key_file,omitempty" json:"tls_key_file,omitempty"`
+}
+
+// CORSSettings is the cross-origin policy of both servers.
+type CORSSettings struct {
+	// AllowedOrigins are origins such as https://app.example.com. A port of
+	// * matches any port and a lone * any origin. Empty uses
+	// DefaultCORSOrigins.
+	AllowedOrigins []string `yaml:"allowed_origins,omitempty" json:"allowed_origins,omitempty"`
+	// AllowCredentials lets allowed pages send cookies and Authorization
+	// headers. It can't be combined with *.
+	AllowCredentials bool `yaml:"allow_credentials,omitempty" json:"allow_credentials"`
+	// MaxAgeSeconds is how long browsers may cache a preflight; 0 means 600.
+	MaxAgeSeconds int `yaml:"max_age_seconds,omitempty" json:"max_age_seconds,omitempty"`
+}
+
+// DefaultCORSOrigins are Scooter Desktop's origins on each platform, and
+// pages served from this machine.
+var DefaultCORSOrigins = []string{
+	"tauri://localhost",
+	"http://tauri.localhost",
+	"https://tauri.localhost",
+	"http://localhost:*",
+	"http://127.0.0.1:*",
+}
+
+// Origins returns the allowed origins.
+func (c CORSSettings) Origins() []string {
+	if len(c.AllowedOrigins) == 0 {
+		return DefaultCORSOrigins
+	}
+	return c.AllowedOrigins
+}
+
+// MaxAge returns how many seconds browsers may cache a preflight.
+func (c CORSSettings) MaxAge() int {
+	if c.MaxAgeSeconds == 0 {
+		return 600
+	}
+	return c.MaxAgeSeconds
+}
+
+// AllowsOrigin reports whether a page of origin may call Scooter.
+func (c CORSSettings) AllowsOrigin(origin string) bool {
+	for _, allowed := range c.Origins() {
+		if MatchOrigin(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchOrigin reports whether origin matches the pattern of an allowed
+// origin. Scheme and host compare case-insensitively.
+func MatchOrigin(pattern, origin string) bool {
+	if pattern == "*" {
+		return true
+	}
+	pattern, origin = strings.ToLower(pattern), strings.ToLower(origin)
+	if base, ok := strings.CutSuffix(pattern, ":*"); ok {
+		if origin == base {
+			return true
+		}
+		port, ok := strings.CutPrefix(origin, base+":")
+		if !ok || port == "" {
+			return false
+		}
+		for _, c := range port {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+		return true
+	}
+	return pattern == origin
+}
+
+// ValidateCORSOrigin returns why pattern can't be an allowed origin, or "".
+func ValidateCORSOrigin(pattern string) string {
+	if pattern == "*" {
+		return ""
+	}
+	u, err := url.Parse(strings.TrimSuffix(pattern, ":*"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Sprintf("%q is not an origin such as https://example.com", pattern)
+	}
+	if u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return fmt.Sprintf("%q must be only a scheme, host and port", pattern)
+	}
+	return ""
+}
+
+// Validate checks the allowed origins and the preflight cache time.
+func (c CORSSettings) Validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if msg := ValidateCORSOrigin(origin); msg != "" {
+			return errors.New(msg)
+		}
+		if origin == "*" && c.AllowCredentials {
+			return errors.New("allow_credentials can't be combined with the * origin")
+		}
+	}
+	if c.MaxAgeSeconds < 0 {
+		return errors.New("max_age_seconds must not be negative")
+	}
+	return nil
+}
+
+// NotificationSettings selects the event journal entries that show a
+// desktop notification.
+type NotificationSettings struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Events are event types such as server.crashed. Empty uses
+	// DefaultNotificationEvents.
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"`
+}
+
+// DefaultNotificationEvents are the event types that notify unless the
+// settings list others.
+var DefaultNotificationEvents = []string{"server.crashed", "server.quarantined"}
+
+// EventTypes returns the event types that notify.
+func (n NotificationSettings) EventTypes() []string {
+	if len(n.Events) == 0 {
+		return DefaultNotificationEvents
+	}
+	return n.Events
+}
+
+// TunnelSettings connect Scooter to a relay. The profile is only served
+// with an API key, its own or gateway_api_key.
+type TunnelSettings struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// RelayURL is the relay's https URL, e.g. https://relay.example.com:8443.
+	RelayURL string `yaml:"relay_url" json:"relay_url"`
+	// Token is the secret the relay was started with.
+	Token   string `yaml:"token" json:"token"`
+	Profile string `yaml:"profile" json:"profile"`
+	// CAFile trusts a self-signed relay certificate; empty uses the system's
+	// CAs.
+	CAFile string `yaml:"ca_file,omitempty" json:"ca_file,omitempty"`
+	// Connections is how many clients can connect at the same moment; 0
+	// means 4.
+	Connections int `yaml:"connections,omitempty" json:"connections,omitempty"`
+}
+
+// AccessGroup grants the holder of APIKey access to some profiles and tools.
+// Empty Profiles or Tools lists mean "all".
+type AccessGroup struct {
+	Name     string   `yaml:"name" json:"name"`
+	APIKey   string   `yaml:"api_key" json:"api_key"`
+	Profiles []string `yaml:"profiles,omitempty" json:"profiles,omitempty"`
+	// Tools lists registry server names or individual tool names.
+	Tools []string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	// Control allows the key to be used against the control API.
+	Control bool `yaml:"control" json:"control"`
+	// ControlRole is what the key may do there: viewer (the default) or
+	// operator. Settings, tokens and credentials concern every profile, so
+	// a group can't be an admin.
+	ControlRole ControlRole `yaml:"control_role,omitempty" json:"control_role,omitempty"`
+	// DefaultProfile serves the key's requests to the root /sse and /message
+	// routes, for clients that can't put a profile in the URL path.
+	DefaultProfile string `yaml:"default_profile,omitempty" json:"default_profile,omitempty"`
+}
+
+// Role returns the group's role on the control API.
+func (g AccessGroup) Role() ControlRole {
+	if g.ControlRole == RoleOperator {
+		return RoleOperator
+	}
+	return RoleViewer
+}
+
+// AllowsProfile reports whether the group may use the given profile.
+func (g AccessGroup) AllowsProfile(id string) bool {
+	return len(g.Profiles) == 0 || contains(g.Profiles, id)
+}
+
+// AllowsTool reports whether the group may use a tool. Either the tool name or
+// the name of the server providing it must be listed.
+func (g AccessGroup) AllowsTool(toolName, serverName string) bool {
+	if len(g.Tools) == 0 {
+		return true
+	}
+	return contains(g.Tools, toolName) || (serverName != "" && contains(g.Tools, serverName))
+}
+
+// ControlRole is what a control token may do. Each role includes the ones
+// below it.
+type ControlRole string
+
+const (
+	// RoleViewer reads status, logs, events and the catalog.
+	RoleViewer ControlRole = "viewer"
+	// RoleOperator also activates and calls tools, reloads and ends sessions.
+	RoleOperator ControlRole = "operator"
+	// RoleAdmin also changes profiles and settings, manages credentials and
+	// control tokens, and reads secrets.
+	RoleAdmin ControlRole = "admin"
+)
+
+var controlRoleRank = map[ControlRole]int{RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3}
+
+// Valid reports whether r is a known role.
+func (r ControlRole) Valid() bool {
+	return controlRoleRank[r] > 0
+}
+
+// Allows reports whether r may do what required may.
+func (r ControlRole) Allows(required ControlRole) bool {
+	return r.Valid() && controlRoleRank[r] >= controlRoleRank[required]
+}
+
+// ControlToken grants its holder Role on the control API. A token with a
+// User only reaches that user's profiles, credentials and engines.
+type ControlToken struct {
+	Name      string      `yaml:"name" json:"name"`
+	Token     string      `yaml:"token" json:"token"`
+	Role      ControlRole `yaml:"role" json:"role"`
+	User      string      `yaml:"user,omitempty" json:"user,omitempty"`
+	CreatedAt time.Time   `yaml:"created_at" json:"created_at"`
+}
+
+// userNamePattern is what user names look like; they name directories.
+var userNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ValidUserName reports whether name can name a user of a shared daemon.
+func ValidUserName(name string) bool {
+	return userNamePattern.MatchString(name)
+}
+
+// HasUser reports whether a control token is scoped to user.
+func (s Settings) HasUser(user string) bool {
+	return user != "" && slices.ContainsFunc(s.ControlTokens, func(t ControlToken) bool { return t.User == user })
+}
+
+// ControlTokenFor returns the control token matching token, if any.
+func (s Settings) ControlTokenFor(token string) (ControlToken, bool) {
+	if token == "" {
+		return ControlToken{}, false
+	}
+	for _, t := range s.ControlTokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			return t, true
+		}
+	}
+	return ControlToken{}, false
+}
+
+// AccessGroupForKey returns the access group that owns key, if any.
+func (s Settings) AccessGroupForKey(key string) (AccessGroup, bool) {
+	if key == "" {
+		return AccessGroup{}, false
+	}
+	for _, g := range s.AccessGroups {
+		if subtle.ConstantTimeCompare([]byte(g.APIKey), []byte(key)) == 1 {
+			return g, true
+		}
+	}
+	return AccessGroup{}, false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
 
 // DefaultSettings returns the standard port configuration.
@@ -38,7 +452,30 @@ func DefaultSettings() Settings {
 		AutoCleanupMinutes: 10,
 		CleanupOnSession:   false,
 		MaxActiveServers:   5,
+		ActivationTimeoutSeconds: 60,
+		QuarantineAfterFailures:  3,
+		MaxRestartsPerHour:       5,
+		ToolContextBudgetTokens:  20000,
 		QuotaPolicy:        "evict",
+
+		WorkspaceQuotaMB:        1024,
+		WorkspaceRetentionHours: 24,
+		ArtifactRetentionHours:  7 * 24,
+
+		SessionIdleMinutes:      60,
+		SessionMaxLifetimeHours: 24,
+		HeartbeatSeconds:        30,
+
+		SummaryMaxTokens: 500,
+
+		HTTPServer: HTTPServerSettings{
+			ReadHeaderTimeoutSeconds: 10,
+			ReadTimeoutSeconds:       60,
+			WriteTimeoutSeconds:      300,
+			IdleTimeoutSeconds:       120,
+			MaxHeaderBytes:           64 << 10,
+			MaxConnections:           256,
+		},
 	}
 }
 
@@ -48,3 +485,51 @@ func GenerateAPIKey() string {
 	rand.Read(b)
 	return "sk-scooter-" + base64.RawURLEncoding.EncodeToString(b)
 }
+
+// SettingsProvider is the single, concurrency-safe source of the live Settings.
+// The control API, MCP gateway and discovery engines share one provider so an
+// update made through any of them is visible everywhere immediately.
+type SettingsProvider struct {
+	mu          sync.RWMutex
+	settings    Settings
+	subscribers []func(Settings)
+}
+
+// NewSettingsProvider creates a provider holding the given settings.
+func NewSettingsProvider(settings Settings) *SettingsProvider {
+	return &SettingsProvider{settings: settings}
+}
+
+// Get returns a copy of the current settings.
+func (p *SettingsProvider) Get() Settings {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.settings
+}
+
+// Set replaces the settings and notifies subscribers.
+func (p *SettingsProvider) Set(settings Settings) {
+	p.Update(func(s *Settings) { *s = settings })
+}
+
+// Update applies fn to the settings atomically, notifies subscribers and
+// returns the new value.
+func (p *SettingsProvider) Update(fn func(*Settings)) Settings {
+	p.mu.Lock()
+	fn(&p.settings)
+	updated := p.settings
+	subscribers := append([]func(Settings){}, p.subscribers...)
+	p.mu.Unlock()
+
+	for _, sub := range subscribers {
+		sub(updated)
+	}
+	return updated
+}
+
+// Subscribe registers fn to be called with the new settings after every change.
+func (p *SettingsProvider) Subscribe(fn func(Settings)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subscribers = append(p.subscribers, fn)
+}
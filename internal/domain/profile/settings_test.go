@@ -0,0 +1,66 @@
+package profile_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsProvider_UpdateNotifiesSubscribers(t *testing.T) {
+	provider := profile.NewSettingsProvider(profile.DefaultSettings())
+
+	var seen []int
+	provider.Subscribe(func(s profile.Settings) {
+		seen = append(seen, s.MaxActiveServers)
+	})
+
+	updated := provider.Update(func(s *profile.Settings) { s.MaxActiveServers = 9 })
+	assert.Equal(t, 9, updated.MaxActiveServers)
+	assert.Equal(t, 9, provider.Get().MaxActiveServers)
+
+	provider.Set(profile.DefaultSettings())
+	assert.Equal(t, []int{9, 5}, seen)
+}
+
+func TestSettingsProvider_ConcurrentAccess(t *testing.T) {
+	provider := profile.NewSettingsProvider(profile.DefaultSettings())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			provider.Update(func(s *profile.Settings) { s.AutoCleanupMinutes++ })
+		}()
+		go func() {
+			defer wg.Done()
+			_ = provider.Get().AutoCleanupMinutes
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, profile.DefaultSettings().AutoCleanupMinutes+50, provider.Get().AutoCleanupMinutes)
+}
+
+func TestCORSSettings(t *testing.T) {
+	var defaults profile.CORSSettings
+	assert.True(t, defaults.AllowsOrigin("tauri://localhost"))
+	assert.True(t, defaults.AllowsOrigin("http://localhost"))
+	assert.True(t, defaults.AllowsOrigin("http://127.0.0.1:5173"))
+	assert.False(t, defaults.AllowsOrigin("http://localhost:80.evil.example"))
+	assert.False(t, defaults.AllowsOrigin("https://example.com"))
+	assert.Equal(t, 600, defaults.MaxAge())
+
+	assert.True(t, profile.MatchOrigin("*", "https://example.com"))
+	assert.True(t, profile.MatchOrigin("https://App.example.com", "https://app.example.com"))
+	assert.False(t, profile.MatchOrigin("https://app.example.com", "https://app.example.com:8443"))
+
+	assert.NoError(t, profile.CORSSettings{AllowedOrigins: []string{"https://app.example.com", "http://localhost:*"}}.Validate())
+	assert.NoError(t, profile.CORSSettings{AllowedOrigins: []string{"*"}}.Validate())
+	assert.Error(t, profile.CORSSettings{AllowedOrigins: []string{"*"}, AllowCredentials: true}.Validate())
+	assert.Error(t, profile.CORSSettings{AllowedOrigins: []string{"localhost:3000"}}.Validate())
+	assert.Error(t, profile.CORSSettings{AllowedOrigins: []string{"https://example.com/app"}}.Validate())
+	assert.Error(t, profile.CORSSettings{MaxAgeSeconds: -1}.Validate())
+}
@@ -12,6 +12,9 @@ import (
 type Store struct {
 	profilesPath string
 	settingsPath string
+	// dataDir holds the tool parameters and presets; the settings' directory
+	// unless SetDataDir changed it
+	dataDir string
 }
 
 // GetProfilesPath returns the path to the profiles file.
@@ -24,6 +27,20 @@ func (s *Store) GetSettingsPath() string {
 	return s.settingsPath
 }
 
+// SetDataDir keeps the store's tool parameters and presets in dir instead
+// of next to the settings, e.g. for a user sharing the owner's settings.
+func (s *Store) SetDataDir(dir string) {
+	s.dataDir = dir
+}
+
+// GetDataDir returns the directory of the tool parameters and presets.
+func (s *Store) GetDataDir() string {
+	if s.dataDir != "" {
+		return s.dataDir
+	}
+	return filepath.Dir(s.settingsPath)
+}
+
 // ProfilesConfig is for the profiles.yaml file.
 type ProfilesConfig struct {
 	Profiles []Profile `yaml:"profiles"`
@@ -34,6 +51,19 @@ type SettingsConfig struct {
 	Settings Settings `yaml:"settings"`
 }
 
+// ConfigDir returns the Scooter configuration directory. SCOOTER_CONFIG_DIR
+// overrides the platform default.
+func ConfigDir() string {
+	if dir := os.Getenv("SCOOTER_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		configDir = "."
+	}
+	return filepath.Join(configDir, "mcp-scooter")
+}
+
 // NewStore creates a new profile store with separate paths for profiles and settings.
 func NewStore(profilesPath, settingsPath string) *Store {
 	return &Store{
@@ -142,15 +172,14 @@ func (s *Store) Save(profiles []Profile, settings Settings) error {
 	return s.SaveSettings(settings)
 }
 
-// getToolParamsPath returns the path to the tool-params.json file.
-func (s *Store) getToolParamsPath() string {
-	dir := filepath.Dir(s.settingsPath)
-	return filepath.Join(dir, "tool-params.json")
+// GetToolParamsPath returns the path to the tool-params.json file.
+func (s *Store) GetToolParamsPath() string {
+	return filepath.Join(s.GetDataDir(), "tool-params.json")
 }
 
 // LoadToolParams reads saved tool test parameters from tool-params.json.
 func (s *Store) LoadToolParams() (map[string]map[string]interface{}, error) {
-	data, err := os.ReadFile(s.getToolParamsPath())
+	data, err := os.ReadFile(s.GetToolParamsPath())
 	if err != nil {
 		return nil, err
 	}
@@ -170,5 +199,40 @@ func (s *Store) SaveToolParams(params map[string]map[string]interface{}) error {
 		return err
 	}
 
-	return os.WriteFile(s.getToolParamsPath(), bytes, 0644)
+	return os.WriteFile(s.GetToolParamsPath(), bytes, 0644)
+}
+
+// GetToolPresetsPath returns the path to the tool-presets.json file.
+func (s *Store) GetToolPresetsPath() string {
+	return filepath.Join(s.GetDataDir(), "tool-presets.json")
+}
+
+// LoadToolPresets reads the tool parameter presets from tool-presets.json.
+// A missing file has no presets.
+func (s *Store) LoadToolPresets() (ToolPresets, error) {
+	presets := ToolPresets{}
+	data, err := os.ReadFile(s.GetToolPresetsPath())
+	if os.IsNotExist(err) {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, err
+	}
+	if presets == nil {
+		presets = ToolPresets{}
+	}
+	return presets, nil
+}
+
+// SaveToolPresets writes the tool parameter presets to tool-presets.json.
+func (s *Store) SaveToolPresets(presets ToolPresets) error {
+	bytes, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.GetToolPresetsPath(), bytes, 0644)
 }
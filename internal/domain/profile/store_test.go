@@ -48,3 +48,45 @@ func TestStore_LoadNonExistent(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Empty(t, loadedProfiles)
 }
+
+func TestStore_Bootstrap(t *testing.T) {
+	dir := t.TempDir()
+	store := profile.NewStore(filepath.Join(dir, "profiles.yaml"), filepath.Join(dir, "settings.yaml"))
+
+	seeded, err := store.Bootstrap([]string{"PATH=/bin", "SCOOTER_CONFIG_DIR=/tmp"})
+	require.NoError(t, err)
+	assert.False(t, seeded, "no configuration variables")
+
+	seeded, err = store.Bootstrap([]string{
+		`SCOOTER_CONFIG_JSON={"profiles":[{"id":"ci","allow_tools":["brave-search"]}],"settings":{"mcp_port":7000}}`,
+		"SCOOTER_GATEWAY_API_KEY=secret",
+		"SCOOTER_VERBOSE_LOGGING=true",
+		"SCOOTER_PROFILE_WORK_ALLOW_TOOLS=github, jira",
+		"SCOOTER_PROFILE_WORK_ENV_GITHUB_TOKEN=ghp_x",
+		"SCOOTER_PROFILE_CI_AUTO_ACTIVATE=1",
+	})
+	require.NoError(t, err)
+	assert.True(t, seeded)
+
+	profiles, settings, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "secret", settings.GatewayAPIKey)
+	assert.True(t, settings.VerboseLogging)
+	assert.Equal(t, 7000, settings.McpPort)
+	assert.Equal(t, profile.DefaultSettings().ControlPort, settings.ControlPort)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, "ci", profiles[0].ID)
+	assert.Equal(t, []string{"brave-search"}, profiles[0].AllowTools)
+	assert.True(t, profiles[0].AutoActivate)
+	assert.Equal(t, "work", profiles[1].ID)
+	assert.Equal(t, []string{"github", "jira"}, profiles[1].AllowTools)
+	assert.Equal(t, "ghp_x", profiles[1].Env["GITHUB_TOKEN"])
+
+	// Existing files are never overwritten
+	seeded, err = store.Bootstrap([]string{"SCOOTER_GATEWAY_API_KEY=other"})
+	require.NoError(t, err)
+	assert.False(t, seeded)
+
+	_, err = profile.NewStore(filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml")).Bootstrap([]string{"SCOOTER_MCP_PORT=abc"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,626 @@
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/mcp-scooter/scooter/internal/i18n"
+	"github.com/mcp-scooter/scooter/internal/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigIssue is a single problem found in profiles.yaml or settings.yaml.
+type ConfigIssue struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+	// Suggestions are known names an unknown one may be a typo of.
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+func (i ConfigIssue) String() string {
+	loc := filepath.Base(i.File)
+	if i.Line > 0 {
+		loc += ":" + strconv.Itoa(i.Line)
+	}
+	msg := loc + ": "
+	if i.Field != "" {
+		msg += i.Field + ": "
+	}
+	msg += i.Message
+	if i.Hint != "" {
+		msg += " (" + i.Hint + ")"
+	}
+	return msg
+}
+
+// ConfigReport holds the result of validating the configuration files.
+type ConfigReport struct {
+	Valid    bool          `json:"valid"`
+	Errors   []ConfigIssue `json:"errors,omitempty"`
+	Warnings []ConfigIssue `json:"warnings,omitempty"`
+}
+
+func (r *ConfigReport) addError(issue ConfigIssue) {
+	r.Errors = append(r.Errors, issue)
+	r.Valid = false
+}
+
+func (r *ConfigReport) addWarning(issue ConfigIssue) {
+	r.Warnings = append(r.Warnings, issue)
+}
+
+// Err returns nil if the report passes, or an error summarising every issue.
+// In strict mode warnings also fail the check.
+func (r *ConfigReport) Err(strict bool) error {
+	issues := r.Errors
+	if strict {
+		issues = append(append([]ConfigIssue{}, r.Errors...), r.Warnings...)
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	lines := make([]string, len(issues))
+	for i, issue := range issues {
+		lines[i] = "  " + issue.String()
+	}
+	return fmt.Errorf("invalid configuration:\n%s", strings.Join(lines, "\n"))
+}
+
+var (
+	profileIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	yamlLinePattern  = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+)
+
+// Validate checks profiles.yaml and settings.yaml without loading them into the
+// running configuration. knownServers is the set of registry entry names used to
+// flag AllowTools entries that don't exist; pass nil to skip that check.
+func (s *Store) Validate(knownServers map[string]bool) *ConfigReport {
+	report := &ConfigReport{Valid: true}
+
+	profileIDs := s.validateProfiles(report, knownServers)
+	s.validateSettings(report, profileIDs)
+
+	return report
+}
+
+func (s *Store) validateProfiles(report *ConfigReport, knownServers map[string]bool) map[string]bool {
+	ids := map[string]bool{}
+	data, err := os.ReadFile(s.profilesPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			report.addError(ConfigIssue{File: s.profilesPath, Message: err.Error()})
+		}
+		return ids
+	}
+
+	// Older versions stored settings alongside profiles; Load still accepts that
+	var config struct {
+		Profiles []Profile `yaml:"profiles"`
+		Settings *Settings `yaml:"settings"`
+	}
+	if !decodeStrict(data, &config, s.profilesPath, report) {
+		return ids
+	}
+	if config.Settings != nil {
+		report.addWarning(ConfigIssue{
+			File:    s.profilesPath,
+			Field:   "settings",
+			Message: "settings in profiles.yaml are deprecated",
+			Hint:    "move them to settings.yaml",
+		})
+	}
+
+	hosts := map[string]string{} // host -> profile id
+	for i, p := range config.Profiles {
+		field := fmt.Sprintf("profiles[%d]", i)
+		if p.ID == "" {
+			report.addError(ConfigIssue{File: s.profilesPath, Field: field + ".id", Message: "profile id is required"})
+			continue
+		}
+		field = fmt.Sprintf("profiles[%d] (%s)", i, p.ID)
+
+		if ids[p.ID] {
+			report.addError(ConfigIssue{
+				File:    s.profilesPath,
+				Field:   field + ".id",
+				Message: fmt.Sprintf("duplicate profile id %q", p.ID),
+				Hint:    "profile ids must be unique",
+			})
+		}
+		ids[p.ID] = true
+
+		if !profileIDPattern.MatchString(p.ID) {
+			report.addWarning(ConfigIssue{
+				File:    s.profilesPath,
+				Field:   field + ".id",
+				Message: "profile id contains characters that are not URL-safe",
+				Hint:    "use letters, digits, '-' and '_' so /profiles/{id}/sse works in every client",
+			})
+		}
+
+		if p.BuiltinPrefix != "" && !builtinPrefixPattern.MatchString(p.BuiltinPrefix) {
+			report.addError(ConfigIssue{
+				File:    s.profilesPath,
+				Field:   field + ".builtin_prefix",
+				Message: fmt.Sprintf("invalid builtin prefix %q", p.BuiltinPrefix),
+				Hint:    "start with a letter and use letters, digits, '_' and '-' (at most 32 characters)",
+			})
+		}
+
+		for _, host := range p.Hosts {
+			host = strings.ToLower(host)
+			if owner, ok := hosts[host]; ok && owner != p.ID {
+				report.addError(ConfigIssue{
+					File:    s.profilesPath,
+					Field:   field + ".hosts",
+					Message: fmt.Sprintf("host %q is already used by profile %q", host, owner),
+					Hint:    "each host name can route to one profile only",
+				})
+			}
+			hosts[host] = p.ID
+		}
+
+		if p.MaxResultBytes < 0 {
+			report.addError(ConfigIssue{File: s.profilesPath, Field: field + ".max_result_bytes", Message: "must not be negative"})
+		} else if p.SummarizeResults && p.MaxResultBytes == 0 {
+			report.addWarning(ConfigIssue{
+				File:    s.profilesPath,
+				Field:   field + ".summarize_results",
+				Message: "has no effect without max_result_bytes",
+				Hint:    "set max_result_bytes to the largest result to pass through unchanged",
+			})
+		}
+
+		if p.MinifyDescriptionChars < 0 {
+			report.addError(ConfigIssue{File: s.profilesPath, Field: field + ".minify_description_chars", Message: "must not be negative"})
+		}
+
+		checkRateLimit := func(field string, l RateLimit) {
+			if l.PerMinute < 0 || l.PerHour < 0 {
+				report.addError(ConfigIssue{File: s.profilesPath, Field: field, Message: "per_minute and per_hour must not be negative"})
+			}
+		}
+		checkRateLimit(field+".rate_limit", p.RateLimit)
+		for name, quota := range p.ToolQuotas {
+			checkRateLimit(fmt.Sprintf("%s.tool_quotas.%s", field, name), quota)
+		}
+
+		for j, h := range p.Hooks {
+			hField := fmt.Sprintf("%s.hooks[%d]", field, j)
+			if h.Command == "" {
+				report.addError(ConfigIssue{File: s.profilesPath, Field: hField + ".command", Message: "command is required"})
+			}
+			if len(h.Events) == 0 {
+				report.addError(ConfigIssue{File: s.profilesPath, Field: hField + ".events", Message: "at least one event is required"})
+			}
+			for _, event := range h.Events {
+				if !slices.Contains(events.Types, event) && event != HookProfileStarted && event != HookServerActivating && event != HookToolCalled {
+					report.addError(ConfigIssue{
+						File:    s.profilesPath,
+						Field:   hField + ".events",
+						Message: fmt.Sprintf("unknown event %q", event),
+						Hint:    fmt.Sprintf("use %s, %s, %s or an event journal type", HookProfileStarted, HookServerActivating, HookToolCalled),
+					})
+				}
+			}
+			for _, pattern := range h.Match {
+				if _, err := path.Match(pattern, ""); err != nil {
+					report.addError(ConfigIssue{File: s.profilesPath, Field: hField + ".match", Message: fmt.Sprintf("invalid pattern %q", pattern)})
+				}
+			}
+			if h.TimeoutSeconds < 0 {
+				report.addError(ConfigIssue{File: s.profilesPath, Field: hField + ".timeout_seconds", Message: "must not be negative"})
+			}
+		}
+
+		for tool, identity := range p.Identities {
+			if !ValidIdentity(identity) {
+				report.addError(ConfigIssue{
+					File:    s.profilesPath,
+					Field:   fmt.Sprintf("%s.identities.%s", field, tool),
+					Message: fmt.Sprintf("invalid credential identity %q", identity),
+					Hint:    "use letters, digits, '-' and '_'",
+				})
+			}
+		}
+
+		migrated := map[string]bool{}
+		for j, m := range p.ToolMigrations {
+			mField := fmt.Sprintf("%s.tool_migrations[%d]", field, j)
+			switch {
+			case m.From == "" || m.Server == "" || m.Tool == "":
+				report.addError(ConfigIssue{File: s.profilesPath, Field: mField, Message: "from, server and tool are required"})
+			case m.From == m.Tool:
+				report.addError(ConfigIssue{File: s.profilesPath, Field: mField, Message: fmt.Sprintf("%q migrates to itself", m.From)})
+			case migrated[m.From]:
+				report.addError(ConfigIssue{
+					File:    s.profilesPath,
+					Field:   mField + ".from",
+					Message: fmt.Sprintf("%q has more than one migration", m.From),
+				})
+			case knownServers != nil && !knownServers[m.Server]:
+				// Reported with the other unknown names below
+			case !slices.ContainsFunc(p.AllowTools, func(entry string) bool {
+				// Categories and tags can't be checked without the registry
+				return MatchAllowEntry(entry, m.Server, "", nil) || strings.Contains(entry, ":")
+			}):
+				report.addWarning(ConfigIssue{
+					File:    s.profilesPath,
+					Field:   mField + ".server",
+					Message: fmt.Sprintf("%q is not in allow_tools, so migrated calls will be refused", m.Server),
+				})
+			}
+			migrated[m.From] = true
+		}
+
+		seen := map[string]bool{}
+		for _, tool := range p.AllowTools {
+			if seen[tool] {
+				report.addWarning(ConfigIssue{
+					File:    s.profilesPath,
+					Field:   field + ".allow_tools",
+					Message: fmt.Sprintf("%q is listed more than once", tool),
+				})
+			}
+			seen[tool] = true
+		}
+
+		if knownServers != nil {
+			for _, issue := range p.LintServers(knownServers) {
+				issue.File = s.profilesPath
+				issue.Field = field + "." + issue.Field
+				report.addWarning(issue)
+			}
+		}
+	}
+
+	return ids
+}
+
+func (s *Store) validateSettings(report *ConfigReport, profileIDs map[string]bool) {
+	data, err := os.ReadFile(s.settingsPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			report.addError(ConfigIssue{File: s.settingsPath, Message: err.Error()})
+		}
+		return
+	}
+
+	config := SettingsConfig{Settings: DefaultSettings()}
+	if !decodeStrict(data, &config, s.settingsPath, report) {
+		return
+	}
+	settings := config.Settings
+
+	// A port of 0 means "use the default" (see Load)
+	checkPort := func(field string, port int) {
+		if port < 0 || port > 65535 {
+			report.addError(ConfigIssue{
+				File:    s.settingsPath,
+				Field:   "settings." + field,
+				Message: fmt.Sprintf("port %d is out of range", port),
+				Hint:    "use a port between 1024 and 65535",
+			})
+		} else if port > 0 && port < 1024 {
+			report.addWarning(ConfigIssue{
+				File:    s.settingsPath,
+				Field:   "settings." + field,
+				Message: fmt.Sprintf("port %d is privileged and may need elevated permissions", port),
+			})
+		}
+	}
+	checkPort("control_port", settings.ControlPort)
+	checkPort("mcp_port", settings.McpPort)
+	if settings.ControlPort != 0 && settings.ControlPort == settings.McpPort {
+		report.addError(ConfigIssue{
+			File:    s.settingsPath,
+			Field:   "settings.mcp_port",
+			Message: fmt.Sprintf("control_port and mcp_port are both %d", settings.McpPort),
+			Hint:    "the control API and MCP gateway need separate ports",
+		})
+	}
+
+	if settings.QuotaPolicy != "" && settings.QuotaPolicy != "block" && settings.QuotaPolicy != "evict" {
+		report.addError(ConfigIssue{
+			File:    s.settingsPath,
+			Field:   "settings.quota_policy",
+			Message: fmt.Sprintf("unknown quota policy %q", settings.QuotaPolicy),
+			Hint:    `use "block" or "evict"`,
+		})
+	}
+	if settings.MaxActiveServers < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.max_active_servers", Message: "must not be negative"})
+	}
+	if settings.ActivationTimeoutSeconds < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.activation_timeout_seconds", Message: "must not be negative"})
+	}
+	if settings.QuarantineAfterFailures < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.quarantine_after_failures", Message: "must not be negative"})
+	}
+	if settings.ToolContextBudgetTokens < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.tool_context_budget_tokens", Message: "must not be negative"})
+	}
+	if settings.ToolsPageSize < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.tools_page_size", Message: "must not be negative"})
+	}
+	if settings.MaxRestartsPerHour < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.max_restarts_per_hour", Message: "must not be negative"})
+	}
+	if settings.WorkspaceQuotaMB < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.workspace_quota_mb", Message: "must not be negative"})
+	}
+	if settings.WorkspaceRetentionHours < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.workspace_retention_hours", Message: "must not be negative"})
+	}
+	if settings.ArtifactRetentionHours < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.artifact_retention_hours", Message: "must not be negative"})
+	}
+	if settings.SessionIdleMinutes < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.session_idle_minutes", Message: "must not be negative"})
+	}
+	if settings.SessionMaxLifetimeHours < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.session_max_lifetime_hours", Message: "must not be negative"})
+	}
+	if settings.HeartbeatSeconds < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.heartbeat_seconds", Message: "must not be negative"})
+	}
+	for _, limit := range []struct {
+		field string
+		value int
+	}{
+		{"read_header_timeout_seconds", settings.HTTPServer.ReadHeaderTimeoutSeconds},
+		{"read_timeout_seconds", settings.HTTPServer.ReadTimeoutSeconds},
+		{"write_timeout_seconds", settings.HTTPServer.WriteTimeoutSeconds},
+		{"idle_timeout_seconds", settings.HTTPServer.IdleTimeoutSeconds},
+		{"max_header_bytes", settings.HTTPServer.MaxHeaderBytes},
+		{"max_connections", settings.HTTPServer.MaxConnections},
+	} {
+		if limit.value < 0 {
+			report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.http_server." + limit.field, Message: "must not be negative"})
+		}
+	}
+	if (settings.HTTPServer.TLSCertFile == "") != (settings.HTTPServer.TLSKeyFile == "") {
+		report.addError(ConfigIssue{
+			File:    s.settingsPath,
+			Field:   "settings.http_server",
+			Message: "tls_cert_file and tls_key_file must be set together",
+		})
+	}
+	if err := settings.CORS.Validate(); err != nil {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.cors", Message: err.Error()})
+	}
+	if settings.SummaryMaxTokens < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.summary_max_tokens", Message: "must not be negative"})
+	}
+	if settings.AutoCleanupMinutes < 0 {
+		report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.auto_cleanup_minutes", Message: "must not be negative"})
+	} else if settings.AutoCleanupEnabled && settings.AutoCleanupMinutes == 0 {
+		report.addWarning(ConfigIssue{
+			File:    s.settingsPath,
+			Field:   "settings.auto_cleanup_minutes",
+			Message: "auto cleanup is enabled with a 0 minute timeout",
+			Hint:    "set a timeout or disable auto_cleanup_enabled",
+		})
+	}
+
+	if !logger.IsConsoleLevel(settings.ConsoleLogLevel) {
+		report.addError(ConfigIssue{
+			File:    s.settingsPath,
+			Field:   "settings.console_log_level",
+			Message: fmt.Sprintf("unknown log level %q", settings.ConsoleLogLevel),
+			Hint:    "use TRACE, DEBUG, INFO, WARN, ERROR or OFF",
+		})
+	}
+	if settings.InstanceID != "" && !profileIDPattern.MatchString(settings.InstanceID) {
+		report.addError(ConfigIssue{
+			File:    s.settingsPath,
+			Field:   "settings.instance_id",
+			Message: fmt.Sprintf("invalid instance id %q", settings.InstanceID),
+			Hint:    "use letters, digits, '-' and '_'",
+		})
+	}
+	if settings.Locale != "" && !i18n.IsSupported(settings.Locale) {
+		report.addWarning(ConfigIssue{
+			File:    s.settingsPath,
+			Field:   "settings.locale",
+			Message: fmt.Sprintf("locale %q is not supported; messages will be in English", settings.Locale),
+			Hint:    fmt.Sprintf("supported locales: %s", strings.Join(i18n.Supported(), ", ")),
+		})
+	}
+
+	groupKeys := map[string]bool{}
+	for i, group := range settings.AccessGroups {
+		field := fmt.Sprintf("settings.access_groups[%d]", i)
+		if group.Name != "" {
+			field = fmt.Sprintf("settings.access_groups[%d] (%s)", i, group.Name)
+		}
+		switch {
+		case group.APIKey == "":
+			report.addError(ConfigIssue{File: s.settingsPath, Field: field + ".api_key", Message: "api key is required"})
+		case groupKeys[group.APIKey]:
+			report.addError(ConfigIssue{
+				File:    s.settingsPath,
+				Field:   field + ".api_key",
+				Message: "api key is already used by another access group",
+				Hint:    "each group needs its own key",
+			})
+		case group.APIKey == settings.GatewayAPIKey:
+			report.addError(ConfigIssue{
+				File:    s.settingsPath,
+				Field:   field + ".api_key",
+				Message: "api key is the same as gateway_api_key",
+				Hint:    "the gateway key is unrestricted; give the group its own key",
+			})
+		}
+		groupKeys[group.APIKey] = true
+
+		if group.ControlRole != "" && group.ControlRole != RoleViewer && group.ControlRole != RoleOperator {
+			report.addError(ConfigIssue{
+				File:    s.settingsPath,
+				Field:   field + ".control_role",
+				Message: fmt.Sprintf("unknown control role %q", group.ControlRole),
+				Hint:    "access groups can be viewer or operator; use a control token for admin access",
+			})
+		}
+
+		for _, id := range group.Profiles {
+			if len(profileIDs) > 0 && !profileIDs[id] {
+				report.addWarning(ConfigIssue{
+					File:    s.settingsPath,
+					Field:   field + ".profiles",
+					Message: fmt.Sprintf("profile %q does not exist", id),
+				})
+			}
+		}
+		if group.DefaultProfile != "" {
+			if !group.AllowsProfile(group.DefaultProfile) {
+				report.addError(ConfigIssue{
+					File:    s.settingsPath,
+					Field:   field + ".default_profile",
+					Message: fmt.Sprintf("default profile %q is not in the group's profiles", group.DefaultProfile),
+					Hint:    "add it to profiles or pick one of them",
+				})
+			} else if len(profileIDs) > 0 && !profileIDs[group.DefaultProfile] {
+				report.addWarning(ConfigIssue{
+					File:    s.settingsPath,
+					Field:   field + ".default_profile",
+					Message: fmt.Sprintf("profile %q does not exist", group.DefaultProfile),
+				})
+			}
+		}
+	}
+
+	for i, eventType := range settings.Notifications.Events {
+		if !slices.Contains(events.Types, eventType) {
+			report.addWarning(ConfigIssue{
+				File:    s.settingsPath,
+				Field:   fmt.Sprintf("settings.notifications.events[%d]", i),
+				Message: fmt.Sprintf("unknown event type %q never notifies", eventType),
+				Hint:    fmt.Sprintf("event types: %s", strings.Join(events.Types, ", ")),
+			})
+		}
+	}
+
+	if tunnel := settings.Tunnel; tunnel.Enabled {
+		if u, err := url.Parse(tunnel.RelayURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			report.addError(ConfigIssue{
+				File:    s.settingsPath,
+				Field:   "settings.tunnel.relay_url",
+				Message: fmt.Sprintf("relay url %q is not an https URL", tunnel.RelayURL),
+				Hint:    "the relay only serves TLS, e.g. https://relay.example.com:8443",
+			})
+		}
+		if tunnel.Token == "" {
+			report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.tunnel.token", Message: "token is required"})
+		}
+		switch {
+		case tunnel.Profile == "":
+			report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.tunnel.profile", Message: "profile is required"})
+		case len(profileIDs) > 0 && !profileIDs[tunnel.Profile]:
+			report.addError(ConfigIssue{
+				File:    s.settingsPath,
+				Field:   "settings.tunnel.profile",
+				Message: fmt.Sprintf("profile %q does not exist", tunnel.Profile),
+			})
+		}
+		if tunnel.Connections < 0 {
+			report.addError(ConfigIssue{File: s.settingsPath, Field: "settings.tunnel.connections", Message: "must not be negative"})
+		}
+	}
+
+	tokens := map[string]bool{}
+	for i, t := range settings.ControlTokens {
+		field := fmt.Sprintf("settings.control_tokens[%d]", i)
+		if t.Name != "" {
+			field = fmt.Sprintf("settings.control_tokens[%d] (%s)", i, t.Name)
+		}
+		if !t.Role.Valid() {
+			report.addError(ConfigIssue{
+				File:    s.settingsPath,
+				Field:   field + ".role",
+				Message: fmt.Sprintf("unknown role %q", t.Role),
+				Hint:    "use admin, operator or viewer",
+			})
+		}
+		switch {
+		case t.Token == "":
+			report.addError(ConfigIssue{File: s.settingsPath, Field: field + ".token", Message: "token is required"})
+		case tokens[t.Token] || groupKeys[t.Token] || t.Token == settings.GatewayAPIKey:
+			report.addError(ConfigIssue{
+				File:    s.settingsPath,
+				Field:   field + ".token",
+				Message: "token is already used by another token or API key",
+				Hint:    "create tokens with POST /api/control-tokens",
+			})
+		}
+		if t.User != "" && !ValidUserName(t.User) {
+			report.addError(ConfigIssue{
+				File:    s.settingsPath,
+				Field:   field + ".user",
+				Message: fmt.Sprintf("invalid user name %q", t.User),
+				Hint:    "use lowercase letters, digits, '-' and '_', e.g. the OS user name",
+			})
+		}
+		tokens[t.Token] = true
+	}
+
+	if settings.LastProfileID != "" && len(profileIDs) > 0 && !profileIDs[settings.LastProfileID] {
+		report.addWarning(ConfigIssue{
+			File:    s.settingsPath,
+			Field:   "settings.last_profile_id",
+			Message: fmt.Sprintf("profile %q does not exist", settings.LastProfileID),
+		})
+	}
+}
+
+// decodeStrict decodes YAML rejecting unknown fields, recording any problems
+// in the report. It returns false if the document could not be used.
+func decodeStrict(data []byte, out interface{}, file string, report *ConfigReport) bool {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	err := dec.Decode(out)
+	if err == nil || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		for _, msg := range typeErr.Errors {
+			issue := yamlIssue(file, msg)
+			if strings.Contains(msg, "not found in type") {
+				issue.Hint = "unknown field; check the spelling or remove it"
+			}
+			report.addError(issue)
+		}
+		// Type errors are partial: known fields were still decoded
+		return true
+	}
+
+	issue := yamlIssue(file, err.Error())
+	issue.Hint = "the file is not valid YAML"
+	report.addError(issue)
+	return false
+}
+
+func yamlIssue(file, msg string) ConfigIssue {
+	issue := ConfigIssue{File: file, Message: msg}
+	if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+		issue.Line, _ = strconv.Atoi(m[1])
+		issue.Message = m[2]
+	}
+	return issue
+}
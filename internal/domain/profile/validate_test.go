@@ -0,0 +1,79 @@
+package profile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Validate(t *testing.T) {
+	tmpDir := t.TempDir()
+	pPath := filepath.Join(tmpDir, "profiles.yaml")
+	sPath := filepath.Join(tmpDir, "settings.yaml")
+
+	require.NoError(t, os.WriteFile(pPath, []byte(`profiles:
+  - id: work
+    allow_tools: [brave-search, made-up]
+  - id: work
+    alow_tools: []
+  - id: home
+    hosts: [shared.internal]
+  - id: lab
+    hosts: [Shared.Internal]
+`), 0644))
+	require.NoError(t, os.WriteFile(sPath, []byte(`settings:
+  control_port: 6277
+  mcp_port: 6277
+  quota_policy: drop
+`), 0644))
+
+	store := profile.NewStore(pPath, sPath)
+	report := store.Validate(map[string]bool{"brave-search": true})
+
+	messages := func(issues []profile.ConfigIssue) []string {
+		out := []string{}
+		for _, issue := range issues {
+			out = append(out, issue.Message)
+		}
+		return out
+	}
+
+	assert.False(t, report.Valid)
+	assert.Contains(t, messages(report.Errors), `duplicate profile id "work"`)
+	assert.Contains(t, messages(report.Errors), "control_port and mcp_port are both 6277")
+	assert.Contains(t, messages(report.Errors), `unknown quota policy "drop"`)
+	assert.Contains(t, messages(report.Errors), `host "shared.internal" is already used by profile "home"`)
+	assert.Contains(t, messages(report.Warnings), `"made-up" is not in the registry`)
+
+	// Unknown fields are reported with their line number
+	var unknown *profile.ConfigIssue
+	for i, issue := range report.Errors {
+		if issue.Line > 0 {
+			unknown = &report.Errors[i]
+		}
+	}
+	require.NotNil(t, unknown)
+	assert.Equal(t, 5, unknown.Line)
+	assert.Contains(t, unknown.Message, "alow_tools")
+}
+
+func TestStore_Validate_Malformed(t *testing.T) {
+	tmpDir := t.TempDir()
+	pPath := filepath.Join(tmpDir, "profiles.yaml")
+	require.NoError(t, os.WriteFile(pPath, []byte("profiles:\n  - id: [unterminated\n"), 0644))
+
+	report := profile.NewStore(pPath, filepath.Join(tmpDir, "settings.yaml")).Validate(nil)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, "the file is not valid YAML", report.Errors[0].Hint)
+	assert.Error(t, report.Err(false))
+}
+
+func TestStore_Validate_Empty(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := profile.NewStore(filepath.Join(tmpDir, "profiles.yaml"), filepath.Join(tmpDir, "settings.yaml"))
+	assert.NoError(t, store.Validate(nil).Err(true))
+}
@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// ValidateValue checks a decoded JSON value (as produced by encoding/json)
+// against a tool schema. Only the subset of JSON Schema modelled by JSONSchema
+// and PropertySchema is enforced; anything else is accepted as-is.
+func ValidateValue(schema *JSONSchema, value interface{}) []ValidationError {
+	if schema == nil {
+		return nil
+	}
+
+	var errs []ValidationError
+	root := PropertySchema{
+		Type:       schema.Type,
+		Properties: schema.Properties,
+		Items:      schema.Items,
+	}
+	validateValue("$", root, value, &errs)
+
+	if obj, ok := value.(map[string]interface{}); ok {
+		for _, name := range schema.Required {
+			if _, present := obj[name]; !present {
+				errs = append(errs, ValidationError{"$." + name, "required property is missing"})
+			}
+		}
+	}
+
+	return errs
+}
+
+func validateValue(path string, schema PropertySchema, value interface{}, errs *[]ValidationError) {
+	if schema.Type != "" && !matchesType(schema.Type, value) {
+		*errs = append(*errs, ValidationError{path, fmt.Sprintf("expected %s, got %s", schema.Type, jsonTypeOf(value))})
+		return
+	}
+
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, allowed := range schema.Enum {
+			if fmt.Sprint(value) == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*errs = append(*errs, ValidationError{path, fmt.Sprintf("value %v is not one of %v", value, schema.Enum)})
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if schema.MinLength != nil && len(v) < *schema.MinLength {
+			*errs = append(*errs, ValidationError{path, fmt.Sprintf("must be at least %d characters", *schema.MinLength)})
+		}
+		if schema.MaxLength != nil && len(v) > *schema.MaxLength {
+			*errs = append(*errs, ValidationError{path, fmt.Sprintf("must be %d characters or less", *schema.MaxLength)})
+		}
+
+	case map[string]interface{}:
+		for name, propSchema := range schema.Properties {
+			if propValue, ok := v[name]; ok {
+				validateValue(path+"."+name, propSchema, propValue, errs)
+			}
+		}
+
+	case []interface{}:
+		if schema.Items != nil {
+			for i, item := range v {
+				validateValue(fmt.Sprintf("%s[%d]", path, i), *schema.Items, item, errs)
+			}
+		}
+
+	default:
+		if n, ok := toFloat(value); ok {
+			if schema.Minimum != nil && n < float64(*schema.Minimum) {
+				*errs = append(*errs, ValidationError{path, fmt.Sprintf("must be >= %d", *schema.Minimum)})
+			}
+			if schema.Maximum != nil && n > float64(*schema.Maximum) {
+				*errs = append(*errs, ValidationError{path, fmt.Sprintf("must be <= %d", *schema.Maximum)})
+			}
+		}
+	}
+}
+
+func matchesType(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		n, ok := toFloat(value)
+		return ok && n == math.Trunc(n)
+	default:
+		// Unknown types are not enforced
+		return true
+	}
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if _, ok := toFloat(value); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,63 @@
+package registry
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateValue(t *testing.T) {
+	minCount := 1
+	schema := &JSONSchema{
+		Type: "object",
+		Properties: map[string]PropertySchema{
+			"title": {Type: "string"},
+			"count": {Type: "integer", Minimum: &minCount},
+			"kind":  {Type: "string", Enum: []string{"web", "news"}},
+			"results": {
+				Type:  "array",
+				Items: &PropertySchema{Type: "object", Properties: map[string]PropertySchema{"url": {Type: "string"}}},
+			},
+		},
+		Required: []string{"title", "results"},
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		wantFields []string
+	}{
+		{"valid", `{"title": "ok", "count": 2, "kind": "web", "results": [{"url": "https://a"}]}`, nil},
+		{"missing required", `{"results": []}`, []string{"$.title"}},
+		{"wrong type", `{"title": 5, "results": []}`, []string{"$.title"}},
+		{"non-integer", `{"title": "ok", "count": 1.5, "results": []}`, []string{"$.count"}},
+		{"below minimum", `{"title": "ok", "count": 0, "results": []}`, []string{"$.count"}},
+		{"enum mismatch", `{"title": "ok", "kind": "images", "results": []}`, []string{"$.kind"}},
+		{"nested item", `{"title": "ok", "results": [{"url": true}]}`, []string{"$.results[0].url"}},
+		{"not an object", `"plain text"`, []string{"$"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.input), &value))
+
+			errs := ValidateValue(schema, value)
+			fields := make([]string, 0, len(errs))
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			if len(tt.wantFields) == 0 {
+				assert.Empty(t, errs)
+			} else {
+				assert.ElementsMatch(t, tt.wantFields, fields)
+			}
+		})
+	}
+}
+
+func TestValidateValue_NilSchema(t *testing.T) {
+	assert.Empty(t, ValidateValue(nil, map[string]interface{}{"any": "thing"}))
+}
@@ -182,14 +182,16 @@ type Package struct {
 	URL       string                `json:"url,omitempty"`
 	LocalPath string                `json:"local_path,omitempty"`
 	SHA256    string                `json:"sha256,omitempty"`
+	Mirrors   []string              `json:"mirrors,omitempty"` // Tried in order when URL fails
 	Image     string                `json:"image,omitempty"`
 	Platforms map[string]PlatformBinary `json:"platforms,omitempty"`
 }
 
 // PlatformBinary defines a binary download for a specific platform.
 type PlatformBinary struct {
-	URL    string `json:"url"`
-	SHA256 string `json:"sha256,omitempty"`
+	URL     string   `json:"url"`
+	SHA256  string   `json:"sha256,omitempty"`
+	Mirrors []string `json:"mirrors,omitempty"` // Tried in order when URL fails
 }
 
 // TransportType defines how Scooter communicates with the MCP.
@@ -202,21 +204,55 @@ const (
 	TransportStreamableHTTP TransportType = "streamable-http"
 )
 
+// IsRemote reports whether the transport reaches a server over HTTP rather
+// than starting it.
+func (t TransportType) IsRemote() bool {
+	return t == TransportHTTP || t == TransportSSE || t == TransportStreamableHTTP
+}
+
 // Runtime defines how to execute the MCP server.
 type Runtime struct {
 	Transport   TransportType     `json:"transport,omitempty"`
 	Command     string            `json:"command,omitempty"`
 	Args        []string          `json:"args,omitempty"`
+	URL         string            `json:"url,omitempty"`     // Remote servers (http, sse, streamable-http transports)
+	Headers     map[string]string `json:"headers,omitempty"` // Sent to remote servers; values may use ${VAR} from the server's env
 	Env         map[string]string `json:"env,omitempty"`
 	Cwd         *string           `json:"cwd,omitempty"`
-	Timeout     int               `json:"timeout,omitempty"`
+	Timeout     int               `json:"timeout,omitempty"`    // Startup timeout in milliseconds
+	ReadyDelay  int               `json:"readyDelay,omitempty"` // Pause in milliseconds after "initialized" before the first request
 	HealthCheck *HealthCheck      `json:"healthCheck,omitempty"`
+	Retry       *RetryPolicy      `json:"retry,omitempty"`
+	Cache       *CachePolicy      `json:"cache,omitempty"`
+	IsolateHome bool              `json:"isolateHome,omitempty"` // Start with a temporary HOME and XDG dirs of its own
+}
+
+// RetryPolicy controls how tool calls that fail at the transport level (EOF,
+// broken pipe, timeout) are retried. Only tools annotated readOnlyHint or
+// idempotentHint are retried; tool errors reported by the server never are.
+type RetryPolicy struct {
+	MaxAttempts      int      `json:"maxAttempts,omitempty"`      // Total attempts including the first; <= 1 disables retries
+	InitialBackoffMs int      `json:"initialBackoffMs,omitempty"` // Delay before the first retry (default 200)
+	MaxBackoffMs     int      `json:"maxBackoffMs,omitempty"`     // Upper bound on the delay (default 5000)
+	Multiplier       float64  `json:"multiplier,omitempty"`       // Backoff growth factor (default 2)
+	Tools            []string `json:"tools,omitempty"`            // Limit retries to these tools; empty means all eligible tools
+}
+
+// CachePolicy reuses the results of tools annotated readOnlyHint or
+// idempotentHint for a while, keyed by their arguments. Other tools are
+// never cached.
+type CachePolicy struct {
+	TTLSeconds     int            `json:"ttlSeconds,omitempty"`     // How long results are reused; <= 0 disables caching
+	ToolTTLSeconds map[string]int `json:"toolTtlSeconds,omitempty"` // Per-tool TTLs replacing TTLSeconds; 0 disables a tool
 }
 
-// HealthCheck defines health monitoring configuration.
+// HealthCheck defines health monitoring configuration. Servers without one
+// are pinged every minute; one with enabled false is never checked.
 type HealthCheck struct {
-	Enabled  bool `json:"enabled,omitempty"`
-	Interval int  `json:"interval,omitempty"`
+	Enabled          bool   `json:"enabled,omitempty"`
+	Interval         int    `json:"interval,omitempty"`         // Between checks in milliseconds (default 60000)
+	Method           string `json:"method,omitempty"`           // "ping" (default) or "tools/list"
+	FailureThreshold int    `json:"failureThreshold,omitempty"` // Failed checks in a row before a restart (default 3)
 }
 
 // Metadata provides additional attribution information.
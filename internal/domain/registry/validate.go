@@ -3,6 +3,7 @@ package registry
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -34,8 +35,25 @@ var (
 	colorPattern   = regexp.MustCompile(`^#([A-Fa-f0-9]{6}|[A-Fa-f0-9]{3})$`)
 	envVarPattern  = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
 	sha256Pattern  = regexp.MustCompile(`^[a-f0-9]{64}$`)
+	// packageVersionPattern is what binary package versions look like; they
+	// name a directory
+	packageVersionPattern = regexp.MustCompile(`^[A-Za-z0-9._+-]+$`)
+	// imagePattern is a docker image reference: [registry[:port]/]path[:tag][@digest].
+	// It can't start with '-', which the docker CLI would take for a flag
+	imagePattern = regexp.MustCompile(`^(?:[A-Za-z0-9](?:[A-Za-z0-9.-]*[A-Za-z0-9])?(?::[0-9]+)?/)?` +
+		`[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*` +
+		`(?::[A-Za-z0-9_][A-Za-z0-9_.-]{0,127})?(?:@[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[A-Fa-f0-9]{32,})?$`)
+	// imageTagPattern is what a docker package's version, used as the
+	// image's tag, looks like
+	imageTagPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
 )
 
+// ValidName reports whether name is a valid entry name, and so safe to use
+// as a registry file name.
+func ValidName(name string) bool {
+	return len(name) >= 2 && len(name) <= 64 && namePattern.MatchString(name)
+}
+
 // ValidCategories contains all valid category values.
 var ValidCategories = map[Category]bool{
 	CategoryDevelopment:   true,
@@ -87,10 +105,22 @@ var ValidTransportTypes = map[TransportType]bool{
 
 // Validate checks an MCPEntry against the schema rules.
 func Validate(entry *MCPEntry) *ValidationResult {
+	// Remote servers are reached at their URL; there is nothing to install
+	return validate(entry, entry.Runtime == nil || !entry.Runtime.Transport.IsRemote())
+}
+
+// ValidateCustom checks an entry for the custom registry. Custom entries
+// may omit the package when their runtime command starts the server, as
+// entries generated from a probe of a local command do.
+func ValidateCustom(entry *MCPEntry) *ValidationResult {
+	return validate(entry, entry.Runtime == nil || (entry.Runtime.Command == "" && !entry.Runtime.Transport.IsRemote()))
+}
+
+func validate(entry *MCPEntry, requirePackage bool) *ValidationResult {
 	result := &ValidationResult{Valid: true}
 
 	// Required fields
-	validateRequired(entry, result)
+	validateRequired(entry, requirePackage, result)
 	if len(result.Errors) > 0 {
 		result.Valid = false
 		return result
@@ -120,7 +150,7 @@ func Validate(entry *MCPEntry) *ValidationResult {
 	return result
 }
 
-func validateRequired(entry *MCPEntry, result *ValidationResult) {
+func validateRequired(entry *MCPEntry, requirePackage bool, result *ValidationResult) {
 	if entry.Name == "" {
 		result.Errors = append(result.Errors, ValidationError{"name", "required field is missing"})
 	}
@@ -145,7 +175,7 @@ func validateRequired(entry *MCPEntry, result *ValidationResult) {
 	if len(entry.Tools) == 0 {
 		result.Errors = append(result.Errors, ValidationError{"tools", "at least one tool is required"})
 	}
-	if entry.Package == nil {
+	if entry.Package == nil && requirePackage {
 		result.Errors = append(result.Errors, ValidationError{"package", "required field is missing"})
 	}
 }
@@ -345,9 +375,17 @@ func validatePackage(pkg *Package, result *ValidationResult) {
 	case PackageDocker:
 		if pkg.Image == "" {
 			result.Errors = append(result.Errors, ValidationError{"package.image", "required for docker package"})
+		} else if !imagePattern.MatchString(pkg.Image) {
+			result.Errors = append(result.Errors, ValidationError{"package.image", fmt.Sprintf("invalid image reference: %s", pkg.Image)})
+		}
+		if pkg.Version != "" && !imageTagPattern.MatchString(pkg.Version) {
+			result.Errors = append(result.Errors, ValidationError{"package.version", "must be a valid image tag"})
 		}
 
 	case PackageBinary:
+		if pkg.Version != "" && (!packageVersionPattern.MatchString(pkg.Version) || strings.Contains(pkg.Version, "..")) {
+			result.Errors = append(result.Errors, ValidationError{"package.version", "must be letters, digits, '.', '_', '+' and '-' only, without '..'"})
+		}
 		if len(pkg.Platforms) == 0 {
 			result.Errors = append(result.Errors, ValidationError{"package.platforms", "required for binary package"})
 		}
@@ -355,9 +393,12 @@ func validatePackage(pkg *Package, result *ValidationResult) {
 			if bin.URL == "" {
 				result.Errors = append(result.Errors, ValidationError{fmt.Sprintf("package.platforms.%s.url", platform), "required"})
 			}
-			if bin.SHA256 != "" && !sha256Pattern.MatchString(bin.SHA256) {
+			if bin.SHA256 == "" {
+				result.Errors = append(result.Errors, ValidationError{fmt.Sprintf("package.platforms.%s.sha256", platform), "required for binary package"})
+			} else if !sha256Pattern.MatchString(bin.SHA256) {
 				result.Errors = append(result.Errors, ValidationError{fmt.Sprintf("package.platforms.%s.sha256", platform), "invalid SHA256 hash"})
 			}
+			validateMirrors(bin.Mirrors, fmt.Sprintf("package.platforms.%s.mirrors", platform), result)
 		}
 
 	case PackageWASM:
@@ -367,17 +408,63 @@ func validatePackage(pkg *Package, result *ValidationResult) {
 		if pkg.SHA256 != "" && !sha256Pattern.MatchString(pkg.SHA256) {
 			result.Errors = append(result.Errors, ValidationError{"package.sha256", "invalid SHA256 hash"})
 		}
+		if pkg.SHA256 == "" && len(pkg.Mirrors) > 0 {
+			result.Errors = append(result.Errors, ValidationError{"package.sha256", "required when mirrors are listed"})
+		}
+		validateMirrors(pkg.Mirrors, "package.mirrors", result)
+	}
+}
+
+// validateMirrors checks that every mirror of a download is an https URL.
+func validateMirrors(mirrors []string, field string, result *ValidationResult) {
+	for i, mirror := range mirrors {
+		if u, err := url.Parse(mirror); err != nil || u.Scheme != "https" || u.Host == "" {
+			result.Errors = append(result.Errors, ValidationError{fmt.Sprintf("%s[%d]", field, i), "must be an https url"})
+		}
 	}
 }
 
 func validateRuntime(runtime *Runtime, result *ValidationResult) {
+	if hc := runtime.HealthCheck; hc != nil {
+		if hc.Method != "" && hc.Method != "ping" && hc.Method != "tools/list" {
+			result.Errors = append(result.Errors, ValidationError{"runtime.healthCheck.method", "must be ping or tools/list"})
+		}
+		if hc.Interval != 0 && hc.Interval < 1000 {
+			result.Errors = append(result.Errors, ValidationError{"runtime.healthCheck.interval", "must be at least 1000 milliseconds"})
+		}
+		if hc.FailureThreshold < 0 {
+			result.Errors = append(result.Errors, ValidationError{"runtime.healthCheck.failureThreshold", "must not be negative"})
+		}
+	}
+	if c := runtime.Cache; c != nil {
+		if c.TTLSeconds < 0 {
+			result.Errors = append(result.Errors, ValidationError{"runtime.cache.ttlSeconds", "must not be negative"})
+		}
+		for tool, ttl := range c.ToolTTLSeconds {
+			if ttl < 0 {
+				result.Errors = append(result.Errors, ValidationError{"runtime.cache.toolTtlSeconds." + tool, "must not be negative"})
+			}
+		}
+	}
 	if runtime.Transport != "" && !ValidTransportTypes[runtime.Transport] {
 		result.Errors = append(result.Errors, ValidationError{"runtime.transport", fmt.Sprintf("invalid transport type: %s", runtime.Transport)})
 	}
 
+	if runtime.Transport.IsRemote() {
+		if u, err := url.Parse(runtime.URL); runtime.URL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			result.Errors = append(result.Errors, ValidationError{"runtime.url", fmt.Sprintf("%s transport requires an http or https url", runtime.Transport)})
+		}
+	} else if runtime.URL != "" || len(runtime.Headers) > 0 {
+		result.Errors = append(result.Errors, ValidationError{"runtime.url", "url and headers require the http, sse or streamable-http transport"})
+	}
+
 	if runtime.Timeout != 0 && runtime.Timeout < 1000 {
 		result.Errors = append(result.Errors, ValidationError{"runtime.timeout", "must be at least 1000ms"})
 	}
+
+	if runtime.ReadyDelay < 0 {
+		result.Errors = append(result.Errors, ValidationError{"runtime.readyDelay", "must not be negative"})
+	}
 }
 
 func addWarnings(entry *MCPEntry, result *ValidationResult) {
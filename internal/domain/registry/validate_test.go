@@ -203,6 +203,28 @@ func TestValidate_Package_NPM(t *testing.T) {
 	assert.True(t, result.Valid, "Expected valid npm package, got errors: %v", result.Errors)
 }
 
+func TestValidate_Package_Docker_Image(t *testing.T) {
+	for image, valid := range map[string]bool{
+		"node":                           true,
+		"mcp/github:1.2":                 true,
+		"ghcr.io/acme/mcp-server:latest": true,
+		"localhost:5000/tools/fetch":     true,
+		"mcp/fetch@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef": true,
+		"--privileged":           false,
+		"-v=/:/host":             false,
+		"mcp/fetch --privileged": false,
+		"MCP/Fetch":              false,
+	} {
+		entry := createMinimalEntry()
+		entry.Package = &Package{Type: PackageDocker, Image: image}
+		assert.Equal(t, valid, Validate(entry).Valid, image)
+	}
+
+	entry := createMinimalEntry()
+	entry.Package = &Package{Type: PackageDocker, Image: "mcp/fetch", Version: "--rm"}
+	assert.False(t, Validate(entry).Valid, "the version is the image's tag")
+}
+
 func TestValidate_Package_Binary_MissingPlatforms(t *testing.T) {
 	entry := createMinimalEntry()
 	entry.Package = &Package{
@@ -222,6 +244,48 @@ func TestValidate_Package_Binary_MissingPlatforms(t *testing.T) {
 	assert.True(t, hasPlatformsError)
 }
 
+func TestValidate_Package_Binary_Version(t *testing.T) {
+	for version, valid := range map[string]bool{
+		"1.2.3":                  true,
+		"v2.0.0-rc.1+build_5":    true,
+		"../../../../.local/bin": false,
+		"..":                     false,
+		"1.0/../../escape":       false,
+		`1.0\..\escape`:          false,
+	} {
+		entry := createMinimalEntry()
+		entry.Package = &Package{
+			Type:      PackageBinary,
+			Version:   version,
+			Platforms: map[string]PlatformBinary{"linux-x64": {URL: "https://example.com/tool", SHA256: testSHA256}},
+		}
+		assert.Equal(t, valid, Validate(entry).Valid, version)
+	}
+}
+
+const testSHA256 = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestValidate_Package_Integrity(t *testing.T) {
+	binary := func(bin PlatformBinary) *MCPEntry {
+		entry := createMinimalEntry()
+		entry.Package = &Package{Type: PackageBinary, Platforms: map[string]PlatformBinary{"linux-x64": bin}}
+		return entry
+	}
+	assert.False(t, Validate(binary(PlatformBinary{URL: "https://example.com/tool"})).Valid, "binaries need a checksum")
+	assert.True(t, Validate(binary(PlatformBinary{URL: "https://example.com/tool", SHA256: testSHA256, Mirrors: []string{"https://mirror.example.com/tool"}})).Valid)
+	assert.False(t, Validate(binary(PlatformBinary{URL: "https://example.com/tool", SHA256: testSHA256, Mirrors: []string{"http://mirror.example.com/tool"}})).Valid, "mirrors must be https")
+
+	wasm := func(sum string, mirrors ...string) *MCPEntry {
+		entry := createMinimalEntry()
+		entry.Package = &Package{Type: PackageWASM, URL: "https://example.com/tool.wasm", SHA256: sum, Mirrors: mirrors}
+		return entry
+	}
+	assert.True(t, Validate(wasm("")).Valid)
+	assert.False(t, Validate(wasm("", "https://mirror.example.com/tool.wasm")).Valid, "mirrors need a checksum")
+	assert.True(t, Validate(wasm(testSHA256, "https://mirror.example.com/tool.wasm")).Valid)
+	assert.False(t, Validate(wasm(testSHA256, "http://mirror.example.com/tool.wasm")).Valid)
+}
+
 func TestValidate_Warnings(t *testing.T) {
 	entry := createMinimalEntry()
 	// No icon, no about, no homepage
@@ -231,6 +295,35 @@ func TestValidate_Warnings(t *testing.T) {
 	assert.True(t, len(result.Warnings) > 0, "Expected warnings for missing optional fields")
 }
 
+func TestValidateCustom_PackageOptionalWithCommand(t *testing.T) {
+	entry := createMinimalEntry()
+	entry.Package = nil
+
+	assert.False(t, Validate(entry).Valid)
+	assert.False(t, ValidateCustom(entry).Valid, "no runtime command either")
+
+	entry.Runtime = &Runtime{Transport: TransportStdio, Command: "python", Args: []string{"server.py"}}
+	result := ValidateCustom(entry)
+	assert.True(t, result.Valid, "Expected valid entry, got errors: %v", result.Errors)
+	assert.False(t, Validate(entry).Valid)
+}
+
+func TestValidate_RemoteRuntime(t *testing.T) {
+	entry := createMinimalEntry()
+	entry.Package = nil
+	entry.Runtime = &Runtime{Transport: TransportStreamableHTTP, URL: "https://mcp.example.com/mcp"}
+	result := Validate(entry)
+	assert.True(t, result.Valid, "Expected valid entry, got errors: %v", result.Errors)
+
+	entry.Runtime.URL = ""
+	assert.False(t, Validate(entry).Valid, "remote transport without a url")
+	entry.Runtime.URL = "ftp://mcp.example.com"
+	assert.False(t, Validate(entry).Valid, "url must be http or https")
+
+	entry.Runtime = &Runtime{Transport: TransportStdio, Command: "python", URL: "https://mcp.example.com/mcp"}
+	assert.False(t, ValidateCustom(entry).Valid, "url needs a remote transport")
+}
+
 // Helper function to create a minimal valid entry
 func createMinimalEntry() *MCPEntry {
 	return &MCPEntry{
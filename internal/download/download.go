@@ -0,0 +1,276 @@
+// Package download fetches package files, WASM modules and server
+// binaries, into the app directory.
+//
+// A download goes to <dest>.part first, so an interrupted one resumes from
+// where it stopped with a Range request. Once complete it is checked
+// against the registry entry's SHA-256 and only then renamed to dest. Each
+// URL of a source is tried in turn, the package URL then its mirrors.
+//
+// WASM modules and server binaries alike are fetched here by the installer
+// before the engine starts them; the WASM worker only loads the module from
+// the app directory.
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/version"
+)
+
+// progressInterval is how often Fetch reports progress while downloading.
+const progressInterval = time.Second
+
+// ErrChecksum is returned when a downloaded file doesn't match its SHA-256.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// Source is where a file can be downloaded from: URLs are tried in order.
+// An empty SHA256 skips verification.
+type Source struct {
+	URLs   []string
+	SHA256 string
+}
+
+// Progress reports a download in progress. Total is -1 while the size is
+// unknown.
+type Progress struct {
+	URL   string `json:"url"`
+	Bytes int64  `json:"bytes"`
+	Total int64  `json:"total"`
+}
+
+// Manager downloads files, one download per destination at a time.
+type Manager struct {
+	Client   *http.Client
+	Attempts int           // Tries per URL before moving to the next one
+	Backoff  time.Duration // Wait before retrying a URL, doubled each time
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// New returns a Manager trying each URL three times.
+func New() *Manager {
+	return &Manager{Client: http.DefaultClient, Attempts: 3, Backoff: time.Second}
+}
+
+// Default is the process-wide manager, shared by every profile's engine so
+// they never write the same file at once.
+var Default = New()
+
+// lock serialises downloads to dest.
+func (m *Manager) lock(dest string) *sync.Mutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locks == nil {
+		m.locks = map[string]*sync.Mutex{}
+	}
+	l, ok := m.locks[dest]
+	if !ok {
+		l = &sync.Mutex{}
+		m.locks[dest] = l
+	}
+	return l
+}
+
+// Fetch makes sure dest holds the file of src. An existing dest is kept if
+// it matches the checksum (or there is none to check); otherwise it is
+// downloaded, calling progress (which may be nil) as it goes.
+func (m *Manager) Fetch(ctx context.Context, src Source, dest string, progress func(Progress)) error {
+	l := m.lock(dest)
+	l.Lock()
+	defer l.Unlock()
+
+	if _, err := os.Stat(dest); err == nil {
+		if src.SHA256 == "" || verify(dest, src.SHA256) == nil {
+			return nil
+		}
+	}
+	if len(src.URLs) == 0 {
+		return fmt.Errorf("no URL to download %s from", filepath.Base(dest))
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	part := dest + ".part"
+	var errs []error
+	for _, url := range src.URLs {
+		err := m.fetchURL(ctx, url, part, src.SHA256, progress)
+		if err == nil {
+			return os.Rename(part, dest)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", url, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return fmt.Errorf("failed to download %s: %w", filepath.Base(dest), errors.Join(errs...))
+}
+
+// fetchURL downloads url into part, retrying and resuming up to m.Attempts
+// times. A checksum mismatch isn't retried: the partial file is dropped and
+// the next URL starts afresh.
+func (m *Manager) fetchURL(ctx context.Context, url, part, sum string, progress func(Progress)) error {
+	attempts := max(m.Attempts, 1)
+	backoff := m.Backoff
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		if err = m.get(ctx, url, part, sum != "", progress); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			continue
+		}
+		if sum == "" {
+			return nil
+		}
+		if err = verify(part, sum); err != nil {
+			os.Remove(part)
+		}
+		return err
+	}
+	return err
+}
+
+// get appends the rest of url to part, starting over if the server ignores
+// the Range request. A server refusing the range means part may already be
+// complete: that is taken on trust only when verified says the caller checks
+// it afterwards, otherwise part is downloaded again from the start.
+func (m *Manager) get(ctx context.Context, url, part string, verified bool, progress func(Progress)) error {
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "mcp-scooter/"+version.Version)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	total := int64(-1)
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0 && rangeStart(resp) == offset:
+		if resp.ContentLength >= 0 {
+			total = offset + resp.ContentLength
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		if verified {
+			return nil
+		}
+		resp.Body.Close()
+		f.Close()
+		if err := os.Remove(part); err != nil {
+			return err
+		}
+		return m.get(ctx, url, part, verified, progress)
+	case resp.StatusCode == http.StatusOK:
+		if offset > 0 {
+			if err := f.Truncate(0); err != nil {
+				return err
+			}
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			offset = 0
+		}
+		total = resp.ContentLength
+	default:
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	p := Progress{URL: url, Bytes: offset, Total: total}
+	report := func() {
+		if progress != nil {
+			progress(p)
+		}
+	}
+	report()
+	last := time.Now()
+	buf := make([]byte, 32<<10)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := f.Write(buf[:n]); err != nil {
+				return err
+			}
+			p.Bytes += int64(n)
+			if time.Since(last) >= progressInterval {
+				report()
+				last = time.Now()
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	report()
+	if total >= 0 && p.Bytes != total {
+		return fmt.Errorf("download ended after %d of %d bytes", p.Bytes, total)
+	}
+	return nil
+}
+
+// rangeStart returns the first byte of a 206 response's Content-Range.
+func rangeStart(resp *http.Response) int64 {
+	v := strings.TrimPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	start, _, _ := strings.Cut(v, "-")
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// verify checks the SHA-256 of the file at path against sum, a hex digest.
+func verify(path, sum string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(got, sum) {
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksum, sum, got)
+	}
+	return nil
+}
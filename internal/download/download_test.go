@@ -0,0 +1,120 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fileServer serves data with Range support, recording each request's Range header.
+type fileServer struct {
+	mu     sync.Mutex
+	data   []byte
+	ranges []string
+}
+
+func (f *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.ranges = append(f.ranges, r.Header.Get("Range"))
+	f.mu.Unlock()
+	http.ServeContent(w, r, "tool.wasm", time.Time{}, bytes.NewReader(f.data))
+}
+
+func testManager() *Manager {
+	return &Manager{Client: http.DefaultClient, Attempts: 2, Backoff: time.Millisecond}
+}
+
+func TestFetch_Resumes(t *testing.T) {
+	data := bytes.Repeat([]byte("scooter "), 10000)
+	files := &fileServer{data: data}
+	srv := httptest.NewServer(files)
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "tool.wasm")
+	require.NoError(t, os.WriteFile(dest+".part", data[:1000], 0644))
+
+	var last Progress
+	err := testManager().Fetch(context.Background(), Source{URLs: []string{srv.URL}, SHA256: checksum(data)}, dest, func(p Progress) { last = p })
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.NoFileExists(t, dest+".part")
+	assert.Equal(t, []string{"bytes=1000-"}, files.ranges)
+	assert.Equal(t, Progress{URL: srv.URL, Bytes: int64(len(data)), Total: int64(len(data))}, last)
+
+	// A verified file isn't downloaded again
+	require.NoError(t, testManager().Fetch(context.Background(), Source{URLs: []string{srv.URL}, SHA256: checksum(data)}, dest, nil))
+	assert.Len(t, files.ranges, 1)
+}
+
+func TestFetch_Mirrors(t *testing.T) {
+	data := []byte("module")
+	var broken int
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		broken++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	mirror := httptest.NewServer(&fileServer{data: data})
+	defer mirror.Close()
+
+	dest := filepath.Join(t.TempDir(), "tool.wasm")
+	err := testManager().Fetch(context.Background(), Source{URLs: []string{down.URL, mirror.URL}, SHA256: checksum(data)}, dest, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, broken, "each URL is retried before the next one")
+	got, _ := os.ReadFile(dest)
+	assert.Equal(t, data, got)
+}
+
+func TestFetch_ChecksumMismatch(t *testing.T) {
+	srv := httptest.NewServer(&fileServer{data: []byte("tampered")})
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "tool.wasm")
+	err := testManager().Fetch(context.Background(), Source{URLs: []string{srv.URL}, SHA256: checksum([]byte("original"))}, dest, nil)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrChecksum))
+	assert.NoFileExists(t, dest)
+	assert.NoFileExists(t, dest+".part", "a corrupt download isn't resumed")
+
+	// An existing file that doesn't match is replaced
+	require.NoError(t, os.WriteFile(dest, []byte("stale"), 0644))
+	require.NoError(t, testManager().Fetch(context.Background(), Source{URLs: []string{srv.URL}, SHA256: checksum([]byte("tampered"))}, dest, nil))
+	got, _ := os.ReadFile(dest)
+	assert.Equal(t, "tampered", string(got))
+}
+
+func TestFetch_RangeNotSatisfiableWithoutChecksum(t *testing.T) {
+	data := []byte("module")
+	files := &fileServer{data: data}
+	srv := httptest.NewServer(files)
+	defer srv.Close()
+
+	// A part file as long as the download can't be trusted unchecked
+	dest := filepath.Join(t.TempDir(), "tool.wasm")
+	require.NoError(t, os.WriteFile(dest+".part", []byte("tampered"), 0644))
+	require.NoError(t, testManager().Fetch(context.Background(), Source{URLs: []string{srv.URL}}, dest, nil))
+
+	got, _ := os.ReadFile(dest)
+	assert.Equal(t, data, got)
+	assert.Equal(t, []string{"bytes=8-", ""}, files.ranges, "starts over without a range")
+}
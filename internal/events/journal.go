@@ -0,0 +1,273 @@
+// Package events keeps a typed history of what the gateway did: server
+// activations, deactivations, crashes and restarts, tool verifications,
+// configuration changes and package downloads. Unlike the free-text log, every event has an ID
+// and a structured payload, so clients can resume from the last event they
+// saw.
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// Event types.
+const (
+	ServerActivated        = "server.activated"
+	ServerActivationFailed = "server.activation_failed"
+	ServerDeactivated      = "server.deactivated"
+	ServerCrashed          = "server.crashed"
+	ServerQuarantined      = "server.quarantined"
+	ServerRestarted        = "server.restarted"
+	ToolVerified           = "tool.verified"
+	ConfigChanged          = "config.changed"
+	PackageDownload        = "package.download"
+	// HandlerPanicked is a panic in a control API or gateway handler that
+	// was recovered; its data holds the panic and the stack.
+	HandlerPanicked = "handler.panicked"
+)
+
+// Types lists every event type.
+var Types = []string{
+	ServerActivated, ServerActivationFailed, ServerDeactivated, ServerCrashed,
+	ServerQuarantined, ServerRestarted, ToolVerified, ConfigChanged, PackageDownload,
+	HandlerPanicked,
+}
+
+// Event is one journal entry. IDs increase by one per event and survive
+// restarts when the journal is persisted.
+type Event struct {
+	ID      int64                  `json:"id"`
+	Time    time.Time              `json:"time"`
+	Type    string                 `json:"type"`
+	Profile string                 `json:"profile,omitempty"`
+	Server  string                 `json:"server,omitempty"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// DefaultCapacity is how many events the journal keeps.
+const DefaultCapacity = 1000
+
+// Journal is a ring buffer of the most recent events, optionally mirrored to
+// a JSON-lines file.
+type Journal struct {
+	mu       sync.RWMutex
+	ring     []Event
+	start    int // index of the oldest event in ring
+	count    int
+	nextID   int64
+	path     string
+	file     *os.File
+	appended int // lines written since the file was last compacted
+
+	subsMu      sync.RWMutex
+	subscribers map[chan Event]bool
+}
+
+// NewJournal returns an in-memory journal that keeps the last capacity
+// events.
+func NewJournal(capacity int) *Journal {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Journal{
+		ring:        make([]Event, capacity),
+		nextID:      1,
+		subscribers: make(map[chan Event]bool),
+	}
+}
+
+// Default is the process-wide journal.
+var Default = NewJournal(DefaultCapacity)
+
+// Record adds an event to the default journal.
+func Record(eventType, profile, server string, data map[string]interface{}) Event {
+	return Default.Record(eventType, profile, server, data)
+}
+
+// Open loads the events persisted at path and appends new events to it.
+// Unreadable lines are skipped. The file is rewritten to hold only the
+// events that fit in the journal.
+func (j *Journal) Open(path string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var ev Event
+			if json.Unmarshal(scanner.Bytes(), &ev) != nil || ev.ID < j.nextID {
+				continue
+			}
+			j.pushLocked(ev)
+			j.nextID = ev.ID + 1
+		}
+		f.Close()
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read event journal: %w", err)
+	}
+
+	j.path = path
+	return j.compactLocked()
+}
+
+// Close stops persisting events.
+func (j *Journal) Close() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file != nil {
+		j.file.Close()
+		j.file = nil
+	}
+}
+
+// Record adds an event, persists it and sends it to subscribers.
+func (j *Journal) Record(eventType, profile, server string, data map[string]interface{}) Event {
+	j.mu.Lock()
+	ev := Event{
+		ID:      j.nextID,
+		Time:    time.Now().UTC(),
+		Type:    eventType,
+		Profile: profile,
+		Server:  server,
+		Data:    data,
+	}
+	j.nextID++
+	j.pushLocked(ev)
+	j.persistLocked(ev)
+	j.mu.Unlock()
+
+	j.subsMu.RLock()
+	for sub := range j.subscribers {
+		select {
+		case sub <- ev:
+		default:
+			// Drop if subscriber is slow; it can catch up with Since
+		}
+	}
+	j.subsMu.RUnlock()
+	return ev
+}
+
+// Since returns up to limit events with an ID greater than since, oldest
+// first; limit <= 0 means no limit. truncated reports that events after
+// since have already been dropped from the journal.
+func (j *Journal) Since(since int64, limit int) (events []Event, truncated bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	events = []Event{}
+	if j.count == 0 {
+		return events, false
+	}
+	oldest := j.ring[j.start].ID
+	skip := 0
+	if since >= oldest {
+		skip = int(since - oldest + 1)
+	} else if since < oldest-1 {
+		truncated = true
+	}
+	for i := skip; i < j.count; i++ {
+		if limit > 0 && len(events) >= limit {
+			break
+		}
+		events = append(events, j.ring[(j.start+i)%len(j.ring)])
+	}
+	return events, truncated
+}
+
+// LastID returns the ID of the newest event, or 0 if there are none.
+func (j *Journal) LastID() int64 {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.nextID - 1
+}
+
+// Subscribe returns a channel that receives new events.
+func (j *Journal) Subscribe() chan Event {
+	j.subsMu.Lock()
+	defer j.subsMu.Unlock()
+	ch := make(chan Event, 100)
+	j.subscribers[ch] = true
+	return ch
+}
+
+// Unsubscribe removes an event subscriber.
+func (j *Journal) Unsubscribe(ch chan Event) {
+	j.subsMu.Lock()
+	defer j.subsMu.Unlock()
+	delete(j.subscribers, ch)
+	close(ch)
+}
+
+// pushLocked appends ev to the ring, overwriting the oldest event when full.
+func (j *Journal) pushLocked(ev Event) {
+	if j.count < len(j.ring) {
+		j.ring[(j.start+j.count)%len(j.ring)] = ev
+		j.count++
+		return
+	}
+	j.ring[j.start] = ev
+	j.start = (j.start + 1) % len(j.ring)
+}
+
+// persistLocked appends ev to the journal file, compacting it once it holds
+// twice as many lines as the ring.
+func (j *Journal) persistLocked(ev Event) {
+	if j.file == nil {
+		return
+	}
+	if j.appended >= 2*len(j.ring) {
+		if err := j.compactLocked(); err != nil {
+			logger.AddLog("ERROR", fmt.Sprintf("[Events] Failed to compact journal: %v", err))
+		}
+		// The compacted file already holds ev
+		return
+	}
+	line, _ := json.Marshal(ev)
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		logger.AddLog("ERROR", fmt.Sprintf("[Events] Failed to write journal: %v", err))
+		return
+	}
+	j.appended++
+}
+
+// compactLocked rewrites the journal file with the events in the ring and
+// reopens it for appending.
+func (j *Journal) compactLocked() error {
+	if j.file != nil {
+		j.file.Close()
+		j.file = nil
+	}
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write event journal: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for i := 0; i < j.count; i++ {
+		line, _ := json.Marshal(j.ring[(j.start+i)%len(j.ring)])
+		w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write event journal: %w", err)
+	}
+	f.Close()
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("failed to write event journal: %w", err)
+	}
+
+	j.file, err = os.OpenFile(j.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open event journal: %w", err)
+	}
+	j.appended = 0
+	return nil
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ids(evs []Event) []int64 {
+	out := []int64{}
+	for _, ev := range evs {
+		out = append(out, ev.ID)
+	}
+	return out
+}
+
+func TestJournal_Since(t *testing.T) {
+	j := NewJournal(3)
+	for i := 0; i < 5; i++ {
+		j.Record(ServerActivated, "work", "github", nil)
+	}
+
+	evs, truncated := j.Since(0, 0)
+	assert.Equal(t, []int64{3, 4, 5}, ids(evs))
+	assert.True(t, truncated, "events 1 and 2 were dropped")
+
+	evs, truncated = j.Since(2, 0)
+	assert.Equal(t, []int64{3, 4, 5}, ids(evs))
+	assert.False(t, truncated)
+
+	evs, _ = j.Since(3, 1)
+	assert.Equal(t, []int64{4}, ids(evs))
+
+	evs, _ = j.Since(5, 0)
+	assert.Empty(t, evs)
+	assert.Equal(t, int64(5), j.LastID())
+}
+
+func TestJournal_Persisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	j := NewJournal(2)
+	require.NoError(t, j.Open(path))
+	j.Record(ServerActivated, "work", "github", map[string]interface{}{"tools": 3})
+	j.Record(ServerCrashed, "work", "github", nil)
+	j.Record(ConfigChanged, "", "", nil)
+	// Enough to force a compaction
+	for i := 0; i < 4; i++ {
+		j.Record(ServerDeactivated, "work", "github", nil)
+	}
+	j.Close()
+
+	reopened := NewJournal(2)
+	require.NoError(t, reopened.Open(path))
+	defer reopened.Close()
+	evs, _ := reopened.Since(0, 0)
+	assert.Equal(t, []int64{6, 7}, ids(evs))
+
+	next := reopened.Record(ToolVerified, "", "github", nil)
+	assert.Equal(t, int64(8), next.ID, "IDs continue after a restart")
+}
+
+func TestJournal_Subscribe(t *testing.T) {
+	j := NewJournal(10)
+	ch := j.Subscribe()
+	defer j.Unsubscribe(ch)
+
+	j.Record(ServerActivated, "work", "github", nil)
+	ev := <-ch
+	assert.Equal(t, ServerActivated, ev.Type)
+	assert.Equal(t, "github", ev.Server)
+}
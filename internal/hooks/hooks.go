@@ -0,0 +1,173 @@
+// Package hooks runs the local commands profiles attach to their lifecycle
+// events: when the profile starts, before and after server activations,
+// after tool calls, and on any event journal entry.
+package hooks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/discovery"
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// maxOutput is how much of a hook's output is logged.
+const maxOutput = 16 << 10
+
+// waitDelay is how long a hook's output is read after it exits or times
+// out. Processes it left running in the background may hold its output
+// open, and would otherwise keep it from returning.
+const waitDelay = 2 * time.Second
+
+// Event is an occurrence hooks can run for.
+type Event struct {
+	Type    string // A journal event type or profile.Hook* constant
+	Profile string
+	Server  string
+	Tool    string
+	Error   string
+}
+
+// Runner runs the hooks of profiles, if settings enable hooks. A nil Runner
+// runs nothing.
+type Runner struct {
+	settings *profile.SettingsProvider
+	profiles func(id string) (profile.Profile, bool)
+}
+
+// NewRunner creates a runner looking profiles up with profiles. Settings
+// are read for every event, so enabling hooks takes effect immediately.
+func NewRunner(settings *profile.SettingsProvider, profiles func(id string) (profile.Profile, bool)) *Runner {
+	return &Runner{settings: settings, profiles: profiles}
+}
+
+// Run runs the hooks of ev's profile that match ev, one after another, and
+// returns the first failure. Hooks after a failed one still run.
+func (r *Runner) Run(ev Event) error {
+	if r == nil || !r.settings.Get().EnableHooks {
+		return nil
+	}
+	p, ok := r.profiles(ev.Profile)
+	if !ok {
+		return nil
+	}
+	var first error
+	for _, h := range p.Hooks {
+		if !h.Matches(ev.Type, ev.Server, ev.Tool) {
+			continue
+		}
+		if err := run(h, ev); err != nil && first == nil {
+			first = fmt.Errorf("hook %s failed: %w", h.DisplayName(), err)
+		}
+	}
+	return first
+}
+
+// Fire runs the hooks for ev in the background.
+func (r *Runner) Fire(ev Event) {
+	if r == nil || !r.settings.Get().EnableHooks {
+		return
+	}
+	go r.Run(ev)
+}
+
+// Watch runs hooks for journal's events until ctx is done.
+func (r *Runner) Watch(ctx context.Context, journal *events.Journal) {
+	ch := journal.Subscribe()
+	defer journal.Unsubscribe(ch)
+	for {
+		select {
+		case ev := <-ch:
+			if ev.Profile == "" {
+				continue
+			}
+			e := Event{Type: ev.Type, Profile: ev.Profile, Server: ev.Server}
+			if msg, ok := ev.Data["error"].(string); ok {
+				e.Error = msg
+			}
+			r.Fire(e)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// run runs one hook for ev and logs its output.
+func run(h profile.Hook, ev Event) error {
+	timeout := time.Duration(h.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = profile.DefaultHookTimeoutSeconds * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.Command, h.Args...)
+	discovery.PrepareCommand(cmd)
+	cmd.Env = os.Environ()
+	for k, v := range h.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Env = append(cmd.Env,
+		"SCOOTER_EVENT="+ev.Type,
+		"SCOOTER_PROFILE="+ev.Profile,
+		"SCOOTER_SERVER="+ev.Server,
+		"SCOOTER_TOOL="+ev.Tool,
+		"SCOOTER_ERROR="+ev.Error,
+	)
+	out := &cappedBuffer{max: maxOutput}
+	cmd.Stdout, cmd.Stderr = out, out
+	cmd.WaitDelay = waitDelay
+
+	name := h.DisplayName()
+	logger.AddLog("INFO", fmt.Sprintf("[Hook %s] Running for %s (profile %s)", name, ev.Type, ev.Profile))
+	started := time.Now()
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %v", timeout)
+	} else if errors.Is(err, exec.ErrWaitDelay) {
+		// The hook succeeded, leaving a process running, e.g. to start a VPN
+		logger.AddLog("DEBUG", fmt.Sprintf("[Hook %s] Left a background process running; its output isn't logged", name))
+		err = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out.Bytes()))
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			logger.AddLog("INFO", fmt.Sprintf("[Hook %s] %s", name, line))
+		}
+	}
+	if out.truncated {
+		logger.AddLog("INFO", fmt.Sprintf("[Hook %s] (output truncated after %d bytes)", name, maxOutput))
+	}
+	if err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("[Hook %s] Failed after %v: %v", name, time.Since(started).Round(time.Millisecond), err))
+		return err
+	}
+	logger.AddLog("DEBUG", fmt.Sprintf("[Hook %s] Finished in %v", name, time.Since(started).Round(time.Millisecond)))
+	return nil
+}
+
+// cappedBuffer keeps the first max bytes written to it.
+type cappedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); len(p) > room {
+		b.truncated = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,105 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHelperHook is the hook command of these tests, run from the test
+// binary itself.
+func TestHelperHook(t *testing.T) {
+	if os.Getenv("SCOOTER_TEST_HOOK") == "" {
+		t.Skip("helper process")
+	}
+	fmt.Printf("%s %s %s %s\n", os.Getenv("SCOOTER_EVENT"), os.Getenv("SCOOTER_PROFILE"), os.Getenv("SCOOTER_SERVER"), os.Getenv("HOOK_GREETING"))
+	if os.Getenv("SCOOTER_SERVER") == "broken" {
+		os.Exit(3)
+	}
+	os.Exit(0)
+}
+
+func hookLogs() []string {
+	var lines []string
+	for _, entry := range logger.GetLogs() {
+		if strings.HasPrefix(entry.Message, "[Hook test]") {
+			lines = append(lines, entry.Level+" "+entry.Message)
+		}
+	}
+	return lines
+}
+
+func TestRunner(t *testing.T) {
+	hook := profile.Hook{
+		Name:    "test",
+		Events:  []string{profile.HookServerActivating},
+		Match:   []string{"git*", "broken"},
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestHelperHook$"},
+		Env:     map[string]string{"SCOOTER_TEST_HOOK": "1", "HOOK_GREETING": "hi"},
+	}
+	profiles := map[string]profile.Profile{"work": {ID: "work", Hooks: []profile.Hook{hook}}}
+	settings := profile.NewSettingsProvider(profile.DefaultSettings())
+	r := NewRunner(settings, func(id string) (profile.Profile, bool) {
+		p, ok := profiles[id]
+		return p, ok
+	})
+
+	ev := Event{Type: profile.HookServerActivating, Profile: "work", Server: "github"}
+	require.NoError(t, r.Run(ev))
+	assert.Empty(t, hookLogs(), "hooks are off unless enabled in the settings")
+
+	settings.Update(func(s *profile.Settings) { s.EnableHooks = true })
+	require.NoError(t, r.Run(ev))
+	assert.Contains(t, hookLogs(), "INFO [Hook test] server.activating work github hi")
+
+	before := len(hookLogs())
+	require.NoError(t, r.Run(Event{Type: profile.HookServerActivating, Profile: "work", Server: "slack"}))
+	require.NoError(t, r.Run(Event{Type: profile.HookToolCalled, Profile: "work", Server: "github"}))
+	require.NoError(t, r.Run(Event{Type: profile.HookServerActivating, Profile: "home", Server: "github"}))
+	assert.Len(t, hookLogs(), before, "no hook matches these events")
+
+	err := r.Run(Event{Type: profile.HookServerActivating, Profile: "work", Server: "broken"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hook test failed")
+
+	var r0 *Runner
+	assert.NoError(t, r0.Run(ev), "a nil runner runs nothing")
+}
+
+func TestHookMatches(t *testing.T) {
+	h := profile.Hook{Events: []string{profile.HookToolCalled}, Match: []string{"brave_*"}}
+	assert.True(t, h.Matches(profile.HookToolCalled, "brave-search", "brave_web_search"))
+	assert.False(t, h.Matches(profile.HookToolCalled, "github", "create_issue"))
+	assert.False(t, h.Matches(profile.HookServerActivating, "brave-search", "brave_web_search"))
+
+	h.Match = nil
+	assert.True(t, h.Matches(profile.HookToolCalled, "", ""))
+}
+
+func TestRunBackgroundProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	// A hook starting a background process succeeds once it exits, though
+	// the process holds its output open
+	started := time.Now()
+	err := run(profile.Hook{Name: "vpn", Command: "sh", Args: []string{"-c", "sleep 60 &"}, TimeoutSeconds: 5}, Event{Type: profile.HookServerActivating, Profile: "work"})
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(started), 5*time.Second)
+
+	// One that times out returns too
+	started = time.Now()
+	err = run(profile.Hook{Name: "vpn", Command: "sh", Args: []string{"-c", "sleep 60 & sleep 60"}, TimeoutSeconds: 1}, Event{Type: profile.HookServerActivating, Profile: "work"})
+	assert.EqualError(t, err, "timed out after 1s")
+	assert.Less(t, time.Since(started), time.Second+waitDelay+time.Second)
+}
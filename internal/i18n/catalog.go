@@ -0,0 +1,113 @@
+package i18n
+
+var en = map[string]string{
+	// Control API
+	"api.id_required":       "id is required",
+	"api.profile_not_found": "profile not found",
+	"api.unauthorized":      "Unauthorized",
+	"api.forbidden":         "Forbidden",
+
+	// MCP gateway
+	"gateway.profile_not_found":     "Profile not found",
+	"gateway.method_not_found":      "Method not found",
+	"gateway.tool_not_found":        "Tool '%s' not found. Use scooter_find to discover available tools.",
+	"gateway.tool_not_active":       "Tool '%s' is not active. Use scooter_add('%s') to enable it first.",
+	"gateway.tool_not_allowed":      "Tool '%s' is not allowed for this profile. Add '%s' to AllowTools in your profile configuration.",
+	"gateway.tool_not_allowed_add":  "Tool '%s' is not allowed for this profile. Add it to AllowTools in your profile configuration before using scooter_add.",
+	"gateway.tool_error":            "Tool error: %v",
+	"gateway.tool_forbidden":        "Tool '%s' is not available to this API key.",
+	"gateway.auto_activate_limited": "Too many automatic activations; activate '%s' with scooter_activate or try again in a minute.",
+	"gateway.invalid_log_level":     "Invalid log level '%s'. Use debug, info, notice, warning, error, critical, alert or emergency.",
+	"gateway.invalid_cursor":        "Invalid cursor. Request tools/list without a cursor to start again.",
+	"gateway.rate_limited":          "Too many tool calls for this profile; try again in %d seconds.",
+	"gateway.quota_exceeded":        "The quota for '%s' is used up; try again in %d seconds.",
+
+	// Builtin tools
+	"builtin.tool_disabled":             "tool is disabled: %s",
+	"builtin.tool_name_required":        "tool_name or tool_names is required",
+	"builtin.tool_name_or_all":          "tool_name is required unless 'all' is true",
+	"builtin.activate.next_step":        "Call any of these tools DIRECTLY by name: %v",
+	"builtin.activate.important":        "Do NOT use 'scooter_call'. Just call the tool directly, e.g., brave_web_search({\"query\": \"...\"})",
+	"builtin.activate.async":            "%s is starting in the background. You will get a tools/list_changed notification when it is ready; check progress with scooter_list_active.",
+	"builtin.deactivate.all":            "All tool servers have been deactivated.",
+	"builtin.deactivate.one":            "Server '%s' has been deactivated.",
+	"builtin.unknown_builtin_tool":      "unknown builtin tool: %s",
+	"builtin.workspace.disabled":        "workspaces are not configured for this profile",
+	"builtin.workspace.path_required":   "path is required for 'delete'",
+	"builtin.workspace.unknown_action":  "unknown workspace action: %s",
+	"builtin.env.tool_name_required":    "tool_name is required",
+	"builtin.history.disabled":          "scooter_history is not enabled for this profile; set call_history: true",
+	"builtin.history.no_session":        "scooter_history needs an SSE session; connect to the /sse endpoint",
+	"builtin.result.handle_required":    "handle is required",
+	"builtin.result.not_found":          "no stored result with handle %s; only the latest results are kept, so call the tool again",
+	"builtin.result.truncated":          "Showing the first %d of %d bytes. Call scooter_result with handle %s and offset %d to read the rest.",
+	"builtin.result.summarized":         "This is an AI summary of a %d-byte result. Call scooter_result with handle %s to read the full result.",
+	"builtin.expand.tool_name_required": "tool_name is required",
+	"builtin.expand.not_active":         "tool %s is not provided by an active server; activate its server with scooter_activate first",
+
+	// CLI
+	"cli.status.title":        "Scooter Daemon Status:",
+	"cli.config.valid":        "Configuration in %s is valid.",
+	"cli.config.error":        "error:   %s",
+	"cli.config.warning":      "warning: %s",
+	"cli.version.daemon_down": "not running",
+	"cli.version.update":      "Update available: %s -> %s",
+	"cli.version.latest":      "You are running the latest version.",
+	"cli.version.check_fail":  "Could not check for updates: %v",
+}
+
+var es = map[string]string{
+	// Control API
+	"api.id_required":       "el id es obligatorio",
+	"api.profile_not_found": "perfil no encontrado",
+	"api.unauthorized":      "No autorizado",
+	"api.forbidden":         "Prohibido",
+
+	// MCP gateway
+	"gateway.profile_not_found":     "Perfil no encontrado",
+	"gateway.method_not_found":      "Método no encontrado",
+	"gateway.tool_not_found":        "No se encontró la herramienta '%s'. Usa scooter_find para descubrir las herramientas disponibles.",
+	"gateway.tool_not_active":       "La herramienta '%s' no está activa. Usa scooter_add('%s') para habilitarla primero.",
+	"gateway.tool_not_allowed":      "La herramienta '%s' no está permitida en este perfil. Añade '%s' a AllowTools en la configuración del perfil.",
+	"gateway.tool_not_allowed_add":  "La herramienta '%s' no está permitida en este perfil. Añádela a AllowTools en la configuración del perfil antes de usar scooter_add.",
+	"gateway.tool_error":            "Error de la herramienta: %v",
+	"gateway.tool_forbidden":        "La herramienta '%s' no está disponible para esta clave de API.",
+	"gateway.auto_activate_limited": "Demasiadas activaciones automáticas; activa '%s' con scooter_activate o inténtalo de nuevo en un minuto.",
+	"gateway.invalid_log_level":     "Nivel de registro no válido '%s'. Usa debug, info, notice, warning, error, critical, alert o emergency.",
+	"gateway.invalid_cursor":        "Cursor no válido. Solicita tools/list sin cursor para empezar de nuevo.",
+	"gateway.rate_limited":          "Demasiadas llamadas a herramientas en este perfil; inténtalo de nuevo en %d segundos.",
+	"gateway.quota_exceeded":        "La cuota de '%s' está agotada; inténtalo de nuevo en %d segundos.",
+
+	// Builtin tools
+	"builtin.tool_disabled":             "la herramienta está deshabilitada: %s",
+	"builtin.tool_name_required":        "tool_name o tool_names es obligatorio",
+	"builtin.tool_name_or_all":          "tool_name es obligatorio salvo que 'all' sea true",
+	"builtin.activate.next_step":        "Llama a cualquiera de estas herramientas DIRECTAMENTE por su nombre: %v",
+	"builtin.activate.important":        "NO uses 'scooter_call'. Llama a la herramienta directamente, p. ej., brave_web_search({\"query\": \"...\"})",
+	"builtin.activate.async":            "%s se está iniciando en segundo plano. Recibirás una notificación tools/list_changed cuando esté listo; consulta el progreso con scooter_list_active.",
+	"builtin.deactivate.all":            "Se han desactivado todos los servidores de herramientas.",
+	"builtin.deactivate.one":            "Se ha desactivado el servidor '%s'.",
+	"builtin.unknown_builtin_tool":      "herramienta integrada desconocida: %s",
+	"builtin.workspace.disabled":        "los espacios de trabajo no están configurados para este perfil",
+	"builtin.workspace.path_required":   "path es obligatorio para 'delete'",
+	"builtin.workspace.unknown_action":  "acción de espacio de trabajo desconocida: %s",
+	"builtin.env.tool_name_required":    "tool_name es obligatorio",
+	"builtin.history.disabled":          "scooter_history no está activado en este perfil; establece call_history: true",
+	"builtin.history.no_session":        "scooter_history necesita una sesión SSE; conéctate al endpoint /sse",
+	"builtin.result.handle_required":    "handle es obligatorio",
+	"builtin.result.not_found":          "no hay ningún resultado guardado con el identificador %s; solo se conservan los últimos resultados, así que vuelve a llamar a la herramienta",
+	"builtin.result.truncated":          "Se muestran los primeros %d de %d bytes. Llama a scooter_result con el identificador %s y el desplazamiento %d para leer el resto.",
+	"builtin.result.summarized":         "Esto es un resumen generado por IA de un resultado de %d bytes. Llama a scooter_result con el identificador %s para leer el resultado completo.",
+	"builtin.expand.tool_name_required": "tool_name es obligatorio",
+	"builtin.expand.not_active":         "ningún servidor activo proporciona la herramienta %s; activa primero su servidor con scooter_activate",
+
+	// CLI
+	"cli.status.title":        "Estado del demonio de Scooter:",
+	"cli.config.valid":        "La configuración en %s es válida.",
+	"cli.config.error":        "error:       %s",
+	"cli.config.warning":      "advertencia: %s",
+	"cli.version.daemon_down": "no está en ejecución",
+	"cli.version.update":      "Actualización disponible: %s -> %s",
+	"cli.version.latest":      "Estás usando la última versión.",
+	"cli.version.check_fail":  "No se pudo comprobar si hay actualizaciones: %v",
+}
@@ -0,0 +1,106 @@
+// Package i18n provides the message catalog for user-facing strings returned by
+// the control API, the MCP gateway's builtin tools and the CLI.
+//
+// Messages are fmt format strings keyed by a dotted identifier. Lookups fall
+// back to English, and then to the key itself, so a missing translation never
+// produces an empty message.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is used when no supported locale is requested.
+const DefaultLocale = "en"
+
+var catalogs = map[string]map[string]string{
+	"en": en,
+	"es": es,
+}
+
+// T returns the message for key in locale, formatted with args.
+func T(locale, key string, args ...interface{}) string {
+	msg, ok := catalogs[Normalize(locale)][key]
+	if !ok {
+		msg, ok = catalogs[DefaultLocale][key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Supported returns the available locale codes.
+func Supported() []string {
+	locales := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// IsSupported reports whether a catalog exists for locale's language.
+func IsSupported(locale string) bool {
+	_, ok := catalogs[language(locale)]
+	return ok
+}
+
+// Normalize maps a locale such as "es-MX" or "es_MX.UTF-8" to a supported
+// catalog code, or DefaultLocale if none matches.
+func Normalize(locale string) string {
+	if IsSupported(locale) {
+		return language(locale)
+	}
+	return DefaultLocale
+}
+
+// language strips region and encoding suffixes: "es_MX.UTF-8" -> "es".
+func language(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_."); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+// FromAcceptLanguage picks the best supported locale from an Accept-Language
+// header, returning fallback if the header names none.
+func FromAcceptLanguage(header, fallback string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		lang := language(tag)
+		if _, ok := catalogs[lang]; ok && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	if best == "" {
+		return Normalize(fallback)
+	}
+	return best
+}
+
+// FromEnv returns the locale configured for the process via SCOOTER_LOCALE
+// or the standard LC_ALL / LC_MESSAGES / LANG variables.
+func FromEnv() string {
+	for _, name := range []string{"SCOOTER_LOCALE", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" && v != "C" && v != "POSIX" {
+			return Normalize(v)
+		}
+	}
+	return DefaultLocale
+}
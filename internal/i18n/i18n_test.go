@@ -0,0 +1,29 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "Server 'github' has been deactivated.", T("en", "builtin.deactivate.one", "github"))
+	assert.Equal(t, "Se ha desactivado el servidor 'github'.", T("es-MX", "builtin.deactivate.one", "github"))
+	assert.Equal(t, "Server 'github' has been deactivated.", T("fr", "builtin.deactivate.one", "github"))
+	assert.Equal(t, "no.such.key", T("es", "no.such.key"))
+}
+
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	for locale, catalog := range catalogs {
+		for key := range en {
+			assert.Contains(t, catalog, key, "locale %s is missing %s", locale, key)
+		}
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	assert.Equal(t, "es", FromAcceptLanguage("es-ES,es;q=0.9,en;q=0.8", "en"))
+	assert.Equal(t, "en", FromAcceptLanguage("fr-FR,en;q=0.5,es;q=0.4", "es"))
+	assert.Equal(t, "es", FromAcceptLanguage("fr-FR", "es"))
+	assert.Equal(t, "en", FromAcceptLanguage("", ""))
+}
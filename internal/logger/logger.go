@@ -6,7 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,27 +17,91 @@ type LogEntry struct {
 	Timestamp string `json:"timestamp"`
 	Level     string `json:"level"`
 	Message   string `json:"message"`
+
+	// Event names a control-plane event the desktop app acts on (for example
+	// "credentials_required"); Data carries its details. Empty for plain logs.
+	Event string                 `json:"event,omitempty"`
+	Data  map[string]interface{} `json:"data,omitempty"`
 }
 
+// maxEntries is how many entries are kept in memory.
+const maxEntries = 1000
+
 var (
 	mu          sync.RWMutex
-	logEntries  []LogEntry
-	maxEntries  = 1000 // Keep last 1000 in memory
+	ring        [maxEntries]LogEntry // in-memory entries; the oldest is at ringStart
+	ringStart   int
+	ringCount   int
 	maxFileSize = int64(5 * 1024 * 1024) // 5MB limit
 	logFilePath string
 	logFile     *os.File
 	logChan     = make(chan LogEntry, 100)
 	done        chan struct{}
 	workerDone  chan struct{}
-	subscribers = make(map[chan LogEntry]bool)
-	subsMu      sync.RWMutex
+
+	// subscribers is replaced, never modified, so add can fan out without
+	// locking or allocating; subsMu serialises the replacements
+	subscribers atomic.Pointer[[]chan LogEntry]
+	subsMu      sync.Mutex
 
 	// Redaction regex for sk-scooter keys
 	scooterKeyRegex = regexp.MustCompile(`sk-scooter-[a-zA-Z0-9]+`)
 
 	verboseEnabled bool
+	consoleLevel   = levelAll // entries below this level are not printed
+)
+
+// Console levels, lowest first. Unknown levels rank as INFO.
+const (
+	levelAll = iota - 1
+	levelTrace
+	levelDebug
+	levelInfo
+	levelWarn
+	levelError
+	levelOff
 )
 
+func levelRank(level string) int {
+	switch strings.ToUpper(level) {
+	case "TRACE":
+		return levelTrace
+	case "DEBUG":
+		return levelDebug
+	case "WARN", "WARNING":
+		return levelWarn
+	case "ERROR":
+		return levelError
+	}
+	return levelInfo
+}
+
+// IsConsoleLevel reports whether level is accepted by SetConsoleLevel.
+func IsConsoleLevel(level string) bool {
+	switch strings.ToUpper(level) {
+	case "", "TRACE", "DEBUG", "INFO", "WARN", "WARNING", "ERROR", "OFF":
+		return true
+	}
+	return false
+}
+
+// SetConsoleLevel limits what is printed to stdout to entries at or above
+// level; "" prints everything and "OFF" nothing. The in-memory buffer, the
+// log file and subscribers still receive every entry.
+func SetConsoleLevel(level string) {
+	rank := levelAll
+	switch up := strings.ToUpper(level); up {
+	case "":
+	case "OFF":
+		rank = levelOff
+	default:
+		rank = levelRank(up)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	consoleLevel = rank
+}
+
 // SetVerbose enables or disables TRACE-level logging.
 func SetVerbose(enabled bool) {
 	mu.Lock()
@@ -43,6 +109,14 @@ func SetVerbose(enabled bool) {
 	verboseEnabled = enabled
 }
 
+// Verbose reports whether TRACE-level logging is enabled, so callers can
+// skip building expensive trace messages.
+func Verbose() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return verboseEnabled
+}
+
 // Trace adds a log entry if verbose logging is enabled.
 func Trace(message string) {
 	mu.RLock()
@@ -92,24 +166,37 @@ func Init(appDir string) error {
 
 // AddLog adds a new log entry.
 func AddLog(level, message string) {
-	// Redact sensitive info
-	message = scooterKeyRegex.ReplaceAllString(message, "sk-scooter-REDACTED")
+	add(LogEntry{Level: level, Message: message})
+}
+
+// AddEvent logs a control-plane event. It is stored and streamed like any
+// other entry, with the event name and data attached for the desktop app.
+func AddEvent(event, message string, data map[string]interface{}) {
+	add(LogEntry{Level: "INFO", Message: message, Event: event, Data: data})
+}
 
-	entry := LogEntry{
-		Timestamp: time.Now().Format(time.RFC3339),
-		Level:     level,
-		Message:   message,
+func add(entry LogEntry) {
+	// Redact sensitive info
+	if strings.Contains(entry.Message, "sk-scooter-") {
+		entry.Message = scooterKeyRegex.ReplaceAllString(entry.Message, "sk-scooter-REDACTED")
 	}
+	entry.Timestamp = time.Now().Format(time.RFC3339)
 
 	mu.Lock()
-	logEntries = append(logEntries, entry)
-	if len(logEntries) > maxEntries {
-		logEntries = logEntries[len(logEntries)-maxEntries:]
+	if ringCount < maxEntries {
+		ring[(ringStart+ringCount)%maxEntries] = entry
+		ringCount++
+	} else {
+		ring[ringStart] = entry
+		ringStart = (ringStart + 1) % maxEntries
 	}
+	toConsole := levelRank(entry.Level) >= consoleLevel
 	mu.Unlock()
 
 	// Print to console for development visibility
-	fmt.Printf("[%s] [%s] %s\n", entry.Timestamp, level, message)
+	if toConsole {
+		fmt.Printf("[%s] [%s] %s\n", entry.Timestamp, entry.Level, entry.Message)
+	}
 
 	// Send to file worker
 	select {
@@ -119,15 +206,15 @@ func AddLog(level, message string) {
 	}
 
 	// Notify subscribers
-	subsMu.RLock()
-	for sub := range subscribers {
-		select {
-		case sub <- entry:
-		default:
-			// Drop if subscriber is slow
+	if subs := subscribers.Load(); subs != nil {
+		for _, sub := range *subs {
+			select {
+			case sub <- entry:
+			default:
+				// Drop if subscriber is slow
+			}
 		}
 	}
-	subsMu.RUnlock()
 }
 
 // Subscribe returns a channel that receives new log entries.
@@ -135,26 +222,48 @@ func Subscribe() chan LogEntry {
 	subsMu.Lock()
 	defer subsMu.Unlock()
 	ch := make(chan LogEntry, 100)
-	subscribers[ch] = true
+	var subs []chan LogEntry
+	if old := subscribers.Load(); old != nil {
+		subs = append(subs, *old...)
+	}
+	subs = append(subs, ch)
+	subscribers.Store(&subs)
 	return ch
 }
 
-// Unsubscribe removes a log subscriber.
+// Unsubscribe removes a log subscriber. The channel is left open: an add
+// that loaded the old subscriber list may still send to it.
 func Unsubscribe(ch chan LogEntry) {
 	subsMu.Lock()
 	defer subsMu.Unlock()
-	delete(subscribers, ch)
-	close(ch)
+	var subs []chan LogEntry
+	if old := subscribers.Load(); old != nil {
+		for _, sub := range *old {
+			if sub != ch {
+				subs = append(subs, sub)
+			}
+		}
+	}
+	subscribers.Store(&subs)
 }
 
-// GetLogs returns all logs currently in memory.
+// GetLogs returns all logs currently in memory, oldest first.
 func GetLogs() []LogEntry {
+	return Tail(maxEntries)
+}
+
+// Tail returns up to the n most recent log entries, oldest first.
+func Tail(n int) []LogEntry {
 	mu.RLock()
 	defer mu.RUnlock()
-	
-	// Return a copy
-	res := make([]LogEntry, len(logEntries))
-	copy(res, logEntries)
+
+	if n > ringCount {
+		n = ringCount
+	}
+	res := make([]LogEntry, n)
+	for i := range res {
+		res[i] = ring[(ringStart+ringCount-n+i)%maxEntries]
+	}
 	return res
 }
 
@@ -163,7 +272,8 @@ func ClearLogs() error {
 	mu.Lock()
 	defer mu.Unlock()
 
-	logEntries = []LogEntry{}
+	ring = [maxEntries]LogEntry{}
+	ringStart, ringCount = 0, 0
 	
 	if logFile != nil {
 		logFile.Close()
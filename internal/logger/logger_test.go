@@ -0,0 +1,67 @@
+package logger_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mcp-scooter/scooter/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTail(t *testing.T) {
+	logger.SetConsoleLevel("OFF")
+	defer logger.SetConsoleLevel("")
+
+	for i := 0; i < 1005; i++ {
+		logger.AddLog("INFO", fmt.Sprintf("entry %d", i))
+	}
+	logs := logger.GetLogs()
+	assert.Len(t, logs, 1000)
+	assert.Equal(t, "entry 5", logs[0].Message)
+	assert.Equal(t, "entry 1004", logs[999].Message)
+
+	tail := logger.Tail(2)
+	assert.Equal(t, "entry 1003", tail[0].Message)
+	assert.Equal(t, "entry 1004", tail[1].Message)
+}
+
+func TestSubscribe(t *testing.T) {
+	logger.SetConsoleLevel("OFF")
+	defer logger.SetConsoleLevel("")
+
+	ch := logger.Subscribe()
+	logger.AddLog("WARN", "key sk-scooter-abc123 leaked")
+	entry := <-ch
+	assert.Equal(t, "key sk-scooter-REDACTED leaked", entry.Message)
+
+	logger.Unsubscribe(ch)
+	logger.AddLog("INFO", "after unsubscribe")
+	assert.Empty(t, ch)
+}
+
+func TestIsConsoleLevel(t *testing.T) {
+	assert.True(t, logger.IsConsoleLevel(""))
+	assert.True(t, logger.IsConsoleLevel("warn"))
+	assert.True(t, logger.IsConsoleLevel("OFF"))
+	assert.False(t, logger.IsConsoleLevel("verbose"))
+}
+
+// BenchmarkAddLog fans every entry out to ten subscribers. It should report
+// the same allocations as with no subscribers at all.
+func BenchmarkAddLog(b *testing.B) {
+	logger.SetConsoleLevel("OFF")
+	defer logger.SetConsoleLevel("")
+
+	for _, n := range []int{0, 10} {
+		b.Run(fmt.Sprintf("subscribers=%d", n), func(b *testing.B) {
+			for i := 0; i < n; i++ {
+				ch := logger.Subscribe()
+				defer logger.Unsubscribe(ch)
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				logger.AddLog("INFO", "benchmark entry")
+			}
+		})
+	}
+}
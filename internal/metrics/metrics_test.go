@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("test_requests_total", "Requests.", "method")
+	latency := r.NewHistogram("test_latency_seconds", "Latency.", []float64{0.1, 1}, "tool")
+
+	requests.Inc("tools/call")
+	requests.Inc("tools/call")
+	requests.Inc(`say "hi"`)
+	latency.Observe(0.05, "search")
+	latency.Observe(0.5, "search")
+	latency.Observe(3, "search")
+
+	var b strings.Builder
+	r.WriteText(&b)
+	WriteGauge(&b, "test_clients", "Clients.", []string{"profile"}, []Sample{{LabelValues: []string{"work"}, Value: 2}})
+
+	assert.Equal(t, `# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{method="say \"hi\""} 1
+test_requests_total{method="tools/call"} 2
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{tool="search",le="0.1"} 1
+test_latency_seconds_bucket{tool="search",le="1"} 2
+test_latency_seconds_bucket{tool="search",le="+Inf"} 3
+test_latency_seconds_sum{tool="search"} 3.55
+test_latency_seconds_count{tool="search"} 3
+# HELP test_clients Clients.
+# TYPE test_clients gauge
+test_clients{profile="work"} 2
+`, b.String())
+}
+
+func TestGatewayMethod(t *testing.T) {
+	assert.Equal(t, "tools/call", GatewayMethod("tools/call"))
+	assert.Equal(t, "other", GatewayMethod("made/up"))
+}
@@ -0,0 +1,59 @@
+package metrics
+
+// Scooter's metrics. Gauges, such as active servers and SSE clients, are
+// read when /metrics is scraped rather than kept here.
+var (
+	// GatewayRequests counts JSON-RPC requests and notifications the
+	// gateway received, by method.
+	GatewayRequests = Default.NewCounter("scooter_gateway_requests_total",
+		"MCP requests and notifications received by the gateway.", "method")
+
+	// ToolCallDuration measures calls to downstream servers' tools.
+	ToolCallDuration = Default.NewHistogram("scooter_tool_call_duration_seconds",
+		"Time taken by tool calls to downstream servers.", DefaultBuckets, "server", "tool")
+
+	// ServerRestarts counts automatic restarts of crashed or unresponsive
+	// servers.
+	ServerRestarts = Default.NewCounter("scooter_server_restarts_total",
+		"Automatic restarts of crashed or unresponsive servers.", "server")
+
+	// Panics counts handler panics that were recovered, by server ("control"
+	// or "gateway").
+	Panics = Default.NewCounter("scooter_panics_total",
+		"Handler panics recovered, by server.", "server")
+
+	// Errors counts failures by type (see the Error constants).
+	Errors = Default.NewCounter("scooter_errors_total",
+		"Errors by type.", "type")
+)
+
+// Error types of the Errors counter.
+const (
+	ErrorAuthFailed       = "auth_failed"
+	ErrorRateLimited      = "rate_limited"
+	ErrorToolCall         = "tool_call"
+	ErrorActivationFailed = "activation_failed"
+	ErrorServerCrashed    = "server_crashed"
+)
+
+// gatewayMethods are the MCP methods counted under their own name; others
+// are counted as "other" so clients can't create unbounded series.
+var gatewayMethods = map[string]bool{
+	"initialize": true, "ping": true,
+	"tools/list": true, "tools/call": true,
+	"resources/list": true, "resources/read": true, "resources/templates/list": true,
+	"resources/subscribe": true, "resources/unsubscribe": true,
+	"prompts/list": true, "prompts/get": true,
+	"completion/complete": true, "logging/setLevel": true,
+	"notifications/initialized": true, "notifications/cancelled": true,
+	"notifications/progress": true, "notifications/roots/list_changed": true,
+}
+
+// GatewayMethod returns the label a gateway request's method is counted
+// under.
+func GatewayMethod(method string) string {
+	if gatewayMethods[method] {
+		return method
+	}
+	return "other"
+}
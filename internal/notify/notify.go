@@ -0,0 +1,132 @@
+// Package notify shows native desktop notifications for event journal
+// entries, so crashes and other critical events are noticed while the
+// desktop app is closed.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// Notifier shows one notification.
+type Notifier interface {
+	Notify(title, message string) error
+}
+
+// Native returns the notifier of this OS: a toast on Windows, Notification
+// Center on macOS and notify-send (libnotify) elsewhere.
+func Native() Notifier {
+	return nativeNotifier{}
+}
+
+// repeatInterval is how long further events of one type and server are
+// dropped after a notification, so a crash loop shows one notification.
+const repeatInterval = time.Minute
+
+// Dispatcher notifies about the journal events that the settings select.
+type Dispatcher struct {
+	journal  *events.Journal
+	settings *profile.SettingsProvider
+	notifier Notifier
+
+	mu   sync.Mutex
+	last map[string]time.Time // type + server -> last notification
+	now  func() time.Time
+}
+
+// NewDispatcher creates a dispatcher for journal's events. Settings are read
+// for every event, so toggling notifications takes effect immediately.
+func NewDispatcher(journal *events.Journal, settings *profile.SettingsProvider, notifier Notifier) *Dispatcher {
+	return &Dispatcher{
+		journal:  journal,
+		settings: settings,
+		notifier: notifier,
+		last:     make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Run dispatches events until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ch := d.journal.Subscribe()
+	defer d.journal.Unsubscribe(ch)
+	for {
+		select {
+		case ev := <-ch:
+			d.Handle(ev)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Handle notifies about ev if the settings select it, and reports whether it
+// did.
+func (d *Dispatcher) Handle(ev events.Event) bool {
+	cfg := d.settings.Get().Notifications
+	if !cfg.Enabled || !slices.Contains(cfg.EventTypes(), ev.Type) {
+		return false
+	}
+
+	key := ev.Type + "\x00" + ev.Server
+	d.mu.Lock()
+	now := d.now()
+	if last, ok := d.last[key]; ok && now.Sub(last) < repeatInterval {
+		d.mu.Unlock()
+		return false
+	}
+	d.last[key] = now
+	d.mu.Unlock()
+
+	title, message := Format(ev)
+	if err := d.notifier.Notify(title, message); err != nil {
+		logger.AddLog("WARN", fmt.Sprintf("[Notify] Failed to show notification: %v", err))
+		return false
+	}
+	return true
+}
+
+// Format returns the title and message of ev's notification.
+func Format(ev events.Event) (title, message string) {
+	server := ev.Server
+	if server == "" {
+		server = "A server"
+	}
+	switch ev.Type {
+	case events.ServerCrashed:
+		title = fmt.Sprintf("%s crashed", server)
+	case events.ServerActivationFailed:
+		title = fmt.Sprintf("%s failed to start", server)
+	case events.ServerQuarantined:
+		title = fmt.Sprintf("%s was quarantined", server)
+	case events.ServerRestarted:
+		title = fmt.Sprintf("%s was restarted", server)
+	case events.ServerActivated:
+		title = fmt.Sprintf("%s started", server)
+	case events.ServerDeactivated:
+		title = fmt.Sprintf("%s stopped", server)
+	case events.HandlerPanicked:
+		title = "Internal error"
+	default:
+		title = ev.Type
+	}
+	title = "Scooter: " + title
+
+	if errMsg, ok := ev.Data["error"].(string); ok && errMsg != "" {
+		message = errMsg
+	}
+	if ev.Profile != "" {
+		if message != "" {
+			message += "\n"
+		}
+		message += fmt.Sprintf("Profile: %s", ev.Profile)
+	}
+	return title, message
+}
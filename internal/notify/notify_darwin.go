@@ -0,0 +1,15 @@
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+type nativeNotifier struct{}
+
+func (nativeNotifier) Notify(title, message string) error {
+	// strconv.Quote escapes quotes and backslashes the way AppleScript reads them
+	script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(message), strconv.Quote(title))
+	return exec.Command("osascript", "-e", script).Run()
+}
@@ -0,0 +1,9 @@
+package notify
+
+import "os/exec"
+
+type nativeNotifier struct{}
+
+func (nativeNotifier) Notify(title, message string) error {
+	return exec.Command("notify-send", "--app-name=Scooter", title, message).Run()
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !windows
+
+package notify
+
+import "os/exec"
+
+type nativeNotifier struct{}
+
+// Most other desktops (the BSDs) have libnotify too.
+func (nativeNotifier) Notify(title, message string) error {
+	return exec.Command("notify-send", "--app-name=Scooter", title, message).Run()
+}
@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/domain/profile"
+	"github.com/mcp-scooter/scooter/internal/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNotifier struct {
+	shown []string
+	err   error
+}
+
+func (f *fakeNotifier) Notify(title, message string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.shown = append(f.shown, title+"|"+message)
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	settings := profile.NewSettingsProvider(profile.DefaultSettings())
+	fake := &fakeNotifier{}
+	d := NewDispatcher(events.NewJournal(10), settings, fake)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	crash := events.Event{Type: events.ServerCrashed, Profile: "work", Server: "github", Data: map[string]interface{}{"error": "exit status 1"}}
+	assert.False(t, d.Handle(crash), "notifications are off by default")
+
+	settings.Update(func(s *profile.Settings) { s.Notifications.Enabled = true })
+	assert.True(t, d.Handle(crash))
+	require.Len(t, fake.shown, 1)
+	assert.Equal(t, "Scooter: github crashed|exit status 1\nProfile: work", fake.shown[0])
+
+	assert.False(t, d.Handle(crash), "repeats within a minute are dropped")
+	assert.True(t, d.Handle(events.Event{Type: events.ServerCrashed, Server: "slack"}), "other servers still notify")
+	now = now.Add(repeatInterval)
+	assert.True(t, d.Handle(crash))
+
+	assert.False(t, d.Handle(events.Event{Type: events.ServerActivated, Server: "github"}), "not a default event type")
+	settings.Update(func(s *profile.Settings) { s.Notifications.Events = []string{events.ServerActivated} })
+	assert.True(t, d.Handle(events.Event{Type: events.ServerActivated, Server: "github"}))
+	assert.False(t, d.Handle(events.Event{Type: events.ServerQuarantined, Server: "x"}), "the list replaces the defaults")
+
+	fake.err = errors.New("no notification daemon")
+	assert.False(t, d.Handle(events.Event{Type: events.ServerActivated, Server: "slack"}))
+}
+
+func TestDispatcherRun(t *testing.T) {
+	settings := profile.NewSettingsProvider(profile.DefaultSettings())
+	settings.Update(func(s *profile.Settings) { s.Notifications.Enabled = true })
+	journal := events.NewJournal(10)
+	notified := make(chan string, 1)
+	d := NewDispatcher(journal, settings, notifierFunc(func(title, _ string) error {
+		notified <- title
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	// The subscription may not exist yet when the first event is recorded
+	deadline := time.After(5 * time.Second)
+	for {
+		journal.Record(events.ServerQuarantined, "work", "flaky", nil)
+		select {
+		case title := <-notified:
+			assert.Equal(t, "Scooter: flaky was quarantined", title)
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no notification")
+		}
+	}
+}
+
+type notifierFunc func(title, message string) error
+
+func (f notifierFunc) Notify(title, message string) error { return f(title, message) }
@@ -0,0 +1,28 @@
+package notify
+
+import (
+	"os/exec"
+	"strings"
+)
+
+type nativeNotifier struct{}
+
+// toastScript shows a toast through the WinRT notification API, which
+// PowerShell can load without extra modules. Toasts need a registered app
+// ID, so PowerShell's own is used. The text arrives in environment
+// variables, so it needs no escaping.
+const toastScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:SCOOTER_TOAST_TITLE)) | Out-Null
+$text.Item(1).AppendChild($template.CreateTextNode($env:SCOOTER_TOAST_MESSAGE)) | Out-Null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe').Show($toast)
+`
+
+func (nativeNotifier) Notify(title, message string) error {
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", strings.TrimSpace(toastScript))
+	cmd.Env = append(cmd.Environ(), "SCOOTER_TOAST_TITLE="+title, "SCOOTER_TOAST_MESSAGE="+message)
+	return cmd.Run()
+}
@@ -0,0 +1,286 @@
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// DefaultConnections is how many idle connections Scooter keeps open to the
+// relay, i.e. how many clients can connect at the same moment.
+const DefaultConnections = 4
+
+// dialTimeout bounds connecting to the relay and the upgrade handshake.
+const dialTimeout = 15 * time.Second
+
+// Config is where and how to connect to a relay.
+type Config struct {
+	// RelayURL is the relay's https URL, e.g. https://relay.example.com.
+	RelayURL string
+	// Token is the secret the relay was started with.
+	Token string
+	// CAFile is a PEM file of CA certificates to trust the relay's
+	// certificate with, e.g. a self-signed one; empty uses the system's.
+	CAFile string
+	// Connections is the number of idle connections to keep; 0 means
+	// DefaultConnections.
+	Connections int
+	// Profile is the profile served, for Status.
+	Profile string
+}
+
+// Status is the tunnel's state, as reported by /api/status.
+type Status struct {
+	// State is "connecting", "connected" (idle connections are waiting for
+	// clients) or "error".
+	State             string     `json:"state"`
+	Relay             string     `json:"relay"`
+	Profile           string     `json:"profile"`
+	IdleConnections   int        `json:"idle_connections"`
+	ActiveConnections int        `json:"active_connections"`
+	ConnectedSince    *time.Time `json:"connected_since,omitempty"`
+	LastError         string     `json:"last_error,omitempty"`
+}
+
+// Client keeps Scooter's connections to a relay and serves the requests
+// arriving on them with a handler.
+type Client struct {
+	cfg       Config
+	relay     *url.URL
+	handler   http.Handler
+	tlsConfig *tls.Config
+	accept    chan net.Conn
+
+	mu             sync.Mutex
+	idle, active   int
+	connectedSince time.Time
+	lastError      string
+}
+
+// NewClient creates a client for cfg serving requests with handler. The
+// relay URL must be https.
+func NewClient(cfg Config, handler http.Handler) (*Client, error) {
+	u, err := url.Parse(cfg.RelayURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid relay url: %w", err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("relay url %q must be an https URL", cfg.RelayURL)
+	}
+	if cfg.Token == "" {
+		return nil, errors.New("a relay token is required")
+	}
+	if cfg.Connections <= 0 {
+		cfg.Connections = DefaultConnections
+	}
+
+	tlsConfig := &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read relay CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &Client{
+		cfg:       cfg,
+		relay:     u,
+		handler:   handler,
+		tlsConfig: tlsConfig,
+		accept:    make(chan net.Conn),
+	}, nil
+}
+
+// Run keeps the connections to the relay and serves requests until ctx is
+// done.
+func (c *Client) Run(ctx context.Context) {
+	logger.AddLog("INFO", fmt.Sprintf("[Tunnel] Exposing profile '%s' through %s", c.cfg.Profile, c.relay.Host))
+	srv := &http.Server{Handler: c.handler, ReadHeaderTimeout: 30 * time.Second}
+	go srv.Serve(&listener{client: c, ctx: ctx})
+	for i := 0; i < c.cfg.Connections; i++ {
+		go c.keepConnection(ctx)
+	}
+	<-ctx.Done()
+	srv.Close()
+}
+
+// keepConnection keeps one idle connection open to the relay, opening a new
+// one whenever a client takes it or it drops.
+func (c *Client) keepConnection(ctx context.Context) {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		conn, r, err := c.dial(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.setError(err)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			backoff = min(backoff*2, time.Minute)
+			continue
+		}
+		backoff = time.Second
+		c.addIdle(1)
+
+		// The relay writes nothing until it hands a client over
+		stop := context.AfterFunc(ctx, func() { conn.Close() })
+		_, err = r.Peek(1)
+		stop()
+		if err != nil {
+			c.addIdle(-1)
+			conn.Close()
+			if ctx.Err() == nil {
+				c.setError(fmt.Errorf("relay closed the connection: %w", err))
+			}
+			continue
+		}
+
+		c.addActive(1)
+		c.addIdle(-1)
+		bc := &bufferedConn{Conn: conn, r: r, onClose: func() { c.addActive(-1) }}
+		select {
+		case c.accept <- bc:
+		case <-ctx.Done():
+			bc.Close()
+			return
+		}
+	}
+}
+
+// dial opens a connection to the relay and upgrades it to a tunnel.
+func (c *Client) dial(ctx context.Context) (net.Conn, *bufio.Reader, error) {
+	host := c.relay.Host
+	if c.relay.Port() == "" {
+		host = net.JoinHostPort(c.relay.Hostname(), "443")
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	d := &tls.Dialer{NetDialer: &net.Dialer{}, Config: c.tlsConfig}
+	conn, err := d.DialContext(dialCtx, "tcp", host)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to relay: %w", err)
+	}
+
+	req, _ := http.NewRequest("GET", "https://"+c.relay.Host+UpgradePath, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", Protocol)
+	req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+	r := bufio.NewReader(conn)
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to register with relay: %w", err)
+	}
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to register with relay: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return nil, nil, fmt.Errorf("relay refused the tunnel: %s", resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, r, nil
+}
+
+func (c *Client) setError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lastError != err.Error() {
+		logger.AddLog("WARN", fmt.Sprintf("[Tunnel] %v", err))
+	}
+	c.lastError = err.Error()
+}
+
+func (c *Client) addIdle(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.idle += n
+	if n > 0 {
+		if c.connectedSince.IsZero() {
+			c.connectedSince = time.Now()
+			logger.AddLog("INFO", fmt.Sprintf("[Tunnel] Connected to %s", c.relay.Host))
+		}
+		c.lastError = ""
+	} else if c.idle == 0 && c.active == 0 {
+		c.connectedSince = time.Time{}
+	}
+}
+
+func (c *Client) addActive(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active += n
+}
+
+// Status returns the tunnel's state.
+func (c *Client) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := Status{
+		State:             "connecting",
+		Relay:             c.relay.Host,
+		Profile:           c.cfg.Profile,
+		IdleConnections:   c.idle,
+		ActiveConnections: c.active,
+		LastError:         c.lastError,
+	}
+	switch {
+	case c.idle > 0:
+		s.State = "connected"
+	case c.lastError != "":
+		s.State = "error"
+	}
+	if !c.connectedSince.IsZero() {
+		since := c.connectedSince.UTC()
+		s.ConnectedSince = &since
+	}
+	return s
+}
+
+// listener hands the http.Server the relay connections clients arrived on.
+type listener struct {
+	client *Client
+	ctx    context.Context
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.client.accept:
+		return conn, nil
+	case <-l.ctx.Done():
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *listener) Close() error { return nil }
+
+func (l *listener) Addr() net.Addr { return relayAddr(l.client.relay.Host) }
+
+// relayAddr is the address of a tunnel listener: the relay's.
+type relayAddr string
+
+func (a relayAddr) Network() string { return "tunnel" }
+func (a relayAddr) String() string  { return string(a) }
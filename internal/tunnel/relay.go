@@ -0,0 +1,154 @@
+package tunnel
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcp-scooter/scooter/internal/logger"
+)
+
+// maxIdle is how many idle Scooter connections a relay keeps; older ones
+// are closed first.
+const maxIdle = 32
+
+// handshakeTimeout bounds reading the start of a connection.
+const handshakeTimeout = 30 * time.Second
+
+// Relay pairs client connections with the idle connections of the Scooter
+// that registered with its token. It expects TLS connections and never
+// looks inside client traffic.
+type Relay struct {
+	token string
+
+	mu   sync.Mutex
+	idle []net.Conn // Oldest first
+}
+
+// NewRelay creates a relay accepting Scooter connections with token.
+func NewRelay(token string) *Relay {
+	return &Relay{token: token}
+}
+
+// Serve handles the connections of ln, a TLS listener, until it fails.
+func (r *Relay) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go r.handle(conn)
+	}
+}
+
+// Connections returns the number of idle Scooter connections.
+func (r *Relay) Connections() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.idle)
+}
+
+// handle tells a Scooter connection from a client by its first line.
+func (r *Relay) handle(conn net.Conn) {
+	conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	br := bufio.NewReader(conn)
+	prefix := "GET " + UpgradePath + " "
+	start, err := br.Peek(len(prefix))
+	if err != nil && len(start) == 0 {
+		conn.Close()
+		return
+	}
+	if string(start) == prefix {
+		r.register(conn, br)
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+	r.forward(&bufferedConn{Conn: conn, r: br})
+}
+
+// register checks a Scooter connection's token and keeps it for a client.
+func (r *Relay) register(conn net.Conn, br *bufio.Reader) {
+	req, err := http.ReadRequest(br)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(r.token)) != 1 || req.Header.Get("Upgrade") != Protocol {
+		logger.AddLog("WARN", fmt.Sprintf("[Relay] Rejected a tunnel from %s: wrong token or protocol", conn.RemoteAddr()))
+		io.WriteString(conn, "HTTP/1.1 401 Unauthorized\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+		conn.Close()
+		return
+	}
+	if _, err := io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: "+Protocol+"\r\n\r\n"); err != nil {
+		conn.Close()
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	r.mu.Lock()
+	r.idle = append(r.idle, conn)
+	if len(r.idle) > maxIdle {
+		r.idle[0].Close()
+		r.idle = r.idle[1:]
+	}
+	r.mu.Unlock()
+}
+
+// take returns an idle Scooter connection that is still open, or nil.
+func (r *Relay) take() net.Conn {
+	for {
+		r.mu.Lock()
+		if len(r.idle) == 0 {
+			r.mu.Unlock()
+			return nil
+		}
+		conn := r.idle[len(r.idle)-1]
+		r.idle = r.idle[:len(r.idle)-1]
+		r.mu.Unlock()
+
+		// Scooter never writes on an idle connection, so a read that
+		// doesn't time out means it was closed
+		conn.SetReadDeadline(time.Now().Add(time.Millisecond))
+		_, err := conn.Read(make([]byte, 1))
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			conn.SetReadDeadline(time.Time{})
+			return conn
+		}
+		conn.Close()
+	}
+}
+
+// forward copies a client connection to a Scooter connection and back.
+func (r *Relay) forward(client net.Conn) {
+	defer client.Close()
+	scooter := r.take()
+	if scooter == nil {
+		const msg = "Scooter is not connected to this relay\n"
+		fmt.Fprintf(client, "HTTP/1.1 502 Bad Gateway\r\nContent-Type: text/plain\r\nContent-Length: %d\r\nConnection: close\r\n\r\n%s", len(msg), msg)
+		return
+	}
+	defer scooter.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(scooter, client)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client, scooter)
+		done <- struct{}{}
+	}()
+	<-done
+}
@@ -0,0 +1,43 @@
+// Package tunnel exposes one profile of the gateway through a relay
+// (cmd/scooter-relay) that Scooter connects out to, so it can be reached
+// from another network without opening a port.
+//
+// Scooter keeps a few idle TLS connections to the relay, each upgraded with
+// the relay token. The relay hands every client connection to one of them
+// and copies bytes both ways, so the client and the gateway speak plain HTTP
+// to each other, SSE streams included; Scooter then opens a replacement.
+// Both legs are TLS, and the gateway requires an API key on every tunneled
+// request.
+package tunnel
+
+import (
+	"bufio"
+	"net"
+	"sync"
+)
+
+// UpgradePath is the relay path Scooter's connections are upgraded on.
+const UpgradePath = "/_scooter/tunnel"
+
+// Protocol is the Upgrade header of tunnel connections.
+const Protocol = "scooter-tunnel/1"
+
+// bufferedConn is a connection whose first bytes were already read into r.
+type bufferedConn struct {
+	net.Conn
+	r       *bufio.Reader
+	once    sync.Once
+	onClose func()
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func (c *bufferedConn) Close() error {
+	err := c.Conn.Close()
+	if c.onClose != nil {
+		c.once.Do(c.onClose)
+	}
+	return err
+}
@@ -0,0 +1,120 @@
+package tunnel
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "relay-secret-0123456789"
+
+// startRelay runs a relay on a TLS listener with httptest's certificate,
+// returning its URL, the relay and a CA file trusting it.
+func startRelay(t *testing.T) (string, *Relay, string, *x509.CertPool) {
+	t.Helper()
+	certSrv := httptest.NewUnstartedServer(nil)
+	certSrv.StartTLS()
+	cert, caCert := certSrv.TLS.Certificates[0], certSrv.Certificate()
+	certSrv.Close()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}, NextProtos: []string{"http/1.1"}})
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	relay := NewRelay(testToken)
+	go relay.Serve(ln)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}), 0o600))
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+	return "https://" + ln.Addr().String(), relay, caFile, pool
+}
+
+func TestTunnel(t *testing.T) {
+	relayURL, relay, caFile, pool := startRelay(t)
+	httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}, Timeout: 5 * time.Second}
+
+	resp, err := httpClient.Get(relayURL + "/sse")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode, "no Scooter is connected yet")
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello from "+r.URL.Path)
+	})
+	client, err := NewClient(Config{RelayURL: relayURL, Token: testToken, CAFile: caFile, Connections: 2, Profile: "work"}, handler)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.Run(ctx)
+	require.Eventually(t, func() bool { return relay.Connections() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	status := client.Status()
+	assert.Equal(t, "connected", status.State)
+	assert.Equal(t, "work", status.Profile)
+	assert.NotNil(t, status.ConnectedSince)
+
+	// More requests than idle connections: each used one is replaced
+	for i := 0; i < 5; i++ {
+		resp, err := httpClient.Get(relayURL + "/profiles/work/sse")
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "hello from /profiles/work/sse", string(body))
+		httpClient.CloseIdleConnections()
+	}
+	require.Eventually(t, func() bool { return relay.Connections() == 2 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestTunnelWrongToken(t *testing.T) {
+	relayURL, relay, caFile, _ := startRelay(t)
+	client, err := NewClient(Config{RelayURL: relayURL, Token: "wrong", CAFile: caFile, Connections: 1}, http.NotFoundHandler())
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.Run(ctx)
+
+	require.Eventually(t, func() bool { return client.Status().State == "error" }, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, client.Status().LastError, "401")
+	assert.Equal(t, 0, relay.Connections())
+}
+
+func TestNewClientRequiresTLS(t *testing.T) {
+	_, err := NewClient(Config{RelayURL: "http://relay.example.com", Token: testToken}, http.NotFoundHandler())
+	assert.ErrorContains(t, err, "https")
+	_, err = NewClient(Config{RelayURL: "https://relay.example.com"}, http.NotFoundHandler())
+	assert.ErrorContains(t, err, "token")
+}
+
+func TestRelayDropsClosedConnections(t *testing.T) {
+	relayURL, relay, caFile, pool := startRelay(t)
+	client, err := NewClient(Config{RelayURL: relayURL, Token: testToken, CAFile: caFile, Connections: 1}, http.NotFoundHandler())
+	require.NoError(t, err)
+	conn, _, err := client.dial(context.Background())
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return relay.Connections() == 1 }, 5*time.Second, 10*time.Millisecond)
+	conn.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	// The closed connection isn't handed to the client
+	raw, err := tls.Dial("tcp", relayURL[len("https://"):], &tls.Config{RootCAs: pool})
+	require.NoError(t, err)
+	defer raw.Close()
+	io.WriteString(raw, "GET / HTTP/1.1\r\nHost: relay\r\n\r\n")
+	raw.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 64)
+	n, _ := io.ReadAtLeast(raw, buf, len("HTTP/1.1 502"))
+	assert.Equal(t, "HTTP/1.1 502", string(buf[:len("HTTP/1.1 502")]), string(buf[:n]))
+	assert.Equal(t, 0, relay.Connections())
+}
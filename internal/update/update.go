@@ -0,0 +1,260 @@
+// Package update implements self-update for the standalone scooter binaries.
+//
+// Each release publishes, per platform:
+//
+//	<binary>_<goos>_<goarch>[.exe]          the executable
+//	<binary>_<goos>_<goarch>[.exe].sha256   its SHA-256 checksum (sha256sum format)
+//	<binary>_<goos>_<goarch>[.exe].sig      Ed25519 signature of the executable
+//
+// The signature is checked against PublicKey, which release builds embed.
+// Builds without it refuse to update themselves.
+package update
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mcp-scooter/scooter/internal/version"
+)
+
+// PublicKey is the base64-encoded Ed25519 key used to verify release
+// signatures. Set via -ldflags; without it Update refuses to install.
+var PublicKey = ""
+
+// ErrNoPublicKey is returned by Update when the build embeds no release key.
+var ErrNoPublicKey = errors.New("this build has no release key to verify updates with; download new releases manually")
+
+// Enabled reports whether this build can verify, and so install, updates.
+func Enabled() bool {
+	return PublicKey != ""
+}
+
+// maxBinarySize guards against runaway downloads.
+const maxBinarySize = 200 << 20
+
+// The binaries releases publish, as passed to New and AssetName.
+const (
+	DaemonBinary = "scooter"
+	CLIBinary    = "scooter-cli"
+)
+
+// AssetName returns the release asset name for a binary on a platform.
+func AssetName(binary, goos, goarch string) string {
+	name := fmt.Sprintf("%s_%s_%s", binary, goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// BackupPath returns where the previous executable is kept for rollback.
+func BackupPath(exePath string) string {
+	return exePath + ".old"
+}
+
+// Updater downloads and installs new releases of a binary in place.
+type Updater struct {
+	Binary  string // DaemonBinary or CLIBinary
+	ExePath string
+	Client  *http.Client
+}
+
+// New returns an Updater for the currently running executable.
+func New(binary string) (*Updater, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return &Updater{Binary: binary, ExePath: exe, Client: http.DefaultClient}, nil
+}
+
+// Check returns the latest release and whether it is newer than the running build.
+func (u *Updater) Check(ctx context.Context) (*version.Release, bool, error) {
+	release, err := version.LatestRelease(ctx, u.Client)
+	if err != nil {
+		return nil, false, err
+	}
+	return release, version.IsNewer(release.Version(), version.Version), nil
+}
+
+// Update downloads the release binary for this platform, verifies it and
+// swaps it in place of the running executable. The previous executable is
+// kept at BackupPath for Rollback.
+func (u *Updater) Update(ctx context.Context, release *version.Release) error {
+	if !Enabled() {
+		return ErrNoPublicKey
+	}
+	name := AssetName(u.Binary, runtime.GOOS, runtime.GOARCH)
+	asset, ok := release.Asset(name)
+	if !ok {
+		return fmt.Errorf("release %s has no binary for %s/%s (expected %s)", release.TagName, runtime.GOOS, runtime.GOARCH, name)
+	}
+	sumAsset, ok := release.Asset(name + ".sha256")
+	if !ok {
+		return fmt.Errorf("release %s has no checksum for %s", release.TagName, name)
+	}
+
+	data, err := u.download(ctx, asset.DownloadURL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	sumData, err := u.download(ctx, sumAsset.DownloadURL)
+	if err != nil {
+		return fmt.Errorf("failed to download checksum: %w", err)
+	}
+	if err := VerifyChecksum(data, sumData); err != nil {
+		return err
+	}
+
+	sigAsset, ok := release.Asset(name + ".sig")
+	if !ok {
+		return fmt.Errorf("release %s has no signature for %s", release.TagName, name)
+	}
+	sig, err := u.download(ctx, sigAsset.DownloadURL)
+	if err != nil {
+		return fmt.Errorf("failed to download signature: %w", err)
+	}
+	if err := VerifySignature(data, sig, PublicKey); err != nil {
+		return err
+	}
+
+	return Apply(u.ExePath, data)
+}
+
+func (u *Updater) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "mcp-scooter/"+version.Version)
+
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBinarySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBinarySize {
+		return nil, fmt.Errorf("download exceeds %d bytes", maxBinarySize)
+	}
+	return data, nil
+}
+
+// VerifyChecksum checks data against a checksum file in sha256sum format
+// ("<hex>  <name>"); a bare hex digest is also accepted.
+func VerifyChecksum(data, checksumFile []byte) error {
+	fields := strings.Fields(string(checksumFile))
+	if len(fields) == 0 {
+		return fmt.Errorf("checksum file is empty")
+	}
+	want, err := hex.DecodeString(fields[0])
+	if err != nil {
+		return fmt.Errorf("invalid checksum: %w", err)
+	}
+	got := sha256.Sum256(data)
+	if !bytes.Equal(got[:], want) {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", fields[0], hex.EncodeToString(got[:]))
+	}
+	return nil
+}
+
+// VerifySignature checks a raw or base64-encoded Ed25519 signature of data.
+func VerifySignature(data, sig []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid update public key")
+	}
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		if err != nil {
+			return fmt.Errorf("invalid signature encoding: %w", err)
+		}
+		sig = decoded
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
+
+// Apply atomically replaces the executable at exePath with data. The new
+// binary is staged next to the target so the final rename stays on one
+// filesystem; the old binary is moved to BackupPath.
+func Apply(exePath string, data []byte) error {
+	info, err := os.Stat(exePath)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(exePath), ".scooter-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to stage update: %w", err)
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to stage update: %w", err)
+	}
+	tmp.Close()
+	if err := os.Chmod(tmpPath, info.Mode().Perm()|0111); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Renaming the running executable is allowed on Windows; overwriting it is not
+	backup := BackupPath(exePath)
+	os.Remove(backup)
+	if err := os.Rename(exePath, backup); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to back up current binary: %w", err)
+	}
+	if err := os.Rename(tmpPath, exePath); err != nil {
+		os.Rename(backup, exePath)
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to install update: %w", err)
+	}
+	return nil
+}
+
+// Rollback swaps the executable with the copy kept by the last Apply.
+// Rolling back twice returns to the updated binary.
+func Rollback(exePath string) error {
+	backup := BackupPath(exePath)
+	if _, err := os.Stat(backup); err != nil {
+		return fmt.Errorf("no previous version to roll back to")
+	}
+
+	swap := exePath + ".swap"
+	os.Remove(swap)
+	if err := os.Rename(exePath, swap); err != nil {
+		return err
+	}
+	if err := os.Rename(backup, exePath); err != nil {
+		os.Rename(swap, exePath)
+		return err
+	}
+	return os.Rename(swap, backup)
+}
@@ -0,0 +1,130 @@
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mcp-scooter/scooter/internal/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestApplyAndRollback(t *testing.T) {
+	exe := filepath.Join(t.TempDir(), "scooter")
+	require.NoError(t, os.WriteFile(exe, []byte("old"), 0755))
+
+	require.NoError(t, Apply(exe, []byte("new")))
+	data, _ := os.ReadFile(exe)
+	assert.Equal(t, "new", string(data))
+	backup, _ := os.ReadFile(BackupPath(exe))
+	assert.Equal(t, "old", string(backup))
+
+	require.NoError(t, Rollback(exe))
+	data, _ = os.ReadFile(exe)
+	assert.Equal(t, "old", string(data))
+}
+
+func TestRollback_NoBackup(t *testing.T) {
+	exe := filepath.Join(t.TempDir(), "scooter")
+	require.NoError(t, os.WriteFile(exe, []byte("bin"), 0755))
+	assert.Error(t, Rollback(exe))
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("binary")
+	sum := sha256.Sum256(data)
+	line := hex.EncodeToString(sum[:]) + "  scooter_linux_amd64\n"
+
+	assert.NoError(t, VerifyChecksum(data, []byte(line)))
+	assert.Error(t, VerifyChecksum([]byte("tampered"), []byte(line)))
+	assert.Error(t, VerifyChecksum(data, nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	key := base64.StdEncoding.EncodeToString(pub)
+	data := []byte("binary")
+	sig := ed25519.Sign(priv, data)
+
+	assert.NoError(t, VerifySignature(data, sig, key))
+	assert.NoError(t, VerifySignature(data, []byte(base64.StdEncoding.EncodeToString(sig)), key))
+	assert.Error(t, VerifySignature([]byte("tampered"), sig, key))
+}
+
+func TestUpdate_RequiresPublicKey(t *testing.T) {
+	defer func(key string) { PublicKey = key }(PublicKey)
+	PublicKey = ""
+
+	exe := filepath.Join(t.TempDir(), "scooter")
+	require.NoError(t, os.WriteFile(exe, []byte("old"), 0755))
+	u := &Updater{Binary: "scooter", ExePath: exe}
+
+	err := u.Update(context.Background(), &version.Release{TagName: "v9.9.9"})
+	assert.ErrorIs(t, err, ErrNoPublicKey)
+	data, _ := os.ReadFile(exe)
+	assert.Equal(t, "old", string(data))
+}
+
+// TestReleaseWorkflowAssets checks that the release workflow publishes the
+// assets the updaters look for, built with the release key.
+func TestReleaseWorkflowAssets(t *testing.T) {
+	data, err := os.ReadFile("../../.github/workflows/release.yml")
+	require.NoError(t, err)
+	var workflow struct {
+		Jobs struct {
+			Release struct {
+				Strategy struct {
+					Matrix struct {
+						Settings []map[string]string `yaml:"settings"`
+					} `yaml:"matrix"`
+				} `yaml:"strategy"`
+				Steps []struct {
+					Name string `yaml:"name"`
+					Run  string `yaml:"run"`
+				} `yaml:"steps"`
+			} `yaml:"release"`
+		} `yaml:"jobs"`
+	}
+	require.NoError(t, yaml.Unmarshal(data, &workflow))
+	job := workflow.Jobs.Release
+	steps := make(map[string]string)
+	for _, step := range job.Steps {
+		steps[step.Name] = step.Run
+	}
+
+	build := steps["Build Go Backend"]
+	assert.Contains(t, build, "update.PublicKey=${UPDATE_PUBLIC_KEY}")
+	upload := steps["Upload standalone binaries"]
+	binaries := regexp.MustCompile(`for BIN in ([^;]+);`).FindStringSubmatch(upload)
+	require.NotNil(t, binaries, "upload step loops over the binaries")
+	asset := regexp.MustCompile(`ASSET="([^"]+)"`).FindStringSubmatch(upload)
+	require.NotNil(t, asset, "upload step names the assets")
+
+	published := strings.Fields(binaries[1])
+	for _, binary := range []string{DaemonBinary, CLIBinary} {
+		assert.Contains(t, published, binary)
+		assert.Regexp(t, `go build -ldflags="\$\{LDFLAGS\}" .*\./cmd/`+binary+`\n`, build, "%s is built with the release key", binary)
+	}
+	require.NotEmpty(t, job.Strategy.Matrix.Settings)
+	for _, platform := range job.Strategy.Matrix.Settings {
+		for _, binary := range published {
+			name := strings.NewReplacer(
+				"${BIN}", binary,
+				"${{ matrix.settings.go_os }}", platform["go_os"],
+				"${{ matrix.settings.go_arch }}", platform["go_arch"],
+				"${{ matrix.settings.binary_ext }}", platform["binary_ext"],
+			).Replace(asset[1])
+			assert.Equal(t, AssetName(binary, platform["go_os"], platform["go_arch"]), name)
+		}
+	}
+}
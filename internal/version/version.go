@@ -0,0 +1,180 @@
+// Package version holds build metadata injected at link time and checks
+// GitHub releases for newer builds.
+//
+// Build with:
+//
+//	go build -ldflags "-X github.com/mcp-scooter/scooter/internal/version.Version=1.2.3 \
+//	  -X github.com/mcp-scooter/scooter/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/mcp-scooter/scooter/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Set via -ldflags at build time.
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+// ReleasesURL is the GitHub API endpoint for the latest published release.
+var ReleasesURL = "https://api.github.com/repos/afaraha8403/MCP-Scooter/releases/latest"
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the build metadata of the running binary.
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+// Release is the subset of a GitHub release we care about.
+type Release struct {
+	TagName string  `json:"tag_name"`
+	Name    string  `json:"name"`
+	HTMLURL string  `json:"html_url"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a downloadable file attached to a release.
+type Asset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+	Size        int64  `json:"size"`
+}
+
+// Asset returns the release asset with the given file name.
+func (r Release) Asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Version returns the release tag without the leading "v".
+func (r Release) Version() string {
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+// LatestRelease fetches the latest published release from GitHub.
+func LatestRelease(ctx context.Context, client *http.Client) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", ReleasesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "mcp-scooter/"+Version)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from release feed: %d", resp.StatusCode)
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to decode release: %w", err)
+	}
+	return &release, nil
+}
+
+// IsNewer reports whether candidate is a higher semantic version than current.
+// Development builds ("dev") are never considered outdated.
+func IsNewer(candidate, current string) bool {
+	current = strings.TrimPrefix(current, "v")
+	candidate = strings.TrimPrefix(candidate, "v")
+	if current == "dev" || current == "" || candidate == "" {
+		return false
+	}
+
+	cMain, cPre := splitPrerelease(candidate)
+	oMain, oPre := splitPrerelease(current)
+
+	cParts := parseParts(cMain)
+	oParts := parseParts(oMain)
+	for i := 0; i < 3; i++ {
+		if cParts[i] != oParts[i] {
+			return cParts[i] > oParts[i]
+		}
+	}
+
+	// Same core version: a release outranks any prerelease of it
+	switch {
+	case cPre == "" && oPre != "":
+		return true
+	case cPre != "" && oPre == "":
+		return false
+	default:
+		return comparePrerelease(cPre, oPre) > 0
+	}
+}
+
+// splitPrerelease splits v into its core version and prerelease, dropping
+// any build metadata, which doesn't count towards precedence.
+func splitPrerelease(v string) (string, string) {
+	v, _, _ = strings.Cut(v, "+")
+	main, pre, _ := strings.Cut(v, "-")
+	return main, pre
+}
+
+// comparePrerelease orders two prereleases as semver does: identifier by
+// identifier, numeric ones numerically and below alphanumeric ones, and a
+// prerelease that is a prefix of the other first.
+func comparePrerelease(a, b string) int {
+	aIDs, bIDs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		aNum, aErr := strconv.ParseUint(aIDs[i], 10, 64)
+		bNum, bErr := strconv.ParseUint(bIDs[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum != bNum {
+				return cmp.Compare(aNum, bNum)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aIDs[i], bIDs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmp.Compare(len(aIDs), len(bIDs))
+}
+
+func parseParts(v string) [3]int {
+	var parts [3]int
+	for i, s := range strings.SplitN(v, ".", 3) {
+		n, _ := strconv.Atoi(s)
+		parts[i] = n
+	}
+	return parts
+}
@@ -0,0 +1,38 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		candidate, current string
+		want               bool
+	}{
+		{"1.2.0", "1.1.9", true},
+		{"v1.2.0", "1.2.0", false},
+		{"1.10.0", "1.9.0", true},
+		{"1.0.0", "1.0.0-beta.1", true},
+		{"1.0.0-beta.2", "1.0.0-beta.1", true},
+		{"1.0.0-beta.1", "1.0.0", false},
+		{"0.9.0", "1.0.0", false},
+		{"9.9.9", "dev", false},
+		{"1.0.0-beta.10", "1.0.0-beta.9", true},
+		{"1.0.0-beta.9", "1.0.0-beta.10", false},
+		{"1.0.0-alpha.beta", "1.0.0-alpha.1", true},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", false},
+		{"1.0.0-alpha.1", "1.0.0-alpha", true},
+		{"1.0.0-alpha", "1.0.0-alpha.1", false},
+		{"1.0.0-beta", "1.0.0-alpha.beta", true},
+		{"1.0.0-rc.1", "1.0.0-beta.11", true},
+		{"1.0.0-rc.1", "1.0.0-rc.1", false},
+		{"1.0.0+build.2", "1.0.0+build.1", false},
+		{"1.0.0+build.1", "1.0.0-rc.1", true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, IsNewer(tt.candidate, tt.current), "%s vs %s", tt.candidate, tt.current)
+	}
+}